@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"log"
 	"os"
 	"path/filepath"
@@ -11,6 +12,8 @@ import (
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 	processmanager "github.com/mudler/go-processmanager"
+	"github.com/mudler/mcps/pkg/selfcheck"
+	"github.com/mudler/mcps/pkg/transport"
 )
 
 // Session represents an active Claude session
@@ -57,12 +60,38 @@ func getEnvInt(key string, defaultValue int) int {
 	return defaultValue
 }
 
+// checkCredentials reports where the Claude CLI will find its credentials
+func checkCredentials(context.Context) (string, error) {
+	if os.Getenv("CLAUDE_CREDENTIALS") != "" {
+		return "CLAUDE_CREDENTIALS", nil
+	}
+	if os.Getenv("ANTHROPIC_API_KEY") != "" {
+		return "ANTHROPIC_API_KEY", nil
+	}
+	if homeDir, err := os.UserHomeDir(); err == nil {
+		credPath := filepath.Join(homeDir, ".claude", ".credentials.json")
+		if _, err := os.Stat(credPath); err == nil {
+			return credPath, nil
+		}
+	}
+	return "", selfcheck.Warn(fmt.Errorf("no CLAUDE_CREDENTIALS, ANTHROPIC_API_KEY or ~/.claude/.credentials.json, sessions may fail to authenticate"))
+}
+
 func main() {
 	// Initialize session manager
 	sessionDir := getEnv("CLAUDE_SESSION_DIR", "/tmp/claude-sessions")
 	maxSessions := getEnvInt("CLAUDE_MAX_SESSIONS", 10)
 	workDir := getEnv("CLAUDE_WORK_DIR", "/root")
 
+	if selfcheck.Enabled() {
+		selfcheck.Exit("claude",
+			selfcheck.Command(getEnv("CLAUDE_BINARY", "claude")),
+			selfcheck.Dir("CLAUDE_SESSION_DIR", sessionDir),
+			selfcheck.Dir("CLAUDE_WORK_DIR", workDir),
+			selfcheck.Check{Name: "credentials", Run: checkCredentials},
+		)
+	}
+
 	// Ensure session directory exists
 	if err := os.MkdirAll(sessionDir, 0755); err != nil {
 		log.Fatalf("Failed to create session directory: %v", err)
@@ -149,7 +178,7 @@ func main() {
 	}, ListSessionsHandler)
 
 	// Run server
-	if err := server.Run(context.Background(), &mcp.StdioTransport{}); err != nil {
+	if err := transport.Run(context.Background(), server); err != nil {
 		log.Fatal(err)
 	}
 
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"container/list"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultCacheTTL  = 10 * time.Minute
+	defaultCacheSize = 100
+)
+
+// cacheKey identifies a search: results depend on the query, the number of
+// results requested and the time range, type and region of the search
+type cacheKey struct {
+	query      string
+	maxResults int
+	options    searchOptions
+}
+
+type cacheEntry struct {
+	key      cacheKey
+	result   string
+	storedAt time.Time
+}
+
+// searchCache is an LRU cache of search results whose entries expire after
+// ttl. A nil cache never hits, which is how caching is disabled.
+type searchCache struct {
+	mutex   sync.Mutex
+	ttl     time.Duration
+	size    int
+	entries map[cacheKey]*list.Element
+	order   *list.List // front is the most recently used
+}
+
+// newSearchCache returns a cache holding up to size results for ttl, or nil
+// when either is not positive
+func newSearchCache(size int, ttl time.Duration) *searchCache {
+	if size <= 0 || ttl <= 0 {
+		return nil
+	}
+	return &searchCache{
+		ttl:     ttl,
+		size:    size,
+		entries: make(map[cacheKey]*list.Element),
+		order:   list.New(),
+	}
+}
+
+// newCacheKey normalizes the query so that case and spacing differences
+// share an entry
+func newCacheKey(query string, maxResults int, opts searchOptions) cacheKey {
+	return cacheKey{
+		query:      strings.ToLower(strings.Join(strings.Fields(query), " ")),
+		maxResults: maxResults,
+		options:    opts,
+	}
+}
+
+// get returns the cached result for key if it has not expired
+func (c *searchCache) get(key cacheKey, now time.Time) (string, bool) {
+	if c == nil {
+		return "", false
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	element, ok := c.entries[key]
+	if !ok {
+		return "", false
+	}
+	entry := element.Value.(*cacheEntry)
+	if now.Sub(entry.storedAt) > c.ttl {
+		c.order.Remove(element)
+		delete(c.entries, key)
+		return "", false
+	}
+	c.order.MoveToFront(element)
+	return entry.result, true
+}
+
+// put stores a result, evicting the least recently used entry when full
+func (c *searchCache) put(key cacheKey, result string, now time.Time) {
+	if c == nil {
+		return
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if element, ok := c.entries[key]; ok {
+		entry := element.Value.(*cacheEntry)
+		entry.result = result
+		entry.storedAt = now
+		c.order.MoveToFront(element)
+		return
+	}
+	c.entries[key] = c.order.PushFront(&cacheEntry{key: key, result: result, storedAt: now})
+	if c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).key)
+	}
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Search cache", func() {
+	var now time.Time
+
+	BeforeEach(func() {
+		now = time.Date(2025, 1, 15, 10, 30, 0, 0, time.UTC)
+	})
+
+	web := searchOptions{kind: "web"}
+
+	It("shares entries across case and spacing differences", func() {
+		Expect(newCacheKey("  Go   Generics ", 5, web)).To(Equal(newCacheKey("go generics", 5, web)))
+	})
+
+	It("keeps apart searches with different results", func() {
+		key := newCacheKey("go generics", 5, web)
+		for _, other := range []cacheKey{
+			newCacheKey("go generics", 10, web),
+			newCacheKey("go generics", 5, searchOptions{kind: "news"}),
+			newCacheKey("go generics", 5, searchOptions{kind: "web", timeRange: "week"}),
+			newCacheKey("go generics", 5, searchOptions{kind: "web", region: "de-de"}),
+			newCacheKey("go generics", 5, searchOptions{kind: "web", region: "us-en"}),
+		} {
+			Expect(other).NotTo(Equal(key))
+		}
+	})
+
+	It("serves results per region", func() {
+		cache := newSearchCache(10, time.Minute)
+		german := newCacheKey("wetter", 5, searchOptions{kind: "web", region: "de-de"})
+		cache.put(german, "german results", now)
+
+		_, ok := cache.get(newCacheKey("wetter", 5, web), now)
+		Expect(ok).To(BeFalse())
+		_, ok = cache.get(newCacheKey("wetter", 5, searchOptions{kind: "web", region: "ch-de"}), now)
+		Expect(ok).To(BeFalse())
+		result, ok := cache.get(german, now)
+		Expect(ok).To(BeTrue())
+		Expect(result).To(Equal("german results"))
+	})
+
+	It("expires entries after the ttl", func() {
+		cache := newSearchCache(10, time.Minute)
+		key := newCacheKey("go", 5, web)
+		cache.put(key, "results", now)
+
+		_, ok := cache.get(key, now.Add(time.Minute))
+		Expect(ok).To(BeTrue())
+		_, ok = cache.get(key, now.Add(time.Minute+time.Second))
+		Expect(ok).To(BeFalse())
+	})
+
+	It("evicts the least recently used entry when full", func() {
+		cache := newSearchCache(2, time.Minute)
+		a, b, c := newCacheKey("a", 5, web), newCacheKey("b", 5, web), newCacheKey("c", 5, web)
+		cache.put(a, "a", now)
+		cache.put(b, "b", now)
+		cache.get(a, now)
+		cache.put(c, "c", now)
+
+		_, ok := cache.get(b, now)
+		Expect(ok).To(BeFalse())
+		_, ok = cache.get(a, now)
+		Expect(ok).To(BeTrue())
+		_, ok = cache.get(c, now)
+		Expect(ok).To(BeTrue())
+	})
+
+	It("is disabled by a zero size or ttl", func() {
+		Expect(newSearchCache(0, time.Minute)).To(BeNil())
+		Expect(newSearchCache(10, 0)).To(BeNil())
+
+		var cache *searchCache
+		cache.put(newCacheKey("go", 5, web), "results", now)
+		_, ok := cache.get(newCacheKey("go", 5, web), now)
+		Expect(ok).To(BeFalse())
+	})
+})
+
+var _ = Describe("Search options", func() {
+	It("normalizes regions", func() {
+		opts, err := parseSearchOptions("", "", " DE-de ")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(opts).To(Equal(searchOptions{kind: "web", region: "de-de"}))
+
+		opts, err = parseSearchOptions("", "", "wt-wt")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(opts).To(Equal(searchOptions{kind: "web"}))
+	})
+
+	It("rejects invalid values", func() {
+		_, err := parseSearchOptions("year", "", "")
+		Expect(err).To(MatchError(ContainSubstring("invalid time_range")))
+		_, err = parseSearchOptions("", "images", "")
+		Expect(err).To(MatchError(ContainSubstring("invalid type")))
+		_, err = parseSearchOptions("", "", "germany")
+		Expect(err).To(MatchError(ContainSubstring("invalid region")))
+	})
+})
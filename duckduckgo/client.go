@@ -0,0 +1,309 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html"
+)
+
+const userAgent = "Mozilla/5.0 (X11; Linux x86_64; rv:128.0) Gecko/20100101 Firefox/128.0"
+
+// searchOptions narrows a search to recent results, to news articles or to a
+// region. The zero value is a plain web search over any time and region.
+type searchOptions struct {
+	timeRange string // "", "day", "week" or "month"
+	kind      string // "web" or "news"
+	region    string // "" or a DuckDuckGo region code such as "us-en"
+}
+
+// timeRangeParams maps time_range values to the DuckDuckGo df parameter
+var timeRangeParams = map[string]string{
+	"day":   "d",
+	"week":  "w",
+	"month": "m",
+}
+
+// regionPattern is the form of DuckDuckGo region codes, country then
+// language
+var regionPattern = regexp.MustCompile(`^[a-z]{2}-[a-z]{2}$`)
+
+// parseSearchOptions validates the time_range, type and region inputs
+func parseSearchOptions(timeRange, kind, region string) (searchOptions, error) {
+	opts := searchOptions{
+		timeRange: strings.ToLower(strings.TrimSpace(timeRange)),
+		kind:      strings.ToLower(strings.TrimSpace(kind)),
+		region:    strings.ToLower(strings.TrimSpace(region)),
+	}
+	if opts.timeRange == "any" {
+		opts.timeRange = ""
+	}
+	if _, ok := timeRangeParams[opts.timeRange]; opts.timeRange != "" && !ok {
+		return searchOptions{}, fmt.Errorf("invalid time_range %q: must be day, week or month", timeRange)
+	}
+	if opts.kind == "" {
+		opts.kind = "web"
+	}
+	if opts.kind != "web" && opts.kind != "news" {
+		return searchOptions{}, fmt.Errorf("invalid type %q: must be web or news", kind)
+	}
+	// wt-wt is DuckDuckGo's "no region"
+	if opts.region == "wt-wt" {
+		opts.region = ""
+	}
+	if opts.region != "" && !regionPattern.MatchString(opts.region) {
+		return searchOptions{}, fmt.Errorf("invalid region %q: must be a DuckDuckGo region code such as us-en or de-de", region)
+	}
+	return opts, nil
+}
+
+// ddgClient queries DuckDuckGo directly, scraping the HTML results page for
+// web searches and the news endpoint for news
+type ddgClient struct {
+	maxResults int
+	options    searchOptions
+	client     *http.Client
+}
+
+func newDDGClient(maxResults int, opts searchOptions) *ddgClient {
+	return &ddgClient{
+		maxResults: maxResults,
+		options:    opts,
+		client:     &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+func (c *ddgClient) Call(ctx context.Context, input string) (string, error) {
+	if c.options.kind == "news" {
+		return c.news(ctx, input)
+	}
+	return c.web(ctx, input)
+}
+
+// statusError is DuckDuckGo answering with a status other than 200
+type statusError struct {
+	code   int
+	status string
+}
+
+func (e *statusError) Error() string {
+	return "duckduckgo: unexpected response: " + e.status
+}
+
+// errAnomaly is DuckDuckGo answering with its bot-detection challenge page
+var errAnomaly = errors.New("duckduckgo returned an anomaly challenge page")
+
+// get fetches rawURL, reporting a failed status as a statusError and
+// DuckDuckGo's challenge page as errAnomaly
+func (c *ddgClient) get(ctx context.Context, rawURL string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", userAgent)
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("duckduckgo: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 4<<20))
+	if err != nil {
+		return nil, fmt.Errorf("duckduckgo: read response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, &statusError{code: resp.StatusCode, status: resp.Status}
+	}
+	if strings.Contains(string(body), "anomaly-modal") {
+		return nil, errAnomaly
+	}
+	return body, nil
+}
+
+// web scrapes the HTML results page, which accepts the same df parameter as
+// the time filter of the regular site
+func (c *ddgClient) web(ctx context.Context, input string) (string, error) {
+	params := url.Values{}
+	params.Set("q", input)
+	if df := timeRangeParams[c.options.timeRange]; df != "" {
+		params.Set("df", df)
+	}
+	if c.options.region != "" {
+		params.Set("kl", c.options.region)
+	}
+	body, err := c.get(ctx, "https://html.duckduckgo.com/html/?"+params.Encode())
+	if err != nil {
+		return "", err
+	}
+	doc, err := html.Parse(strings.NewReader(string(body)))
+	if err != nil {
+		return "", fmt.Errorf("duckduckgo: parse results: %w", err)
+	}
+
+	var b strings.Builder
+	count := 0
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if count >= c.maxResults {
+			return
+		}
+		if n.Type == html.ElementNode && hasClass(n, "result") && !hasClass(n, "result--ad") {
+			title := findByClass(n, "result__a")
+			if title != nil {
+				fmt.Fprintf(&b, "Title: %s\n", nodeText(title))
+				fmt.Fprintf(&b, "Description: %s\n", nodeText(findByClass(n, "result__snippet")))
+				fmt.Fprintf(&b, "Link: %s\n\n", resultLink(attr(title, "href")))
+				count++
+			}
+			return
+		}
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+	walk(doc)
+
+	if count == 0 {
+		return "No good DuckDuckGo Search Results was found", nil
+	}
+	return b.String(), nil
+}
+
+var vqdPattern = regexp.MustCompile(`vqd=["']?([0-9-]+)`)
+
+type newsResponse struct {
+	Results []struct {
+		Date    int64  `json:"date"`
+		Title   string `json:"title"`
+		Excerpt string `json:"excerpt"`
+		URL     string `json:"url"`
+		Source  string `json:"source"`
+	} `json:"results"`
+}
+
+var tagPattern = regexp.MustCompile(`<[^>]*>`)
+
+// news queries the news endpoint, which needs the vqd token DuckDuckGo embeds
+// in the regular search page for the same query
+func (c *ddgClient) news(ctx context.Context, input string) (string, error) {
+	body, err := c.get(ctx, "https://duckduckgo.com/?"+url.Values{"q": {input}}.Encode())
+	if err != nil {
+		return "", err
+	}
+	match := vqdPattern.FindSubmatch(body)
+	if match == nil {
+		return "", fmt.Errorf("duckduckgo: no search token in response, news search unavailable")
+	}
+
+	params := url.Values{}
+	params.Set("q", input)
+	params.Set("vqd", string(match[1]))
+	params.Set("l", cmp.Or(c.options.region, "wt-wt"))
+	params.Set("o", "json")
+	params.Set("noamp", "1")
+	params.Set("p", "-1")
+	if df := timeRangeParams[c.options.timeRange]; df != "" {
+		params.Set("df", df)
+	}
+	body, err = c.get(ctx, "https://duckduckgo.com/news.js?"+params.Encode())
+	if err != nil {
+		return "", err
+	}
+	var news newsResponse
+	if err := json.Unmarshal(body, &news); err != nil {
+		return "", fmt.Errorf("duckduckgo: decode news: %w", err)
+	}
+	if len(news.Results) == 0 {
+		return "No good DuckDuckGo News Results was found", nil
+	}
+
+	var b strings.Builder
+	for i, r := range news.Results {
+		if i >= c.maxResults {
+			break
+		}
+		fmt.Fprintf(&b, "Title: %s\n", html.UnescapeString(r.Title))
+		fmt.Fprintf(&b, "Description: %s\n", html.UnescapeString(tagPattern.ReplaceAllString(r.Excerpt, "")))
+		if r.Date > 0 {
+			fmt.Fprintf(&b, "Published: %s\n", time.Unix(r.Date, 0).UTC().Format(time.RFC3339))
+		}
+		if r.Source != "" {
+			fmt.Fprintf(&b, "Source: %s\n", r.Source)
+		}
+		fmt.Fprintf(&b, "Link: %s\n\n", r.URL)
+	}
+	return b.String(), nil
+}
+
+// resultLink unwraps the DuckDuckGo redirect around result links
+func resultLink(href string) string {
+	if strings.HasPrefix(href, "//") {
+		href = "https:" + href
+	}
+	u, err := url.Parse(href)
+	if err != nil {
+		return href
+	}
+	if target := u.Query().Get("uddg"); target != "" {
+		return target
+	}
+	return href
+}
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+func hasClass(n *html.Node, class string) bool {
+	for _, c := range strings.Fields(attr(n, "class")) {
+		if c == class {
+			return true
+		}
+	}
+	return false
+}
+
+// findByClass returns the first element below n with the given class
+func findByClass(n *html.Node, class string) *html.Node {
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type == html.ElementNode && hasClass(child, class) {
+			return child
+		}
+		if found := findByClass(child, class); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+// nodeText returns the text content of n with whitespace collapsed
+func nodeText(n *html.Node) string {
+	if n == nil {
+		return ""
+	}
+	var b strings.Builder
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.TextNode {
+			b.WriteString(n.Data)
+		}
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+	walk(n)
+	return strings.Join(strings.Fields(b.String()), " ")
+}
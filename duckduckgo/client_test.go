@@ -0,0 +1,241 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"sync"
+
+	"github.com/mudler/mcps/pkg/mock"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// webPage is a trimmed html.duckduckgo.com results page: an ad, then
+// results linked through the DuckDuckGo redirect or directly
+const webPage = `<!DOCTYPE html>
+<html><body>
+<div id="links" class="results">
+  <div class="result results_links results_links_deep result--ad">
+    <div class="links_main links_deep result__body">
+      <h2 class="result__title"><a class="result__a" href="https://duckduckgo.com/y.js?ad_provider=bing">Learn Go Fast - Sponsored</a></h2>
+      <a class="result__snippet" href="https://duckduckgo.com/y.js?ad_provider=bing">Buy our course.</a>
+    </div>
+  </div>
+  <div class="result results_links results_links_deep web-result">
+    <div class="links_main links_deep result__body">
+      <h2 class="result__title">
+        <a rel="nofollow" class="result__a" href="//duckduckgo.com/l/?uddg=https%3A%2F%2Fgo.dev%2F&amp;rut=abc">The Go
+          Programming Language</a>
+      </h2>
+      <a class="result__snippet" href="//duckduckgo.com/l/?uddg=https%3A%2F%2Fgo.dev%2F&amp;rut=abc"><b>Go</b> is an open source programming language &amp; toolchain.</a>
+    </div>
+  </div>
+  <div class="result results_links results_links_deep web-result">
+    <div class="links_main links_deep result__body">
+      <h2 class="result__title"><a rel="nofollow" class="result__a" href="https://pkg.go.dev/">Go Packages</a></h2>
+      <a class="result__snippet" href="https://pkg.go.dev/">Search Go packages.</a>
+    </div>
+  </div>
+  <div class="result results_links results_links_deep web-result">
+    <div class="links_main links_deep result__body">
+      <h2 class="result__title"><a rel="nofollow" class="result__a" href="//duckduckgo.com/l/?uddg=https%3A%2F%2Fgo.dev%2Ftour%2F">A Tour of Go</a></h2>
+    </div>
+  </div>
+</div>
+</body></html>`
+
+// anomalyPage is the challenge DuckDuckGo serves to clients it suspects of
+// being bots
+const anomalyPage = `<html><body><div class="anomaly-modal__mask"><div class="anomaly-modal__title">Unfortunately, bots use DuckDuckGo too.</div></div></body></html>`
+
+// newsToken is the search page the news search reads its vqd token from
+const newsToken = `<html><head><script>DDG.deep.initialize('/d.js?q=golang&l=us-en&s=0&dl=en&ct=US&vqd=4-123456789012345678901234567890&p_ent=');</script></head></html>`
+
+// newsResults is a trimmed news.js response
+const newsResults = `{
+  "query": "golang",
+  "results": [
+    {"date": 1736937000, "title": "Go 1.24 &amp; beyond", "excerpt": "The <b>Go</b> team ships &quot;generic&quot; type aliases.", "url": "https://go.dev/blog/go1.24", "source": "The Go Blog", "relative_time": "2 hours ago"},
+    {"date": 0, "title": "Gophers meet up", "excerpt": "Meetup notes.", "url": "https://example.com/meetup", "source": ""}
+  ]
+}`
+
+var _ = Describe("DuckDuckGo client", func() {
+	var (
+		mutex    sync.Mutex
+		requests []*url.URL
+	)
+
+	// page answers with status and body, recording the request
+	page := func(status int, body string) mock.Handler {
+		return func(req *http.Request) (int, interface{}) {
+			mutex.Lock()
+			defer mutex.Unlock()
+			requests = append(requests, req.URL)
+			return status, body
+		}
+	}
+	// lastQuery returns the query parameters of the last request
+	lastQuery := func() url.Values {
+		mutex.Lock()
+		defer mutex.Unlock()
+		Expect(requests).NotTo(BeEmpty())
+		return requests[len(requests)-1].Query()
+	}
+
+	newClient := func(maxResults int, opts searchOptions, routes ...mock.Route) *ddgClient {
+		c := newDDGClient(maxResults, opts)
+		c.client = mock.NewClient(routes...)
+		return c
+	}
+
+	BeforeEach(func() {
+		requests = nil
+	})
+
+	Describe("web", func() {
+		It("parses results, skipping ads and unwrapping redirects", func() {
+			c := newClient(5, searchOptions{kind: "web"}, mock.Route{Pattern: "/html/", Handler: page(http.StatusOK, webPage)})
+			result, err := c.Call(context.Background(), "golang")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result).To(Equal("" +
+				"Title: The Go Programming Language\n" +
+				"Description: Go is an open source programming language & toolchain.\n" +
+				"Link: https://go.dev/\n\n" +
+				"Title: Go Packages\n" +
+				"Description: Search Go packages.\n" +
+				"Link: https://pkg.go.dev/\n\n" +
+				"Title: A Tour of Go\n" +
+				"Description: \n" +
+				"Link: https://go.dev/tour/\n\n"))
+			Expect(lastQuery().Get("q")).To(Equal("golang"))
+		})
+
+		It("stops at the maximum number of results", func() {
+			c := newClient(1, searchOptions{kind: "web"}, mock.Route{Pattern: "/html/", Handler: page(http.StatusOK, webPage)})
+			result, err := c.Call(context.Background(), "golang")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result).To(ContainSubstring("The Go Programming Language"))
+			Expect(result).NotTo(ContainSubstring("Go Packages"))
+		})
+
+		It("sends the time range and region", func() {
+			c := newClient(5, searchOptions{kind: "web", timeRange: "week", region: "de-de"}, mock.Route{Pattern: "/html/", Handler: page(http.StatusOK, webPage)})
+			_, err := c.Call(context.Background(), "golang")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(lastQuery().Get("df")).To(Equal("w"))
+			Expect(lastQuery().Get("kl")).To(Equal("de-de"))
+		})
+
+		It("finds no results in a page whose markup it does not know", func() {
+			changed := `<html><body><ol class="react-results--main">
+				<li data-layout="organic"><article><h2><a href="https://go.dev/">The Go Programming Language</a></h2></article></li>
+			</ol></body></html>`
+			c := newClient(5, searchOptions{kind: "web"}, mock.Route{Pattern: "/html/", Handler: page(http.StatusOK, changed)})
+			result, err := c.Call(context.Background(), "golang")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result).To(Equal("No good DuckDuckGo Search Results was found"))
+		})
+
+		It("skips results without a title link", func() {
+			changed := `<html><body><div class="result"><h2 class="result__title"><span>No link</span></h2></div></body></html>`
+			c := newClient(5, searchOptions{kind: "web"}, mock.Route{Pattern: "/html/", Handler: page(http.StatusOK, changed)})
+			result, err := c.Call(context.Background(), "golang")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result).To(Equal("No good DuckDuckGo Search Results was found"))
+		})
+
+		It("reports the challenge page as a rate limit", func() {
+			c := newClient(5, searchOptions{kind: "web"}, mock.Route{Pattern: "/html/", Handler: page(http.StatusOK, anomalyPage)})
+			_, err := c.Call(context.Background(), "golang")
+			Expect(err).To(MatchError(errAnomaly))
+			Expect(isRateLimited(err)).To(BeTrue())
+		})
+
+		It("reports the status of failed responses", func() {
+			c := newClient(5, searchOptions{kind: "web"}, mock.Route{Pattern: "/html/", Handler: page(http.StatusAccepted, anomalyPage)})
+			_, err := c.Call(context.Background(), "golang")
+			Expect(err).To(MatchError(ContainSubstring("202 Accepted")))
+			Expect(isRateLimited(err)).To(BeTrue())
+
+			c = newClient(5, searchOptions{kind: "web"}, mock.Route{Pattern: "/html/", Handler: page(http.StatusForbidden, "")})
+			_, err = c.Call(context.Background(), "golang")
+			Expect(err).To(MatchError(ContainSubstring("403 Forbidden")))
+			Expect(isTransient(err)).To(BeFalse())
+		})
+	})
+
+	Describe("news", func() {
+		newsRoutes := func(token, results string) []mock.Route {
+			return []mock.Route{
+				{Pattern: "/", Handler: page(http.StatusOK, token)},
+				{Pattern: "/news.js", Handler: page(http.StatusOK, results)},
+			}
+		}
+
+		It("parses articles with their date and source", func() {
+			c := newClient(5, searchOptions{kind: "news", timeRange: "day", region: "us-en"}, newsRoutes(newsToken, newsResults)...)
+			result, err := c.Call(context.Background(), "golang")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result).To(Equal("" +
+				"Title: Go 1.24 & beyond\n" +
+				`Description: The Go team ships "generic" type aliases.` + "\n" +
+				"Published: 2025-01-15T10:30:00Z\n" +
+				"Source: The Go Blog\n" +
+				"Link: https://go.dev/blog/go1.24\n\n" +
+				"Title: Gophers meet up\n" +
+				"Description: Meetup notes.\n" +
+				"Link: https://example.com/meetup\n\n"))
+
+			query := lastQuery()
+			Expect(query.Get("q")).To(Equal("golang"))
+			Expect(query.Get("vqd")).To(Equal("4-123456789012345678901234567890"))
+			Expect(query.Get("l")).To(Equal("us-en"))
+			Expect(query.Get("df")).To(Equal("d"))
+		})
+
+		It("searches every region when none is set", func() {
+			c := newClient(5, searchOptions{kind: "news"}, newsRoutes(`<script>vqd="4-42"</script>`, newsResults)...)
+			_, err := c.Call(context.Background(), "golang")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(lastQuery().Get("vqd")).To(Equal("4-42"))
+			Expect(lastQuery().Get("l")).To(Equal("wt-wt"))
+			Expect(lastQuery().Has("df")).To(BeFalse())
+		})
+
+		It("stops at the maximum number of results", func() {
+			c := newClient(1, searchOptions{kind: "news"}, newsRoutes(newsToken, newsResults)...)
+			result, err := c.Call(context.Background(), "golang")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result).To(ContainSubstring("Go 1.24"))
+			Expect(result).NotTo(ContainSubstring("Gophers"))
+		})
+
+		It("reports no results", func() {
+			c := newClient(5, searchOptions{kind: "news"}, newsRoutes(newsToken, `{"results": []}`)...)
+			result, err := c.Call(context.Background(), "golang")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result).To(Equal("No good DuckDuckGo News Results was found"))
+		})
+
+		It("fails when the search page no longer carries a token", func() {
+			c := newClient(5, searchOptions{kind: "news"}, newsRoutes(`<html><body>Search</body></html>`, newsResults)...)
+			_, err := c.Call(context.Background(), "golang")
+			Expect(err).To(MatchError(ContainSubstring("no search token")))
+			Expect(isTransient(err)).To(BeFalse())
+		})
+
+		It("fails when the news response is not JSON", func() {
+			c := newClient(5, searchOptions{kind: "news"}, newsRoutes(newsToken, `<html>Not found</html>`)...)
+			_, err := c.Call(context.Background(), "golang")
+			Expect(err).To(MatchError(ContainSubstring("decode news")))
+		})
+
+		It("reports the challenge page as a rate limit", func() {
+			c := newClient(5, searchOptions{kind: "news"}, newsRoutes(anomalyPage, newsResults)...)
+			_, err := c.Call(context.Background(), "golang")
+			Expect(err).To(MatchError(errAnomaly))
+		})
+	})
+})
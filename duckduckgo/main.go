@@ -1,31 +1,110 @@
 package main
 
 import (
+	"cmp"
 	"context"
+	"errors"
+	"fmt"
 	"log"
+	"net/http"
 	"os"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
-	"github.com/tmc/langchaingo/tools/duckduckgo"
+	"github.com/mudler/mcps/pkg/mock"
+	"github.com/mudler/mcps/pkg/selfcheck"
+	"github.com/mudler/mcps/pkg/transport"
 )
 
 type Input struct {
-	Query string `json:"query" jsonschema:"the query to search for"`
+	Query     string `json:"query" jsonschema:"the query to search for"`
+	TimeRange string `json:"time_range,omitempty" jsonschema:"only return results from the last day, week or month (default: any time)"`
+	Type      string `json:"type,omitempty" jsonschema:"web or news, news returns recent articles with their publication date (default: web)"`
+	Region    string `json:"region,omitempty" jsonschema:"DuckDuckGo region code of the results, country then language, e.g. us-en, uk-en or de-de; wt-wt for no region (default: DUCKDUCKGO_REGION, or no region)"`
 }
 
 type Output struct {
-	Result string `json:"result" jsonschema:"the result of the search"`
+	Result   string `json:"result" jsonschema:"the result of the search"`
+	Cached   bool   `json:"cached,omitempty" jsonschema:"whether the result was served from the cache"`
+	Fallback bool   `json:"fallback,omitempty" jsonschema:"whether the result came from the SearXNG fallback because DuckDuckGo failed"`
 }
 
 var maxResults = 5
 
+// cache holds recent results, nil when DUCKDUCKGO_CACHE_TTL or
+// DUCKDUCKGO_CACHE_SIZE is 0
+var cache *searchCache
+
+// retries and retryBackoff control how failed DuckDuckGo searches are retried
+var (
+	retries      = defaultRetries
+	retryBackoff = defaultRetryBackoff
+)
+
+// defaultRegion is the region of searches that do not set one, from
+// DUCKDUCKGO_REGION
+var defaultRegion string
+
+// fallbackURL is the SearXNG instance queried when DuckDuckGo still fails
+// after retrying, empty unless DUCKDUCKGO_FALLBACK_SEARXNG_URL is set
+var fallbackURL string
+
 func init() {
 	var err error
 	maxResults, err = strconv.Atoi(os.Getenv("MAX_RESULTS"))
 	if err != nil {
 		maxResults = 5
 	}
+
+	ttl := defaultCacheTTL
+	if value := os.Getenv("DUCKDUCKGO_CACHE_TTL"); value != "" {
+		if ttl, err = time.ParseDuration(value); err != nil {
+			log.Printf("Warning: invalid DUCKDUCKGO_CACHE_TTL %q, using %s", value, defaultCacheTTL)
+			ttl = defaultCacheTTL
+		}
+	}
+	size := defaultCacheSize
+	if value := os.Getenv("DUCKDUCKGO_CACHE_SIZE"); value != "" {
+		if size, err = strconv.Atoi(value); err != nil {
+			log.Printf("Warning: invalid DUCKDUCKGO_CACHE_SIZE %q, using %d", value, defaultCacheSize)
+			size = defaultCacheSize
+		}
+	}
+	cache = newSearchCache(size, ttl)
+
+	if value := os.Getenv("DUCKDUCKGO_RETRIES"); value != "" {
+		if retries, err = strconv.Atoi(value); err != nil || retries < 0 {
+			log.Printf("Warning: invalid DUCKDUCKGO_RETRIES %q, using %d", value, defaultRetries)
+			retries = defaultRetries
+		}
+	}
+	if value := os.Getenv("DUCKDUCKGO_RETRY_BACKOFF"); value != "" {
+		if retryBackoff, err = time.ParseDuration(value); err != nil || retryBackoff < 0 {
+			log.Printf("Warning: invalid DUCKDUCKGO_RETRY_BACKOFF %q, using %s", value, defaultRetryBackoff)
+			retryBackoff = defaultRetryBackoff
+		}
+	}
+	fallbackURL = os.Getenv("DUCKDUCKGO_FALLBACK_SEARXNG_URL")
+
+	if value := os.Getenv("DUCKDUCKGO_REGION"); value != "" {
+		if opts, err := parseSearchOptions("", "", value); err != nil {
+			log.Printf("Warning: %v, searching without a region", err)
+		} else {
+			defaultRegion = opts.region
+		}
+	}
+}
+
+// searcher runs a search: ddgClient, or mockSearcher with DUCKDUCKGO_MOCK
+type searcher interface {
+	Call(ctx context.Context, input string) (string, error)
+}
+
+// newSearcher creates the client used for each search
+var newSearcher = func(opts searchOptions) (searcher, error) {
+	return newDDGClient(maxResults, opts), nil
 }
 
 func Search(ctx context.Context, req *mcp.CallToolRequest, input Input) (
@@ -33,23 +112,72 @@ func Search(ctx context.Context, req *mcp.CallToolRequest, input Input) (
 	Output,
 	error,
 ) {
-	ddg, err := duckduckgo.New(maxResults, "MCP")
+	opts, err := parseSearchOptions(input.TimeRange, input.Type, cmp.Or(input.Region, defaultRegion))
 	if err != nil {
-		return nil, Output{Result: "Error searching the web"}, err
+		return nil, Output{}, err
+	}
+	key := newCacheKey(input.Query, maxResults, opts)
+	if result, ok := cache.get(key, time.Now()); ok {
+		return nil, Output{Result: result, Cached: true}, nil
 	}
-	result, err := ddg.Call(context.Background(), input.Query)
+
+	ddg, err := newSearcher(opts)
 	if err != nil {
 		return nil, Output{Result: "Error searching the web"}, err
 	}
+	result, err := searchWithRetry(ctx, ddg, input.Query, retries, retryBackoff)
+	if err == nil {
+		cache.put(key, result, time.Now())
+		return nil, Output{Result: result}, nil
+	}
+	if fallbackURL != "" && ctx.Err() == nil {
+		log.Printf("DuckDuckGo search failed, falling back to SearXNG: %v", err)
+		fallbackResult, fallbackErr := newSearxngSearcher(fallbackURL, maxResults, opts).Call(ctx, input.Query)
+		if fallbackErr == nil {
+			cache.put(key, fallbackResult, time.Now())
+			return nil, Output{Result: fallbackResult, Fallback: true}, nil
+		}
+		err = fmt.Errorf("%w; fallback failed: %v", err, fallbackErr)
+	}
+	if errors.Is(err, errRateLimited) {
+		return nil, Output{Result: "DuckDuckGo is rate-limiting searches, try again later"}, err
+	}
+	return nil, Output{Result: "Error searching the web"}, err
+}
 
-	return nil, Output{Result: result}, nil
+// selfChecks probes DuckDuckGo and the SearXNG fallback, if configured
+func selfChecks(mocked bool) []selfcheck.Check {
+	if mocked {
+		return []selfcheck.Check{selfcheck.Skipped("api", "DUCKDUCKGO_MOCK enabled")}
+	}
+	client := &http.Client{Timeout: 30 * time.Second}
+	checks := []selfcheck.Check{
+		selfcheck.HTTP("duckduckgo", client, "https://html.duckduckgo.com/html/", nil),
+	}
+	if fallbackURL != "" {
+		// The fallback only matters once DuckDuckGo fails
+		checks = append(checks, selfcheck.Optional(selfcheck.HTTP("searxng", client, strings.TrimRight(fallbackURL, "/")+"/search?q=test&format=json", nil)))
+	}
+	return checks
 }
 
 func main() {
+	mocked := mock.Enabled("DUCKDUCKGO")
+	if selfcheck.Enabled() {
+		selfcheck.Exit("duckduckgo", selfChecks(mocked)...)
+	}
+	if mocked {
+		// Return canned results instead of querying DuckDuckGo
+		newSearcher = func(opts searchOptions) (searcher, error) {
+			return mockSearcher{maxResults: maxResults, options: opts}, nil
+		}
+		log.Println("DUCKDUCKGO_MOCK enabled: using canned search results")
+	}
+
 	// Create a server with a single tool.
 	server := mcp.NewServer(&mcp.Implementation{Name: "duckduckgo", Version: "v1.0.0"}, nil)
-	mcp.AddTool(server, &mcp.Tool{Name: "search", Description: "search the web"}, Search)
-	if err := server.Run(context.Background(), &mcp.StdioTransport{}); err != nil {
+	mcp.AddTool(server, &mcp.Tool{Name: "search", Description: "search the web, optionally restricted to news, to results from the last day, week or month, or to a region"}, Search)
+	if err := transport.Run(context.Background(), server); err != nil {
 		log.Fatal(err)
 	}
 }
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// mockSearcher returns deterministic results built from the query, used when
+// DUCKDUCKGO_MOCK is enabled
+type mockSearcher struct {
+	maxResults int
+	options    searchOptions
+}
+
+func (m mockSearcher) Call(ctx context.Context, input string) (string, error) {
+	query := strings.TrimSpace(input)
+	slug := strings.ToLower(strings.Join(strings.Fields(query), "-"))
+
+	kind := "result"
+	if m.options.kind == "news" {
+		kind = "news article"
+	}
+	if m.options.timeRange != "" {
+		kind += " from the last " + m.options.timeRange
+	}
+	if m.options.region != "" {
+		kind += " in " + m.options.region
+	}
+
+	var b strings.Builder
+	for i := 1; i <= m.maxResults; i++ {
+		fmt.Fprintf(&b, "Title: %s - mock %s %d\n", query, kind, i)
+		fmt.Fprintf(&b, "Description: Canned search result %d for %q.\n", i, query)
+		fmt.Fprintf(&b, "Link: https://example.com/%s/%d\n\n", slug, i)
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"time"
+)
+
+const (
+	defaultRetries      = 2
+	defaultRetryBackoff = time.Second
+)
+
+// errRateLimited is returned when DuckDuckGo answers with its rate-limit or
+// bot-detection page instead of results
+var errRateLimited = errors.New("DuckDuckGo is rate-limiting or blocking searches from this server; wait a few minutes before searching again, or set DUCKDUCKGO_FALLBACK_SEARXNG_URL to use a SearXNG instance when this happens")
+
+// isRateLimited reports whether err is DuckDuckGo refusing to serve results:
+// a 429, a 202 (its rate-limit answer on the HTML endpoint) or the anomaly
+// challenge page
+func isRateLimited(err error) bool {
+	var status *statusError
+	if errors.As(err, &status) {
+		return status.code == http.StatusTooManyRequests || status.code == http.StatusAccepted
+	}
+	return errors.Is(err, errAnomaly)
+}
+
+// isTransient reports whether a search failing with err may succeed when
+// tried again: DuckDuckGo rate-limiting, a 5xx, or a timeout. Other failures,
+// such as a 403 or an unparseable response, would fail the same way.
+func isTransient(err error) bool {
+	if isRateLimited(err) {
+		return true
+	}
+	var status *statusError
+	if errors.As(err, &status) {
+		return status.code >= 500
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// searchWithRetry calls s until it succeeds or retries extra attempts have
+// failed, doubling the wait between attempts starting from backoff. It stops
+// early when ctx is done or the failure is not transient. A final rate-limit failure is reported as
+// errRateLimited.
+func searchWithRetry(ctx context.Context, s searcher, query string, retries int, backoff time.Duration) (string, error) {
+	var err error
+	for attempt := 0; ; attempt++ {
+		var result string
+		result, err = s.Call(ctx, query)
+		if err == nil {
+			return result, nil
+		}
+		if ctx.Err() != nil || attempt >= retries || !isTransient(err) {
+			break
+		}
+		log.Printf("Search attempt %d/%d failed: %v; retrying in %s", attempt+1, retries+1, err, backoff)
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+	if isRateLimited(err) {
+		return "", fmt.Errorf("%w (%v)", errRateLimited, err)
+	}
+	return "", err
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// failingSearcher fails with errs in turn, then succeeds
+type failingSearcher struct {
+	errs  []error
+	calls int
+}
+
+func (s *failingSearcher) Call(ctx context.Context, input string) (string, error) {
+	s.calls++
+	if s.calls <= len(s.errs) {
+		return "", s.errs[s.calls-1]
+	}
+	return "results", nil
+}
+
+func status(code int) error {
+	return &statusError{code: code, status: http.StatusText(code)}
+}
+
+var _ = Describe("Retries", func() {
+	DescribeTable("retries transient failures",
+		func(err error) {
+			s := &failingSearcher{errs: []error{err, err}}
+			result, searchErr := searchWithRetry(context.Background(), s, "go", 2, time.Millisecond)
+			Expect(searchErr).NotTo(HaveOccurred())
+			Expect(result).To(Equal("results"))
+			Expect(s.calls).To(Equal(3))
+		},
+		Entry("429", status(http.StatusTooManyRequests)),
+		Entry("202", status(http.StatusAccepted)),
+		Entry("500", status(http.StatusInternalServerError)),
+		Entry("503", status(http.StatusServiceUnavailable)),
+		Entry("anomaly page", errAnomaly),
+	)
+
+	DescribeTable("does not retry other failures",
+		func(err error) {
+			s := &failingSearcher{errs: []error{err}}
+			_, searchErr := searchWithRetry(context.Background(), s, "go", 2, time.Millisecond)
+			Expect(searchErr).To(MatchError(err))
+			Expect(errors.Is(searchErr, errRateLimited)).To(BeFalse())
+			Expect(s.calls).To(Equal(1))
+		},
+		Entry("403", status(http.StatusForbidden)),
+		Entry("404", status(http.StatusNotFound)),
+		Entry("400", status(http.StatusBadRequest)),
+		Entry("unparseable response", errors.New("duckduckgo: decode news: unexpected end of JSON input")),
+	)
+
+	It("reports a lasting rate limit as errRateLimited", func() {
+		err := status(http.StatusAccepted)
+		s := &failingSearcher{errs: []error{err, err, err}}
+		_, searchErr := searchWithRetry(context.Background(), s, "go", 2, time.Millisecond)
+		Expect(searchErr).To(MatchError(errRateLimited))
+		Expect(s.calls).To(Equal(3))
+	})
+
+	It("reports a lasting 5xx as it is", func() {
+		err := status(http.StatusBadGateway)
+		s := &failingSearcher{errs: []error{err, err, err}}
+		_, searchErr := searchWithRetry(context.Background(), s, "go", 2, time.Millisecond)
+		Expect(searchErr).To(MatchError(err))
+		Expect(errors.Is(searchErr, errRateLimited)).To(BeFalse())
+	})
+
+	It("stops waiting when the context is done", func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(10*time.Millisecond, cancel)
+		s := &failingSearcher{errs: []error{status(http.StatusTooManyRequests)}}
+		_, err := searchWithRetry(ctx, s, "go", 2, time.Hour)
+		Expect(err).To(MatchError(context.Canceled))
+		Expect(s.calls).To(Equal(1))
+	})
+})
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// searxngSearcher queries a SearXNG instance, used as a fallback when
+// DuckDuckGo fails. The instance must have the json format enabled in its
+// search.formats setting.
+type searxngSearcher struct {
+	baseURL    string
+	maxResults int
+	options    searchOptions
+	client     *http.Client
+}
+
+func newSearxngSearcher(baseURL string, maxResults int, opts searchOptions) *searxngSearcher {
+	return &searxngSearcher{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		maxResults: maxResults,
+		options:    opts,
+		client:     &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+type searxngResponse struct {
+	Results []struct {
+		Title   string `json:"title"`
+		URL     string `json:"url"`
+		Content string `json:"content"`
+	} `json:"results"`
+}
+
+// Call returns the results in the same format as the DuckDuckGo tool
+func (s *searxngSearcher) Call(ctx context.Context, input string) (string, error) {
+	params := url.Values{}
+	params.Set("q", input)
+	params.Set("format", "json")
+	if s.options.timeRange != "" {
+		params.Set("time_range", s.options.timeRange)
+	}
+	if s.options.kind == "news" {
+		params.Set("categories", "news")
+	}
+	if country, language, ok := strings.Cut(s.options.region, "-"); ok {
+		// SearXNG takes the language first, e.g. en-US for us-en
+		params.Set("language", language+"-"+strings.ToUpper(country))
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.baseURL+"/search?"+params.Encode(), nil)
+	if err != nil {
+		return "", fmt.Errorf("searxng: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("searxng: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusForbidden {
+		return "", fmt.Errorf("searxng: %s (is the json format enabled on the instance?)", resp.Status)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("searxng: %s", resp.Status)
+	}
+
+	var body searxngResponse
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", fmt.Errorf("searxng: decode response: %w", err)
+	}
+	if len(body.Results) == 0 {
+		return "No good SearXNG Search Results was found", nil
+	}
+
+	var b strings.Builder
+	for i, r := range body.Results {
+		if i >= s.maxResults {
+			break
+		}
+		fmt.Fprintf(&b, "Title: %s\n", r.Title)
+		fmt.Fprintf(&b, "Description: %s\n", strings.TrimSpace(r.Content))
+		fmt.Fprintf(&b, "Link: %s\n\n", r.URL)
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"context"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/mudler/mcps/pkg/batch"
+)
+
+// batchHandlers returns the tools a batch may call, the trash tools only
+// when a trash is configured
+func batchHandlers() batch.Handlers {
+	handlers := batch.Handlers{
+		"read":             batch.Tool(readFile),
+		"head":             batch.Tool(headFile),
+		"wc":               batch.Tool(wcFiles),
+		"write":            batch.Tool(writeFile),
+		"write_template":   batch.Tool(writeTemplate),
+		"edit":             batch.Tool(editFile),
+		"replace_in_files": batch.Tool(replaceInFiles),
+		"symlink":          batch.Tool(createSymlink),
+		"readlink":         batch.Tool(readSymlink),
+		"copy":             batch.Tool(copyPath),
+		"delete":           batch.Tool(deletePath),
+		"glob":             batch.Tool(globFiles),
+		"grep":             batch.Tool(grepFiles),
+	}
+	if trashDir != "" {
+		handlers["restore"] = batch.Tool(restoreFromTrash)
+		handlers["empty_trash"] = batch.Tool(emptyTrash)
+	}
+	return handlers
+}
+
+// newBatchHandler returns a handler running operations with handlers. Unlike
+// the file-backed servers there is no transaction: each operation changes
+// the filesystem as it runs.
+func newBatchHandler(handlers batch.Handlers) func(context.Context, *mcp.CallToolRequest, batch.Input) (*mcp.CallToolResult, batch.Output, error) {
+	return func(ctx context.Context, req *mcp.CallToolRequest, input batch.Input) (*mcp.CallToolResult, batch.Output, error) {
+		output, err := handlers.Run(ctx, req, input)
+		if err != nil {
+			return nil, batch.Output{}, err
+		}
+		return nil, output, nil
+	}
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"log"
+	"os"
+	"strconv"
+	"unicode/utf8"
+)
+
+// binarySniffBytes is how much of a file is looked at to tell whether it is
+// binary
+const binarySniffBytes = 512
+
+// defaultMaxFileBytes is the largest file read loads by default
+const defaultMaxFileBytes = 10 << 20
+
+// maxFileBytes caps the size of the files read loads, set with
+// FILESYSTEM_MAX_FILE_BYTES
+var maxFileBytes int64 = defaultMaxFileBytes
+
+// maxFileBytesFromEnv reads FILESYSTEM_MAX_FILE_BYTES, defaulting to
+// defaultMaxFileBytes
+func maxFileBytesFromEnv() int64 {
+	value := os.Getenv("FILESYSTEM_MAX_FILE_BYTES")
+	if value == "" {
+		return defaultMaxFileBytes
+	}
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || n <= 0 {
+		log.Printf("Warning: invalid FILESYSTEM_MAX_FILE_BYTES %q, using %d", value, defaultMaxFileBytes)
+		return defaultMaxFileBytes
+	}
+	return n
+}
+
+// isBinary reports whether the start of a file looks binary: it holds a NUL
+// byte or is not valid UTF-8. A rune cut at the end of head is not counted.
+func isBinary(head []byte) bool {
+	if bytes.IndexByte(head, 0) >= 0 {
+		return true
+	}
+	for i := len(head) - 1; i >= 0 && i >= len(head)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(head[i]) {
+			if !utf8.FullRune(head[i:]) {
+				head = head[:i]
+			}
+			break
+		}
+	}
+	return !utf8.Valid(head)
+}
+
+// sniffBinary wraps file in a reader and reports whether the file is binary,
+// without consuming what it looked at
+func sniffBinary(file *os.File) (*bufio.Reader, bool) {
+	reader := bufio.NewReader(file)
+	head, _ := reader.Peek(binarySniffBytes)
+	return reader, isBinary(head)
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Binary files", func() {
+	DescribeTable("are told from text by their first bytes",
+		func(head []byte, want bool) {
+			Expect(isBinary(head)).To(Equal(want))
+		},
+		Entry("text", []byte("hello\nworld\n"), false),
+		Entry("utf8", []byte("héllo wörld ✓\n"), false),
+		Entry("cut rune", []byte("✓")[:2], false),
+		Entry("nul", []byte("hello\x00world"), true),
+		Entry("invalid utf8", []byte("hello \xff\xfe world"), true),
+		Entry("empty", nil, false),
+	)
+
+	It("are skipped by searches and only read with force", func() {
+		root := GinkgoT().TempDir()
+		text := filepath.Join(root, "a.txt")
+		Expect(os.WriteFile(text, []byte("needle\n"), 0644)).To(Succeed())
+		blob := filepath.Join(root, "b.bin")
+		Expect(os.WriteFile(blob, []byte("\x7fELF\x00\x00needle\n"), 0644)).To(Succeed())
+
+		_, out, err := grepFiles(context.Background(), nil, grepFilesInput{Pat: "needle", Path: root})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(out.Success).To(BeTrue(), out.Error)
+		Expect(out.Count).To(Equal(1))
+		Expect(out.Matches[0]).To(HavePrefix(text))
+		_, counted, err := grepFiles(context.Background(), nil, grepFilesInput{Pat: "needle", Path: root, CountOnly: true})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(counted.Count).To(Equal(1))
+
+		_, read, err := readFile(context.Background(), nil, readFileInput{Path: blob})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(read.Success).To(BeFalse())
+		Expect(read.Error).To(ContainSubstring("binary file"))
+		_, read, err = readFile(context.Background(), nil, readFileInput{Path: blob, Force: true})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(read.Success).To(BeTrue(), read.Error)
+		Expect(read.Content).To(ContainSubstring("needle"))
+	})
+})
+
+var _ = Describe("FILESYSTEM_MAX_FILE_BYTES", func() {
+	It("refuses to read larger files", func() {
+		path := filepath.Join(GinkgoT().TempDir(), "big.txt")
+		Expect(os.WriteFile(path, []byte(strings.Repeat("line\n", 100)), 0644)).To(Succeed())
+		DeferCleanup(func(limit int64) { maxFileBytes = limit }, maxFileBytes)
+		maxFileBytes = 100
+
+		_, out, err := readFile(context.Background(), nil, readFileInput{Path: path, Force: true})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(out.Success).To(BeFalse())
+		Expect(out.Error).To(ContainSubstring("FILESYSTEM_MAX_FILE_BYTES"))
+
+		maxFileBytes = 500
+		_, out, err = readFile(context.Background(), nil, readFileInput{Path: path})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(out.Success).To(BeTrue(), out.Error)
+		Expect(out.TotalLines).To(Equal(100))
+	})
+})
@@ -0,0 +1,277 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// Input type for copy operation
+type copyInput struct {
+	Src       string `json:"src" jsonschema:"the file or directory to copy"`
+	Dst       string `json:"dst" jsonschema:"the path to copy to, directories are copied recursively to this path"`
+	Overwrite bool   `json:"overwrite,omitempty" jsonschema:"optional replace files that already exist at the destination (default: false)"`
+}
+
+// Output type for copy operation
+type copyOutput struct {
+	Files   int    `json:"files" jsonschema:"number of files copied, symbolic links included"`
+	Success bool   `json:"success" jsonschema:"whether operation was successful"`
+	Error   string `json:"error,omitempty" jsonschema:"error message if failed"`
+}
+
+// copyEntry is a path of the source tree and where it is copied to
+type copyEntry struct {
+	src, dst string
+	mode     fs.FileMode
+}
+
+// copyPath copies a file, or a directory tree, preserving file modes
+func copyPath(ctx context.Context, req *mcp.CallToolRequest, input copyInput) (
+	*mcp.CallToolResult,
+	copyOutput,
+	error,
+) {
+	if input.Src == "" || input.Dst == "" {
+		return nil, copyOutput{
+			Success: false,
+			Error:   "src and dst are required",
+		}, nil
+	}
+
+	src, err := paths.Resolve(input.Src)
+	if err != nil {
+		return nil, copyOutput{
+			Success: false,
+			Error:   err.Error(),
+		}, nil
+	}
+	dst, err := paths.Resolve(input.Dst)
+	if err != nil {
+		return nil, copyOutput{
+			Success: false,
+			Error:   err.Error(),
+		}, nil
+	}
+
+	entries, err := copyPlan(src, dst)
+	if err != nil {
+		return nil, copyOutput{
+			Success: false,
+			Error:   err.Error(),
+		}, nil
+	}
+
+	// Look for conflicts first so a refused copy leaves nothing behind
+	if !input.Overwrite {
+		for _, entry := range entries {
+			if entry.mode.IsDir() {
+				continue
+			}
+			if _, err := os.Lstat(entry.dst); err == nil {
+				return nil, copyOutput{
+					Success: false,
+					Error:   fmt.Sprintf("%s already exists, use overwrite=true to replace it", entry.dst),
+				}, nil
+			}
+		}
+	}
+
+	// Create parent directories if needed
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return nil, copyOutput{
+			Success: false,
+			Error:   err.Error(),
+		}, nil
+	}
+
+	files := 0
+	for _, entry := range entries {
+		if err := ctx.Err(); err != nil {
+			return nil, copyOutput{
+				Files:   files,
+				Success: false,
+				Error:   err.Error(),
+			}, nil
+		}
+		if err := copyEntryTo(entry); err != nil {
+			return nil, copyOutput{
+				Files:   files,
+				Success: false,
+				Error:   err.Error(),
+			}, nil
+		}
+		if !entry.mode.IsDir() {
+			files++
+		}
+	}
+
+	return nil, copyOutput{
+		Files:   files,
+		Success: true,
+	}, nil
+}
+
+// copyPlan lists what copying src to dst creates, directories before their
+// contents. Symlinks inside a directory are copied as links, and refused when
+// they lead outside the root where they are or where they are copied to;
+// other special files are skipped.
+func copyPlan(src, dst string) ([]copyEntry, error) {
+	info, err := os.Stat(src)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		if !info.Mode().IsRegular() {
+			return nil, fmt.Errorf("%s is not a regular file", src)
+		}
+		if sameFile(src, dst) {
+			return nil, fmt.Errorf("%s and %s are the same file", src, dst)
+		}
+		return []copyEntry{{src: src, dst: dst, mode: info.Mode()}}, nil
+	}
+
+	// Copying a directory into itself would never end
+	realSrc, err := filepath.EvalSymlinks(src)
+	if err != nil {
+		return nil, err
+	}
+	if contains(realSrc, realDst(dst)) {
+		return nil, fmt.Errorf("cannot copy %s into itself", src)
+	}
+
+	var entries []copyEntry
+	err = filepath.WalkDir(src, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+
+		if d.Type()&os.ModeSymlink != 0 {
+			if outsideRoot(path) || linkOutsideRoot(path, target) {
+				return fmt.Errorf("%s: symbolic link leads outside the root", path)
+			}
+			entries = append(entries, copyEntry{src: path, dst: target, mode: os.ModeSymlink})
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() && !info.Mode().IsRegular() {
+			return nil
+		}
+		entries = append(entries, copyEntry{src: path, dst: target, mode: info.Mode()})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// linkOutsideRoot reports whether the symlink at src, recreated at dst with
+// the same target, would lead outside the root. A relative target is
+// resolved from the directory of dst, which can be shallower than src.
+func linkOutsideRoot(src, dst string) bool {
+	if paths.Root == "" {
+		return false
+	}
+	target, err := os.Readlink(src)
+	if err != nil {
+		return true
+	}
+	if !filepath.IsAbs(target) {
+		target = filepath.Join(realDst(filepath.Dir(dst)), target)
+	}
+	return outsideRoot(target)
+}
+
+// realDst resolves the symlinks of the closest existing parent of dst
+func realDst(dst string) string {
+	var missing []string
+	for dir := dst; ; dir = filepath.Dir(dir) {
+		if real, err := filepath.EvalSymlinks(dir); err == nil {
+			return filepath.Join(append([]string{real}, missing...)...)
+		}
+		if filepath.Dir(dir) == dir {
+			return dst
+		}
+		missing = append([]string{filepath.Base(dir)}, missing...)
+	}
+}
+
+// sameFile reports whether a and b are the same existing file
+func sameFile(a, b string) bool {
+	aInfo, err := os.Stat(a)
+	if err != nil {
+		return false
+	}
+	bInfo, err := os.Stat(b)
+	if err != nil {
+		return false
+	}
+	return os.SameFile(aInfo, bInfo)
+}
+
+// copyEntryTo creates a directory, copies a symlink or streams a file to its
+// destination, replacing a file or link already there
+func copyEntryTo(entry copyEntry) error {
+	switch {
+	case entry.mode.IsDir():
+		if err := os.MkdirAll(entry.dst, entry.mode.Perm()); err != nil {
+			return err
+		}
+		return os.Chmod(entry.dst, entry.mode.Perm())
+	case entry.mode&os.ModeSymlink != 0:
+		target, err := os.Readlink(entry.src)
+		if err != nil {
+			return err
+		}
+		if err := os.Remove(entry.dst); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return os.Symlink(target, entry.dst)
+	default:
+		return copyFile(entry.src, entry.dst, entry.mode.Perm())
+	}
+}
+
+// copyFile streams src to dst, which gets mode
+func copyFile(src, dst string, mode fs.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	// A link at dst is replaced rather than written through
+	if info, err := os.Lstat(dst); err == nil && info.Mode()&os.ModeSymlink != 0 {
+		if err := os.Remove(dst); err != nil {
+			return err
+		}
+	}
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return fmt.Errorf("%s: %w", dst, err)
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+	// The mode given to OpenFile is reduced by the umask, and ignored when
+	// the file already existed
+	return os.Chmod(dst, mode)
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+
+	"github.com/mudler/mcps/pkg/safepath"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("copy", func() {
+	var root string
+
+	BeforeEach(func() {
+		root = GinkgoT().TempDir()
+	})
+
+	It("copies a file with its mode", func() {
+		src := filepath.Join(root, "run.sh")
+		Expect(os.WriteFile(src, []byte("#!/bin/sh\necho hi\n"), 0755)).To(Succeed())
+		dst := filepath.Join(root, "out", "run.sh")
+
+		_, out, err := copyPath(context.Background(), nil, copyInput{Src: src, Dst: dst})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(out.Success).To(BeTrue(), out.Error)
+		Expect(out.Files).To(Equal(1))
+		Expect(os.ReadFile(dst)).To(BeEquivalentTo("#!/bin/sh\necho hi\n"))
+		info, err := os.Stat(dst)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(info.Mode().Perm()).To(Equal(os.FileMode(0755)))
+
+		// Existing files are only replaced with overwrite
+		Expect(os.WriteFile(src, []byte("changed\n"), 0755)).To(Succeed())
+		_, out, err = copyPath(context.Background(), nil, copyInput{Src: src, Dst: dst})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(out.Success).To(BeFalse())
+		Expect(out.Error).To(ContainSubstring("overwrite=true"))
+		_, out, err = copyPath(context.Background(), nil, copyInput{Src: src, Dst: dst, Overwrite: true})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(out.Success).To(BeTrue(), out.Error)
+		Expect(os.ReadFile(dst)).To(BeEquivalentTo("changed\n"))
+
+		_, out, _ = copyPath(context.Background(), nil, copyInput{Src: src, Dst: src, Overwrite: true})
+		Expect(out.Success).To(BeFalse())
+		Expect(out.Error).To(ContainSubstring("same file"))
+	})
+
+	It("copies a directory tree with modes and links", func() {
+		src := filepath.Join(root, "src")
+		for path, content := range map[string]string{
+			"a.txt":       "a\n",
+			"sub/b.txt":   "b\n",
+			"sub/c/d.txt": "d\n",
+		} {
+			path = filepath.Join(src, path)
+			Expect(os.MkdirAll(filepath.Dir(path), 0755)).To(Succeed())
+			Expect(os.WriteFile(path, []byte(content), 0600)).To(Succeed())
+		}
+		Expect(os.Chmod(filepath.Join(src, "sub"), 0700)).To(Succeed())
+		Expect(os.Symlink("sub/b.txt", filepath.Join(src, "link"))).To(Succeed())
+		dst := filepath.Join(root, "dst")
+
+		_, out, err := copyPath(context.Background(), nil, copyInput{Src: src, Dst: dst})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(out.Success).To(BeTrue(), out.Error)
+		Expect(out.Files).To(Equal(4))
+		Expect(os.ReadFile(filepath.Join(dst, "sub", "c", "d.txt"))).To(BeEquivalentTo("d\n"))
+		info, err := os.Stat(filepath.Join(dst, "sub"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(info.Mode().Perm()).To(Equal(os.FileMode(0700)))
+		info, err = os.Stat(filepath.Join(dst, "a.txt"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(info.Mode().Perm()).To(Equal(os.FileMode(0600)))
+		Expect(os.Readlink(filepath.Join(dst, "link"))).To(Equal("sub/b.txt"))
+
+		// A conflict refuses the whole copy before anything is written
+		Expect(os.WriteFile(filepath.Join(src, "new.txt"), []byte("new\n"), 0644)).To(Succeed())
+		_, out, _ = copyPath(context.Background(), nil, copyInput{Src: src, Dst: dst})
+		Expect(out.Success).To(BeFalse())
+		Expect(out.Error).To(ContainSubstring("already exists"))
+		Expect(filepath.Join(dst, "new.txt")).NotTo(BeAnExistingFile())
+		_, out, _ = copyPath(context.Background(), nil, copyInput{Src: src, Dst: dst, Overwrite: true})
+		Expect(out.Success).To(BeTrue(), out.Error)
+		Expect(out.Files).To(Equal(5))
+
+		_, out, _ = copyPath(context.Background(), nil, copyInput{Src: src, Dst: filepath.Join(src, "sub", "copy")})
+		Expect(out.Success).To(BeFalse())
+		Expect(out.Error).To(ContainSubstring("into itself"))
+	})
+
+	It("refuses links that would lead outside the root from a shallower destination", func() {
+		guard, err := safepath.New(root)
+		Expect(err).NotTo(HaveOccurred())
+		DeferCleanup(func(previous safepath.Guard) { paths = previous }, paths)
+		paths = guard
+		root = guard.Root
+
+		// x/y/z/dir/link resolves to root/x/etc where it is, but to a
+		// directory outside the root once dir is copied to the top of the root
+		dir := filepath.Join(root, "x", "y", "z", "dir")
+		Expect(os.MkdirAll(filepath.Join(root, "x", "etc"), 0755)).To(Succeed())
+		Expect(os.MkdirAll(dir, 0755)).To(Succeed())
+		Expect(os.Symlink("../../../etc", filepath.Join(dir, "link"))).To(Succeed())
+
+		// Deeper or equally deep destinations keep the link inside the root
+		_, out, _ := copyPath(context.Background(), nil, copyInput{Src: dir, Dst: filepath.Join(root, "a", "b", "c", "dir")})
+		Expect(out.Success).To(BeTrue(), out.Error)
+
+		_, out, _ = copyPath(context.Background(), nil, copyInput{Src: dir, Dst: filepath.Join(root, "dir")})
+		Expect(out.Success).To(BeFalse())
+		Expect(out.Error).To(ContainSubstring("symbolic link leads outside the root"))
+		_, err = os.Lstat(filepath.Join(root, "dir", "link"))
+		Expect(os.IsNotExist(err)).To(BeTrue())
+	})
+})
@@ -0,0 +1,400 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// maxGrepMatches is the number of matches grep returns by default
+const maxGrepMatches = 50
+
+// maxGrepContext caps the context lines grep returns around a match
+const maxGrepContext = 10
+
+// grepGroupSeparator is returned between match groups with context lines
+const grepGroupSeparator = "--"
+
+// defaultGrepMatchesLimit is the largest max_matches grep accepts by default
+const defaultGrepMatchesLimit = 1000
+
+// grepWorkers is the number of files searched concurrently, set with
+// FILESYSTEM_GREP_WORKERS
+var grepWorkers = runtime.GOMAXPROCS(0)
+
+// grepMatchesLimit caps max_matches, set with FILESYSTEM_GREP_MAX_MATCHES
+var grepMatchesLimit = defaultGrepMatchesLimit
+
+// Input type for grep operation
+type grepFilesInput struct {
+	Pat        string `json:"pat" jsonschema:"the regex pattern to search for"`
+	Path       string `json:"path,omitempty" jsonschema:"optional base path (default: '.')"`
+	MaxMatches int    `json:"max_matches,omitempty" jsonschema:"maximum number of matches to return (default: 50, capped by the server limit, 1000 unless configured)"`
+	CountOnly  bool   `json:"count_only,omitempty" jsonschema:"only count the matching lines of each file, without returning them; every match is counted"`
+	Before     int    `json:"before,omitempty" jsonschema:"number of lines of context to return before each match, like grep -B (default: 0, cap 10)"`
+	After      int    `json:"after,omitempty" jsonschema:"number of lines of context to return after each match, like grep -A (default: 0, cap 10)"`
+}
+
+// Output type for grep operation
+type grepFilesOutput struct {
+	Matches []string        `json:"matches,omitempty" jsonschema:"list of matches in format 'filepath:line_number:content'; with before or after, context lines in format 'filepath-line_number-content' and '--' between match groups"`
+	Files   []grepFileCount `json:"files,omitempty" jsonschema:"with count_only, the number of matching lines of each file with matches, in walk order"`
+	Count   int             `json:"count" jsonschema:"number of matches found; with before or after, the number of match groups, overlapping groups being merged"`
+	Success bool            `json:"success" jsonschema:"whether operation was successful"`
+	Error   string          `json:"error,omitempty" jsonschema:"error message if failed"`
+}
+
+// grepFileCount is the number of matching lines of a file
+type grepFileCount struct {
+	Path  string `json:"path"`
+	Count int    `json:"count"`
+}
+
+// grepWorkersFromEnv reads FILESYSTEM_GREP_WORKERS, defaulting to GOMAXPROCS
+func grepWorkersFromEnv() int {
+	value := os.Getenv("FILESYSTEM_GREP_WORKERS")
+	if value == "" {
+		return runtime.GOMAXPROCS(0)
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n <= 0 {
+		log.Printf("Warning: invalid FILESYSTEM_GREP_WORKERS %q, using %d", value, runtime.GOMAXPROCS(0))
+		return runtime.GOMAXPROCS(0)
+	}
+	return n
+}
+
+// grepMatchesLimitFromEnv reads FILESYSTEM_GREP_MAX_MATCHES, defaulting to
+// defaultGrepMatchesLimit
+func grepMatchesLimitFromEnv() int {
+	value := os.Getenv("FILESYSTEM_GREP_MAX_MATCHES")
+	if value == "" {
+		return defaultGrepMatchesLimit
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n <= 0 {
+		log.Printf("Warning: invalid FILESYSTEM_GREP_MAX_MATCHES %q, using %d", value, defaultGrepMatchesLimit)
+		return defaultGrepMatchesLimit
+	}
+	return n
+}
+
+// searchFileForPattern returns up to maxMatches match groups of a file: the
+// matching lines formatted as 'filepath:line_number:content', each preceded
+// by up to before and followed by up to after context lines formatted as
+// 'filepath-line_number-content', as grep -B/-A does. The groups of matches
+// whose context lines touch or overlap are merged. Without context every
+// matching line is a group of its own. Binary files have no matches.
+func searchFileForPattern(path string, re *regexp.Regexp, maxMatches, before, after int) [][]string {
+	var groups [][]string
+
+	file, err := os.Open(path)
+	if err != nil {
+		return groups // Skip files that can't be opened
+	}
+	defer file.Close()
+	reader, binary := sniffBinary(file)
+	if binary {
+		return groups // Skip binary files
+	}
+
+	type line struct {
+		num  int
+		text string
+	}
+	var (
+		previous  []line // The last before lines, not yet in a group
+		emitted   int    // The last line number in a group
+		afterLeft int    // The context lines still to add after a match
+	)
+	scanner := bufio.NewScanner(reader)
+	lineNum := 1
+	for scanner.Scan() {
+		text := scanner.Text()
+		if re.MatchString(text) {
+			contiguous := len(groups) > 0 && (before > 0 || after > 0) && lineNum-len(previous) <= emitted+1
+			if !contiguous {
+				if len(groups) >= maxMatches {
+					break
+				}
+				groups = append(groups, nil)
+			}
+			group := &groups[len(groups)-1]
+			for _, l := range previous {
+				*group = append(*group, fmt.Sprintf("%s-%d-%s", path, l.num, strings.TrimSpace(l.text)))
+			}
+			*group = append(*group, fmt.Sprintf("%s:%d:%s", path, lineNum, strings.TrimSpace(text)))
+			previous = previous[:0]
+			emitted = lineNum
+			afterLeft = after
+		} else if afterLeft > 0 {
+			groups[len(groups)-1] = append(groups[len(groups)-1], fmt.Sprintf("%s-%d-%s", path, lineNum, strings.TrimSpace(text)))
+			emitted = lineNum
+			afterLeft--
+		} else if before > 0 {
+			if len(previous) == before {
+				previous = append(previous[:0], previous[1:]...)
+			}
+			previous = append(previous, line{num: lineNum, text: text})
+		}
+		if len(groups) >= maxMatches && afterLeft == 0 {
+			break
+		}
+		lineNum++
+	}
+
+	return groups
+}
+
+// countFileMatches returns the number of lines of a file matching re, 0 for
+// binary files
+func countFileMatches(path string, re *regexp.Regexp) int {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0 // Skip files that can't be opened
+	}
+	defer file.Close()
+	reader, binary := sniffBinary(file)
+	if binary {
+		return 0 // Skip binary files
+	}
+
+	count := 0
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		if re.Match(scanner.Bytes()) {
+			count++
+		}
+	}
+	return count
+}
+
+// grepTree searches the files under basePath with workers goroutines and
+// returns the first maxMatches matches in walk order, the same ones a
+// sequential search returns.
+func grepTree(ctx context.Context, basePath string, re *regexp.Regexp, maxMatches, workers int) ([]string, error) {
+	groups, err := grepContextTree(ctx, basePath, re, maxMatches, 0, 0, workers)
+	if err != nil {
+		return nil, err
+	}
+	var matches []string
+	for _, group := range groups {
+		matches = append(matches, group...)
+	}
+	return matches, nil
+}
+
+// grepContextTree searches the files under basePath with workers goroutines
+// and returns the first maxMatches match groups in walk order, each with up
+// to before and after context lines, the same ones a sequential search
+// returns.
+//
+// Files are numbered as they are walked and their groups merged in that
+// order. No new file is handed out once maxMatches groups are found: every
+// file before it was already handed out, so the first groups are complete.
+func grepContextTree(ctx context.Context, basePath string, re *regexp.Regexp, maxMatches, before, after, workers int) ([][]string, error) {
+	type job struct {
+		index int
+		path  string
+	}
+
+	var (
+		mutex   sync.Mutex
+		results = map[int][][]string{}
+		found   int
+		wg      sync.WaitGroup
+	)
+	jobs := make(chan job)
+	for i := 0; i < max(workers, 1); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range jobs {
+				fileGroups := searchFileForPattern(j.path, re, maxMatches, before, after)
+				if len(fileGroups) == 0 {
+					continue
+				}
+				mutex.Lock()
+				results[j.index] = fileGroups
+				found += len(fileGroups)
+				mutex.Unlock()
+			}
+		}()
+	}
+
+	files := 0
+	err := walkFiles(basePath, func(path string, d os.DirEntry) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		// Limit matches
+		mutex.Lock()
+		done := found >= maxMatches
+		mutex.Unlock()
+		if done {
+			return filepath.SkipAll
+		}
+
+		jobs <- job{index: files, path: path}
+		files++
+		return nil
+	})
+	close(jobs)
+	wg.Wait()
+	if err != nil {
+		return nil, err
+	}
+
+	var groups [][]string
+	for i := 0; i < files && len(groups) < maxMatches; i++ {
+		groups = append(groups, results[i]...)
+	}
+	if len(groups) > maxMatches {
+		groups = groups[:maxMatches]
+	}
+	return groups, nil
+}
+
+// grepCountTree counts the matching lines of every file under basePath with
+// workers goroutines. Files with matches are returned in walk order with the
+// total count.
+func grepCountTree(ctx context.Context, basePath string, re *regexp.Regexp, workers int) ([]grepFileCount, int, error) {
+	type job struct {
+		index int
+		path  string
+	}
+
+	var (
+		mutex  sync.Mutex
+		counts = map[int]int{}
+		wg     sync.WaitGroup
+	)
+	jobs := make(chan job)
+	for i := 0; i < max(workers, 1); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range jobs {
+				if n := countFileMatches(j.path, re); n > 0 {
+					mutex.Lock()
+					counts[j.index] = n
+					mutex.Unlock()
+				}
+			}
+		}()
+	}
+
+	var walked []string
+	err := walkFiles(basePath, func(path string, d os.DirEntry) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		jobs <- job{index: len(walked), path: path}
+		walked = append(walked, path)
+		return nil
+	})
+	close(jobs)
+	wg.Wait()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	files := []grepFileCount{}
+	total := 0
+	for i, path := range walked {
+		if n, ok := counts[i]; ok {
+			files = append(files, grepFileCount{Path: path, Count: n})
+			total += n
+		}
+	}
+	return files, total, nil
+}
+
+// grepFiles searches files for regex pattern
+func grepFiles(ctx context.Context, req *mcp.CallToolRequest, input grepFilesInput) (
+	*mcp.CallToolResult,
+	grepFilesOutput,
+	error,
+) {
+	// Compile regex
+	re, err := regexp.Compile(input.Pat)
+	if err != nil {
+		return nil, grepFilesOutput{
+			Success: false,
+			Error:   fmt.Sprintf("invalid regex pattern: %s", err.Error()),
+		}, nil
+	}
+
+	basePath := input.Path
+	if basePath == "" {
+		basePath = "."
+	}
+	if _, err := paths.Resolve(basePath); err != nil {
+		return nil, grepFilesOutput{
+			Success: false,
+			Error:   err.Error(),
+		}, nil
+	}
+
+	if input.CountOnly {
+		files, total, err := grepCountTree(ctx, basePath, re, grepWorkers)
+		if err != nil {
+			return nil, grepFilesOutput{
+				Success: false,
+				Error:   err.Error(),
+			}, nil
+		}
+		return nil, grepFilesOutput{
+			Files:   files,
+			Count:   total,
+			Success: true,
+		}, nil
+	}
+
+	maxMatches := maxGrepMatches
+	if input.MaxMatches > 0 {
+		maxMatches = min(input.MaxMatches, grepMatchesLimit)
+	}
+
+	if input.Before < 0 || input.After < 0 {
+		return nil, grepFilesOutput{
+			Success: false,
+			Error:   "before and after must not be negative",
+		}, nil
+	}
+	before, after := min(input.Before, maxGrepContext), min(input.After, maxGrepContext)
+
+	groups, err := grepContextTree(ctx, basePath, re, maxMatches, before, after, grepWorkers)
+	if err != nil {
+		return nil, grepFilesOutput{
+			Success: false,
+			Error:   err.Error(),
+		}, nil
+	}
+
+	// Groups with context are told apart by a separator, as grep does
+	var matches []string
+	for i, group := range groups {
+		if i > 0 && (before > 0 || after > 0) {
+			matches = append(matches, grepGroupSeparator)
+		}
+		matches = append(matches, group...)
+	}
+
+	return nil, grepFilesOutput{
+		Matches: matches,
+		Count:   len(groups),
+		Success: true,
+	}, nil
+}
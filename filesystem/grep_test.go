@@ -0,0 +1,142 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// writeGrepTree fills root with dirs*files files of lines lines each, every
+// file holding one line matching "needle"
+func writeGrepTree(root string, dirs, files, lines int) error {
+	line := strings.Repeat("lorem ipsum dolor sit amet ", 4) + "\n"
+	for d := 0; d < dirs; d++ {
+		dir := filepath.Join(root, fmt.Sprintf("dir%03d", d))
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+		for f := 0; f < files; f++ {
+			content := strings.Repeat(line, lines/2) + "needle\n" + strings.Repeat(line, lines/2)
+			if err := os.WriteFile(filepath.Join(dir, fmt.Sprintf("file%03d.txt", f)), []byte(content), 0644); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// writeFiles creates files under root, named by their path relative to it
+func writeFiles(root string, files map[string]string) {
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		Expect(os.MkdirAll(filepath.Dir(path), 0755)).To(Succeed())
+		Expect(os.WriteFile(path, []byte(content), 0644)).To(Succeed())
+	}
+}
+
+var _ = Describe("grep_files", func() {
+	var root string
+
+	BeforeEach(func() {
+		root = GinkgoT().TempDir()
+	})
+
+	It("finds the same matches, in the same order, with concurrent workers", func() {
+		Expect(writeGrepTree(root, 8, 20, 10)).To(Succeed())
+		re := regexp.MustCompile("needle")
+
+		sequential, err := grepTree(context.Background(), root, re, maxGrepMatches, 1)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(sequential).To(HaveLen(maxGrepMatches))
+		for i := 0; i < 20; i++ {
+			concurrent, err := grepTree(context.Background(), root, re, maxGrepMatches, 8)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(concurrent).To(Equal(sequential))
+		}
+	})
+
+	DescribeTable("caps the matches at max_matches and the server limit",
+		func(maxMatches, want int) {
+			Expect(writeGrepTree(root, 8, 20, 10)).To(Succeed())
+			DeferCleanup(func(limit int) { grepMatchesLimit = limit }, grepMatchesLimit)
+			grepMatchesLimit = 120
+
+			_, out, err := grepFiles(context.Background(), nil, grepFilesInput{Pat: "needle", Path: root, MaxMatches: maxMatches})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(out.Success).To(BeTrue(), out.Error)
+			Expect(out.Count).To(Equal(want))
+			Expect(out.Matches).To(HaveLen(want))
+		},
+		Entry("default", 0, maxGrepMatches),
+		Entry("below the default", 3, 3),
+		Entry("above the default", 100, 100),
+		Entry("above the server limit", 500, 120),
+	)
+
+	It("counts the matches of each file with count_only", func() {
+		writeFiles(root, map[string]string{"a.txt": "needle\nhay\nneedle\n", "b.txt": "hay\n", "sub/c.txt": "needle needle\n"})
+
+		_, out, err := grepFiles(context.Background(), nil, grepFilesInput{Pat: "needle", Path: root, CountOnly: true})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(out.Success).To(BeTrue(), out.Error)
+		Expect(out.Files).To(Equal([]grepFileCount{{Path: filepath.Join(root, "a.txt"), Count: 2}, {Path: filepath.Join(root, "sub", "c.txt"), Count: 1}}))
+		Expect(out.Count).To(Equal(3))
+		Expect(out.Matches).To(BeNil())
+	})
+
+	It("returns context lines, grouping overlapping ones", func() {
+		content := "one\nneedle two\nthree\nfour\nneedle five\nsix\nseven\neight\nnine\nneedle ten\n"
+		path := filepath.Join(root, "a.txt")
+		writeFiles(root, map[string]string{"a.txt": content})
+
+		// The context of the first two matches overlaps, so they make one group
+		_, out, err := grepFiles(context.Background(), nil, grepFilesInput{Pat: "needle", Path: root, Before: 1, After: 2})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(out.Success).To(BeTrue(), out.Error)
+		Expect(out.Matches).To(Equal([]string{
+			path + "-1-one", path + ":2:needle two", path + "-3-three", path + "-4-four", path + ":5:needle five", path + "-6-six", path + "-7-seven",
+			grepGroupSeparator,
+			path + "-9-nine", path + ":10:needle ten",
+		}))
+		Expect(out.Count).To(Equal(2))
+
+		// The limit counts groups, and the last one keeps its context
+		_, out, err = grepFiles(context.Background(), nil, grepFilesInput{Pat: "needle", Path: root, MaxMatches: 1, After: 1})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(out.Success).To(BeTrue(), out.Error)
+		Expect(out.Matches).To(Equal([]string{path + ":2:needle two", path + "-3-three"}))
+		Expect(out.Count).To(Equal(1))
+	})
+})
+
+// BenchmarkGrepTree searches a tree without enough matches to stop early,
+// sequentially and with GOMAXPROCS workers, e.g. go test -bench GrepTree -cpu 8
+func BenchmarkGrepTree(b *testing.B) {
+	root := b.TempDir()
+	if err := writeGrepTree(root, 20, 20, 2000); err != nil {
+		b.Fatal(err)
+	}
+	re := regexp.MustCompile(`needle\d+`)
+
+	counts := []int{1}
+	if n := runtime.GOMAXPROCS(0); n > 1 {
+		counts = append(counts, n)
+	}
+	for _, workers := range counts {
+		b.Run(fmt.Sprintf("workers=%d", workers), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, err := grepTree(context.Background(), root, re, maxGrepMatches, workers); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
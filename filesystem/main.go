@@ -4,15 +4,19 @@ import (
 	"bufio"
 	"context"
 	"fmt"
+	"io"
 	"log"
 	"os"
 	"path/filepath"
-	"regexp"
 	"sort"
 	"strings"
 	"time"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/mudler/mcps/pkg/output"
+	"github.com/mudler/mcps/pkg/safepath"
+	"github.com/mudler/mcps/pkg/selfcheck"
+	"github.com/mudler/mcps/pkg/transport"
 )
 
 // Input type for reading files
@@ -20,12 +24,14 @@ type readFileInput struct {
 	Path   string `json:"path" jsonschema:"the file path to read"`
 	Offset int    `json:"offset,omitempty" jsonschema:"optional line offset to start reading from (0-based)"`
 	Limit  int    `json:"limit,omitempty" jsonschema:"optional maximum number of lines to read"`
+	Force  bool   `json:"force,omitempty" jsonschema:"read the file even if it looks binary (default: false)"`
 }
 
 // Output type for read operation
 type readFileOutput struct {
 	Content    string `json:"content" jsonschema:"file content with line numbers in format '   1| content'"`
 	TotalLines int    `json:"total_lines" jsonschema:"total number of lines in file"`
+	Truncated  bool   `json:"truncated,omitempty" jsonschema:"whether content was cut to fit MCP_MAX_RESPONSE_BYTES, the marker at the end tells which offset to continue from"`
 	Success    bool   `json:"success" jsonschema:"whether operation was successful"`
 	Error      string `json:"error,omitempty" jsonschema:"error message if failed"`
 }
@@ -57,6 +63,96 @@ type editFileOutput struct {
 	Error        string `json:"error,omitempty" jsonschema:"error message if failed"`
 }
 
+// Input type for replace across files operation
+type replaceInFilesInput struct {
+	Pat    string `json:"pat" jsonschema:"the glob pattern selecting the files to edit"`
+	Path   string `json:"path,omitempty" jsonschema:"optional base path (default: '.')"`
+	Old    string `json:"old" jsonschema:"the old string to replace"`
+	New    string `json:"new" jsonschema:"the new string to replace with"`
+	All    bool   `json:"all,omitempty" jsonschema:"optional replace all occurrences in each file (default: false, files with more than one occurrence are skipped)"`
+	DryRun bool   `json:"dry_run,omitempty" jsonschema:"optional report what would change without writing any file (default: false)"`
+}
+
+// Per-file result of a replace across files operation
+type fileReplacement struct {
+	Path         string `json:"path" jsonschema:"the file path"`
+	Replacements int    `json:"replacements" jsonschema:"number of replacements made (or that would be made in dry run)"`
+	Error        string `json:"error,omitempty" jsonschema:"error message if the file was skipped"`
+}
+
+// Output type for replace across files operation
+type replaceInFilesOutput struct {
+	Files   []fileReplacement `json:"files" jsonschema:"files containing the old string with their replacement counts"`
+	Total   int               `json:"total" jsonschema:"total number of replacements across all files"`
+	DryRun  bool              `json:"dry_run" jsonschema:"whether this was a dry run"`
+	Success bool              `json:"success" jsonschema:"whether operation was successful"`
+	Error   string            `json:"error,omitempty" jsonschema:"error message if failed"`
+}
+
+// Input type for symlink creation
+type symlinkInput struct {
+	Target   string `json:"target" jsonschema:"the path the link points to, relative targets are resolved from the link's directory"`
+	LinkPath string `json:"link_path" jsonschema:"the path of the link to create"`
+}
+
+// Output type for symlink creation
+type symlinkOutput struct {
+	Success bool   `json:"success" jsonschema:"whether operation was successful"`
+	Error   string `json:"error,omitempty" jsonschema:"error message if failed"`
+}
+
+// Input type for reading a symlink
+type readlinkInput struct {
+	Path string `json:"path" jsonschema:"the symlink path to read"`
+}
+
+// Output type for reading a symlink
+type readlinkOutput struct {
+	Target       string `json:"target" jsonschema:"the link target as stored in the link"`
+	ResolvedPath string `json:"resolved_path" jsonschema:"the target resolved against the link's directory"`
+	TargetExists bool   `json:"target_exists" jsonschema:"whether the target exists (false for a dangling link)"`
+	Success      bool   `json:"success" jsonschema:"whether operation was successful"`
+	Error        string `json:"error,omitempty" jsonschema:"error message if failed"`
+}
+
+// Input type for head operation
+type headFileInput struct {
+	Path  string `json:"path" jsonschema:"the file path to read"`
+	Lines int    `json:"lines,omitempty" jsonschema:"optional number of lines to return from the start of the file (default: 10)"`
+}
+
+// Output type for head operation
+type headFileOutput struct {
+	Content   string `json:"content" jsonschema:"first lines of the file with line numbers in format '   1| content'"`
+	Lines     int    `json:"lines" jsonschema:"number of lines returned"`
+	HasMore   bool   `json:"has_more" jsonschema:"whether the file continues past the returned lines"`
+	Truncated bool   `json:"truncated,omitempty" jsonschema:"whether content was cut to fit MCP_MAX_RESPONSE_BYTES"`
+	Success   bool   `json:"success" jsonschema:"whether operation was successful"`
+	Error     string `json:"error,omitempty" jsonschema:"error message if failed"`
+}
+
+// Input type for word count operation
+type wcInput struct {
+	Paths []string `json:"paths" jsonschema:"the file paths to count"`
+}
+
+// Line, word and byte counts of a single file
+type wcCount struct {
+	Path  string `json:"path" jsonschema:"the file path"`
+	Lines int    `json:"lines" jsonschema:"number of newline characters, as counted by wc -l"`
+	Words int    `json:"words" jsonschema:"number of whitespace-separated words"`
+	Bytes int64  `json:"bytes" jsonschema:"size in bytes"`
+	Error string `json:"error,omitempty" jsonschema:"error message if the file could not be counted"`
+}
+
+// Output type for word count operation
+type wcOutput struct {
+	Files   []wcCount `json:"files" jsonschema:"counts per file, in request order"`
+	Total   wcCount   `json:"total" jsonschema:"sum of the counts of every file that could be read"`
+	Success bool      `json:"success" jsonschema:"whether operation was successful"`
+	Error   string    `json:"error,omitempty" jsonschema:"error message if failed"`
+}
+
 // Input type for glob operation
 type globFilesInput struct {
 	Pat  string `json:"pat" jsonschema:"the glob pattern to match files"`
@@ -71,18 +167,24 @@ type globFilesOutput struct {
 	Error   string   `json:"error,omitempty" jsonschema:"error message if failed"`
 }
 
-// Input type for grep operation
-type grepFilesInput struct {
-	Pat  string `json:"pat" jsonschema:"the regex pattern to search for"`
-	Path string `json:"path,omitempty" jsonschema:"optional base path (default: '.')"`
+// paths validates the paths received by the tools, confining them to
+// FILESYSTEM_ROOT when it is set
+var paths safepath.Guard
+
+// outsideRoot reports whether path, found by a glob or directory walk, leads
+// outside FILESYSTEM_ROOT through a symlink
+func outsideRoot(path string) bool {
+	if paths.Root == "" {
+		return false
+	}
+	_, err := paths.Resolve(path)
+	return err != nil
 }
 
-// Output type for grep operation
-type grepFilesOutput struct {
-	Matches []string `json:"matches" jsonschema:"list of matches in format 'filepath:line_number:content'"`
-	Count   int      `json:"count" jsonschema:"number of matches found"`
-	Success bool     `json:"success" jsonschema:"whether operation was successful"`
-	Error   string   `json:"error,omitempty" jsonschema:"error message if failed"`
+// walkedOutsideRoot is outsideRoot for the entries of a directory walk, only
+// symlinks needing a check as the walk does not follow them
+func walkedOutsideRoot(path string, d os.DirEntry) bool {
+	return d.Type()&os.ModeSymlink != 0 && outsideRoot(path)
 }
 
 // readFile reads a file with optional offset and limit
@@ -91,8 +193,16 @@ func readFile(ctx context.Context, req *mcp.CallToolRequest, input readFileInput
 	readFileOutput,
 	error,
 ) {
+	path, err := paths.Resolve(input.Path)
+	if err != nil {
+		return nil, readFileOutput{
+			Success: false,
+			Error:   err.Error(),
+		}, nil
+	}
+
 	// Read file content
-	file, err := os.Open(input.Path)
+	file, err := os.Open(path)
 	if err != nil {
 		return nil, readFileOutput{
 			Success: false,
@@ -101,9 +211,32 @@ func readFile(ctx context.Context, req *mcp.CallToolRequest, input readFileInput
 	}
 	defer file.Close()
 
+	// The whole file is loaded to count its lines
+	info, err := file.Stat()
+	if err != nil {
+		return nil, readFileOutput{
+			Success: false,
+			Error:   err.Error(),
+		}, nil
+	}
+	if info.Size() > maxFileBytes {
+		return nil, readFileOutput{
+			Success: false,
+			Error:   fmt.Sprintf("file is %d bytes, larger than the %d bytes read loads (FILESYSTEM_MAX_FILE_BYTES); use head or grep instead", info.Size(), maxFileBytes),
+		}, nil
+	}
+
+	reader, binary := sniffBinary(file)
+	if binary && !input.Force {
+		return nil, readFileOutput{
+			Success: false,
+			Error:   "binary file, refusing to read it; set force to read it anyway",
+		}, nil
+	}
+
 	// Read all lines
 	var lines []string
-	scanner := bufio.NewScanner(file)
+	scanner := bufio.NewScanner(reader)
 	for scanner.Scan() {
 		lines = append(lines, scanner.Text())
 	}
@@ -155,21 +288,193 @@ func readFile(ctx context.Context, req *mcp.CallToolRequest, input readFileInput
 
 	content := strings.Join(formattedLines, "\n")
 
+	// Keep the response within MCP_MAX_RESPONSE_BYTES, cutting on a line boundary
+	kept, truncated := output.Head(content, output.MaxBytes())
+	if truncated {
+		if i := strings.LastIndexByte(kept, '\n'); i >= 0 {
+			kept = kept[:i]
+		}
+		next := offset + strings.Count(kept, "\n") + 1
+		content, _ = output.TruncateTo(content, len(kept), fmt.Sprintf("call read again with offset=%d to continue", next))
+	}
+
 	return nil, readFileOutput{
 		Content:    content,
 		TotalLines: totalLines,
+		Truncated:  truncated,
 		Success:    true,
 	}, nil
 }
 
+// headFile returns the first lines of a file without reading the rest
+func headFile(ctx context.Context, req *mcp.CallToolRequest, input headFileInput) (
+	*mcp.CallToolResult,
+	headFileOutput,
+	error,
+) {
+	n := input.Lines
+	if n <= 0 {
+		n = 10
+	}
+
+	path, err := paths.Resolve(input.Path)
+	if err != nil {
+		return nil, headFileOutput{
+			Success: false,
+			Error:   err.Error(),
+		}, nil
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, headFileOutput{
+			Success: false,
+			Error:   err.Error(),
+		}, nil
+	}
+	defer file.Close()
+
+	// Stop as soon as one line past the requested ones is seen
+	var lines []string
+	hasMore := false
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if len(lines) == n {
+			hasMore = true
+			break
+		}
+		lines = append(lines, scanner.Text())
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, headFileOutput{
+			Success: false,
+			Error:   err.Error(),
+		}, nil
+	}
+
+	width := len(fmt.Sprintf("%d", len(lines)))
+	if width < 4 {
+		width = 4
+	}
+
+	formattedLines := make([]string, 0, len(lines))
+	for i, line := range lines {
+		formattedLines = append(formattedLines, fmt.Sprintf("%*d| %s", width, i+1, line))
+	}
+
+	content, truncated := output.Truncate(strings.Join(formattedLines, "\n"), "request fewer lines or use read with offset and limit")
+
+	return nil, headFileOutput{
+		Content:   content,
+		Lines:     len(lines),
+		HasMore:   hasMore,
+		Truncated: truncated,
+		Success:   true,
+	}, nil
+}
+
+// countFile streams a file and counts its lines, words and bytes like wc
+func countFile(path string) (wcCount, error) {
+	count := wcCount{Path: path}
+
+	resolved, err := paths.Resolve(path)
+	if err != nil {
+		return count, err
+	}
+	file, err := os.Open(resolved)
+	if err != nil {
+		return count, err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return count, err
+	}
+	if info.IsDir() {
+		return count, fmt.Errorf("%s is a directory", path)
+	}
+
+	inWord := false
+	buf := make([]byte, 64*1024)
+	for {
+		n, err := file.Read(buf)
+		for _, b := range buf[:n] {
+			switch b {
+			case '\n':
+				count.Lines++
+				inWord = false
+			case ' ', '\t', '\r', '\v', '\f':
+				inWord = false
+			default:
+				if !inWord {
+					count.Words++
+					inWord = true
+				}
+			}
+		}
+		count.Bytes += int64(n)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return count, err
+		}
+	}
+
+	return count, nil
+}
+
+// wcFiles returns line, word and byte counts for one or more files
+func wcFiles(ctx context.Context, req *mcp.CallToolRequest, input wcInput) (
+	*mcp.CallToolResult,
+	wcOutput,
+	error,
+) {
+	if len(input.Paths) == 0 {
+		return nil, wcOutput{
+			Success: false,
+			Error:   "at least one path is required",
+		}, nil
+	}
+
+	result := wcOutput{
+		Files:   []wcCount{},
+		Total:   wcCount{Path: "total"},
+		Success: true,
+	}
+	for _, path := range input.Paths {
+		count, err := countFile(path)
+		if err != nil {
+			result.Files = append(result.Files, wcCount{Path: path, Error: err.Error()})
+			continue
+		}
+		result.Files = append(result.Files, count)
+		result.Total.Lines += count.Lines
+		result.Total.Words += count.Words
+		result.Total.Bytes += count.Bytes
+	}
+
+	return nil, result, nil
+}
+
 // writeFile writes content to a file
 func writeFile(ctx context.Context, req *mcp.CallToolRequest, input writeFileInput) (
 	*mcp.CallToolResult,
 	writeFileOutput,
 	error,
 ) {
+	path, err := paths.Resolve(input.Path)
+	if err != nil {
+		return nil, writeFileOutput{
+			Success: false,
+			Error:   err.Error(),
+		}, nil
+	}
+
 	// Create parent directories if needed
-	dir := filepath.Dir(input.Path)
+	dir := filepath.Dir(path)
 	if err := os.MkdirAll(dir, 0755); err != nil {
 		return nil, writeFileOutput{
 			Success: false,
@@ -178,7 +483,7 @@ func writeFile(ctx context.Context, req *mcp.CallToolRequest, input writeFileInp
 	}
 
 	// Write file
-	if err := os.WriteFile(input.Path, []byte(input.Content), 0644); err != nil {
+	if err := os.WriteFile(path, []byte(input.Content), 0644); err != nil {
 		return nil, writeFileOutput{
 			Success: false,
 			Error:   err.Error(),
@@ -190,14 +495,109 @@ func writeFile(ctx context.Context, req *mcp.CallToolRequest, input writeFileInp
 	}, nil
 }
 
+// createSymlink creates a symbolic link at link_path pointing to target
+func createSymlink(ctx context.Context, req *mcp.CallToolRequest, input symlinkInput) (
+	*mcp.CallToolResult,
+	symlinkOutput,
+	error,
+) {
+	if input.Target == "" || input.LinkPath == "" {
+		return nil, symlinkOutput{
+			Success: false,
+			Error:   "target and link_path are required",
+		}, nil
+	}
+
+	// The link itself is checked, and its target like any other path
+	// (relative targets are relative to the directory holding the link)
+	linkPath, err := paths.ResolveLink(input.LinkPath)
+	if err == nil {
+		target := input.Target
+		if !filepath.IsAbs(target) {
+			target = filepath.Join(filepath.Dir(linkPath), target)
+		}
+		_, err = paths.Resolve(target)
+	}
+	if err != nil {
+		return nil, symlinkOutput{
+			Success: false,
+			Error:   err.Error(),
+		}, nil
+	}
+
+	// Create parent directories if needed
+	if err := os.MkdirAll(filepath.Dir(linkPath), 0755); err != nil {
+		return nil, symlinkOutput{
+			Success: false,
+			Error:   err.Error(),
+		}, nil
+	}
+
+	if err := os.Symlink(input.Target, linkPath); err != nil {
+		return nil, symlinkOutput{
+			Success: false,
+			Error:   err.Error(),
+		}, nil
+	}
+
+	return nil, symlinkOutput{
+		Success: true,
+	}, nil
+}
+
+// readSymlink returns the target of a symbolic link
+func readSymlink(ctx context.Context, req *mcp.CallToolRequest, input readlinkInput) (
+	*mcp.CallToolResult,
+	readlinkOutput,
+	error,
+) {
+	path, err := paths.ResolveLink(input.Path)
+	if err != nil {
+		return nil, readlinkOutput{
+			Success: false,
+			Error:   err.Error(),
+		}, nil
+	}
+
+	target, err := os.Readlink(path)
+	if err != nil {
+		return nil, readlinkOutput{
+			Success: false,
+			Error:   err.Error(),
+		}, nil
+	}
+
+	// Relative targets are relative to the directory holding the link
+	resolved := target
+	if !filepath.IsAbs(resolved) {
+		resolved = filepath.Join(filepath.Dir(input.Path), resolved)
+	}
+	_, statErr := os.Stat(path)
+
+	return nil, readlinkOutput{
+		Target:       target,
+		ResolvedPath: resolved,
+		TargetExists: statErr == nil,
+		Success:      true,
+	}, nil
+}
+
 // editFile replaces old string with new string in a file
 func editFile(ctx context.Context, req *mcp.CallToolRequest, input editFileInput) (
 	*mcp.CallToolResult,
 	editFileOutput,
 	error,
 ) {
+	path, err := paths.Resolve(input.Path)
+	if err != nil {
+		return nil, editFileOutput{
+			Success: false,
+			Error:   err.Error(),
+		}, nil
+	}
+
 	// Read file content
-	content, err := os.ReadFile(input.Path)
+	content, err := os.ReadFile(path)
 	if err != nil {
 		return nil, editFileOutput{
 			Success: false,
@@ -233,7 +633,7 @@ func editFile(ctx context.Context, req *mcp.CallToolRequest, input editFileInput
 	}
 
 	// Write back
-	if err := os.WriteFile(input.Path, []byte(newContent), 0644); err != nil {
+	if err := os.WriteFile(path, []byte(newContent), 0644); err != nil {
 		return nil, editFileOutput{
 			Success: false,
 			Error:   err.Error(),
@@ -246,39 +646,29 @@ func editFile(ctx context.Context, req *mcp.CallToolRequest, input editFileInput
 	}, nil
 }
 
-// globFiles finds files by glob pattern
-func globFiles(ctx context.Context, req *mcp.CallToolRequest, input globFilesInput) (
-	*mcp.CallToolResult,
-	globFilesOutput,
-	error,
-) {
-	basePath := input.Path
+// matchFiles returns the regular files under basePath matching the glob
+// pattern, supporting ** for recursive matching
+func matchFiles(basePath, pat string) ([]string, error) {
 	if basePath == "" {
 		basePath = "."
 	}
+	if _, err := paths.Resolve(basePath); err != nil {
+		return nil, err
+	}
 
 	var matches []string
 
 	// Check if pattern contains ** for recursive matching
-	if strings.Contains(input.Pat, "**") {
+	if strings.Contains(pat, "**") {
 		// Use WalkDir for recursive matching
 		// Extract the pattern after ** for matching
-		patternParts := strings.Split(input.Pat, "**")
+		patternParts := strings.Split(pat, "**")
 		var suffix string
 		if len(patternParts) > 1 {
 			suffix = strings.TrimPrefix(patternParts[1], "/")
 		}
 
-		err := filepath.WalkDir(basePath, func(path string, d os.DirEntry, err error) error {
-			if err != nil {
-				return nil // Skip errors
-			}
-
-			// Skip directories
-			if d.IsDir() {
-				return nil
-			}
-
+		err := walkFiles(basePath, func(path string, d os.DirEntry) error {
 			// Get relative path for matching
 			relPath, err := filepath.Rel(basePath, path)
 			if err != nil {
@@ -309,24 +699,21 @@ func globFiles(ctx context.Context, req *mcp.CallToolRequest, input globFilesInp
 		})
 
 		if err != nil {
-			return nil, globFilesOutput{
-				Success: false,
-				Error:   err.Error(),
-			}, nil
+			return nil, err
 		}
 	} else {
 		// Use standard glob for simple patterns
-		pattern := filepath.Join(basePath, input.Pat)
+		pattern := filepath.Join(basePath, pat)
 		files, err := filepath.Glob(pattern)
 		if err != nil {
-			return nil, globFilesOutput{
-				Success: false,
-				Error:   err.Error(),
-			}, nil
+			return nil, err
 		}
 
-		// Filter out directories
+		// Filter out directories and paths leaving the root
 		for _, file := range files {
+			if outsideRoot(file) {
+				continue
+			}
 			info, err := os.Stat(file)
 			if err != nil {
 				continue
@@ -337,6 +724,88 @@ func globFiles(ctx context.Context, req *mcp.CallToolRequest, input globFilesInp
 		}
 	}
 
+	return matches, nil
+}
+
+// replaceInFiles applies the same string replacement to every file matching a glob pattern
+func replaceInFiles(ctx context.Context, req *mcp.CallToolRequest, input replaceInFilesInput) (
+	*mcp.CallToolResult,
+	replaceInFilesOutput,
+	error,
+) {
+	if input.Old == "" {
+		return nil, replaceInFilesOutput{
+			Success: false,
+			Error:   "old string must not be empty",
+		}, nil
+	}
+
+	files, err := matchFiles(input.Path, input.Pat)
+	if err != nil {
+		return nil, replaceInFilesOutput{
+			Success: false,
+			Error:   err.Error(),
+		}, nil
+	}
+	sort.Strings(files)
+
+	results := []fileReplacement{}
+	total := 0
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			results = append(results, fileReplacement{Path: file, Error: err.Error()})
+			continue
+		}
+
+		contentStr := string(content)
+		count := strings.Count(contentStr, input.Old)
+		if count == 0 {
+			continue
+		}
+
+		if count > 1 && !input.All {
+			results = append(results, fileReplacement{
+				Path:  file,
+				Error: fmt.Sprintf("old string appears %d times in file, use all=true to replace all occurrences", count),
+			})
+			continue
+		}
+
+		if !input.DryRun {
+			newContent := strings.ReplaceAll(contentStr, input.Old, input.New)
+			if err := os.WriteFile(file, []byte(newContent), 0644); err != nil {
+				results = append(results, fileReplacement{Path: file, Error: err.Error()})
+				continue
+			}
+		}
+
+		results = append(results, fileReplacement{Path: file, Replacements: count})
+		total += count
+	}
+
+	return nil, replaceInFilesOutput{
+		Files:   results,
+		Total:   total,
+		DryRun:  input.DryRun,
+		Success: true,
+	}, nil
+}
+
+// globFiles finds files by glob pattern
+func globFiles(ctx context.Context, req *mcp.CallToolRequest, input globFilesInput) (
+	*mcp.CallToolResult,
+	globFilesOutput,
+	error,
+) {
+	matches, err := matchFiles(input.Path, input.Pat)
+	if err != nil {
+		return nil, globFilesOutput{
+			Success: false,
+			Error:   err.Error(),
+		}, nil
+	}
+
 	// Sort by modification time (newest first)
 	type fileWithTime struct {
 		path    string
@@ -372,95 +841,43 @@ func globFiles(ctx context.Context, req *mcp.CallToolRequest, input globFilesInp
 	}, nil
 }
 
-// searchFileForPattern searches a file for regex pattern matches
-func searchFileForPattern(path string, re *regexp.Regexp, maxMatches int) []string {
-	var matches []string
-
-	file, err := os.Open(path)
-	if err != nil {
-		return matches // Skip files that can't be opened
-	}
-	defer file.Close()
-
-	scanner := bufio.NewScanner(file)
-	lineNum := 1
-	for scanner.Scan() {
-		if len(matches) >= maxMatches {
-			break
-		}
-
-		line := scanner.Text()
-		if re.MatchString(line) {
-			match := fmt.Sprintf("%s:%d:%s", path, lineNum, strings.TrimSpace(line))
-			matches = append(matches, match)
-		}
-		lineNum++
+func main() {
+	// Relative paths are resolved against the root
+	var rootErr error
+	paths, rootErr = safepath.New(os.Getenv("FILESYSTEM_ROOT"))
+	if rootErr == nil && paths.Root != "" {
+		rootErr = os.Chdir(paths.Root)
 	}
-
-	return matches
-}
-
-// grepFiles searches files for regex pattern
-func grepFiles(ctx context.Context, req *mcp.CallToolRequest, input grepFilesInput) (
-	*mcp.CallToolResult,
-	grepFilesOutput,
-	error,
-) {
-	// Compile regex
-	re, err := regexp.Compile(input.Pat)
-	if err != nil {
-		return nil, grepFilesOutput{
-			Success: false,
-			Error:   fmt.Sprintf("invalid regex pattern: %s", err.Error()),
-		}, nil
+	if rootErr != nil {
+		rootErr = fmt.Errorf("invalid FILESYSTEM_ROOT: %w", rootErr)
 	}
 
-	basePath := input.Path
-	if basePath == "" {
-		basePath = "."
-	}
-
-	var matches []string
-	const maxMatches = 50
-
-	// Walk directory tree
-	err = filepath.WalkDir(basePath, func(path string, d os.DirEntry, err error) error {
+	if dir := os.Getenv("FILESYSTEM_TRASH_DIR"); dir != "" {
+		abs, err := filepath.Abs(dir)
 		if err != nil {
-			return nil // Skip errors
+			log.Fatalf("Invalid FILESYSTEM_TRASH_DIR %q: %v", dir, err)
 		}
-
-		// Skip directories
-		if d.IsDir() {
-			return nil
+		trashDir = abs
+	}
+	grepWorkers = grepWorkersFromEnv()
+	grepMatchesLimit = grepMatchesLimitFromEnv()
+	followSymlinks = followSymlinksFromEnv()
+	maxFileBytes = maxFileBytesFromEnv()
+
+	if selfcheck.Enabled() {
+		var checks []selfcheck.Check
+		if paths.Root != "" || rootErr != nil {
+			checks = append(checks, selfcheck.Value("FILESYSTEM_ROOT", paths.Root, rootErr))
 		}
-
-		// Limit matches
-		if len(matches) >= maxMatches {
-			return filepath.SkipAll
+		if trashDir != "" {
+			checks = append(checks, selfcheck.Dir("FILESYSTEM_TRASH_DIR", trashDir))
 		}
-
-		// Process file and search for matches
-		fileMatches := searchFileForPattern(path, re, maxMatches-len(matches))
-		matches = append(matches, fileMatches...)
-
-		return nil
-	})
-
-	if err != nil {
-		return nil, grepFilesOutput{
-			Success: false,
-			Error:   err.Error(),
-		}, nil
+		selfcheck.Exit("filesystem", checks...)
+	}
+	if rootErr != nil {
+		log.Fatal(rootErr)
 	}
 
-	return nil, grepFilesOutput{
-		Matches: matches,
-		Count:   len(matches),
-		Success: true,
-	}, nil
-}
-
-func main() {
 	// Create MCP server for filesystem operations
 	server := mcp.NewServer(&mcp.Implementation{
 		Name:    "filesystem",
@@ -470,21 +887,82 @@ func main() {
 	// Add tool for reading files
 	mcp.AddTool(server, &mcp.Tool{
 		Name:        "read",
-		Description: "Read file with line numbers, supports optional offset and limit for reading specific line ranges",
+		Description: "Read file with line numbers, supports optional offset and limit for reading specific line ranges; binary files are refused unless force is set",
 	}, readFile)
 
+	// Add tools for cheap file inspection
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "head",
+		Description: "Return the first lines of a file with line numbers (default 10) without reading the rest of the file",
+	}, headFile)
+
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "wc",
+		Description: "Count lines, words and bytes of one or more files, like wc, with a total across all files",
+	}, wcFiles)
+
 	// Add tool for writing files
 	mcp.AddTool(server, &mcp.Tool{
 		Name:        "write",
 		Description: "Write content to a file, creates parent directories if needed, overwrites existing files",
 	}, writeFile)
 
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "write_template",
+		Description: "Render a Go text/template with vars and write the result to a file, variables are referenced as {{name}} or {{.name}}, fails listing the missing vars if the template references vars that are not provided, creates parent directories if needed, overwrites existing files",
+	}, writeTemplate)
+
 	// Add tool for editing files
 	mcp.AddTool(server, &mcp.Tool{
 		Name:        "edit",
 		Description: "Replace old string with new string in a file, old string must be unique unless all=true",
 	}, editFile)
 
+	// Add tool for project-wide replacements
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "replace_in_files",
+		Description: "Replace old string with new string in every file matching a glob pattern, returns per-file replacement counts, supports dry_run to preview changes",
+	}, replaceInFiles)
+
+	// Add tools for symbolic links
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "symlink",
+		Description: "Create a symbolic link at link_path pointing to target, creates parent directories if needed, fails if link_path already exists",
+	}, createSymlink)
+
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "readlink",
+		Description: "Read the target of a symbolic link, also reports the resolved path and whether the target exists",
+	}, readSymlink)
+
+	// Add tool for copying files and directory trees
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "copy",
+		Description: "Copy a file, or a directory recursively, from src to dst preserving file modes, creates parent directories if needed, fails if a destination file exists unless overwrite=true, returns the number of files copied",
+	}, copyPath)
+
+	// Add tool for deleting files, moved to the trash when one is configured
+	deleteDescription := "Delete a file or directory permanently, non-empty directories require recursive=true"
+	if trashDir != "" {
+		deleteDescription = "Delete a file or directory by moving it to the trash, non-empty directories require recursive=true, returns the trash name to pass to restore"
+	}
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "delete",
+		Description: deleteDescription,
+	}, deletePath)
+
+	if trashDir != "" {
+		mcp.AddTool(server, &mcp.Tool{
+			Name:        "restore",
+			Description: "Restore a deleted file or directory from the trash to its original path, by trash name or by original path (most recent delete)",
+		}, restoreFromTrash)
+
+		mcp.AddTool(server, &mcp.Tool{
+			Name:        "empty_trash",
+			Description: "Permanently remove entries from the trash, optionally only those deleted more than older_than hours ago",
+		}, emptyTrash)
+	}
+
 	// Add tool for glob file matching
 	mcp.AddTool(server, &mcp.Tool{
 		Name:        "glob",
@@ -494,11 +972,18 @@ func main() {
 	// Add tool for grep file search
 	mcp.AddTool(server, &mcp.Tool{
 		Name:        "grep",
-		Description: "Search files for regex pattern, returns up to 50 matches",
+		Description: "Search files for regex pattern, returns up to 50 matches unless max_matches is set; with before and after, returns that many context lines around each match, a match group counting once against the limit; with count_only, returns the number of matching lines of each file instead",
 	}, grepFiles)
 
+	// Add tool for running several operations in one call
+	handlers := batchHandlers()
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "batch",
+		Description: fmt.Sprintf("Run several filesystem operations in order in a single call, stopping at the first failure unless continue_on_error is set. Operations are not rolled back: those run before a failure keep their changes. Each operation names a tool (%s) with its arguments; returns the result of each", strings.Join(handlers.Names(), ", ")),
+	}, newBatchHandler(handlers))
+
 	// Run the server
-	if err := server.Run(context.Background(), &mcp.StdioTransport{}); err != nil {
+	if err := transport.Run(context.Background(), server); err != nil {
 		log.Fatal(err)
 	}
 }
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"regexp"
+
+	"github.com/mudler/mcps/pkg/safepath"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// setRoot confines the tools to a new root holding inside.txt, with a link
+// to a directory outside of it holding secret.txt, and returns both
+func setRoot() (root, outside string) {
+	root, outside = GinkgoT().TempDir(), GinkgoT().TempDir()
+	Expect(os.WriteFile(filepath.Join(root, "inside.txt"), []byte("needle inside\n"), 0644)).To(Succeed())
+	Expect(os.WriteFile(filepath.Join(outside, "secret.txt"), []byte("needle secret\n"), 0644)).To(Succeed())
+	Expect(os.Symlink(outside, filepath.Join(root, "escape"))).To(Succeed())
+	Expect(os.Symlink(filepath.Join(outside, "secret.txt"), filepath.Join(root, "secret-link.txt"))).To(Succeed())
+
+	guard, err := safepath.New(root)
+	Expect(err).NotTo(HaveOccurred())
+	DeferCleanup(func(previous safepath.Guard) { paths = previous }, paths)
+	paths = guard
+	return guard.Root, outside
+}
+
+var _ = Describe("Root confinement", func() {
+	var root, outside string
+
+	BeforeEach(func() {
+		root, outside = setRoot()
+	})
+
+	It("rejects paths outside the root", func() {
+		for _, path := range []string{
+			filepath.Join(outside, "secret.txt"),
+			filepath.Join(root, "..", filepath.Base(outside), "secret.txt"),
+			filepath.Join(root, "escape", "secret.txt"),
+			filepath.Join(root, "secret-link.txt"),
+		} {
+			_, out, err := readFile(context.Background(), nil, readFileInput{Path: path})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(out.Success).To(BeFalse(), path)
+			Expect(out.Error).To(ContainSubstring(safepath.ErrOutsideRoot.Error()))
+
+			_, written, err := writeFile(context.Background(), nil, writeFileInput{Path: path, Content: "overwritten"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(written.Success).To(BeFalse(), path)
+		}
+
+		Expect(os.ReadFile(filepath.Join(outside, "secret.txt"))).To(BeEquivalentTo("needle secret\n"))
+
+		_, err := paths.Resolve(filepath.Join(root, "inside.txt"))
+		Expect(err).NotTo(HaveOccurred())
+		_, err = paths.Resolve(filepath.Join(outside, "secret.txt"))
+		Expect(err).To(MatchError(safepath.ErrOutsideRoot))
+	})
+
+	It("skips links leading outside the root in searches", func() {
+		matches, err := grepTree(context.Background(), root, regexp.MustCompile("needle"), maxGrepMatches, 2)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(matches).To(HaveLen(1))
+		Expect(matches[0]).To(HavePrefix(filepath.Join(root, "inside.txt")))
+	})
+
+	DescribeTable("globs only match files inside the root",
+		func(pattern string) {
+			files, err := matchFiles(root, pattern)
+			Expect(err).NotTo(HaveOccurred())
+			for _, file := range files {
+				Expect(filepath.Base(file)).To(Equal("inside.txt"), pattern)
+			}
+		},
+		Entry("top level", "*.txt"),
+		Entry("through the link", "escape/*"),
+		Entry("recursive", "**/*.txt"),
+	)
+})
@@ -0,0 +1,197 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"go/token"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+	"text/template/parse"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// Input type for writing a rendered template
+type writeTemplateInput struct {
+	Path     string            `json:"path" jsonschema:"the file path to write to"`
+	Template string            `json:"template" jsonschema:"Go text/template source, variables are referenced as {{name}} or {{.name}}"`
+	Vars     map[string]string `json:"vars,omitempty" jsonschema:"values of the template variables"`
+}
+
+// Output type for write template operation
+type writeTemplateOutput struct {
+	Bytes   int      `json:"bytes" jsonschema:"size of the rendered file in bytes"`
+	Missing []string `json:"missing,omitempty" jsonschema:"variables referenced by the template but not provided"`
+	Success bool     `json:"success" jsonschema:"whether operation was successful"`
+	Error   string   `json:"error,omitempty" jsonschema:"error message if failed"`
+}
+
+// builtinTemplateFuncs are the functions predefined by text/template, vars
+// named like them are only reachable as {{.name}}
+var builtinTemplateFuncs = map[string]bool{
+	"and": true, "call": true, "html": true, "index": true, "slice": true,
+	"js": true, "len": true, "not": true, "or": true, "print": true,
+	"printf": true, "println": true, "urlquery": true,
+	"eq": true, "ge": true, "gt": true, "le": true, "lt": true, "ne": true,
+}
+
+// templateVars returns the variable names referenced by a template, both
+// as functions ({{name}}) and as fields of the root data ({{.name}})
+func templateVars(text string) ([]string, error) {
+	tree := parse.New("template")
+	tree.Mode = parse.SkipFuncCheck
+	trees := map[string]*parse.Tree{}
+	if _, err := tree.Parse(text, "", "", trees); err != nil {
+		return nil, err
+	}
+
+	seen := map[string]bool{}
+	var walk func(node parse.Node, rootDot bool)
+	walk = func(node parse.Node, rootDot bool) {
+		switch n := node.(type) {
+		case *parse.ListNode:
+			if n == nil {
+				return
+			}
+			for _, child := range n.Nodes {
+				walk(child, rootDot)
+			}
+		case *parse.ActionNode:
+			walk(n.Pipe, rootDot)
+		case *parse.PipeNode:
+			if n == nil {
+				return
+			}
+			for _, cmd := range n.Cmds {
+				walk(cmd, rootDot)
+			}
+		case *parse.CommandNode:
+			for _, arg := range n.Args {
+				walk(arg, rootDot)
+			}
+		case *parse.ChainNode:
+			walk(n.Node, rootDot)
+		case *parse.IdentifierNode:
+			if !builtinTemplateFuncs[n.Ident] {
+				seen[n.Ident] = true
+			}
+		case *parse.FieldNode:
+			// Inside range and with, dot is no longer the vars
+			if rootDot {
+				seen[n.Ident[0]] = true
+			}
+		case *parse.IfNode:
+			walk(n.Pipe, rootDot)
+			walk(n.List, rootDot)
+			walk(n.ElseList, rootDot)
+		case *parse.RangeNode:
+			walk(n.Pipe, rootDot)
+			walk(n.List, false)
+			walk(n.ElseList, rootDot)
+		case *parse.WithNode:
+			walk(n.Pipe, rootDot)
+			walk(n.List, false)
+			walk(n.ElseList, rootDot)
+		case *parse.TemplateNode:
+			walk(n.Pipe, rootDot)
+		}
+	}
+	for _, t := range trees {
+		walk(t.Root, true)
+	}
+
+	vars := make([]string, 0, len(seen))
+	for name := range seen {
+		vars = append(vars, name)
+	}
+	sort.Strings(vars)
+	return vars, nil
+}
+
+// renderTemplate executes the template with vars, each var being available
+// both as a function and as a field of dot
+func renderTemplate(text string, vars map[string]string) (string, error) {
+	funcs := template.FuncMap{}
+	for name, value := range vars {
+		if token.IsIdentifier(name) && !builtinTemplateFuncs[name] {
+			funcs[name] = func() string { return value }
+		}
+	}
+
+	tmpl, err := template.New("template").Funcs(funcs).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", err
+	}
+	var rendered strings.Builder
+	if err := tmpl.Execute(&rendered, vars); err != nil {
+		return "", err
+	}
+	return rendered.String(), nil
+}
+
+// writeTemplate renders a template with the given vars and writes the result
+func writeTemplate(ctx context.Context, req *mcp.CallToolRequest, input writeTemplateInput) (
+	*mcp.CallToolResult,
+	writeTemplateOutput,
+	error,
+) {
+	referenced, err := templateVars(input.Template)
+	if err != nil {
+		return nil, writeTemplateOutput{
+			Success: false,
+			Error:   fmt.Sprintf("invalid template: %v", err),
+		}, nil
+	}
+	var missing []string
+	for _, name := range referenced {
+		if _, ok := input.Vars[name]; !ok {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		return nil, writeTemplateOutput{
+			Missing: missing,
+			Success: false,
+			Error:   fmt.Sprintf("missing vars: %s", strings.Join(missing, ", ")),
+		}, nil
+	}
+
+	rendered, err := renderTemplate(input.Template, input.Vars)
+	if err != nil {
+		return nil, writeTemplateOutput{
+			Success: false,
+			Error:   err.Error(),
+		}, nil
+	}
+
+	path, err := paths.Resolve(input.Path)
+	if err != nil {
+		return nil, writeTemplateOutput{
+			Success: false,
+			Error:   err.Error(),
+		}, nil
+	}
+
+	// Create parent directories if needed
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, writeTemplateOutput{
+			Success: false,
+			Error:   err.Error(),
+		}, nil
+	}
+
+	if err := os.WriteFile(path, []byte(rendered), 0644); err != nil {
+		return nil, writeTemplateOutput{
+			Success: false,
+			Error:   err.Error(),
+		}, nil
+	}
+
+	return nil, writeTemplateOutput{
+		Bytes:   len(rendered),
+		Success: true,
+	}, nil
+}
@@ -0,0 +1,368 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// trashDir is where delete moves files to, set with FILESYSTEM_TRASH_DIR.
+// Deletes are permanent when it is empty.
+//
+// Trashed paths are kept under files/ with a timestamped name, and a JSON
+// record of their original location under info/ with the same name.
+var trashDir string
+
+const trashTimeLayout = "20060102T150405.000000000"
+
+// trashInfo records where a trashed path came from
+type trashInfo struct {
+	Path      string    `json:"path"`
+	DeletedAt time.Time `json:"deleted_at"`
+}
+
+// Input type for delete operation
+type deleteInput struct {
+	Path      string `json:"path" jsonschema:"the file or directory to delete"`
+	Recursive bool   `json:"recursive,omitempty" jsonschema:"optional delete non-empty directories (default: false)"`
+}
+
+// Output type for delete operation
+type deleteOutput struct {
+	TrashName string `json:"trash_name,omitempty" jsonschema:"name of the entry in the trash, pass it to restore to undo the delete (empty when the delete was permanent)"`
+	Success   bool   `json:"success" jsonschema:"whether operation was successful"`
+	Error     string `json:"error,omitempty" jsonschema:"error message if failed"`
+}
+
+// Input type for restore operation
+type restoreInput struct {
+	Name string `json:"name,omitempty" jsonschema:"the trash entry to restore, as returned by delete"`
+	Path string `json:"path,omitempty" jsonschema:"the original path to restore, the most recent delete of that path is restored (used when name is empty)"`
+}
+
+// Output type for restore operation
+type restoreOutput struct {
+	Path    string `json:"path" jsonschema:"the path the entry was restored to"`
+	Success bool   `json:"success" jsonschema:"whether operation was successful"`
+	Error   string `json:"error,omitempty" jsonschema:"error message if failed"`
+}
+
+// Input type for empty trash operation
+type emptyTrashInput struct {
+	OlderThan float64 `json:"older_than,omitempty" jsonschema:"optional only remove entries deleted more than this many hours ago (default: remove everything)"`
+}
+
+// Output type for empty trash operation
+type emptyTrashOutput struct {
+	Removed int    `json:"removed" jsonschema:"number of trash entries permanently removed"`
+	Success bool   `json:"success" jsonschema:"whether operation was successful"`
+	Error   string `json:"error,omitempty" jsonschema:"error message if failed"`
+}
+
+func trashFilesDir() string { return filepath.Join(trashDir, "files") }
+func trashInfoDir() string  { return filepath.Join(trashDir, "info") }
+
+// contains reports whether path is dir or inside it
+func contains(dir, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// insideTrash reports whether path is the trash directory or inside it
+func insideTrash(path string) bool {
+	return contains(trashDir, path)
+}
+
+// moveToTrash moves path into the trash and returns its trash entry name
+func moveToTrash(path string, now time.Time) (string, error) {
+	for _, dir := range []string{trashFilesDir(), trashInfoDir()} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return "", err
+		}
+	}
+
+	// Deleting the same name twice in the same instant is unlikely but
+	// must not overwrite the first entry
+	base := now.UTC().Format(trashTimeLayout) + "-" + filepath.Base(path)
+	name := base
+	for i := 1; ; i++ {
+		if _, err := os.Lstat(filepath.Join(trashFilesDir(), name)); os.IsNotExist(err) {
+			break
+		}
+		name = fmt.Sprintf("%s.%d", base, i)
+	}
+
+	info, err := json.Marshal(trashInfo{Path: path, DeletedAt: now.UTC()})
+	if err != nil {
+		return "", err
+	}
+	infoPath := filepath.Join(trashInfoDir(), name+".json")
+	if err := os.WriteFile(infoPath, info, 0644); err != nil {
+		return "", err
+	}
+	if err := os.Rename(path, filepath.Join(trashFilesDir(), name)); err != nil {
+		os.Remove(infoPath)
+		return "", fmt.Errorf("failed to move %s to the trash (FILESYSTEM_TRASH_DIR must be on the same filesystem): %w", path, err)
+	}
+	return name, nil
+}
+
+// readTrashInfo returns the record of a trash entry
+func readTrashInfo(name string) (trashInfo, error) {
+	var info trashInfo
+	data, err := os.ReadFile(filepath.Join(trashInfoDir(), name+".json"))
+	if err != nil {
+		return info, err
+	}
+	err = json.Unmarshal(data, &info)
+	return info, err
+}
+
+// trashEntries returns the names of the entries in the trash, oldest first
+func trashEntries() ([]string, error) {
+	entries, err := os.ReadDir(trashFilesDir())
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	// Names start with the deletion time
+	sort.Strings(names)
+	return names, nil
+}
+
+// deletePath removes a file or directory, moving it to the trash when
+// FILESYSTEM_TRASH_DIR is set
+func deletePath(ctx context.Context, req *mcp.CallToolRequest, input deleteInput) (
+	*mcp.CallToolResult,
+	deleteOutput,
+	error,
+) {
+	if input.Path == "" {
+		return nil, deleteOutput{
+			Success: false,
+			Error:   "path is required",
+		}, nil
+	}
+
+	path, err := paths.ResolveLink(input.Path)
+	if err != nil {
+		return nil, deleteOutput{
+			Success: false,
+			Error:   err.Error(),
+		}, nil
+	}
+	info, err := os.Lstat(path)
+	if err != nil {
+		return nil, deleteOutput{
+			Success: false,
+			Error:   err.Error(),
+		}, nil
+	}
+
+	// Refuse non-empty directories unless asked, whether trashing or not
+	if info.IsDir() && !input.Recursive {
+		entries, err := os.ReadDir(path)
+		if err != nil {
+			return nil, deleteOutput{
+				Success: false,
+				Error:   err.Error(),
+			}, nil
+		}
+		if len(entries) > 0 {
+			return nil, deleteOutput{
+				Success: false,
+				Error:   fmt.Sprintf("%s is a non-empty directory, use recursive=true to delete it", input.Path),
+			}, nil
+		}
+	}
+
+	if trashDir == "" {
+		if err := os.RemoveAll(path); err != nil {
+			return nil, deleteOutput{
+				Success: false,
+				Error:   err.Error(),
+			}, nil
+		}
+		return nil, deleteOutput{
+			Success: true,
+		}, nil
+	}
+
+	if insideTrash(path) {
+		return nil, deleteOutput{
+			Success: false,
+			Error:   "cannot delete the trash directory or its contents, use empty_trash instead",
+		}, nil
+	}
+	if contains(path, trashDir) {
+		return nil, deleteOutput{
+			Success: false,
+			Error:   fmt.Sprintf("%s contains the trash directory", input.Path),
+		}, nil
+	}
+
+	name, err := moveToTrash(path, time.Now())
+	if err != nil {
+		return nil, deleteOutput{
+			Success: false,
+			Error:   err.Error(),
+		}, nil
+	}
+
+	return nil, deleteOutput{
+		TrashName: name,
+		Success:   true,
+	}, nil
+}
+
+// restoreFromTrash moves a trash entry back to its original path
+func restoreFromTrash(ctx context.Context, req *mcp.CallToolRequest, input restoreInput) (
+	*mcp.CallToolResult,
+	restoreOutput,
+	error,
+) {
+	name := input.Name
+	if name == "" {
+		if input.Path == "" {
+			return nil, restoreOutput{
+				Success: false,
+				Error:   "name or path is required",
+			}, nil
+		}
+		path, err := paths.ResolveLink(input.Path)
+		if err != nil {
+			return nil, restoreOutput{
+				Success: false,
+				Error:   err.Error(),
+			}, nil
+		}
+		names, err := trashEntries()
+		if err != nil {
+			return nil, restoreOutput{
+				Success: false,
+				Error:   err.Error(),
+			}, nil
+		}
+		// Newest first, so the latest delete of the path wins
+		for i := len(names) - 1; i >= 0; i-- {
+			if info, err := readTrashInfo(names[i]); err == nil && info.Path == path {
+				name = names[i]
+				break
+			}
+		}
+		if name == "" {
+			return nil, restoreOutput{
+				Success: false,
+				Error:   fmt.Sprintf("%s not found in the trash", input.Path),
+			}, nil
+		}
+	}
+	if name != filepath.Base(name) || name == "." || name == ".." {
+		return nil, restoreOutput{
+			Success: false,
+			Error:   fmt.Sprintf("invalid trash entry name %q", name),
+		}, nil
+	}
+
+	info, err := readTrashInfo(name)
+	if err != nil {
+		return nil, restoreOutput{
+			Success: false,
+			Error:   fmt.Sprintf("trash entry %q not found", name),
+		}, nil
+	}
+	// The entry may have been deleted before FILESYSTEM_ROOT was set
+	if _, err := paths.ResolveLink(info.Path); err != nil {
+		return nil, restoreOutput{
+			Success: false,
+			Error:   err.Error(),
+		}, nil
+	}
+	if _, err := os.Lstat(info.Path); err == nil {
+		return nil, restoreOutput{
+			Success: false,
+			Error:   fmt.Sprintf("%s already exists, move it away before restoring", info.Path),
+		}, nil
+	}
+
+	// Create parent directories if needed
+	if err := os.MkdirAll(filepath.Dir(info.Path), 0755); err != nil {
+		return nil, restoreOutput{
+			Success: false,
+			Error:   err.Error(),
+		}, nil
+	}
+	if err := os.Rename(filepath.Join(trashFilesDir(), name), info.Path); err != nil {
+		return nil, restoreOutput{
+			Success: false,
+			Error:   err.Error(),
+		}, nil
+	}
+	os.Remove(filepath.Join(trashInfoDir(), name+".json"))
+
+	return nil, restoreOutput{
+		Path:    info.Path,
+		Success: true,
+	}, nil
+}
+
+// emptyTrash permanently removes trash entries
+func emptyTrash(ctx context.Context, req *mcp.CallToolRequest, input emptyTrashInput) (
+	*mcp.CallToolResult,
+	emptyTrashOutput,
+	error,
+) {
+	if input.OlderThan < 0 {
+		return nil, emptyTrashOutput{
+			Success: false,
+			Error:   "older_than must not be negative",
+		}, nil
+	}
+
+	names, err := trashEntries()
+	if err != nil {
+		return nil, emptyTrashOutput{
+			Success: false,
+			Error:   err.Error(),
+		}, nil
+	}
+
+	cutoff := time.Now().Add(-time.Duration(input.OlderThan * float64(time.Hour)))
+	removed := 0
+	for _, name := range names {
+		if input.OlderThan > 0 {
+			// Entries without a record are kept, their age is unknown
+			info, err := readTrashInfo(name)
+			if err != nil || info.DeletedAt.After(cutoff) {
+				continue
+			}
+		}
+		if err := os.RemoveAll(filepath.Join(trashFilesDir(), name)); err != nil {
+			return nil, emptyTrashOutput{
+				Removed: removed,
+				Success: false,
+				Error:   err.Error(),
+			}, nil
+		}
+		os.Remove(filepath.Join(trashInfoDir(), name+".json"))
+		removed++
+	}
+
+	return nil, emptyTrashOutput{
+		Removed: removed,
+		Success: true,
+	}, nil
+}
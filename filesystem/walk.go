@@ -0,0 +1,103 @@
+package main
+
+import (
+	"errors"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// followSymlinks is set with FILESYSTEM_FOLLOW_SYMLINKS
+var followSymlinks bool
+
+// followSymlinksFromEnv reads FILESYSTEM_FOLLOW_SYMLINKS, not following
+// symlinked directories by default
+func followSymlinksFromEnv() bool {
+	value := os.Getenv("FILESYSTEM_FOLLOW_SYMLINKS")
+	if value == "" {
+		return false
+	}
+	follow, err := strconv.ParseBool(value)
+	if err != nil {
+		log.Printf("Warning: invalid FILESYSTEM_FOLLOW_SYMLINKS %q, not following symlinked directories", value)
+		return false
+	}
+	return follow
+}
+
+// walkFiles calls fn for every file under basePath in lexical order, as
+// filepath.WalkDir does, skipping unreadable entries and symlinks leading
+// outside the root. Symlinked directories are skipped unless followSymlinks
+// is set, in which case each directory is walked once, by its real path, so
+// link cycles end and files reachable through several links are not
+// reported twice. fn may return filepath.SkipAll to stop the walk.
+func walkFiles(basePath string, fn func(path string, d os.DirEntry) error) error {
+	var err error
+	if followSymlinks {
+		err = walkFollowing(basePath, map[string]bool{}, fn)
+	} else {
+		err = filepath.WalkDir(basePath, func(path string, d os.DirEntry, err error) error {
+			if err != nil {
+				return nil // Skip errors
+			}
+			if d.IsDir() || walkedOutsideRoot(path, d) || linkedDir(path, d) {
+				return nil
+			}
+			return fn(path, d)
+		})
+	}
+	if errors.Is(err, filepath.SkipAll) {
+		return nil
+	}
+	return err
+}
+
+// walkFollowing walks dir following symlinked directories, visited holding
+// the real paths of the directories already walked
+func walkFollowing(dir string, visited map[string]bool, fn func(path string, d os.DirEntry) error) error {
+	real, err := filepath.EvalSymlinks(dir)
+	if err != nil || visited[real] {
+		return nil
+	}
+	visited[real] = true
+
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil
+	}
+	if !info.IsDir() {
+		return fn(dir, fs.FileInfoToDirEntry(info))
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil // Skip errors
+	}
+	for _, entry := range entries {
+		path := filepath.Join(dir, entry.Name())
+		if walkedOutsideRoot(path, entry) {
+			continue
+		}
+		if entry.IsDir() || linkedDir(path, entry) {
+			if err := walkFollowing(path, visited, fn); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := fn(path, entry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// linkedDir reports whether a walked entry is a symlink to a directory
+func linkedDir(path string, d os.DirEntry) bool {
+	if d.Type()&os.ModeSymlink == 0 {
+		return false
+	}
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"regexp"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// writeLinkedTree creates a tree with a symlink cycle and a second link to
+// the same directory:
+//
+//	a/file.txt
+//	a/loop -> ..
+//	b -> a
+func writeLinkedTree() string {
+	root := GinkgoT().TempDir()
+	Expect(os.MkdirAll(filepath.Join(root, "a"), 0755)).To(Succeed())
+	Expect(os.WriteFile(filepath.Join(root, "a", "file.txt"), []byte("needle\n"), 0644)).To(Succeed())
+	Expect(os.Symlink("..", filepath.Join(root, "a", "loop"))).To(Succeed())
+	Expect(os.Symlink("a", filepath.Join(root, "b"))).To(Succeed())
+	return root
+}
+
+var _ = Describe("Walking symlinks", func() {
+	var root string
+
+	BeforeEach(func() {
+		root = writeLinkedTree()
+	})
+
+	It("skips symlinked directories by default", func() {
+		files, err := matchFiles(root, "**/*")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(files).To(Equal([]string{filepath.Join(root, "a", "file.txt")}))
+	})
+
+	It("follows each symlinked directory once when enabled", func() {
+		DeferCleanup(func(follow bool) { followSymlinks = follow }, followSymlinks)
+		followSymlinks = true
+
+		files, err := matchFiles(root, "**/*.txt")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(files).To(Equal([]string{filepath.Join(root, "a", "file.txt")}))
+
+		matches, err := grepTree(context.Background(), root, regexp.MustCompile("needle"), maxGrepMatches, 2)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(matches).To(HaveLen(1))
+
+		// Reached through a link first, the directory is still walked once
+		linked := GinkgoT().TempDir()
+		Expect(os.Symlink(filepath.Join(root, "a"), filepath.Join(linked, "0-link"))).To(Succeed())
+		Expect(os.Symlink(root, filepath.Join(linked, "1-root"))).To(Succeed())
+		files, err = matchFiles(linked, "**/*.txt")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(files).To(Equal([]string{filepath.Join(linked, "0-link", "file.txt")}))
+	})
+})
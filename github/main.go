@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"log"
 	"net/http"
 	"os"
@@ -10,6 +11,8 @@ import (
 	"time"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/mudler/mcps/pkg/selfcheck"
+	"github.com/mudler/mcps/pkg/transport"
 )
 
 const (
@@ -30,12 +33,14 @@ func main() {
 	}
 	apiURL = strings.TrimRight(apiURL, "/")
 
+	var commentLenErr error
 	if v := os.Getenv("GITHUB_MAX_COMMENT_LENGTH"); v != "" {
 		n, err := strconv.Atoi(v)
 		if err != nil {
-			log.Fatalf("GITHUB_MAX_COMMENT_LENGTH must be an integer: %v", err)
+			commentLenErr = fmt.Errorf("GITHUB_MAX_COMMENT_LENGTH must be an integer: %v", err)
+		} else {
+			maxCommentLen = n
 		}
-		maxCommentLen = n
 	}
 
 	client = &GitHubClient{
@@ -46,6 +51,13 @@ func main() {
 		},
 	}
 
+	if selfcheck.Enabled() {
+		selfcheck.Exit("github", selfChecks(commentLenErr)...)
+	}
+	if commentLenErr != nil {
+		log.Fatal(commentLenErr)
+	}
+
 	server := mcp.NewServer(&mcp.Implementation{Name: "github", Version: "v1.0.0"}, nil)
 
 	enabled := enabledToolSet(os.Getenv("GITHUB_TOOLS"))
@@ -64,7 +76,7 @@ func main() {
 		}, GetPullRequest)
 	}
 
-	if err := server.Run(context.Background(), &mcp.StdioTransport{}); err != nil {
+	if err := transport.Run(context.Background(), server); err != nil {
 		log.Fatal(err)
 	}
 }
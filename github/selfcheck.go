@@ -0,0 +1,41 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/mudler/mcps/pkg/selfcheck"
+)
+
+// selfChecks reports the configuration and queries the rate limit endpoint,
+// which shows whether the token is accepted without using up quota
+func selfChecks(commentLenErr error) []selfcheck.Check {
+	return []selfcheck.Check{
+		selfcheck.Value("GITHUB_MAX_COMMENT_LENGTH", strconv.Itoa(maxCommentLen), commentLenErr),
+		{Name: "GITHUB_TOKEN", Run: func(context.Context) (string, error) {
+			if os.Getenv("GITHUB_TOKEN") == "" {
+				return "", selfcheck.Warn(fmt.Errorf("not set, requests are unauthenticated and limited to 60 per hour"))
+			}
+			return "set", nil
+		}},
+		{Name: "api", Run: func(ctx context.Context) (string, error) {
+			body, _, err := client.Get(ctx, "/rate_limit", nil)
+			if err != nil {
+				return "", fmt.Errorf("%s: %w", client.BaseURL, err)
+			}
+			var limits struct {
+				Rate struct {
+					Limit     int `json:"limit"`
+					Remaining int `json:"remaining"`
+				} `json:"rate"`
+			}
+			if err := json.Unmarshal(body, &limits); err != nil {
+				return "", fmt.Errorf("parsing rate limit: %w", err)
+			}
+			return fmt.Sprintf("%s reachable, %d of %d requests remaining", client.BaseURL, limits.Rate.Remaining, limits.Rate.Limit), nil
+		}},
+	}
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	ha "github.com/mkelcik/go-ha-client"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// domainServices are the services set_domain_state can call
+var domainServices = map[string]bool{"turn_on": true, "turn_off": true, "toggle": true}
+
+type SetDomainStateInput struct {
+	Domain  string `json:"domain" jsonschema:"the domain of the entities (e.g., 'light', 'switch')"`
+	Service string `json:"service" jsonschema:"turn_on, turn_off or toggle"`
+	Area    string `json:"area,omitempty" jsonschema:"only the entities in this area, by name or ID (e.g., 'Kitchen')"`
+	Name    string `json:"name,omitempty" jsonschema:"only the entities whose entity ID or friendly name contains this text"`
+}
+
+// DomainEntityResult is the outcome of the service call on one entity
+type DomainEntityResult struct {
+	EntityID     string      `json:"entity_id" jsonschema:"the entity ID"`
+	FriendlyName interface{} `json:"friendly_name,omitempty" jsonschema:"friendly name if available"`
+	Success      bool        `json:"success" jsonschema:"whether the service call on the entity succeeded"`
+	Message      string      `json:"message,omitempty" jsonschema:"the error of a failed call"`
+}
+
+type SetDomainStateOutput struct {
+	Success bool                 `json:"success" jsonschema:"whether the service was called on every matching entity"`
+	Message string               `json:"message" jsonschema:"status message"`
+	Results []DomainEntityResult `json:"results" jsonschema:"the outcome for each matching entity"`
+	Count   int                  `json:"count" jsonschema:"number of matching entities"`
+	Failed  int                  `json:"failed" jsonschema:"number of entities the service call failed on"`
+}
+
+// SetDomainState calls turn_on, turn_off or toggle on every entity of a
+// domain, optionally restricted to an area or a name
+func SetDomainState(ctx context.Context, req *mcp.CallToolRequest, input SetDomainStateInput) (
+	*mcp.CallToolResult,
+	SetDomainStateOutput,
+	error,
+) {
+	if input.Domain == "" {
+		return nil, SetDomainStateOutput{}, fmt.Errorf("domain is required")
+	}
+	if !domainServices[input.Service] {
+		return nil, SetDomainStateOutput{}, fmt.Errorf("service must be turn_on, turn_off or toggle, got %q", input.Service)
+	}
+
+	states, err := client.GetStates(ctx)
+	if err != nil {
+		return nil, SetDomainStateOutput{}, fmt.Errorf("failed to get states: %w", err)
+	}
+	cacheStates(states)
+
+	var inArea map[string]bool
+	if input.Area != "" {
+		inArea, err = areaEntities(ctx, input.Area)
+		if err != nil {
+			return nil, SetDomainStateOutput{}, err
+		}
+	}
+
+	name := strings.ToLower(input.Name)
+	results := []DomainEntityResult{}
+	failed := 0
+	for _, state := range states {
+		if !strings.HasPrefix(state.EntityId, input.Domain+".") {
+			continue
+		}
+		if inArea != nil && !inArea[state.EntityId] {
+			continue
+		}
+		friendlyName, _ := state.Attributes["friendly_name"].(string)
+		if name != "" && !strings.Contains(strings.ToLower(state.EntityId), name) && !strings.Contains(strings.ToLower(friendlyName), name) {
+			continue
+		}
+
+		result := DomainEntityResult{EntityID: state.EntityId, FriendlyName: state.Attributes["friendly_name"], Success: true}
+		_, err := client.CallService(ctx, ha.DefaultServiceCmd{
+			Domain:   input.Domain,
+			Service:  input.Service,
+			EntityId: state.EntityId,
+		})
+		if err != nil {
+			result.Success = false
+			result.Message = fmt.Sprintf("Failed to call service: %v", err)
+			failed++
+		}
+		results = append(results, result)
+	}
+
+	output := SetDomainStateOutput{
+		Success: len(results) > 0 && failed == 0,
+		Results: results,
+		Count:   len(results),
+		Failed:  failed,
+	}
+	switch {
+	case len(results) == 0:
+		output.Message = fmt.Sprintf("No %s entities match", input.Domain)
+	case failed > 0:
+		output.Message = fmt.Sprintf("Called %s.%s on %d of %d entities, %d failed", input.Domain, input.Service, len(results)-failed, len(results), failed)
+	default:
+		output.Message = fmt.Sprintf("Successfully called %s.%s on %d entities", input.Domain, input.Service, len(results))
+	}
+
+	return nil, output, nil
+}
+
+// areaEntities returns the entity IDs of an area, by name or ID. The REST
+// API has no area registry, so they are listed with a template.
+func areaEntities(ctx context.Context, area string) (map[string]bool, error) {
+	quoted, err := json.Marshal(area)
+	if err != nil {
+		return nil, err
+	}
+	rendered, err := client.RenderTemplate(ctx, fmt.Sprintf("{{ area_entities(%s) | tojson }}", quoted))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the entities of area %q: %w", area, err)
+	}
+	var entityIDs []string
+	if err := json.Unmarshal([]byte(rendered), &entityIDs); err != nil {
+		return nil, fmt.Errorf("failed to parse the entities of area %q: %w", area, err)
+	}
+	result := make(map[string]bool, len(entityIDs))
+	for _, entityID := range entityIDs {
+		result[entityID] = true
+	}
+	return result, nil
+}
@@ -2,15 +2,20 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
+	"strconv"
 	"strings"
 	"time"
 
 	ha "github.com/mkelcik/go-ha-client"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/mudler/mcps/pkg/mock"
+	"github.com/mudler/mcps/pkg/selfcheck"
+	"github.com/mudler/mcps/pkg/transport"
 )
 
 // Global Home Assistant client
@@ -24,9 +29,21 @@ type GetServicesInput struct {
 }
 
 type CallServiceInput struct {
-	Domain   string `json:"domain" jsonschema:"the domain of the service (e.g., 'switch', 'light')"`
-	Service  string `json:"service" jsonschema:"the service name (e.g., 'turn_on', 'turn_off')"`
-	EntityID string `json:"entity_id" jsonschema:"the entity ID (e.g., 'switch.switch_1')"`
+	Domain         string                 `json:"domain" jsonschema:"the domain of the service (e.g., 'switch', 'light')"`
+	Service        string                 `json:"service" jsonschema:"the service name (e.g., 'turn_on', 'turn_off')"`
+	EntityID       string                 `json:"entity_id" jsonschema:"the entity ID (e.g., 'switch.switch_1') or its friendly name (e.g., 'living room lamp')"`
+	Data           map[string]interface{} `json:"data,omitempty" jsonschema:"optional service data (e.g., {'brightness': 120}, or {'type': 'daily'} for weather.get_forecasts)"`
+	ReturnResponse bool                   `json:"return_response,omitempty" jsonschema:"capture the data returned by the service, e.g. for calendar.get_events or weather.get_forecasts; fails for services that do not return data"`
+}
+
+type SetStateInput struct {
+	EntityID   string                 `json:"entity_id" jsonschema:"the entity ID to write (e.g., 'input_boolean.vacation_mode', 'sensor.agent_status')"`
+	State      string                 `json:"state" jsonschema:"the new state value"`
+	Attributes map[string]interface{} `json:"attributes,omitempty" jsonschema:"optional attributes, merged over the entity's current attributes"`
+}
+
+type GetStatesInput struct {
+	EntityIDs []string `json:"entity_ids" jsonschema:"the entity IDs or friendly names to fetch (e.g., ['light.living_room', 'outdoor temperature'])"`
 }
 
 type SearchEntitiesInput struct {
@@ -81,8 +98,37 @@ type GetServicesOutput struct {
 }
 
 type CallServiceOutput struct {
-	Success bool   `json:"success" jsonschema:"whether the call was successful"`
-	Message string `json:"message" jsonschema:"status message"`
+	Success    bool            `json:"success" jsonschema:"whether the call was successful"`
+	Message    string          `json:"message" jsonschema:"status message"`
+	EntityID   string          `json:"entity_id,omitempty" jsonschema:"the entity ID the friendly name was resolved to"`
+	Candidates []EntitySummary `json:"candidates,omitempty" jsonschema:"the entities matching an ambiguous friendly name; call again with one of their IDs"`
+	Response   interface{}     `json:"response,omitempty" jsonschema:"the data returned by the service, with return_response"`
+}
+
+type SetStateOutput struct {
+	Success    bool                   `json:"success" jsonschema:"whether the state was written"`
+	Created    bool                   `json:"created" jsonschema:"whether the entity did not exist before and was created"`
+	EntityID   string                 `json:"entity_id" jsonschema:"the entity ID"`
+	State      string                 `json:"state" jsonschema:"the state stored by Home Assistant"`
+	Attributes map[string]interface{} `json:"attributes,omitempty" jsonschema:"the attributes stored by Home Assistant"`
+	Message    string                 `json:"message" jsonschema:"status message"`
+}
+
+// EntityState is the full state of a single entity, including all attributes.
+type EntityState struct {
+	EntityID   string                 `json:"entity_id" jsonschema:"the entity ID"`
+	Domain     string                 `json:"domain" jsonschema:"domain of the entity"`
+	State      string                 `json:"state" jsonschema:"current state"`
+	Attributes map[string]interface{} `json:"attributes" jsonschema:"all attributes of the entity"`
+}
+
+type GetStatesOutput struct {
+	Entities   []EntityState              `json:"entities" jsonschema:"the requested entities that exist, in request order"`
+	Count      int                        `json:"count" jsonschema:"number of entities returned"`
+	Resolved   map[string]string          `json:"resolved,omitempty" jsonschema:"entity IDs the requested friendly names were resolved to, by name"`
+	NotFound   []string                   `json:"not_found,omitempty" jsonschema:"requested entity IDs or names that Home Assistant does not know about"`
+	Candidates map[string][]EntitySummary `json:"candidates,omitempty" jsonschema:"the entities matching each ambiguous friendly name, by name"`
+	Note       string                     `json:"note,omitempty" jsonschema:"explanation when some entity IDs were skipped"`
 }
 
 type SearchEntitiesOutput struct {
@@ -163,6 +209,53 @@ func CallService(ctx context.Context, req *mcp.CallToolRequest, input CallServic
 	CallServiceOutput,
 	error,
 ) {
+	// Resolve friendly names such as "living room lamp" to an entity ID
+	if input.EntityID != "" && !isEntityID(input.EntityID) {
+		states, err := cachedStates(ctx)
+		if err != nil {
+			return nil, CallServiceOutput{}, err
+		}
+		entityID, err := resolveEntity(input.EntityID, input.Domain, states)
+		if err != nil {
+			output := CallServiceOutput{Success: false, Message: err.Error()}
+			var ambiguous *ambiguousEntityError
+			if errors.As(err, &ambiguous) {
+				output.Candidates = ambiguous.candidates
+			}
+			return nil, output, nil
+		}
+		input.EntityID = entityID
+	}
+
+	// go-ha-client only sends entity_id, service data and responses need
+	// the REST API directly
+	if input.ReturnResponse || len(input.Data) > 0 {
+		data := map[string]interface{}{}
+		for k, v := range input.Data {
+			data[k] = v
+		}
+		if input.EntityID != "" {
+			data["entity_id"] = input.EntityID
+		}
+		response, changed, err := callServiceREST(ctx, input.Domain, input.Service, data, input.ReturnResponse)
+		if err != nil {
+			return nil, CallServiceOutput{
+				Success: false,
+				Message: fmt.Sprintf("Failed to call service: %v", err),
+			}, nil
+		}
+		target := ""
+		if input.EntityID != "" {
+			target = " on entity " + input.EntityID
+		}
+		return nil, CallServiceOutput{
+			Success:  true,
+			Message:  fmt.Sprintf("Successfully called %s.%s%s (%d states changed)", input.Domain, input.Service, target, changed),
+			EntityID: input.EntityID,
+			Response: response,
+		}, nil
+	}
+
 	// Prepare the service command
 	cmd := ha.DefaultServiceCmd{
 		Domain:   input.Domain,
@@ -180,13 +273,154 @@ func CallService(ctx context.Context, req *mcp.CallToolRequest, input CallServic
 	}
 
 	output := CallServiceOutput{
-		Success: true,
-		Message: fmt.Sprintf("Successfully called %s.%s on entity %s", input.Domain, input.Service, input.EntityID),
+		Success:  true,
+		Message:  fmt.Sprintf("Successfully called %s.%s on entity %s", input.Domain, input.Service, input.EntityID),
+		EntityID: input.EntityID,
 	}
 
 	return nil, output, nil
 }
 
+// SetState writes an entity state directly into the Home Assistant state
+// machine. This does not talk to any device: it only changes what Home
+// Assistant reports until the owning integration updates the entity again.
+func SetState(ctx context.Context, req *mcp.CallToolRequest, input SetStateInput) (
+	*mcp.CallToolResult,
+	SetStateOutput,
+	error,
+) {
+	if input.EntityID == "" || !strings.Contains(input.EntityID, ".") {
+		return nil, SetStateOutput{}, fmt.Errorf("entity_id must be in the form 'domain.object_id'")
+	}
+
+	// Posting a state replaces all attributes, so keep the current ones
+	attributes := map[string]interface{}{}
+	if current, err := client.GetStateForEntity(ctx, input.EntityID); err == nil {
+		for k, v := range current.Attributes {
+			attributes[k] = v
+		}
+	}
+	for k, v := range input.Attributes {
+		attributes[k] = v
+	}
+
+	resp, err := client.CreateState(ctx, input.EntityID, ha.State{
+		State:      input.State,
+		Attributes: attributes,
+	})
+	if err != nil {
+		return nil, SetStateOutput{
+			Success:  false,
+			EntityID: input.EntityID,
+			Message:  fmt.Sprintf("Failed to set state: %v", err),
+		}, nil
+	}
+	if !resp.Created() && !resp.Updated() {
+		return nil, SetStateOutput{
+			Success:  false,
+			EntityID: input.EntityID,
+			Message:  fmt.Sprintf("Failed to set state: Home Assistant returned HTTP %d", resp.CreateCode),
+		}, nil
+	}
+
+	output := SetStateOutput{
+		Success:    true,
+		Created:    resp.Created(),
+		EntityID:   input.EntityID,
+		State:      resp.State.State,
+		Attributes: resp.Attributes,
+		Message:    fmt.Sprintf("State of %s set to %q", input.EntityID, resp.State.State),
+	}
+
+	return nil, output, nil
+}
+
+// GetStates returns the full state of a given set of entities. All states are
+// fetched in a single request and filtered locally, which is cheaper than one
+// request per entity.
+func GetStates(ctx context.Context, req *mcp.CallToolRequest, input GetStatesInput) (
+	*mcp.CallToolResult,
+	GetStatesOutput,
+	error,
+) {
+	if len(input.EntityIDs) == 0 {
+		return nil, GetStatesOutput{}, fmt.Errorf("entity_ids must not be empty")
+	}
+
+	states, err := client.GetStates(ctx)
+	if err != nil {
+		return nil, GetStatesOutput{}, fmt.Errorf("failed to get states: %w", err)
+	}
+	cacheStates(states)
+
+	byID := make(map[string]EntityState, len(states))
+	for _, state := range states {
+		data := strings.Split(state.EntityId, ".")
+		domain := ""
+		if len(data) > 0 {
+			domain = data[0]
+		}
+		byID[state.EntityId] = EntityState{
+			EntityID:   state.EntityId,
+			Domain:     domain,
+			State:      state.State,
+			Attributes: state.Attributes,
+		}
+	}
+
+	output := GetStatesOutput{Entities: []EntityState{}}
+	seen := make(map[string]bool, len(input.EntityIDs))
+	for _, id := range input.EntityIDs {
+		id = strings.TrimSpace(id)
+		if id == "" {
+			continue
+		}
+		if !isEntityID(id) {
+			entityID, err := resolveEntity(id, "", states)
+			var ambiguous *ambiguousEntityError
+			switch {
+			case errors.As(err, &ambiguous):
+				if output.Candidates == nil {
+					output.Candidates = map[string][]EntitySummary{}
+				}
+				output.Candidates[id] = ambiguous.candidates
+				continue
+			case err != nil:
+				output.NotFound = append(output.NotFound, id)
+				continue
+			}
+			if output.Resolved == nil {
+				output.Resolved = map[string]string{}
+			}
+			output.Resolved[id] = entityID
+			id = entityID
+		}
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+
+		entity, ok := byID[id]
+		if !ok {
+			output.NotFound = append(output.NotFound, id)
+			continue
+		}
+		output.Entities = append(output.Entities, entity)
+	}
+	output.Count = len(output.Entities)
+
+	var notes []string
+	if len(output.NotFound) > 0 {
+		notes = append(notes, fmt.Sprintf("Skipped %d unknown entity ID(s): %s", len(output.NotFound), strings.Join(output.NotFound, ", ")))
+	}
+	if len(output.Candidates) > 0 {
+		notes = append(notes, fmt.Sprintf("Skipped %d ambiguous name(s), see candidates", len(output.Candidates)))
+	}
+	output.Note = strings.Join(notes, ". ")
+
+	return nil, output, nil
+}
+
 // SearchEntities searches for entities matching the keyword
 func SearchEntities(ctx context.Context, req *mcp.CallToolRequest, input SearchEntitiesInput) (
 	*mcp.CallToolResult,
@@ -312,29 +546,71 @@ func SearchServices(ctx context.Context, req *mcp.CallToolRequest, input SearchS
 	return nil, output, nil
 }
 
+// getEnvInt reads a positive integer from the environment, falling back to
+// defaultValue when it is unset or invalid
+func getEnvInt(name string, defaultValue int) int {
+	value := os.Getenv(name)
+	if value == "" {
+		return defaultValue
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n <= 0 {
+		log.Printf("Warning: invalid %s %q, using %d", name, value, defaultValue)
+		return defaultValue
+	}
+	return n
+}
+
 func main() {
 	// Get configuration from environment variables
+	mockMode := mock.Enabled("HA")
 	token := os.Getenv("HA_TOKEN")
-	if token == "" {
-		log.Fatal("HA_TOKEN environment variable is required")
-	}
-
 	host := os.Getenv("HA_HOST")
 	if host == "" {
 		host = "http://localhost:8123"
 	}
 
+	httpClient := &http.Client{
+		// Retry transient failures and pause calls while Home Assistant is down
+		Transport: newResilientTransport(nil,
+			getEnvInt("HA_MAX_ATTEMPTS", defaultMaxAttempts),
+			getEnvInt("HA_CIRCUIT_THRESHOLD", defaultCircuitThreshold),
+			time.Duration(getEnvInt("HA_CIRCUIT_COOLDOWN", defaultCircuitCooldown))*time.Second),
+		Timeout: 30 * time.Second,
+	}
+	if mockMode {
+		// Serve canned entities and services instead of talking to Home Assistant
+		httpClient = newMockHTTPClient()
+		log.Println("HA_MOCK enabled: using canned Home Assistant responses")
+	}
+
+	apiHost, apiToken, apiClient = host, token, httpClient
+	batteryThreshold = getEnvInt("HA_BATTERY_THRESHOLD", defaultBatteryThreshold)
+
 	// Create Home Assistant client
 	client = ha.NewClient(
 		ha.ClientConfig{
 			Token: token,
 			Host:  host,
 		},
-		&http.Client{
-			Timeout: 30 * time.Second,
-		},
+		httpClient,
 	)
 
+	// Websocket subscriptions talk to the real instance, not the mock client
+	if !mockMode {
+		subscriptions = NewSubscriptionManager(host, token,
+			getEnvInt("HA_SUBSCRIPTION_BUFFER", defaultSubscriptionBuffer),
+			getEnvInt("HA_MAX_SUBSCRIPTIONS", defaultMaxSubscriptions))
+		defer subscriptions.CloseAll()
+	}
+
+	if selfcheck.Enabled() {
+		selfcheck.Exit("homeassistant", selfChecks(mockMode, host)...)
+	}
+	if token == "" && !mockMode {
+		log.Fatal("HA_TOKEN environment variable is required")
+	}
+
 	// Test connection
 	if err := client.Ping(context.Background()); err != nil {
 		log.Printf("Warning: Could not ping Home Assistant instance: %v", err)
@@ -358,9 +634,29 @@ func main() {
 
 	mcp.AddTool(server, &mcp.Tool{
 		Name:        "call_service",
-		Description: "Call a service in Home Assistant (e.g., turn_on, turn_off, toggle)",
+		Description: "Call a service in Home Assistant (e.g., turn_on, turn_off, toggle), with optional service data. entity_id can be an entity ID or a friendly name such as 'living room lamp'; ambiguous names return the candidate entities. Set return_response to get the data returned by services such as calendar.get_events or weather.get_forecasts.",
 	}, CallService)
 
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "set_domain_state",
+		Description: "Call turn_on, turn_off or toggle on every entity of a domain at once (e.g., turn off all lights), optionally only those in an area or whose name contains a text. Returns the outcome for each entity.",
+	}, SetDomainState)
+
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "set_state",
+		Description: "Write an entity state (and optional attributes) directly into the Home Assistant state machine, e.g. for input helpers or template sensors. This does not control physical devices; use call_service for that.",
+	}, SetState)
+
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "get_states",
+		Description: "Get the current state and all attributes of a specific list of entities in one call, by entity ID or friendly name. Unknown entity IDs are skipped and reported in not_found, ambiguous names in candidates.",
+	}, GetStates)
+
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "get_problems",
+		Description: "Report what looks broken in the home: entities that are unavailable or unknown, in an error state (error, fault, jammed, problem sensors on...), or with a battery below battery_threshold percent. Problems are grouped by reason, most serious first.",
+	}, GetProblems)
+
 	mcp.AddTool(server, &mcp.Tool{
 		Name:        "search_entities",
 		Description: "Search for entities in Home Assistant by keyword (searches entity ID, domain, state, friendly name). Returns full details: entity_id, state, friendly_name, domain.",
@@ -371,8 +667,23 @@ func main() {
 		Description: "Search for services in Home Assistant by keyword (searches domain and name). Returns full details including service fields.",
 	}, SearchServices)
 
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "subscribe_states",
+		Description: "Watch entities for state changes over a persistent websocket connection. Returns a subscription_id; changes are buffered in the background until retrieved with poll_subscription. Close it with unsubscribe when done.",
+	}, SubscribeStates)
+
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "poll_subscription",
+		Description: "Get the state changes buffered by a subscription since the previous poll, oldest first",
+	}, PollSubscription)
+
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "unsubscribe",
+		Description: "Close a state subscription and its websocket connection",
+	}, Unsubscribe)
+
 	// Run the server
-	if err := server.Run(context.Background(), &mcp.StdioTransport{}); err != nil {
+	if err := transport.Run(context.Background(), server); err != nil {
 		log.Fatal(err)
 	}
 }
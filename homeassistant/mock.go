@@ -0,0 +1,173 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/mudler/mcps/pkg/mock"
+)
+
+// mockUpdated is the fixed timestamp reported for every mock entity
+const mockUpdated = "2025-01-15T10:30:00.000000+00:00"
+
+// mockStates are the entities reported when HA_MOCK is enabled
+var mockStates = []map[string]interface{}{
+	mockState("light.living_room", "on", map[string]interface{}{"friendly_name": "Living Room Light", "brightness": 255, "color_mode": "brightness"}),
+	mockState("light.kitchen", "off", map[string]interface{}{"friendly_name": "Kitchen Light"}),
+	mockState("switch.coffee_machine", "off", map[string]interface{}{"friendly_name": "Coffee Machine"}),
+	mockState("sensor.outdoor_temperature", "18.4", map[string]interface{}{"friendly_name": "Outdoor Temperature", "unit_of_measurement": "°C", "device_class": "temperature"}),
+	mockState("binary_sensor.front_door", "off", map[string]interface{}{"friendly_name": "Front Door", "device_class": "door"}),
+	mockState("sensor.front_door_battery", "12", map[string]interface{}{"friendly_name": "Front Door Battery", "unit_of_measurement": "%", "device_class": "battery"}),
+	mockState("switch.garage_plug", "unavailable", map[string]interface{}{"friendly_name": "Garage Plug"}),
+	mockState("input_boolean.vacation_mode", "off", map[string]interface{}{"friendly_name": "Vacation Mode"}),
+	mockState("weather.home", "sunny", map[string]interface{}{"friendly_name": "Home", "temperature": 18.4, "temperature_unit": "°C"}),
+}
+
+// mockServices are the services reported when HA_MOCK is enabled
+var mockServices = []map[string]interface{}{
+	{
+		"domain": "light",
+		"services": map[string]interface{}{
+			"turn_on": map[string]interface{}{
+				"name":        "Turn on",
+				"description": "Turn on one or more lights.",
+				"fields": map[string]interface{}{
+					"brightness": map[string]interface{}{
+						"description": "Number indicating brightness, where 0 turns the light off and 255 is the maximum.",
+						"example":     120,
+						"selector":    map[string]interface{}{"number": map[string]interface{}{"min": 0, "max": 255}},
+					},
+				},
+			},
+			"turn_off": map[string]interface{}{"name": "Turn off", "description": "Turn off one or more lights.", "fields": map[string]interface{}{}},
+			"toggle":   map[string]interface{}{"name": "Toggle", "description": "Toggle one or more lights.", "fields": map[string]interface{}{}},
+		},
+	},
+	{
+		"domain": "switch",
+		"services": map[string]interface{}{
+			"turn_on":  map[string]interface{}{"name": "Turn on", "description": "Turn a switch on.", "fields": map[string]interface{}{}},
+			"turn_off": map[string]interface{}{"name": "Turn off", "description": "Turn a switch off.", "fields": map[string]interface{}{}},
+		},
+	},
+	{
+		"domain": "input_boolean",
+		"services": map[string]interface{}{
+			"turn_on":  map[string]interface{}{"name": "Turn on", "description": "Turn on an input boolean.", "fields": map[string]interface{}{}},
+			"turn_off": map[string]interface{}{"name": "Turn off", "description": "Turn off an input boolean.", "fields": map[string]interface{}{}},
+		},
+	},
+}
+
+func mockState(entityID, state string, attributes map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"entity_id":    entityID,
+		"state":        state,
+		"attributes":   attributes,
+		"last_changed": mockUpdated,
+		"last_updated": mockUpdated,
+		"context":      map[string]interface{}{"id": "mock", "parent_id": nil, "user_id": nil},
+	}
+}
+
+func findMockState(entityID string) map[string]interface{} {
+	for _, state := range mockStates {
+		if state["entity_id"] == entityID {
+			return state
+		}
+	}
+	return nil
+}
+
+// newMockHTTPClient returns an HTTP client answering the Home Assistant REST
+// API from the fixtures above. Writes are echoed back but not remembered, so
+// every call sees the same states.
+func newMockHTTPClient() *http.Client {
+	return mock.NewClient(
+		mock.Route{Method: http.MethodGet, Pattern: "/api/", Handler: mock.JSON(http.StatusOK, map[string]string{"message": "API running."})},
+		mock.Route{Method: http.MethodGet, Pattern: "/api", Handler: mock.JSON(http.StatusOK, map[string]string{"message": "API running."})},
+		mock.Route{Method: http.MethodGet, Pattern: "/api/states", Handler: mock.JSON(http.StatusOK, mockStates)},
+		mock.Route{Method: http.MethodGet, Pattern: "/api/states/*", Handler: func(req *http.Request) (int, interface{}) {
+			state := findMockState(strings.TrimPrefix(req.URL.Path, "/api/states/"))
+			if state == nil {
+				return http.StatusNotFound, map[string]string{"message": "Entity not found."}
+			}
+			return http.StatusOK, state
+		}},
+		mock.Route{Method: http.MethodPost, Pattern: "/api/states/*", Handler: func(req *http.Request) (int, interface{}) {
+			entityID := strings.TrimPrefix(req.URL.Path, "/api/states/")
+			var body struct {
+				State      string                 `json:"state"`
+				Attributes map[string]interface{} `json:"attributes"`
+			}
+			if err := mock.DecodeBody(req, &body); err != nil {
+				return http.StatusBadRequest, map[string]string{"message": "Invalid JSON specified."}
+			}
+			status := http.StatusOK
+			if findMockState(entityID) == nil {
+				status = http.StatusCreated
+			}
+			if body.Attributes == nil {
+				body.Attributes = map[string]interface{}{}
+			}
+			return status, mockState(entityID, body.State, body.Attributes)
+		}},
+		mock.Route{Method: http.MethodPost, Pattern: "/api/template", Handler: mockRenderTemplate},
+		mock.Route{Method: http.MethodGet, Pattern: "/api/services", Handler: mock.JSON(http.StatusOK, mockServices)},
+		mock.Route{Method: http.MethodPost, Pattern: "/api/services/*/*", Handler: mockCallService},
+	)
+}
+
+// mockAreas are the entities of each area, by lowercase area name
+var mockAreas = map[string][]string{
+	"kitchen":     {"light.kitchen", "switch.coffee_machine"},
+	"living room": {"light.living_room"},
+}
+
+// mockRenderTemplate answers the area_entities template of set_domain_state,
+// the only template the server renders
+func mockRenderTemplate(req *http.Request) (int, interface{}) {
+	var body struct {
+		Template string `json:"template"`
+	}
+	if err := mock.DecodeBody(req, &body); err != nil {
+		return http.StatusBadRequest, map[string]string{"message": "Invalid JSON specified."}
+	}
+	entityIDs := []string{}
+	for area, entities := range mockAreas {
+		if strings.Contains(strings.ToLower(body.Template), `"`+area+`"`) {
+			entityIDs = entities
+		}
+	}
+	return http.StatusOK, entityIDs
+}
+
+// mockForecast is returned by weather.get_forecasts with return_response
+var mockForecast = []map[string]interface{}{
+	{"datetime": "2025-01-16T00:00:00+00:00", "condition": "sunny", "temperature": 21.0, "templow": 12.0, "precipitation": 0.0},
+	{"datetime": "2025-01-17T00:00:00+00:00", "condition": "rainy", "temperature": 16.0, "templow": 10.0, "precipitation": 4.2},
+}
+
+// mockCallService answers service calls. With return_response, only
+// weather.get_forecasts returns data, other services are rejected like Home
+// Assistant rejects services that do not support responses.
+func mockCallService(req *http.Request) (int, interface{}) {
+	if !req.URL.Query().Has("return_response") {
+		return http.StatusOK, []interface{}{}
+	}
+	if req.URL.Path != "/api/services/weather/get_forecasts" {
+		return http.StatusBadRequest, map[string]string{"message": "Service does not support responses. Remove return_response from request."}
+	}
+	var body struct {
+		EntityID string `json:"entity_id"`
+	}
+	_ = mock.DecodeBody(req, &body)
+	entityID := body.EntityID
+	if entityID == "" {
+		entityID = "weather.home"
+	}
+	return http.StatusOK, map[string]interface{}{
+		"changed_states":   []interface{}{},
+		"service_response": map[string]interface{}{entityID: map[string]interface{}{"forecast": mockForecast}},
+	}
+}
@@ -0,0 +1,207 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	ha "github.com/mkelcik/go-ha-client"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// defaultBatteryThreshold is the battery level, in percent, below which a
+// battery is reported as low
+const defaultBatteryThreshold = 20
+
+// batteryThreshold is set with HA_BATTERY_THRESHOLD
+var batteryThreshold = defaultBatteryThreshold
+
+// Problem reasons reported by get_problems
+const (
+	problemUnavailable = "unavailable"
+	problemUnknown     = "unknown"
+	problemLowBattery  = "low_battery"
+	problemError       = "error"
+)
+
+// errorStates are states integrations use to report a failing device
+var errorStates = map[string]bool{
+	"error":   true,
+	"fault":   true,
+	"failed":  true,
+	"problem": true,
+	"jammed":  true,
+	"offline": true,
+}
+
+type GetProblemsInput struct {
+	BatteryThreshold int      `json:"battery_threshold,omitempty" jsonschema:"optional battery level in percent below which a battery is reported as low (default: HA_BATTERY_THRESHOLD)"`
+	Domains          []string `json:"domains,omitempty" jsonschema:"optional only check the entities of these domains (e.g., ['sensor', 'light'])"`
+}
+
+// Problem is an entity that looks broken and why
+type Problem struct {
+	EntityID     string      `json:"entity_id" jsonschema:"the entity ID"`
+	Domain       string      `json:"domain" jsonschema:"domain of the entity"`
+	FriendlyName interface{} `json:"friendly_name,omitempty" jsonschema:"friendly name if available"`
+	State        string      `json:"state" jsonschema:"current state"`
+	Reason       string      `json:"reason" jsonschema:"unavailable, unknown, low_battery or error"`
+	BatteryLevel *float64    `json:"battery_level,omitempty" jsonschema:"the battery level in percent, for low batteries"`
+	LastChanged  string      `json:"last_changed,omitempty" jsonschema:"when the state last changed, showing how long the problem has lasted"`
+}
+
+type GetProblemsOutput struct {
+	Problems         []Problem      `json:"problems" jsonschema:"the entities with a problem, grouped by reason then sorted by entity ID"`
+	Count            int            `json:"count" jsonschema:"number of problems"`
+	ByReason         map[string]int `json:"by_reason" jsonschema:"number of problems of each reason"`
+	Checked          int            `json:"checked" jsonschema:"number of entities checked"`
+	BatteryThreshold int            `json:"battery_threshold" jsonschema:"the battery level in percent below which batteries were reported"`
+	Message          string         `json:"message" jsonschema:"summary of the report"`
+}
+
+// problemOrder is the order reasons are reported in, most serious first
+var problemOrder = map[string]int{
+	problemUnavailable: 0,
+	problemError:       1,
+	problemUnknown:     2,
+	problemLowBattery:  3,
+}
+
+// GetProblems scans every entity for the ones that look broken: unavailable
+// or unknown, in an error state, or with a low battery
+func GetProblems(ctx context.Context, req *mcp.CallToolRequest, input GetProblemsInput) (
+	*mcp.CallToolResult,
+	GetProblemsOutput,
+	error,
+) {
+	threshold := batteryThreshold
+	if input.BatteryThreshold != 0 {
+		if input.BatteryThreshold < 0 || input.BatteryThreshold > 100 {
+			return nil, GetProblemsOutput{}, fmt.Errorf("battery_threshold must be between 1 and 100, got %d", input.BatteryThreshold)
+		}
+		threshold = input.BatteryThreshold
+	}
+	var domains map[string]bool
+	if len(input.Domains) > 0 {
+		domains = make(map[string]bool, len(input.Domains))
+		for _, domain := range input.Domains {
+			domains[strings.TrimSpace(domain)] = true
+		}
+	}
+
+	states, err := client.GetStates(ctx)
+	if err != nil {
+		return nil, GetProblemsOutput{}, fmt.Errorf("failed to get states: %w", err)
+	}
+	cacheStates(states)
+
+	output := GetProblemsOutput{
+		Problems:         []Problem{},
+		ByReason:         map[string]int{},
+		BatteryThreshold: threshold,
+	}
+	for _, state := range states {
+		domain, _, _ := strings.Cut(state.EntityId, ".")
+		if domains != nil && !domains[domain] {
+			continue
+		}
+		output.Checked++
+
+		problem, ok := entityProblem(state, float64(threshold))
+		if !ok {
+			continue
+		}
+		problem.Domain = domain
+		output.Problems = append(output.Problems, problem)
+		output.ByReason[problem.Reason]++
+	}
+
+	sort.SliceStable(output.Problems, func(i, j int) bool {
+		a, b := output.Problems[i], output.Problems[j]
+		if a.Reason != b.Reason {
+			return problemOrder[a.Reason] < problemOrder[b.Reason]
+		}
+		return a.EntityID < b.EntityID
+	})
+	output.Count = len(output.Problems)
+
+	if output.Count == 0 {
+		output.Message = fmt.Sprintf("No problems found in %d entities", output.Checked)
+	} else {
+		var counts []string
+		for _, reason := range []string{problemUnavailable, problemError, problemUnknown, problemLowBattery} {
+			if n := output.ByReason[reason]; n > 0 {
+				counts = append(counts, fmt.Sprintf("%d %s", n, reason))
+			}
+		}
+		output.Message = fmt.Sprintf("Found %d problem(s) in %d entities: %s", output.Count, output.Checked, strings.Join(counts, ", "))
+	}
+
+	return nil, output, nil
+}
+
+// entityProblem returns the problem of an entity, if it has one. A battery
+// is read from the battery_level attribute, or from the state of battery
+// sensors reporting a percentage.
+func entityProblem(state ha.StateEntity, threshold float64) (Problem, bool) {
+	problem := Problem{
+		EntityID:     state.EntityId,
+		FriendlyName: state.Attributes["friendly_name"],
+		State:        state.State,
+	}
+	if !state.LastChanged.IsZero() {
+		problem.LastChanged = state.LastChanged.Format(time.RFC3339)
+	}
+
+	value := strings.ToLower(state.State)
+	switch {
+	case value == "unavailable":
+		problem.Reason = problemUnavailable
+		return problem, true
+	case value == "unknown":
+		problem.Reason = problemUnknown
+		return problem, true
+	case errorStates[value]:
+		problem.Reason = problemError
+		return problem, true
+	case strings.HasPrefix(state.EntityId, "binary_sensor.") && state.Attributes["device_class"] == "problem" && value == "on":
+		problem.Reason = problemError
+		return problem, true
+	}
+
+	level, ok := batteryLevel(state)
+	if ok && level < threshold {
+		problem.Reason = problemLowBattery
+		problem.BatteryLevel = &level
+		return problem, true
+	}
+	return Problem{}, false
+}
+
+// batteryLevel returns the battery level of an entity in percent
+func batteryLevel(state ha.StateEntity) (float64, bool) {
+	if level, ok := numberValue(state.Attributes["battery_level"]); ok {
+		return level, true
+	}
+	if state.Attributes["device_class"] == "battery" && state.Attributes["unit_of_measurement"] == "%" {
+		return numberValue(state.State)
+	}
+	return 0, false
+}
+
+// numberValue converts a number, or a string holding one, to a float
+func numberValue(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case int:
+		return float64(v), true
+	case string:
+		n, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		return n, err == nil
+	}
+	return 0, false
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	defaultMaxAttempts      = 3
+	defaultCircuitThreshold = 5
+	defaultCircuitCooldown  = 30
+	retryBaseDelay          = 500 * time.Millisecond
+)
+
+// errUnavailable is returned while the circuit is open
+var errUnavailable = errors.New("Home Assistant unavailable")
+
+// resilientTransport retries requests failing with transient errors and
+// stops calling Home Assistant for a cooldown after repeated failures, so a
+// restarting or overloaded instance is not hammered.
+//
+// GET requests are retried on network errors, timeouts and 502/503/504
+// responses. Other requests, such as service calls, are only retried when
+// they surely did not run: the connection was refused or Home Assistant
+// answered 502/503/504.
+type resilientTransport struct {
+	next        http.RoundTripper
+	maxAttempts int
+	threshold   int
+	cooldown    time.Duration
+	// retryDelay is the wait before the first retry, doubled on each further
+	// one
+	retryDelay time.Duration
+
+	mutex     sync.Mutex
+	failures  int
+	openUntil time.Time
+	probing   bool
+}
+
+// newResilientTransport wraps next, a nil next being http.DefaultTransport
+func newResilientTransport(next http.RoundTripper, maxAttempts, threshold int, cooldown time.Duration) *resilientTransport {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &resilientTransport{next: next, maxAttempts: maxAttempts, threshold: threshold, cooldown: cooldown, retryDelay: retryBaseDelay}
+}
+
+// allow reports whether a request may be sent. Once the cooldown is over a
+// single request is let through to probe Home Assistant.
+func (t *resilientTransport) allow() error {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if t.failures < t.threshold {
+		return nil
+	}
+	if wait := time.Until(t.openUntil); wait > 0 {
+		return fmt.Errorf("%w: %d consecutive failures, retrying in %s", errUnavailable, t.failures, (wait + time.Second - 1).Truncate(time.Second))
+	}
+	if t.probing {
+		return fmt.Errorf("%w: %d consecutive failures, checking whether it is back", errUnavailable, t.failures)
+	}
+	t.probing = true
+	return nil
+}
+
+// done records the outcome of a request, opening the circuit once
+// threshold requests in a row failed
+func (t *resilientTransport) done(failed bool) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.probing = false
+	if !failed {
+		if t.failures >= t.threshold {
+			log.Println("Home Assistant is reachable again")
+		}
+		t.failures = 0
+		return
+	}
+	t.failures++
+	if t.failures >= t.threshold {
+		t.openUntil = time.Now().Add(t.cooldown)
+		log.Printf("Warning: Home Assistant failed %d times in a row, pausing calls for %s", t.failures, t.cooldown)
+	}
+}
+
+func (t *resilientTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := t.allow(); err != nil {
+		return nil, err
+	}
+
+	idempotent := req.Method == http.MethodGet || req.Method == http.MethodHead
+	maxAttempts := t.maxAttempts
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		// The body cannot be sent again
+		maxAttempts = 1
+	}
+
+	var resp *http.Response
+	var err error
+	for attempt := 1; ; attempt++ {
+		resp, err = t.next.RoundTrip(req)
+		if attempt >= maxAttempts || req.Context().Err() != nil || !transientFailure(resp, err, idempotent) {
+			break
+		}
+
+		if resp != nil {
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+		select {
+		case <-req.Context().Done():
+			t.done(!callerGaveUp(req))
+			return nil, req.Context().Err()
+		case <-time.After(t.retryDelay << (attempt - 1)):
+		}
+		if req, err = rewind(req); err != nil {
+			t.done(true)
+			return nil, err
+		}
+	}
+
+	t.done(!callerGaveUp(req) && transientFailure(resp, err, true))
+	return resp, err
+}
+
+// callerGaveUp reports whether the request was canceled by the caller,
+// which says nothing about Home Assistant. Timeouts still count as failures.
+func callerGaveUp(req *http.Request) bool {
+	return errors.Is(req.Context().Err(), context.Canceled)
+}
+
+// rewind returns a copy of req with a fresh body for another attempt
+func rewind(req *http.Request) (*http.Request, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return req, nil
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, err
+	}
+	retry := req.Clone(req.Context())
+	retry.Body = body
+	return retry, nil
+}
+
+// transientFailure reports whether a request failed in a way worth
+// retrying. Unless idempotent, only failures where the request surely did
+// not reach Home Assistant count.
+func transientFailure(resp *http.Response, err error, idempotent bool) bool {
+	if err != nil {
+		if errors.Is(err, context.Canceled) {
+			return false
+		}
+		if idempotent {
+			return true
+		}
+		var opErr *net.OpError
+		return errors.As(err, &opErr) && opErr.Op == "dial"
+	}
+	switch resp.StatusCode {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
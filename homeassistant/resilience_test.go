@@ -0,0 +1,235 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// scriptedTransport answers requests with the outcomes of script in turn,
+// repeating the last one, and records the bodies it was sent
+type scriptedTransport struct {
+	mutex  sync.Mutex
+	script []func(*http.Request) (*http.Response, error)
+	calls  int
+	bodies []string
+}
+
+func (s *scriptedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	s.mutex.Lock()
+	step := s.script[min(s.calls, len(s.script)-1)]
+	s.calls++
+	if req.Body != nil {
+		body, _ := io.ReadAll(req.Body)
+		s.bodies = append(s.bodies, string(body))
+	}
+	s.mutex.Unlock()
+	return step(req)
+}
+
+func (s *scriptedTransport) callCount() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.calls
+}
+
+func respond(code int) func(*http.Request) (*http.Response, error) {
+	return func(req *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: code, Body: io.NopCloser(strings.NewReader("")), Request: req}, nil
+	}
+}
+
+func fail(err error) func(*http.Request) (*http.Response, error) {
+	return func(*http.Request) (*http.Response, error) { return nil, err }
+}
+
+var (
+	refused = &net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED}
+	reset   = &net.OpError{Op: "read", Net: "tcp", Err: syscall.ECONNRESET}
+)
+
+var _ = Describe("Resilient transport", func() {
+	newTransport := func(script ...func(*http.Request) (*http.Response, error)) (*resilientTransport, *scriptedTransport) {
+		next := &scriptedTransport{script: script}
+		t := newResilientTransport(next, 3, 3, 50*time.Millisecond)
+		t.retryDelay = time.Millisecond
+		return t, next
+	}
+	get := func() *http.Request {
+		req, err := http.NewRequest(http.MethodGet, "http://localhost:8123/api/states", nil)
+		Expect(err).NotTo(HaveOccurred())
+		return req
+	}
+	post := func() *http.Request {
+		req, err := http.NewRequest(http.MethodPost, "http://localhost:8123/api/services/light/toggle", strings.NewReader(`{"entity_id":"light.kitchen"}`))
+		Expect(err).NotTo(HaveOccurred())
+		return req
+	}
+	statusOf := func(resp *http.Response, err error) int {
+		Expect(err).NotTo(HaveOccurred())
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	Describe("retries", func() {
+		It("retries reads on 502/503/504 and network errors", func() {
+			t, next := newTransport(respond(http.StatusServiceUnavailable), fail(reset), respond(http.StatusOK))
+			Expect(statusOf(t.RoundTrip(get()))).To(Equal(http.StatusOK))
+			Expect(next.callCount()).To(Equal(3))
+		})
+
+		It("gives up after the maximum attempts", func() {
+			t, next := newTransport(respond(http.StatusBadGateway))
+			Expect(statusOf(t.RoundTrip(get()))).To(Equal(http.StatusBadGateway))
+			Expect(next.callCount()).To(Equal(3))
+		})
+
+		It("does not retry errors Home Assistant answered with", func() {
+			t, next := newTransport(respond(http.StatusNotFound))
+			Expect(statusOf(t.RoundTrip(get()))).To(Equal(http.StatusNotFound))
+			Expect(next.callCount()).To(Equal(1))
+		})
+
+		It("does not retry service calls that may have run", func() {
+			t, next := newTransport(fail(reset), respond(http.StatusOK))
+			_, err := t.RoundTrip(post())
+			Expect(err).To(MatchError(reset))
+			Expect(next.callCount()).To(Equal(1))
+
+			deadline := &net.OpError{Op: "read", Net: "tcp", Err: context.DeadlineExceeded}
+			t, next = newTransport(fail(deadline), respond(http.StatusOK))
+			_, err = t.RoundTrip(post())
+			Expect(err).To(HaveOccurred())
+			Expect(next.callCount()).To(Equal(1))
+		})
+
+		It("retries service calls that surely did not run, sending the body again", func() {
+			t, next := newTransport(fail(refused), respond(http.StatusServiceUnavailable), respond(http.StatusOK))
+			Expect(statusOf(t.RoundTrip(post()))).To(Equal(http.StatusOK))
+			Expect(next.callCount()).To(Equal(3))
+			Expect(next.bodies).To(HaveEach(`{"entity_id":"light.kitchen"}`))
+		})
+
+		It("does not retry a body that cannot be sent again", func() {
+			t, next := newTransport(fail(refused), respond(http.StatusOK))
+			req := post()
+			req.GetBody = nil
+			_, err := t.RoundTrip(req)
+			Expect(err).To(MatchError(refused))
+			Expect(next.callCount()).To(Equal(1))
+		})
+
+		It("stops retrying when the caller gives up", func() {
+			t, next := newTransport(respond(http.StatusServiceUnavailable))
+			t.retryDelay = time.Hour
+			ctx, cancel := context.WithCancel(context.Background())
+			time.AfterFunc(10*time.Millisecond, cancel)
+			_, err := t.RoundTrip(get().WithContext(ctx))
+			Expect(err).To(MatchError(context.Canceled))
+			Expect(next.callCount()).To(Equal(1))
+		})
+	})
+
+	Describe("circuit breaker", func() {
+		// failRequests sends n reads, with no retries, that fail
+		failRequests := func(t *resilientTransport, n int) {
+			for range n {
+				_, err := t.RoundTrip(get())
+				Expect(err).To(MatchError(refused))
+			}
+		}
+
+		It("opens after threshold consecutive failures", func() {
+			t, next := newTransport(fail(refused))
+			t.maxAttempts = 1
+			failRequests(t, 3)
+
+			_, err := t.RoundTrip(get())
+			Expect(err).To(MatchError(errUnavailable))
+			Expect(err).To(MatchError(ContainSubstring("3 consecutive failures, retrying in 1s")))
+			Expect(next.callCount()).To(Equal(3))
+		})
+
+		It("is reset by a success", func() {
+			t, next := newTransport(fail(refused), fail(refused), respond(http.StatusOK), fail(refused))
+			t.maxAttempts = 1
+			failRequests(t, 2)
+			Expect(statusOf(t.RoundTrip(get()))).To(Equal(http.StatusOK))
+			failRequests(t, 2)
+			Expect(next.callCount()).To(Equal(5))
+		})
+
+		It("does not count errors Home Assistant answered with, or canceled calls", func() {
+			t, next := newTransport(respond(http.StatusUnauthorized))
+			t.maxAttempts = 1
+			for range 5 {
+				Expect(statusOf(t.RoundTrip(get()))).To(Equal(http.StatusUnauthorized))
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+			next.script = []func(*http.Request) (*http.Response, error){fail(context.Canceled)}
+			for range 5 {
+				_, err := t.RoundTrip(get().WithContext(ctx))
+				Expect(err).To(MatchError(context.Canceled))
+			}
+			Expect(next.callCount()).To(Equal(10))
+		})
+
+		It("lets a single probe through after the cooldown, closing when it succeeds", func() {
+			release := make(chan struct{})
+			t, next := newTransport(fail(refused), fail(refused), fail(refused), func(req *http.Request) (*http.Response, error) {
+				<-release
+				return respond(http.StatusOK)(req)
+			})
+			t.maxAttempts = 1
+			failRequests(t, 3)
+
+			_, err := t.RoundTrip(get())
+			Expect(err).To(MatchError(errUnavailable))
+			time.Sleep(60 * time.Millisecond)
+
+			probed := make(chan int)
+			go func() {
+				defer GinkgoRecover()
+				resp, err := t.RoundTrip(get())
+				probed <- statusOf(resp, err)
+			}()
+			Eventually(next.callCount).Should(Equal(4))
+
+			// Other calls wait for the probe
+			_, err = t.RoundTrip(get())
+			Expect(err).To(MatchError(ContainSubstring("checking whether it is back")))
+			Expect(next.callCount()).To(Equal(4))
+
+			close(release)
+			Eventually(probed).Should(Receive(Equal(http.StatusOK)))
+			Expect(statusOf(t.RoundTrip(get()))).To(Equal(http.StatusOK))
+			Expect(next.callCount()).To(Equal(5))
+		})
+
+		It("opens again for a cooldown when the probe fails", func() {
+			t, next := newTransport(fail(refused))
+			t.maxAttempts = 1
+			failRequests(t, 3)
+			time.Sleep(60 * time.Millisecond)
+
+			_, err := t.RoundTrip(get())
+			Expect(errors.Is(err, errUnavailable)).To(BeFalse())
+			Expect(next.callCount()).To(Equal(4))
+
+			_, err = t.RoundTrip(get())
+			Expect(err).To(MatchError(ContainSubstring("4 consecutive failures, retrying in")))
+			Expect(next.callCount()).To(Equal(4))
+		})
+	})
+})
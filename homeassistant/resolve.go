@@ -0,0 +1,206 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	ha "github.com/mkelcik/go-ha-client"
+)
+
+const (
+	// entityCacheTTL is how long the entity list used to resolve names is
+	// reused before being fetched again
+	entityCacheTTL = 30 * time.Second
+	// minNameScore is the score below which a friendly name does not match
+	minNameScore = 0.5
+	// ambiguousScoreGap is how close to the best score another match must be
+	// for the name to be ambiguous
+	ambiguousScoreGap = 0.1
+	// maxCandidates caps the entities returned for an ambiguous name
+	maxCandidates = 5
+)
+
+// entityIDPattern matches Home Assistant entity IDs, e.g. light.living_room
+var entityIDPattern = regexp.MustCompile(`^[a-z0-9_]+\.[a-z0-9_]+$`)
+
+// nameStopWords are ignored when comparing names, so "the living room lamp"
+// matches "Living Room Lamp"
+var nameStopWords = map[string]bool{"the": true, "a": true, "an": true, "my": true, "in": true, "of": true}
+
+// entityCache keeps the entity list used to resolve friendly names
+var entityCache struct {
+	mutex   sync.Mutex
+	states  ha.StateEntities
+	fetched time.Time
+}
+
+// cachedStates returns the entity list, fetching it when the cache is older
+// than entityCacheTTL
+func cachedStates(ctx context.Context) (ha.StateEntities, error) {
+	entityCache.mutex.Lock()
+	defer entityCache.mutex.Unlock()
+	if entityCache.states != nil && time.Since(entityCache.fetched) < entityCacheTTL {
+		return entityCache.states, nil
+	}
+	states, err := client.GetStates(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get states: %w", err)
+	}
+	entityCache.states, entityCache.fetched = states, time.Now()
+	return states, nil
+}
+
+// cacheStates refreshes the cache with states fetched by another tool
+func cacheStates(states ha.StateEntities) {
+	entityCache.mutex.Lock()
+	defer entityCache.mutex.Unlock()
+	entityCache.states, entityCache.fetched = states, time.Now()
+}
+
+// ambiguousEntityError is returned when a name matches several entities
+// equally well
+type ambiguousEntityError struct {
+	name       string
+	candidates []EntitySummary
+}
+
+func (e *ambiguousEntityError) Error() string {
+	ids := make([]string, len(e.candidates))
+	for i, candidate := range e.candidates {
+		ids[i] = candidate.EntityID
+	}
+	return fmt.Sprintf("%q matches several entities, pass one of: %s", e.name, strings.Join(ids, ", "))
+}
+
+// resolveEntity returns the entity ID for ref. Entity IDs are returned as is,
+// anything else is matched against the friendly names (and object IDs) of
+// states, preferring entities of domain when set. Names matching several
+// entities equally well return an *ambiguousEntityError with the candidates.
+func resolveEntity(ref, domain string, states ha.StateEntities) (string, error) {
+	ref = strings.TrimSpace(ref)
+	if isEntityID(ref) {
+		return ref, nil
+	}
+	query := nameTokens(ref)
+	if len(query) == 0 {
+		return "", fmt.Errorf("entity_id must be an entity ID or a friendly name")
+	}
+
+	type match struct {
+		entity EntitySummary
+		score  float64
+	}
+	var matches []match
+	for _, state := range states {
+		entityDomain, objectID, _ := strings.Cut(state.EntityId, ".")
+		friendlyName, _ := state.Attributes["friendly_name"].(string)
+		score := max(nameScore(query, nameTokens(friendlyName)), nameScore(query, nameTokens(objectID)))
+		if score < minNameScore {
+			continue
+		}
+		// Prefer the domain of the service being called: "kitchen" with
+		// light.turn_on is the light, not the kitchen sensor
+		if domain != "" && entityDomain == domain {
+			score += 0.5
+		}
+		matches = append(matches, match{EntitySummary{EntityID: state.EntityId, Domain: entityDomain, FriendlyName: state.Attributes["friendly_name"]}, score})
+	}
+	if len(matches) == 0 {
+		return "", fmt.Errorf("no entity matches %q, use search_entities to find its entity ID", ref)
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].score > matches[j].score })
+	var candidates []EntitySummary
+	for _, m := range matches {
+		if m.score < matches[0].score-ambiguousScoreGap || len(candidates) == maxCandidates {
+			break
+		}
+		candidates = append(candidates, m.entity)
+	}
+	if len(candidates) > 1 {
+		return "", &ambiguousEntityError{name: ref, candidates: candidates}
+	}
+	return candidates[0].EntityID, nil
+}
+
+// isEntityID reports whether ref has the domain.object_id form
+func isEntityID(ref string) bool {
+	return entityIDPattern.MatchString(ref)
+}
+
+// nameTokens splits a name into lowercase words, dropping punctuation,
+// underscores and stop words
+func nameTokens(name string) []string {
+	words := strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return !('a' <= r && r <= 'z' || '0' <= r && r <= '9' || r > 127)
+	})
+	tokens := words[:0]
+	for _, word := range words {
+		if !nameStopWords[word] {
+			tokens = append(tokens, word)
+		}
+	}
+	return tokens
+}
+
+// nameScore compares two names word by word, from 0 (nothing in common) to
+// 1 (same words). It mostly counts the query words found in the name, so
+// "light" matches every light about equally, with a bonus for covering the
+// whole name. Words match when equal or, for words of four letters or more,
+// one typo or plural apart.
+func nameScore(query, name []string) float64 {
+	if len(query) == 0 || len(name) == 0 {
+		return 0
+	}
+	used := make([]bool, len(name))
+	matched := 0
+	for _, q := range query {
+		for i, n := range name {
+			if !used[i] && similarWords(q, n) {
+				used[i] = true
+				matched++
+				break
+			}
+		}
+	}
+	return 0.8*float64(matched)/float64(len(query)) + 0.2*float64(matched)/float64(len(name))
+}
+
+// similarWords reports whether two words are equal or, when long enough, at
+// most one edit apart
+func similarWords(a, b string) bool {
+	if a == b {
+		return true
+	}
+	if len(a) < 4 || len(b) < 4 {
+		return false
+	}
+	return editDistance(a, b) <= 1
+}
+
+// editDistance is the Levenshtein distance between two strings
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur := make([]int, len(rb)+1)
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(rb)]
+}
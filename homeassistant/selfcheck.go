@@ -0,0 +1,36 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mudler/mcps/pkg/selfcheck"
+)
+
+// selfChecks validates the token and reaches Home Assistant over the REST
+// API and the websocket used by subscriptions
+func selfChecks(mockMode bool, host string) []selfcheck.Check {
+	if mockMode {
+		return []selfcheck.Check{
+			selfcheck.Skipped("HA_TOKEN", "HA_MOCK enabled"),
+			selfcheck.Skipped("api", "HA_MOCK enabled"),
+		}
+	}
+	return []selfcheck.Check{
+		selfcheck.Env("HA_TOKEN"),
+		{Name: "api", Run: func(ctx context.Context) (string, error) {
+			if err := client.Ping(ctx); err != nil {
+				return "", fmt.Errorf("could not ping %s: %w", host, err)
+			}
+			return host + " reachable", nil
+		}},
+		{Name: "websocket", Run: func(ctx context.Context) (string, error) {
+			conn, err := subscriptions.dial(ctx)
+			if err != nil {
+				return "", selfcheck.Warn(fmt.Errorf("subscriptions unavailable: %w", err))
+			}
+			conn.Close()
+			return "authenticated and subscribed to state_changed", nil
+		}},
+	}
+}
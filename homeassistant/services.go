@@ -0,0 +1,83 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// The REST settings, for the calls go-ha-client does not cover
+var (
+	apiHost   string
+	apiToken  string
+	apiClient *http.Client
+)
+
+// serviceCallResponse is the body of a service call made with
+// ?return_response
+type serviceCallResponse struct {
+	ChangedStates   []json.RawMessage `json:"changed_states"`
+	ServiceResponse interface{}       `json:"service_response"`
+}
+
+// callServiceREST calls a service with arbitrary service data. With
+// returnResponse, Home Assistant includes the data returned by the service,
+// e.g. the events of calendar.get_events, which is returned along with the
+// number of changed states.
+func callServiceREST(ctx context.Context, domain, service string, data map[string]interface{}, returnResponse bool) (interface{}, int, error) {
+	body, err := json.Marshal(data)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to encode service data: %w", err)
+	}
+	endpoint := fmt.Sprintf("%s/api/services/%s/%s", strings.TrimRight(apiHost, "/"), url.PathEscape(domain), url.PathEscape(service))
+	if returnResponse {
+		endpoint += "?return_response"
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+apiToken)
+
+	resp, err := apiClient.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		// Home Assistant explains rejected calls in {"message": "..."}, e.g.
+		// for services that do not support responses
+		var apiErr struct {
+			Message string `json:"message"`
+		}
+		if json.Unmarshal(respBody, &apiErr) == nil && apiErr.Message != "" {
+			return nil, 0, fmt.Errorf("HTTP %d: %s", resp.StatusCode, apiErr.Message)
+		}
+		return nil, 0, fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+
+	if !returnResponse {
+		// Without return_response the body is the list of changed states
+		var changed []json.RawMessage
+		if err := json.Unmarshal(respBody, &changed); err != nil {
+			return nil, 0, fmt.Errorf("failed to parse response: %w", err)
+		}
+		return nil, len(changed), nil
+	}
+	var result serviceCallResponse
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return nil, 0, fmt.Errorf("failed to parse response: %w", err)
+	}
+	return result.ServiceResponse, len(result.ChangedStates), nil
+}
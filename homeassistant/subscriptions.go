@@ -0,0 +1,455 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"net/url"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"golang.org/x/net/websocket"
+)
+
+const (
+	defaultSubscriptionBuffer = 1000
+	defaultMaxSubscriptions   = 10
+	websocketHandshakeTimeout = 10 * time.Second
+)
+
+// StateChange is a state_changed event of a subscribed entity
+type StateChange struct {
+	EntityID      string                 `json:"entity_id" jsonschema:"the entity ID"`
+	OldState      string                 `json:"old_state,omitempty" jsonschema:"the previous state, empty when the entity was added"`
+	NewState      string                 `json:"new_state,omitempty" jsonschema:"the new state, empty when the entity was removed"`
+	NewAttributes map[string]interface{} `json:"new_attributes,omitempty" jsonschema:"attributes after the change"`
+	ChangedAt     time.Time              `json:"changed_at" jsonschema:"when Home Assistant fired the event"`
+}
+
+// Subscription buffers state changes received over the Home Assistant
+// websocket API until they are polled
+type Subscription struct {
+	ID        string
+	EntityIDs []string
+	CreatedAt time.Time
+	Status    string // "active", "closed" or "error"
+	Error     string
+	events    []StateChange
+	dropped   int
+	conn      *websocket.Conn
+}
+
+// SubscriptionManager keeps the open subscriptions, one websocket each.
+// Subscriptions that lost their websocket are kept until their error is
+// polled, without counting toward maxSubscriptions.
+type SubscriptionManager struct {
+	subscriptions map[string]*Subscription
+	// connecting is the number of subscriptions being set up, which already
+	// hold a slot
+	connecting       int
+	mutex            sync.Mutex
+	host             string
+	token            string
+	bufferSize       int
+	maxSubscriptions int
+}
+
+// Global subscription manager, nil in mock mode
+var subscriptions *SubscriptionManager
+
+// NewSubscriptionManager creates a manager connecting to the Home Assistant
+// instance at host
+func NewSubscriptionManager(host, token string, bufferSize, maxSubscriptions int) *SubscriptionManager {
+	return &SubscriptionManager{
+		subscriptions:    make(map[string]*Subscription),
+		host:             host,
+		token:            token,
+		bufferSize:       bufferSize,
+		maxSubscriptions: maxSubscriptions,
+	}
+}
+
+// wsMessage is a message of the Home Assistant websocket API
+type wsMessage struct {
+	ID      int    `json:"id,omitempty"`
+	Type    string `json:"type"`
+	Success bool   `json:"success,omitempty"`
+	Message string `json:"message,omitempty"`
+	Error   *struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	} `json:"error,omitempty"`
+	Event *struct {
+		EventType string    `json:"event_type"`
+		TimeFired time.Time `json:"time_fired"`
+		Data      struct {
+			EntityID string   `json:"entity_id"`
+			OldState *wsState `json:"old_state"`
+			NewState *wsState `json:"new_state"`
+		} `json:"data"`
+	} `json:"event,omitempty"`
+}
+
+type wsState struct {
+	State      string                 `json:"state"`
+	Attributes map[string]interface{} `json:"attributes"`
+}
+
+// websocketURL turns the REST host into the websocket API endpoint
+func websocketURL(host string) (string, error) {
+	u, err := url.Parse(host)
+	if err != nil {
+		return "", fmt.Errorf("invalid HA_HOST: %w", err)
+	}
+	switch u.Scheme {
+	case "https":
+		u.Scheme = "wss"
+	default:
+		u.Scheme = "ws"
+	}
+	u.Path = strings.TrimSuffix(u.Path, "/") + "/api/websocket"
+	return u.String(), nil
+}
+
+// dial opens a websocket, authenticates and subscribes to state_changed
+// events. ctx only bounds the connection setup, not the subscription.
+func (sm *SubscriptionManager) dial(ctx context.Context) (*websocket.Conn, error) {
+	wsURL, err := websocketURL(sm.host)
+	if err != nil {
+		return nil, err
+	}
+	config, err := websocket.NewConfig(wsURL, sm.host)
+	if err != nil {
+		return nil, fmt.Errorf("invalid websocket URL: %w", err)
+	}
+	config.Dialer = &net.Dialer{Timeout: websocketHandshakeTimeout}
+	conn, err := config.DialContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to %s: %w", wsURL, err)
+	}
+	deadline := time.Now().Add(websocketHandshakeTimeout)
+	if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Before(deadline) {
+		deadline = ctxDeadline
+	}
+	conn.SetDeadline(deadline)
+
+	var msg wsMessage
+	if err := websocket.JSON.Receive(conn, &msg); err != nil || msg.Type != "auth_required" {
+		conn.Close()
+		return nil, fmt.Errorf("unexpected websocket greeting (type %q): %v", msg.Type, err)
+	}
+	if err := websocket.JSON.Send(conn, map[string]string{"type": "auth", "access_token": sm.token}); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to authenticate: %w", err)
+	}
+	msg = wsMessage{}
+	if err := websocket.JSON.Receive(conn, &msg); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to authenticate: %w", err)
+	}
+	if msg.Type != "auth_ok" {
+		conn.Close()
+		return nil, fmt.Errorf("authentication rejected: %s", msg.Message)
+	}
+
+	subscribe := map[string]interface{}{"id": 1, "type": "subscribe_events", "event_type": "state_changed"}
+	if err := websocket.JSON.Send(conn, subscribe); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to subscribe: %w", err)
+	}
+	msg = wsMessage{}
+	if err := websocket.JSON.Receive(conn, &msg); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to subscribe: %w", err)
+	}
+	if msg.Type != "result" || !msg.Success {
+		conn.Close()
+		if msg.Error != nil {
+			return nil, fmt.Errorf("failed to subscribe: %s", msg.Error.Message)
+		}
+		return nil, fmt.Errorf("failed to subscribe: unexpected %q message", msg.Type)
+	}
+
+	conn.SetDeadline(time.Time{})
+	return conn, nil
+}
+
+// Subscribe opens a subscription to the state changes of entityIDs, which
+// may be globs such as "light.*". No entity IDs means every entity.
+func (sm *SubscriptionManager) Subscribe(ctx context.Context, entityIDs []string) (*Subscription, error) {
+	for _, pattern := range entityIDs {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid entity pattern %q: %w", pattern, err)
+		}
+	}
+
+	// Take the slot before dialling so concurrent calls cannot exceed the
+	// limit
+	sm.mutex.Lock()
+	if sm.active()+sm.connecting >= sm.maxSubscriptions {
+		sm.mutex.Unlock()
+		return nil, fmt.Errorf("maximum number of subscriptions (%d) reached, unsubscribe first", sm.maxSubscriptions)
+	}
+	sm.connecting++
+	sm.mutex.Unlock()
+
+	conn, err := sm.dial(ctx)
+
+	sm.mutex.Lock()
+	defer sm.mutex.Unlock()
+	sm.connecting--
+	if err != nil {
+		return nil, err
+	}
+
+	sub := &Subscription{
+		ID:        uuid.New().String(),
+		EntityIDs: entityIDs,
+		CreatedAt: time.Now(),
+		Status:    "active",
+		events:    []StateChange{},
+		conn:      conn,
+	}
+	sm.subscriptions[sub.ID] = sub
+
+	go sm.receive(sub)
+	return sub, nil
+}
+
+// active returns the number of subscriptions receiving events. The caller
+// holds the mutex.
+func (sm *SubscriptionManager) active() int {
+	n := 0
+	for _, sub := range sm.subscriptions {
+		if sub.Status == "active" {
+			n++
+		}
+	}
+	return n
+}
+
+// matches reports whether the subscription watches entityID
+func (s *Subscription) matches(entityID string) bool {
+	if len(s.EntityIDs) == 0 {
+		return true
+	}
+	for _, pattern := range s.EntityIDs {
+		if ok, _ := path.Match(pattern, entityID); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// receive buffers events until the websocket is closed, dropping the oldest
+// events once the buffer is full. A lost websocket marks the subscription
+// as errored, which frees its slot.
+func (sm *SubscriptionManager) receive(sub *Subscription) {
+	for {
+		var msg wsMessage
+		if err := websocket.JSON.Receive(sub.conn, &msg); err != nil {
+			sm.mutex.Lock()
+			if sub.Status == "active" {
+				sub.Status = "error"
+				sub.Error = fmt.Sprintf("websocket closed: %v", err)
+				log.Printf("Warning: subscription %s stopped: %v", sub.ID, err)
+			}
+			sm.mutex.Unlock()
+			sub.conn.Close()
+			return
+		}
+		if msg.Type != "event" || msg.Event == nil || !sub.matches(msg.Event.Data.EntityID) {
+			continue
+		}
+
+		change := StateChange{
+			EntityID:  msg.Event.Data.EntityID,
+			ChangedAt: msg.Event.TimeFired,
+		}
+		if msg.Event.Data.OldState != nil {
+			change.OldState = msg.Event.Data.OldState.State
+		}
+		if msg.Event.Data.NewState != nil {
+			change.NewState = msg.Event.Data.NewState.State
+			change.NewAttributes = msg.Event.Data.NewState.Attributes
+		}
+
+		sm.mutex.Lock()
+		sub.events = append(sub.events, change)
+		if len(sub.events) > sm.bufferSize {
+			sub.dropped += len(sub.events) - sm.bufferSize
+			sub.events = sub.events[len(sub.events)-sm.bufferSize:]
+		}
+		sm.mutex.Unlock()
+	}
+}
+
+// Poll returns and removes up to max buffered events (all when max <= 0),
+// along with a snapshot of the subscription and the number of events
+// dropped since the last poll. An errored subscription is forgotten once
+// its last events have been returned.
+func (sm *SubscriptionManager) Poll(id string, max int) ([]StateChange, Subscription, int, error) {
+	sm.mutex.Lock()
+	defer sm.mutex.Unlock()
+
+	sub, exists := sm.subscriptions[id]
+	if !exists {
+		return nil, Subscription{}, 0, fmt.Errorf("subscription not found: %s", id)
+	}
+
+	n := len(sub.events)
+	if max > 0 && max < n {
+		n = max
+	}
+	events := make([]StateChange, n)
+	copy(events, sub.events[:n])
+	sub.events = sub.events[n:]
+	dropped := sub.dropped
+	sub.dropped = 0
+	if sub.Status == "error" && len(sub.events) == 0 {
+		delete(sm.subscriptions, id)
+	}
+	return events, *sub, dropped, nil
+}
+
+// Unsubscribe closes a subscription and returns the number of buffered
+// events that were never polled
+func (sm *SubscriptionManager) Unsubscribe(id string) (int, error) {
+	sm.mutex.Lock()
+	sub, exists := sm.subscriptions[id]
+	if !exists {
+		sm.mutex.Unlock()
+		return 0, fmt.Errorf("subscription not found: %s", id)
+	}
+	sub.Status = "closed"
+	discarded := len(sub.events)
+	delete(sm.subscriptions, id)
+	sm.mutex.Unlock()
+
+	sub.conn.Close()
+	return discarded, nil
+}
+
+// CloseAll closes every subscription
+func (sm *SubscriptionManager) CloseAll() {
+	sm.mutex.Lock()
+	ids := make([]string, 0, len(sm.subscriptions))
+	for id := range sm.subscriptions {
+		ids = append(ids, id)
+	}
+	sm.mutex.Unlock()
+
+	for _, id := range ids {
+		sm.Unsubscribe(id)
+	}
+}
+
+type SubscribeStatesInput struct {
+	EntityIDs []string `json:"entity_ids,omitempty" jsonschema:"entity IDs or globs to watch (e.g. ['light.*', 'sensor.outdoor_temperature']); empty watches every entity"`
+}
+
+type SubscribeStatesOutput struct {
+	SubscriptionID string   `json:"subscription_id" jsonschema:"the subscription ID to pass to poll_subscription and unsubscribe"`
+	EntityIDs      []string `json:"entity_ids,omitempty" jsonschema:"the watched entity IDs or globs"`
+	Message        string   `json:"message" jsonschema:"status message"`
+}
+
+type PollSubscriptionInput struct {
+	SubscriptionID string `json:"subscription_id" jsonschema:"the subscription ID returned by subscribe_states"`
+	MaxEvents      int    `json:"max_events,omitempty" jsonschema:"maximum number of events to return, oldest first (default: all buffered events)"`
+}
+
+type PollSubscriptionOutput struct {
+	SubscriptionID string        `json:"subscription_id" jsonschema:"the subscription ID"`
+	Status         string        `json:"status" jsonschema:"active, or error when the websocket was lost (subscribe again, the subscription is gone once its events were polled)"`
+	Events         []StateChange `json:"events" jsonschema:"state changes since the previous poll, oldest first"`
+	Count          int           `json:"count" jsonschema:"number of events returned"`
+	Remaining      int           `json:"remaining" jsonschema:"events still buffered, returned by the next poll"`
+	Dropped        int           `json:"dropped,omitempty" jsonschema:"events discarded since the previous poll because the buffer was full"`
+	Error          string        `json:"error,omitempty" jsonschema:"why the subscription stopped receiving events"`
+}
+
+type UnsubscribeInput struct {
+	SubscriptionID string `json:"subscription_id" jsonschema:"the subscription ID to close"`
+}
+
+type UnsubscribeOutput struct {
+	SubscriptionID string `json:"subscription_id" jsonschema:"the subscription ID"`
+	Discarded      int    `json:"discarded" jsonschema:"buffered events that were never polled"`
+	Message        string `json:"message" jsonschema:"status message"`
+}
+
+// SubscribeStates opens a websocket subscription to state changes
+func SubscribeStates(ctx context.Context, req *mcp.CallToolRequest, input SubscribeStatesInput) (
+	*mcp.CallToolResult,
+	SubscribeStatesOutput,
+	error,
+) {
+	if subscriptions == nil {
+		return nil, SubscribeStatesOutput{}, fmt.Errorf("subscriptions are not available in mock mode")
+	}
+
+	sub, err := subscriptions.Subscribe(ctx, input.EntityIDs)
+	if err != nil {
+		return nil, SubscribeStatesOutput{}, err
+	}
+
+	return nil, SubscribeStatesOutput{
+		SubscriptionID: sub.ID,
+		EntityIDs:      sub.EntityIDs,
+		Message:        "Subscribed, call poll_subscription to get state changes",
+	}, nil
+}
+
+// PollSubscription returns the state changes buffered since the last poll
+func PollSubscription(ctx context.Context, req *mcp.CallToolRequest, input PollSubscriptionInput) (
+	*mcp.CallToolResult,
+	PollSubscriptionOutput,
+	error,
+) {
+	if subscriptions == nil {
+		return nil, PollSubscriptionOutput{}, fmt.Errorf("subscriptions are not available in mock mode")
+	}
+
+	events, sub, dropped, err := subscriptions.Poll(input.SubscriptionID, input.MaxEvents)
+	if err != nil {
+		return nil, PollSubscriptionOutput{}, err
+	}
+
+	return nil, PollSubscriptionOutput{
+		SubscriptionID: sub.ID,
+		Status:         sub.Status,
+		Events:         events,
+		Count:          len(events),
+		Remaining:      len(sub.events),
+		Dropped:        dropped,
+		Error:          sub.Error,
+	}, nil
+}
+
+// Unsubscribe closes a subscription
+func Unsubscribe(ctx context.Context, req *mcp.CallToolRequest, input UnsubscribeInput) (
+	*mcp.CallToolResult,
+	UnsubscribeOutput,
+	error,
+) {
+	if subscriptions == nil {
+		return nil, UnsubscribeOutput{}, fmt.Errorf("subscriptions are not available in mock mode")
+	}
+
+	discarded, err := subscriptions.Unsubscribe(input.SubscriptionID)
+	if err != nil {
+		return nil, UnsubscribeOutput{}, err
+	}
+
+	return nil, UnsubscribeOutput{
+		SubscriptionID: input.SubscriptionID,
+		Discarded:      discarded,
+		Message:        "Subscription closed",
+	}, nil
+}
@@ -0,0 +1,205 @@
+package main
+
+import (
+	"context"
+	"net/http/httptest"
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"golang.org/x/net/websocket"
+)
+
+// fakeWebsocket is a Home Assistant websocket API accepting the token
+// "secret", then running stream on the subscribed connection
+type fakeWebsocket struct {
+	server *httptest.Server
+	// ready is closed to let handshakes complete, they wait for it
+	ready chan struct{}
+
+	mutex       sync.Mutex
+	handshaking int
+	stream      func(conn *websocket.Conn)
+}
+
+func newFakeWebsocket() *fakeWebsocket {
+	f := &fakeWebsocket{ready: make(chan struct{})}
+	close(f.ready)
+	// By default connections stay open until the client closes them
+	f.stream = func(conn *websocket.Conn) {
+		var msg wsMessage
+		websocket.JSON.Receive(conn, &msg)
+	}
+	f.server = httptest.NewServer(websocket.Handler(f.serve))
+	DeferCleanup(f.server.Close)
+	return f
+}
+
+func (f *fakeWebsocket) serve(conn *websocket.Conn) {
+	defer conn.Close()
+	f.mutex.Lock()
+	f.handshaking++
+	ready, stream := f.ready, f.stream
+	f.mutex.Unlock()
+	<-ready
+
+	websocket.JSON.Send(conn, map[string]string{"type": "auth_required"})
+	var auth map[string]string
+	if err := websocket.JSON.Receive(conn, &auth); err != nil {
+		return
+	}
+	if auth["access_token"] != "secret" {
+		websocket.JSON.Send(conn, map[string]string{"type": "auth_invalid", "message": "Invalid access token"})
+		return
+	}
+	websocket.JSON.Send(conn, map[string]string{"type": "auth_ok"})
+	var subscribe map[string]interface{}
+	if err := websocket.JSON.Receive(conn, &subscribe); err != nil {
+		return
+	}
+	websocket.JSON.Send(conn, map[string]interface{}{"id": 1, "type": "result", "success": true})
+	stream(conn)
+}
+
+// hold makes handshakes wait until the returned function is called
+func (f *fakeWebsocket) hold() func() {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.ready = make(chan struct{})
+	return sync.OnceFunc(func() { close(f.ready) })
+}
+
+func (f *fakeWebsocket) connections() int {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return f.handshaking
+}
+
+func (f *fakeWebsocket) setStream(stream func(conn *websocket.Conn)) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.stream = stream
+}
+
+var _ = Describe("Subscriptions", func() {
+	var (
+		ha *fakeWebsocket
+		sm *SubscriptionManager
+	)
+
+	BeforeEach(func() {
+		ha = newFakeWebsocket()
+		sm = NewSubscriptionManager(ha.server.URL, "secret", 10, 2)
+		DeferCleanup(sm.CloseAll)
+	})
+
+	It("buffers the state changes of matching entities", func() {
+		ha.setStream(func(conn *websocket.Conn) {
+			for _, entity := range []string{"light.kitchen", "sensor.outdoor", "light.hall"} {
+				websocket.JSON.Send(conn, map[string]interface{}{
+					"type": "event",
+					"event": map[string]interface{}{
+						"event_type": "state_changed",
+						"time_fired": "2025-01-15T10:30:00Z",
+						"data": map[string]interface{}{
+							"entity_id": entity,
+							"old_state": map[string]interface{}{"state": "off"},
+							"new_state": map[string]interface{}{"state": "on", "attributes": map[string]interface{}{"brightness": 255}},
+						},
+					},
+				})
+			}
+			var msg wsMessage
+			websocket.JSON.Receive(conn, &msg)
+		})
+		sub, err := sm.Subscribe(context.Background(), []string{"light.*"})
+		Expect(err).NotTo(HaveOccurred())
+
+		var events []StateChange
+		Eventually(func() []StateChange {
+			polled, _, _, err := sm.Poll(sub.ID, 0)
+			Expect(err).NotTo(HaveOccurred())
+			events = append(events, polled...)
+			return events
+		}).Should(HaveLen(2))
+		Expect(events[0].EntityID).To(Equal("light.kitchen"))
+		Expect(events[0].OldState).To(Equal("off"))
+		Expect(events[0].NewState).To(Equal("on"))
+		Expect(events[0].NewAttributes).To(HaveKeyWithValue("brightness", 255.0))
+		Expect(events[1].EntityID).To(Equal("light.hall"))
+	})
+
+	It("reports a rejected token", func() {
+		sm = NewSubscriptionManager(ha.server.URL, "wrong", 10, 2)
+		_, err := sm.Subscribe(context.Background(), nil)
+		Expect(err).To(MatchError(ContainSubstring("authentication rejected: Invalid access token")))
+	})
+
+	It("counts subscriptions being connected toward the limit", func() {
+		release := ha.hold()
+		DeferCleanup(release)
+
+		results := make(chan error, 2)
+		for range 2 {
+			go func() {
+				_, err := sm.Subscribe(context.Background(), nil)
+				results <- err
+			}()
+		}
+		Eventually(ha.connections).Should(Equal(2))
+
+		// Both slots are taken while the first two are still connecting
+		_, err := sm.Subscribe(context.Background(), nil)
+		Expect(err).To(MatchError(ContainSubstring("maximum number of subscriptions (2) reached")))
+		Expect(ha.connections()).To(Equal(2))
+
+		release()
+		Eventually(results).Should(Receive(BeNil()))
+		Eventually(results).Should(Receive(BeNil()))
+		_, err = sm.Subscribe(context.Background(), nil)
+		Expect(err).To(MatchError(ContainSubstring("maximum number of subscriptions (2) reached")))
+	})
+
+	It("frees the slot of a subscription that failed to connect", func() {
+		release := ha.hold()
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		_, err := sm.Subscribe(ctx, nil)
+		Expect(err).To(HaveOccurred())
+		release()
+
+		for range 2 {
+			_, err := sm.Subscribe(context.Background(), nil)
+			Expect(err).NotTo(HaveOccurred())
+		}
+	})
+
+	It("frees the slot of a subscription that lost its websocket", func() {
+		ha.setStream(func(conn *websocket.Conn) {})
+		lost, err := sm.Subscribe(context.Background(), nil)
+		Expect(err).NotTo(HaveOccurred())
+		Eventually(func() string {
+			sm.mutex.Lock()
+			defer sm.mutex.Unlock()
+			return lost.Status
+		}).Should(Equal("error"))
+
+		ha.setStream(func(conn *websocket.Conn) {
+			var msg wsMessage
+			websocket.JSON.Receive(conn, &msg)
+		})
+		for range 2 {
+			_, err := sm.Subscribe(context.Background(), nil)
+			Expect(err).NotTo(HaveOccurred())
+		}
+
+		// The error is reported once, then the subscription is forgotten
+		_, snapshot, _, err := sm.Poll(lost.ID, 0)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(snapshot.Status).To(Equal("error"))
+		Expect(snapshot.Error).To(ContainSubstring("websocket closed"))
+		_, _, _, err = sm.Poll(lost.ID, 0)
+		Expect(err).To(MatchError(ContainSubstring("subscription not found")))
+	})
+})
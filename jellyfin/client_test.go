@@ -0,0 +1,45 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("JellyfinClient", func() {
+	var server *httptest.Server
+
+	BeforeEach(func() {
+		// A server that never answers until the request is abandoned
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			select {
+			case <-r.Context().Done():
+			case <-time.After(10 * time.Second):
+			}
+		}))
+		client = &JellyfinClient{
+			BaseURL:    server.URL,
+			APIKey:     "test",
+			HTTPClient: &http.Client{Timeout: 30 * time.Second},
+		}
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("aborts an in-flight request when the context is cancelled", func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(100*time.Millisecond, cancel)
+
+		started := time.Now()
+		_, _, err := ListLibraries(ctx, nil, ListLibrariesInput{})
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("context canceled"))
+		Expect(time.Since(started)).To(BeNumerically("<", 5*time.Second))
+	})
+})
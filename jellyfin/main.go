@@ -9,6 +9,8 @@ import (
 	"time"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/mudler/mcps/pkg/selfcheck"
+	"github.com/mudler/mcps/pkg/transport"
 )
 
 var client *JellyfinClient
@@ -76,17 +78,8 @@ func registerTool(server *mcp.Server, td toolDef) {
 }
 
 func main() {
-	jellyfinURL := os.Getenv("JELLYFIN_URL")
-	if jellyfinURL == "" {
-		log.Fatal("JELLYFIN_URL environment variable is required")
-	}
-	jellyfinURL = strings.TrimRight(jellyfinURL, "/")
-
+	jellyfinURL := strings.TrimRight(os.Getenv("JELLYFIN_URL"), "/")
 	apiKey := os.Getenv("JELLYFIN_API_KEY")
-	if apiKey == "" {
-		log.Fatal("JELLYFIN_API_KEY environment variable is required")
-	}
-
 	userID := os.Getenv("JELLYFIN_USER_ID")
 
 	client = &JellyfinClient{
@@ -98,6 +91,16 @@ func main() {
 		},
 	}
 
+	if selfcheck.Enabled() {
+		selfcheck.Exit("jellyfin", selfChecks()...)
+	}
+	if jellyfinURL == "" {
+		log.Fatal("JELLYFIN_URL environment variable is required")
+	}
+	if apiKey == "" {
+		log.Fatal("JELLYFIN_API_KEY environment variable is required")
+	}
+
 	// Resolve JELLYFIN_USERNAME to a user ID if JELLYFIN_USER_ID is not set
 	if client.UserID == "" {
 		if username := os.Getenv("JELLYFIN_USERNAME"); username != "" {
@@ -142,7 +145,7 @@ func main() {
 		registerTool(server, td)
 	}
 
-	if err := server.Run(context.Background(), &mcp.StdioTransport{}); err != nil {
+	if err := transport.Run(context.Background(), server); err != nil {
 		log.Fatal(err)
 	}
 }
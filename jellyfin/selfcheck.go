@@ -0,0 +1,45 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/mudler/mcps/pkg/selfcheck"
+)
+
+// selfChecks validates the required settings, then that the server is
+// reachable and accepts the API key
+func selfChecks() []selfcheck.Check {
+	checks := []selfcheck.Check{
+		selfcheck.Env("JELLYFIN_URL"),
+		selfcheck.Env("JELLYFIN_API_KEY"),
+	}
+	if client.BaseURL == "" || client.APIKey == "" {
+		return checks
+	}
+	checks = append(checks,
+		selfcheck.Check{Name: "server", Run: func(ctx context.Context) (string, error) {
+			// Unlike /System/Info/Public this needs a valid API key
+			if _, err := client.Get(ctx, "/System/Info", nil); err != nil {
+				return "", fmt.Errorf("could not connect to %s: %w", client.BaseURL, err)
+			}
+			return client.BaseURL + " reachable, API key accepted", nil
+		}},
+		selfcheck.Check{Name: "user", Run: func(ctx context.Context) (string, error) {
+			if client.UserID != "" {
+				return "JELLYFIN_USER_ID " + client.UserID, nil
+			}
+			username := os.Getenv("JELLYFIN_USERNAME")
+			if username == "" {
+				return "", selfcheck.Warn(fmt.Errorf("neither JELLYFIN_USER_ID nor JELLYFIN_USERNAME is set, user-scoped tools will fail"))
+			}
+			id, err := ResolveUsername(ctx, client, username)
+			if err != nil {
+				return "", fmt.Errorf("failed to resolve JELLYFIN_USERNAME %q: %w", username, err)
+			}
+			return fmt.Sprintf("JELLYFIN_USERNAME %q is user %s", username, id), nil
+		}},
+	)
+	return checks
+}
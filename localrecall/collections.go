@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// CollectionInfo summarizes what a collection holds
+type CollectionInfo struct {
+	Name          string `json:"name" jsonschema:"the name of the collection"`
+	DocumentCount int    `json:"document_count" jsonschema:"number of documents in the collection"`
+	Empty         bool   `json:"empty" jsonschema:"whether the collection has no documents, searching it returns nothing"`
+	TotalSize     int64  `json:"total_size,omitempty" jsonschema:"total size of the documents in bytes, when reported by LocalRecall"`
+	CreatedAt     string `json:"created_at,omitempty" jsonschema:"when the collection was created, when reported by LocalRecall"`
+	Error         string `json:"error,omitempty" jsonschema:"why the collection could not be inspected"`
+}
+
+type GetCollectionInfoInput struct {
+	Name string `json:"name" jsonschema:"the name of the collection"`
+}
+
+type GetCollectionInfoInputWithoutCollection struct {
+}
+
+type ListCollectionsInput struct {
+	IncludeStats bool `json:"include_stats,omitempty" jsonschema:"also return the document count of each collection, one extra request per collection (default: false)"`
+}
+
+// collectionInfo reads the stats of a collection from its entries. The
+// document count is always available; the size and creation time are only
+// filled in when the LocalRecall version reports them.
+func collectionInfo(ctx context.Context, collectionName string) (CollectionInfo, error) {
+	apiResp, err := makeRequest(ctx, "GET", fmt.Sprintf("/api/collections/%s/entries", collectionName), nil)
+	if err != nil {
+		return CollectionInfo{}, err
+	}
+
+	// Extract data from response
+	data, ok := apiResp.Data.(map[string]interface{})
+	if !ok {
+		return CollectionInfo{}, fmt.Errorf("unexpected response data format")
+	}
+
+	info := CollectionInfo{Name: collectionName}
+	if countVal, ok := data["count"].(float64); ok {
+		info.DocumentCount = int(countVal)
+	} else if entriesData, ok := data["entries"].([]interface{}); ok {
+		info.DocumentCount = len(entriesData)
+	}
+	info.Empty = info.DocumentCount == 0
+	if sizeVal, ok := data["total_size"].(float64); ok {
+		info.TotalSize = int64(sizeVal)
+	}
+	if createdAtVal, ok := data["created_at"].(string); ok {
+		info.CreatedAt = createdAtVal
+	}
+
+	return info, nil
+}
+
+// GetCollectionInfo returns the stats of a collection
+func GetCollectionInfo(ctx context.Context, req *mcp.CallToolRequest, input GetCollectionInfoInput) (
+	*mcp.CallToolResult,
+	CollectionInfo,
+	error,
+) {
+	if input.Name == "" {
+		return nil, CollectionInfo{}, fmt.Errorf("name is required")
+	}
+	info, err := collectionInfo(ctx, input.Name)
+	if err != nil {
+		return nil, CollectionInfo{}, err
+	}
+	return nil, info, nil
+}
+
+// GetCollectionInfoWithoutCollection returns the stats of the default collection
+func GetCollectionInfoWithoutCollection(ctx context.Context, req *mcp.CallToolRequest, input GetCollectionInfoInputWithoutCollection) (
+	*mcp.CallToolResult,
+	CollectionInfo,
+	error,
+) {
+	info, err := collectionInfo(ctx, defaultCollectionName)
+	if err != nil {
+		return nil, CollectionInfo{}, err
+	}
+	return nil, info, nil
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/mudler/mcps/pkg/mock"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Collection stats", func() {
+	BeforeEach(func() {
+		useMockLocalRecall(mock.NewClient(
+			mock.Route{Method: http.MethodGet, Pattern: "/api/collections", Handler: func(*http.Request) (int, interface{}) {
+				return mockOK(map[string]interface{}{"collections": []string{"docs", "notes", "broken"}, "count": 3})
+			}},
+			mock.Route{Method: http.MethodGet, Pattern: "/api/collections/docs/entries", Handler: func(*http.Request) (int, interface{}) {
+				return mockOK(map[string]interface{}{"entries": []string{"a.md", "b.md"}, "count": 2, "total_size": 2048, "created_at": mockTimestamp})
+			}},
+			// Older LocalRecall versions only list the entries
+			mock.Route{Method: http.MethodGet, Pattern: "/api/collections/notes/entries", Handler: func(*http.Request) (int, interface{}) {
+				return mockOK(map[string]interface{}{"entries": []string{}})
+			}},
+			mock.Route{Method: http.MethodGet, Pattern: "/api/collections/broken/entries", Handler: func(*http.Request) (int, interface{}) {
+				return http.StatusNotFound, APIResponse{Error: &APIError{Code: "NOT_FOUND", Message: "collection not found"}}
+			}},
+		))
+	})
+
+	It("reports the stats of a collection", func() {
+		_, info, err := GetCollectionInfo(context.Background(), nil, GetCollectionInfoInput{Name: "docs"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(info).To(Equal(CollectionInfo{Name: "docs", DocumentCount: 2, TotalSize: 2048, CreatedAt: mockTimestamp}))
+	})
+
+	It("counts the entries when no count is reported", func() {
+		_, info, err := GetCollectionInfo(context.Background(), nil, GetCollectionInfoInput{Name: "notes"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(info).To(Equal(CollectionInfo{Name: "notes", Empty: true}))
+	})
+
+	It("requires a name", func() {
+		_, _, err := GetCollectionInfo(context.Background(), nil, GetCollectionInfoInput{})
+		Expect(err).To(MatchError("name is required"))
+	})
+
+	It("lists collections without stats by default", func() {
+		_, out, err := ListCollections(context.Background(), nil, ListCollectionsInput{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(out.Collections).To(Equal([]string{"docs", "notes", "broken"}))
+		Expect(out.Details).To(BeNil())
+	})
+
+	It("reports the collections it cannot inspect along with the others", func() {
+		_, out, err := ListCollections(context.Background(), nil, ListCollectionsInput{IncludeStats: true})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(out.Details).To(HaveLen(3))
+		Expect(out.Details[0].DocumentCount).To(Equal(2))
+		Expect(out.Details[1].Empty).To(BeTrue())
+		Expect(out.Details[2].Name).To(Equal("broken"))
+		Expect(out.Details[2].Error).To(ContainSubstring("collection not found"))
+	})
+})
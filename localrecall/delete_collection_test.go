@@ -0,0 +1,50 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"sync"
+
+	"github.com/mudler/mcps/pkg/mock"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("delete_collection", func() {
+	var (
+		mutex   sync.Mutex
+		deleted []string
+	)
+
+	BeforeEach(func() {
+		deleted = nil
+		useMockLocalRecall(mock.NewClient(
+			mock.Route{Method: http.MethodDelete, Pattern: "/api/collections/*", Handler: func(req *http.Request) (int, interface{}) {
+				if req.URL.Path == "/api/collections/missing" {
+					return http.StatusNotFound, APIResponse{Error: &APIError{Code: "NOT_FOUND", Message: "collection not found"}}
+				}
+				mutex.Lock()
+				defer mutex.Unlock()
+				deleted = append(deleted, req.URL.Path)
+				return mockOK(map[string]interface{}{"deleted_at": mockTimestamp})
+			}},
+		))
+	})
+
+	It("deletes the collection", func() {
+		_, out, err := DeleteCollection(context.Background(), nil, DeleteCollectionInput{Name: "notes"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(out).To(Equal(DeleteCollectionOutput{Name: "notes", DeletedAt: mockTimestamp}))
+		Expect(deleted).To(Equal([]string{"/api/collections/notes"}))
+	})
+
+	It("reports errors of LocalRecall", func() {
+		_, _, err := DeleteCollection(context.Background(), nil, DeleteCollectionInput{Name: "missing"})
+		Expect(err).To(MatchError("API error: NOT_FOUND: collection not found"))
+	})
+
+	It("is enabled by LOCALRECALL_ENABLED_TOOLS", func() {
+		Expect(parseEnabledTools("")).To(HaveKey("delete_collection"))
+		Expect(parseEnabledTools("search")).NotTo(HaveKey("delete_collection"))
+	})
+})
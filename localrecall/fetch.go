@@ -0,0 +1,143 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"path"
+	"syscall"
+	"time"
+)
+
+// maxFetchSize bounds documents downloaded with file_url
+const maxFetchSize = 64 << 20
+
+// fetchClient downloads file_url documents. It is separate from httpClient,
+// which only talks to LocalRecall. main replaces it when
+// LOCALRECALL_FETCH_ALLOW_PRIVATE is set.
+var fetchClient = newFetchClient(false)
+
+// newFetchClient returns a client checking every address it connects to with
+// guardDial, redirects included, unless allowPrivate is set. The check runs
+// on the resolved IP, so a host name cannot point it to an internal address.
+// Proxies are not used, as the proxy would be the address checked.
+func newFetchClient(allowPrivate bool) *http.Client {
+	dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}
+	if !allowPrivate {
+		dialer.Control = guardDial
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	// Bounds async downloads too, which have no overall timeout
+	transport.ResponseHeaderTimeout = 30 * time.Second
+	return &http.Client{Timeout: 30 * time.Second, Transport: transport}
+}
+
+// guardDial refuses connections to loopback, private and link-local
+// addresses, such as the 169.254.169.254 cloud metadata endpoint
+func guardDial(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return err
+	}
+	addr = addr.Unmap()
+	if addr.IsLoopback() || addr.IsPrivate() || addr.IsLinkLocalUnicast() || addr.IsLinkLocalMulticast() || addr.IsUnspecified() {
+		return fmt.Errorf("refusing to fetch from %s, a loopback, private or link-local address (set LOCALRECALL_FETCH_ALLOW_PRIVATE=true to allow it)", addr)
+	}
+	return nil
+}
+
+// document is the content of add_document: held in memory, or streamed from
+// a download
+type document struct {
+	data []byte
+	// stream is read once while uploading, then closed; nil for data
+	stream io.ReadCloser
+}
+
+// close releases the download of a streamed document
+func (d document) close() {
+	if d.stream != nil {
+		d.stream.Close()
+	}
+}
+
+// fetchDocument starts downloading the document at rawURL with client. It
+// returns the document, streamed from the response, and the last element of
+// the URL path, to be used when no filename is given. The download fails
+// once it exceeds maxFetchSize.
+func fetchDocument(ctx context.Context, client *http.Client, rawURL string) (document, string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return document{}, "", fmt.Errorf("invalid file_url: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return document{}, "", fmt.Errorf("invalid file_url %q: only http and https URLs are supported", rawURL)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return document{}, "", fmt.Errorf("failed to create request: %w", err)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return document{}, "", fmt.Errorf("failed to fetch file_url: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		resp.Body.Close()
+		return document{}, "", fmt.Errorf("failed to fetch file_url: %s", resp.Status)
+	}
+	if resp.ContentLength > maxFetchSize {
+		resp.Body.Close()
+		return document{}, "", errFetchTooLarge
+	}
+
+	name := path.Base(u.Path)
+	if name == "." || name == "/" {
+		name = ""
+	}
+	return document{stream: &limitedBody{body: resp.Body, left: maxFetchSize}}, name, nil
+}
+
+// errFetchTooLarge is returned for file_url documents over maxFetchSize
+var errFetchTooLarge = fmt.Errorf("file_url document larger than %d MB", maxFetchSize>>20)
+
+// limitedBody reads a response body, failing with errFetchTooLarge once more
+// than left bytes were read
+type limitedBody struct {
+	body io.ReadCloser
+	left int64
+}
+
+func (l *limitedBody) Read(p []byte) (int, error) {
+	if l.left < 0 {
+		return 0, errFetchTooLarge
+	}
+	// Read one byte past the limit to tell a document of exactly the
+	// limit from a larger one
+	if int64(len(p)) > l.left+1 {
+		p = p[:l.left+1]
+	}
+	n, err := l.body.Read(p)
+	l.left -= int64(n)
+	if l.left < 0 {
+		return 0, errFetchTooLarge
+	}
+	if err != nil && err != io.EOF {
+		err = fmt.Errorf("failed to read file_url: %w", err)
+	}
+	return n, err
+}
+
+func (l *limitedBody) Close() error {
+	return l.body.Close()
+}
@@ -0,0 +1,215 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// upload is a document received by fakeLocalRecall
+type upload struct {
+	filename      string
+	content       string
+	contentLength int64
+}
+
+// fakeLocalRecall accepts uploads to any collection and records them
+type fakeLocalRecall struct {
+	mutex   sync.Mutex
+	uploads []upload
+}
+
+func (f *fakeLocalRecall) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(APIResponse{Error: &APIError{Code: "bad_request", Message: err.Error()}})
+		return
+	}
+	content, _ := io.ReadAll(file)
+	f.mutex.Lock()
+	f.uploads = append(f.uploads, upload{filename: header.Filename, content: string(content), contentLength: r.ContentLength})
+	f.mutex.Unlock()
+	json.NewEncoder(w).Encode(APIResponse{Success: true, Data: map[string]interface{}{"uploaded_at": "2025-01-15T10:30:00Z"}})
+}
+
+// contents returns the content of every upload
+func (f *fakeLocalRecall) contents() []string {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	contents := []string{}
+	for _, u := range f.uploads {
+		contents = append(contents, u.content)
+	}
+	return contents
+}
+
+func (f *fakeLocalRecall) received() []upload {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return append([]upload(nil), f.uploads...)
+}
+
+var _ = Describe("file_url", func() {
+	var (
+		localRecall *fakeLocalRecall
+		documents   *httptest.Server
+	)
+
+	BeforeEach(func() {
+		localRecall = &fakeLocalRecall{}
+		server := httptest.NewServer(localRecall)
+		DeferCleanup(server.Close)
+
+		prevURL, prevClient, prevFetchClient := localRecallURL, httpClient, fetchClient
+		DeferCleanup(func() { localRecallURL, httpClient, fetchClient = prevURL, prevClient, prevFetchClient })
+		localRecallURL, httpClient = server.URL, server.Client()
+
+		documents = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/", "/docs/guide.md":
+				io.WriteString(w, "# Guide\n")
+			case "/slow":
+				// Slower than a short client timeout, after the headers
+				io.WriteString(w, "# Slow\n")
+				w.(http.Flusher).Flush()
+				time.Sleep(300 * time.Millisecond)
+				io.WriteString(w, "done\n")
+			case "/redirect":
+				http.Redirect(w, r, "/docs/guide.md", http.StatusFound)
+			case "/large":
+				// Streamed without a Content-Length
+				for range (maxFetchSize >> 20) + 1 {
+					w.Write(make([]byte, 1<<20))
+					w.(http.Flusher).Flush()
+				}
+			case "/large-declared":
+				w.Header().Set("Content-Length", "100000000")
+				w.WriteHeader(http.StatusOK)
+			default:
+				http.NotFound(w, r)
+			}
+		}))
+		DeferCleanup(documents.Close)
+	})
+
+	Describe("address checks", func() {
+		DescribeTable("refuses internal addresses",
+			func(address string) {
+				Expect(guardDial("tcp", address, nil)).To(MatchError(ContainSubstring("refusing to fetch from")))
+			},
+			Entry("loopback", "127.0.0.1:80"),
+			Entry("loopback range", "127.1.2.3:8080"),
+			Entry("IPv6 loopback", "[::1]:80"),
+			Entry("10/8", "10.0.0.1:80"),
+			Entry("172.16/12", "172.31.255.1:443"),
+			Entry("192.168/16", "192.168.1.1:80"),
+			Entry("cloud metadata", "169.254.169.254:80"),
+			Entry("IPv6 link-local", "[fe80::1%eth0]:80"),
+			Entry("IPv6 unique local", "[fd00::1]:80"),
+			Entry("IPv4-mapped loopback", "[::ffff:127.0.0.1]:80"),
+			Entry("unspecified", "0.0.0.0:80"),
+		)
+
+		DescribeTable("allows public addresses",
+			func(address string) {
+				Expect(guardDial("tcp", address, nil)).To(Succeed())
+			},
+			Entry("IPv4", "93.184.216.34:443"),
+			Entry("IPv6", "[2606:4700::6810:84e5]:443"),
+			Entry("outside 172.16/12", "172.32.0.1:80"),
+		)
+
+		It("allows internal addresses when opted out", func() {
+			fetchClient = newFetchClient(true)
+			_, _, err := addDocumentWithCollection(context.Background(), "docs", "", "", documents.URL+"/docs/guide.md", "", false)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("checks the resolved address of the URL", func() {
+			for _, rawURL := range []string{documents.URL + "/docs/guide.md", strings.Replace(documents.URL, "127.0.0.1", "localhost", 1) + "/docs/guide.md"} {
+				_, _, err := addDocumentWithCollection(context.Background(), "docs", "", "", rawURL, "", false)
+				Expect(err).To(MatchError(ContainSubstring("refusing to fetch from 127.0.0.1")))
+			}
+			Expect(localRecall.received()).To(BeEmpty())
+		})
+	})
+
+	Describe("uploads", func() {
+		BeforeEach(func() {
+			// The test servers listen on loopback
+			fetchClient = newFetchClient(true)
+		})
+
+		It("streams the document to LocalRecall", func() {
+			_, out, err := addDocumentWithCollection(context.Background(), "docs", "", "", documents.URL+"/docs/guide.md", "", false)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(out.Filename).To(Equal("guide.md"))
+			Expect(out.UploadedAt).To(Equal("2025-01-15T10:30:00Z"))
+
+			Expect(localRecall.received()).To(HaveLen(1))
+			received := localRecall.received()[0]
+			Expect(received.filename).To(Equal("guide.md"))
+			Expect(received.content).To(Equal("# Guide\n"))
+			// Streamed bodies have no length known in advance
+			Expect(received.contentLength).To(Equal(int64(-1)))
+		})
+
+		It("follows redirects", func() {
+			_, _, err := addDocumentWithCollection(context.Background(), "docs", "", "", documents.URL+"/redirect", "guide.md", false)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(localRecall.contents()).To(Equal([]string{"# Guide\n"}))
+		})
+
+		It("streams async uploads after the tool call returns", func() {
+			ctx, cancel := context.WithCancel(context.Background())
+			_, out, err := addDocumentWithCollection(ctx, "docs", "", "", documents.URL+"/docs/guide.md", "", true)
+			cancel()
+			Expect(err).NotTo(HaveOccurred())
+			Eventually(func() string {
+				job, _ := jobs.get(out.JobID)
+				return job.Status
+			}).Should(Equal(jobCompleted))
+			Expect(localRecall.contents()).To(Equal([]string{"# Guide\n"}))
+		})
+
+		It("lets async downloads outlast the timeout of calls", func() {
+			fetchClient.Timeout = 100 * time.Millisecond
+			_, _, err := addDocumentWithCollection(context.Background(), "docs", "", "", documents.URL+"/slow", "slow.md", false)
+			Expect(err).To(HaveOccurred())
+
+			_, out, err := addDocumentWithCollection(context.Background(), "docs", "", "", documents.URL+"/slow", "slow.md", true)
+			Expect(err).NotTo(HaveOccurred())
+			Eventually(func() IngestionJob {
+				job, _ := jobs.get(out.JobID)
+				return job
+			}).Should(HaveField("Status", jobCompleted))
+			Expect(localRecall.contents()).To(Equal([]string{"# Slow\ndone\n"}))
+		})
+
+		It("reports failed downloads at once", func() {
+			_, _, err := addDocumentWithCollection(context.Background(), "docs", "", "", documents.URL+"/missing.md", "", true)
+			Expect(err).To(MatchError(ContainSubstring("404 Not Found")))
+			_, _, err = addDocumentWithCollection(context.Background(), "docs", "", "", documents.URL+"/", "", false)
+			Expect(err).To(MatchError(ContainSubstring("filename required")))
+			Expect(localRecall.received()).To(BeEmpty())
+		})
+
+		It("refuses documents larger than the limit", func() {
+			_, _, err := addDocumentWithCollection(context.Background(), "docs", "", "", documents.URL+"/large-declared", "large.bin", false)
+			Expect(err).To(MatchError(errFetchTooLarge))
+
+			_, _, err = addDocumentWithCollection(context.Background(), "docs", "", "", documents.URL+"/large", "large.bin", false)
+			Expect(err).To(MatchError(ContainSubstring("larger than 64 MB")))
+			Expect(localRecall.received()).To(BeEmpty())
+		})
+	})
+})
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+const (
+	// defaultIngestTimeout bounds a background upload, which is not limited
+	// by the 30 second timeout of synchronous calls
+	defaultIngestTimeout = 10 * time.Minute
+	// maxIngestionJobs is the number of jobs remembered, finished jobs are
+	// forgotten oldest first beyond it
+	maxIngestionJobs = 100
+)
+
+// Job states
+const (
+	jobRunning   = "running"
+	jobCompleted = "completed"
+	jobFailed    = "failed"
+)
+
+// ingestTimeout is set with LOCALRECALL_INGEST_TIMEOUT
+var ingestTimeout = defaultIngestTimeout
+
+// IngestionJob tracks a document uploaded with async=true. LocalRecall has
+// no job API, so jobs are tracked by this server and lost on restart.
+type IngestionJob struct {
+	ID         string `json:"id" jsonschema:"the job ID"`
+	Collection string `json:"collection" jsonschema:"the name of the collection"`
+	Filename   string `json:"filename" jsonschema:"the filename of the document"`
+	Status     string `json:"status" jsonschema:"running, completed or failed"`
+	Error      string `json:"error,omitempty" jsonschema:"why the ingestion failed"`
+	StartedAt  string `json:"started_at" jsonschema:"when the job started"`
+	FinishedAt string `json:"finished_at,omitempty" jsonschema:"when the job completed or failed"`
+	UploadedAt string `json:"uploaded_at,omitempty" jsonschema:"timestamp reported by LocalRecall when the document was stored"`
+}
+
+type GetIngestionStatusInput struct {
+	JobID string `json:"job_id,omitempty" jsonschema:"the job ID returned by add_document (default: list all known jobs, most recent first)"`
+}
+
+type GetIngestionStatusOutput struct {
+	Jobs  []IngestionJob `json:"jobs" jsonschema:"the requested job, or all known jobs"`
+	Count int            `json:"count" jsonschema:"number of jobs returned"`
+}
+
+// ingestionJobs holds the async uploads of this process
+type ingestionJobs struct {
+	mutex sync.Mutex
+	next  int
+	jobs  map[string]*IngestionJob
+	order []string
+}
+
+var jobs = &ingestionJobs{jobs: map[string]*IngestionJob{}}
+
+// start registers a running job and uploads the document in the background
+func (j *ingestionJobs) start(collectionName, filename string, doc document) IngestionJob {
+	j.mutex.Lock()
+	j.next++
+	job := &IngestionJob{
+		ID:         fmt.Sprintf("ingest-%d", j.next),
+		Collection: collectionName,
+		Filename:   filename,
+		Status:     jobRunning,
+		StartedAt:  time.Now().UTC().Format(time.RFC3339),
+	}
+	j.jobs[job.ID] = job
+	j.order = append(j.order, job.ID)
+	j.pruneLocked()
+	snapshot := *job
+	j.mutex.Unlock()
+
+	go func() {
+		defer doc.close()
+		// The upload outlives the tool call, so it gets its own deadline
+		ctx, cancel := context.WithTimeout(context.Background(), ingestTimeout)
+		defer cancel()
+		client := *httpClient
+		client.Timeout = 0
+
+		uploadedAt, err := uploadDocument(ctx, &client, collectionName, filename, doc)
+
+		j.mutex.Lock()
+		defer j.mutex.Unlock()
+		job.FinishedAt = time.Now().UTC().Format(time.RFC3339)
+		if err != nil {
+			job.Status = jobFailed
+			job.Error = err.Error()
+			debugLog("Ingestion job %s failed: %v", job.ID, err)
+			return
+		}
+		job.Status = jobCompleted
+		job.UploadedAt = uploadedAt
+		debugLog("Ingestion job %s completed", job.ID)
+	}()
+
+	return snapshot
+}
+
+// pruneLocked forgets the oldest finished jobs beyond maxIngestionJobs.
+// Running jobs are always kept.
+func (j *ingestionJobs) pruneLocked() {
+	excess := len(j.order) - maxIngestionJobs
+	if excess <= 0 {
+		return
+	}
+	kept := j.order[:0]
+	for _, id := range j.order {
+		if excess > 0 && j.jobs[id].Status != jobRunning {
+			delete(j.jobs, id)
+			excess--
+			continue
+		}
+		kept = append(kept, id)
+	}
+	j.order = kept
+}
+
+// get returns a copy of a job
+func (j *ingestionJobs) get(id string) (IngestionJob, bool) {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	job, ok := j.jobs[id]
+	if !ok {
+		return IngestionJob{}, false
+	}
+	return *job, true
+}
+
+// list returns copies of all jobs, most recent first
+func (j *ingestionJobs) list() []IngestionJob {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	list := make([]IngestionJob, 0, len(j.order))
+	for i := len(j.order) - 1; i >= 0; i-- {
+		list = append(list, *j.jobs[j.order[i]])
+	}
+	return list
+}
+
+// uploadDocument stores a document in a collection and returns the upload
+// timestamp reported by LocalRecall
+func uploadDocument(ctx context.Context, client *http.Client, collectionName, filename string, doc document) (string, error) {
+	apiResp, err := makeMultipartRequest(ctx, client, fmt.Sprintf("/api/collections/%s/upload", collectionName), filename, doc)
+	if err != nil {
+		return "", err
+	}
+
+	// Extract data from response
+	data, ok := apiResp.Data.(map[string]interface{})
+	if !ok {
+		return "", fmt.Errorf("unexpected response data format")
+	}
+
+	uploadedAt := ""
+	if uploadedAtVal, ok := data["uploaded_at"].(string); ok {
+		uploadedAt = uploadedAtVal
+	}
+	return uploadedAt, nil
+}
+
+// GetIngestionStatus reports the progress of documents added with async=true
+func GetIngestionStatus(ctx context.Context, req *mcp.CallToolRequest, input GetIngestionStatusInput) (
+	*mcp.CallToolResult,
+	GetIngestionStatusOutput,
+	error,
+) {
+	if input.JobID != "" {
+		job, ok := jobs.get(input.JobID)
+		if !ok {
+			return nil, GetIngestionStatusOutput{}, fmt.Errorf("unknown job %q (jobs are forgotten when the server restarts)", input.JobID)
+		}
+		return nil, GetIngestionStatusOutput{Jobs: []IngestionJob{job}, Count: 1}, nil
+	}
+
+	list := jobs.list()
+	return nil, GetIngestionStatusOutput{Jobs: list, Count: len(list)}, nil
+}
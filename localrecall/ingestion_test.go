@@ -0,0 +1,157 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/mudler/mcps/pkg/mock"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// useJobs gives the spec its own ingestion jobs
+func useJobs() {
+	DeferCleanup(func(previous *ingestionJobs) { jobs = previous }, jobs)
+	jobs = &ingestionJobs{jobs: map[string]*IngestionJob{}}
+}
+
+// useMockLocalRecall sends the requests to LocalRecall to client
+func useMockLocalRecall(client *http.Client) {
+	DeferCleanup(func(url string, previous *http.Client) { localRecallURL, httpClient = url, previous }, localRecallURL, httpClient)
+	localRecallURL, httpClient = "http://localrecall.test", client
+}
+
+var _ = Describe("Async ingestion", func() {
+	var (
+		release chan struct{}
+		status  int
+	)
+
+	BeforeEach(func() {
+		useJobs()
+		release, status = make(chan struct{}), http.StatusOK
+		// Uploads wait for release, then answer with status
+		useMockLocalRecall(mock.NewClient(
+			mock.Route{Method: http.MethodPost, Pattern: "/api/collections/*/upload", Handler: func(*http.Request) (int, interface{}) {
+				<-release
+				if status != http.StatusOK {
+					return status, APIResponse{Error: &APIError{Code: "storage_full", Message: "no space left"}}
+				}
+				return mockOK(map[string]interface{}{"uploaded_at": mockTimestamp})
+			}},
+		))
+		// Uploads still running would use the next spec's client
+		DeferCleanup(func() {
+			Eventually(func() []IngestionJob {
+				running := []IngestionJob{}
+				for _, job := range jobs.list() {
+					if job.Status == jobRunning {
+						running = append(running, job)
+					}
+				}
+				return running
+			}).Should(BeEmpty())
+		})
+	})
+
+	jobStatus := func(id string) func() IngestionJob {
+		return func() IngestionJob {
+			job, _ := jobs.get(id)
+			return job
+		}
+	}
+
+	It("reports a running job until the upload completes", func() {
+		_, out, err := addDocumentWithCollection(context.Background(), "docs", "", "content", "", "doc.txt", true)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(out.JobID).To(Equal("ingest-1"))
+		Expect(out.Status).To(Equal(jobRunning))
+
+		_, status, err := GetIngestionStatus(context.Background(), nil, GetIngestionStatusInput{JobID: out.JobID})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(status.Jobs).To(HaveLen(1))
+		Expect(status.Jobs[0].Status).To(Equal(jobRunning))
+		Expect(status.Jobs[0].FinishedAt).To(BeEmpty())
+
+		close(release)
+		Eventually(jobStatus(out.JobID)).Should(And(
+			HaveField("Status", jobCompleted),
+			HaveField("UploadedAt", mockTimestamp),
+			HaveField("FinishedAt", Not(BeEmpty())),
+		))
+	})
+
+	It("reports the error of a failed upload", func() {
+		status = http.StatusInternalServerError
+		close(release)
+		_, out, err := addDocumentWithCollection(context.Background(), "docs", "", "content", "", "doc.txt", true)
+		Expect(err).NotTo(HaveOccurred())
+
+		Eventually(jobStatus(out.JobID)).Should(And(
+			HaveField("Status", jobFailed),
+			HaveField("Error", ContainSubstring("storage_full: no space left")),
+		))
+	})
+
+	It("lists the jobs most recent first", func() {
+		close(release)
+		for _, name := range []string{"a.txt", "b.txt"} {
+			_, _, err := addDocumentWithCollection(context.Background(), "docs", "", "content", "", name, true)
+			Expect(err).NotTo(HaveOccurred())
+		}
+		_, out, err := GetIngestionStatus(context.Background(), nil, GetIngestionStatusInput{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(out.Count).To(Equal(2))
+		Expect(out.Jobs[0].Filename).To(Equal("b.txt"))
+		Expect(out.Jobs[1].Filename).To(Equal("a.txt"))
+	})
+
+	It("fails for an unknown job", func() {
+		_, _, err := GetIngestionStatus(context.Background(), nil, GetIngestionStatusInput{JobID: "ingest-42"})
+		Expect(err).To(MatchError(ContainSubstring(`unknown job "ingest-42"`)))
+	})
+})
+
+var _ = Describe("pruneLocked", func() {
+	// newJobs returns jobs holding count jobs, those listed in running
+	// still running
+	newJobs := func(count int, running ...int) *ingestionJobs {
+		j := &ingestionJobs{jobs: map[string]*IngestionJob{}}
+		for i := 1; i <= count; i++ {
+			job := &IngestionJob{ID: fmt.Sprintf("ingest-%d", i), Status: jobCompleted}
+			for _, r := range running {
+				if r == i {
+					job.Status = jobRunning
+				}
+			}
+			j.jobs[job.ID] = job
+			j.order = append(j.order, job.ID)
+		}
+		return j
+	}
+
+	It("forgets the oldest finished jobs", func() {
+		j := newJobs(maxIngestionJobs+2, 1)
+		j.pruneLocked()
+		Expect(j.order).To(HaveLen(maxIngestionJobs))
+		Expect(j.jobs).To(HaveLen(maxIngestionJobs))
+		Expect(j.order[:2]).To(Equal([]string{"ingest-1", "ingest-4"}))
+	})
+
+	It("keeps running jobs beyond the limit", func() {
+		running := make([]int, 0, maxIngestionJobs+1)
+		for i := 1; i <= maxIngestionJobs+1; i++ {
+			running = append(running, i)
+		}
+		j := newJobs(maxIngestionJobs+1, running...)
+		j.pruneLocked()
+		Expect(j.order).To(HaveLen(maxIngestionJobs + 1))
+	})
+
+	It("keeps every job under the limit", func() {
+		j := newJobs(3)
+		j.pruneLocked()
+		Expect(j.order).To(Equal([]string{"ingest-1", "ingest-2", "ingest-3"}))
+	})
+})
@@ -10,10 +10,15 @@ import (
 	"mime/multipart"
 	"net/http"
 	"os"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/mudler/mcps/pkg/mock"
+	"github.com/mudler/mcps/pkg/safepath"
+	"github.com/mudler/mcps/pkg/selfcheck"
+	"github.com/mudler/mcps/pkg/transport"
 )
 
 // Global HTTP client for LocalRecall API
@@ -23,6 +28,10 @@ var apiKey string
 var defaultCollectionName string
 var debugMode bool
 
+// filesGuard validates file_path, confining it to LOCALRECALL_FILES_ROOT
+// when set
+var filesGuard safepath.Guard
+
 // debugLog prints debug messages only when DEBUG=1 is set
 func debugLog(format string, args ...interface{}) {
 	if debugMode {
@@ -64,17 +73,25 @@ type ResetCollectionInput struct {
 	Name string `json:"name" jsonschema:"the name of the collection to reset"`
 }
 
+type DeleteCollectionInput struct {
+	Name string `json:"name" jsonschema:"the name of the collection to delete"`
+}
+
 type AddDocumentInput struct {
 	CollectionName string `json:"collection_name" jsonschema:"the name of the collection"`
-	FilePath       string `json:"file_path,omitempty" jsonschema:"path to the file to upload (mutually exclusive with file_content)"`
-	FileContent    string `json:"file_content,omitempty" jsonschema:"file content as string (mutually exclusive with file_path)"`
-	Filename       string `json:"filename" jsonschema:"the filename for the document"`
+	FilePath       string `json:"file_path,omitempty" jsonschema:"path to the file to upload (mutually exclusive with file_content and file_url)"`
+	FileContent    string `json:"file_content,omitempty" jsonschema:"file content as string (mutually exclusive with file_path and file_url)"`
+	FileURL        string `json:"file_url,omitempty" jsonschema:"http(s) URL of a document to fetch and upload (mutually exclusive with file_path and file_content)"`
+	Filename       string `json:"filename" jsonschema:"the filename for the document (default with file_url: the last element of the URL path)"`
+	Async          bool   `json:"async,omitempty" jsonschema:"return a job ID immediately and ingest in the background, poll it with get_ingestion_status (default: false)"`
 }
 
 type AddDocumentInputWithoutCollection struct {
-	FilePath    string `json:"file_path,omitempty" jsonschema:"path to the file to upload (mutually exclusive with file_content)"`
-	FileContent string `json:"file_content,omitempty" jsonschema:"file content as string (mutually exclusive with file_path)"`
-	Filename    string `json:"filename" jsonschema:"the filename for the document"`
+	FilePath    string `json:"file_path,omitempty" jsonschema:"path to the file to upload (mutually exclusive with file_content and file_url)"`
+	FileContent string `json:"file_content,omitempty" jsonschema:"file content as string (mutually exclusive with file_path and file_url)"`
+	FileURL     string `json:"file_url,omitempty" jsonschema:"http(s) URL of a document to fetch and upload (mutually exclusive with file_path and file_content)"`
+	Filename    string `json:"filename" jsonschema:"the filename for the document (default with file_url: the last element of the URL path)"`
+	Async       bool   `json:"async,omitempty" jsonschema:"return a job ID immediately and ingest in the background, poll it with get_ingestion_status (default: false)"`
 }
 
 type ListFilesInput struct {
@@ -94,11 +111,23 @@ type DeleteEntryInputWithoutCollection struct {
 }
 
 // Output types for tools
+
+// SearchResult is a chunk of a document matching a search
+type SearchResult struct {
+	ID         string                 `json:"id,omitempty" jsonschema:"the ID of the matching chunk"`
+	Collection string                 `json:"collection,omitempty" jsonschema:"the collection the chunk was found in, set by search_all"`
+	Content    string                 `json:"content" jsonschema:"the text of the matching chunk"`
+	Source     string                 `json:"source,omitempty" jsonschema:"the document the chunk comes from, to cite it"`
+	Score      float64                `json:"score" jsonschema:"similarity of the chunk to the query, higher is closer"`
+	Metadata   map[string]interface{} `json:"metadata,omitempty" jsonschema:"metadata stored with the chunk"`
+	Raw        map[string]interface{} `json:"raw,omitempty" jsonschema:"the result as returned by LocalRecall, set when it lacks the content, source or score"`
+}
+
 type SearchOutput struct {
-	Query      string                   `json:"query" jsonschema:"the search query"`
-	MaxResults int                      `json:"max_results" jsonschema:"maximum number of results requested"`
-	Results    []map[string]interface{} `json:"results" jsonschema:"search results"`
-	Count      int                      `json:"count" jsonschema:"number of results returned"`
+	Query      string         `json:"query" jsonschema:"the search query"`
+	MaxResults int            `json:"max_results" jsonschema:"maximum number of results requested"`
+	Results    []SearchResult `json:"results" jsonschema:"search results"`
+	Count      int            `json:"count" jsonschema:"number of results returned"`
 }
 
 type CreateCollectionOutput struct {
@@ -111,15 +140,23 @@ type ResetCollectionOutput struct {
 	ResetAt    string `json:"reset_at" jsonschema:"timestamp when the collection was reset"`
 }
 
+type DeleteCollectionOutput struct {
+	Name      string `json:"name" jsonschema:"the name of the deleted collection"`
+	DeletedAt string `json:"deleted_at" jsonschema:"timestamp when the collection was deleted"`
+}
+
 type AddDocumentOutput struct {
 	Filename   string `json:"filename" jsonschema:"the filename of the uploaded document"`
 	Collection string `json:"collection" jsonschema:"the name of the collection"`
-	UploadedAt string `json:"uploaded_at" jsonschema:"timestamp when the document was uploaded"`
+	UploadedAt string `json:"uploaded_at,omitempty" jsonschema:"timestamp when the document was uploaded (empty for async uploads)"`
+	JobID      string `json:"job_id,omitempty" jsonschema:"the ingestion job ID for async uploads"`
+	Status     string `json:"status,omitempty" jsonschema:"the ingestion job status for async uploads"`
 }
 
 type ListCollectionsOutput struct {
-	Collections []string `json:"collections" jsonschema:"list of collection names"`
-	Count       int      `json:"count" jsonschema:"number of collections"`
+	Collections []string         `json:"collections" jsonschema:"list of collection names"`
+	Count       int              `json:"count" jsonschema:"number of collections"`
+	Details     []CollectionInfo `json:"details,omitempty" jsonschema:"stats of each collection, with include_stats"`
 }
 
 type ListFilesOutput struct {
@@ -136,29 +173,32 @@ type DeleteEntryOutput struct {
 
 // makeRequest makes an HTTP request to the LocalRecall API
 func makeRequest(ctx context.Context, method, endpoint string, body interface{}) (*APIResponse, error) {
-	var reqBody io.Reader
+	var jsonData []byte
 	if body != nil {
-		jsonData, err := json.Marshal(body)
+		var err error
+		jsonData, err = json.Marshal(body)
 		if err != nil {
 			return nil, fmt.Errorf("failed to marshal request body: %w", err)
 		}
-		reqBody = bytes.NewBuffer(jsonData)
-	}
-
-	req, err := http.NewRequestWithContext(ctx, method, localRecallURL+endpoint, reqBody)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-
-	if body != nil {
-		req.Header.Set("Content-Type", "application/json")
-	}
-
-	if apiKey != "" {
-		req.Header.Set("Authorization", "Bearer "+apiKey)
 	}
 
-	resp, err := httpClient.Do(req)
+	resp, err := doWithRetry(ctx, httpClient, idempotentRequest(method, endpoint), func() (*http.Request, error) {
+		var reqBody io.Reader
+		if body != nil {
+			reqBody = bytes.NewReader(jsonData)
+		}
+		req, err := http.NewRequestWithContext(ctx, method, localRecallURL+endpoint, reqBody)
+		if err != nil {
+			return nil, err
+		}
+		if body != nil {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		if apiKey != "" {
+			req.Header.Set("Authorization", "Bearer "+apiKey)
+		}
+		return req, nil
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to make request: %w", err)
 	}
@@ -188,36 +228,52 @@ func makeRequest(ctx context.Context, method, endpoint string, body interface{})
 	return &apiResp, nil
 }
 
-// makeMultipartRequest makes a multipart form request for file uploads
-func makeMultipartRequest(ctx context.Context, endpoint, filename string, fileContent []byte) (*APIResponse, error) {
-	var buf bytes.Buffer
-	writer := multipart.NewWriter(&buf)
-
-	part, err := writer.CreateFormFile("file", filename)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create form file: %w", err)
-	}
-
-	if _, err := part.Write(fileContent); err != nil {
-		return nil, fmt.Errorf("failed to write file content: %w", err)
-	}
-
-	if err := writer.Close(); err != nil {
-		return nil, fmt.Errorf("failed to close multipart writer: %w", err)
-	}
-
-	req, err := http.NewRequestWithContext(ctx, "POST", localRecallURL+endpoint, &buf)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
+// makeMultipartRequest uploads a document as a multipart form. A document
+// held in memory is retried when LocalRecall could not be reached; a
+// download is streamed into the form as it arrives, so it is sent once.
+func makeMultipartRequest(ctx context.Context, client *http.Client, endpoint, filename string, doc document) (*APIResponse, error) {
+	var resp *http.Response
+	var err error
+	if doc.stream != nil {
+		body, writer := io.Pipe()
+		form := multipart.NewWriter(writer)
+		go func() {
+			part, err := form.CreateFormFile("file", filename)
+			if err == nil {
+				_, err = io.Copy(part, doc.stream)
+			}
+			if err == nil {
+				err = form.Close()
+			}
+			writer.CloseWithError(err)
+		}()
 
-	req.Header.Set("Content-Type", writer.FormDataContentType())
+		req, reqErr := newUploadRequest(ctx, endpoint, body, form.FormDataContentType())
+		if reqErr != nil {
+			body.Close()
+			return nil, fmt.Errorf("failed to create request: %w", reqErr)
+		}
+		resp, err = client.Do(req)
+	} else {
+		var buf bytes.Buffer
+		form := multipart.NewWriter(&buf)
+		part, partErr := form.CreateFormFile("file", filename)
+		if partErr != nil {
+			return nil, fmt.Errorf("failed to create form file: %w", partErr)
+		}
+		if _, err := part.Write(doc.data); err != nil {
+			return nil, fmt.Errorf("failed to write file content: %w", err)
+		}
+		if err := form.Close(); err != nil {
+			return nil, fmt.Errorf("failed to close multipart writer: %w", err)
+		}
 
-	if apiKey != "" {
-		req.Header.Set("Authorization", "Bearer "+apiKey)
+		// Uploads are not idempotent: they are only retried when LocalRecall
+		// could not be reached
+		resp, err = doWithRetry(ctx, client, false, func() (*http.Request, error) {
+			return newUploadRequest(ctx, endpoint, bytes.NewReader(buf.Bytes()), form.FormDataContentType())
+		})
 	}
-
-	resp, err := httpClient.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to make request: %w", err)
 	}
@@ -247,6 +303,19 @@ func makeMultipartRequest(ctx context.Context, endpoint, filename string, fileCo
 	return &apiResp, nil
 }
 
+// newUploadRequest creates the POST of a multipart form to LocalRecall
+func newUploadRequest(ctx context.Context, endpoint string, body io.Reader, contentType string) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, "POST", localRecallURL+endpoint, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", contentType)
+	if apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+apiKey)
+	}
+	return req, nil
+}
+
 // Search searches content in a LocalRecall collection
 func Search(ctx context.Context, req *mcp.CallToolRequest, input SearchInput) (
 	*mcp.CallToolResult,
@@ -292,11 +361,11 @@ func searchWithCollection(ctx context.Context, collectionName, query string, max
 		return nil, SearchOutput{}, fmt.Errorf("unexpected response data format")
 	}
 
-	results := []map[string]interface{}{}
+	results := []SearchResult{}
 	if resultsData, ok := data["results"].([]interface{}); ok {
 		for _, r := range resultsData {
 			if resultMap, ok := r.(map[string]interface{}); ok {
-				results = append(results, resultMap)
+				results = append(results, searchResultFromMap(resultMap))
 			}
 		}
 	}
@@ -316,6 +385,34 @@ func searchWithCollection(ctx context.Context, collectionName, query string, max
 	return nil, output, nil
 }
 
+// searchResultFromMap decodes a search result of the API. The raw result is
+// kept when a field is missing, so nothing LocalRecall returned is lost.
+func searchResultFromMap(result map[string]interface{}) SearchResult {
+	searchResult := SearchResult{}
+	if id, ok := result["id"].(string); ok {
+		searchResult.ID = id
+	}
+	content, hasContent := result["content"].(string)
+	searchResult.Content = content
+
+	score, hasScore := result["similarity"].(float64)
+	if !hasScore {
+		score, hasScore = result["score"].(float64)
+	}
+	searchResult.Score = score
+
+	if metadata, ok := result["metadata"].(map[string]interface{}); ok && len(metadata) > 0 {
+		searchResult.Metadata = metadata
+	}
+	source, hasSource := searchResult.Metadata["source"].(string)
+	searchResult.Source = source
+
+	if !hasContent || !hasScore || !hasSource {
+		searchResult.Raw = result
+	}
+	return searchResult
+}
+
 // CreateCollection creates a new collection
 func CreateCollection(ctx context.Context, req *mcp.CallToolRequest, input CreateCollectionInput) (
 	*mcp.CallToolResult,
@@ -380,13 +477,43 @@ func ResetCollection(ctx context.Context, req *mcp.CallToolRequest, input ResetC
 	return nil, output, nil
 }
 
+// DeleteCollection deletes a collection and all of its entries
+func DeleteCollection(ctx context.Context, req *mcp.CallToolRequest, input DeleteCollectionInput) (
+	*mcp.CallToolResult,
+	DeleteCollectionOutput,
+	error,
+) {
+	apiResp, err := makeRequest(ctx, "DELETE", fmt.Sprintf("/api/collections/%s", input.Name), nil)
+	if err != nil {
+		return nil, DeleteCollectionOutput{}, err
+	}
+
+	// Extract data from response
+	data, ok := apiResp.Data.(map[string]interface{})
+	if !ok {
+		return nil, DeleteCollectionOutput{}, fmt.Errorf("unexpected response data format")
+	}
+
+	deletedAt := ""
+	if deletedAtVal, ok := data["deleted_at"].(string); ok {
+		deletedAt = deletedAtVal
+	}
+
+	output := DeleteCollectionOutput{
+		Name:      input.Name,
+		DeletedAt: deletedAt,
+	}
+
+	return nil, output, nil
+}
+
 // AddDocument adds a document to a collection
 func AddDocument(ctx context.Context, req *mcp.CallToolRequest, input AddDocumentInput) (
 	*mcp.CallToolResult,
 	AddDocumentOutput,
 	error,
 ) {
-	return addDocumentWithCollection(ctx, input.CollectionName, input.FilePath, input.FileContent, input.Filename)
+	return addDocumentWithCollection(ctx, input.CollectionName, input.FilePath, input.FileContent, input.FileURL, input.Filename, input.Async)
 }
 
 // AddDocumentWithoutCollection adds a document to a collection using default collection
@@ -395,47 +522,81 @@ func AddDocumentWithoutCollection(ctx context.Context, req *mcp.CallToolRequest,
 	AddDocumentOutput,
 	error,
 ) {
-	return addDocumentWithCollection(ctx, defaultCollectionName, input.FilePath, input.FileContent, input.Filename)
+	return addDocumentWithCollection(ctx, defaultCollectionName, input.FilePath, input.FileContent, input.FileURL, input.Filename, input.Async)
 }
 
 // addDocumentWithCollection is the internal implementation for add document
-func addDocumentWithCollection(ctx context.Context, collectionName, filePath, fileContent, filename string) (
+func addDocumentWithCollection(ctx context.Context, collectionName, filePath, fileContent, fileURL, filename string, async bool) (
 	*mcp.CallToolResult,
 	AddDocumentOutput,
 	error,
 ) {
-	var fileContentBytes []byte
+	var doc document
 	var err error
 
-	if filePath != "" && fileContent != "" {
-		return nil, AddDocumentOutput{}, fmt.Errorf("cannot specify both file_path and file_content")
+	sources := 0
+	for _, source := range []string{filePath, fileContent, fileURL} {
+		if source != "" {
+			sources++
+		}
+	}
+	if sources > 1 {
+		return nil, AddDocumentOutput{}, fmt.Errorf("specify only one of file_path, file_content and file_url")
 	}
 
 	if filePath != "" {
-		fileContentBytes, err = os.ReadFile(filePath)
+		filePath, err = filesGuard.Resolve(filePath)
+		if err != nil {
+			return nil, AddDocumentOutput{}, fmt.Errorf("invalid file_path: %w", err)
+		}
+		doc.data, err = os.ReadFile(filePath)
 		if err != nil {
 			return nil, AddDocumentOutput{}, fmt.Errorf("failed to read file: %w", err)
 		}
 	} else if fileContent != "" {
-		fileContentBytes = []byte(fileContent)
+		doc.data = []byte(fileContent)
+	} else if fileURL != "" {
+		// An async download continues after the tool call returns, as long
+		// as its upload: the 30 second timeout of calls would cut it short
+		fetchCtx, client := ctx, fetchClient
+		if async {
+			fetchCtx = context.WithoutCancel(ctx)
+			background := *fetchClient
+			background.Timeout = 0
+			client = &background
+		}
+		var urlFilename string
+		doc, urlFilename, err = fetchDocument(fetchCtx, client, fileURL)
+		if err != nil {
+			return nil, AddDocumentOutput{}, err
+		}
+		if filename == "" {
+			if urlFilename == "" {
+				doc.close()
+				return nil, AddDocumentOutput{}, fmt.Errorf("filename required: file_url has no file name in its path")
+			}
+			filename = urlFilename
+		}
 	} else {
-		return nil, AddDocumentOutput{}, fmt.Errorf("must specify either file_path or file_content")
-	}
-
-	apiResp, err := makeMultipartRequest(ctx, fmt.Sprintf("/api/collections/%s/upload", collectionName), filename, fileContentBytes)
-	if err != nil {
-		return nil, AddDocumentOutput{}, err
+		return nil, AddDocumentOutput{}, fmt.Errorf("must specify one of file_path, file_content or file_url")
 	}
 
-	// Extract data from response
-	data, ok := apiResp.Data.(map[string]interface{})
-	if !ok {
-		return nil, AddDocumentOutput{}, fmt.Errorf("unexpected response data format")
+	// The file is read, or its download started, before returning so path
+	// and URL errors are reported at once
+	if async {
+		job := jobs.start(collectionName, filename, doc)
+		return nil, AddDocumentOutput{
+			Filename:   filename,
+			Collection: collectionName,
+			JobID:      job.ID,
+			Status:     job.Status,
+		}, nil
 	}
 
-	uploadedAt := ""
-	if uploadedAtVal, ok := data["uploaded_at"].(string); ok {
-		uploadedAt = uploadedAtVal
+	defer doc.close()
+	uploadedAt, err := uploadDocument(ctx, httpClient, collectionName, filename, doc)
+	if err != nil {
+		return nil, AddDocumentOutput{}, err
 	}
 
 	output := AddDocumentOutput{
@@ -448,7 +609,7 @@ func addDocumentWithCollection(ctx context.Context, collectionName, filePath, fi
 }
 
 // ListCollections lists all collections
-func ListCollections(ctx context.Context, req *mcp.CallToolRequest, input struct{}) (
+func ListCollections(ctx context.Context, req *mcp.CallToolRequest, input ListCollectionsInput) (
 	*mcp.CallToolResult,
 	ListCollectionsOutput,
 	error,
@@ -483,6 +644,18 @@ func ListCollections(ctx context.Context, req *mcp.CallToolRequest, input struct
 		Count:       count,
 	}
 
+	if input.IncludeStats {
+		output.Details = make([]CollectionInfo, 0, len(collections))
+		for _, name := range collections {
+			// One unreadable collection should not hide the others
+			info, err := collectionInfo(ctx, name)
+			if err != nil {
+				info = CollectionInfo{Name: name, Error: err.Error()}
+			}
+			output.Details = append(output.Details, info)
+		}
+	}
+
 	return nil, output, nil
 }
 
@@ -608,6 +781,52 @@ func deleteEntryWithCollection(ctx context.Context, collectionName, entry string
 	return nil, output, nil
 }
 
+// validTools are the tool names LOCALRECALL_ENABLED_TOOLS accepts
+var validTools = map[string]bool{
+	"search":               true,
+	"search_all":           true,
+	"create_collection":    true,
+	"reset_collection":     true,
+	"delete_collection":    true,
+	"add_document":         true,
+	"list_collections":     true,
+	"list_files":           true,
+	"delete_entry":         true,
+	"get_ingestion_status": true,
+	"get_collection_info":  true,
+}
+
+// parseEnabledTools parses LOCALRECALL_ENABLED_TOOLS, a comma-separated list
+// of tool names, empty enabling every tool
+func parseEnabledTools(value string) map[string]bool {
+	enabledTools := make(map[string]bool)
+	if value == "" {
+		for tool := range validTools {
+			enabledTools[tool] = true
+		}
+		return enabledTools
+	}
+
+	for _, tool := range strings.Split(value, ",") {
+		tool = strings.TrimSpace(tool)
+		if tool == "" {
+			continue
+		}
+		if validTools[tool] {
+			enabledTools[tool] = true
+		} else {
+			debugLog("Warning: Unknown tool name '%s' will be ignored", tool)
+		}
+	}
+	// async uploads of add_document can only be followed with
+	// get_ingestion_status
+	if enabledTools["add_document"] && !enabledTools["get_ingestion_status"] {
+		enabledTools["get_ingestion_status"] = true
+		debugLog("Tool 'get_ingestion_status' enabled along with 'add_document'")
+	}
+	return enabledTools
+}
+
 func main() {
 	// Check for debug mode
 	debugMode = os.Getenv("DEBUG") == "1"
@@ -621,46 +840,60 @@ func main() {
 	apiKey = os.Getenv("LOCALRECALL_API_KEY")
 	defaultCollectionName = os.Getenv("LOCALRECALL_COLLECTION")
 
+	if value := os.Getenv("LOCALRECALL_INGEST_TIMEOUT"); value != "" {
+		seconds, err := strconv.Atoi(value)
+		if err != nil || seconds <= 0 {
+			log.Printf("Warning: invalid LOCALRECALL_INGEST_TIMEOUT %q, using %d", value, int(defaultIngestTimeout.Seconds()))
+		} else {
+			ingestTimeout = time.Duration(seconds) * time.Second
+		}
+	}
+
+	if value := os.Getenv("LOCALRECALL_FETCH_ALLOW_PRIVATE"); value != "" {
+		allow, err := strconv.ParseBool(value)
+		if err != nil {
+			log.Printf("Warning: invalid LOCALRECALL_FETCH_ALLOW_PRIVATE %q, private addresses stay refused", value)
+		}
+		fetchClient = newFetchClient(allow)
+	}
+
+	var maxRetriesErr error
+	maxRetries, maxRetriesErr = parseMaxRetries(os.Getenv("LOCALRECALL_MAX_RETRIES"))
+
 	// Create HTTP client with timeout
 	httpClient = &http.Client{
 		Timeout: 30 * time.Second,
 	}
+	if mock.Enabled("LOCALRECALL") {
+		// Serve canned collections and search results instead of calling LocalRecall
+		httpClient = newMockHTTPClient()
+		debugLog("LOCALRECALL_MOCK enabled: using canned LocalRecall responses")
+	}
 
-	// Parse enabled tools
-	enabledToolsStr := os.Getenv("LOCALRECALL_ENABLED_TOOLS")
-	enabledTools := make(map[string]bool)
+	var filesRootErr error
+	filesGuard, filesRootErr = safepath.New(os.Getenv("LOCALRECALL_FILES_ROOT"))
+	if filesRootErr != nil {
+		filesRootErr = fmt.Errorf("invalid LOCALRECALL_FILES_ROOT: %w", filesRootErr)
+	}
 
-	// Valid tool names
-	validTools := map[string]bool{
-		"search":            true,
-		"create_collection": true,
-		"reset_collection":  true,
-		"add_document":      true,
-		"list_collections":  true,
-		"list_files":        true,
-		"delete_entry":      true,
-	}
-
-	if enabledToolsStr != "" {
-		// Parse comma-separated list
-		tools := strings.Split(enabledToolsStr, ",")
-		for _, tool := range tools {
-			tool = strings.TrimSpace(tool)
-			if tool == "" {
-				continue
-			}
-			if validTools[tool] {
-				enabledTools[tool] = true
-			} else {
-				debugLog("Warning: Unknown tool name '%s' will be ignored", tool)
-			}
+	if selfcheck.Enabled() {
+		checks := selfChecks()
+		if filesGuard.Root != "" || filesRootErr != nil {
+			checks = append(checks, selfcheck.Value("LOCALRECALL_FILES_ROOT", filesGuard.Root, filesRootErr))
 		}
-	} else {
-		// If not specified, enable all tools
-		for tool := range validTools {
-			enabledTools[tool] = true
+		if os.Getenv("LOCALRECALL_MAX_RETRIES") != "" {
+			checks = append(checks, selfcheck.Value("LOCALRECALL_MAX_RETRIES", strconv.Itoa(maxRetries), maxRetriesErr))
 		}
+		selfcheck.Exit("localrecall", checks...)
+	}
+	if filesRootErr != nil {
+		log.Fatal(filesRootErr)
 	}
+	if maxRetriesErr != nil {
+		log.Fatal(maxRetriesErr)
+	}
+
+	enabledTools := parseEnabledTools(os.Getenv("LOCALRECALL_ENABLED_TOOLS"))
 
 	// Create MCP server
 	server := mcp.NewServer(&mcp.Implementation{
@@ -671,7 +904,7 @@ func main() {
 	// Register tools based on enabled list
 	if enabledTools["search"] {
 		if defaultCollectionName != "" {
-			desc := fmt.Sprintf("Search content in LocalRecall collection '%s'", defaultCollectionName)
+			desc := fmt.Sprintf("Search content in LocalRecall collection '%s'%s", defaultCollectionName, retryNote(true))
 			mcp.AddTool(server, &mcp.Tool{
 				Name:        "search",
 				Description: desc,
@@ -680,12 +913,20 @@ func main() {
 		} else {
 			mcp.AddTool(server, &mcp.Tool{
 				Name:        "search",
-				Description: "Search content in a LocalRecall collection",
+				Description: "Search content in a LocalRecall collection" + retryNote(true),
 			}, Search)
 			debugLog("Tool 'search' enabled")
 		}
 	}
 
+	if enabledTools["search_all"] {
+		mcp.AddTool(server, &mcp.Tool{
+			Name:        "search_all",
+			Description: "Search content in every LocalRecall collection at once, when it is not known which one holds the answer. Results are merged by score, each tagged with its collection; collections that fail are skipped and listed" + retryNote(true),
+		}, SearchAll)
+		debugLog("Tool 'search_all' enabled")
+	}
+
 	if enabledTools["create_collection"] {
 		mcp.AddTool(server, &mcp.Tool{
 			Name:        "create_collection",
@@ -702,9 +943,17 @@ func main() {
 		debugLog("Tool 'reset_collection' enabled")
 	}
 
+	if enabledTools["delete_collection"] {
+		mcp.AddTool(server, &mcp.Tool{
+			Name:        "delete_collection",
+			Description: "Delete a collection and all of its entries from LocalRecall",
+		}, DeleteCollection)
+		debugLog("Tool 'delete_collection' enabled")
+	}
+
 	if enabledTools["add_document"] {
 		if defaultCollectionName != "" {
-			desc := fmt.Sprintf("Add a document to LocalRecall collection '%s'", defaultCollectionName)
+			desc := fmt.Sprintf("Add a document to LocalRecall collection '%s'%s", defaultCollectionName, retryNote(false))
 			mcp.AddTool(server, &mcp.Tool{
 				Name:        "add_document",
 				Description: desc,
@@ -713,20 +962,45 @@ func main() {
 		} else {
 			mcp.AddTool(server, &mcp.Tool{
 				Name:        "add_document",
-				Description: "Add a document to a LocalRecall collection",
+				Description: "Add a document to a LocalRecall collection" + retryNote(false),
 			}, AddDocument)
 			debugLog("Tool 'add_document' enabled")
 		}
 	}
 
+	if enabledTools["get_ingestion_status"] {
+		mcp.AddTool(server, &mcp.Tool{
+			Name:        "get_ingestion_status",
+			Description: "Get the status of documents added with async=true, by job ID or for all recent jobs",
+		}, GetIngestionStatus)
+		debugLog("Tool 'get_ingestion_status' enabled")
+	}
+
 	if enabledTools["list_collections"] {
 		mcp.AddTool(server, &mcp.Tool{
 			Name:        "list_collections",
-			Description: "List all collections in LocalRecall",
+			Description: "List all collections in LocalRecall, optionally with the document count of each",
 		}, ListCollections)
 		debugLog("Tool 'list_collections' enabled")
 	}
 
+	if enabledTools["get_collection_info"] {
+		if defaultCollectionName != "" {
+			desc := fmt.Sprintf("Get the document count and stats of LocalRecall collection '%s'", defaultCollectionName)
+			mcp.AddTool(server, &mcp.Tool{
+				Name:        "get_collection_info",
+				Description: desc,
+			}, GetCollectionInfoWithoutCollection)
+			debugLog("Tool 'get_collection_info' enabled (using default collection: %s)", defaultCollectionName)
+		} else {
+			mcp.AddTool(server, &mcp.Tool{
+				Name:        "get_collection_info",
+				Description: "Get the document count and stats of a LocalRecall collection, to check what it holds before searching it",
+			}, GetCollectionInfo)
+			debugLog("Tool 'get_collection_info' enabled")
+		}
+	}
+
 	if enabledTools["list_files"] {
 		if defaultCollectionName != "" {
 			desc := fmt.Sprintf("List files in LocalRecall collection '%s'", defaultCollectionName)
@@ -769,7 +1043,7 @@ func main() {
 	}
 
 	// Run the server
-	if err := server.Run(context.Background(), &mcp.StdioTransport{}); err != nil {
+	if err := transport.Run(context.Background(), server); err != nil {
 		log.Fatal(err)
 	}
 }
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/mudler/mcps/pkg/mock"
+)
+
+// mockTimestamp is reported for every write when LOCALRECALL_MOCK is enabled
+const mockTimestamp = "2025-01-15T10:30:00Z"
+
+var mockCollections = []string{"docs", "notes"}
+
+var mockEntries = []string{"getting-started.md", "architecture.md", "faq.txt"}
+
+var mockResults = []map[string]interface{}{
+	{
+		"id":         "getting-started.md-0",
+		"content":    "To get started, run the server with LOCALRECALL_URL pointing at your LocalRecall instance.",
+		"metadata":   map[string]interface{}{"source": "getting-started.md"},
+		"similarity": 0.91,
+	},
+	{
+		"id":         "architecture.md-2",
+		"content":    "Documents are split into chunks, embedded and stored per collection.",
+		"metadata":   map[string]interface{}{"source": "architecture.md"},
+		"similarity": 0.84,
+	},
+	{
+		"id":         "faq.txt-1",
+		"content":    "Collections can be reset at any time; this removes every stored entry.",
+		"metadata":   map[string]interface{}{"source": "faq.txt"},
+		"similarity": 0.77,
+	},
+}
+
+// mockOK wraps data in the LocalRecall success envelope
+func mockOK(data interface{}) (int, interface{}) {
+	return http.StatusOK, APIResponse{Success: true, Data: data}
+}
+
+// newMockHTTPClient returns an HTTP client answering the LocalRecall API from
+// the fixtures above. Writes succeed without changing any fixture.
+func newMockHTTPClient() *http.Client {
+	return mock.NewClient(
+		mock.Route{Method: http.MethodGet, Pattern: "/api/collections", Handler: func(*http.Request) (int, interface{}) {
+			return mockOK(map[string]interface{}{"collections": mockCollections, "count": len(mockCollections)})
+		}},
+		mock.Route{Method: http.MethodPost, Pattern: "/api/collections", Handler: func(req *http.Request) (int, interface{}) {
+			var body struct {
+				Name string `json:"name"`
+			}
+			_ = mock.DecodeBody(req, &body)
+			return mockOK(map[string]interface{}{"name": body.Name, "created_at": mockTimestamp})
+		}},
+		mock.Route{Method: http.MethodPost, Pattern: "/api/collections/*/reset", Handler: func(req *http.Request) (int, interface{}) {
+			return mockOK(map[string]interface{}{"collection": path.Base(path.Dir(req.URL.Path)), "reset_at": mockTimestamp})
+		}},
+		mock.Route{Method: http.MethodDelete, Pattern: "/api/collections/*", Handler: func(req *http.Request) (int, interface{}) {
+			return mockOK(map[string]interface{}{"collection": path.Base(req.URL.Path), "deleted_at": mockTimestamp})
+		}},
+		mock.Route{Method: http.MethodPost, Pattern: "/api/collections/*/upload", Handler: func(req *http.Request) (int, interface{}) {
+			return mockOK(map[string]interface{}{"collection": path.Base(path.Dir(req.URL.Path)), "uploaded_at": mockTimestamp})
+		}},
+		mock.Route{Method: http.MethodPost, Pattern: "/api/collections/*/search", Handler: func(req *http.Request) (int, interface{}) {
+			var body struct {
+				Query      string `json:"query"`
+				MaxResults int    `json:"max_results"`
+			}
+			_ = mock.DecodeBody(req, &body)
+			results := mockResults
+			if body.MaxResults > 0 && body.MaxResults < len(results) {
+				results = results[:body.MaxResults]
+			}
+			return mockOK(map[string]interface{}{"query": body.Query, "results": results, "count": len(results)})
+		}},
+		mock.Route{Method: http.MethodGet, Pattern: "/api/collections/*/entries", Handler: func(req *http.Request) (int, interface{}) {
+			return mockOK(map[string]interface{}{"collection": path.Base(path.Dir(req.URL.Path)), "entries": mockEntries, "count": len(mockEntries)})
+		}},
+		mock.Route{Method: http.MethodDelete, Pattern: "/api/collections/*/entry/delete", Handler: func(req *http.Request) (int, interface{}) {
+			var body struct {
+				Entry string `json:"entry"`
+			}
+			_ = mock.DecodeBody(req, &body)
+			remaining := []string{}
+			for _, entry := range mockEntries {
+				if entry != body.Entry {
+					remaining = append(remaining, entry)
+				}
+			}
+			if len(remaining) == len(mockEntries) {
+				return http.StatusNotFound, APIResponse{Error: &APIError{
+					Code:    "NOT_FOUND",
+					Message: fmt.Sprintf("entry %q not found", body.Entry),
+					Details: "known entries: " + strings.Join(mockEntries, ", "),
+				}}
+			}
+			return mockOK(map[string]interface{}{"deleted_entry": body.Entry, "remaining_entries": remaining, "entry_count": len(remaining)})
+		}},
+	)
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultMaxRetries = 2
+	retryBaseDelay    = 500 * time.Millisecond
+)
+
+// maxRetries is set with LOCALRECALL_MAX_RETRIES
+var maxRetries = defaultMaxRetries
+
+// parseMaxRetries reads LOCALRECALL_MAX_RETRIES, 0 disabling retries
+func parseMaxRetries(value string) (int, error) {
+	if value == "" {
+		return defaultMaxRetries, nil
+	}
+	retries, err := strconv.Atoi(value)
+	if err != nil || retries < 0 {
+		return defaultMaxRetries, fmt.Errorf("invalid LOCALRECALL_MAX_RETRIES %q: expected a number of retries, 0 to disable", value)
+	}
+	return retries, nil
+}
+
+// idempotentRequest reports whether a request may be sent again after
+// LocalRecall failed to answer it. Searches are POSTs but only read.
+func idempotentRequest(method, endpoint string) bool {
+	return method == http.MethodGet || (method == http.MethodPost && strings.HasSuffix(endpoint, "/search"))
+}
+
+// retryNote describes the retries of a tool, for its description
+func retryNote(idempotent bool) string {
+	switch {
+	case maxRetries == 0:
+		return ""
+	case idempotent:
+		return fmt.Sprintf(". Connection errors and 5xx responses from LocalRecall are retried up to %d times with backoff", maxRetries)
+	default:
+		return fmt.Sprintf(". Retried up to %d times with backoff only when LocalRecall cannot be reached, so it never runs twice", maxRetries)
+	}
+}
+
+// doWithRetry sends the request built by newRequest, retrying up to
+// maxRetries times with exponential backoff on transient failures. A new
+// request is built for each attempt so its body can be sent again. Retries
+// stop when ctx is done or its deadline would pass during the backoff.
+func doWithRetry(ctx context.Context, client *http.Client, idempotent bool, newRequest func() (*http.Request, error)) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		req, err := newRequest()
+		if err != nil {
+			return nil, fmt.Errorf("failed to create request: %w", err)
+		}
+		resp, err := client.Do(req)
+		if attempt >= maxRetries || ctx.Err() != nil || !transientFailure(resp, err, idempotent) {
+			return resp, err
+		}
+
+		delay := retryBaseDelay << attempt
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < delay {
+			return resp, err
+		}
+		if resp != nil {
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+		debugLog("Retrying %s %s in %s (attempt %d of %d): %s", req.Method, req.URL.Path, delay, attempt+1, maxRetries, failureReason(resp, err))
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+}
+
+// transientFailure reports whether a request failed in a way worth
+// retrying. Unless idempotent, only failures where the request surely did
+// not reach LocalRecall count, as it may have run despite a 5xx.
+func transientFailure(resp *http.Response, err error, idempotent bool) bool {
+	if err != nil {
+		if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+			return false
+		}
+		if idempotent {
+			return true
+		}
+		var opErr *net.OpError
+		return errors.As(err, &opErr) && opErr.Op == "dial"
+	}
+	return idempotent && resp.StatusCode >= http.StatusInternalServerError
+}
+
+// failureReason describes a failed attempt for the debug log
+func failureReason(resp *http.Response, err error) string {
+	if err != nil {
+		return err.Error()
+	}
+	return resp.Status
+}
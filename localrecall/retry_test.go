@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Retries", func() {
+	var (
+		mutex    sync.Mutex
+		requests int
+		failures int
+	)
+
+	count := func() int {
+		mutex.Lock()
+		defer mutex.Unlock()
+		return requests
+	}
+
+	BeforeEach(func() {
+		requests, failures = 0, 100
+		// The first failures requests fail with a 500
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			mutex.Lock()
+			requests++
+			failed := requests <= failures
+			mutex.Unlock()
+			if failed {
+				w.WriteHeader(http.StatusInternalServerError)
+				json.NewEncoder(w).Encode(APIResponse{Error: &APIError{Code: "internal_error", Message: "unavailable"}})
+				return
+			}
+			json.NewEncoder(w).Encode(APIResponse{Success: true, Data: map[string]interface{}{"collections": []string{"docs"}, "results": []interface{}{}}})
+		}))
+		DeferCleanup(server.Close)
+
+		DeferCleanup(func(url string, client *http.Client, retries int) {
+			localRecallURL, httpClient, maxRetries = url, client, retries
+		}, localRecallURL, httpClient, maxRetries)
+		localRecallURL, httpClient, maxRetries = server.URL, server.Client(), 1
+	})
+
+	It("retries a GET after a 500", func() {
+		_, _, err := ListCollections(context.Background(), nil, ListCollectionsInput{})
+		Expect(err).To(MatchError(ContainSubstring("internal_error")))
+		Expect(count()).To(Equal(2))
+	})
+
+	It("returns the answer of a successful retry", func() {
+		failures = 1
+		_, out, err := ListCollections(context.Background(), nil, ListCollectionsInput{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(out.Collections).To(Equal([]string{"docs"}))
+		Expect(count()).To(Equal(2))
+	})
+
+	It("retries a search after a 500", func() {
+		_, _, err := searchWithCollection(context.Background(), "docs", "query", 5)
+		Expect(err).To(HaveOccurred())
+		Expect(count()).To(Equal(2))
+	})
+
+	It("does not retry an upload after a 500", func() {
+		_, _, err := addDocumentWithCollection(context.Background(), "docs", "", "content", "", "doc.txt", false)
+		Expect(err).To(MatchError(ContainSubstring("internal_error")))
+		Expect(count()).To(Equal(1))
+	})
+
+	It("does not retry a DELETE after a 500", func() {
+		_, _, err := DeleteCollection(context.Background(), nil, DeleteCollectionInput{Name: "docs"})
+		Expect(err).To(HaveOccurred())
+		Expect(count()).To(Equal(1))
+	})
+
+	It("does not retry when disabled", func() {
+		maxRetries = 0
+		_, _, err := ListCollections(context.Background(), nil, ListCollectionsInput{})
+		Expect(err).To(HaveOccurred())
+		Expect(count()).To(Equal(1))
+	})
+
+	DescribeTable("LOCALRECALL_MAX_RETRIES",
+		func(value string, expected int, valid bool) {
+			retries, err := parseMaxRetries(value)
+			Expect(retries).To(Equal(expected))
+			if valid {
+				Expect(err).NotTo(HaveOccurred())
+			} else {
+				Expect(err).To(MatchError(ContainSubstring("invalid LOCALRECALL_MAX_RETRIES")))
+			}
+		},
+		Entry("default", "", defaultMaxRetries, true),
+		Entry("disabled", "0", 0, true),
+		Entry("configured", "5", 5, true),
+		Entry("negative", "-1", defaultMaxRetries, false),
+		Entry("not a number", "often", defaultMaxRetries, false),
+	)
+})
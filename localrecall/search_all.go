@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// searchAllWorkers bounds the collections searched at the same time
+const searchAllWorkers = 4
+
+type SearchAllInput struct {
+	Query      string `json:"query" jsonschema:"the search query"`
+	MaxResults int    `json:"max_results,omitempty" jsonschema:"maximum number of results to return across all collections (default: 5)"`
+}
+
+// CollectionError is a collection that could not be searched
+type CollectionError struct {
+	Name  string `json:"name" jsonschema:"the name of the collection"`
+	Error string `json:"error" jsonschema:"why it could not be searched"`
+}
+
+type SearchAllOutput struct {
+	Query       string            `json:"query" jsonschema:"the search query"`
+	MaxResults  int               `json:"max_results" jsonschema:"maximum number of results requested"`
+	Results     []SearchResult    `json:"results" jsonschema:"search results of all collections, highest score first, each with its collection"`
+	Count       int               `json:"count" jsonschema:"number of results returned"`
+	Collections []string          `json:"collections" jsonschema:"the collections searched successfully"`
+	Failed      []CollectionError `json:"failed,omitempty" jsonschema:"the collections skipped because searching them failed"`
+}
+
+// SearchAll searches every collection and merges the results by score
+func SearchAll(ctx context.Context, req *mcp.CallToolRequest, input SearchAllInput) (
+	*mcp.CallToolResult,
+	SearchAllOutput,
+	error,
+) {
+	if input.Query == "" {
+		return nil, SearchAllOutput{}, fmt.Errorf("query is required")
+	}
+	maxResults := input.MaxResults
+	if maxResults <= 0 {
+		maxResults = 5
+	}
+
+	_, collections, err := ListCollections(ctx, req, ListCollectionsInput{})
+	if err != nil {
+		return nil, SearchAllOutput{}, fmt.Errorf("failed to list collections: %w", err)
+	}
+
+	// Each collection is asked for maxResults, as the best results overall
+	// may all come from one of them
+	outputs := make([]SearchOutput, len(collections.Collections))
+	errs := make([]error, len(collections.Collections))
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for range min(searchAllWorkers, len(collections.Collections)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				_, outputs[i], errs[i] = searchWithCollection(ctx, collections.Collections[i], input.Query, maxResults)
+			}
+		}()
+	}
+	for i := range collections.Collections {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	output := SearchAllOutput{
+		Query:       input.Query,
+		MaxResults:  maxResults,
+		Results:     []SearchResult{},
+		Collections: []string{},
+	}
+	for i, name := range collections.Collections {
+		if errs[i] != nil {
+			output.Failed = append(output.Failed, CollectionError{Name: name, Error: errs[i].Error()})
+			continue
+		}
+		output.Collections = append(output.Collections, name)
+		for _, result := range outputs[i].Results {
+			result.Collection = name
+			output.Results = append(output.Results, result)
+		}
+	}
+	if len(output.Failed) > 0 && len(output.Collections) == 0 {
+		return nil, SearchAllOutput{}, fmt.Errorf("searching all %d collections failed, %s: %s", len(output.Failed), output.Failed[0].Name, output.Failed[0].Error)
+	}
+
+	// Ties keep the order of the collections
+	sort.SliceStable(output.Results, func(i, j int) bool {
+		return output.Results[i].Score > output.Results[j].Score
+	})
+	if len(output.Results) > maxResults {
+		output.Results = output.Results[:maxResults]
+	}
+	output.Count = len(output.Results)
+
+	return nil, output, nil
+}
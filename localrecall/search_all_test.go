@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"path"
+
+	"github.com/mudler/mcps/pkg/mock"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// scoredResult is a search result of LocalRecall with the given score
+func scoredResult(source string, score float64) map[string]interface{} {
+	return map[string]interface{}{"content": source, "similarity": score, "metadata": map[string]interface{}{"source": source}}
+}
+
+var _ = Describe("search_all", func() {
+	var collections []string
+
+	BeforeEach(func() {
+		collections = []string{"docs", "notes", "wiki"}
+		results := map[string][]map[string]interface{}{
+			"docs":  {scoredResult("docs-1", 0.9), scoredResult("docs-2", 0.5)},
+			"notes": {scoredResult("notes-1", 0.7), scoredResult("notes-2", 0.5)},
+			"wiki":  {scoredResult("wiki-1", 0.8)},
+		}
+		useMockLocalRecall(mock.NewClient(
+			mock.Route{Method: http.MethodGet, Pattern: "/api/collections", Handler: func(*http.Request) (int, interface{}) {
+				return mockOK(map[string]interface{}{"collections": collections, "count": len(collections)})
+			}},
+			mock.Route{Method: http.MethodPost, Pattern: "/api/collections/*/search", Handler: func(req *http.Request) (int, interface{}) {
+				name := path.Base(path.Dir(req.URL.Path))
+				found, ok := results[name]
+				if !ok {
+					return http.StatusNotFound, APIResponse{Error: &APIError{Code: "NOT_FOUND", Message: "collection not found"}}
+				}
+				return mockOK(map[string]interface{}{"results": found, "count": len(found)})
+			}},
+		))
+	})
+
+	sources := func(results []SearchResult) []string {
+		list := []string{}
+		for _, result := range results {
+			list = append(list, result.Collection+"/"+result.Source)
+		}
+		return list
+	}
+
+	It("merges the results of every collection by score", func() {
+		_, out, err := SearchAll(context.Background(), nil, SearchAllInput{Query: "q", MaxResults: 10})
+		Expect(err).NotTo(HaveOccurred())
+		// Ties keep the order of the collections
+		Expect(sources(out.Results)).To(Equal([]string{"docs/docs-1", "wiki/wiki-1", "notes/notes-1", "docs/docs-2", "notes/notes-2"}))
+		Expect(out.Count).To(Equal(5))
+		Expect(out.Collections).To(Equal([]string{"docs", "notes", "wiki"}))
+		Expect(out.Failed).To(BeEmpty())
+	})
+
+	It("returns the best max_results results overall", func() {
+		_, out, err := SearchAll(context.Background(), nil, SearchAllInput{Query: "q", MaxResults: 2})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(sources(out.Results)).To(Equal([]string{"docs/docs-1", "wiki/wiki-1"}))
+		Expect(out.Count).To(Equal(2))
+
+		_, out, err = SearchAll(context.Background(), nil, SearchAllInput{Query: "q"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(out.MaxResults).To(Equal(5))
+		Expect(out.Count).To(Equal(5))
+	})
+
+	It("reports the collections that failed along with the results of the others", func() {
+		collections = []string{"docs", "gone"}
+		_, out, err := SearchAll(context.Background(), nil, SearchAllInput{Query: "q"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(sources(out.Results)).To(Equal([]string{"docs/docs-1", "docs/docs-2"}))
+		Expect(out.Collections).To(Equal([]string{"docs"}))
+		Expect(out.Failed).To(HaveLen(1))
+		Expect(out.Failed[0].Name).To(Equal("gone"))
+		Expect(out.Failed[0].Error).To(ContainSubstring("collection not found"))
+	})
+
+	It("fails when every collection failed", func() {
+		collections = []string{"gone", "lost"}
+		_, _, err := SearchAll(context.Background(), nil, SearchAllInput{Query: "q"})
+		Expect(err).To(MatchError(ContainSubstring("searching all 2 collections failed, gone")))
+	})
+
+	It("returns no results without collections", func() {
+		collections = []string{}
+		_, out, err := SearchAll(context.Background(), nil, SearchAllInput{Query: "q"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(out.Results).To(BeEmpty())
+		Expect(out.Count).To(BeZero())
+	})
+
+	It("requires a query", func() {
+		_, _, err := SearchAll(context.Background(), nil, SearchAllInput{})
+		Expect(err).To(MatchError("query is required"))
+	})
+})
@@ -0,0 +1,47 @@
+package main
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("searchResultFromMap", func() {
+	metadata := map[string]interface{}{"source": "guide.md", "page": 2.0}
+
+	DescribeTable("decodes search results",
+		func(result map[string]interface{}, expected SearchResult) {
+			Expect(searchResultFromMap(result)).To(Equal(expected))
+		},
+		Entry("complete result, without the raw result",
+			map[string]interface{}{"id": "guide.md-0", "content": "hello", "similarity": 0.9, "metadata": metadata},
+			SearchResult{ID: "guide.md-0", Content: "hello", Score: 0.9, Source: "guide.md", Metadata: metadata},
+		),
+		Entry("score instead of similarity",
+			map[string]interface{}{"content": "hello", "score": 0.5, "metadata": metadata},
+			SearchResult{Content: "hello", Score: 0.5, Source: "guide.md", Metadata: metadata},
+		),
+		Entry("similarity preferred over score",
+			map[string]interface{}{"content": "hello", "similarity": 0.9, "score": 0.5, "metadata": metadata},
+			SearchResult{Content: "hello", Score: 0.9, Source: "guide.md", Metadata: metadata},
+		),
+	)
+
+	DescribeTable("keeps the raw result when a field is missing",
+		func(result map[string]interface{}) {
+			decoded := searchResultFromMap(result)
+			Expect(decoded.Raw).To(Equal(result))
+		},
+		Entry("no content", map[string]interface{}{"similarity": 0.9, "metadata": metadata}),
+		Entry("no score", map[string]interface{}{"content": "hello", "metadata": metadata}),
+		Entry("no metadata", map[string]interface{}{"content": "hello", "similarity": 0.9}),
+		Entry("no source in the metadata", map[string]interface{}{"content": "hello", "similarity": 0.9, "metadata": map[string]interface{}{"page": 2.0}}),
+		Entry("a score of another type", map[string]interface{}{"content": "hello", "similarity": "0.9", "metadata": metadata}),
+	)
+
+	It("leaves out empty metadata", func() {
+		decoded := searchResultFromMap(map[string]interface{}{"content": "hello", "similarity": 0.9, "metadata": map[string]interface{}{}})
+		Expect(decoded.Metadata).To(BeNil())
+		Expect(decoded.Source).To(BeEmpty())
+		Expect(decoded.Raw).NotTo(BeNil())
+	})
+})
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/mudler/mcps/pkg/mock"
+	"github.com/mudler/mcps/pkg/selfcheck"
+)
+
+// selfChecks lists the collections to check the LocalRecall API and key, and
+// that the default collection exists
+func selfChecks() []selfcheck.Check {
+	if mock.Enabled("LOCALRECALL") {
+		return []selfcheck.Check{selfcheck.Skipped("api", "LOCALRECALL_MOCK enabled")}
+	}
+	return []selfcheck.Check{
+		{Name: "api", Run: func(ctx context.Context) (string, error) {
+			_, out, err := ListCollections(ctx, nil, ListCollectionsInput{})
+			if err != nil {
+				return "", fmt.Errorf("%s: %w", localRecallURL, err)
+			}
+			detail := fmt.Sprintf("%s reachable, %d collections", localRecallURL, out.Count)
+			if defaultCollectionName == "" {
+				return detail, nil
+			}
+			for _, name := range out.Collections {
+				if name == defaultCollectionName {
+					return detail, nil
+				}
+			}
+			return "", selfcheck.Warn(fmt.Errorf("LOCALRECALL_COLLECTION %q not found (have: %s)", defaultCollectionName, strings.Join(out.Collections, ", ")))
+		}},
+	}
+}
@@ -0,0 +1,27 @@
+package main
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("LOCALRECALL_ENABLED_TOOLS", func() {
+	It("enables every tool when empty", func() {
+		Expect(parseEnabledTools("")).To(Equal(validTools))
+	})
+
+	It("enables the listed tools, ignoring unknown ones", func() {
+		Expect(parseEnabledTools(" search, list_files,,nope ")).To(Equal(map[string]bool{
+			"search":     true,
+			"list_files": true,
+		}))
+	})
+
+	It("enables get_ingestion_status along with add_document", func() {
+		Expect(parseEnabledTools("search,add_document")).To(Equal(map[string]bool{
+			"search":               true,
+			"add_document":         true,
+			"get_ingestion_status": true,
+		}))
+	})
+})
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/mudler/mcps/pkg/batch"
+)
+
+// batchKey is the context key marking the operations of a batch
+type batchKey struct{}
+
+// inBatch reports whether ctx belongs to a batch, which holds the lock
+func inBatch(ctx context.Context) bool {
+	return ctx.Value(batchKey{}) != nil
+}
+
+// batchHandlers are the tools a batch may call
+var batchHandlers = batch.Handlers{
+	"send_message":        batch.Tool(SendMessage),
+	"read_messages":       batch.Tool(ReadMessages),
+	"add_labels":          batch.Tool(AddLabels),
+	"remove_labels":       batch.Tool(RemoveLabels),
+	"mark_message_read":   batch.Tool(MarkMessageRead),
+	"mark_message_unread": batch.Tool(MarkMessageUnread),
+	"delete_message":      batch.Tool(DeleteMessage),
+}
+
+// mailboxSnapshot is the content of the mailbox file before a batch, to
+// restore it when the batch is rolled back
+type mailboxSnapshot struct {
+	data   []byte
+	exists bool
+}
+
+func takeMailboxSnapshot() (mailboxSnapshot, error) {
+	data, err := os.ReadFile(mailboxFilePath)
+	if os.IsNotExist(err) {
+		return mailboxSnapshot{}, nil
+	}
+	if err != nil {
+		return mailboxSnapshot{}, fmt.Errorf("failed to read mailbox file: %w", err)
+	}
+	return mailboxSnapshot{data: data, exists: true}, nil
+}
+
+// restore atomically puts back the mailbox file as it was
+func (s mailboxSnapshot) restore() error {
+	if !s.exists {
+		if err := os.Remove(mailboxFilePath); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to roll back mailbox file: %w", err)
+		}
+		return nil
+	}
+	tempFile := mailboxFilePath + ".tmp"
+	if err := os.WriteFile(tempFile, s.data, 0644); err != nil {
+		return fmt.Errorf("failed to roll back mailbox file: %w", err)
+	}
+	if err := os.Rename(tempFile, mailboxFilePath); err != nil {
+		os.Remove(tempFile) // Clean up on error
+		return fmt.Errorf("failed to roll back mailbox file: %w", err)
+	}
+	return nil
+}
+
+// Batch runs mailbox operations in order as a transaction: the mailbox stays
+// locked for the whole batch, and is restored as it was when the batch stops
+// on a failure
+func Batch(ctx context.Context, req *mcp.CallToolRequest, input batch.Input) (
+	*mcp.CallToolResult,
+	batch.Output,
+	error,
+) {
+	if err := batchHandlers.Validate(input); err != nil {
+		return nil, batch.Output{}, err
+	}
+
+	var output batch.Output
+	err := withLock(ctx, mailboxFilePath, func() error {
+		snapshot, err := takeMailboxSnapshot()
+		if err != nil {
+			return err
+		}
+
+		output, err = batchHandlers.Run(context.WithValue(ctx, batchKey{}, true), req, input)
+		if err == nil && !output.Stopped(input) {
+			return nil
+		}
+		if restoreErr := snapshot.restore(); restoreErr != nil {
+			return restoreErr
+		}
+		output.RolledBack = err == nil && output.Succeeded > 0
+		return err
+	})
+	if err != nil {
+		return nil, batch.Output{}, err
+	}
+
+	return nil, output, nil
+}
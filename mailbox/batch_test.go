@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"os"
+
+	"github.com/mudler/mcps/pkg/batch"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Batch", func() {
+	for _, backend := range []string{storageJSON, storageJSONL} {
+		Describe("with the "+backend+" backend", func() {
+			var path string
+
+			BeforeEach(func() {
+				path = useMailbox(backend)
+			})
+
+			It("runs the operations in order and keeps their changes", func() {
+				_, out, err := Batch(context.Background(), nil, batch.Input{Operations: []batch.Operation{
+					{Tool: "send_message", Arguments: map[string]any{"recipient": "bob", "content": "first"}},
+					{Tool: "send_message", Arguments: map[string]any{"recipient": "bob", "content": "second"}},
+					{Tool: "read_messages"},
+				}})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(out.Succeeded).To(Equal(3))
+				Expect(out.RolledBack).To(BeFalse())
+				Expect(out.Results[2].Output).To(BeAssignableToTypeOf(ReadMessagesOutput{}))
+				Expect(out.Results[2].Output.(ReadMessagesOutput).Count).To(Equal(2))
+				Expect(contents()).To(Equal([]string{"first", "second"}))
+			})
+
+			It("restores the mailbox when it stops on a failure", func() {
+				send("before")
+				saved, err := os.ReadFile(path)
+				Expect(err).NotTo(HaveOccurred())
+
+				_, out, err := Batch(context.Background(), nil, batch.Input{Operations: []batch.Operation{
+					{Tool: "send_message", Arguments: map[string]any{"recipient": "bob", "content": "discarded"}},
+					{Tool: "mark_message_read", Arguments: map[string]any{"id": "missing"}},
+					{Tool: "send_message", Arguments: map[string]any{"recipient": "bob", "content": "skipped"}},
+				}})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(out.Succeeded).To(Equal(1))
+				Expect(out.Failed).To(Equal(1))
+				Expect(out.Skipped).To(Equal(1))
+				Expect(out.RolledBack).To(BeTrue())
+				Expect(os.ReadFile(path)).To(Equal(saved))
+				Expect(contents()).To(Equal([]string{"before"}))
+			})
+
+			It("removes a mailbox file it created when it stops on a failure", func() {
+				_, out, err := Batch(context.Background(), nil, batch.Input{Operations: []batch.Operation{
+					{Tool: "send_message", Arguments: map[string]any{"recipient": "bob", "content": "discarded"}},
+					{Tool: "delete_message", Arguments: map[string]any{"id": "missing"}},
+				}})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(out.RolledBack).To(BeTrue())
+				Expect(path).NotTo(BeAnExistingFile())
+			})
+
+			It("keeps the successful operations with continue_on_error", func() {
+				_, out, err := Batch(context.Background(), nil, batch.Input{ContinueOnError: true, Operations: []batch.Operation{
+					{Tool: "send_message", Arguments: map[string]any{"recipient": "bob", "content": "first"}},
+					{Tool: "mark_message_read", Arguments: map[string]any{"id": "missing"}},
+					{Tool: "send_message", Arguments: map[string]any{"recipient": "bob", "content": "second"}},
+				}})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(out.Succeeded).To(Equal(2))
+				Expect(out.Failed).To(Equal(1))
+				Expect(out.RolledBack).To(BeFalse())
+				Expect(contents()).To(Equal([]string{"first", "second"}))
+			})
+		})
+	}
+
+	It("rejects unknown tools before running anything", func() {
+		path := useMailbox(storageJSON)
+		_, _, err := Batch(context.Background(), nil, batch.Input{Operations: []batch.Operation{
+			{Tool: "send_message", Arguments: map[string]any{"recipient": "bob", "content": "first"}},
+			{Tool: "batch"},
+		}})
+		Expect(err).To(HaveOccurred())
+		Expect(path).NotTo(BeAnExistingFile())
+	})
+})
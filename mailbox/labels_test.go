@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Labels", func() {
+	var id string
+
+	BeforeEach(func() {
+		useMailbox(storageJSON)
+		id = send("first")
+		send("second")
+	})
+
+	It("adds labels once each, ignoring blank ones", func() {
+		_, out, err := AddLabels(context.Background(), nil, AddLabelsInput{ID: id, Labels: []string{" todo ", "fyi", ""}})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(out.Success).To(BeTrue())
+		Expect(out.Labels).To(Equal([]string{"todo", "fyi"}))
+
+		_, out, err = AddLabels(context.Background(), nil, AddLabelsInput{ID: id, Labels: []string{"todo", "handled"}})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(out.Labels).To(Equal([]string{"todo", "fyi", "handled"}))
+	})
+
+	It("removes labels", func() {
+		_, _, err := AddLabels(context.Background(), nil, AddLabelsInput{ID: id, Labels: []string{"todo", "fyi"}})
+		Expect(err).NotTo(HaveOccurred())
+		_, out, err := RemoveLabels(context.Background(), nil, RemoveLabelsInput{ID: id, Labels: []string{"todo", "unknown"}})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(out.Success).To(BeTrue())
+		Expect(out.Labels).To(Equal([]string{"fyi"}))
+	})
+
+	It("filters the messages read by label", func() {
+		_, _, err := AddLabels(context.Background(), nil, AddLabelsInput{ID: id, Labels: []string{"todo"}})
+		Expect(err).NotTo(HaveOccurred())
+
+		_, out, err := ReadMessages(context.Background(), nil, ReadMessagesInput{Label: "todo"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(out.Count).To(Equal(1))
+		Expect(out.Messages[0].ID).To(Equal(id))
+		Expect(contents()).To(Equal([]string{"first", "second"}))
+	})
+
+	It("requires a label", func() {
+		_, _, err := AddLabels(context.Background(), nil, AddLabelsInput{ID: id, Labels: []string{" "}})
+		Expect(err).To(MatchError("at least one label is required"))
+	})
+
+	It("only labels messages of this agent", func() {
+		_, out, err := AddLabels(context.Background(), nil, AddLabelsInput{ID: "missing", Labels: []string{"todo"}})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(out.Success).To(BeFalse())
+		Expect(out.Message).To(ContainSubstring("not found"))
+
+		agentName = "alice"
+		_, out, err = AddLabels(context.Background(), nil, AddLabelsInput{ID: id, Labels: []string{"todo"}})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(out.Success).To(BeFalse())
+		Expect(out.Message).To(ContainSubstring("does not belong to this agent"))
+	})
+})
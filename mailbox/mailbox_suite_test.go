@@ -0,0 +1,25 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestMailbox(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Mailbox Suite")
+}
+
+// useMailbox stores the mailbox of agent "bob" in a new file with the given
+// backend until the end of the spec, and returns the path of the file
+func useMailbox(backend string) string {
+	DeferCleanup(func(path, name, previous string) {
+		mailboxFilePath, agentName, storage = path, name, previous
+	}, mailboxFilePath, agentName, storage)
+	mailboxFilePath = filepath.Join(GinkgoT().TempDir(), "mailbox."+backend)
+	agentName, storage = "bob", backend
+	return mailboxFilePath
+}
@@ -7,25 +7,33 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/gofrs/flock"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/mudler/mcps/pkg/selfcheck"
+	"github.com/mudler/mcps/pkg/transport"
 )
 
 // Message represents a single message in the mailbox
 type Message struct {
-	ID        string    `json:"id"`        // Unique identifier
-	Sender    string    `json:"sender"`    // Agent name who sent
-	Recipient string    `json:"recipient"` // Agent name recipient
-	Content   string    `json:"content"`   // Message content
-	Timestamp time.Time `json:"timestamp"` // When sent
-	Read      bool      `json:"read"`      // Read status
+	ID        string    `json:"id"`               // Unique identifier
+	Sender    string    `json:"sender"`           // Agent name who sent
+	Recipient string    `json:"recipient"`        // Agent name recipient
+	Content   string    `json:"content"`          // Message content
+	Timestamp time.Time `json:"timestamp"`        // When sent
+	Read      bool      `json:"read"`             // Read status
+	Labels    []string  `json:"labels,omitempty"` // Labels used to organize the inbox
 }
 
 // Mailbox represents the entire mailbox
 type Mailbox struct {
 	Messages []Message `json:"messages"`
+
+	// logLines is the number of records read from a JSONL log
+	logLines int
 }
 
 // Input types for different operations
@@ -34,7 +42,9 @@ type SendMessageInput struct {
 	Content   string `json:"content" jsonschema:"the message content"`
 }
 
-type ReadMessagesInput struct{}
+type ReadMessagesInput struct {
+	Label string `json:"label,omitempty" jsonschema:"optional label to filter messages by"`
+}
 
 type MarkMessageReadInput struct {
 	ID string `json:"id" jsonschema:"the ID of the message to mark as read"`
@@ -48,6 +58,16 @@ type DeleteMessageInput struct {
 	ID string `json:"id" jsonschema:"the ID of the message to delete"`
 }
 
+type AddLabelsInput struct {
+	ID     string   `json:"id" jsonschema:"the ID of the message to label"`
+	Labels []string `json:"labels" jsonschema:"the labels to add (e.g. todo, fyi, handled)"`
+}
+
+type RemoveLabelsInput struct {
+	ID     string   `json:"id" jsonschema:"the ID of the message to unlabel"`
+	Labels []string `json:"labels" jsonschema:"the labels to remove"`
+}
+
 // Output types
 type SendMessageOutput struct {
 	ID        string    `json:"id" jsonschema:"the ID of the sent message"`
@@ -78,6 +98,12 @@ type DeleteMessageOutput struct {
 	Message string `json:"message" jsonschema:"status message"`
 }
 
+type UpdateLabelsOutput struct {
+	Success bool     `json:"success" jsonschema:"whether the operation was successful"`
+	Message string   `json:"message" jsonschema:"status message"`
+	Labels  []string `json:"labels,omitempty" jsonschema:"the labels of the message after the update"`
+}
+
 var mailboxFilePath string
 var agentName string
 
@@ -86,16 +112,42 @@ func generateID() string {
 	return fmt.Sprintf("%d", time.Now().UnixNano())
 }
 
-// withLock executes a function with file locking
-func withLock(filePath string, fn func() error) error {
+// withLock executes a function holding the exclusive file lock. It must be
+// used by every operation that modifies the mailbox. Operations of a batch
+// run directly, the batch holding the lock.
+func withLock(ctx context.Context, filePath string, fn func() error) error {
+	if inBatch(ctx) {
+		return fn()
+	}
+	return lockAndRun(filePath, false, fn)
+}
+
+// withReadLock executes a function holding a shared file lock. Any number of
+// readers can hold it at the same time; it only waits for in-flight writers,
+// so read-only operations never block each other.
+func withReadLock(ctx context.Context, filePath string, fn func() error) error {
+	if inBatch(ctx) {
+		return fn()
+	}
+	return lockAndRun(filePath, true, fn)
+}
+
+// lockAndRun acquires the shared or exclusive lock on filePath and runs fn
+func lockAndRun(filePath string, shared bool, fn func() error) error {
 	lockPath := filePath + ".lock"
 	fileLock := flock.New(lockPath)
 
-	// Acquire exclusive lock with timeout
+	// Acquire lock with timeout
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	locked, err := fileLock.TryLockContext(ctx, 100*time.Millisecond)
+	var locked bool
+	var err error
+	if shared {
+		locked, err = fileLock.TryRLockContext(ctx, 100*time.Millisecond)
+	} else {
+		locked, err = fileLock.TryLockContext(ctx, 100*time.Millisecond)
+	}
 	if err != nil {
 		return fmt.Errorf("failed to acquire lock: %w", err)
 	}
@@ -109,6 +161,10 @@ func withLock(filePath string, fn func() error) error {
 
 // loadMailbox loads the mailbox from file
 func loadMailbox() (*Mailbox, error) {
+	if storage == storageJSONL {
+		return loadLog()
+	}
+
 	mailbox := &Mailbox{Messages: []Message{}}
 
 	// Check if file exists
@@ -131,7 +187,7 @@ func loadMailbox() (*Mailbox, error) {
 	return mailbox, nil
 }
 
-// saveMailbox saves the mailbox to file atomically
+// saveMailbox saves the mailbox to the JSON file atomically
 func saveMailbox(mailbox *Mailbox) error {
 	// Create directory if it doesn't exist
 	if err := os.MkdirAll(filepath.Dir(mailboxFilePath), 0755); err != nil {
@@ -173,12 +229,7 @@ func SendMessage(ctx context.Context, req *mcp.CallToolRequest, input SendMessag
 
 	var output SendMessageOutput
 
-	err := withLock(mailboxFilePath, func() error {
-		mailbox, err := loadMailbox()
-		if err != nil {
-			return err
-		}
-
+	err := withLock(ctx, mailboxFilePath, func() error {
 		message := Message{
 			ID:        generateID(),
 			Sender:    agentName,
@@ -188,9 +239,7 @@ func SendMessage(ctx context.Context, req *mcp.CallToolRequest, input SendMessag
 			Read:      false,
 		}
 
-		mailbox.Messages = append(mailbox.Messages, message)
-
-		if err := saveMailbox(mailbox); err != nil {
+		if err := addMessage(message); err != nil {
 			return err
 		}
 
@@ -220,7 +269,7 @@ func ReadMessages(ctx context.Context, req *mcp.CallToolRequest, input ReadMessa
 ) {
 	var output ReadMessagesOutput
 
-	err := withLock(mailboxFilePath, func() error {
+	err := withReadLock(ctx, mailboxFilePath, func() error {
 		mailbox, err := loadMailbox()
 		if err != nil {
 			return err
@@ -231,8 +280,11 @@ func ReadMessages(ctx context.Context, req *mcp.CallToolRequest, input ReadMessa
 		unreadCount := 0
 		if agentName == "" {
 			// Return all messages
-			myMessages = mailbox.Messages
 			for _, msg := range mailbox.Messages {
+				if !hasLabel(msg, input.Label) {
+					continue
+				}
+				myMessages = append(myMessages, msg)
 				if !msg.Read {
 					unreadCount++
 				}
@@ -240,7 +292,7 @@ func ReadMessages(ctx context.Context, req *mcp.CallToolRequest, input ReadMessa
 		} else {
 			// Filter messages for this agent
 			for _, msg := range mailbox.Messages {
-				if msg.Recipient == agentName {
+				if msg.Recipient == agentName && hasLabel(msg, input.Label) {
 					myMessages = append(myMessages, msg)
 					if !msg.Read {
 						unreadCount++
@@ -273,12 +325,13 @@ func MarkMessageRead(ctx context.Context, req *mcp.CallToolRequest, input MarkMe
 ) {
 	var output MarkMessageReadOutput
 
-	err := withLock(mailboxFilePath, func() error {
+	err := withLock(ctx, mailboxFilePath, func() error {
 		mailbox, err := loadMailbox()
 		if err != nil {
 			return err
 		}
 
+		var message Message
 		found := false
 		for i := range mailbox.Messages {
 			if mailbox.Messages[i].ID == input.ID {
@@ -291,6 +344,7 @@ func MarkMessageRead(ctx context.Context, req *mcp.CallToolRequest, input MarkMe
 					return nil
 				}
 				mailbox.Messages[i].Read = true
+				message = mailbox.Messages[i]
 				found = true
 				break
 			}
@@ -304,7 +358,7 @@ func MarkMessageRead(ctx context.Context, req *mcp.CallToolRequest, input MarkMe
 			return nil
 		}
 
-		if err := saveMailbox(mailbox); err != nil {
+		if err := updateMessage(mailbox, message); err != nil {
 			return err
 		}
 
@@ -331,12 +385,13 @@ func MarkMessageUnread(ctx context.Context, req *mcp.CallToolRequest, input Mark
 ) {
 	var output MarkMessageUnreadOutput
 
-	err := withLock(mailboxFilePath, func() error {
+	err := withLock(ctx, mailboxFilePath, func() error {
 		mailbox, err := loadMailbox()
 		if err != nil {
 			return err
 		}
 
+		var message Message
 		found := false
 		for i := range mailbox.Messages {
 			if mailbox.Messages[i].ID == input.ID {
@@ -349,6 +404,7 @@ func MarkMessageUnread(ctx context.Context, req *mcp.CallToolRequest, input Mark
 					return nil
 				}
 				mailbox.Messages[i].Read = false
+				message = mailbox.Messages[i]
 				found = true
 				break
 			}
@@ -362,7 +418,7 @@ func MarkMessageUnread(ctx context.Context, req *mcp.CallToolRequest, input Mark
 			return nil
 		}
 
-		if err := saveMailbox(mailbox); err != nil {
+		if err := updateMessage(mailbox, message); err != nil {
 			return err
 		}
 
@@ -381,6 +437,119 @@ func MarkMessageUnread(ctx context.Context, req *mcp.CallToolRequest, input Mark
 	return nil, output, nil
 }
 
+// hasLabel reports whether msg carries label; an empty label matches every message
+func hasLabel(msg Message, label string) bool {
+	return label == "" || slices.Contains(msg.Labels, label)
+}
+
+// normalizeLabels trims labels and drops empty values
+func normalizeLabels(labels []string) []string {
+	var result []string
+	for _, label := range labels {
+		if label = strings.TrimSpace(label); label != "" {
+			result = append(result, label)
+		}
+	}
+	return result
+}
+
+// updateLabels applies fn to the labels of one of this agent's messages
+func updateLabels(ctx context.Context, id string, labels []string, fn func(current, labels []string) []string) (UpdateLabelsOutput, error) {
+	labels = normalizeLabels(labels)
+	if len(labels) == 0 {
+		return UpdateLabelsOutput{}, fmt.Errorf("at least one label is required")
+	}
+
+	var output UpdateLabelsOutput
+
+	err := withLock(ctx, mailboxFilePath, func() error {
+		mailbox, err := loadMailbox()
+		if err != nil {
+			return err
+		}
+
+		var message *Message
+		for i := range mailbox.Messages {
+			if mailbox.Messages[i].ID == id {
+				message = &mailbox.Messages[i]
+				break
+			}
+		}
+
+		if message == nil {
+			output = UpdateLabelsOutput{
+				Success: false,
+				Message: fmt.Sprintf("message with ID '%s' not found", id),
+			}
+			return nil
+		}
+
+		// Only allow labeling if this agent is the recipient
+		if message.Recipient != agentName {
+			output = UpdateLabelsOutput{
+				Success: false,
+				Message: fmt.Sprintf("message '%s' does not belong to this agent", id),
+			}
+			return nil
+		}
+
+		message.Labels = fn(message.Labels, labels)
+
+		if err := updateMessage(mailbox, *message); err != nil {
+			return err
+		}
+
+		output = UpdateLabelsOutput{
+			Success: true,
+			Message: fmt.Sprintf("labels of message '%s' updated", id),
+			Labels:  message.Labels,
+		}
+
+		return nil
+	})
+
+	return output, err
+}
+
+// AddLabels adds labels to a message
+func AddLabels(ctx context.Context, req *mcp.CallToolRequest, input AddLabelsInput) (
+	*mcp.CallToolResult,
+	UpdateLabelsOutput,
+	error,
+) {
+	output, err := updateLabels(ctx, input.ID, input.Labels, func(current, labels []string) []string {
+		for _, label := range labels {
+			if !slices.Contains(current, label) {
+				current = append(current, label)
+			}
+		}
+		return current
+	})
+	if err != nil {
+		return nil, UpdateLabelsOutput{}, err
+	}
+
+	return nil, output, nil
+}
+
+// RemoveLabels removes labels from a message
+func RemoveLabels(ctx context.Context, req *mcp.CallToolRequest, input RemoveLabelsInput) (
+	*mcp.CallToolResult,
+	UpdateLabelsOutput,
+	error,
+) {
+	output, err := updateLabels(ctx, input.ID, input.Labels, func(current, labels []string) []string {
+		return slices.DeleteFunc(current, func(label string) bool {
+			return slices.Contains(labels, label)
+		})
+	})
+	if err != nil {
+		return nil, UpdateLabelsOutput{}, err
+	}
+
+	return nil, output, nil
+}
+
 // DeleteMessage deletes a message by ID (only if recipient matches agent name)
 func DeleteMessage(ctx context.Context, req *mcp.CallToolRequest, input DeleteMessageInput) (
 	*mcp.CallToolResult,
@@ -389,7 +558,7 @@ func DeleteMessage(ctx context.Context, req *mcp.CallToolRequest, input DeleteMe
 ) {
 	var output DeleteMessageOutput
 
-	err := withLock(mailboxFilePath, func() error {
+	err := withLock(ctx, mailboxFilePath, func() error {
 		mailbox, err := loadMailbox()
 		if err != nil {
 			return err
@@ -424,7 +593,7 @@ func DeleteMessage(ctx context.Context, req *mcp.CallToolRequest, input DeleteMe
 
 		mailbox.Messages = newMessages
 
-		if err := saveMailbox(mailbox); err != nil {
+		if err := deleteMessage(mailbox, input.ID); err != nil {
 			return err
 		}
 
@@ -444,15 +613,29 @@ func DeleteMessage(ctx context.Context, req *mcp.CallToolRequest, input DeleteMe
 }
 
 func main() {
+	var storageErr error
+	storage, storageErr = parseStorage(os.Getenv("MAILBOX_STORAGE"))
+
 	// Get file path from environment variable, default to /data/mailbox.json
+	// or /data/mailbox.jsonl
 	mailboxFilePath = os.Getenv("MAILBOX_FILE_PATH")
 	if mailboxFilePath == "" {
-		mailboxFilePath = "/data/mailbox.json"
+		mailboxFilePath = "/data/mailbox." + storage
 	}
 
 	// Get agent name from environment variable (optional - if empty, returns all messages)
 	agentName = os.Getenv("MAILBOX_AGENT_NAME")
 
+	if selfcheck.Enabled() {
+		selfcheck.Exit("mailbox",
+			selfcheck.Value("MAILBOX_STORAGE", storage, storageErr),
+			selfcheck.File("MAILBOX_FILE_PATH", mailboxFilePath),
+		)
+	}
+	if storageErr != nil {
+		log.Fatal(storageErr)
+	}
+
 	// Create directory if it doesn't exist
 	os.MkdirAll(filepath.Dir(mailboxFilePath), 0755)
 
@@ -467,9 +650,19 @@ func main() {
 
 	mcp.AddTool(server, &mcp.Tool{
 		Name:        "read_messages",
-		Description: "Read all messages for this agent (or all messages if agent name is empty)",
+		Description: "Read all messages for this agent (or all messages if agent name is empty), optionally only those with a given label",
 	}, ReadMessages)
 
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "add_labels",
+		Description: "Add labels to a message by ID to organize the inbox (e.g. todo, fyi, handled)",
+	}, AddLabels)
+
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "remove_labels",
+		Description: "Remove labels from a message by ID",
+	}, RemoveLabels)
+
 	mcp.AddTool(server, &mcp.Tool{
 		Name:        "mark_message_read",
 		Description: "Mark a message as read by ID",
@@ -485,7 +678,12 @@ func main() {
 		Description: "Delete a message by ID (only if recipient matches this agent)",
 	}, DeleteMessage)
 
-	if err := server.Run(context.Background(), &mcp.StdioTransport{}); err != nil {
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "batch",
+		Description: fmt.Sprintf("Run several mailbox operations in order in a single call, as a transaction: the mailbox is locked for the whole batch and restored as it was when it stops on a failure (unless continue_on_error is set, in which case the successful operations are kept). Each operation names a tool (%s) with its arguments; returns the result of each", strings.Join(batchHandlers.Names(), ", ")),
+	}, Batch)
+
+	if err := transport.Run(context.Background(), server); err != nil {
 		log.Fatal(err)
 	}
 }
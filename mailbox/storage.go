@@ -0,0 +1,202 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Storage backends, selected with MAILBOX_STORAGE
+const (
+	// storageJSON rewrites the whole mailbox file on every change
+	storageJSON = "json"
+	// storageJSONL appends a line per change to a log, so sending a message
+	// does not depend on the size of the mailbox
+	storageJSONL = "jsonl"
+)
+
+var storage = storageJSON
+
+// parseStorage validates MAILBOX_STORAGE, empty meaning the JSON backend.
+// The JSON backend is returned along with the error of an unknown value.
+func parseStorage(value string) (string, error) {
+	switch value = strings.ToLower(strings.TrimSpace(value)); value {
+	case "", storageJSON:
+		return storageJSON, nil
+	case storageJSONL:
+		return storageJSONL, nil
+	}
+	return storageJSON, fmt.Errorf("unknown MAILBOX_STORAGE %q (expected json or jsonl)", value)
+}
+
+// logRecord is a line of the JSONL log: a message as of its last change, or
+// the ID of a deleted message
+type logRecord struct {
+	Message *Message `json:"message,omitempty"`
+	Deleted string   `json:"deleted,omitempty"`
+}
+
+// addMessage stores a new message
+func addMessage(message Message) error {
+	if storage == storageJSONL {
+		return appendLog(logRecord{Message: &message})
+	}
+	mailbox, err := loadMailbox()
+	if err != nil {
+		return err
+	}
+	mailbox.Messages = append(mailbox.Messages, message)
+	return saveMailbox(mailbox)
+}
+
+// updateMessage stores a message of mailbox changed in place
+func updateMessage(mailbox *Mailbox, message Message) error {
+	if storage == storageJSONL {
+		return appendLogAndCompact(mailbox, logRecord{Message: &message})
+	}
+	return saveMailbox(mailbox)
+}
+
+// deleteMessage stores the deletion of a message already removed from mailbox
+func deleteMessage(mailbox *Mailbox, id string) error {
+	if storage == storageJSONL {
+		return appendLogAndCompact(mailbox, logRecord{Deleted: id})
+	}
+	return saveMailbox(mailbox)
+}
+
+// loadLog folds the JSONL log into a mailbox, the last record of a message
+// winning. Lines that cannot be parsed, cut short by a crash during an
+// append, are skipped with a warning.
+func loadLog() (*Mailbox, error) {
+	mailbox := &Mailbox{Messages: []Message{}}
+
+	f, err := os.Open(mailboxFilePath)
+	if os.IsNotExist(err) {
+		return mailbox, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read mailbox file: %w", err)
+	}
+	defer f.Close()
+
+	messages := map[string]Message{}
+	var order []string
+	reader := bufio.NewReader(f)
+	for lineNumber := 1; ; lineNumber++ {
+		line, err := reader.ReadBytes('\n')
+		if err != nil && err != io.EOF {
+			return nil, fmt.Errorf("failed to read mailbox file: %w", err)
+		}
+		last := err == io.EOF
+		if len(bytes.TrimSpace(line)) > 0 {
+			mailbox.logLines++
+			var record logRecord
+			if err := json.Unmarshal(line, &record); err != nil {
+				log.Printf("Warning: skipping line %d of %s: %v", lineNumber, mailboxFilePath, err)
+				record = logRecord{}
+			}
+			switch {
+			case record.Message != nil:
+				if _, ok := messages[record.Message.ID]; !ok {
+					order = append(order, record.Message.ID)
+				}
+				messages[record.Message.ID] = *record.Message
+			case record.Deleted != "":
+				delete(messages, record.Deleted)
+			}
+		}
+		if last {
+			break
+		}
+	}
+
+	// Messages keep the order in which they were sent
+	for _, id := range order {
+		if message, ok := messages[id]; ok {
+			mailbox.Messages = append(mailbox.Messages, message)
+			delete(messages, id)
+		}
+	}
+
+	return mailbox, nil
+}
+
+// appendLog appends records to the JSONL log
+func appendLog(records ...logRecord) error {
+	if err := os.MkdirAll(filepath.Dir(mailboxFilePath), 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	var data []byte
+	for _, record := range records {
+		line, err := json.Marshal(record)
+		if err != nil {
+			return fmt.Errorf("failed to marshal message: %w", err)
+		}
+		data = append(append(data, line...), '\n')
+	}
+
+	f, err := os.OpenFile(mailboxFilePath, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open mailbox file: %w", err)
+	}
+	defer f.Close()
+
+	// Start on a new line if a previous append was cut short
+	if info, err := f.Stat(); err == nil && info.Size() > 0 {
+		last := make([]byte, 1)
+		if _, err := f.ReadAt(last, info.Size()-1); err == nil && last[0] != '\n' {
+			data = append([]byte{'\n'}, data...)
+		}
+	}
+
+	if _, err := f.Write(data); err != nil {
+		return fmt.Errorf("failed to append to mailbox file: %w", err)
+	}
+	return nil
+}
+
+// appendLogAndCompact appends a record for a change already applied to
+// mailbox, then rewrites the log with one line per message once most of its
+// lines are superseded
+func appendLogAndCompact(mailbox *Mailbox, record logRecord) error {
+	if obsolete := mailbox.logLines + 1 - len(mailbox.Messages); obsolete <= len(mailbox.Messages) {
+		if err := appendLog(record); err != nil {
+			return err
+		}
+		mailbox.logLines++
+		return nil
+	}
+	return compactLog(mailbox)
+}
+
+// compactLog atomically replaces the log with the current messages
+func compactLog(mailbox *Mailbox) error {
+	var data bytes.Buffer
+	for i := range mailbox.Messages {
+		line, err := json.Marshal(logRecord{Message: &mailbox.Messages[i]})
+		if err != nil {
+			return fmt.Errorf("failed to marshal message: %w", err)
+		}
+		data.Write(line)
+		data.WriteByte('\n')
+	}
+
+	tempFile := mailboxFilePath + ".tmp"
+	if err := os.WriteFile(tempFile, data.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to write temporary file: %w", err)
+	}
+	if err := os.Rename(tempFile, mailboxFilePath); err != nil {
+		os.Remove(tempFile) // Clean up on error
+		return fmt.Errorf("failed to rename temporary file: %w", err)
+	}
+	mailbox.logLines = len(mailbox.Messages)
+	return nil
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"context"
+	"os"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// send sends content to bob and returns the ID of the message
+func send(content string) string {
+	_, out, err := SendMessage(context.Background(), nil, SendMessageInput{Recipient: "bob", Content: content})
+	Expect(err).NotTo(HaveOccurred())
+	return out.ID
+}
+
+// contents returns the content of the messages of bob
+func contents() []string {
+	_, out, err := ReadMessages(context.Background(), nil, ReadMessagesInput{})
+	Expect(err).NotTo(HaveOccurred())
+	list := []string{}
+	for _, message := range out.Messages {
+		list = append(list, message.Content)
+	}
+	return list
+}
+
+// logLines returns the lines of the mailbox file
+func logLines(path string) []string {
+	data, err := os.ReadFile(path)
+	Expect(err).NotTo(HaveOccurred())
+	return strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+}
+
+var _ = Describe("Storage", func() {
+	DescribeTable("MAILBOX_STORAGE",
+		func(value, expected string, valid bool) {
+			backend, err := parseStorage(value)
+			Expect(backend).To(Equal(expected))
+			if valid {
+				Expect(err).NotTo(HaveOccurred())
+			} else {
+				Expect(err).To(MatchError(ContainSubstring("unknown MAILBOX_STORAGE")))
+			}
+		},
+		Entry("default", "", storageJSON, true),
+		Entry("json", "json", storageJSON, true),
+		Entry("jsonl", " JSONL ", storageJSONL, true),
+		Entry("unknown", "sqlite", storageJSON, false),
+	)
+
+	for _, backend := range []string{storageJSON, storageJSONL} {
+		It("keeps the changes of every tool with the "+backend+" backend", func() {
+			useMailbox(backend)
+			first, second := send("first"), send("second")
+			send("third")
+
+			_, read, err := MarkMessageRead(context.Background(), nil, MarkMessageReadInput{ID: first})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(read.Success).To(BeTrue())
+			_, deleted, err := DeleteMessage(context.Background(), nil, DeleteMessageInput{ID: second})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(deleted.Success).To(BeTrue())
+
+			_, out, err := ReadMessages(context.Background(), nil, ReadMessagesInput{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(out.Count).To(Equal(2))
+			Expect(out.Unread).To(Equal(1))
+			Expect(out.Messages[0].Content).To(Equal("first"))
+			Expect(out.Messages[0].Read).To(BeTrue())
+			Expect(out.Messages[1].Content).To(Equal("third"))
+		})
+	}
+
+	Describe("jsonl", func() {
+		var path string
+
+		BeforeEach(func() {
+			path = useMailbox(storageJSONL)
+		})
+
+		It("appends a line per change and replays them in order", func() {
+			first := send("first")
+			send("second")
+			_, _, err := MarkMessageRead(context.Background(), nil, MarkMessageReadInput{ID: first})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(logLines(path)).To(HaveLen(3))
+			mailbox, err := loadLog()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(mailbox.Messages).To(HaveLen(2))
+			// The message keeps its place, with its last change
+			Expect(mailbox.Messages[0].ID).To(Equal(first))
+			Expect(mailbox.Messages[0].Read).To(BeTrue())
+			Expect(mailbox.logLines).To(Equal(3))
+		})
+
+		It("skips a last line cut short and starts the next append on a new line", func() {
+			send("first")
+			f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0644)
+			Expect(err).NotTo(HaveOccurred())
+			_, err = f.WriteString(`{"message":{"id":"cut","recip`)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(f.Close()).To(Succeed())
+
+			Expect(contents()).To(Equal([]string{"first"}))
+			send("second")
+			Expect(contents()).To(Equal([]string{"first", "second"}))
+			Expect(logLines(path)).To(HaveLen(3))
+		})
+
+		It("compacts the log once most of its lines are superseded", func() {
+			id := send("only")
+			for range 2 {
+				_, _, err := MarkMessageRead(context.Background(), nil, MarkMessageReadInput{ID: id})
+				Expect(err).NotTo(HaveOccurred())
+			}
+			// The second change outnumbered the message, compacting the log
+			Expect(logLines(path)).To(HaveLen(1))
+			Expect(contents()).To(Equal([]string{"only"}))
+
+			_, _, err := DeleteMessage(context.Background(), nil, DeleteMessageInput{ID: id})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(contents()).To(BeEmpty())
+			data, err := os.ReadFile(path)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(data).To(BeEmpty())
+		})
+
+		It("returns an empty mailbox without a file", func() {
+			mailbox, err := loadLog()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(mailbox.Messages).To(BeEmpty())
+		})
+	})
+})
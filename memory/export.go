@@ -0,0 +1,158 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/mudler/mcps/pkg/safepath"
+)
+
+type ExportMarkdownInput struct {
+	Path string `json:"path,omitempty" jsonschema:"optional file to write the document to instead of returning it, under the export directory (MEMORY_EXPORT_DIR, by default the index directory) against which relative paths are resolved"`
+}
+
+type ExportMarkdownOutput struct {
+	Markdown string `json:"markdown,omitempty" jsonschema:"the Markdown document, when no path is given"`
+	Path     string `json:"path,omitempty" jsonschema:"the file the document was written to"`
+	Bytes    int    `json:"bytes" jsonschema:"size of the document in bytes"`
+	Count    int    `json:"count" jsonschema:"number of entries exported"`
+}
+
+// exportFiles confines the files exports are written to, to MEMORY_EXPORT_DIR
+// or by default the index directory
+var exportFiles safepath.Guard
+
+// exportFilePath resolves the path of an export, relative paths being
+// relative to the export directory
+func exportFilePath(path string) (string, error) {
+	if exportFiles.Root == "" {
+		return "", fmt.Errorf("no export directory configured")
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(exportFiles.Root, path)
+	}
+	return exportFiles.Resolve(path)
+}
+
+// exportDateLayout is the day entries are grouped by in exports
+const exportDateLayout = "2006-01-02"
+
+// renderMarkdown renders entries as a Markdown document for humans to read:
+// a header with the generation time and entry count, then one section per
+// creation day, oldest first, listing the entries of the day with their
+// timestamps and links
+func renderMarkdown(entries []MemoryEntry, generatedAt time.Time) string {
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].CreatedAt.Before(entries[j].CreatedAt)
+	})
+	names := make(map[string]string, len(entries))
+	for _, entry := range entries {
+		names[entry.ID] = entry.Name
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Memory export\n\n")
+	fmt.Fprintf(&b, "Generated at %s, %s.\n", generatedAt.UTC().Format(time.RFC3339), entryCount(len(entries)))
+
+	for start := 0; start < len(entries); {
+		day := exportDay(entries[start].CreatedAt)
+		end := start + 1
+		for end < len(entries) && exportDay(entries[end].CreatedAt) == day {
+			end++
+		}
+		fmt.Fprintf(&b, "\n## %s (%s)\n", day, entryCount(end-start))
+
+		for _, entry := range entries[start:end] {
+			fmt.Fprintf(&b, "\n### %s\n\n", entry.Name)
+			fmt.Fprintf(&b, "- ID: `%s`\n", entry.ID)
+			if !entry.CreatedAt.IsZero() {
+				fmt.Fprintf(&b, "- Created: %s\n", entry.CreatedAt.UTC().Format(time.RFC3339))
+			}
+			if len(entry.RelatedIDs) > 0 {
+				related := make([]string, 0, len(entry.RelatedIDs))
+				for _, id := range entry.RelatedIDs {
+					if name, ok := names[id]; ok {
+						related = append(related, fmt.Sprintf("%s (`%s`)", name, id))
+					} else {
+						related = append(related, fmt.Sprintf("`%s`", id))
+					}
+				}
+				fmt.Fprintf(&b, "- Related: %s\n", strings.Join(related, ", "))
+			}
+			fmt.Fprintf(&b, "\n%s\n", strings.TrimSpace(entry.Content))
+		}
+		start = end
+	}
+
+	return b.String()
+}
+
+// exportDay returns the day an entry is grouped under in exports
+func exportDay(createdAt time.Time) string {
+	if createdAt.IsZero() {
+		return "Unknown date"
+	}
+	return createdAt.UTC().Format(exportDateLayout)
+}
+
+// entryCount formats a number of entries
+func entryCount(n int) string {
+	if n == 1 {
+		return "1 entry"
+	}
+	return fmt.Sprintf("%d entries", n)
+}
+
+// Export all memory entries as a Markdown document
+func ExportMarkdown(ctx context.Context, req *mcp.CallToolRequest, input ExportMarkdownInput) (
+	*mcp.CallToolResult,
+	ExportMarkdownOutput,
+	error,
+) {
+	path := ""
+	if input.Path != "" {
+		var err error
+		if path, err = exportFilePath(input.Path); err != nil {
+			return nil, ExportMarkdownOutput{}, fmt.Errorf("invalid path: %w", err)
+		}
+	}
+
+	entries := []MemoryEntry{}
+	err := forEachEntry([]string{"name", "content", "created_at", "related_ids"}, func(entry MemoryEntry) {
+		entries = append(entries, entry)
+	})
+	if err != nil {
+		return nil, ExportMarkdownOutput{}, err
+	}
+	markdown := renderMarkdown(entries, time.Now())
+
+	output := ExportMarkdownOutput{
+		Bytes: len(markdown),
+		Count: len(entries),
+	}
+	if path == "" {
+		output.Markdown = markdown
+		return nil, output, nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, ExportMarkdownOutput{}, fmt.Errorf("failed to create export directory: %w", err)
+	}
+	tempFile := path + ".tmp"
+	if err := os.WriteFile(tempFile, []byte(markdown), 0644); err != nil {
+		return nil, ExportMarkdownOutput{}, fmt.Errorf("failed to write export: %w", err)
+	}
+	if err := os.Rename(tempFile, path); err != nil {
+		os.Remove(tempFile)
+		return nil, ExportMarkdownOutput{}, fmt.Errorf("failed to write export: %w", err)
+	}
+	output.Path = path
+
+	return nil, output, nil
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+
+	"github.com/mudler/mcps/pkg/safepath"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("export_markdown", func() {
+	var root string
+
+	BeforeEach(func() {
+		useTestIndex()
+		prev := exportFiles
+		DeferCleanup(func() { exportFiles = prev })
+		var err error
+		exportFiles, err = safepath.New(GinkgoT().TempDir())
+		Expect(err).NotTo(HaveOccurred())
+		root = exportFiles.Root
+
+		_, _, err = AddMemory(context.Background(), nil, AddMemoryInput{Name: "groceries", Content: "milk and eggs"})
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("returns the document without a path", func() {
+		_, out, err := ExportMarkdown(context.Background(), nil, ExportMarkdownInput{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(out.Count).To(Equal(1))
+		Expect(out.Markdown).To(ContainSubstring("### groceries"))
+		Expect(out.Path).To(BeEmpty())
+	})
+
+	It("writes relative paths under the export directory", func() {
+		_, out, err := ExportMarkdown(context.Background(), nil, ExportMarkdownInput{Path: "exports/memory.md"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(out.Path).To(Equal(filepath.Join(root, "exports", "memory.md")))
+		Expect(out.Markdown).To(BeEmpty())
+		data, err := os.ReadFile(out.Path)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(data)).To(ContainSubstring("milk and eggs"))
+	})
+
+	It("refuses paths outside the export directory", func() {
+		outside := GinkgoT().TempDir()
+		Expect(os.Symlink(outside, filepath.Join(root, "linked"))).To(Succeed())
+		for _, path := range []string{"../memory.md", filepath.Join(outside, "memory.md"), "linked/memory.md"} {
+			_, _, err := ExportMarkdown(context.Background(), nil, ExportMarkdownInput{Path: path})
+			Expect(errors.Is(err, safepath.ErrOutsideRoot)).To(BeTrue(), "path %s: %v", path, err)
+		}
+		Expect(filepath.Join(outside, "memory.md")).NotTo(BeAnExistingFile())
+	})
+})
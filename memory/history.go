@@ -0,0 +1,214 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/gofrs/flock"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// MemoryVersion is a previous version of a memory entry, replaced by an update
+type MemoryVersion struct {
+	Version    int       `json:"version" jsonschema:"version number, 1 being the content the entry was created with"`
+	Name       string    `json:"name" jsonschema:"the name at this version"`
+	Content    string    `json:"content" jsonschema:"the content at this version"`
+	ReplacedAt time.Time `json:"replaced_at" jsonschema:"when this version was replaced by an update"`
+}
+
+type GetMemoryHistoryInput struct {
+	ID string `json:"id" jsonschema:"the ID of the memory entry"`
+}
+
+type GetMemoryHistoryOutput struct {
+	Entry   MemoryEntry     `json:"entry" jsonschema:"the current version of the memory entry"`
+	History []MemoryVersion `json:"history" jsonschema:"previous versions, newest first"`
+	Count   int             `json:"count" jsonschema:"number of previous versions kept"`
+}
+
+// historyLockTimeout is how long an update waits for another writer to
+// release the history file
+const historyLockTimeout = 5 * time.Second
+
+// memoryHistory keeps previous versions of entries in a JSON file next to
+// the index, so old content does not show up in searches. It is disabled
+// when maxVersions is 0.
+//
+// The file can be shared by several processes, so updates hold a lock file
+// from loading the file to saving it: concurrent writers are serialized and
+// each one sees and keeps the changes of the others.
+type memoryHistory struct {
+	path        string
+	maxVersions int
+	mutex       sync.Mutex
+}
+
+var history = &memoryHistory{}
+
+func (h *memoryHistory) enabled() bool {
+	return h.maxVersions > 0
+}
+
+// load reads the history file, a missing file holding no versions
+func (h *memoryHistory) load() (map[string][]MemoryVersion, error) {
+	all := map[string][]MemoryVersion{}
+	data, err := os.ReadFile(h.path)
+	if os.IsNotExist(err) {
+		return all, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read memory history: %w", err)
+	}
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &all); err != nil {
+			return nil, fmt.Errorf("failed to parse memory history: %w", err)
+		}
+	}
+	return all, nil
+}
+
+// withLock runs fn holding the lock file of the history, shared with the
+// other processes writing it
+func (h *memoryHistory) withLock(fn func() error) error {
+	if err := os.MkdirAll(filepath.Dir(h.path), 0755); err != nil {
+		return fmt.Errorf("failed to create history directory: %w", err)
+	}
+	fileLock := flock.New(h.path + ".lock")
+
+	ctx, cancel := context.WithTimeout(context.Background(), historyLockTimeout)
+	defer cancel()
+
+	locked, err := fileLock.TryLockContext(ctx, 50*time.Millisecond)
+	if err != nil {
+		return fmt.Errorf("failed to lock memory history: %w", err)
+	}
+	if !locked {
+		return fmt.Errorf("memory history is locked by another process")
+	}
+	defer fileLock.Unlock()
+
+	return fn()
+}
+
+// update replaces the versions of an entry with the result of change, nil
+// removing them
+func (h *memoryHistory) update(id string, change func([]MemoryVersion) []MemoryVersion) error {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	return h.withLock(func() error {
+		all, err := h.load()
+		if err != nil {
+			return err
+		}
+		loaded := all[id]
+		versions := change(slices.Clone(loaded))
+		if versions == nil && loaded == nil {
+			return nil
+		}
+		if versions == nil {
+			delete(all, id)
+		} else {
+			all[id] = versions
+		}
+		return h.save(all)
+	})
+}
+
+// save writes the versions of all entries, replacing the file atomically so
+// readers never see it half-written
+func (h *memoryHistory) save(all map[string][]MemoryVersion) error {
+	data, err := json.MarshalIndent(all, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal memory history: %w", err)
+	}
+	tempFile := h.path + ".tmp"
+	if err := os.WriteFile(tempFile, data, 0644); err != nil {
+		return fmt.Errorf("failed to write memory history: %w", err)
+	}
+	if err := os.Rename(tempFile, h.path); err != nil {
+		os.Remove(tempFile)
+		return fmt.Errorf("failed to write memory history: %w", err)
+	}
+	return nil
+}
+
+// push records the version of entry that is about to be replaced, dropping
+// the oldest versions beyond maxVersions
+func (h *memoryHistory) push(entry MemoryEntry, replacedAt time.Time) error {
+	if !h.enabled() {
+		return nil
+	}
+	return h.update(entry.ID, func(versions []MemoryVersion) []MemoryVersion {
+		version := MemoryVersion{Version: 1, Name: entry.Name, Content: entry.Content, ReplacedAt: replacedAt}
+		if len(versions) > 0 {
+			version.Version = versions[len(versions)-1].Version + 1
+		}
+		versions = append(versions, version)
+		if len(versions) > h.maxVersions {
+			versions = versions[len(versions)-h.maxVersions:]
+		}
+		return versions
+	})
+}
+
+// get returns the kept versions of an entry, newest first
+func (h *memoryHistory) get(id string) ([]MemoryVersion, error) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	all, err := h.load()
+	if err != nil {
+		return nil, err
+	}
+	versions := all[id]
+	result := make([]MemoryVersion, 0, len(versions))
+	for i := len(versions) - 1; i >= 0; i-- {
+		result = append(result, versions[i])
+	}
+	return result, nil
+}
+
+// remove drops the history of a removed entry
+func (h *memoryHistory) remove(id string) error {
+	if !h.enabled() {
+		return nil
+	}
+	return h.update(id, func([]MemoryVersion) []MemoryVersion {
+		return nil
+	})
+}
+
+// Get the previous versions of a memory entry
+func GetMemoryHistory(ctx context.Context, req *mcp.CallToolRequest, input GetMemoryHistoryInput) (
+	*mcp.CallToolResult,
+	GetMemoryHistoryOutput,
+	error,
+) {
+	entry, err := getEntry(input.ID)
+	if err != nil {
+		return nil, GetMemoryHistoryOutput{}, err
+	}
+	if entry == nil {
+		return nil, GetMemoryHistoryOutput{}, fmt.Errorf("memory entry with ID '%s' not found", input.ID)
+	}
+
+	versions, err := history.get(input.ID)
+	if err != nil {
+		return nil, GetMemoryHistoryOutput{}, err
+	}
+
+	output := GetMemoryHistoryOutput{
+		Entry:   *entry,
+		History: versions,
+		Count:   len(versions),
+	}
+
+	return nil, output, nil
+}
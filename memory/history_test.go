@@ -0,0 +1,70 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("memory history", func() {
+	var path string
+
+	BeforeEach(func() {
+		path = filepath.Join(GinkgoT().TempDir(), "memory-history.json")
+	})
+
+	It("keeps the latest versions of an entry, newest first", func() {
+		h := &memoryHistory{path: path, maxVersions: 2}
+		for i := 1; i <= 3; i++ {
+			Expect(h.push(MemoryEntry{ID: "a", Name: "a", Content: fmt.Sprintf("v%d", i)}, time.Now())).To(Succeed())
+		}
+		versions, err := h.get("a")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(versions).To(HaveLen(2))
+		Expect(versions[0].Version).To(Equal(3))
+		Expect(versions[0].Content).To(Equal("v3"))
+		Expect(versions[1].Content).To(Equal("v2"))
+
+		Expect(h.remove("a")).To(Succeed())
+		Expect(h.get("a")).To(BeEmpty())
+	})
+
+	It("merges the changes of concurrent writers sharing the file", func() {
+		// Two histories on the same file stand for two server processes
+		writers := []*memoryHistory{
+			{path: path, maxVersions: 100},
+			{path: path, maxVersions: 100},
+		}
+		const pushes = 20
+
+		var wg sync.WaitGroup
+		for w, h := range writers {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				defer GinkgoRecover()
+				for i := 0; i < pushes; i++ {
+					// Each writer records its own entry and a shared one
+					own := MemoryEntry{ID: fmt.Sprintf("writer-%d", w), Content: fmt.Sprint(i)}
+					Expect(h.push(own, time.Now())).To(Succeed())
+					Expect(h.push(MemoryEntry{ID: "shared", Content: fmt.Sprintf("%d-%d", w, i)}, time.Now())).To(Succeed())
+				}
+			}()
+		}
+		wg.Wait()
+
+		for w := range writers {
+			versions, err := writers[0].get(fmt.Sprintf("writer-%d", w))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(versions).To(HaveLen(pushes))
+		}
+		shared, err := writers[1].get("shared")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(shared).To(HaveLen(2 * pushes))
+		Expect(shared[0].Version).To(Equal(2 * pushes))
+	})
+})
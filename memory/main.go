@@ -6,40 +6,73 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
 	"time"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/blevesearch/bleve/v2"
+	"github.com/blevesearch/bleve/v2/search/query"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/mudler/mcps/pkg/safepath"
+	"github.com/mudler/mcps/pkg/selfcheck"
+	"github.com/mudler/mcps/pkg/transport"
 )
 
 // Memory entry structure
 type MemoryEntry struct {
-	ID        string    `json:"id"`
-	Name      string    `json:"name"`
-	Content   string    `json:"content"`
-	CreatedAt time.Time `json:"created_at"`
+	ID         string    `json:"id"`
+	Name       string    `json:"name"`
+	Content    string    `json:"content"`
+	CreatedAt  time.Time `json:"created_at"`
+	RelatedIDs []string  `json:"related_ids,omitempty"`
 }
 
 // Input types for different operations
 type AddMemoryInput struct {
-	Name    string `json:"name" jsonschema:"the name/title of the memory entry"`
-	Content string `json:"content" jsonschema:"the content to store in memory"`
+	Name       string   `json:"name" jsonschema:"the name/title of the memory entry"`
+	Content    string   `json:"content" jsonschema:"the content to store in memory"`
+	RelatedIDs []string `json:"related_ids,omitempty" jsonschema:"optional IDs of existing entries to link this entry to"`
+}
+
+type UpdateMemoryInput struct {
+	ID      string `json:"id" jsonschema:"the ID of the memory entry to update"`
+	Name    string `json:"name,omitempty" jsonschema:"the new name (default: keep the current name)"`
+	Content string `json:"content,omitempty" jsonschema:"the new content (default: keep the current content)"`
 }
 
 type RemoveMemoryInput struct {
 	ID string `json:"id" jsonschema:"the ID of the memory entry to remove"`
 }
 
+type LinkMemoryInput struct {
+	ID         string   `json:"id" jsonschema:"the ID of the memory entry to link from"`
+	RelatedIDs []string `json:"related_ids" jsonschema:"the IDs of the entries to link to (links are bidirectional)"`
+	Unlink     bool     `json:"unlink,omitempty" jsonschema:"remove the links instead of adding them"`
+}
+
+type GetRelatedInput struct {
+	ID    string `json:"id" jsonschema:"the ID of the memory entry"`
+	Depth int    `json:"depth,omitempty" jsonschema:"how many hops of links to follow (default: 1, max: 5)"`
+}
+
 type SearchMemoryInput struct {
 	Query string `json:"query" jsonschema:"the search query to find matching memory entries"`
 }
 
 // Output types
 type AddMemoryOutput struct {
-	ID        string    `json:"id" jsonschema:"the ID of the created memory entry"`
-	Name      string    `json:"name" jsonschema:"the name of the memory entry"`
-	Content   string    `json:"content" jsonschema:"the stored content"`
-	CreatedAt time.Time `json:"created_at" jsonschema:"when the entry was created"`
+	ID         string    `json:"id" jsonschema:"the ID of the created memory entry"`
+	Name       string    `json:"name" jsonschema:"the name of the memory entry"`
+	Content    string    `json:"content" jsonschema:"the stored content"`
+	CreatedAt  time.Time `json:"created_at" jsonschema:"when the entry was created"`
+	RelatedIDs []string  `json:"related_ids,omitempty" jsonschema:"IDs of the linked entries"`
+}
+
+type UpdateMemoryOutput struct {
+	Entry           MemoryEntry `json:"entry" jsonschema:"the memory entry after the update"`
+	PreviousVersion int         `json:"previous_version,omitempty" jsonschema:"version number under which the replaced content was kept, when history is enabled"`
 }
 
 type ListMemoryOutput struct {
@@ -52,10 +85,42 @@ type RemoveMemoryOutput struct {
 	Message string `json:"message" jsonschema:"status message"`
 }
 
+type LinkMemoryOutput struct {
+	ID         string   `json:"id" jsonschema:"the ID of the memory entry"`
+	RelatedIDs []string `json:"related_ids" jsonschema:"the entry's linked IDs after the update"`
+}
+
+type RelatedMemoryEntry struct {
+	MemoryEntry
+	Distance int `json:"distance" jsonschema:"number of link hops from the requested entry"`
+}
+
+type GetRelatedOutput struct {
+	Entry   MemoryEntry          `json:"entry" jsonschema:"the requested memory entry"`
+	Related []RelatedMemoryEntry `json:"related" jsonschema:"linked entries ordered by distance"`
+	Count   int                  `json:"count" jsonschema:"number of related entries"`
+}
+
 type SearchMemoryOutput struct {
 	Query   string        `json:"query" jsonschema:"the search query used"`
 	Results []MemoryEntry `json:"results" jsonschema:"matching memory entries"`
 	Count   int           `json:"count" jsonschema:"number of matching entries found"`
+	Partial bool          `json:"partial,omitempty" jsonschema:"whether no entry matched whole terms and the results match the query words as prefixes instead"`
+}
+
+type TermCount struct {
+	Term  string `json:"term" jsonschema:"the indexed term"`
+	Count int    `json:"count" jsonschema:"number of entries containing the term"`
+}
+
+type MemoryStatsOutput struct {
+	TotalEntries  int         `json:"total_entries" jsonschema:"number of memory entries"`
+	TotalBytes    int         `json:"total_bytes" jsonschema:"total size of the entries' content in bytes"`
+	Oldest        *time.Time  `json:"oldest,omitempty" jsonschema:"creation time of the oldest entry"`
+	Newest        *time.Time  `json:"newest,omitempty" jsonschema:"creation time of the newest entry"`
+	LinkedEntries int         `json:"linked_entries" jsonschema:"number of entries linked to at least one other entry"`
+	TotalLinks    int         `json:"total_links" jsonschema:"number of links between entries"`
+	TopTerms      []TermCount `json:"top_terms" jsonschema:"most recurring terms in the entries' content"`
 }
 
 // Global variable to store the bleve index
@@ -103,6 +168,11 @@ func initBleveIndex() error {
 	dateFieldMapping.Store = true
 	entryMapping.AddFieldMappingsAt("created_at", dateFieldMapping)
 
+	// Map related_ids as keywords (stored, matched exactly)
+	relatedFieldMapping := bleve.NewKeywordFieldMapping()
+	relatedFieldMapping.Store = true
+	entryMapping.AddFieldMappingsAt("related_ids", relatedFieldMapping)
+
 	// Add document mapping to index mapping
 	mapping.AddDocumentMapping("_default", entryMapping)
 
@@ -116,12 +186,130 @@ func initBleveIndex() error {
 	return nil
 }
 
+// entryPageSize is how many entries are fetched per search when going
+// through the whole index
+const entryPageSize = 1000
+
+// forEachEntry calls fn with every entry of the index, with the given stored
+// fields, fetching them a page at a time so none is left out however large
+// the index grows
+func forEachEntry(fields []string, fn func(MemoryEntry)) error {
+	for from := 0; ; from += entryPageSize {
+		searchRequest := bleve.NewSearchRequestOptions(bleve.NewMatchAllQuery(), entryPageSize, from, false)
+		searchRequest.Fields = fields
+		// A stable order keeps pages from overlapping
+		searchRequest.SortBy([]string{"_id"})
+
+		searchResult, err := index.Search(searchRequest)
+		if err != nil {
+			return fmt.Errorf("failed to search index: %w", err)
+		}
+		for _, hit := range searchResult.Hits {
+			fn(entryFromFields(hit.ID, hit.Fields))
+		}
+		if len(searchResult.Hits) < entryPageSize {
+			return nil
+		}
+	}
+}
+
+// entryFromFields builds a memory entry from the stored fields of a search hit
+func entryFromFields(id string, fields map[string]interface{}) MemoryEntry {
+	entry := MemoryEntry{
+		ID: id,
+	}
+
+	if nameVal, ok := fields["name"].(string); ok {
+		entry.Name = nameVal
+	}
+	if contentVal, ok := fields["content"].(string); ok {
+		entry.Content = contentVal
+	}
+	if createdAtVal, ok := fields["created_at"].(string); ok {
+		if t, err := time.Parse(time.RFC3339, createdAtVal); err == nil {
+			entry.CreatedAt = t
+		}
+	} else if createdAtVal, ok := fields["created_at"].(time.Time); ok {
+		entry.CreatedAt = createdAtVal
+	}
+
+	// Bleve returns a single string for one value and a slice for several
+	switch related := fields["related_ids"].(type) {
+	case string:
+		entry.RelatedIDs = []string{related}
+	case []interface{}:
+		for _, v := range related {
+			if id, ok := v.(string); ok {
+				entry.RelatedIDs = append(entry.RelatedIDs, id)
+			}
+		}
+	}
+
+	return entry
+}
+
+// getEntry loads a memory entry by ID, returning nil if it does not exist
+func getEntry(id string) (*MemoryEntry, error) {
+	searchRequest := bleve.NewSearchRequest(bleve.NewDocIDQuery([]string{id}))
+	searchRequest.Fields = []string{"name", "content", "created_at", "related_ids"}
+
+	searchResult, err := index.Search(searchRequest)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search index: %w", err)
+	}
+	if len(searchResult.Hits) == 0 {
+		return nil, nil
+	}
+
+	entry := entryFromFields(searchResult.Hits[0].ID, searchResult.Hits[0].Fields)
+	return &entry, nil
+}
+
+// validateIDs checks that every ID refers to an existing entry
+func validateIDs(ids []string) error {
+	for _, id := range ids {
+		entry, err := getEntry(id)
+		if err != nil {
+			return err
+		}
+		if entry == nil {
+			return fmt.Errorf("memory entry with ID '%s' not found", id)
+		}
+	}
+	return nil
+}
+
+// addLink adds id to the entry's related IDs if not already present
+func addLink(entry *MemoryEntry, id string) {
+	for _, existing := range entry.RelatedIDs {
+		if existing == id {
+			return
+		}
+	}
+	entry.RelatedIDs = append(entry.RelatedIDs, id)
+}
+
+// removeLink removes id from the entry's related IDs
+func removeLink(entry *MemoryEntry, id string) {
+	kept := entry.RelatedIDs[:0]
+	for _, existing := range entry.RelatedIDs {
+		if existing != id {
+			kept = append(kept, existing)
+		}
+	}
+	entry.RelatedIDs = kept
+}
+
 // Add memory entry
 func AddMemory(ctx context.Context, req *mcp.CallToolRequest, input AddMemoryInput) (
 	*mcp.CallToolResult,
 	AddMemoryOutput,
 	error,
 ) {
+	if err := validateIDs(input.RelatedIDs); err != nil {
+		return nil, AddMemoryOutput{}, err
+	}
+
 	entry := MemoryEntry{
 		ID:        generateID(),
 		Name:      input.Name,
@@ -134,11 +322,21 @@ func AddMemory(ctx context.Context, req *mcp.CallToolRequest, input AddMemoryInp
 		return nil, AddMemoryOutput{}, fmt.Errorf("failed to index memory entry: %w", err)
 	}
 
+	// Link the new entry with the related ones in both directions
+	if len(input.RelatedIDs) > 0 {
+		_, linked, err := LinkMemory(ctx, req, LinkMemoryInput{ID: entry.ID, RelatedIDs: input.RelatedIDs})
+		if err != nil {
+			return nil, AddMemoryOutput{}, err
+		}
+		entry.RelatedIDs = linked.RelatedIDs
+	}
+
 	output := AddMemoryOutput{
-		ID:        entry.ID,
-		Name:      entry.Name,
-		Content:   entry.Content,
-		CreatedAt: entry.CreatedAt,
+		ID:         entry.ID,
+		Name:       entry.Name,
+		Content:    entry.Content,
+		CreatedAt:  entry.CreatedAt,
+		RelatedIDs: entry.RelatedIDs,
 	}
 
 	return nil, output, nil
@@ -177,6 +375,57 @@ func ListMemory(ctx context.Context, req *mcp.CallToolRequest, input struct{}) (
 	return nil, output, nil
 }
 
+// Update the name and/or content of a memory entry, keeping its ID, creation
+// time and links
+func UpdateMemory(ctx context.Context, req *mcp.CallToolRequest, input UpdateMemoryInput) (
+	*mcp.CallToolResult,
+	UpdateMemoryOutput,
+	error,
+) {
+	if input.Name == "" && input.Content == "" {
+		return nil, UpdateMemoryOutput{}, fmt.Errorf("name or content is required")
+	}
+
+	entry, err := getEntry(input.ID)
+	if err != nil {
+		return nil, UpdateMemoryOutput{}, err
+	}
+	if entry == nil {
+		return nil, UpdateMemoryOutput{}, fmt.Errorf("memory entry with ID '%s' not found", input.ID)
+	}
+
+	// Keep the replaced version before touching the index so no edit goes unrecorded
+	if err := history.push(*entry, time.Now()); err != nil {
+		return nil, UpdateMemoryOutput{}, err
+	}
+
+	if input.Name != "" {
+		entry.Name = input.Name
+	}
+	if input.Content != "" {
+		entry.Content = input.Content
+	}
+
+	if err := index.Index(entry.ID, *entry); err != nil {
+		return nil, UpdateMemoryOutput{}, fmt.Errorf("failed to index memory entry: %w", err)
+	}
+
+	output := UpdateMemoryOutput{
+		Entry: *entry,
+	}
+	if history.enabled() {
+		versions, err := history.get(entry.ID)
+		if err != nil {
+			return nil, UpdateMemoryOutput{}, err
+		}
+		if len(versions) > 0 {
+			output.PreviousVersion = versions[0].Version
+		}
+	}
+
+	return nil, output, nil
+}
+
 // Remove memory entry by ID
 func RemoveMemory(ctx context.Context, req *mcp.CallToolRequest, input RemoveMemoryInput) (
 	*mcp.CallToolResult,
@@ -197,11 +446,22 @@ func RemoveMemory(ctx context.Context, req *mcp.CallToolRequest, input RemoveMem
 		return nil, output, nil
 	}
 
+	// Drop the links pointing back to the removed entry
+	if entry, err := getEntry(input.ID); err == nil && entry != nil && len(entry.RelatedIDs) > 0 {
+		if _, _, err := LinkMemory(ctx, req, LinkMemoryInput{ID: entry.ID, RelatedIDs: entry.RelatedIDs, Unlink: true}); err != nil {
+			log.Printf("Warning: failed to unlink memory entry %s: %v", entry.ID, err)
+		}
+	}
+
 	// Delete the document from the index
 	if err := index.Delete(input.ID); err != nil {
 		return nil, RemoveMemoryOutput{}, fmt.Errorf("failed to delete memory entry: %w", err)
 	}
 
+	if err := history.remove(input.ID); err != nil {
+		log.Printf("Warning: failed to remove history of memory entry %s: %v", input.ID, err)
+	}
+
 	output := RemoveMemoryOutput{
 		Success: true,
 		Message: fmt.Sprintf("Memory entry with ID '%s' removed successfully", input.ID),
@@ -224,35 +484,27 @@ func SearchMemory(ctx context.Context, req *mcp.CallToolRequest, input SearchMem
 	contentQuery.SetField("content")
 	disjunctionQuery := bleve.NewDisjunctionQuery(nameQuery, contentQuery)
 
-	searchRequest := bleve.NewSearchRequest(disjunctionQuery)
-	searchRequest.Size = 100                                         // Limit results to 100
-	searchRequest.Fields = []string{"name", "content", "created_at"} // Request stored fields
-
-	searchResult, err := index.Search(searchRequest)
+	searchResult, err := searchEntries(disjunctionQuery)
 	if err != nil {
-		return nil, SearchMemoryOutput{}, fmt.Errorf("failed to search index: %w", err)
+		return nil, SearchMemoryOutput{}, err
 	}
 
-	results := make([]MemoryEntry, 0, len(searchResult.Hits))
-	for _, hit := range searchResult.Hits {
-		entry := MemoryEntry{
-			ID: hit.ID,
-		}
-
-		// Try to extract fields from stored fields in search result first
-		if nameVal, ok := hit.Fields["name"].(string); ok {
-			entry.Name = nameVal
-		}
-		if contentVal, ok := hit.Fields["content"].(string); ok {
-			entry.Content = contentVal
-		}
-		if createdAtVal, ok := hit.Fields["created_at"].(string); ok {
-			if t, err := time.Parse(time.RFC3339, createdAtVal); err == nil {
-				entry.CreatedAt = t
+	// Match queries only find whole terms, so "kube" misses "kubernetes".
+	// When nothing matched, look the query words up as term prefixes.
+	partial := false
+	if len(searchResult.Hits) == 0 {
+		if prefixQuery := partialTermsQuery(input.Query); prefixQuery != nil {
+			searchResult, err = searchEntries(prefixQuery)
+			if err != nil {
+				return nil, SearchMemoryOutput{}, err
 			}
-		} else if createdAtVal, ok := hit.Fields["created_at"].(time.Time); ok {
-			entry.CreatedAt = createdAtVal
+			partial = len(searchResult.Hits) > 0
 		}
+	}
+
+	results := make([]MemoryEntry, 0, len(searchResult.Hits))
+	for _, hit := range searchResult.Hits {
+		entry := entryFromFields(hit.ID, hit.Fields)
 
 		// If fields are missing, try to fetch document and reconstruct from stored data
 		// Note: This is a fallback - stored fields should work with Store = true
@@ -273,6 +525,228 @@ func SearchMemory(ctx context.Context, req *mcp.CallToolRequest, input SearchMem
 		Query:   input.Query,
 		Results: results,
 		Count:   len(results),
+		Partial: partial,
+	}
+
+	return nil, output, nil
+}
+
+// minPrefixLength is the shortest query word looked up as a term prefix,
+// shorter ones would match most of the index
+const minPrefixLength = 3
+
+// searchEntries runs a query over the entries, returning up to 100 hits with
+// their stored fields
+func searchEntries(q query.Query) (*bleve.SearchResult, error) {
+	searchRequest := bleve.NewSearchRequest(q)
+	searchRequest.Size = 100                                                        // Limit results to 100
+	searchRequest.Fields = []string{"name", "content", "created_at", "related_ids"} // Request stored fields
+
+	searchResult, err := index.Search(searchRequest)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search index: %w", err)
+	}
+	return searchResult, nil
+}
+
+// partialTermsQuery matches the entries with a term in their name or content
+// starting with one of the query words, or returns nil when no word is long
+// enough. Words are lowercased like the standard analyzer does at indexing.
+func partialTermsQuery(text string) query.Query {
+	var prefixes []query.Query
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+	for _, word := range words {
+		if utf8.RuneCountInString(word) < minPrefixLength {
+			continue
+		}
+		for _, field := range []string{"name", "content"} {
+			prefix := bleve.NewPrefixQuery(word)
+			prefix.SetField(field)
+			prefixes = append(prefixes, prefix)
+		}
+	}
+	if len(prefixes) == 0 {
+		return nil
+	}
+	return bleve.NewDisjunctionQuery(prefixes...)
+}
+
+// Link memory entries to each other (or unlink them)
+func LinkMemory(ctx context.Context, req *mcp.CallToolRequest, input LinkMemoryInput) (
+	*mcp.CallToolResult,
+	LinkMemoryOutput,
+	error,
+) {
+	if len(input.RelatedIDs) == 0 {
+		return nil, LinkMemoryOutput{}, fmt.Errorf("related_ids is required")
+	}
+
+	entry, err := getEntry(input.ID)
+	if err != nil {
+		return nil, LinkMemoryOutput{}, err
+	}
+	if entry == nil {
+		return nil, LinkMemoryOutput{}, fmt.Errorf("memory entry with ID '%s' not found", input.ID)
+	}
+
+	related := make([]*MemoryEntry, 0, len(input.RelatedIDs))
+	for _, id := range input.RelatedIDs {
+		if id == input.ID {
+			return nil, LinkMemoryOutput{}, fmt.Errorf("cannot link memory entry '%s' to itself", id)
+		}
+		other, err := getEntry(id)
+		if err != nil {
+			return nil, LinkMemoryOutput{}, err
+		}
+		if other == nil {
+			return nil, LinkMemoryOutput{}, fmt.Errorf("memory entry with ID '%s' not found", id)
+		}
+		related = append(related, other)
+	}
+
+	// Links are bidirectional so neighbors can be found from either side
+	batch := index.NewBatch()
+	for _, other := range related {
+		if input.Unlink {
+			removeLink(entry, other.ID)
+			removeLink(other, entry.ID)
+		} else {
+			addLink(entry, other.ID)
+			addLink(other, entry.ID)
+		}
+		if err := batch.Index(other.ID, *other); err != nil {
+			return nil, LinkMemoryOutput{}, fmt.Errorf("failed to index memory entry: %w", err)
+		}
+	}
+	if err := batch.Index(entry.ID, *entry); err != nil {
+		return nil, LinkMemoryOutput{}, fmt.Errorf("failed to index memory entry: %w", err)
+	}
+	if err := index.Batch(batch); err != nil {
+		return nil, LinkMemoryOutput{}, fmt.Errorf("failed to update links: %w", err)
+	}
+
+	relatedIDs := entry.RelatedIDs
+	if relatedIDs == nil {
+		relatedIDs = []string{}
+	}
+
+	return nil, LinkMemoryOutput{ID: entry.ID, RelatedIDs: relatedIDs}, nil
+}
+
+// Get a memory entry together with its linked neighbors
+func GetRelated(ctx context.Context, req *mcp.CallToolRequest, input GetRelatedInput) (
+	*mcp.CallToolResult,
+	GetRelatedOutput,
+	error,
+) {
+	depth := input.Depth
+	if depth <= 0 {
+		depth = 1
+	}
+	if depth > 5 {
+		depth = 5
+	}
+
+	entry, err := getEntry(input.ID)
+	if err != nil {
+		return nil, GetRelatedOutput{}, err
+	}
+	if entry == nil {
+		return nil, GetRelatedOutput{}, fmt.Errorf("memory entry with ID '%s' not found", input.ID)
+	}
+
+	// Breadth-first walk so each neighbor is reported at its shortest distance
+	visited := map[string]bool{entry.ID: true}
+	frontier := []*MemoryEntry{entry}
+	related := []RelatedMemoryEntry{}
+	for distance := 1; distance <= depth && len(frontier) > 0; distance++ {
+		var next []*MemoryEntry
+		for _, current := range frontier {
+			for _, id := range current.RelatedIDs {
+				if visited[id] {
+					continue
+				}
+				visited[id] = true
+
+				neighbor, err := getEntry(id)
+				if err != nil {
+					return nil, GetRelatedOutput{}, err
+				}
+				if neighbor == nil {
+					// Dangling link to a removed entry
+					continue
+				}
+				related = append(related, RelatedMemoryEntry{MemoryEntry: *neighbor, Distance: distance})
+				next = append(next, neighbor)
+			}
+		}
+		frontier = next
+	}
+
+	output := GetRelatedOutput{
+		Entry:   *entry,
+		Related: related,
+		Count:   len(related),
+	}
+
+	return nil, output, nil
+}
+
+// Get an overview of the memory contents
+func GetMemoryStats(ctx context.Context, req *mcp.CallToolRequest, input struct{}) (
+	*mcp.CallToolResult,
+	MemoryStatsOutput,
+	error,
+) {
+	total, err := index.DocCount()
+	if err != nil {
+		return nil, MemoryStatsOutput{}, fmt.Errorf("failed to count entries: %w", err)
+	}
+
+	// The term facet covers every entry without fetching any
+	searchRequest := bleve.NewSearchRequestOptions(bleve.NewMatchAllQuery(), 0, 0, false)
+	searchRequest.AddFacet("terms", bleve.NewFacetRequest("content", 10))
+
+	searchResult, err := index.Search(searchRequest)
+	if err != nil {
+		return nil, MemoryStatsOutput{}, fmt.Errorf("failed to search index: %w", err)
+	}
+
+	output := MemoryStatsOutput{
+		TotalEntries: int(total),
+		TopTerms:     []TermCount{},
+	}
+
+	err = forEachEntry([]string{"content", "created_at", "related_ids"}, func(entry MemoryEntry) {
+		output.TotalBytes += len(entry.Content)
+		if len(entry.RelatedIDs) > 0 {
+			output.LinkedEntries++
+			output.TotalLinks += len(entry.RelatedIDs)
+		}
+		if entry.CreatedAt.IsZero() {
+			return
+		}
+		if output.Oldest == nil || entry.CreatedAt.Before(*output.Oldest) {
+			createdAt := entry.CreatedAt
+			output.Oldest = &createdAt
+		}
+		if output.Newest == nil || entry.CreatedAt.After(*output.Newest) {
+			createdAt := entry.CreatedAt
+			output.Newest = &createdAt
+		}
+	})
+	if err != nil {
+		return nil, MemoryStatsOutput{}, err
+	}
+	// Links are stored on both ends
+	output.TotalLinks /= 2
+
+	if facet, ok := searchResult.Facets["terms"]; ok && facet.Terms != nil {
+		for _, term := range facet.Terms.Terms() {
+			output.TopTerms = append(output.TopTerms, TermCount{Term: term.Term, Count: term.Count})
+		}
 	}
 
 	return nil, output, nil
@@ -288,6 +762,52 @@ func main() {
 	// Create directory if it doesn't exist
 	os.MkdirAll(filepath.Dir(indexPath), 0755)
 
+	// Exports are written under MEMORY_EXPORT_DIR, the index directory by default
+	exportDir := os.Getenv("MEMORY_EXPORT_DIR")
+	if exportDir == "" {
+		exportDir = filepath.Dir(indexPath)
+	}
+	var exportDirErr error
+	exportFiles, exportDirErr = safepath.New(exportDir)
+	if exportDirErr != nil {
+		exportDirErr = fmt.Errorf("Invalid MEMORY_EXPORT_DIR: %w", exportDirErr)
+	}
+
+	// Version history is opt-in to keep its size under control
+	var historyErr error
+	if value := os.Getenv("MEMORY_HISTORY_VERSIONS"); value != "" {
+		maxVersions, err := strconv.Atoi(value)
+		if err != nil || maxVersions < 0 {
+			historyErr = fmt.Errorf("Invalid MEMORY_HISTORY_VERSIONS %q: must be a non-negative integer", value)
+		} else {
+			history.maxVersions = maxVersions
+		}
+	}
+	history.path = os.Getenv("MEMORY_HISTORY_PATH")
+	if history.path == "" {
+		history.path = filepath.Join(filepath.Dir(indexPath), "memory-history.json")
+	}
+
+	if selfcheck.Enabled() {
+		checks := []selfcheck.Check{
+			selfcheck.Dir("MEMORY_INDEX_PATH", filepath.Dir(indexPath)),
+			selfcheck.Value("MEMORY_HISTORY_VERSIONS", strconv.Itoa(history.maxVersions), historyErr),
+		}
+		if history.enabled() {
+			checks = append(checks, selfcheck.File("MEMORY_HISTORY_PATH", history.path))
+		}
+		if os.Getenv("MEMORY_EXPORT_DIR") != "" {
+			checks = append(checks, selfcheck.Value("MEMORY_EXPORT_DIR", exportFiles.Root, exportDirErr))
+		}
+		selfcheck.Exit("memory", checks...)
+	}
+	if historyErr != nil {
+		log.Fatal(historyErr)
+	}
+	if exportDirErr != nil {
+		log.Fatal(exportDirErr)
+	}
+
 	// Initialize bleve index
 	if err := initBleveIndex(); err != nil {
 		log.Fatalf("Failed to initialize bleve index: %v", err)
@@ -302,6 +822,11 @@ func main() {
 		addToolName = "add_memory"
 	}
 
+	updateToolName := os.Getenv("MEMORY_UPDATE_TOOL_NAME")
+	if updateToolName == "" {
+		updateToolName = "update_memory"
+	}
+
 	listToolName := os.Getenv("MEMORY_LIST_TOOL_NAME")
 	if listToolName == "" {
 		listToolName = "list_memory"
@@ -317,12 +842,42 @@ func main() {
 		searchToolName = "search_memory"
 	}
 
+	linkToolName := os.Getenv("MEMORY_LINK_TOOL_NAME")
+	if linkToolName == "" {
+		linkToolName = "link_memory"
+	}
+
+	relatedToolName := os.Getenv("MEMORY_RELATED_TOOL_NAME")
+	if relatedToolName == "" {
+		relatedToolName = "get_related"
+	}
+
+	statsToolName := os.Getenv("MEMORY_STATS_TOOL_NAME")
+	if statsToolName == "" {
+		statsToolName = "get_memory_stats"
+	}
+
+	historyToolName := os.Getenv("MEMORY_HISTORY_TOOL_NAME")
+	if historyToolName == "" {
+		historyToolName = "get_memory_history"
+	}
+
+	exportToolName := os.Getenv("MEMORY_EXPORT_TOOL_NAME")
+	if exportToolName == "" {
+		exportToolName = "export_markdown"
+	}
+
 	// Register memory tools
 	mcp.AddTool(server, &mcp.Tool{
 		Name:        addToolName,
 		Description: "Add a new entry to memory storage",
 	}, AddMemory)
 
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        updateToolName,
+		Description: "Update the name and/or content of a memory entry by ID, keeping its creation time and links",
+	}, UpdateMemory)
+
 	mcp.AddTool(server, &mcp.Tool{
 		Name:        listToolName,
 		Description: "List all memory entry names",
@@ -338,7 +893,34 @@ func main() {
 		Description: "Search memory entries by name and content using full-text search",
 	}, SearchMemory)
 
-	if err := server.Run(context.Background(), &mcp.StdioTransport{}); err != nil {
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        linkToolName,
+		Description: "Link memory entries to each other (bidirectional), or unlink them with unlink=true",
+	}, LinkMemory)
+
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        relatedToolName,
+		Description: "Get a memory entry together with its linked entries, optionally following links up to N hops",
+	}, GetRelated)
+
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        statsToolName,
+		Description: "Get an overview of the memory: entry count, total content size, oldest/newest entry, links and most recurring terms",
+	}, GetMemoryStats)
+
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        exportToolName,
+		Description: "Export all memory entries as a Markdown document for human review, grouped by creation day with timestamps and links, optionally written to a file",
+	}, ExportMarkdown)
+
+	if history.enabled() {
+		mcp.AddTool(server, &mcp.Tool{
+			Name:        historyToolName,
+			Description: "Get the previous versions of a memory entry (newest first), as recorded by updates",
+		}, GetMemoryHistory)
+	}
+
+	if err := transport.Run(context.Background(), server); err != nil {
 		log.Fatal(err)
 	}
 }
@@ -0,0 +1,26 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestMemory(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Memory Suite")
+}
+
+// useTestIndex points the server at a new index in a temporary directory for
+// the duration of a spec
+func useTestIndex() {
+	prevIndex, prevPath := index, indexPath
+	indexPath = filepath.Join(GinkgoT().TempDir(), "memory.bleve")
+	Expect(initBleveIndex()).To(Succeed())
+	DeferCleanup(func() {
+		index.Close()
+		index, indexPath = prevIndex, prevPath
+	})
+}
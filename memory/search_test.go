@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("search_memory", func() {
+	BeforeEach(func() {
+		useTestIndex()
+		for _, entry := range []AddMemoryInput{
+			{Name: "Kubernetes cluster", Content: "the staging cluster runs on three nodes"},
+			{Name: "groceries", Content: "milk and eggs"},
+		} {
+			_, _, err := AddMemory(context.Background(), nil, entry)
+			Expect(err).NotTo(HaveOccurred())
+		}
+	})
+
+	names := func(out SearchMemoryOutput) []string {
+		var names []string
+		for _, entry := range out.Results {
+			names = append(names, entry.Name)
+		}
+		return names
+	}
+
+	It("matches whole terms", func() {
+		_, out, err := SearchMemory(context.Background(), nil, SearchMemoryInput{Query: "kubernetes"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(names(out)).To(ConsistOf("Kubernetes cluster"))
+		Expect(out.Partial).To(BeFalse())
+	})
+
+	It("falls back to term prefixes when no whole term matches", func() {
+		_, out, err := SearchMemory(context.Background(), nil, SearchMemoryInput{Query: "Kube"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(names(out)).To(ConsistOf("Kubernetes cluster"))
+		Expect(out.Count).To(Equal(1))
+		Expect(out.Partial).To(BeTrue())
+	})
+
+	It("looks every query word up as a prefix", func() {
+		_, out, err := SearchMemory(context.Background(), nil, SearchMemoryInput{Query: "stag, gro"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(names(out)).To(ConsistOf("Kubernetes cluster", "groceries"))
+		Expect(out.Partial).To(BeTrue())
+	})
+
+	It("does not look up words shorter than minPrefixLength", func() {
+		_, out, err := SearchMemory(context.Background(), nil, SearchMemoryInput{Query: "ku"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(out.Results).To(BeEmpty())
+		Expect(out.Partial).To(BeFalse())
+	})
+
+	It("is not partial when no prefix matches either", func() {
+		_, out, err := SearchMemory(context.Background(), nil, SearchMemoryInput{Query: "xyzzy"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(out.Results).To(BeEmpty())
+		Expect(out.Partial).To(BeFalse())
+	})
+})
+
+var _ = Describe("partialTermsQuery", func() {
+	It("returns nil when every word is shorter than minPrefixLength", func() {
+		Expect(partialTermsQuery("a ku, io")).To(BeNil())
+		Expect(partialTermsQuery("")).To(BeNil())
+	})
+
+	It("counts runes rather than bytes", func() {
+		Expect(partialTermsQuery("éé")).To(BeNil())
+		Expect(partialTermsQuery("été")).NotTo(BeNil())
+	})
+})
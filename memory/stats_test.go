@@ -0,0 +1,52 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("get_memory_stats", func() {
+	BeforeEach(useTestIndex)
+
+	It("covers every entry beyond a single page", func() {
+		start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+		entries := 2*entryPageSize + 5
+		batch := index.NewBatch()
+		for i := 0; i < entries; i++ {
+			entry := MemoryEntry{
+				ID:        fmt.Sprintf("%06d", i),
+				Name:      fmt.Sprintf("entry %d", i),
+				Content:   strings.Repeat("x", 10),
+				CreatedAt: start.Add(time.Duration(i) * time.Minute),
+			}
+			if i < 2 {
+				entry.RelatedIDs = []string{fmt.Sprintf("%06d", 1-i)}
+			}
+			Expect(batch.Index(entry.ID, entry)).To(Succeed())
+		}
+		Expect(index.Batch(batch)).To(Succeed())
+
+		_, out, err := GetMemoryStats(context.Background(), nil, struct{}{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(out.TotalEntries).To(Equal(entries))
+		Expect(out.TotalBytes).To(Equal(10 * entries))
+		Expect(out.Oldest.Equal(start)).To(BeTrue())
+		Expect(out.Newest.Equal(start.Add(time.Duration(entries-1) * time.Minute))).To(BeTrue())
+		Expect(out.LinkedEntries).To(Equal(2))
+		Expect(out.TotalLinks).To(Equal(1))
+		Expect(out.TopTerms).NotTo(BeEmpty())
+		Expect(out.TopTerms[0].Count).To(Equal(entries))
+	})
+
+	It("reports an empty memory", func() {
+		_, out, err := GetMemoryStats(context.Background(), nil, struct{}{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(out.TotalEntries).To(BeZero())
+		Expect(out.Oldest).To(BeNil())
+	})
+})
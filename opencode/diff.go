@@ -0,0 +1,165 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	// diffContext is the number of unchanged lines shown around changes
+	diffContext = 3
+	// maxDiffCells bounds the LCS table of a file; larger changes are shown
+	// as a removal of the old lines followed by the new ones
+	maxDiffCells = 1 << 22
+)
+
+// edit is a line of an edit script: ' ' kept, '-' removed or '+' added
+type edit struct {
+	op   byte
+	line string
+}
+
+// splitLines splits content into lines keeping their "\n", so a last line
+// without one can be told apart
+func splitLines(content string) []string {
+	if content == "" {
+		return nil
+	}
+	lines := strings.SplitAfter(content, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// editScript returns the edits turning a into b. Common leading and
+// trailing lines are matched directly, the rest with a longest common
+// subsequence.
+func editScript(a, b []string) []edit {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	edits := make([]edit, 0, len(a)+len(b))
+	for _, line := range a[:prefix] {
+		edits = append(edits, edit{' ', line})
+	}
+	am, bm := a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]
+	if len(am)*len(bm) > maxDiffCells {
+		for _, line := range am {
+			edits = append(edits, edit{'-', line})
+		}
+		for _, line := range bm {
+			edits = append(edits, edit{'+', line})
+		}
+	} else {
+		edits = append(edits, lcsEdits(am, bm)...)
+	}
+	for _, line := range a[len(a)-suffix:] {
+		edits = append(edits, edit{' ', line})
+	}
+	return edits
+}
+
+// lcsEdits computes the edits between a and b from the table of their
+// longest common suffixes
+func lcsEdits(a, b []string) []edit {
+	width := len(b) + 1
+	table := make([]int32, (len(a)+1)*width)
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				table[i*width+j] = table[(i+1)*width+j+1] + 1
+			} else {
+				table[i*width+j] = max(table[(i+1)*width+j], table[i*width+j+1])
+			}
+		}
+	}
+
+	edits := make([]edit, 0, len(a)+len(b))
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			edits = append(edits, edit{' ', a[i]})
+			i++
+			j++
+		case table[(i+1)*width+j] >= table[i*width+j+1]:
+			edits = append(edits, edit{'-', a[i]})
+			i++
+		default:
+			edits = append(edits, edit{'+', b[j]})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		edits = append(edits, edit{'-', a[i]})
+	}
+	for ; j < len(b); j++ {
+		edits = append(edits, edit{'+', b[j]})
+	}
+	return edits
+}
+
+// unifiedDiff formats the hunks turning a into b and counts the added and
+// deleted lines
+func unifiedDiff(a, b []string) (string, int, int) {
+	edits := editScript(a, b)
+
+	// Line numbers in a and b before each edit
+	oldPos := make([]int, len(edits)+1)
+	newPos := make([]int, len(edits)+1)
+	var changes []int
+	additions, deletions := 0, 0
+	for i, e := range edits {
+		oldPos[i+1], newPos[i+1] = oldPos[i], newPos[i]
+		if e.op != '+' {
+			oldPos[i+1]++
+		}
+		if e.op != '-' {
+			newPos[i+1]++
+		}
+		switch e.op {
+		case '+':
+			additions++
+			changes = append(changes, i)
+		case '-':
+			deletions++
+			changes = append(changes, i)
+		}
+	}
+
+	var out strings.Builder
+	for c := 0; c < len(changes); {
+		start := max(changes[c]-diffContext, 0)
+		last := changes[c]
+		// Changes closer than twice the context share a hunk
+		for c++; c < len(changes) && changes[c]-last <= 2*diffContext; c++ {
+			last = changes[c]
+		}
+		end := min(last+diffContext+1, len(edits))
+
+		oldStart, oldCount := oldPos[start]+1, oldPos[end]-oldPos[start]
+		newStart, newCount := newPos[start]+1, newPos[end]-newPos[start]
+		if oldCount == 0 {
+			oldStart--
+		}
+		if newCount == 0 {
+			newStart--
+		}
+		fmt.Fprintf(&out, "@@ -%d,%d +%d,%d @@\n", oldStart, oldCount, newStart, newCount)
+		for _, e := range edits[start:end] {
+			out.WriteByte(e.op)
+			out.WriteString(e.line)
+			if !strings.HasSuffix(e.line, "\n") {
+				out.WriteString("\n\\ No newline at end of file\n")
+			}
+		}
+	}
+	return out.String(), additions, deletions
+}
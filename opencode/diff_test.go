@@ -0,0 +1,125 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// numberedLines returns "prefix1\n" to "prefixN\n"
+func numberedLines(prefix string, n int) []string {
+	lines := make([]string, n)
+	for i := range lines {
+		lines[i] = fmt.Sprintf("%s%d\n", prefix, i+1)
+	}
+	return lines
+}
+
+var _ = Describe("Diff", func() {
+	It("splits lines keeping a missing final newline apart", func() {
+		Expect(splitLines("")).To(BeNil())
+		Expect(splitLines("a\nb\n")).To(Equal([]string{"a\n", "b\n"}))
+		Expect(splitLines("a\nb")).To(Equal([]string{"a\n", "b"}))
+	})
+
+	It("finds the shortest edits between two files", func() {
+		a := splitLines("a\nb\nc\nd\ne\n")
+		b := splitLines("a\nc\nd\nx\ne\n")
+		Expect(editScript(a, b)).To(Equal([]edit{
+			{' ', "a\n"}, {'-', "b\n"}, {' ', "c\n"}, {' ', "d\n"}, {'+', "x\n"}, {' ', "e\n"},
+		}))
+	})
+
+	It("formats nothing for identical content", func() {
+		lines := numberedLines("line", 5)
+		diff, additions, deletions := unifiedDiff(lines, lines)
+		Expect(diff).To(BeEmpty())
+		Expect(additions).To(BeZero())
+		Expect(deletions).To(BeZero())
+	})
+
+	It("shows changes with three lines of context", func() {
+		a := numberedLines("line", 10)
+		b := append(append(append([]string{}, a[:5]...), "new\n"), a[5:]...)
+		diff, additions, deletions := unifiedDiff(a, b)
+		Expect(diff).To(Equal("@@ -3,6 +3,7 @@\n line3\n line4\n line5\n+new\n line6\n line7\n line8\n"))
+		Expect(additions).To(Equal(1))
+		Expect(deletions).To(BeZero())
+	})
+
+	It("keeps distant changes in separate hunks and merges close ones", func() {
+		a := numberedLines("line", 20)
+		b := append([]string{}, a...)
+		b[1], b[17] = "two\n", "eighteen\n"
+		diff, _, _ := unifiedDiff(a, b)
+		Expect(strings.Count(diff, "@@ -")).To(Equal(2))
+		Expect(diff).To(HavePrefix("@@ -1,5 +1,5 @@\n line1\n-line2\n+two\n"))
+		Expect(diff).To(ContainSubstring("@@ -15,6 +15,6 @@\n"))
+
+		b = append([]string{}, a...)
+		b[5], b[11] = "six\n", "twelve\n"
+		diff, additions, deletions := unifiedDiff(a, b)
+		Expect(strings.Count(diff, "@@ -")).To(Equal(1))
+		Expect(diff).To(HavePrefix("@@ -3,13 +3,13 @@\n"))
+		Expect(additions).To(Equal(2))
+		Expect(deletions).To(Equal(2))
+	})
+
+	It("uses line zero for files created or emptied", func() {
+		diff, additions, _ := unifiedDiff(nil, []string{"a\n", "b\n"})
+		Expect(diff).To(Equal("@@ -0,0 +1,2 @@\n+a\n+b\n"))
+		Expect(additions).To(Equal(2))
+
+		diff, _, deletions := unifiedDiff([]string{"a\n"}, nil)
+		Expect(diff).To(Equal("@@ -1,1 +0,0 @@\n-a\n"))
+		Expect(deletions).To(Equal(1))
+	})
+
+	It("marks a missing newline at the end of the file", func() {
+		diff, _, _ := unifiedDiff(splitLines("a\nb"), splitLines("a\nb\n"))
+		Expect(diff).To(Equal("@@ -1,2 +1,2 @@\n a\n-b\n\\ No newline at end of file\n+b\n"))
+	})
+
+	It("replaces whole blocks when they are too large to compare", func() {
+		a := numberedLines("old", 2100)
+		b := numberedLines("new", 2100)
+		Expect(len(a) * len(b)).To(BeNumerically(">", maxDiffCells))
+		edits := editScript(append([]string{"same\n"}, a...), append([]string{"same\n"}, b...))
+		Expect(edits).To(HaveLen(1 + 2*2100))
+		Expect(edits[0]).To(Equal(edit{' ', "same\n"}))
+		Expect(edits[1]).To(Equal(edit{'-', "old1\n"}))
+		Expect(edits[2101]).To(Equal(edit{'+', "new1\n"}))
+	})
+
+	It("diffs the working directory against the snapshot", func() {
+		workDir := GinkgoT().TempDir()
+		snapshotDir := filepath.Join(GinkgoT().TempDir(), "snapshot")
+		Expect(os.WriteFile(filepath.Join(workDir, "kept.txt"), []byte("same\n"), 0644)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(workDir, "changed.txt"), []byte("a\nb\n"), 0644)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(workDir, "deleted.txt"), []byte("gone\n"), 0644)).To(Succeed())
+		Expect(takeSnapshot(workDir, snapshotDir, nil, 1<<20)).To(Succeed())
+
+		Expect(os.WriteFile(filepath.Join(workDir, "changed.txt"), []byte("a\nc\n"), 0644)).To(Succeed())
+		Expect(os.Remove(filepath.Join(workDir, "deleted.txt"))).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(workDir, "added.txt"), []byte("new\n"), 0644)).To(Succeed())
+
+		diff, changes, err := diffSnapshot(snapshotDir, workDir, nil, "")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(changes).To(Equal([]FileChange{
+			{Path: "added.txt", Status: "added", Additions: 1},
+			{Path: "changed.txt", Status: "modified", Additions: 1, Deletions: 1},
+			{Path: "deleted.txt", Status: "deleted", Deletions: 1},
+		}))
+		Expect(diff).To(ContainSubstring("diff --git a/changed.txt b/changed.txt\n--- a/changed.txt\n+++ b/changed.txt\n@@ -1,2 +1,2 @@\n a\n-b\n+c\n"))
+		Expect(diff).To(ContainSubstring("--- /dev/null\n+++ b/added.txt\n"))
+		Expect(diff).NotTo(ContainSubstring("kept.txt"))
+
+		_, changes, err = diffSnapshot(snapshotDir, workDir, nil, "changed.txt")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(changes).To(HaveLen(1))
+	})
+})
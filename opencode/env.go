@@ -0,0 +1,77 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// alwaysPassedEnv are passed to opencode even when they are not in
+// OPENCODE_ENV_ALLOWLIST, as it cannot start or be configured without them
+var alwaysPassedEnv = []string{"PATH", "HOME", "OPENCODE_CONFIG", "OPENCODE_CONFIG_CONTENT"}
+
+// envName is the allowed form of environment variable names
+var envName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// parseEnvAllowlist reads OPENCODE_ENV_ALLOWLIST, a comma separated list of
+// variable names where a trailing * matches any suffix (e.g. AWS_*). It
+// returns nil when the list is empty, meaning the whole environment is passed.
+func parseEnvAllowlist(value string) ([]string, error) {
+	var allowlist []string
+	for _, entry := range strings.Split(value, ",") {
+		if entry = strings.TrimSpace(entry); entry == "" {
+			continue
+		}
+		if !envName.MatchString(strings.TrimSuffix(entry, "*")) {
+			return nil, fmt.Errorf("invalid OPENCODE_ENV_ALLOWLIST entry %q: expected a variable name, optionally ending with *", entry)
+		}
+		allowlist = append(allowlist, entry)
+	}
+	return allowlist, nil
+}
+
+// envAllowed reports whether the variable name matches the allowlist
+func envAllowed(allowlist []string, name string) bool {
+	for _, entry := range slices.Concat(allowlist, alwaysPassedEnv) {
+		if prefix, ok := strings.CutSuffix(entry, "*"); ok {
+			if strings.HasPrefix(name, prefix) {
+				return true
+			}
+		} else if name == entry {
+			return true
+		}
+	}
+	return false
+}
+
+// sessionEnv builds the environment of an opencode process: the server's
+// environment, restricted to envAllowlist when set, with the variables given
+// on start_session set on top
+func (sm *SessionManager) sessionEnv(extra map[string]string) ([]string, error) {
+	names := make([]string, 0, len(extra))
+	for name := range extra {
+		if !envName.MatchString(name) {
+			return nil, fmt.Errorf("invalid environment variable name %q", name)
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var env []string
+	for _, kv := range os.Environ() {
+		name, _, _ := strings.Cut(kv, "=")
+		if _, ok := extra[name]; ok {
+			continue
+		}
+		if sm.envAllowlist == nil || envAllowed(sm.envAllowlist, name) {
+			env = append(env, kv)
+		}
+	}
+	for _, name := range names {
+		env = append(env, name+"="+extra[name])
+	}
+	return env, nil
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Environment allowlist", func() {
+	It("parses names and prefixes, skipping blanks", func() {
+		allowlist, err := parseEnvAllowlist(" OPENAI_API_KEY, ,AWS_* ")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(allowlist).To(Equal([]string{"OPENAI_API_KEY", "AWS_*"}))
+
+		allowlist, err = parseEnvAllowlist("")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(allowlist).To(BeNil())
+	})
+
+	It("refuses entries that are not variable names", func() {
+		for _, value := range []string{"A-B", "1ABC", "*", "A*B", "FOO=bar"} {
+			_, err := parseEnvAllowlist(value)
+			Expect(err).To(MatchError(ContainSubstring("invalid OPENCODE_ENV_ALLOWLIST entry")), value)
+		}
+	})
+
+	It("matches exact names and prefixes", func() {
+		allowlist := []string{"OPENAI_API_KEY", "AWS_*"}
+		Expect(envAllowed(allowlist, "OPENAI_API_KEY")).To(BeTrue())
+		Expect(envAllowed(allowlist, "OPENAI_API_KEY_2")).To(BeFalse())
+		Expect(envAllowed(allowlist, "AWS_REGION")).To(BeTrue())
+		Expect(envAllowed(allowlist, "AWS_")).To(BeTrue())
+		Expect(envAllowed(allowlist, "AWS")).To(BeFalse())
+		Expect(envAllowed(allowlist, "GITHUB_TOKEN")).To(BeFalse())
+	})
+
+	It("always passes the variables opencode needs", func() {
+		for _, name := range alwaysPassedEnv {
+			Expect(envAllowed([]string{"AWS_*"}, name)).To(BeTrue(), name)
+		}
+	})
+
+	Describe("sessionEnv", func() {
+		BeforeEach(func() {
+			GinkgoT().Setenv("AWS_REGION", "eu-west-1")
+			GinkgoT().Setenv("GITHUB_TOKEN", "server-token")
+			GinkgoT().Setenv("HOME", "/home/opencode")
+		})
+
+		It("passes the whole environment without an allowlist", func() {
+			sm := &SessionManager{}
+			env, err := sm.sessionEnv(nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(env).To(ContainElements("AWS_REGION=eu-west-1", "GITHUB_TOKEN=server-token"))
+		})
+
+		It("restricts the inherited environment to the allowlist", func() {
+			sm := &SessionManager{envAllowlist: []string{"AWS_*"}}
+			env, err := sm.sessionEnv(nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(env).To(ContainElements("AWS_REGION=eu-west-1", "HOME=/home/opencode"))
+			Expect(env).NotTo(ContainElement(HavePrefix("GITHUB_TOKEN=")))
+		})
+
+		It("sets the variables of the session on top, unfiltered", func() {
+			sm := &SessionManager{envAllowlist: []string{"AWS_*"}}
+			env, err := sm.sessionEnv(map[string]string{
+				"GITHUB_TOKEN": "session-token",
+				"AWS_REGION":   "us-east-1",
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(env).To(ContainElements("GITHUB_TOKEN=session-token", "AWS_REGION=us-east-1"))
+			Expect(env).NotTo(ContainElement("AWS_REGION=eu-west-1"))
+			Expect(env[len(env)-2:]).To(Equal([]string{"AWS_REGION=us-east-1", "GITHUB_TOKEN=session-token"}))
+		})
+
+		It("refuses invalid variable names", func() {
+			sm := &SessionManager{}
+			_, err := sm.sessionEnv(map[string]string{"BAD-NAME": "x"})
+			Expect(err).To(MatchError(ContainSubstring(`invalid environment variable name "BAD-NAME"`)))
+		})
+	})
+})
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// FailureReason is the likely cause of a failed session, classified from
+// its stderr
+type FailureReason struct {
+	Cause      string `json:"cause" jsonschema:"auth, model_not_found, rate_limit, crash, start_failed or unknown"`
+	Summary    string `json:"summary" jsonschema:"what went wrong"`
+	Suggestion string `json:"suggestion" jsonschema:"how to fix it"`
+	Line       string `json:"line,omitempty" jsonschema:"the log line the cause was recognized from"`
+}
+
+// failureSignature recognizes a cause of failure from a log line
+type failureSignature struct {
+	cause      string
+	pattern    *regexp.Regexp
+	summary    string
+	suggestion string
+}
+
+// failureSignatures are tried in order, the most specific causes first, as a
+// crash is often the consequence of one of the others
+var failureSignatures = []failureSignature{
+	{
+		cause:      "auth",
+		pattern:    regexp.MustCompile(`(?i)(\b40[13]\b|unauthori[sz]ed|forbidden|invalid.{0,20}api.?key|api.?key.{0,30}(missing|invalid|not (set|found))|authentication|ProviderAuthError)`),
+		summary:    "the model provider rejected the credentials",
+		suggestion: "set the provider API key in the server environment (and OPENCODE_ENV_ALLOWLIST if used) or pass it in the env of the session",
+	},
+	{
+		cause:      "model_not_found",
+		pattern:    regexp.MustCompile(`(?i)(ModelNotFound|model.{0,40}(not found|does not exist|not exist|unknown|not supported)|(unknown|invalid) model|no such model)`),
+		summary:    "the requested model is not available",
+		suggestion: "check the model name and its provider prefix (provider/model) against the models configured for opencode",
+	},
+	{
+		cause:      "rate_limit",
+		pattern:    regexp.MustCompile(`(?i)(\b429\b|rate.?limit|too many requests|quota|overloaded)`),
+		summary:    "the model provider rate limited the session or its quota is exhausted",
+		suggestion: "wait before starting the session again, or use another model or API key",
+	},
+	{
+		cause:      "crash",
+		pattern:    regexp.MustCompile(`(?i)(panic:|segmentation fault|SIGSEGV|SIGKILL|\bkilled\b|out of memory|heap out of memory|fatal error|uncaught exception|unhandled.{0,20}rejection)`),
+		summary:    "opencode crashed",
+		suggestion: "check the stack trace in the session stderr; when out of memory, give the server more memory or run a smaller task",
+	},
+}
+
+// diagnoseFailure classifies why a session exited with exitCode from its
+// stderr. Causes are recognized from the last matching line, as the error
+// that stopped opencode is usually printed last.
+func diagnoseFailure(stderr, exitCode string) *FailureReason {
+	lines := strings.Split(strings.TrimRight(stderr, "\n"), "\n")
+	for _, signature := range failureSignatures {
+		for i := len(lines) - 1; i >= 0; i-- {
+			if signature.pattern.MatchString(lines[i]) {
+				return &FailureReason{
+					Cause:      signature.cause,
+					Summary:    signature.summary,
+					Suggestion: signature.suggestion,
+					Line:       strings.TrimSpace(lines[i]),
+				}
+			}
+		}
+	}
+
+	reason := &FailureReason{
+		Cause:      "unknown",
+		Summary:    fmt.Sprintf("opencode exited with code %s", exitCode),
+		Suggestion: "read or search the session logs for the error",
+	}
+	for i := len(lines) - 1; i >= 0; i-- {
+		if line := strings.TrimSpace(lines[i]); line != "" {
+			reason.Line = line
+			break
+		}
+	}
+	return reason
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mudler/mcps/pkg/safepath"
+)
+
+// resolveFiles checks that the files attached to a session can be read by
+// opencode and returns their absolute paths. Relative paths are resolved
+// against the working directory, where opencode runs. Every bad path is
+// reported in the error, not only the first one.
+func (sm *SessionManager) resolveFiles(files []string) ([]string, error) {
+	resolved := make([]string, 0, len(files))
+	var problems []string
+	for _, file := range files {
+		path, err := sm.resolveFile(file)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%q: %v", file, err))
+			continue
+		}
+		resolved = append(resolved, path)
+	}
+	if len(problems) > 0 {
+		return nil, fmt.Errorf("invalid files: %s", strings.Join(problems, "; "))
+	}
+	return resolved, nil
+}
+
+// resolveFile validates a single attached file
+func (sm *SessionManager) resolveFile(file string) (string, error) {
+	// Empty paths are left for the guard to reject
+	path := file
+	if !filepath.IsAbs(path) && strings.TrimSpace(path) != "" {
+		path = filepath.Join(sm.workDir, path)
+	}
+	path, err := sm.filesGuard.Resolve(path)
+	if errors.Is(err, safepath.ErrOutsideRoot) {
+		return "", fmt.Errorf("outside OPENCODE_FILES_ROOT (%s)", sm.filesGuard.Root)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	info, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		return "", fmt.Errorf("does not exist")
+	}
+	if err != nil {
+		return "", err
+	}
+	if info.IsDir() {
+		return "", fmt.Errorf("is a directory")
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("not readable")
+	}
+	f.Close()
+
+	return path, nil
+}
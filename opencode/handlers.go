@@ -3,6 +3,9 @@ package main
 import (
 	"context"
 	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
 	"time"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
@@ -10,12 +13,13 @@ import (
 
 // StartSessionInput represents the input for starting a session
 type StartSessionInput struct {
-	Message   string   `json:"message" jsonschema:"the message to send to opencode"`
-	Files     []string `json:"files,omitempty" jsonschema:"file(s) to attach to message"`
-	Title     string   `json:"title,omitempty" jsonschema:"title for the session"`
-	Continue  bool     `json:"continue,omitempty" jsonschema:"continue the last session"`
-	SessionID string   `json:"session_id,omitempty" jsonschema:"session id to continue"`
-	Thinking  bool     `json:"thinking,omitempty" jsonschema:"show thinking blocks"`
+	Message   string            `json:"message" jsonschema:"the message to send to opencode"`
+	Files     []string          `json:"files,omitempty" jsonschema:"file(s) to attach to message"`
+	Title     string            `json:"title,omitempty" jsonschema:"title for the session"`
+	Continue  bool              `json:"continue,omitempty" jsonschema:"continue the last session"`
+	SessionID string            `json:"session_id,omitempty" jsonschema:"session id to continue"`
+	Thinking  bool              `json:"thinking,omitempty" jsonschema:"show thinking blocks"`
+	Env       map[string]string `json:"env,omitempty" jsonschema:"environment variables to set for the opencode process"`
 }
 
 // StartSessionOutput represents the output from starting a session
@@ -32,15 +36,23 @@ func StartSessionHandler(ctx context.Context, req *mcp.CallToolRequest, input St
 		return nil, StartSessionOutput{}, fmt.Errorf("session manager not initialized")
 	}
 
+	// Keep agents starting sessions in a loop from thrashing the host
+	if err := globalSessionManager.createLimiter.Wait(ctx, maxSessionRateWait); err != nil {
+		return nil, StartSessionOutput{}, err
+	}
+
 	session, err := globalSessionManager.CreateSession(
 		input.Message,
 		input.Title,
 		input.SessionID,
 		input.Files,
+		input.Env,
 		input.Continue,
 		input.Thinking,
 	)
 	if err != nil {
+		// Refused sessions do not count against the limit
+		globalSessionManager.createLimiter.cancel()
 		return nil, StartSessionOutput{}, err
 	}
 
@@ -61,14 +73,18 @@ type GetSessionStatusInput struct {
 
 // GetSessionStatusOutput represents the output from getting session status
 type GetSessionStatusOutput struct {
-	SessionID string    `json:"session_id" jsonschema:"the session ID"`
-	Status    string    `json:"status" jsonschema:"the session status: running, completed, failed, stopped, or not_found"`
-	PID       string    `json:"pid,omitempty" jsonschema:"the process ID"`
-	ExitCode  string    `json:"exit_code,omitempty" jsonschema:"the exit code if completed"`
-	CreatedAt time.Time `json:"created_at" jsonschema:"when the session was created"`
-	StartedAt time.Time `json:"started_at,omitempty" jsonschema:"when the session started"`
-	StoppedAt time.Time `json:"stopped_at,omitempty" jsonschema:"when the session stopped"`
-	Duration  string    `json:"duration,omitempty" jsonschema:"the session duration"`
+	SessionID  string    `json:"session_id" jsonschema:"the session ID"`
+	Status     string    `json:"status" jsonschema:"the session status: running, completed, failed, stopped, or not_found"`
+	PID        string    `json:"pid,omitempty" jsonschema:"the process ID"`
+	ExitCode   string    `json:"exit_code,omitempty" jsonschema:"the exit code if completed"`
+	CreatedAt  time.Time `json:"created_at" jsonschema:"when the session was created"`
+	StartedAt  time.Time `json:"started_at,omitempty" jsonschema:"when the session started"`
+	StoppedAt  time.Time `json:"stopped_at,omitempty" jsonschema:"when the session stopped"`
+	Duration   string    `json:"duration,omitempty" jsonschema:"the session duration"`
+	StdoutPath string    `json:"stdout_path,omitempty" jsonschema:"absolute path of the file the session stdout is written to, for tailing with external tools"`
+	StderrPath string    `json:"stderr_path,omitempty" jsonschema:"absolute path of the file the session stderr is written to, for tailing with external tools"`
+
+	FailureReason *FailureReason `json:"failure_reason,omitempty" jsonschema:"the likely cause of a failed session and how to fix it, the raw logs staying available"`
 }
 
 // GetSessionStatusHandler handles getting the status of a session
@@ -86,13 +102,17 @@ func GetSessionStatusHandler(ctx context.Context, req *mcp.CallToolRequest, inpu
 	}
 
 	output := GetSessionStatusOutput{
-		SessionID: session.ID,
-		Status:    session.Status,
-		PID:       session.PID,
-		ExitCode:  session.ExitCode,
-		CreatedAt: session.CreatedAt,
-		StartedAt: session.StartedAt,
-		StoppedAt: session.StoppedAt,
+		SessionID:  session.ID,
+		Status:     session.Status,
+		PID:        session.PID,
+		ExitCode:   session.ExitCode,
+		CreatedAt:  session.CreatedAt,
+		StartedAt:  session.StartedAt,
+		StoppedAt:  session.StoppedAt,
+		StdoutPath: session.StdoutPath,
+		StderrPath: session.StderrPath,
+
+		FailureReason: session.Failure,
 	}
 
 	// Calculate duration
@@ -150,6 +170,111 @@ func GetSessionLogsHandler(ctx context.Context, req *mcp.CallToolRequest, input
 	return nil, output, nil
 }
 
+// SearchSessionLogsInput represents the input for searching session logs
+type SearchSessionLogsInput struct {
+	SessionID  string `json:"session_id" jsonschema:"the session ID"`
+	Pattern    string `json:"pattern" jsonschema:"regular expression (Go RE2 syntax) matched against each log line, e.g. '(?i)error|panic'"`
+	Stream     string `json:"stream,omitempty" jsonschema:"stdout, stderr or both (default: both)"`
+	MaxMatches int    `json:"max_matches,omitempty" jsonschema:"maximum number of matching lines to return (default: 100)"`
+}
+
+// SearchSessionLogsOutput represents the lines matching a search
+type SearchSessionLogsOutput struct {
+	SessionID    string     `json:"session_id" jsonschema:"the session ID"`
+	Matches      []LogMatch `json:"matches" jsonschema:"matching lines with their stream and line number, stdout first"`
+	Count        int        `json:"count" jsonschema:"number of matches returned"`
+	TotalMatches int        `json:"total_matches" jsonschema:"number of matching lines in the logs"`
+	Truncated    bool       `json:"truncated,omitempty" jsonschema:"whether more lines matched than max_matches"`
+}
+
+// SearchSessionLogsHandler handles searching the logs of a session
+func SearchSessionLogsHandler(ctx context.Context, req *mcp.CallToolRequest, input SearchSessionLogsInput) (*mcp.CallToolResult, SearchSessionLogsOutput, error) {
+	if globalSessionManager == nil {
+		return nil, SearchSessionLogsOutput{}, fmt.Errorf("session manager not initialized")
+	}
+
+	if input.Pattern == "" {
+		return nil, SearchSessionLogsOutput{}, fmt.Errorf("pattern is required")
+	}
+	pattern, err := regexp.Compile(input.Pattern)
+	if err != nil {
+		return nil, SearchSessionLogsOutput{}, fmt.Errorf("invalid pattern: %w", err)
+	}
+
+	stream := input.Stream
+	switch stream {
+	case "", "both":
+		stream = ""
+	case "stdout", "stderr":
+	default:
+		return nil, SearchSessionLogsOutput{}, fmt.Errorf("invalid stream %q: must be stdout, stderr or both", input.Stream)
+	}
+
+	maxMatches := input.MaxMatches
+	if maxMatches <= 0 {
+		maxMatches = 100
+	}
+
+	matches, total, err := globalSessionManager.SearchSessionLogs(input.SessionID, pattern, stream, maxMatches)
+	if err != nil {
+		return nil, SearchSessionLogsOutput{}, err
+	}
+
+	output := SearchSessionLogsOutput{
+		SessionID:    input.SessionID,
+		Matches:      matches,
+		Count:        len(matches),
+		TotalMatches: total,
+		Truncated:    total > len(matches),
+	}
+
+	return nil, output, nil
+}
+
+// GetSessionDiffInput represents the input for getting a session diff
+type GetSessionDiffInput struct {
+	SessionID string `json:"session_id" jsonschema:"the session ID"`
+	Path      string `json:"path,omitempty" jsonschema:"only diff this file or directory, relative to the working directory (default: everything)"`
+}
+
+// GetSessionDiffOutput represents the changes made by a session
+type GetSessionDiffOutput struct {
+	SessionID string       `json:"session_id" jsonschema:"the session ID"`
+	Diff      string       `json:"diff" jsonschema:"unified diff of the working directory against its state when the session started"`
+	Files     []FileChange `json:"files" jsonschema:"the changed files"`
+	Count     int          `json:"count" jsonschema:"number of changed files"`
+}
+
+// GetSessionDiffHandler handles getting the changes made by a session
+func GetSessionDiffHandler(ctx context.Context, req *mcp.CallToolRequest, input GetSessionDiffInput) (*mcp.CallToolResult, GetSessionDiffOutput, error) {
+	if globalSessionManager == nil {
+		return nil, GetSessionDiffOutput{}, fmt.Errorf("session manager not initialized")
+	}
+
+	path := input.Path
+	if filepath.IsAbs(path) {
+		rel, err := filepath.Rel(globalSessionManager.workDir, path)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return nil, GetSessionDiffOutput{}, fmt.Errorf("path %s is outside the working directory", input.Path)
+		}
+		path = rel
+	}
+
+	diff, files, err := globalSessionManager.GetSessionDiff(input.SessionID, path)
+	if err != nil {
+		return nil, GetSessionDiffOutput{}, err
+	}
+
+	output := GetSessionDiffOutput{
+		SessionID: input.SessionID,
+		Diff:      diff,
+		Files:     files,
+		Count:     len(files),
+	}
+
+	return nil, output, nil
+}
+
 // StopSessionInput represents the input for stopping a session
 type StopSessionInput struct {
 	SessionID string `json:"session_id" jsonschema:"the session ID to stop"`
@@ -183,9 +308,56 @@ func StopSessionHandler(ctx context.Context, req *mcp.CallToolRequest, input Sto
 	return nil, output, nil
 }
 
+// DeleteSessionInput represents the input for deleting a session
+type DeleteSessionInput struct {
+	SessionID string `json:"session_id" jsonschema:"the session ID to delete"`
+	Force     bool   `json:"force,omitempty" jsonschema:"stop the session first if it is still running"`
+}
+
+// DeleteSessionOutput represents the output from deleting a session
+type DeleteSessionOutput struct {
+	SessionID string `json:"session_id" jsonschema:"the session ID"`
+	Deleted   bool   `json:"deleted" jsonschema:"whether the session was deleted"`
+	Stopped   bool   `json:"stopped" jsonschema:"whether the session was running and had to be stopped first"`
+	Message   string `json:"message" jsonschema:"status message"`
+}
+
+// DeleteSessionHandler handles deleting a session and its logs
+func DeleteSessionHandler(ctx context.Context, req *mcp.CallToolRequest, input DeleteSessionInput) (*mcp.CallToolResult, DeleteSessionOutput, error) {
+	if globalSessionManager == nil {
+		return nil, DeleteSessionOutput{}, fmt.Errorf("session manager not initialized")
+	}
+
+	stopped, err := globalSessionManager.DeleteSession(input.SessionID, input.Force)
+	if err != nil {
+		return nil, DeleteSessionOutput{}, err
+	}
+
+	message := "Session deleted successfully"
+	if stopped {
+		message = "Session stopped and deleted successfully"
+	}
+
+	output := DeleteSessionOutput{
+		SessionID: input.SessionID,
+		Deleted:   true,
+		Stopped:   stopped,
+		Message:   message,
+	}
+
+	return nil, output, nil
+}
+
 // ListSessionsInput represents the input for listing sessions
 type ListSessionsInput struct {
-	StatusFilter string `json:"status_filter,omitempty" jsonschema:"filter by status: running, completed, failed, stopped, or all"`
+	StatusFilter  string `json:"status_filter,omitempty" jsonschema:"filter by status: running, completed, failed, stopped, or all"`
+	Model         string `json:"model,omitempty" jsonschema:"only return sessions using this model"`
+	Title         string `json:"title,omitempty" jsonschema:"only return sessions whose title contains this text (case-insensitive)"`
+	CreatedAfter  string `json:"created_after,omitempty" jsonschema:"only return sessions created at or after this RFC3339 timestamp"`
+	CreatedBefore string `json:"created_before,omitempty" jsonschema:"only return sessions created at or before this RFC3339 timestamp"`
+	Sort          string `json:"sort,omitempty" jsonschema:"sort order by creation time: asc or desc (default: desc)"`
+	Limit         int    `json:"limit,omitempty" jsonschema:"maximum number of sessions to return (default: all)"`
+	Offset        int    `json:"offset,omitempty" jsonschema:"number of sessions to skip"`
 }
 
 // SessionInfo represents a session in the list
@@ -194,6 +366,7 @@ type SessionInfo struct {
 	Status         string    `json:"status" jsonschema:"the session status"`
 	PID            string    `json:"pid,omitempty" jsonschema:"the process ID"`
 	MessagePreview string    `json:"message_preview" jsonschema:"preview of the message"`
+	Title          string    `json:"title,omitempty" jsonschema:"the session title"`
 	CreatedAt      time.Time `json:"created_at" jsonschema:"when the session was created"`
 	Model          string    `json:"model,omitempty" jsonschema:"the model used"`
 }
@@ -202,6 +375,7 @@ type SessionInfo struct {
 type ListSessionsOutput struct {
 	Sessions []SessionInfo `json:"sessions" jsonschema:"list of sessions"`
 	Count    int           `json:"count" jsonschema:"number of sessions"`
+	Total    int           `json:"total" jsonschema:"number of sessions matching the filters before pagination"`
 }
 
 // ListSessionsHandler handles listing all sessions
@@ -210,7 +384,42 @@ func ListSessionsHandler(ctx context.Context, req *mcp.CallToolRequest, input Li
 		return nil, ListSessionsOutput{}, fmt.Errorf("session manager not initialized")
 	}
 
-	sessions := globalSessionManager.ListSessions(input.StatusFilter)
+	filter := SessionFilter{
+		Status: input.StatusFilter,
+		Model:  input.Model,
+		Title:  input.Title,
+		Limit:  input.Limit,
+		Offset: input.Offset,
+	}
+
+	switch input.Sort {
+	case "", "desc":
+	case "asc":
+		filter.Ascending = true
+	default:
+		return nil, ListSessionsOutput{}, fmt.Errorf("invalid sort order: %s (expected asc or desc)", input.Sort)
+	}
+
+	if input.Limit < 0 || input.Offset < 0 {
+		return nil, ListSessionsOutput{}, fmt.Errorf("limit and offset must not be negative")
+	}
+
+	if input.CreatedAfter != "" {
+		t, err := time.Parse(time.RFC3339, input.CreatedAfter)
+		if err != nil {
+			return nil, ListSessionsOutput{}, fmt.Errorf("invalid created_after: %w", err)
+		}
+		filter.CreatedAfter = t
+	}
+	if input.CreatedBefore != "" {
+		t, err := time.Parse(time.RFC3339, input.CreatedBefore)
+		if err != nil {
+			return nil, ListSessionsOutput{}, fmt.Errorf("invalid created_before: %w", err)
+		}
+		filter.CreatedBefore = t
+	}
+
+	sessions, total := globalSessionManager.ListSessions(filter)
 
 	sessionInfos := []SessionInfo{}
 	for _, session := range sessions {
@@ -225,6 +434,7 @@ func ListSessionsHandler(ctx context.Context, req *mcp.CallToolRequest, input Li
 			Status:         session.Status,
 			PID:            session.PID,
 			MessagePreview: preview,
+			Title:          session.Title,
 			CreatedAt:      session.CreatedAt,
 			Model:          session.Model,
 		})
@@ -233,6 +443,7 @@ func ListSessionsHandler(ctx context.Context, req *mcp.CallToolRequest, input Li
 	output := ListSessionsOutput{
 		Sessions: sessionInfos,
 		Count:    len(sessionInfos),
+		Total:    total,
 	}
 
 	return nil, output, nil
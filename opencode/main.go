@@ -2,29 +2,49 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"log"
 	"os"
+	"path/filepath"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 	processmanager "github.com/mudler/go-processmanager"
+	"github.com/mudler/mcps/pkg/safepath"
+	"github.com/mudler/mcps/pkg/selfcheck"
+	"github.com/mudler/mcps/pkg/transport"
 )
 
 // Session represents an active opencode session
 type Session struct {
-	ID        string                  `json:"id"`
-	Status    string                  `json:"status"`
-	PID       string                  `json:"pid"`
-	Message   string                  `json:"message"`
-	Model     string                  `json:"model"`
-	CreatedAt time.Time               `json:"created_at"`
-	StartedAt time.Time               `json:"started_at,omitempty"`
-	StoppedAt time.Time               `json:"stopped_at,omitempty"`
-	ExitCode  string                  `json:"exit_code"`
-	Process   *processmanager.Process `json:"-"`
-	StateDir  string                  `json:"state_dir"`
+	ID         string                  `json:"id"`
+	Status     string                  `json:"status"`
+	PID        string                  `json:"pid"`
+	Message    string                  `json:"message"`
+	Title      string                  `json:"title,omitempty"`
+	Model      string                  `json:"model"`
+	CreatedAt  time.Time               `json:"created_at"`
+	StartedAt  time.Time               `json:"started_at,omitempty"`
+	StoppedAt  time.Time               `json:"stopped_at,omitempty"`
+	ExitCode   string                  `json:"exit_code"`
+	Process    *processmanager.Process `json:"-"`
+	StateDir   string                  `json:"state_dir"`
+	StdoutPath string                  `json:"stdout_path"`
+	StderrPath string                  `json:"stderr_path"`
+	Failure    *FailureReason          `json:"failure_reason,omitempty"`
+
+	// SnapshotDir holds the copy of the working directory taken when the
+	// session started, SnapshotError why none could be taken
+	SnapshotDir   string `json:"-"`
+	SnapshotError string `json:"-"`
+
+	// cancel is closed to make runSession stop watching the process, done
+	// is closed once runSession has returned
+	cancel chan struct{}
+	done   chan struct{}
 }
 
 // SessionManager manages all opencode sessions
@@ -33,6 +53,14 @@ type SessionManager struct {
 	mutex               sync.RWMutex
 	sessionDir, workDir string
 	maxSessions         int
+	snapshotMaxBytes    int64
+	// filesGuard validates attached files, confining them to
+	// OPENCODE_FILES_ROOT when set
+	filesGuard safepath.Guard
+	// envAllowlist, when set, restricts the environment passed to opencode
+	envAllowlist []string
+	// createLimiter limits how often sessions are started, nil for no limit
+	createLimiter *rateLimiter
 }
 
 // Global session manager
@@ -61,17 +89,58 @@ func main() {
 	sessionDir := getEnv("OPENCODE_SESSION_DIR", "/tmp/opencode-sessions")
 	maxSessions := getEnvInt("OPENCODE_MAX_SESSIONS", 10)
 	workDir := getEnv("OPENCODE_WORK_DIR", "/root")
+	snapshotMaxBytes := getEnvInt("OPENCODE_SNAPSHOT_MAX_BYTES", defaultSnapshotMaxBytes)
+	sessionRate := getEnvInt("OPENCODE_SESSION_RATE", defaultSessionRate)
+	sessionBurst := getEnvInt("OPENCODE_SESSION_BURST", defaultSessionBurst)
+	filesGuard, filesRootErr := safepath.New(os.Getenv("OPENCODE_FILES_ROOT"))
+	if filesRootErr != nil {
+		filesRootErr = fmt.Errorf("invalid OPENCODE_FILES_ROOT: %w", filesRootErr)
+	}
+	envAllowlist, envAllowlistErr := parseEnvAllowlist(os.Getenv("OPENCODE_ENV_ALLOWLIST"))
+
+	if selfcheck.Enabled() {
+		checks := []selfcheck.Check{
+			selfcheck.Command(getEnv("OPENCODE_BINARY", "opencode")),
+			selfcheck.Dir("OPENCODE_SESSION_DIR", sessionDir),
+			selfcheck.Dir("OPENCODE_WORK_DIR", workDir),
+		}
+		if filesGuard.Root != "" || filesRootErr != nil {
+			checks = append(checks, selfcheck.Value("OPENCODE_FILES_ROOT", filesGuard.Root, filesRootErr))
+		}
+		if envAllowlist != nil || envAllowlistErr != nil {
+			checks = append(checks, selfcheck.Value("OPENCODE_ENV_ALLOWLIST", strings.Join(envAllowlist, ","), envAllowlistErr))
+		}
+		selfcheck.Exit("opencode", checks...)
+	}
+	if filesRootErr != nil {
+		log.Fatal(filesRootErr)
+	}
+	if envAllowlistErr != nil {
+		log.Fatal(envAllowlistErr)
+	}
 
 	// Ensure session directory exists
 	if err := os.MkdirAll(sessionDir, 0755); err != nil {
 		log.Fatalf("Failed to create session directory: %v", err)
 	}
 
+	// Snapshots are compared by path, so both directories are made absolute
+	if abs, err := filepath.Abs(sessionDir); err == nil {
+		sessionDir = abs
+	}
+	if abs, err := filepath.Abs(workDir); err == nil {
+		workDir = abs
+	}
+
 	globalSessionManager = &SessionManager{
-		sessions:    make(map[string]*Session),
-		sessionDir:  sessionDir,
-		workDir:     workDir,
-		maxSessions: maxSessions,
+		sessions:         make(map[string]*Session),
+		sessionDir:       sessionDir,
+		workDir:          workDir,
+		maxSessions:      maxSessions,
+		snapshotMaxBytes: int64(snapshotMaxBytes),
+		filesGuard:       filesGuard,
+		envAllowlist:     envAllowlist,
+		createLimiter:    newRateLimiter(sessionRate, sessionBurst),
 	}
 
 	// Create MCP server
@@ -95,25 +164,40 @@ func main() {
 		getSessionLogsName = "get_session_logs"
 	}
 
+	searchSessionLogsName := os.Getenv("OPENCODE_TOOL_SEARCH_SESSION_LOGS_NAME")
+	if searchSessionLogsName == "" {
+		searchSessionLogsName = "search_session_logs"
+	}
+
 	stopSessionName := os.Getenv("OPENCODE_TOOL_STOP_SESSION_NAME")
 	if stopSessionName == "" {
 		stopSessionName = "stop_session"
 	}
 
+	deleteSessionName := os.Getenv("OPENCODE_TOOL_DELETE_SESSION_NAME")
+	if deleteSessionName == "" {
+		deleteSessionName = "delete_session"
+	}
+
 	listSessionsName := os.Getenv("OPENCODE_TOOL_LIST_SESSIONS_NAME")
 	if listSessionsName == "" {
 		listSessionsName = "list_sessions"
 	}
 
+	getSessionDiffName := os.Getenv("OPENCODE_TOOL_GET_SESSION_DIFF_NAME")
+	if getSessionDiffName == "" {
+		getSessionDiffName = "get_session_diff"
+	}
+
 	// Register tools
 	mcp.AddTool(server, &mcp.Tool{
 		Name:        startSessionName,
-		Description: "Start a new opencode session with a message. Returns a session ID that can be used to check status and retrieve logs.",
+		Description: "Start a new opencode session with a message. Returns a session ID that can be used to check status and retrieve logs. Optionally set environment variables for the opencode process with env.",
 	}, StartSessionHandler)
 
 	mcp.AddTool(server, &mcp.Tool{
 		Name:        getSessionStatusName,
-		Description: "Get the status of an opencode session by ID. Returns running, completed, failed, or not_found.",
+		Description: "Get the status of an opencode session by ID. Returns running, completed, failed, or not_found. Failed sessions include a failure_reason with the likely cause, recognized from stderr, and a suggested fix.",
 	}, GetSessionStatusHandler)
 
 	mcp.AddTool(server, &mcp.Tool{
@@ -121,18 +205,33 @@ func main() {
 		Description: "Get the stdout and stderr logs from an opencode session. Optionally specify the number of lines to retrieve (default 100).",
 	}, GetSessionLogsHandler)
 
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        searchSessionLogsName,
+		Description: "Search the stdout and stderr of an opencode session with a regular expression. Returns only the matching lines with their line numbers, useful to find errors or markers in long runs without fetching all output.",
+	}, SearchSessionLogsHandler)
+
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        getSessionDiffName,
+		Description: "Get the unified diff of the changes an opencode session made to its working directory, compared to the snapshot taken when the session started. The working directory is shared, so changes made meanwhile by other sessions show up too. Also lists the changed files with added/deleted line counts. Optionally restrict the diff to a path.",
+	}, GetSessionDiffHandler)
+
 	mcp.AddTool(server, &mcp.Tool{
 		Name:        stopSessionName,
 		Description: "Stop a running opencode session by ID. Optionally force kill the process.",
 	}, StopSessionHandler)
 
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        deleteSessionName,
+		Description: "Delete an opencode session by ID immediately, removing its state directory and logs. Running sessions are refused unless force is set, in which case they are stopped first.",
+	}, DeleteSessionHandler)
+
 	mcp.AddTool(server, &mcp.Tool{
 		Name:        listSessionsName,
-		Description: "List all opencode sessions. Optionally filter by status: running, completed, failed, or all.",
+		Description: "List opencode sessions ordered by creation time. Optionally filter by status, model, title substring or creation time window, and paginate with limit/offset.",
 	}, ListSessionsHandler)
 
 	// Run server
-	if err := server.Run(context.Background(), &mcp.StdioTransport{}); err != nil {
+	if err := transport.Run(context.Background(), server); err != nil {
 		log.Fatal(err)
 	}
 
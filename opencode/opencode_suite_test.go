@@ -0,0 +1,43 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestOpencode(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Opencode Suite")
+}
+
+// newTestManager returns a session manager running script, a shell script
+// standing in for opencode, in temporary directories
+func newTestManager(script string) *SessionManager {
+	dir := GinkgoT().TempDir()
+	binary := filepath.Join(dir, "opencode")
+	Expect(os.WriteFile(binary, []byte("#!/bin/sh\n"+script+"\n"), 0755)).To(Succeed())
+	GinkgoT().Setenv("OPENCODE_BINARY", binary)
+
+	workDir := filepath.Join(dir, "work")
+	Expect(os.Mkdir(workDir, 0755)).To(Succeed())
+	return &SessionManager{
+		sessions:    make(map[string]*Session),
+		sessionDir:  filepath.Join(dir, "sessions"),
+		workDir:     workDir,
+		maxSessions: 10,
+	}
+}
+
+// sessionStatus reads the status of a session under the manager's lock
+func sessionStatus(sm *SessionManager, id string) string {
+	sm.mutex.RLock()
+	defer sm.mutex.RUnlock()
+	if session, ok := sm.sessions[id]; ok {
+		return session.Status
+	}
+	return "not_found"
+}
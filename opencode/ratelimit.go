@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultSessionRate is how many sessions may be started per minute
+	defaultSessionRate = 10
+	// defaultSessionBurst is how many sessions may be started at once
+	defaultSessionBurst = 5
+	// maxSessionRateWait is how long start_session waits for the rate limit
+	// before refusing
+	maxSessionRateWait = 2 * time.Second
+)
+
+// rateLimiter is a token bucket limiting how often sessions are created: it
+// holds up to burst tokens, refilled at perMinute tokens per minute, and
+// each creation takes one
+type rateLimiter struct {
+	mutex     sync.Mutex
+	perMinute int
+	burst     float64
+	tokens    float64
+	last      time.Time
+	now       func() time.Time
+}
+
+// newRateLimiter returns a limiter starting full, nil when perMinute is not
+// positive, meaning no limit
+func newRateLimiter(perMinute, burst int) *rateLimiter {
+	if perMinute <= 0 {
+		return nil
+	}
+	if burst < 1 {
+		burst = 1
+	}
+	return &rateLimiter{
+		perMinute: perMinute,
+		burst:     float64(burst),
+		tokens:    float64(burst),
+		last:      time.Now(),
+		now:       time.Now,
+	}
+}
+
+// rateLimitedError is returned when sessions are created faster than allowed
+type rateLimitedError struct {
+	perMinute  int
+	retryAfter time.Duration
+}
+
+func (e *rateLimitedError) Error() string {
+	return fmt.Sprintf("too many sessions started (limit %d per minute), slow down and retry in %s",
+		e.perMinute, e.retryAfter.Round(time.Second))
+}
+
+// reserve takes a token, reporting how long to wait until it is available.
+// It fails without taking one when that is longer than maxWait.
+func (l *rateLimiter) reserve(maxWait time.Duration) (time.Duration, error) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	now := l.now()
+	perSecond := float64(l.perMinute) / 60
+	l.tokens = min(l.burst, l.tokens+now.Sub(l.last).Seconds()*perSecond)
+	l.last = now
+
+	wait := time.Duration(0)
+	if l.tokens < 1 {
+		wait = time.Duration((1 - l.tokens) / perSecond * float64(time.Second))
+	}
+	if wait > maxWait {
+		return 0, &rateLimitedError{perMinute: l.perMinute, retryAfter: wait}
+	}
+	// Tokens go negative while reserved, so later callers queue behind
+	l.tokens--
+	return wait, nil
+}
+
+// cancel returns a token taken by reserve or Wait, for a session that was
+// not created after all
+func (l *rateLimiter) cancel() {
+	if l == nil {
+		return
+	}
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.tokens = min(l.burst, l.tokens+1)
+}
+
+// Wait blocks until a session may be created, briefly: when the limit would
+// keep the caller waiting longer than maxWait, it fails with a
+// rateLimitedError instead. A nil limiter never waits.
+func (l *rateLimiter) Wait(ctx context.Context, maxWait time.Duration) error {
+	if l == nil {
+		return nil
+	}
+	wait, err := l.reserve(maxWait)
+	if err != nil || wait == 0 {
+		return err
+	}
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		l.cancel()
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("rateLimiter", func() {
+	var (
+		limiter *rateLimiter
+		clock   time.Time
+	)
+
+	BeforeEach(func() {
+		clock = time.Date(2025, 1, 15, 10, 30, 0, 0, time.UTC)
+		limiter = newRateLimiter(6, 2)
+		limiter.now = func() time.Time { return clock }
+		limiter.last = clock
+	})
+
+	It("is disabled when the rate is not positive", func() {
+		Expect(newRateLimiter(0, 5)).To(BeNil())
+		var disabled *rateLimiter
+		Expect(disabled.Wait(context.Background(), 0)).To(Succeed())
+		disabled.cancel()
+	})
+
+	It("lets a burst through, then makes callers wait", func() {
+		for i := 0; i < 2; i++ {
+			wait, err := limiter.reserve(0)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(wait).To(BeZero())
+		}
+		// 6 per minute refills a token every 10 seconds
+		wait, err := limiter.reserve(time.Minute)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(wait).To(Equal(10 * time.Second))
+		// The next caller queues behind the reserved token
+		wait, err = limiter.reserve(time.Minute)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(wait).To(Equal(20 * time.Second))
+	})
+
+	It("refills tokens over time, up to the burst", func() {
+		limiter.reserve(0)
+		limiter.reserve(0)
+
+		clock = clock.Add(10 * time.Second)
+		wait, err := limiter.reserve(0)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(wait).To(BeZero())
+
+		clock = clock.Add(time.Hour)
+		for i := 0; i < 2; i++ {
+			_, err := limiter.reserve(0)
+			Expect(err).NotTo(HaveOccurred())
+		}
+		_, err = limiter.reserve(0)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("refuses without taking a token when the wait is longer than allowed", func() {
+		limiter.reserve(0)
+		limiter.reserve(0)
+
+		_, err := limiter.reserve(5 * time.Second)
+		var limited *rateLimitedError
+		Expect(errors.As(err, &limited)).To(BeTrue())
+		Expect(limited.retryAfter).To(Equal(10 * time.Second))
+		Expect(err.Error()).To(Equal("too many sessions started (limit 6 per minute), slow down and retry in 10s"))
+
+		clock = clock.Add(10 * time.Second)
+		wait, err := limiter.reserve(0)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(wait).To(BeZero())
+	})
+
+	It("gives back cancelled tokens", func() {
+		limiter.reserve(0)
+		limiter.reserve(0)
+		limiter.cancel()
+
+		wait, err := limiter.reserve(0)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(wait).To(BeZero())
+
+		// Never beyond the burst
+		limiter.cancel()
+		limiter.cancel()
+		limiter.cancel()
+		Expect(limiter.tokens).To(Equal(2.0))
+	})
+
+	It("gives the token back when the caller gives up waiting", func() {
+		limiter.reserve(0)
+		limiter.reserve(0)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		Expect(limiter.Wait(ctx, time.Minute)).To(MatchError(context.Canceled))
+		Expect(limiter.tokens).To(BeNumerically("~", 0, 1e-9))
+	})
+
+	It("does not count sessions that failed to be created", func() {
+		dir := GinkgoT().TempDir()
+		prev := globalSessionManager
+		DeferCleanup(func() { globalSessionManager = prev })
+		globalSessionManager = &SessionManager{
+			sessions:      map[string]*Session{},
+			sessionDir:    dir,
+			workDir:       dir,
+			createLimiter: limiter,
+		}
+
+		// No session can be created with maxSessions at zero
+		for i := 0; i < 5; i++ {
+			_, _, err := StartSessionHandler(context.Background(), nil, StartSessionInput{Message: "hello"})
+			Expect(err).To(MatchError(ContainSubstring("maximum number of sessions")))
+		}
+		Expect(limiter.tokens).To(Equal(2.0))
+	})
+})
@@ -4,18 +4,21 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	processmanager "github.com/mudler/go-processmanager"
+	"github.com/mudler/mcps/pkg/output"
 )
 
 // SessionManager methods
 
 // CreateSession creates a new session and starts the opencode process
-func (sm *SessionManager) CreateSession(message, title, sessionID string, files []string, useContinue, thinking bool) (*Session, error) {
+func (sm *SessionManager) CreateSession(message, title, sessionID string, files []string, env map[string]string, useContinue, thinking bool) (*Session, error) {
 	sm.mutex.Lock()
 	defer sm.mutex.Unlock()
 
@@ -24,6 +27,17 @@ func (sm *SessionManager) CreateSession(message, title, sessionID string, files
 		return nil, fmt.Errorf("maximum number of sessions (%d) reached", sm.maxSessions)
 	}
 
+	// Catch bad attachments here rather than with a cryptic opencode error
+	files, err := sm.resolveFiles(files)
+	if err != nil {
+		return nil, err
+	}
+
+	environment, err := sm.sessionEnv(env)
+	if err != nil {
+		return nil, err
+	}
+
 	// Generate unique session ID
 	id := uuid.New().String()
 
@@ -97,17 +111,29 @@ func (sm *SessionManager) CreateSession(message, title, sessionID string, files
 		processmanager.WithArgs(args...),
 		processmanager.WithStateDir(sessionDir),
 		processmanager.WithWorkDir(sm.workDir),
-		processmanager.WithEnvironment(os.Environ()...),
+		processmanager.WithEnvironment(environment...),
 	)
 
 	session := &Session{
 		ID:        id,
 		Status:    "starting",
 		Message:   message,
+		Title:     title,
 		Model:     getEnv("OPENCODE_MODEL", ""),
 		CreatedAt: time.Now(),
 		Process:   process,
 		StateDir:  sessionDir,
+		cancel:    make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+
+	// Resolve the log paths so they can be tailed from outside the server
+	session.StdoutPath, session.StderrPath = process.StdoutPath(), process.StderrPath()
+	if abs, err := filepath.Abs(session.StdoutPath); err == nil {
+		session.StdoutPath = abs
+	}
+	if abs, err := filepath.Abs(session.StderrPath); err == nil {
+		session.StderrPath = abs
 	}
 
 	sm.sessions[id] = session
@@ -118,8 +144,31 @@ func (sm *SessionManager) CreateSession(message, title, sessionID string, files
 	return session, nil
 }
 
-// runSession runs the opencode process and monitors its status
+// runSession runs the opencode process and monitors its status, until it
+// exits or the session is deleted
 func (sm *SessionManager) runSession(session *Session) {
+	defer close(session.done)
+
+	// Snapshot the working directory so get_session_diff can tell what the
+	// session changed
+	var snapshotDir, snapshotError string
+	if sm.snapshotMaxBytes > 0 {
+		dir := filepath.Join(session.StateDir, "snapshot")
+		if err := takeSnapshot(sm.workDir, dir, sm.isStateDir, sm.snapshotMaxBytes); err != nil {
+			snapshotError = err.Error()
+		} else {
+			snapshotDir = dir
+		}
+	}
+
+	// The process is started under the lock, so a session is never seen
+	// running without a PID to stop
+	sm.mutex.Lock()
+	session.SnapshotDir, session.SnapshotError = snapshotDir, snapshotError
+	if session.cancelled() {
+		sm.mutex.Unlock()
+		return
+	}
 	session.StartedAt = time.Now()
 	session.Status = "running"
 
@@ -129,37 +178,70 @@ func (sm *SessionManager) runSession(session *Session) {
 		session.Status = "failed"
 		session.ExitCode = "-1"
 		session.StoppedAt = time.Now()
+		session.Failure = &FailureReason{
+			Cause:      "start_failed",
+			Summary:    fmt.Sprintf("opencode could not be started: %v", err),
+			Suggestion: "check that OPENCODE_BINARY points to an installed opencode and that the working directory exists",
+		}
+		sm.mutex.Unlock()
 		return
 	}
 
 	session.PID = session.Process.PID
+	sm.mutex.Unlock()
 
 	// Wait for process to complete by polling
 	// The process manager handles the process lifecycle
 	// We poll to check when it's done
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+	var exitCode string
 	for {
-		time.Sleep(100 * time.Millisecond)
+		select {
+		case <-session.cancel:
+			return
+		case <-ticker.C:
+		}
 		// Check if process is still running by checking if we can get exit code
-		exitCode, err := session.Process.ExitCode()
+		exitCode, err = session.Process.ExitCode()
 		if err == nil && exitCode != "" {
 			// Process has completed
-			session.ExitCode = exitCode
 			break
 		}
 	}
+	_, stderr, _ := readSessionLogs(session)
 
+	sm.mutex.Lock()
+	if session.cancelled() {
+		sm.mutex.Unlock()
+		return
+	}
+	session.ExitCode = exitCode
 	session.StoppedAt = time.Now()
 
 	if session.ExitCode == "0" {
 		session.Status = "completed"
 	} else {
 		session.Status = "failed"
+		session.Failure = diagnoseFailure(stderr, session.ExitCode)
 	}
+	sm.mutex.Unlock()
 
 	// Schedule cleanup based on retention policy
 	go sm.scheduleCleanup(session.ID)
 }
 
+// cancelled reports whether the session was deleted, asking runSession to
+// stop
+func (s *Session) cancelled() bool {
+	select {
+	case <-s.cancel:
+		return true
+	default:
+		return false
+	}
+}
+
 // GetSession retrieves a session by ID
 func (sm *SessionManager) GetSession(id string) (*Session, bool) {
 	sm.mutex.RLock()
@@ -191,6 +273,53 @@ func (sm *SessionManager) StopSession(id string, force bool) error {
 	return nil
 }
 
+// DeleteSession removes a session immediately, including its state directory
+// and logs. Running sessions are only stopped and deleted when force is set.
+// It reports whether the session had to be stopped first.
+func (sm *SessionManager) DeleteSession(id string, force bool) (bool, error) {
+	sm.mutex.Lock()
+	session, exists := sm.sessions[id]
+	if !exists {
+		sm.mutex.Unlock()
+		return false, fmt.Errorf("session not found: %s", id)
+	}
+
+	stopped := false
+	if session.Status == "running" || session.Status == "starting" {
+		if !force {
+			sm.mutex.Unlock()
+			return false, fmt.Errorf("session is %s; stop it first or set force to delete it anyway", session.Status)
+		}
+		// A session still starting has no process yet, cancelling it is
+		// enough to keep it from starting one
+		if session.Status == "running" {
+			if err := session.Process.Stop(); err != nil {
+				sm.mutex.Unlock()
+				return false, fmt.Errorf("failed to stop session: %w", err)
+			}
+		}
+		session.Status = "stopped"
+		session.StoppedAt = time.Now()
+		stopped = true
+	}
+	if !session.cancelled() {
+		close(session.cancel)
+	}
+	sm.mutex.Unlock()
+
+	// Wait for runSession to let go of the session before removing its
+	// directory, it still takes the lock while starting
+	<-session.done
+
+	sm.mutex.Lock()
+	defer sm.mutex.Unlock()
+	if err := os.RemoveAll(session.StateDir); err != nil {
+		return stopped, fmt.Errorf("failed to remove session directory: %w", err)
+	}
+	delete(sm.sessions, id)
+	return stopped, nil
+}
+
 // GetSessionLogs retrieves stdout and stderr logs from a session
 func (sm *SessionManager) GetSessionLogs(id string, lines int) (stdout, stderr string, err error) {
 	sm.mutex.RLock()
@@ -201,43 +330,210 @@ func (sm *SessionManager) GetSessionLogs(id string, lines int) (stdout, stderr s
 		return "", "", fmt.Errorf("session not found: %s", id)
 	}
 
-	stdoutPath := session.Process.StdoutPath()
-	stderrPath := session.Process.StderrPath()
+	stdout, stderr, err = readSessionLogs(session)
+	if err != nil {
+		return "", "", err
+	}
 
-	stdoutBytes, err := os.ReadFile(stdoutPath)
+	// Limit to specified number of lines (from end)
+	if lines > 0 {
+		stdout = getLastNLines(stdout, lines)
+		stderr = getLastNLines(stderr, lines)
+	}
+
+	// Keep the most recent output within MCP_MAX_RESPONSE_BYTES
+	stdout, _ = output.TruncateTail(stdout, "request fewer lines or tail stdout_path directly")
+	stderr, _ = output.TruncateTail(stderr, "request fewer lines or tail stderr_path directly")
+
+	return stdout, stderr, nil
+}
+
+// readSessionLogs reads the full stdout and stderr files of a session,
+// treating missing files as empty
+func readSessionLogs(session *Session) (stdout, stderr string, err error) {
+	stdoutBytes, err := os.ReadFile(session.StdoutPath)
 	if err != nil && !os.IsNotExist(err) {
 		return "", "", fmt.Errorf("failed to read stdout: %w", err)
 	}
 
-	stderrBytes, err := os.ReadFile(stderrPath)
+	stderrBytes, err := os.ReadFile(session.StderrPath)
 	if err != nil && !os.IsNotExist(err) {
 		return "", "", fmt.Errorf("failed to read stderr: %w", err)
 	}
 
-	stdout = string(stdoutBytes)
-	stderr = string(stderrBytes)
+	return string(stdoutBytes), string(stderrBytes), nil
+}
 
-	// Limit to specified number of lines (from end)
-	if lines > 0 {
-		stdout = getLastNLines(stdout, lines)
-		stderr = getLastNLines(stderr, lines)
+// LogMatch is a log line matching a search pattern
+type LogMatch struct {
+	Stream string `json:"stream" jsonschema:"stdout or stderr"`
+	Line   int    `json:"line" jsonschema:"1-based line number within the stream"`
+	Text   string `json:"text" jsonschema:"the matching line, cut after 1000 bytes"`
+}
+
+// maxMatchLineLength caps each returned line, opencode's JSON events can be
+// long. LogMatch.Text's schema and the README state it.
+const maxMatchLineLength = 1000
+
+// SearchSessionLogs returns the lines of the requested streams ("stdout",
+// "stderr" or "" for both) matching pattern, stdout first, up to maxMatches,
+// along with the total number of matching lines
+func (sm *SessionManager) SearchSessionLogs(id string, pattern *regexp.Regexp, stream string, maxMatches int) ([]LogMatch, int, error) {
+	sm.mutex.RLock()
+	session, exists := sm.sessions[id]
+	sm.mutex.RUnlock()
+	if !exists {
+		return nil, 0, fmt.Errorf("session not found: %s", id)
 	}
 
-	return stdout, stderr, nil
+	stdout, stderr, err := readSessionLogs(session)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	matches := []LogMatch{}
+	total := 0
+	search := func(name, content string) {
+		if stream != "" && stream != name {
+			return
+		}
+		for i, line := range strings.Split(content, "\n") {
+			if !pattern.MatchString(line) {
+				continue
+			}
+			total++
+			if len(matches) >= maxMatches {
+				continue
+			}
+			if kept, cut := output.Head(line, maxMatchLineLength); cut {
+				line = kept + "..."
+			}
+			matches = append(matches, LogMatch{Stream: name, Line: i + 1, Text: line})
+		}
+	}
+	search("stdout", stdout)
+	search("stderr", stderr)
+
+	return matches, total, nil
+}
+
+// isStateDir reports whether a directory holds session state, which is
+// left out of snapshots when OPENCODE_SESSION_DIR is inside the working
+// directory
+func (sm *SessionManager) isStateDir(path string) bool {
+	if path == sm.sessionDir {
+		return true
+	}
+	if filepath.Dir(path) != sm.sessionDir {
+		return false
+	}
+	_, err := uuid.Parse(filepath.Base(path))
+	return err == nil
+}
+
+// GetSessionDiff returns the unified diff of the working directory against
+// the snapshot taken when the session started, limited to path when set,
+// along with the changed files. The working directory is shared by all
+// sessions, so the diff includes the changes of concurrent sessions too.
+func (sm *SessionManager) GetSessionDiff(id, path string) (string, []FileChange, error) {
+	sm.mutex.RLock()
+	session, exists := sm.sessions[id]
+	var snapshotDir, snapshotError string
+	if exists {
+		snapshotDir, snapshotError = session.SnapshotDir, session.SnapshotError
+	}
+	sm.mutex.RUnlock()
+	if !exists {
+		return "", nil, fmt.Errorf("session not found: %s", id)
+	}
+
+	if snapshotDir == "" {
+		switch {
+		case snapshotError != "":
+			return "", nil, fmt.Errorf("no snapshot of the working directory was taken: %s", snapshotError)
+		case sm.snapshotMaxBytes <= 0:
+			return "", nil, fmt.Errorf("snapshots are disabled (OPENCODE_SNAPSHOT_MAX_BYTES=%d)", sm.snapshotMaxBytes)
+		default:
+			return "", nil, fmt.Errorf("the snapshot of the working directory is still being taken, retry shortly")
+		}
+	}
+
+	diff, changes, err := diffSnapshot(snapshotDir, sm.workDir, sm.isStateDir, path)
+	if err != nil {
+		return "", nil, err
+	}
+	diff, _ = output.Truncate(diff, "pass a path to diff fewer files")
+	return diff, changes, nil
+}
+
+// SessionFilter selects and orders the sessions returned by ListSessions
+type SessionFilter struct {
+	Status        string
+	Model         string
+	Title         string
+	CreatedAfter  time.Time
+	CreatedBefore time.Time
+	Ascending     bool
+	Limit         int
+	Offset        int
+}
+
+// matches reports whether a session satisfies the filter criteria
+func (f SessionFilter) matches(session *Session) bool {
+	if f.Status != "" && f.Status != "all" && session.Status != f.Status {
+		return false
+	}
+	if f.Model != "" && session.Model != f.Model {
+		return false
+	}
+	if f.Title != "" && !strings.Contains(strings.ToLower(session.Title), strings.ToLower(f.Title)) {
+		return false
+	}
+	if !f.CreatedAfter.IsZero() && session.CreatedAt.Before(f.CreatedAfter) {
+		return false
+	}
+	if !f.CreatedBefore.IsZero() && session.CreatedAt.After(f.CreatedBefore) {
+		return false
+	}
+	return true
 }
 
-// ListSessions returns all sessions, optionally filtered by status
-func (sm *SessionManager) ListSessions(statusFilter string) []*Session {
+// ListSessions returns the sessions matching the filter sorted by creation
+// time (ties broken by ID), along with the total number of matches before
+// pagination is applied
+func (sm *SessionManager) ListSessions(filter SessionFilter) ([]*Session, int) {
 	sm.mutex.RLock()
 	defer sm.mutex.RUnlock()
 
 	var result []*Session
 	for _, session := range sm.sessions {
-		if statusFilter == "" || statusFilter == "all" || session.Status == statusFilter {
+		if filter.matches(session) {
 			result = append(result, session)
 		}
 	}
-	return result
+
+	sort.Slice(result, func(i, j int) bool {
+		a, b := result[i], result[j]
+		if !a.CreatedAt.Equal(b.CreatedAt) {
+			if filter.Ascending {
+				return a.CreatedAt.Before(b.CreatedAt)
+			}
+			return a.CreatedAt.After(b.CreatedAt)
+		}
+		return a.ID < b.ID
+	})
+
+	total := len(result)
+	if filter.Offset > 0 {
+		if filter.Offset >= total {
+			return []*Session{}, total
+		}
+		result = result[filter.Offset:]
+	}
+	if filter.Limit > 0 && filter.Limit < len(result) {
+		result = result[:filter.Limit]
+	}
+	return result, total
 }
 
 // StopAllSessions stops all running sessions (called on server shutdown)
@@ -246,9 +542,12 @@ func (sm *SessionManager) StopAllSessions() {
 	defer sm.mutex.Unlock()
 
 	for _, session := range sm.sessions {
-		if session.Status == "running" || session.Status == "starting" {
+		if session.Status == "running" {
 			session.Process.Stop()
 		}
+		if !session.cancelled() {
+			close(session.cancel)
+		}
 		// Clean up session directory
 		os.RemoveAll(session.StateDir)
 	}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	processmanager "github.com/mudler/go-processmanager"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("DeleteSession", func() {
+	It("refuses running sessions without force", func() {
+		sm := newTestManager("exec sleep 30")
+		session, err := sm.CreateSession("hello", "", "", nil, nil, false, false)
+		Expect(err).NotTo(HaveOccurred())
+		DeferCleanup(sm.StopAllSessions)
+
+		Eventually(func() string { return sessionStatus(sm, session.ID) }).Should(Equal("running"))
+		_, err = sm.DeleteSession(session.ID, false)
+		Expect(err).To(MatchError(ContainSubstring("set force")))
+		Expect(sessionStatus(sm, session.ID)).To(Equal("running"))
+	})
+
+	It("stops a running session and its watcher before removing it", func() {
+		sm := newTestManager("exec sleep 30")
+		session, err := sm.CreateSession("hello", "", "", nil, nil, false, false)
+		Expect(err).NotTo(HaveOccurred())
+
+		Eventually(func() string { return sessionStatus(sm, session.ID) }).Should(Equal("running"))
+		process := session.Process
+
+		stopped, err := sm.DeleteSession(session.ID, true)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(stopped).To(BeTrue())
+		Expect(sessionStatus(sm, session.ID)).To(Equal("not_found"))
+		Expect(session.StateDir).NotTo(BeADirectory())
+		Expect(session.done).To(BeClosed())
+		Eventually(process.IsAlive).Should(BeFalse())
+	})
+
+	It("keeps a session still starting from starting its process", func() {
+		sm := newTestManager("exec sleep 30")
+		stateDir := filepath.Join(sm.sessionDir, "starting")
+		Expect(os.MkdirAll(stateDir, 0755)).To(Succeed())
+		session := &Session{
+			ID:        "starting",
+			Status:    "starting",
+			CreatedAt: time.Now(),
+			Process: processmanager.New(
+				processmanager.WithName(os.Getenv("OPENCODE_BINARY")),
+				processmanager.WithStateDir(stateDir),
+				processmanager.WithWorkDir(sm.workDir),
+			),
+			StateDir: stateDir,
+			cancel:   make(chan struct{}),
+			done:     make(chan struct{}),
+		}
+		sm.sessions[session.ID] = session
+
+		type result struct {
+			stopped bool
+			err     error
+		}
+		deleted := make(chan result, 1)
+		go func() {
+			stopped, err := sm.DeleteSession(session.ID, true)
+			deleted <- result{stopped, err}
+		}()
+		Eventually(session.cancel).Should(BeClosed())
+		Consistently(deleted).ShouldNot(Receive())
+
+		// The watcher starts late, as it would after a long snapshot
+		go sm.runSession(session)
+		var r result
+		Eventually(deleted).Should(Receive(&r))
+		Expect(r.err).NotTo(HaveOccurred())
+		Expect(r.stopped).To(BeTrue())
+		Expect(session.PID).To(BeEmpty())
+		Expect(sessionStatus(sm, session.ID)).To(Equal("not_found"))
+		Expect(stateDir).NotTo(BeADirectory())
+	})
+})
@@ -0,0 +1,220 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// defaultSnapshotMaxBytes bounds the copy of the working directory taken
+// when a session starts
+const defaultSnapshotMaxBytes = 50 * 1024 * 1024
+
+// FileChange summarizes how a file changed during a session
+type FileChange struct {
+	Path      string `json:"path" jsonschema:"path relative to the working directory"`
+	Status    string `json:"status" jsonschema:"added, modified or deleted"`
+	Additions int    `json:"additions" jsonschema:"number of added lines"`
+	Deletions int    `json:"deletions" jsonschema:"number of deleted lines"`
+	Binary    bool   `json:"binary,omitempty" jsonschema:"whether the file is binary, its content is not diffed"`
+}
+
+// walkWorkDir calls fn with the path relative to root of every regular file
+// under it. .git directories and the directories for which skip returns true
+// are left out, as are entries that cannot be read.
+func walkWorkDir(root string, skip func(path string) bool, fn func(rel, path string, info fs.FileInfo) error) error {
+	return filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if d != nil && d.IsDir() && path != root {
+				return fs.SkipDir
+			}
+			if path == root {
+				return err
+			}
+			return nil
+		}
+		if d.IsDir() {
+			if path != root && (d.Name() == ".git" || (skip != nil && skip(path))) {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		return fn(filepath.ToSlash(rel), path, info)
+	})
+}
+
+// takeSnapshot copies the regular files of workDir into dest. It fails,
+// leaving no snapshot behind, when they add up to more than maxBytes.
+func takeSnapshot(workDir, dest string, skip func(string) bool, maxBytes int64) error {
+	var total int64
+	err := walkWorkDir(workDir, skip, func(rel, path string, info fs.FileInfo) error {
+		total += info.Size()
+		if total > maxBytes {
+			return fmt.Errorf("working directory exceeds OPENCODE_SNAPSHOT_MAX_BYTES (%d bytes)", maxBytes)
+		}
+		target := filepath.Join(dest, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return err
+		}
+		return copyFile(path, target)
+	})
+	if err != nil {
+		os.RemoveAll(dest)
+		return err
+	}
+	return os.MkdirAll(dest, 0755)
+}
+
+// copyFile copies the content of a file, skipping files that cannot be read
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return nil
+	}
+	defer in.Close()
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// listFiles returns the files under root keyed by their relative path
+func listFiles(root string, skip func(string) bool) (map[string]string, error) {
+	files := map[string]string{}
+	err := walkWorkDir(root, skip, func(rel, path string, info fs.FileInfo) error {
+		files[rel] = path
+		return nil
+	})
+	return files, err
+}
+
+// diffSnapshot returns the unified diff of workDir against the snapshot,
+// limited to the files under prefix when set, along with a summary of the
+// changed files
+func diffSnapshot(snapshotDir, workDir string, skip func(string) bool, prefix string) (string, []FileChange, error) {
+	before, err := listFiles(snapshotDir, nil)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to read snapshot: %w", err)
+	}
+	after, err := listFiles(workDir, skip)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to read working directory: %w", err)
+	}
+
+	var paths []string
+	for rel := range before {
+		paths = append(paths, rel)
+	}
+	for rel := range after {
+		if _, ok := before[rel]; !ok {
+			paths = append(paths, rel)
+		}
+	}
+	sort.Strings(paths)
+
+	prefix = strings.Trim(filepath.ToSlash(filepath.Clean(prefix)), "/")
+	if prefix == "." {
+		prefix = ""
+	}
+	var diff strings.Builder
+	changes := []FileChange{}
+	for _, rel := range paths {
+		if prefix != "" && rel != prefix && !strings.HasPrefix(rel, prefix+"/") {
+			continue
+		}
+		oldContent, err := readIfPresent(before[rel])
+		if err != nil {
+			return "", nil, err
+		}
+		newContent, err := readIfPresent(after[rel])
+		if err != nil {
+			return "", nil, err
+		}
+		_, existed := before[rel]
+		_, exists := after[rel]
+		if existed && exists && bytes.Equal(oldContent, newContent) {
+			continue
+		}
+
+		change := FileChange{Path: rel, Status: "modified"}
+		oldName, newName := "a/"+rel, "b/"+rel
+		switch {
+		case !existed:
+			change.Status = "added"
+			oldName = "/dev/null"
+		case !exists:
+			change.Status = "deleted"
+			newName = "/dev/null"
+		}
+
+		fmt.Fprintf(&diff, "diff --git a/%s b/%s\n", rel, rel)
+		switch change.Status {
+		case "added":
+			fmt.Fprintf(&diff, "new file mode %s\n", gitMode(after[rel]))
+		case "deleted":
+			fmt.Fprintf(&diff, "deleted file mode %s\n", gitMode(before[rel]))
+		}
+		if isBinary(oldContent) || isBinary(newContent) {
+			change.Binary = true
+			fmt.Fprintf(&diff, "Binary files %s and %s differ\n", oldName, newName)
+		} else {
+			fmt.Fprintf(&diff, "--- %s\n+++ %s\n", oldName, newName)
+			hunks, additions, deletions := unifiedDiff(splitLines(string(oldContent)), splitLines(string(newContent)))
+			diff.WriteString(hunks)
+			change.Additions, change.Deletions = additions, deletions
+		}
+		changes = append(changes, change)
+	}
+
+	return diff.String(), changes, nil
+}
+
+// readIfPresent reads a file, an empty path reads as no content
+func readIfPresent(path string) ([]byte, error) {
+	if path == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return data, nil
+}
+
+// gitMode returns the mode git records for a regular file
+func gitMode(path string) string {
+	if info, err := os.Stat(path); err == nil && info.Mode()&0111 != 0 {
+		return "100755"
+	}
+	return "100644"
+}
+
+// isBinary reports whether content looks binary, the way git does: a NUL
+// byte in the first 8000 bytes
+func isBinary(content []byte) bool {
+	if len(content) > 8000 {
+		content = content[:8000]
+	}
+	return bytes.IndexByte(content, 0) >= 0
+}
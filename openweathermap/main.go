@@ -13,6 +13,8 @@ import (
 	"time"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/mudler/mcps/pkg/selfcheck"
+	"github.com/mudler/mcps/pkg/transport"
 )
 
 type Input struct {
@@ -228,7 +230,24 @@ func GetWeather(ctx context.Context, req *mcp.CallToolRequest, input Input) (
 	return nil, output, nil
 }
 
+// selfChecks validates OWM_API_KEY against the geocoding API
+func selfChecks() []selfcheck.Check {
+	apiKey := os.Getenv("OWM_API_KEY")
+	if apiKey == "" {
+		return []selfcheck.Check{selfcheck.Env("OWM_API_KEY")}
+	}
+	client := &http.Client{Timeout: 10 * time.Second}
+	return []selfcheck.Check{
+		selfcheck.Env("OWM_API_KEY"),
+		selfcheck.HTTP("api", client, "https://api.openweathermap.org/geo/1.0/direct?q=London&limit=1&appid="+url.QueryEscape(apiKey), nil),
+	}
+}
+
 func main() {
+	if selfcheck.Enabled() {
+		selfcheck.Exit("openweathermap", selfChecks()...)
+	}
+
 	server := mcp.NewServer(&mcp.Implementation{
 		Name:    "openweathermap",
 		Version: "v1.0.0",
@@ -239,7 +258,7 @@ func main() {
 		Description: "Get current weather and 5-day forecast for a city using OpenWeatherMap",
 	}, GetWeather)
 
-	if err := server.Run(context.Background(), &mcp.StdioTransport{}); err != nil {
+	if err := transport.Run(context.Background(), server); err != nil {
 		log.Fatal(err)
 	}
 }
@@ -0,0 +1,177 @@
+// Package batch runs several tool calls of a server in a single call.
+//
+// A batch tool takes a list of operations, each naming one of the server's
+// tools with its arguments, and runs them in order, returning the result of
+// each. By default it stops at the first failing operation; with
+// continue_on_error the remaining operations still run. Servers storing
+// their state in a file run the whole batch under their lock, and discard
+// its changes when it stops on a failure.
+package batch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// MaxOperations is the maximum number of operations of a batch
+const MaxOperations = 50
+
+// Operation is a tool call of a batch
+type Operation struct {
+	Tool      string         `json:"tool" jsonschema:"name of the tool to call"`
+	Arguments map[string]any `json:"arguments,omitempty" jsonschema:"arguments of the tool, as when calling it directly"`
+}
+
+// Input is the input of a batch tool
+type Input struct {
+	Operations      []Operation `json:"operations" jsonschema:"the tool calls to run, in order"`
+	ContinueOnError bool        `json:"continue_on_error,omitempty" jsonschema:"run the remaining operations after one fails instead of stopping (default: false)"`
+}
+
+// Result is the outcome of an operation
+type Result struct {
+	Index   int    `json:"index" jsonschema:"position of the operation in the batch, from 0"`
+	Tool    string `json:"tool" jsonschema:"the tool called"`
+	Success bool   `json:"success" jsonschema:"whether the operation succeeded"`
+	Output  any    `json:"output,omitempty" jsonschema:"the output of the tool"`
+	Error   string `json:"error,omitempty" jsonschema:"why the operation failed"`
+}
+
+// Output is the output of a batch tool
+type Output struct {
+	Results    []Result `json:"results" jsonschema:"the result of each operation run, in order"`
+	Succeeded  int      `json:"succeeded" jsonschema:"number of operations that succeeded"`
+	Failed     int      `json:"failed" jsonschema:"number of operations that failed"`
+	Skipped    int      `json:"skipped,omitempty" jsonschema:"number of operations not run because an earlier one failed"`
+	RolledBack bool     `json:"rolled_back,omitempty" jsonschema:"true when the changes of the batch were discarded because it stopped on a failure"`
+}
+
+// Handler runs an operation with its arguments
+type Handler func(ctx context.Context, req *mcp.CallToolRequest, arguments map[string]any) (any, error)
+
+// Tool adapts a tool handler to a batch Handler. The arguments are decoded
+// into the input of the tool, unknown arguments being rejected. Outputs with
+// a false success field, as returned by tools reporting failures in their
+// output, count as failures.
+func Tool[In, Out any](handler func(context.Context, *mcp.CallToolRequest, In) (*mcp.CallToolResult, Out, error)) Handler {
+	return func(ctx context.Context, req *mcp.CallToolRequest, arguments map[string]any) (any, error) {
+		var input In
+		if len(arguments) > 0 {
+			data, err := json.Marshal(arguments)
+			if err != nil {
+				return nil, fmt.Errorf("invalid arguments: %w", err)
+			}
+			decoder := json.NewDecoder(bytes.NewReader(data))
+			decoder.DisallowUnknownFields()
+			if err := decoder.Decode(&input); err != nil {
+				return nil, fmt.Errorf("invalid arguments: %w", err)
+			}
+		}
+
+		_, output, err := handler(ctx, req, input)
+		if err != nil {
+			return nil, err
+		}
+		return output, failure(output)
+	}
+}
+
+// failure returns the error reported by an output with a false success
+// field, from its error or message field
+func failure(output any) error {
+	data, err := json.Marshal(output)
+	if err != nil {
+		return nil
+	}
+	var fields struct {
+		Success *bool  `json:"success"`
+		Error   string `json:"error"`
+		Message string `json:"message"`
+	}
+	if json.Unmarshal(data, &fields) != nil || fields.Success == nil || *fields.Success {
+		return nil
+	}
+	switch {
+	case fields.Error != "":
+		return fmt.Errorf("%s", fields.Error)
+	case fields.Message != "":
+		return fmt.Errorf("%s", fields.Message)
+	}
+	return fmt.Errorf("operation failed")
+}
+
+// Handlers are the tools a batch may call, by name
+type Handlers map[string]Handler
+
+// Names returns the sorted names of the tools
+func (h Handlers) Names() []string {
+	names := make([]string, 0, len(h))
+	for name := range h {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Validate checks the operations before any of them runs: there must be at
+// least one and at most MaxOperations, each calling a known tool
+func (h Handlers) Validate(input Input) error {
+	if len(input.Operations) == 0 {
+		return fmt.Errorf("at least one operation is required")
+	}
+	if len(input.Operations) > MaxOperations {
+		return fmt.Errorf("too many operations: %d (max %d)", len(input.Operations), MaxOperations)
+	}
+	for i, operation := range input.Operations {
+		if _, ok := h[operation.Tool]; !ok {
+			return fmt.Errorf("operation %d: unknown tool %q (available: %s)", i, operation.Tool, strings.Join(h.Names(), ", "))
+		}
+	}
+	return nil
+}
+
+// Run validates the operations and runs them in order. It stops at the
+// first failure unless input.ContinueOnError is set, and returns an error
+// only for invalid input or when ctx is done.
+func (h Handlers) Run(ctx context.Context, req *mcp.CallToolRequest, input Input) (Output, error) {
+	if err := h.Validate(input); err != nil {
+		return Output{}, err
+	}
+
+	output := Output{Results: []Result{}}
+	for i, operation := range input.Operations {
+		if err := ctx.Err(); err != nil {
+			return Output{}, err
+		}
+
+		result := Result{Index: i, Tool: operation.Tool}
+		out, err := h[operation.Tool](ctx, req, operation.Arguments)
+		result.Output = out
+		if err != nil {
+			result.Error = err.Error()
+			output.Failed++
+		} else {
+			result.Success = true
+			output.Succeeded++
+		}
+		output.Results = append(output.Results, result)
+
+		if err != nil && !input.ContinueOnError {
+			output.Skipped = len(input.Operations) - i - 1
+			break
+		}
+	}
+	return output, nil
+}
+
+// Stopped reports whether the batch stopped on a failure, in which case
+// servers running it as a transaction discard its changes
+func (o Output) Stopped(input Input) bool {
+	return o.Failed > 0 && !input.ContinueOnError
+}
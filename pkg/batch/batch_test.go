@@ -0,0 +1,127 @@
+package batch
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+type echoInput struct {
+	Text string `json:"text"`
+	Fail bool   `json:"fail"`
+}
+
+type echoOutput struct {
+	Text string `json:"text"`
+}
+
+type reportOutput struct {
+	Success bool   `json:"success"`
+	Error   string `json:"error,omitempty"`
+}
+
+var _ = Describe("Batch", func() {
+	var (
+		calls    []string
+		handlers Handlers
+	)
+
+	BeforeEach(func() {
+		calls = nil
+		handlers = Handlers{
+			"echo": Tool(func(ctx context.Context, req *mcp.CallToolRequest, input echoInput) (*mcp.CallToolResult, echoOutput, error) {
+				calls = append(calls, input.Text)
+				if input.Fail {
+					return nil, echoOutput{}, fmt.Errorf("failed %s", input.Text)
+				}
+				return nil, echoOutput{Text: input.Text}, nil
+			}),
+			"report": Tool(func(ctx context.Context, req *mcp.CallToolRequest, input struct{}) (*mcp.CallToolResult, reportOutput, error) {
+				calls = append(calls, "report")
+				return nil, reportOutput{Success: false, Error: "not found"}, nil
+			}),
+		}
+	})
+
+	It("runs the operations in order", func() {
+		output, err := handlers.Run(context.Background(), nil, Input{Operations: []Operation{
+			{Tool: "echo", Arguments: map[string]any{"text": "one"}},
+			{Tool: "echo", Arguments: map[string]any{"text": "two"}},
+		}})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(calls).To(Equal([]string{"one", "two"}))
+		Expect(output.Succeeded).To(Equal(2))
+		Expect(output.Results).To(HaveLen(2))
+		Expect(output.Results[1]).To(Equal(Result{Index: 1, Tool: "echo", Success: true, Output: echoOutput{Text: "two"}}))
+	})
+
+	It("stops at the first failure", func() {
+		output, err := handlers.Run(context.Background(), nil, Input{Operations: []Operation{
+			{Tool: "echo", Arguments: map[string]any{"text": "one", "fail": true}},
+			{Tool: "echo", Arguments: map[string]any{"text": "two"}},
+		}})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(calls).To(Equal([]string{"one"}))
+		Expect(output.Failed).To(Equal(1))
+		Expect(output.Skipped).To(Equal(1))
+		Expect(output.Results[0].Error).To(Equal("failed one"))
+		Expect(output.Stopped(Input{})).To(BeTrue())
+	})
+
+	It("continues after failures with continue_on_error", func() {
+		input := Input{ContinueOnError: true, Operations: []Operation{
+			{Tool: "echo", Arguments: map[string]any{"text": "one", "fail": true}},
+			{Tool: "echo", Arguments: map[string]any{"text": "two"}},
+		}}
+		output, err := handlers.Run(context.Background(), nil, input)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(calls).To(Equal([]string{"one", "two"}))
+		Expect(output.Succeeded).To(Equal(1))
+		Expect(output.Failed).To(Equal(1))
+		Expect(output.Skipped).To(BeZero())
+		Expect(output.Stopped(input)).To(BeFalse())
+	})
+
+	It("counts outputs with a false success field as failures", func() {
+		output, err := handlers.Run(context.Background(), nil, Input{Operations: []Operation{{Tool: "report"}}})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(output.Failed).To(Equal(1))
+		Expect(output.Results[0].Error).To(Equal("not found"))
+		Expect(output.Results[0].Output).To(Equal(reportOutput{Error: "not found"}))
+	})
+
+	It("rejects unknown arguments", func() {
+		output, err := handlers.Run(context.Background(), nil, Input{Operations: []Operation{
+			{Tool: "echo", Arguments: map[string]any{"txt": "one"}},
+		}})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(calls).To(BeEmpty())
+		Expect(output.Results[0].Error).To(ContainSubstring("invalid arguments"))
+	})
+
+	It("validates the operations before running any", func() {
+		_, err := handlers.Run(context.Background(), nil, Input{Operations: []Operation{
+			{Tool: "echo", Arguments: map[string]any{"text": "one"}},
+			{Tool: "batch"},
+		}})
+		Expect(err).To(MatchError(`operation 1: unknown tool "batch" (available: echo, report)`))
+		Expect(calls).To(BeEmpty())
+
+		_, err = handlers.Run(context.Background(), nil, Input{})
+		Expect(err).To(MatchError(ContainSubstring("at least one operation")))
+
+		_, err = handlers.Run(context.Background(), nil, Input{Operations: make([]Operation, MaxOperations+1)})
+		Expect(err).To(MatchError(ContainSubstring("too many operations")))
+	})
+
+	It("stops when the context is done", func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, err := handlers.Run(ctx, nil, Input{Operations: []Operation{{Tool: "echo"}}})
+		Expect(err).To(MatchError(context.Canceled))
+		Expect(calls).To(BeEmpty())
+	})
+})
@@ -0,0 +1,213 @@
+// Package metrics records how often each tool of a server is called and how
+// long the calls take.
+//
+// Setting MCP_METRICS=true installs a middleware that counts the calls,
+// errors and latency of every tool, and registers a get_tool_metrics tool
+// returning them. Metrics live in memory and start over when the server
+// restarts.
+package metrics
+
+import (
+	"context"
+	"errors"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+const (
+	// EnvMetrics enables tool metrics when true
+	EnvMetrics = "MCP_METRICS"
+	// ToolName is the name of the tool returning the metrics
+	ToolName = "get_tool_metrics"
+
+	// maxTools bounds the number of tool names recorded, calls to unknown
+	// tools are recorded too and must not grow the table without limit
+	maxTools = 1000
+)
+
+// Enabled reports whether MCP_METRICS is set to true
+func Enabled() bool {
+	value := strings.TrimSpace(os.Getenv(EnvMetrics))
+	if value == "" {
+		return false
+	}
+	enabled, err := strconv.ParseBool(value)
+	if err != nil {
+		log.Printf("Warning: invalid %s %q, tool metrics disabled", EnvMetrics, value)
+		return false
+	}
+	return enabled
+}
+
+// ToolMetrics are the recorded calls of one tool
+type ToolMetrics struct {
+	Tool         string    `json:"tool" jsonschema:"the tool name"`
+	Calls        int       `json:"calls" jsonschema:"number of calls"`
+	Errors       int       `json:"errors" jsonschema:"number of calls that failed"`
+	TotalMs      float64   `json:"total_ms" jsonschema:"time spent in the tool in milliseconds"`
+	AvgMs        float64   `json:"avg_ms" jsonschema:"average call duration in milliseconds"`
+	MinMs        float64   `json:"min_ms" jsonschema:"fastest call in milliseconds"`
+	MaxMs        float64   `json:"max_ms" jsonschema:"slowest call in milliseconds"`
+	LastCalledAt time.Time `json:"last_called_at" jsonschema:"when the tool was last called"`
+	LastError    string    `json:"last_error,omitempty" jsonschema:"the error of the most recent failed call"`
+}
+
+// Recorder accumulates the metrics of tool calls
+type Recorder struct {
+	mutex sync.Mutex
+	since time.Time
+	tools map[string]*ToolMetrics
+	now   func() time.Time
+}
+
+// NewRecorder returns an empty recorder
+func NewRecorder() *Recorder {
+	return &Recorder{since: time.Now(), tools: map[string]*ToolMetrics{}, now: time.Now}
+}
+
+// Record adds a call of tool that took duration, failed when err is not nil
+func (r *Recorder) Record(tool string, duration time.Duration, err error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	m, ok := r.tools[tool]
+	if !ok {
+		if len(r.tools) >= maxTools {
+			return
+		}
+		m = &ToolMetrics{Tool: tool}
+		r.tools[tool] = m
+	}
+
+	ms := float64(duration) / float64(time.Millisecond)
+	if m.Calls == 0 || ms < m.MinMs {
+		m.MinMs = ms
+	}
+	if ms > m.MaxMs {
+		m.MaxMs = ms
+	}
+	m.Calls++
+	m.TotalMs += ms
+	m.AvgMs = m.TotalMs / float64(m.Calls)
+	m.LastCalledAt = r.now()
+	if err != nil {
+		m.Errors++
+		m.LastError = err.Error()
+	}
+}
+
+// Snapshot returns a copy of the metrics sorted by tool name, and the time
+// recording started
+func (r *Recorder) Snapshot() ([]ToolMetrics, time.Time) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	list := make([]ToolMetrics, 0, len(r.tools))
+	for _, m := range r.tools {
+		list = append(list, *m)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Tool < list[j].Tool })
+	return list, r.since
+}
+
+// Reset forgets all recorded calls
+func (r *Recorder) Reset() {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.tools = map[string]*ToolMetrics{}
+	r.since = r.now()
+}
+
+// Middleware records every tools/call request. A call fails when the
+// request itself fails, e.g. for an unknown tool or invalid arguments, or
+// when the tool returns an error result.
+func (r *Recorder) Middleware() mcp.Middleware {
+	return func(next mcp.MethodHandler) mcp.MethodHandler {
+		return func(ctx context.Context, method string, req mcp.Request) (mcp.Result, error) {
+			call, ok := req.(*mcp.CallToolRequest)
+			if !ok || call.Params == nil {
+				return next(ctx, method, req)
+			}
+
+			start := time.Now()
+			result, err := next(ctx, method, req)
+			callErr := err
+			if res, ok := result.(*mcp.CallToolResult); ok && err == nil && res.IsError {
+				callErr = resultError(res)
+			}
+			r.Record(call.Params.Name, time.Since(start), callErr)
+			return result, err
+		}
+	}
+}
+
+// resultError returns the error of a failed tool result. Handlers built
+// with mcp.AddTool set it; results flagged by hand only carry the message
+// in their text content.
+func resultError(res *mcp.CallToolResult) error {
+	if err := res.GetError(); err != nil {
+		return err
+	}
+	var texts []string
+	for _, content := range res.Content {
+		if text, ok := content.(*mcp.TextContent); ok {
+			texts = append(texts, text.Text)
+		}
+	}
+	if len(texts) == 0 {
+		return errors.New("tool returned an error")
+	}
+	return errors.New(strings.Join(texts, "\n"))
+}
+
+// GetToolMetricsInput selects the metrics to return
+type GetToolMetricsInput struct {
+	Tool  string `json:"tool,omitempty" jsonschema:"only return the metrics of this tool (default: all tools)"`
+	Reset bool   `json:"reset,omitempty" jsonschema:"clear all metrics after returning them"`
+}
+
+// GetToolMetricsOutput holds the recorded metrics
+type GetToolMetricsOutput struct {
+	Tools []ToolMetrics `json:"tools" jsonschema:"metrics of each called tool, sorted by name"`
+	Count int           `json:"count" jsonschema:"number of tools returned"`
+	Since time.Time     `json:"since" jsonschema:"when recording started, at server start or the last reset"`
+}
+
+// GetToolMetrics is the handler of the get_tool_metrics tool
+func (r *Recorder) GetToolMetrics(ctx context.Context, req *mcp.CallToolRequest, input GetToolMetricsInput) (*mcp.CallToolResult, GetToolMetricsOutput, error) {
+	list, since := r.Snapshot()
+	if input.Reset {
+		r.Reset()
+	}
+
+	if input.Tool != "" {
+		filtered := []ToolMetrics{}
+		for _, m := range list {
+			if m.Tool == input.Tool {
+				filtered = append(filtered, m)
+			}
+		}
+		list = filtered
+	}
+
+	return nil, GetToolMetricsOutput{Tools: list, Count: len(list), Since: since}, nil
+}
+
+// Register records the tool calls of server and adds the get_tool_metrics
+// tool to it
+func Register(server *mcp.Server) *Recorder {
+	recorder := NewRecorder()
+	server.AddReceivingMiddleware(recorder.Middleware())
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        ToolName,
+		Description: "Get usage metrics of the tools of this server: number of calls and errors, and average, min and max duration in milliseconds. Optionally filter by tool name or reset the metrics.",
+	}, recorder.GetToolMetrics)
+	return recorder
+}
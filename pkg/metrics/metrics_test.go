@@ -0,0 +1,130 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+type echoInput struct {
+	Text string `json:"text,omitempty"`
+	Fail bool   `json:"fail,omitempty"`
+}
+
+type echoOutput struct {
+	Text string `json:"text"`
+}
+
+var _ = Describe("Metrics", func() {
+	Context("Enabled", func() {
+		It("should be off by default", func() {
+			GinkgoT().Setenv(EnvMetrics, "")
+			Expect(Enabled()).To(BeFalse())
+		})
+
+		It("should read MCP_METRICS", func() {
+			GinkgoT().Setenv(EnvMetrics, "true")
+			Expect(Enabled()).To(BeTrue())
+		})
+
+		It("should stay off on invalid values", func() {
+			GinkgoT().Setenv(EnvMetrics, "sometimes")
+			Expect(Enabled()).To(BeFalse())
+		})
+	})
+
+	Context("Recorder", func() {
+		It("should aggregate calls per tool", func() {
+			r := NewRecorder()
+			r.Record("search", 10*time.Millisecond, nil)
+			r.Record("search", 30*time.Millisecond, errors.New("boom"))
+			r.Record("fetch", 5*time.Millisecond, nil)
+
+			list, _ := r.Snapshot()
+			Expect(list).To(HaveLen(2))
+			Expect(list[0].Tool).To(Equal("fetch"))
+			search := list[1]
+			Expect(search.Calls).To(Equal(2))
+			Expect(search.Errors).To(Equal(1))
+			Expect(search.LastError).To(Equal("boom"))
+			Expect(search.TotalMs).To(BeNumerically("~", 40, 0.001))
+			Expect(search.AvgMs).To(BeNumerically("~", 20, 0.001))
+			Expect(search.MinMs).To(BeNumerically("~", 10, 0.001))
+			Expect(search.MaxMs).To(BeNumerically("~", 30, 0.001))
+		})
+
+		It("should forget everything on reset", func() {
+			r := NewRecorder()
+			r.Record("search", time.Millisecond, nil)
+			r.Reset()
+			list, _ := r.Snapshot()
+			Expect(list).To(BeEmpty())
+		})
+	})
+
+	Context("Register", func() {
+		var session *mcp.ClientSession
+
+		BeforeEach(func() {
+			server := mcp.NewServer(&mcp.Implementation{Name: "test", Version: "v1.0.0"}, nil)
+			mcp.AddTool(server, &mcp.Tool{Name: "echo"}, func(ctx context.Context, req *mcp.CallToolRequest, input echoInput) (*mcp.CallToolResult, echoOutput, error) {
+				if input.Fail {
+					return nil, echoOutput{}, errors.New("echo failed")
+				}
+				return nil, echoOutput{Text: input.Text}, nil
+			})
+			Register(server)
+
+			ctx := context.Background()
+			serverTransport, clientTransport := mcp.NewInMemoryTransports()
+			serverSession, err := server.Connect(ctx, serverTransport, nil)
+			Expect(err).ToNot(HaveOccurred())
+			DeferCleanup(serverSession.Close)
+
+			client := mcp.NewClient(&mcp.Implementation{Name: "client", Version: "v1.0.0"}, nil)
+			session, err = client.Connect(ctx, clientTransport, nil)
+			Expect(err).ToNot(HaveOccurred())
+			DeferCleanup(session.Close)
+		})
+
+		call := func(name string, args map[string]any) *mcp.CallToolResult {
+			result, err := session.CallTool(context.Background(), &mcp.CallToolParams{Name: name, Arguments: args})
+			Expect(err).ToNot(HaveOccurred())
+			return result
+		}
+
+		metricsOf := func(tool string) map[string]any {
+			result := call(ToolName, map[string]any{"tool": tool})
+			Expect(result.IsError).To(BeFalse())
+			output := result.StructuredContent.(map[string]any)
+			tools := output["tools"].([]any)
+			Expect(tools).To(HaveLen(1))
+			return tools[0].(map[string]any)
+		}
+
+		It("should record the calls and errors of every tool", func() {
+			call("echo", map[string]any{"text": "hi"})
+			call("echo", map[string]any{"text": "hi"})
+			Expect(call("echo", map[string]any{"fail": true}).IsError).To(BeTrue())
+
+			echo := metricsOf("echo")
+			Expect(echo["calls"]).To(BeEquivalentTo(3))
+			Expect(echo["errors"]).To(BeEquivalentTo(1))
+			Expect(echo["last_error"]).To(Equal("echo failed"))
+		})
+
+		It("should reset the metrics on request", func() {
+			call("echo", map[string]any{"text": "hi"})
+			call(ToolName, map[string]any{"reset": true})
+
+			echo := metricsOf(ToolName)
+			Expect(echo["calls"]).To(BeEquivalentTo(1))
+			result := call(ToolName, map[string]any{"tool": "echo"})
+			Expect(result.StructuredContent.(map[string]any)["count"]).To(BeEquivalentTo(0))
+		})
+	})
+})
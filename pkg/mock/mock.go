@@ -0,0 +1,127 @@
+// Package mock serves canned HTTP responses so servers that depend on a live
+// backend can run offline, for demos and for integration tests of agent flows.
+//
+// A server opts in with <PREFIX>_MOCK=true (e.g. TWITTER_MOCK, HA_MOCK) and
+// swaps the *http.Client handed to its API client for one built by NewClient.
+// Everything above the HTTP layer, including response decoding, runs as usual.
+package mock
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// Enabled reports whether mock mode is on for the server whose environment
+// variables use the given prefix, i.e. whether <PREFIX>_MOCK is true
+func Enabled(prefix string) bool {
+	name := strings.ToUpper(prefix) + "_MOCK"
+	value := strings.TrimSpace(os.Getenv(name))
+	if value == "" {
+		return false
+	}
+	enabled, err := strconv.ParseBool(value)
+	if err != nil {
+		log.Printf("Warning: invalid %s %q, mock mode disabled", name, value)
+		return false
+	}
+	return enabled
+}
+
+// Handler produces the status code and body for a request. Bodies that are
+// strings or byte slices are sent as is, anything else is encoded as JSON.
+type Handler func(req *http.Request) (int, interface{})
+
+// Route maps requests to a Handler. Method matches any method when empty and
+// Pattern is matched against the URL path with path.Match, so '*' stands for
+// a single path segment.
+type Route struct {
+	Method  string
+	Pattern string
+	Handler Handler
+}
+
+// JSON returns a Handler that always answers with the given status and body
+func JSON(status int, body interface{}) Handler {
+	return func(*http.Request) (int, interface{}) {
+		return status, body
+	}
+}
+
+// Transport is an http.RoundTripper answering from a fixed set of routes.
+// Requests never leave the process; unmatched ones get a 404.
+type Transport struct {
+	routes []Route
+}
+
+// NewTransport returns a Transport for the given routes, tried in order
+func NewTransport(routes ...Route) *Transport {
+	return &Transport{routes: routes}
+}
+
+// NewClient returns an *http.Client backed by a Transport for the given routes
+func NewClient(routes ...Route) *http.Client {
+	return &http.Client{Transport: NewTransport(routes...)}
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	status := http.StatusNotFound
+	var body interface{} = map[string]string{
+		"error": fmt.Sprintf("no mock response for %s %s", req.Method, req.URL.Path),
+	}
+	for _, route := range t.routes {
+		if route.Method != "" && route.Method != req.Method {
+			continue
+		}
+		if ok, _ := path.Match(route.Pattern, req.URL.Path); ok {
+			status, body = route.Handler(req)
+			break
+		}
+	}
+	if req.Body != nil {
+		req.Body.Close()
+	}
+
+	var data []byte
+	switch v := body.(type) {
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		var err error
+		data, err = json.Marshal(v)
+		if err != nil {
+			return nil, fmt.Errorf("mock: failed to encode response for %s: %w", req.URL.Path, err)
+		}
+	}
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", status, http.StatusText(status)),
+		StatusCode:    status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header{"Content-Type": []string{"application/json"}},
+		Body:          io.NopCloser(bytes.NewReader(data)),
+		ContentLength: int64(len(data)),
+		Request:       req,
+	}, nil
+}
+
+// DecodeBody decodes a JSON request body into v, for handlers that echo
+// parts of the request back
+func DecodeBody(req *http.Request, v interface{}) error {
+	if req.Body == nil {
+		return fmt.Errorf("empty request body")
+	}
+	return json.NewDecoder(req.Body).Decode(v)
+}
@@ -0,0 +1,82 @@
+package mock
+
+import (
+	"io"
+	"net/http"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Mock", func() {
+	Context("Enabled", func() {
+		It("should be off when unset", func() {
+			GinkgoT().Setenv("EXAMPLE_MOCK", "")
+			Expect(Enabled("example")).To(BeFalse())
+		})
+
+		It("should read the prefixed variable", func() {
+			GinkgoT().Setenv("EXAMPLE_MOCK", "true")
+			Expect(Enabled("example")).To(BeTrue())
+		})
+
+		It("should ignore invalid values", func() {
+			GinkgoT().Setenv("EXAMPLE_MOCK", "sometimes")
+			Expect(Enabled("EXAMPLE")).To(BeFalse())
+		})
+	})
+
+	Context("NewClient", func() {
+		var client *http.Client
+
+		BeforeEach(func() {
+			client = NewClient(
+				Route{Method: http.MethodGet, Pattern: "/api/items", Handler: JSON(http.StatusOK, []string{"a", "b"})},
+				Route{Method: http.MethodGet, Pattern: "/api/items/*", Handler: JSON(http.StatusOK, "raw")},
+				Route{Method: http.MethodPost, Pattern: "/api/items", Handler: func(req *http.Request) (int, interface{}) {
+					var body map[string]string
+					if err := DecodeBody(req, &body); err != nil {
+						return http.StatusBadRequest, map[string]string{"error": err.Error()}
+					}
+					return http.StatusCreated, body
+				}},
+			)
+		})
+
+		get := func(method, url, body string) (int, string) {
+			req, err := http.NewRequest(method, url, strings.NewReader(body))
+			Expect(err).NotTo(HaveOccurred())
+			resp, err := client.Do(req)
+			Expect(err).NotTo(HaveOccurred())
+			defer resp.Body.Close()
+			data, err := io.ReadAll(resp.Body)
+			Expect(err).NotTo(HaveOccurred())
+			return resp.StatusCode, string(data)
+		}
+
+		It("should encode bodies as JSON", func() {
+			status, body := get(http.MethodGet, "https://example.com/api/items", "")
+			Expect(status).To(Equal(http.StatusOK))
+			Expect(body).To(MatchJSON(`["a","b"]`))
+		})
+
+		It("should match single path segments with wildcards", func() {
+			status, body := get(http.MethodGet, "https://example.com/api/items/42?verbose=1", "")
+			Expect(status).To(Equal(http.StatusOK))
+			Expect(body).To(Equal("raw"))
+		})
+
+		It("should let handlers echo the request", func() {
+			status, body := get(http.MethodPost, "https://example.com/api/items", `{"name":"c"}`)
+			Expect(status).To(Equal(http.StatusCreated))
+			Expect(body).To(MatchJSON(`{"name":"c"}`))
+		})
+
+		It("should answer unknown routes with 404", func() {
+			status, body := get(http.MethodDelete, "https://example.com/api/items", "")
+			Expect(status).To(Equal(http.StatusNotFound))
+			Expect(body).To(ContainSubstring("no mock response for DELETE /api/items"))
+		})
+	})
+})
@@ -0,0 +1,91 @@
+// Package output keeps tool results within a size the model can consume.
+//
+// MCP_MAX_RESPONSE_BYTES sets the maximum number of bytes a single text field
+// of a tool result may carry. Oversized values are cut on a UTF-8 boundary and
+// end with a marker telling the caller how much was dropped and how to fetch
+// the rest. When the variable is unset or not positive, outputs are returned
+// unchanged.
+package output
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// EnvMaxResponseBytes is the per-field response size limit in bytes
+const EnvMaxResponseBytes = "MCP_MAX_RESPONSE_BYTES"
+
+// MaxBytes returns the configured response size limit, or 0 if unlimited
+func MaxBytes() int {
+	value := strings.TrimSpace(os.Getenv(EnvMaxResponseBytes))
+	if value == "" {
+		return 0
+	}
+	limit, err := strconv.Atoi(value)
+	if err != nil || limit < 0 {
+		log.Printf("Warning: invalid %s %q, responses are not truncated", EnvMaxResponseBytes, value)
+		return 0
+	}
+	return limit
+}
+
+// Truncate keeps the beginning of s within the configured limit. hint is
+// appended to the marker and should tell the caller how to get the rest
+// (e.g. which offset to pass). The returned bool reports whether s was cut.
+func Truncate(s, hint string) (string, bool) {
+	return TruncateTo(s, MaxBytes(), hint)
+}
+
+// TruncateTail keeps the end of s within the configured limit, for outputs
+// such as logs where the most recent data matters most
+func TruncateTail(s, hint string) (string, bool) {
+	return TruncateTailTo(s, MaxBytes(), hint)
+}
+
+// TruncateTo is like Truncate with an explicit limit; limit <= 0 disables it
+func TruncateTo(s string, limit int, hint string) (string, bool) {
+	kept, ok := Head(s, limit)
+	if !ok {
+		return s, false
+	}
+	return kept + "\n" + marker(len(s)-len(kept), len(s), hint), true
+}
+
+// TruncateTailTo is like TruncateTail with an explicit limit; limit <= 0
+// disables it
+func TruncateTailTo(s string, limit int, hint string) (string, bool) {
+	if limit <= 0 || len(s) <= limit {
+		return s, false
+	}
+	start := len(s) - limit
+	for start < len(s) && !utf8.RuneStart(s[start]) {
+		start++
+	}
+	return marker(start, len(s), hint) + "\n" + s[start:], true
+}
+
+// Head returns the longest prefix of s that fits in limit bytes without
+// splitting a UTF-8 sequence, and whether anything was dropped. Callers that
+// track byte offsets can use len of the result to compute where to resume.
+func Head(s string, limit int) (string, bool) {
+	if limit <= 0 || len(s) <= limit {
+		return s, false
+	}
+	end := limit
+	for end > 0 && !utf8.RuneStart(s[end]) {
+		end--
+	}
+	return s[:end], true
+}
+
+func marker(dropped, total int, hint string) string {
+	msg := fmt.Sprintf("[output truncated: %d of %d bytes omitted", dropped, total)
+	if hint != "" {
+		msg += "; " + hint
+	}
+	return msg + "]"
+}
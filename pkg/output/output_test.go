@@ -0,0 +1,67 @@
+package output
+
+import (
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Output", func() {
+	Context("MaxBytes", func() {
+		It("should be unlimited when unset", func() {
+			GinkgoT().Setenv(EnvMaxResponseBytes, "")
+			Expect(MaxBytes()).To(Equal(0))
+		})
+
+		It("should read the configured limit", func() {
+			GinkgoT().Setenv(EnvMaxResponseBytes, "1024")
+			Expect(MaxBytes()).To(Equal(1024))
+		})
+
+		It("should ignore invalid values", func() {
+			GinkgoT().Setenv(EnvMaxResponseBytes, "lots")
+			Expect(MaxBytes()).To(Equal(0))
+		})
+	})
+
+	Context("TruncateTo", func() {
+		It("should leave short output untouched", func() {
+			out, truncated := TruncateTo("hello", 10, "")
+			Expect(truncated).To(BeFalse())
+			Expect(out).To(Equal("hello"))
+		})
+
+		It("should not truncate when the limit is disabled", func() {
+			out, truncated := TruncateTo(strings.Repeat("a", 100), 0, "")
+			Expect(truncated).To(BeFalse())
+			Expect(out).To(HaveLen(100))
+		})
+
+		It("should keep the head and append a marker with the hint", func() {
+			out, truncated := TruncateTo("0123456789", 4, "use offset=4")
+			Expect(truncated).To(BeTrue())
+			Expect(out).To(Equal("0123\n[output truncated: 6 of 10 bytes omitted; use offset=4]"))
+		})
+
+		It("should not split multi-byte characters", func() {
+			out, truncated := TruncateTo("aé", 2, "")
+			Expect(truncated).To(BeTrue())
+			Expect(out).To(HavePrefix("a\n"))
+		})
+	})
+
+	Context("TruncateTailTo", func() {
+		It("should keep the tail and prepend a marker", func() {
+			out, truncated := TruncateTailTo("0123456789", 4, "")
+			Expect(truncated).To(BeTrue())
+			Expect(out).To(Equal("[output truncated: 6 of 10 bytes omitted]\n6789"))
+		})
+
+		It("should not split multi-byte characters", func() {
+			out, truncated := TruncateTailTo("éa", 2, "")
+			Expect(truncated).To(BeTrue())
+			Expect(out).To(HaveSuffix("\na"))
+		})
+	})
+})
@@ -0,0 +1,51 @@
+// Package resources exposes the state of a server as read-only MCP
+// resources, so clients can list and read it natively instead of calling a
+// list tool.
+//
+// Resources are rendered as JSON each time they are read, so they always
+// reflect the current state.
+package resources
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// MIMEType is the content type of the resources
+const MIMEType = "application/json"
+
+// ReadFunc returns the value a resource is rendered from
+type ReadFunc func(ctx context.Context) (any, error)
+
+// AddJSON registers a read-only resource whose content is the indented JSON
+// encoding of the value returned by read
+func AddJSON(server *mcp.Server, resource *mcp.Resource, read ReadFunc) {
+	if resource.MIMEType == "" {
+		resource.MIMEType = MIMEType
+	}
+	server.AddResource(resource, Handler(resource.MIMEType, read))
+}
+
+// Handler returns the resource handler rendering read as JSON
+func Handler(mimeType string, read ReadFunc) mcp.ResourceHandler {
+	return func(ctx context.Context, req *mcp.ReadResourceRequest) (*mcp.ReadResourceResult, error) {
+		value, err := read(ctx)
+		if err != nil {
+			return nil, err
+		}
+		data, err := json.MarshalIndent(value, "", "  ")
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode %s: %w", req.Params.URI, err)
+		}
+		return &mcp.ReadResourceResult{
+			Contents: []*mcp.ResourceContents{{
+				URI:      req.Params.URI,
+				MIMEType: mimeType,
+				Text:     string(data),
+			}},
+		}, nil
+	}
+}
@@ -0,0 +1,68 @@
+package resources
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Resources", func() {
+	var session *mcp.ClientSession
+	var items []string
+
+	BeforeEach(func() {
+		items = []string{"first"}
+		server := mcp.NewServer(&mcp.Implementation{Name: "test", Version: "v1.0.0"}, nil)
+		AddJSON(server, &mcp.Resource{URI: "test://items", Name: "items"}, func(ctx context.Context) (any, error) {
+			return map[string]any{"items": items, "count": len(items)}, nil
+		})
+		AddJSON(server, &mcp.Resource{URI: "test://broken", Name: "broken"}, func(ctx context.Context) (any, error) {
+			return nil, errors.New("storage unavailable")
+		})
+
+		ctx := context.Background()
+		serverTransport, clientTransport := mcp.NewInMemoryTransports()
+		serverSession, err := server.Connect(ctx, serverTransport, nil)
+		Expect(err).ToNot(HaveOccurred())
+		DeferCleanup(serverSession.Close)
+
+		client := mcp.NewClient(&mcp.Implementation{Name: "client", Version: "v1.0.0"}, nil)
+		session, err = client.Connect(ctx, clientTransport, nil)
+		Expect(err).ToNot(HaveOccurred())
+		DeferCleanup(session.Close)
+	})
+
+	It("should list the resources as JSON", func() {
+		result, err := session.ListResources(context.Background(), nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result.Resources).To(HaveLen(2))
+		for _, resource := range result.Resources {
+			Expect(resource.MIMEType).To(Equal(MIMEType))
+		}
+	})
+
+	It("should render the current value on every read", func() {
+		read := func() map[string]any {
+			result, err := session.ReadResource(context.Background(), &mcp.ReadResourceParams{URI: "test://items"})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result.Contents).To(HaveLen(1))
+			Expect(result.Contents[0].URI).To(Equal("test://items"))
+			var value map[string]any
+			Expect(json.Unmarshal([]byte(result.Contents[0].Text), &value)).To(Succeed())
+			return value
+		}
+
+		Expect(read()["count"]).To(BeEquivalentTo(1))
+		items = append(items, "second")
+		Expect(read()["items"]).To(Equal([]any{"first", "second"}))
+	})
+
+	It("should fail the read when the value cannot be read", func() {
+		_, err := session.ReadResource(context.Background(), &mcp.ReadResourceParams{URI: "test://broken"})
+		Expect(err).To(MatchError(ContainSubstring("storage unavailable")))
+	})
+})
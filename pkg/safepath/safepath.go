@@ -0,0 +1,138 @@
+// Package safepath validates file paths received in tool inputs.
+//
+// A Guard rejects empty paths and paths containing NUL bytes, and returns
+// absolute, cleaned paths. When it has a root, paths must stay under it once
+// ".." and symlinks are resolved, so a tool cannot be pointed at arbitrary
+// files. Paths that do not exist yet, such as files about to be written, are
+// checked through their closest existing parent.
+package safepath
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ErrOutsideRoot is returned for paths resolving outside the root of a Guard
+var ErrOutsideRoot = errors.New("path outside allowed root")
+
+// Guard validates paths, confining them to Root when it is set
+type Guard struct {
+	// Root is the absolute directory paths must be under, with its symlinks
+	// resolved. Paths are not confined when it is empty.
+	Root string
+}
+
+// New returns a Guard confining paths to root. An empty root returns a Guard
+// that only validates paths.
+func New(root string) (Guard, error) {
+	if root == "" {
+		return Guard{}, nil
+	}
+	abs, err := filepath.Abs(root)
+	if err != nil {
+		return Guard{}, err
+	}
+	real, err := filepath.EvalSymlinks(abs)
+	if err != nil {
+		return Guard{}, err
+	}
+	info, err := os.Stat(real)
+	if err != nil {
+		return Guard{}, err
+	}
+	if !info.IsDir() {
+		return Guard{}, fmt.Errorf("%s is not a directory", root)
+	}
+	return Guard{Root: real}, nil
+}
+
+// Clean rejects empty paths and paths containing NUL bytes, and returns the
+// absolute, cleaned form of path. Relative paths are resolved against the
+// working directory of the process.
+func Clean(path string) (string, error) {
+	if strings.TrimSpace(path) == "" {
+		return "", errors.New("empty path")
+	}
+	if strings.ContainsRune(path, 0) {
+		return "", fmt.Errorf("path %q contains a NUL byte", path)
+	}
+	return filepath.Abs(path)
+}
+
+// Resolve validates path and returns its absolute form. With a root, the
+// path must be under it once its symlinks are followed.
+func (g Guard) Resolve(path string) (string, error) {
+	abs, err := Clean(path)
+	if err != nil {
+		return "", err
+	}
+	if g.Root == "" {
+		return abs, nil
+	}
+	real, err := realPath(abs)
+	if err != nil {
+		return "", err
+	}
+	if !Within(g.Root, real) {
+		return "", fmt.Errorf("%s: %w (%s)", path, ErrOutsideRoot, g.Root)
+	}
+	return abs, nil
+}
+
+// ResolveLink is Resolve for operations on the path itself rather than what
+// it points to, such as reading or creating a symlink: only the parent
+// directory has its symlinks followed.
+func (g Guard) ResolveLink(path string) (string, error) {
+	abs, err := Clean(path)
+	if err != nil {
+		return "", err
+	}
+	if g.Root == "" {
+		return abs, nil
+	}
+	parent, err := realPath(filepath.Dir(abs))
+	if err != nil {
+		return "", err
+	}
+	if !Within(g.Root, filepath.Join(parent, filepath.Base(abs))) {
+		return "", fmt.Errorf("%s: %w (%s)", path, ErrOutsideRoot, g.Root)
+	}
+	return abs, nil
+}
+
+// Within reports whether path is root or under it. Both must be absolute and
+// clean.
+func Within(root, path string) bool {
+	rel, err := filepath.Rel(root, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// realPath resolves the symlinks of abs. When abs does not exist, those of
+// its closest existing parent are resolved and the rest is appended. Dangling
+// symlinks are followed to their target, which writing to them would create.
+func realPath(abs string) (string, error) {
+	var missing []string
+	for dir := abs; ; dir = filepath.Dir(dir) {
+		real, err := filepath.EvalSymlinks(dir)
+		if err == nil {
+			return filepath.Join(append([]string{real}, missing...)...), nil
+		}
+		if !errors.Is(err, os.ErrNotExist) || filepath.Dir(dir) == dir {
+			return "", err
+		}
+		if target, err := os.Readlink(dir); err == nil {
+			if !filepath.IsAbs(target) {
+				target = filepath.Join(filepath.Dir(dir), target)
+			}
+			real, err := realPath(filepath.Clean(target))
+			if err != nil {
+				return "", err
+			}
+			return filepath.Join(append([]string{real}, missing...)...), nil
+		}
+		missing = append([]string{filepath.Base(dir)}, missing...)
+	}
+}
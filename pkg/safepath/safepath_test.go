@@ -0,0 +1,110 @@
+package safepath
+
+import (
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Safepath", func() {
+	var root, outside string
+
+	BeforeEach(func() {
+		base := GinkgoT().TempDir()
+		root, outside = filepath.Join(base, "root"), filepath.Join(base, "outside")
+		Expect(os.MkdirAll(filepath.Join(root, "sub"), 0755)).To(Succeed())
+		Expect(os.MkdirAll(outside, 0755)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(root, "sub", "file.txt"), []byte("in"), 0644)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(outside, "secret.txt"), []byte("out"), 0644)).To(Succeed())
+	})
+
+	Context("Clean", func() {
+		It("should return absolute, cleaned paths", func() {
+			Expect(Clean("/tmp/a/../b")).To(Equal("/tmp/b"))
+			wd, err := os.Getwd()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(Clean("a.txt")).To(Equal(filepath.Join(wd, "a.txt")))
+		})
+
+		It("should reject empty paths and NUL bytes", func() {
+			_, err := Clean(" ")
+			Expect(err).To(MatchError(ContainSubstring("empty path")))
+			_, err = Clean("/tmp/a\x00.txt")
+			Expect(err).To(MatchError(ContainSubstring("NUL byte")))
+		})
+	})
+
+	Context("New", func() {
+		It("should not confine paths without a root", func() {
+			guard, err := New("")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(guard.Resolve(filepath.Join(outside, "secret.txt"))).To(Equal(filepath.Join(outside, "secret.txt")))
+		})
+
+		It("should reject roots that are not directories", func() {
+			_, err := New(filepath.Join(root, "sub", "file.txt"))
+			Expect(err).To(MatchError(ContainSubstring("not a directory")))
+			_, err = New(filepath.Join(root, "missing"))
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Context("Resolve", func() {
+		var guard Guard
+
+		BeforeEach(func() {
+			var err error
+			guard, err = New(root)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("should accept paths under the root, existing or not", func() {
+			Expect(guard.Resolve(filepath.Join(root, "sub", "file.txt"))).To(Equal(filepath.Join(root, "sub", "file.txt")))
+			Expect(guard.Resolve(filepath.Join(root, "new", "dir", "file.txt"))).To(Equal(filepath.Join(root, "new", "dir", "file.txt")))
+			Expect(guard.Resolve(root)).To(Equal(root))
+		})
+
+		It("should reject .. escaping the root", func() {
+			_, err := guard.Resolve(filepath.Join(root, "sub", "..", "..", "outside", "secret.txt"))
+			Expect(err).To(MatchError(ErrOutsideRoot))
+		})
+
+		It("should reject symlinks pointing outside the root", func() {
+			Expect(os.Symlink(outside, filepath.Join(root, "escape"))).To(Succeed())
+			_, err := guard.Resolve(filepath.Join(root, "escape", "secret.txt"))
+			Expect(err).To(MatchError(ErrOutsideRoot))
+			_, err = guard.Resolve(filepath.Join(root, "escape", "new.txt"))
+			Expect(err).To(MatchError(ErrOutsideRoot))
+		})
+
+		It("should reject dangling symlinks pointing outside the root", func() {
+			Expect(os.Symlink(filepath.Join(outside, "created.txt"), filepath.Join(root, "dangling"))).To(Succeed())
+			_, err := guard.Resolve(filepath.Join(root, "dangling"))
+			Expect(err).To(MatchError(ErrOutsideRoot))
+		})
+
+		It("should accept symlinks staying under the root", func() {
+			Expect(os.Symlink(filepath.Join(root, "sub"), filepath.Join(root, "alias"))).To(Succeed())
+			Expect(guard.Resolve(filepath.Join(root, "alias", "file.txt"))).To(Equal(filepath.Join(root, "alias", "file.txt")))
+		})
+
+		It("should not confuse siblings sharing the root's prefix", func() {
+			Expect(os.MkdirAll(root+"2", 0755)).To(Succeed())
+			_, err := guard.Resolve(filepath.Join(root+"2", "file.txt"))
+			Expect(err).To(MatchError(ErrOutsideRoot))
+		})
+	})
+
+	Context("ResolveLink", func() {
+		It("should check the link itself, not its target", func() {
+			guard, err := New(root)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(os.Symlink(outside, filepath.Join(root, "escape"))).To(Succeed())
+			Expect(guard.ResolveLink(filepath.Join(root, "escape"))).To(Equal(filepath.Join(root, "escape")))
+			_, err = guard.ResolveLink(filepath.Join(root, "escape", "secret.txt"))
+			Expect(err).To(MatchError(ErrOutsideRoot))
+		})
+	})
+})
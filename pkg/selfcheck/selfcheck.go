@@ -0,0 +1,304 @@
+// Package selfcheck implements the preflight mode shared by all servers.
+//
+// Starting a server with --check, or with MCP_SELFCHECK=true, validates its
+// configuration and backend connectivity instead of serving MCP: every check
+// runs, a JSON report is written to stderr and the process exits with status
+// 0 when no check failed and 1 otherwise.
+package selfcheck
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mudler/mcps/pkg/transport"
+)
+
+const (
+	// EnvSelfCheck enables the self-check mode when true
+	EnvSelfCheck = "MCP_SELFCHECK"
+	// Flag enables the self-check mode when passed as a command line argument
+	Flag = "--check"
+
+	// checkTimeout bounds each check, so an unreachable backend does not
+	// stall the report
+	checkTimeout = 15 * time.Second
+)
+
+// Status is the outcome of a check
+type Status string
+
+const (
+	StatusOK   Status = "ok"
+	StatusWarn Status = "warn"
+	StatusFail Status = "fail"
+)
+
+// Check is a single preflight step. Run returns a short description of what
+// was found, or an error when the check failed. Errors wrapped with Warn are
+// reported without failing the self-check.
+type Check struct {
+	Name string
+	Run  func(ctx context.Context) (string, error)
+}
+
+// Result is the outcome of a Check
+type Result struct {
+	Name   string `json:"name"`
+	Status Status `json:"status"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// Report is written to stderr in self-check mode
+type Report struct {
+	Server string   `json:"server"`
+	OK     bool     `json:"ok"`
+	Checks []Result `json:"checks"`
+}
+
+type warning struct{ err error }
+
+func (w warning) Error() string { return w.err.Error() }
+func (w warning) Unwrap() error { return w.err }
+
+// Warn marks err as a warning: the check is reported as "warn" and does not
+// fail the self-check. Warn(nil) is nil.
+func Warn(err error) error {
+	if err == nil {
+		return nil
+	}
+	return warning{err: err}
+}
+
+// Enabled reports whether the server was started with --check or
+// MCP_SELFCHECK=true
+func Enabled() bool {
+	for _, arg := range os.Args[1:] {
+		if arg == Flag || arg == "-check" {
+			return true
+		}
+	}
+	value := strings.TrimSpace(os.Getenv(EnvSelfCheck))
+	if value == "" {
+		return false
+	}
+	enabled, err := strconv.ParseBool(value)
+	if err != nil {
+		log.Printf("Warning: invalid %s %q, self-check disabled", EnvSelfCheck, value)
+		return false
+	}
+	return enabled
+}
+
+// Run executes the transport check followed by checks, in order, and
+// returns the report
+func Run(ctx context.Context, server string, checks ...Check) Report {
+	report := Report{Server: server, OK: true, Checks: []Result{}}
+	for _, check := range append([]Check{transportCheck()}, checks...) {
+		result := runCheck(ctx, check)
+		if result.Status == StatusFail {
+			report.OK = false
+		}
+		report.Checks = append(report.Checks, result)
+	}
+	return report
+}
+
+func runCheck(ctx context.Context, check Check) (result Result) {
+	result = Result{Name: check.Name, Status: StatusOK}
+	defer func() {
+		if r := recover(); r != nil {
+			result.Status = StatusFail
+			result.Detail = fmt.Sprintf("panic: %v", r)
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(ctx, checkTimeout)
+	defer cancel()
+	detail, err := check.Run(ctx)
+	var w warning
+	switch {
+	case errors.As(err, &w):
+		result.Status = StatusWarn
+		result.Detail = err.Error()
+	case err != nil:
+		result.Status = StatusFail
+		result.Detail = err.Error()
+	default:
+		result.Detail = detail
+	}
+	return result
+}
+
+// Write encodes the report as indented JSON
+func (r Report) Write(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(r)
+}
+
+// Exit runs the checks, writes the report to stderr and exits, with status
+// 1 if any check failed. It never returns.
+func Exit(server string, checks ...Check) {
+	report := Run(context.Background(), server, checks...)
+	if err := report.Write(os.Stderr); err != nil {
+		log.Printf("Failed to write self-check report: %v", err)
+	}
+	if !report.OK {
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
+
+// transportCheck validates the MCP transport settings every server uses
+func transportCheck() Check {
+	return Check{Name: transport.EnvTransport, Run: func(context.Context) (string, error) {
+		mode := strings.ToLower(strings.TrimSpace(os.Getenv(transport.EnvTransport)))
+		switch mode {
+		case "", "stdio":
+			return "stdio", nil
+		case "http":
+			addr := os.Getenv(transport.EnvHTTPAddr)
+			if addr == "" {
+				addr = ":8080"
+			}
+			if os.Getenv(transport.EnvAuthToken) == "" {
+				return "", Warn(fmt.Errorf("http on %s without %s, the endpoint is unauthenticated", addr, transport.EnvAuthToken))
+			}
+			return "http on " + addr, nil
+		}
+		return "", fmt.Errorf("unknown value %q (expected stdio or http)", mode)
+	}}
+}
+
+// Env checks that a required environment variable is set
+func Env(name string) Check {
+	return Check{Name: name, Run: func(context.Context) (string, error) {
+		if os.Getenv(name) == "" {
+			return "", fmt.Errorf("%s is required", name)
+		}
+		return "set", nil
+	}}
+}
+
+// Value reports a configuration value, failing with err when it is invalid.
+// It is used for settings parsed by the server before the checks run.
+func Value(name, value string, err error) Check {
+	return Check{Name: name, Run: func(context.Context) (string, error) {
+		if err != nil {
+			return "", err
+		}
+		return value, nil
+	}}
+}
+
+// Dir checks that dir exists, or can be created, and that files can be
+// written in it
+func Dir(name, dir string) Check {
+	return Check{Name: name, Run: func(context.Context) (string, error) {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return "", fmt.Errorf("cannot create %s: %w", dir, err)
+		}
+		file, err := os.CreateTemp(dir, ".selfcheck-*")
+		if err != nil {
+			return "", fmt.Errorf("%s is not writable: %w", dir, err)
+		}
+		file.Close()
+		os.Remove(file.Name())
+		return dir + " is writable", nil
+	}}
+}
+
+// File checks that the file at path can be written, creating its directory
+// if needed. An existing file is left untouched.
+func File(name, path string) Check {
+	check := Dir(name, filepath.Dir(path))
+	return Check{Name: name, Run: func(ctx context.Context) (string, error) {
+		if _, err := check.Run(ctx); err != nil {
+			return "", err
+		}
+		if info, err := os.Stat(path); err == nil {
+			if info.IsDir() {
+				return "", fmt.Errorf("%s is a directory", path)
+			}
+			file, err := os.OpenFile(path, os.O_WRONLY, 0)
+			if err != nil {
+				return "", fmt.Errorf("%s is not writable: %w", path, err)
+			}
+			file.Close()
+			return path + " exists and is writable", nil
+		}
+		return path + " will be created", nil
+	}}
+}
+
+// Command checks that an executable is available on PATH
+func Command(name string) Check {
+	return Check{Name: "command " + name, Run: func(context.Context) (string, error) {
+		path, err := exec.LookPath(name)
+		if err != nil {
+			return "", fmt.Errorf("%s not found in PATH", name)
+		}
+		return path, nil
+	}}
+}
+
+// HTTP checks that url answers a GET request with a non-error status, using
+// client and the given headers. The query string, which may hold an API key,
+// is left out of the report.
+func HTTP(name string, client *http.Client, rawURL string, header http.Header) Check {
+	return Check{Name: name, Run: func(ctx context.Context) (string, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+		if err != nil {
+			return "", err
+		}
+		for key, values := range header {
+			req.Header[key] = values
+		}
+		shown := *req.URL
+		shown.RawQuery = ""
+		resp, err := client.Do(req)
+		if err != nil {
+			// The client error repeats the full URL
+			var urlErr *url.Error
+			if errors.As(err, &urlErr) {
+				err = urlErr.Err
+			}
+			return "", fmt.Errorf("GET %s: %w", shown.String(), err)
+		}
+		defer resp.Body.Close()
+		io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<20))
+		if resp.StatusCode >= 400 {
+			return "", fmt.Errorf("GET %s returned %s", shown.String(), resp.Status)
+		}
+		return fmt.Sprintf("GET %s returned %s", shown.String(), resp.Status), nil
+	}}
+}
+
+// Optional downgrades the failures of check to warnings, for features the
+// server can run without
+func Optional(check Check) Check {
+	return Check{Name: check.Name, Run: func(ctx context.Context) (string, error) {
+		detail, err := check.Run(ctx)
+		return detail, Warn(err)
+	}}
+}
+
+// Skipped reports a check that does not apply, e.g. backend connectivity in
+// mock mode
+func Skipped(name, reason string) Check {
+	return Check{Name: name, Run: func(context.Context) (string, error) {
+		return "skipped: " + reason, nil
+	}}
+}
@@ -0,0 +1,13 @@
+package selfcheck_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestSelfCheck(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "SelfCheck Suite")
+}
@@ -0,0 +1,126 @@
+package selfcheck_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+
+	"github.com/mudler/mcps/pkg/selfcheck"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("SelfCheck", func() {
+	BeforeEach(func() {
+		GinkgoT().Setenv("MCP_TRANSPORT", "")
+	})
+
+	Context("Enabled", func() {
+		It("should be off by default", func() {
+			GinkgoT().Setenv(selfcheck.EnvSelfCheck, "")
+			Expect(selfcheck.Enabled()).To(BeFalse())
+		})
+
+		It("should read MCP_SELFCHECK", func() {
+			GinkgoT().Setenv(selfcheck.EnvSelfCheck, "true")
+			Expect(selfcheck.Enabled()).To(BeTrue())
+		})
+
+		It("should accept the --check flag", func() {
+			GinkgoT().Setenv(selfcheck.EnvSelfCheck, "")
+			args := os.Args
+			DeferCleanup(func() { os.Args = args })
+			os.Args = []string{"server", "--check"}
+			Expect(selfcheck.Enabled()).To(BeTrue())
+		})
+	})
+
+	Context("Run", func() {
+		It("should report each check with the transport first", func() {
+			report := selfcheck.Run(context.Background(), "example",
+				selfcheck.Check{Name: "good", Run: func(context.Context) (string, error) { return "fine", nil }},
+				selfcheck.Check{Name: "soft", Run: func(context.Context) (string, error) { return "", selfcheck.Warn(errors.New("meh")) }},
+			)
+			Expect(report.OK).To(BeTrue())
+			Expect(report.Checks).To(HaveLen(3))
+			Expect(report.Checks[0]).To(Equal(selfcheck.Result{Name: "MCP_TRANSPORT", Status: selfcheck.StatusOK, Detail: "stdio"}))
+			Expect(report.Checks[1]).To(Equal(selfcheck.Result{Name: "good", Status: selfcheck.StatusOK, Detail: "fine"}))
+			Expect(report.Checks[2]).To(Equal(selfcheck.Result{Name: "soft", Status: selfcheck.StatusWarn, Detail: "meh"}))
+		})
+
+		It("should fail on errors and panics", func() {
+			report := selfcheck.Run(context.Background(), "example",
+				selfcheck.Check{Name: "bad", Run: func(context.Context) (string, error) { return "", errors.New("broken") }},
+				selfcheck.Check{Name: "panics", Run: func(context.Context) (string, error) { panic("boom") }},
+			)
+			Expect(report.OK).To(BeFalse())
+			Expect(report.Checks[1].Status).To(Equal(selfcheck.StatusFail))
+			Expect(report.Checks[2].Status).To(Equal(selfcheck.StatusFail))
+			Expect(report.Checks[2].Detail).To(ContainSubstring("boom"))
+		})
+
+		It("should downgrade optional checks to warnings", func() {
+			report := selfcheck.Run(context.Background(), "example",
+				selfcheck.Optional(selfcheck.Check{Name: "extra", Run: func(context.Context) (string, error) { return "", errors.New("missing") }}),
+			)
+			Expect(report.OK).To(BeTrue())
+			Expect(report.Checks[1]).To(Equal(selfcheck.Result{Name: "extra", Status: selfcheck.StatusWarn, Detail: "missing"}))
+		})
+
+		It("should fail on an unknown transport", func() {
+			GinkgoT().Setenv("MCP_TRANSPORT", "carrier-pigeon")
+			report := selfcheck.Run(context.Background(), "example")
+			Expect(report.OK).To(BeFalse())
+		})
+
+		It("should write the report as JSON", func() {
+			var buf bytes.Buffer
+			Expect(selfcheck.Run(context.Background(), "example").Write(&buf)).To(Succeed())
+			Expect(buf.String()).To(ContainSubstring(`"server": "example"`))
+			Expect(buf.String()).To(ContainSubstring(`"ok": true`))
+		})
+	})
+
+	Context("checks", func() {
+		It("should require environment variables", func() {
+			GinkgoT().Setenv("SELFCHECK_EXAMPLE", "")
+			_, err := selfcheck.Env("SELFCHECK_EXAMPLE").Run(context.Background())
+			Expect(err).To(HaveOccurred())
+			GinkgoT().Setenv("SELFCHECK_EXAMPLE", "x")
+			_, err = selfcheck.Env("SELFCHECK_EXAMPLE").Run(context.Background())
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("should create and probe directories", func() {
+			dir := filepath.Join(GinkgoT().TempDir(), "nested", "dir")
+			_, err := selfcheck.Dir("dir", dir).Run(context.Background())
+			Expect(err).NotTo(HaveOccurred())
+			entries, err := os.ReadDir(dir)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(entries).To(BeEmpty())
+		})
+
+		It("should reject a directory where a file is expected", func() {
+			_, err := selfcheck.File("file", GinkgoT().TempDir()).Run(context.Background())
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("should check HTTP endpoints", func() {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Header.Get("Authorization") != "Bearer secret" {
+					w.WriteHeader(http.StatusUnauthorized)
+				}
+			}))
+			defer server.Close()
+
+			_, err := selfcheck.HTTP("api", server.Client(), server.URL, nil).Run(context.Background())
+			Expect(err).To(MatchError(ContainSubstring("401")))
+			_, err = selfcheck.HTTP("api", server.Client(), server.URL, http.Header{"Authorization": {"Bearer secret"}}).Run(context.Background())
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
+})
@@ -0,0 +1,108 @@
+// Package transport selects how an MCP server is exposed to clients.
+//
+// By default servers speak MCP over stdio. Setting MCP_TRANSPORT=http serves
+// the streamable HTTP transport on MCP_HTTP_ADDR instead; in that mode
+// MCP_AUTH_TOKEN, when set, must be presented as a bearer token on every
+// request.
+//
+// Run also installs the tool metrics of package metrics when MCP_METRICS is
+// enabled, so every server gets them without extra wiring.
+package transport
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/mudler/mcps/pkg/metrics"
+)
+
+const (
+	// EnvTransport selects the transport: "stdio" (default) or "http"
+	EnvTransport = "MCP_TRANSPORT"
+	// EnvHTTPAddr is the listen address used in HTTP mode
+	EnvHTTPAddr = "MCP_HTTP_ADDR"
+	// EnvAuthToken is the bearer token required in HTTP mode, if set
+	EnvAuthToken = "MCP_AUTH_TOKEN"
+
+	defaultHTTPAddr = ":8080"
+)
+
+// Run serves the MCP server on the transport selected by MCP_TRANSPORT and
+// blocks until the client disconnects or ctx is cancelled
+func Run(ctx context.Context, server *mcp.Server) error {
+	mode := strings.ToLower(strings.TrimSpace(os.Getenv(EnvTransport)))
+	if metrics.Enabled() {
+		metrics.Register(server)
+	}
+	switch mode {
+	case "", "stdio":
+		return server.Run(ctx, &mcp.StdioTransport{})
+	case "http":
+		addr := os.Getenv(EnvHTTPAddr)
+		if addr == "" {
+			addr = defaultHTTPAddr
+		}
+		return RunHTTP(ctx, server, addr, os.Getenv(EnvAuthToken))
+	default:
+		return fmt.Errorf("unknown %s %q (expected stdio or http)", EnvTransport, mode)
+	}
+}
+
+// RunHTTP serves the MCP server over streamable HTTP on addr. When token is
+// not empty, requests without a matching bearer token are rejected.
+func RunHTTP(ctx context.Context, server *mcp.Server, addr, token string) error {
+	var handler http.Handler = mcp.NewStreamableHTTPHandler(func(*http.Request) *mcp.Server {
+		return server
+	}, nil)
+	if token != "" {
+		handler = RequireBearerToken(token, handler)
+	} else {
+		log.Printf("Warning: %s is not set, the HTTP transport on %s is unauthenticated", EnvAuthToken, addr)
+	}
+
+	httpServer := &http.Server{
+		Addr:              addr,
+		Handler:           handler,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- httpServer.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errCh:
+		if errors.Is(err, http.ErrServerClosed) {
+			return nil
+		}
+		return err
+	case <-ctx.Done():
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		return httpServer.Shutdown(shutdownCtx)
+	}
+}
+
+// RequireBearerToken wraps next so that only requests carrying
+// "Authorization: Bearer <token>" are served; all others get a 401
+func RequireBearerToken(token string, next http.Handler) http.Handler {
+	expected := []byte(token)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(strings.TrimSpace(got)), expected) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="mcp"`)
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
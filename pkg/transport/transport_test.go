@@ -0,0 +1,59 @@
+package transport
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Transport", func() {
+	Context("RequireBearerToken", func() {
+		var handler http.Handler
+
+		BeforeEach(func() {
+			handler = RequireBearerToken("secret", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			}))
+		})
+
+		serve := func(authorization string) *httptest.ResponseRecorder {
+			req := httptest.NewRequest(http.MethodPost, "/", nil)
+			if authorization != "" {
+				req.Header.Set("Authorization", authorization)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			return rec
+		}
+
+		It("should accept a matching bearer token", func() {
+			Expect(serve("Bearer secret").Code).To(Equal(http.StatusOK))
+		})
+
+		It("should reject a missing token", func() {
+			rec := serve("")
+			Expect(rec.Code).To(Equal(http.StatusUnauthorized))
+			Expect(rec.Header().Get("WWW-Authenticate")).To(ContainSubstring("Bearer"))
+		})
+
+		It("should reject a wrong token", func() {
+			Expect(serve("Bearer nope").Code).To(Equal(http.StatusUnauthorized))
+		})
+
+		It("should reject other authorization schemes", func() {
+			Expect(serve("Basic secret").Code).To(Equal(http.StatusUnauthorized))
+		})
+	})
+
+	Context("Run", func() {
+		It("should reject an unknown transport", func() {
+			GinkgoT().Setenv(EnvTransport, "carrier-pigeon")
+			server := mcp.NewServer(&mcp.Implementation{Name: "test", Version: "v1.0.0"}, nil)
+			Expect(Run(context.Background(), server)).To(MatchError(ContainSubstring("unknown MCP_TRANSPORT")))
+		})
+	})
+})
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultAuditMaxSizeMB is the size of the audit log above which it is
+// rotated
+const defaultAuditMaxSizeMB = 10
+
+// redacted replaces redacted arguments in the audit log
+const redacted = "[REDACTED]"
+
+// auditRecord is one invocation of an executor in the audit log
+type auditRecord struct {
+	Timestamp time.Time `json:"timestamp"`
+	Executor  string    `json:"executor"`
+	Args      []string  `json:"args"`
+	RunID     string    `json:"run_id,omitempty"`
+	// Status is completed, failed or error for calls that did not run, and
+	// the final status of background runs
+	Status     string `json:"status"`
+	ExitCode   int    `json:"exit_code"`
+	Error      string `json:"error,omitempty"`
+	DurationMs int64  `json:"duration_ms"`
+}
+
+// auditLog appends a JSON line per invocation to a file, moving it to
+// path.1 once it grows over maxBytes
+type auditLog struct {
+	path     string
+	maxBytes int64
+	redact   []*regexp.Regexp
+	mutex    sync.Mutex
+}
+
+// audit is nil unless SCRIPTS_AUDIT_LOG is set
+var audit *auditLog
+
+// newAuditLogFromEnv configures the audit log from SCRIPTS_AUDIT_LOG,
+// SCRIPTS_AUDIT_MAX_SIZE_MB and SCRIPTS_AUDIT_REDACT. It returns nil when
+// auditing is disabled.
+func newAuditLogFromEnv() (*auditLog, error) {
+	path := os.Getenv("SCRIPTS_AUDIT_LOG")
+	if path == "" {
+		return nil, nil
+	}
+	a := &auditLog{path: path, maxBytes: defaultAuditMaxSizeMB << 20}
+
+	if value := strings.TrimSpace(os.Getenv("SCRIPTS_AUDIT_MAX_SIZE_MB")); value != "" {
+		size, err := strconv.Atoi(value)
+		if err != nil || size < 0 {
+			return nil, fmt.Errorf("invalid SCRIPTS_AUDIT_MAX_SIZE_MB %q: expected a number of MB, 0 to disable rotation", value)
+		}
+		a.maxBytes = int64(size) << 20
+	}
+
+	for _, pattern := range strings.Split(os.Getenv("SCRIPTS_AUDIT_REDACT"), ",") {
+		if pattern = strings.TrimSpace(pattern); pattern == "" {
+			continue
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid SCRIPTS_AUDIT_REDACT pattern %q: %w", pattern, err)
+		}
+		a.redact = append(a.redact, re)
+	}
+	return a, nil
+}
+
+// redactArgs hides the arguments at the executor's redact_args positions and
+// the parts of the others matching SCRIPTS_AUDIT_REDACT
+func (a *auditLog) redactArgs(config ExecutorConfig, args []string) []string {
+	result := make([]string, len(args))
+	for i, arg := range args {
+		if slices.Contains(config.RedactArgs, i) {
+			result[i] = redacted
+			continue
+		}
+		for _, re := range a.redact {
+			arg = re.ReplaceAllString(arg, redacted)
+		}
+		result[i] = arg
+	}
+	return result
+}
+
+// record logs an invocation of config that started at started. It does
+// nothing when auditing is disabled, and failures are only logged so they
+// never fail the call.
+func (a *auditLog) record(config ExecutorConfig, args []string, started time.Time, entry auditRecord) {
+	if a == nil {
+		return
+	}
+	entry.Timestamp = started
+	entry.Executor = config.Name
+	entry.Args = a.redactArgs(config, args)
+	entry.DurationMs = time.Since(started).Milliseconds()
+
+	if err := a.write(entry); err != nil {
+		log.Printf("Warning: failed to write audit log: %v", err)
+	}
+}
+
+// write appends an entry, rotating the file first when it would exceed
+// maxBytes
+func (a *auditLog) write(entry auditRecord) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to marshal audit entry: %w", err)
+	}
+	data = append(data, '\n')
+
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(a.path), 0755); err != nil {
+		return fmt.Errorf("failed to create audit log directory: %w", err)
+	}
+	if info, err := os.Stat(a.path); err == nil && a.maxBytes > 0 && info.Size() > 0 && info.Size()+int64(len(data)) > a.maxBytes {
+		if err := os.Rename(a.path, a.path+".1"); err != nil {
+			return fmt.Errorf("failed to rotate audit log: %w", err)
+		}
+	}
+
+	file, err := os.OpenFile(a.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open audit log: %w", err)
+	}
+	defer file.Close()
+
+	if _, err := file.Write(data); err != nil {
+		return fmt.Errorf("failed to write audit entry: %w", err)
+	}
+	return nil
+}
+
+// callStatus is the audit status of a synchronous call
+func callStatus(result ExecuteOutput, err error) string {
+	switch {
+	case err != nil:
+		return "error"
+	case result.ExitCode == 0:
+		return "completed"
+	default:
+		return "failed"
+	}
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"regexp"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// readAudit returns the records of an audit log file
+func readAudit(path string) []auditRecord {
+	file, err := os.Open(path)
+	Expect(err).NotTo(HaveOccurred())
+	defer file.Close()
+
+	var records []auditRecord
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var record auditRecord
+		Expect(json.Unmarshal(scanner.Bytes(), &record)).To(Succeed())
+		records = append(records, record)
+	}
+	Expect(scanner.Err()).NotTo(HaveOccurred())
+	return records
+}
+
+var _ = Describe("Audit log", func() {
+	var path string
+
+	BeforeEach(func() {
+		path = filepath.Join(GinkgoT().TempDir(), "logs", "audit.jsonl")
+	})
+
+	Describe("configuration", func() {
+		It("is disabled without SCRIPTS_AUDIT_LOG", func() {
+			GinkgoT().Setenv("SCRIPTS_AUDIT_LOG", "")
+			a, err := newAuditLogFromEnv()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(a).To(BeNil())
+			// A disabled log ignores records
+			a.record(ExecutorConfig{Name: "echo"}, nil, time.Now(), auditRecord{})
+		})
+
+		It("reads the size and redaction patterns", func() {
+			GinkgoT().Setenv("SCRIPTS_AUDIT_LOG", path)
+			GinkgoT().Setenv("SCRIPTS_AUDIT_MAX_SIZE_MB", "2")
+			GinkgoT().Setenv("SCRIPTS_AUDIT_REDACT", "token=\\S+, ,sk-[a-z0-9]+")
+			a, err := newAuditLogFromEnv()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(a.maxBytes).To(Equal(int64(2 << 20)))
+			Expect(a.redact).To(HaveLen(2))
+		})
+
+		It("refuses invalid values", func() {
+			GinkgoT().Setenv("SCRIPTS_AUDIT_LOG", path)
+			GinkgoT().Setenv("SCRIPTS_AUDIT_MAX_SIZE_MB", "-1")
+			_, err := newAuditLogFromEnv()
+			Expect(err).To(MatchError(ContainSubstring("invalid SCRIPTS_AUDIT_MAX_SIZE_MB")))
+
+			GinkgoT().Setenv("SCRIPTS_AUDIT_MAX_SIZE_MB", "")
+			GinkgoT().Setenv("SCRIPTS_AUDIT_REDACT", "(")
+			_, err = newAuditLogFromEnv()
+			Expect(err).To(MatchError(ContainSubstring("invalid SCRIPTS_AUDIT_REDACT pattern")))
+		})
+	})
+
+	Describe("redaction", func() {
+		It("hides the arguments at redact_args positions", func() {
+			a := &auditLog{}
+			args := a.redactArgs(ExecutorConfig{RedactArgs: []int{1, 5}}, []string{"deploy", "s3cret", "prod"})
+			Expect(args).To(Equal([]string{"deploy", redacted, "prod"}))
+		})
+
+		It("hides the parts of other arguments matching SCRIPTS_AUDIT_REDACT", func() {
+			a := &auditLog{redact: []*regexp.Regexp{
+				regexp.MustCompile(`token=\S+`),
+				regexp.MustCompile(`sk-[a-z0-9]+`),
+			}}
+			args := a.redactArgs(ExecutorConfig{RedactArgs: []int{0}}, []string{
+				"secret",
+				"--header token=abc123 --verbose",
+				"key sk-abc and sk-def",
+				"plain",
+			})
+			Expect(args).To(Equal([]string{
+				redacted,
+				"--header " + redacted + " --verbose",
+				"key " + redacted + " and " + redacted,
+				"plain",
+			}))
+		})
+
+		It("never writes redacted values to the file", func() {
+			a := &auditLog{path: path, redact: []*regexp.Regexp{regexp.MustCompile(`hunter2`)}}
+			a.record(ExecutorConfig{Name: "login", RedactArgs: []int{0}}, []string{"s3cret", "pw=hunter2"}, time.Now(), auditRecord{Status: "completed"})
+
+			content, err := os.ReadFile(path)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(content)).NotTo(ContainSubstring("s3cret"))
+			Expect(string(content)).NotTo(ContainSubstring("hunter2"))
+
+			records := readAudit(path)
+			Expect(records).To(HaveLen(1))
+			Expect(records[0].Executor).To(Equal("login"))
+			Expect(records[0].Args).To(Equal([]string{redacted, "pw=" + redacted}))
+			Expect(records[0].Status).To(Equal("completed"))
+		})
+	})
+
+	Describe("rotation", func() {
+		It("moves the log to .1 once the next entry would exceed the size", func() {
+			a := &auditLog{path: path, maxBytes: 400}
+			for i := 0; i < 10; i++ {
+				a.record(ExecutorConfig{Name: "echo"}, []string{"hello"}, time.Now(), auditRecord{Status: "completed"})
+			}
+
+			current, err := os.Stat(path)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(current.Size()).To(BeNumerically("<=", 400))
+			rotated, err := os.Stat(path + ".1")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(rotated.Size()).To(BeNumerically("<=", 400))
+
+			// Only one rotated file is kept
+			Expect(path + ".2").NotTo(BeAnExistingFile())
+			total := len(readAudit(path)) + len(readAudit(path+".1"))
+			Expect(total).To(BeNumerically("<", 10))
+		})
+
+		It("always writes an entry larger than the limit", func() {
+			a := &auditLog{path: path, maxBytes: 10}
+			a.record(ExecutorConfig{Name: "echo"}, []string{"hello"}, time.Now(), auditRecord{})
+			Expect(readAudit(path)).To(HaveLen(1))
+			Expect(path + ".1").NotTo(BeAnExistingFile())
+		})
+
+		It("never rotates when the size is 0", func() {
+			a := &auditLog{path: path}
+			for i := 0; i < 50; i++ {
+				a.record(ExecutorConfig{Name: "echo"}, []string{"hello"}, time.Now(), auditRecord{})
+			}
+			Expect(readAudit(path)).To(HaveLen(50))
+			Expect(path + ".1").NotTo(BeAnExistingFile())
+		})
+	})
+})
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Executor environment", func() {
+	var dir string
+
+	BeforeEach(func() {
+		dir = GinkgoT().TempDir()
+	})
+
+	writeEnvFile := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		Expect(os.WriteFile(path, []byte(content), 0600)).To(Succeed())
+		return path
+	}
+
+	Describe("parseEnvFile", func() {
+		It("reads KEY=VALUE lines, skipping comments and blanks", func() {
+			values, err := parseEnvFile(writeEnvFile("app.env", `
+# database
+DB_HOST = localhost
+export DB_USER=admin
+DB_PASSWORD="p4ss word"
+DB_NAME='app'
+URL=https://example.com/?a=b
+EMPTY=
+`))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(values).To(Equal(map[string]string{
+				"DB_HOST":     "localhost",
+				"DB_USER":     "admin",
+				"DB_PASSWORD": "p4ss word",
+				"DB_NAME":     "app",
+				"URL":         "https://example.com/?a=b",
+				"EMPTY":       "",
+			}))
+		})
+
+		It("keeps mismatched quotes", func() {
+			values, err := parseEnvFile(writeEnvFile("quotes.env", "A=\"open\nB='mixed\"\nC=\"\n"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(values).To(Equal(map[string]string{"A": `"open`, "B": `'mixed"`, "C": `"`}))
+		})
+
+		It("reports malformed lines by number", func() {
+			_, err := parseEnvFile(writeEnvFile("bad.env", "A=1\nnot a pair\n"))
+			Expect(err).To(MatchError("line 2: expected KEY=VALUE"))
+
+			_, err = parseEnvFile(writeEnvFile("empty.env", "# comment\n=value\n"))
+			Expect(err).To(MatchError("line 2: empty key"))
+		})
+
+		It("fails on missing files", func() {
+			_, err := parseEnvFile(filepath.Join(dir, "missing.env"))
+			Expect(os.IsNotExist(err)).To(BeTrue())
+		})
+	})
+
+	Describe("resolveEnv", func() {
+		It("applies env files in order, then env_from, then env", func() {
+			GinkgoT().Setenv("SCRIPTS_TEST_TOKEN", "from-server")
+			env, err := resolveEnv(ExecutorConfig{
+				EnvFiles: []string{
+					writeEnvFile("first.env", "TOKEN=first\n"),
+					writeEnvFile("second.env", "TOKEN=second\n"),
+				},
+				EnvFrom: map[string]string{"TOKEN": "SCRIPTS_TEST_TOKEN"},
+				Env:     map[string]string{"MODE": "test"},
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(env).To(Equal([]string{"TOKEN=first", "TOKEN=second", "TOKEN=from-server", "MODE=test"}))
+
+			// Later values win once appended to the command's environment
+			env, err = resolveEnv(ExecutorConfig{
+				EnvFiles: []string{writeEnvFile("third.env", "TOKEN=file\n")},
+				Env:      map[string]string{"TOKEN": "literal"},
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(slices.Index(env, "TOKEN=literal")).To(BeNumerically(">", slices.Index(env, "TOKEN=file")))
+		})
+
+		It("fails when env_from refers to an unset variable", func() {
+			_, err := resolveEnv(ExecutorConfig{EnvFrom: map[string]string{"TOKEN": "SCRIPTS_TEST_UNSET"}})
+			Expect(err).To(MatchError("environment variable SCRIPTS_TEST_UNSET referenced by TOKEN is not set"))
+		})
+
+		It("names the env file it could not read", func() {
+			path := filepath.Join(dir, "missing.env")
+			_, err := resolveEnv(ExecutorConfig{EnvFiles: []string{path}})
+			Expect(err).To(MatchError(ContainSubstring("failed to read env file " + path)))
+		})
+	})
+})
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// interpreters maps interpreter names to the program run for them, from
+// SCRIPTS_INTERPRETERS
+var interpreters = map[string]string{}
+
+// parseInterpreters reads SCRIPTS_INTERPRETERS, a comma separated list of
+// name=path pairs such as python3=/usr/local/bin/python3
+func parseInterpreters(value string) (map[string]string, error) {
+	result := map[string]string{}
+	for _, entry := range strings.Split(value, ",") {
+		if entry = strings.TrimSpace(entry); entry == "" {
+			continue
+		}
+		name, path, ok := strings.Cut(entry, "=")
+		name, path = strings.TrimSpace(name), strings.TrimSpace(path)
+		if !ok || name == "" || path == "" {
+			return nil, fmt.Errorf("invalid SCRIPTS_INTERPRETERS entry %q: expected name=path", entry)
+		}
+		result[name] = path
+	}
+	return result, nil
+}
+
+// extendPath prepends the directories of SCRIPTS_PATH to PATH, for both
+// interpreter lookups and the environment of the executors
+func extendPath(dirs string) error {
+	if dirs = strings.Trim(dirs, string(os.PathListSeparator)); dirs == "" {
+		return nil
+	}
+	if current := os.Getenv("PATH"); current != "" {
+		dirs += string(os.PathListSeparator) + current
+	}
+	return os.Setenv("PATH", dirs)
+}
+
+// resolveInterpreter returns the program to run for an interpreter, either
+// its SCRIPTS_INTERPRETERS mapping (looked up by name, then by base name for
+// shebang paths) or its location in PATH
+func resolveInterpreter(name string) (string, error) {
+	mapped, ok := interpreters[name]
+	if !ok {
+		mapped, ok = interpreters[filepath.Base(name)]
+	}
+	if ok {
+		path, err := exec.LookPath(mapped)
+		if err != nil {
+			return "", fmt.Errorf("interpreter %s is mapped to %s in SCRIPTS_INTERPRETERS, which cannot be run: %w", name, mapped, err)
+		}
+		return path, nil
+	}
+	path, err := exec.LookPath(name)
+	if err != nil {
+		base := filepath.Base(name)
+		return "", fmt.Errorf("interpreter %s not found: install it, map it in SCRIPTS_INTERPRETERS (e.g. %s=/usr/local/bin/%s) or add its directory to SCRIPTS_PATH", name, base, base)
+	}
+	return path, nil
+}
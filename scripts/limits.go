@@ -0,0 +1,27 @@
+package main
+
+import "os/exec"
+
+// rlimitHelperArg makes the server re-execute itself as a small wrapper that
+// applies the executor's resource limits and then execs the real command, so
+// the limits are in place before the first instruction of the script runs
+const rlimitHelperArg = "__mcp_scripts_rlimit_exec"
+
+// hasLimits reports whether an executor sets any resource limit
+func hasLimits(config ExecutorConfig) bool {
+	return config.MaxMemoryMB > 0 || config.MaxCPUSeconds > 0
+}
+
+// limitedExitError returns the error to report when an executor's exit looks
+// caused by one of its resource limits, or "" otherwise. stderr is the
+// captured standard error, used to recognize failed allocations.
+func limitedExitError(config ExecutorConfig, err error, stderr string) string {
+	if !hasLimits(config) || err == nil {
+		return ""
+	}
+	exitError, ok := err.(*exec.ExitError)
+	if !ok {
+		return ""
+	}
+	return limitExceeded(config, exitError, stderr)
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// limitsSupported reports whether max_memory_mb and max_cpu_seconds are enforced
+const limitsSupported = true
+
+// allocationFailures are messages printed by common runtimes when an
+// allocation fails under RLIMIT_AS
+var allocationFailures = []string{
+	"Cannot allocate memory",
+	"out of memory",
+	"MemoryError",
+	"bad_alloc",
+}
+
+// applyLimits rewrites cmd to run through the rlimit helper when the executor
+// has resource limits
+func applyLimits(cmd *exec.Cmd, config ExecutorConfig) error {
+	if !hasLimits(config) || cmd.Err != nil {
+		// Without limits there is nothing to do, and a failed lookup is
+		// reported by cmd.Start
+		return nil
+	}
+	self, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to apply resource limits: %w", err)
+	}
+	cmd.Args = append([]string{
+		self,
+		rlimitHelperArg,
+		strconv.Itoa(config.MaxMemoryMB),
+		strconv.Itoa(config.MaxCPUSeconds),
+		cmd.Path,
+	}, cmd.Args...)
+	cmd.Path = self
+	return nil
+}
+
+// runLimited is the rlimit helper: it sets RLIMIT_AS and RLIMIT_CPU on its
+// own process and replaces itself with the command. args are the memory
+// limit in MB, the CPU limit in seconds (0 meaning unlimited), the program
+// path and its argv.
+func runLimited(args []string) {
+	fail := func(format string, a ...interface{}) {
+		fmt.Fprintf(os.Stderr, "rlimit: "+format+"\n", a...)
+		os.Exit(127)
+	}
+	if len(args) < 4 {
+		fail("expected <memory_mb> <cpu_seconds> <path> <argv...>")
+	}
+	memoryMB, err := strconv.ParseUint(args[0], 10, 64)
+	if err != nil {
+		fail("invalid memory limit %q", args[0])
+	}
+	cpuSeconds, err := strconv.ParseUint(args[1], 10, 64)
+	if err != nil {
+		fail("invalid CPU limit %q", args[1])
+	}
+
+	if memoryMB > 0 {
+		limit := memoryMB << 20
+		if err := syscall.Setrlimit(syscall.RLIMIT_AS, &syscall.Rlimit{Cur: limit, Max: limit}); err != nil {
+			fail("failed to set memory limit: %v", err)
+		}
+	}
+	if cpuSeconds > 0 {
+		// SIGXCPU at the soft limit, SIGKILL one second later if it is ignored
+		if err := syscall.Setrlimit(syscall.RLIMIT_CPU, &syscall.Rlimit{Cur: cpuSeconds, Max: cpuSeconds + 1}); err != nil {
+			fail("failed to set CPU limit: %v", err)
+		}
+	}
+
+	if err := syscall.Exec(args[2], args[3:], os.Environ()); err != nil {
+		fail("failed to execute %s: %v", args[2], err)
+	}
+}
+
+// limitExceeded inspects how a limited executor exited
+func limitExceeded(config ExecutorConfig, exitError *exec.ExitError, stderr string) string {
+	status, ok := exitError.Sys().(syscall.WaitStatus)
+	if !ok {
+		return ""
+	}
+	if config.MaxCPUSeconds > 0 && status.Signaled() && status.Signal() == syscall.SIGXCPU {
+		return fmt.Sprintf("CPU time limit of %d second(s) exceeded", config.MaxCPUSeconds)
+	}
+	if config.MaxMemoryMB > 0 {
+		crashed := status.Signaled() && (status.Signal() == syscall.SIGSEGV || status.Signal() == syscall.SIGABRT || status.Signal() == syscall.SIGBUS)
+		for _, message := range allocationFailures {
+			if strings.Contains(stderr, message) {
+				crashed = true
+				break
+			}
+		}
+		if crashed {
+			return fmt.Sprintf("memory limit of %d MB was likely exceeded", config.MaxMemoryMB)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func init() {
+	// Limited commands are re-executed through the test binary, as they are
+	// through the server
+	if len(os.Args) > 1 && os.Args[1] == rlimitHelperArg {
+		runLimited(os.Args[2:])
+	}
+}
+
+var _ = Describe("Resource limits on Linux", func() {
+	It("runs limited commands through the rlimit helper", func() {
+		self, err := os.Executable()
+		Expect(err).NotTo(HaveOccurred())
+		cmd := exec.Command("/bin/sh", "-c", "true")
+		Expect(applyLimits(cmd, ExecutorConfig{MaxMemoryMB: 64})).To(Succeed())
+		Expect(cmd.Path).To(Equal(self))
+		Expect(cmd.Args).To(Equal([]string{self, rlimitHelperArg, "64", "0", "/bin/sh", "/bin/sh", "-c", "true"}))
+
+		cmd = exec.Command("/bin/sh", "-c", "true")
+		Expect(applyLimits(cmd, ExecutorConfig{})).To(Succeed())
+		Expect(cmd.Args).To(Equal([]string{"/bin/sh", "-c", "true"}))
+	})
+
+	It("applies the limits to the child", func() {
+		out, err := executeScript(context.Background(), ExecutorConfig{
+			Name:          "limits",
+			Content:       "#!/bin/sh\nulimit -v\nulimit -t\n",
+			MaxMemoryMB:   256,
+			MaxCPUSeconds: 5,
+		}, nil, "")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(out.ExitCode).To(BeZero(), out.Stderr)
+		Expect(strings.Fields(out.Stdout)).To(Equal([]string{"262144", "5"}))
+	})
+
+	It("reports a command stopped by the CPU limit", func() {
+		out, err := executeScript(context.Background(), ExecutorConfig{
+			Name:          "spin",
+			Content:       "#!/bin/sh\nwhile :; do :; done\n",
+			MaxCPUSeconds: 1,
+			Timeout:       10,
+		}, nil, "")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(out.ExitCode).NotTo(BeZero())
+		Expect(out.Stderr).To(ContainSubstring("CPU time limit of 1 second(s) exceeded"))
+	})
+
+	It("refuses invalid helper arguments", func() {
+		self, err := os.Executable()
+		Expect(err).NotTo(HaveOccurred())
+		output, err := exec.Command(self, rlimitHelperArg, "lots", "0", "/bin/true", "true").CombinedOutput()
+		Expect(err).To(HaveOccurred())
+		Expect(err.(*exec.ExitError).ExitCode()).To(Equal(127))
+		Expect(string(output)).To(ContainSubstring(`invalid memory limit "lots"`))
+	})
+})
@@ -0,0 +1,30 @@
+//go:build !linux
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+)
+
+// limitsSupported reports whether max_memory_mb and max_cpu_seconds are enforced
+const limitsSupported = false
+
+// applyLimits refuses to run limited executors where limits cannot be enforced
+func applyLimits(cmd *exec.Cmd, config ExecutorConfig) error {
+	if hasLimits(config) {
+		return fmt.Errorf("max_memory_mb and max_cpu_seconds are only supported on Linux")
+	}
+	return nil
+}
+
+// runLimited is only reachable on Linux
+func runLimited(args []string) {
+	fmt.Fprintln(os.Stderr, "rlimit: resource limits are only supported on Linux")
+	os.Exit(127)
+}
+
+func limitExceeded(config ExecutorConfig, exitError *exec.ExitError, stderr string) string {
+	return ""
+}
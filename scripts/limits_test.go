@@ -0,0 +1,50 @@
+package main
+
+import (
+	"errors"
+	"os/exec"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Resource limits", func() {
+	DescribeTable("validating the limits of executors",
+		func(limits string, message string) {
+			executors, err := parseExecutors(`[{"name": "limited", "description": "d", "command": "true"` + limits + `}]`)
+			switch {
+			case message != "":
+				Expect(err).To(MatchError(ContainSubstring(message)))
+			case limits != "" && !limitsSupported:
+				Expect(err).To(MatchError(ContainSubstring("only supported on Linux")))
+			default:
+				Expect(err).NotTo(HaveOccurred())
+				Expect(executors).To(HaveLen(1))
+			}
+		},
+		Entry("no limits", "", ""),
+		Entry("memory", `, "max_memory_mb": 256`, ""),
+		Entry("CPU", `, "max_cpu_seconds": 10`, ""),
+		Entry("negative memory", `, "max_memory_mb": -1`, "must not be negative"),
+		Entry("negative CPU", `, "max_cpu_seconds": -5`, "must not be negative"),
+	)
+
+	It("reads the limits of executors", func() {
+		if !limitsSupported {
+			Skip("resource limits are only supported on Linux")
+		}
+		executors, err := parseExecutors(`[{"name": "limited", "description": "d", "command": "true", "max_memory_mb": 256, "max_cpu_seconds": 10}]`)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(executors[0].MaxMemoryMB).To(Equal(256))
+		Expect(executors[0].MaxCPUSeconds).To(Equal(10))
+		Expect(hasLimits(executors[0])).To(BeTrue())
+	})
+
+	It("only blames the limits for exits of limited executors", func() {
+		exitErr := exec.Command("false").Run()
+		Expect(exitErr).To(BeAssignableToTypeOf(&exec.ExitError{}))
+		Expect(limitedExitError(ExecutorConfig{}, exitErr, "out of memory")).To(BeEmpty())
+		Expect(limitedExitError(ExecutorConfig{MaxMemoryMB: 64}, nil, "out of memory")).To(BeEmpty())
+		Expect(limitedExitError(ExecutorConfig{MaxMemoryMB: 64}, errors.New("timeout"), "out of memory")).To(BeEmpty())
+	})
+})
@@ -4,14 +4,21 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"log"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/mudler/mcps/pkg/output"
+	"github.com/mudler/mcps/pkg/safepath"
+	"github.com/mudler/mcps/pkg/selfcheck"
+	"github.com/mudler/mcps/pkg/transport"
 )
 
 // ExecutorConfig represents a single script/program executor configuration
@@ -25,19 +32,99 @@ type ExecutorConfig struct {
 	Timeout     int               `json:"timeout,omitempty"`
 	WorkingDir  string            `json:"working_dir,omitempty"`
 	Env         map[string]string `json:"env,omitempty"`
+	EnvFiles    []string          `json:"env_files,omitempty"`
+	EnvFrom     map[string]string `json:"env_from,omitempty"`
+	LongRunning bool              `json:"long_running,omitempty"`
+	Usage       string            `json:"usage,omitempty"`
+	Example     string            `json:"example,omitempty"`
+	MinArgs     int               `json:"min_args,omitempty"`
+	// RedactArgs lists the positions of arguments hidden in the audit log
+	RedactArgs []int `json:"redact_args,omitempty"`
+	// Resource limits, enforced with setrlimit on Linux
+	MaxMemoryMB   int `json:"max_memory_mb,omitempty"`
+	MaxCPUSeconds int `json:"max_cpu_seconds,omitempty"`
 }
 
 // Input struct for script/program execution
 type ExecuteInput struct {
-	Args []string `json:"args,omitempty" jsonschema:"arguments to pass to the script or program"`
+	Args       []string `json:"args,omitempty" jsonschema:"arguments to pass to the script or program"`
+	OutputFile string   `json:"output_file,omitempty" jsonschema:"write stdout to this file instead of returning it, the file must be under SCRIPTS_OUTPUT_DIR and relative paths are resolved against it"`
 }
 
 // Output struct for execution results
 type ExecuteOutput struct {
-	Stdout     string `json:"stdout" jsonschema:"standard output from execution"`
-	Stderr     string `json:"stderr" jsonschema:"standard error from execution"`
-	ExitCode   int    `json:"exit_code" jsonschema:"exit code from execution"`
-	DurationMs int    `json:"duration_ms" jsonschema:"execution duration in milliseconds"`
+	Stdout      string `json:"stdout" jsonschema:"standard output from execution"`
+	Stderr      string `json:"stderr" jsonschema:"standard error from execution"`
+	ExitCode    int    `json:"exit_code" jsonschema:"exit code from execution"`
+	DurationMs  int    `json:"duration_ms" jsonschema:"execution duration in milliseconds"`
+	OutputFile  string `json:"output_file,omitempty" jsonschema:"the file stdout was written to, when output_file was set"`
+	StdoutBytes int64  `json:"stdout_bytes,omitempty" jsonschema:"number of bytes written to output_file"`
+}
+
+// DescribeExecutorInput represents the input for describing an executor
+type DescribeExecutorInput struct {
+	Name string `json:"name" jsonschema:"the executor (tool) name"`
+}
+
+// DescribeExecutorOutput describes how to invoke an executor
+type DescribeExecutorOutput struct {
+	Name        string `json:"name" jsonschema:"the executor name"`
+	Description string `json:"description" jsonschema:"what the executor does"`
+	Usage       string `json:"usage,omitempty" jsonschema:"the expected positional arguments"`
+	Example     string `json:"example,omitempty" jsonschema:"an example invocation"`
+	MinArgs     int    `json:"min_args" jsonschema:"minimum number of arguments required"`
+	LongRunning bool   `json:"long_running" jsonschema:"whether the executor runs in the background"`
+	Timeout     int    `json:"timeout" jsonschema:"execution timeout in seconds"`
+}
+
+// executorsByName holds the configured executors for describe_executor
+var executorsByName = map[string]ExecutorConfig{}
+
+// toolDescription returns the executor description extended with its usage
+// and example, so agents see them in the tool list
+func toolDescription(config ExecutorConfig) string {
+	description := config.Description
+	if config.Usage != "" {
+		description += "\nUsage: " + config.Usage
+	}
+	if config.Example != "" {
+		description += "\nExample: " + config.Example
+	}
+	return description
+}
+
+// checkArgs verifies that enough arguments were passed to the executor
+func checkArgs(config ExecutorConfig, args []string) error {
+	if len(args) >= config.MinArgs {
+		return nil
+	}
+	if config.Usage != "" {
+		return fmt.Errorf("%s expects at least %d argument(s), got %d (usage: %s)", config.Name, config.MinArgs, len(args), config.Usage)
+	}
+	return fmt.Errorf("%s expects at least %d argument(s), got %d", config.Name, config.MinArgs, len(args))
+}
+
+// DescribeExecutorHandler returns the usage information of an executor
+func DescribeExecutorHandler(ctx context.Context, req *mcp.CallToolRequest, input DescribeExecutorInput) (*mcp.CallToolResult, DescribeExecutorOutput, error) {
+	config, exists := executorsByName[input.Name]
+	if !exists {
+		return nil, DescribeExecutorOutput{}, fmt.Errorf("executor not found: %s", input.Name)
+	}
+
+	timeout := config.Timeout
+	if timeout <= 0 {
+		timeout = 30
+	}
+
+	return nil, DescribeExecutorOutput{
+		Name:        config.Name,
+		Description: config.Description,
+		Usage:       config.Usage,
+		Example:     config.Example,
+		MinArgs:     config.MinArgs,
+		LongRunning: config.LongRunning,
+		Timeout:     timeout,
+	}, nil
 }
 
 // detectInterpreter attempts to detect the interpreter from shebang or file extension
@@ -48,6 +135,14 @@ func detectInterpreter(content string, path string) string {
 		if len(lines) > 0 {
 			shebang := strings.TrimSpace(lines[0][2:]) // Remove "#!"
 			parts := strings.Fields(shebang)
+			// "#!/usr/bin/env python3" names the interpreter to look up
+			if len(parts) > 1 && filepath.Base(parts[0]) == "env" {
+				for _, part := range parts[1:] {
+					if !strings.HasPrefix(part, "-") {
+						return part
+					}
+				}
+			}
 			if len(parts) > 0 {
 				return parts[0]
 			}
@@ -74,23 +169,80 @@ func detectInterpreter(content string, path string) string {
 	return ""
 }
 
-// executeScript runs a script or program with the given configuration
-func executeScript(ctx context.Context, config ExecutorConfig, args []string) (ExecuteOutput, error) {
-	startTime := time.Now()
+// parseEnvFile reads a dotenv-style file and returns its key/value pairs.
+// Blank lines and lines starting with '#' are ignored, an optional "export "
+// prefix is stripped and values may be wrapped in single or double quotes.
+func parseEnvFile(path string) (map[string]string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
 
-	// Determine timeout
-	timeout := 30 * time.Second
-	if config.Timeout > 0 {
-		timeout = time.Duration(config.Timeout) * time.Second
+	values := make(map[string]string)
+	for i, line := range strings.Split(string(content), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected KEY=VALUE", i+1)
+		}
+		key = strings.TrimSpace(key)
+		if key == "" {
+			return nil, fmt.Errorf("line %d: empty key", i+1)
+		}
+		value = strings.TrimSpace(value)
+		if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+			value = value[1 : len(value)-1]
+		}
+		values[key] = value
 	}
+	return values, nil
+}
 
-	// Create context with timeout
-	execCtx, cancel := context.WithTimeout(ctx, timeout)
-	defer cancel()
+// resolveEnv builds the extra environment for an executor. Values are read at
+// execution time so that secrets never need to live in the SCRIPTS JSON:
+// env_files are loaded in order, env_from copies variables from the server's
+// own environment, and the literal env map is applied last.
+// The resolved values must never be logged.
+func resolveEnv(config ExecutorConfig) ([]string, error) {
+	var env []string
+
+	for _, path := range config.EnvFiles {
+		values, err := parseEnvFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read env file %s: %w", path, err)
+		}
+		for k, v := range values {
+			env = append(env, fmt.Sprintf("%s=%s", k, v))
+		}
+	}
+
+	for k, source := range config.EnvFrom {
+		v, ok := os.LookupEnv(source)
+		if !ok {
+			return nil, fmt.Errorf("environment variable %s referenced by %s is not set", source, k)
+		}
+		env = append(env, fmt.Sprintf("%s=%s", k, v))
+	}
 
+	for k, v := range config.Env {
+		env = append(env, fmt.Sprintf("%s=%s", k, v))
+	}
+
+	return env, nil
+}
+
+// buildCommand prepares the command for an executor without starting it.
+// The returned cleanup function removes any temporary script file and must
+// be called once the command has finished.
+func buildCommand(ctx context.Context, config ExecutorConfig, args []string) (*exec.Cmd, func(), error) {
 	var cmd *exec.Cmd
-	var tempFile string
 	var err error
+	cleanup := func() {}
 
 	// Handle inline content
 	if config.Content != "" {
@@ -98,21 +250,21 @@ func executeScript(ctx context.Context, config ExecutorConfig, args []string) (E
 		var tempFileHandle *os.File
 		tempFileHandle, err = os.CreateTemp("", "mcp-script-*")
 		if err != nil {
-			return ExecuteOutput{}, fmt.Errorf("failed to create temporary file: %w", err)
+			return nil, cleanup, fmt.Errorf("failed to create temporary file: %w", err)
 		}
-		tempFile = tempFileHandle.Name()
-		defer os.Remove(tempFile)
+		tempFile := tempFileHandle.Name()
+		cleanup = func() { os.Remove(tempFile) }
 
 		// Write content to temp file
 		if _, err = tempFileHandle.WriteString(config.Content); err != nil {
 			tempFileHandle.Close()
-			return ExecuteOutput{}, fmt.Errorf("failed to write script content: %w", err)
+			return nil, cleanup, fmt.Errorf("failed to write script content: %w", err)
 		}
 		tempFileHandle.Close()
 
 		// Make executable
 		if err = os.Chmod(tempFile, 0755); err != nil {
-			return ExecuteOutput{}, fmt.Errorf("failed to make script executable: %w", err)
+			return nil, cleanup, fmt.Errorf("failed to make script executable: %w", err)
 		}
 
 		// Determine interpreter
@@ -123,10 +275,14 @@ func executeScript(ctx context.Context, config ExecutorConfig, args []string) (E
 
 		if interpreter != "" {
 			// Execute with interpreter
-			cmd = exec.CommandContext(execCtx, interpreter, tempFile)
+			program, err := resolveInterpreter(interpreter)
+			if err != nil {
+				return nil, cleanup, err
+			}
+			cmd = exec.CommandContext(ctx, program, tempFile)
 		} else {
 			// Try to execute directly
-			cmd = exec.CommandContext(execCtx, tempFile)
+			cmd = exec.CommandContext(ctx, tempFile)
 		}
 	} else if config.Path != "" {
 		// Handle file path
@@ -140,24 +296,28 @@ func executeScript(ctx context.Context, config ExecutorConfig, args []string) (E
 		}
 
 		if interpreter != "" {
-			cmd = exec.CommandContext(execCtx, interpreter, config.Path)
+			program, err := resolveInterpreter(interpreter)
+			if err != nil {
+				return nil, cleanup, err
+			}
+			cmd = exec.CommandContext(ctx, program, config.Path)
 		} else {
 			// Execute directly
-			cmd = exec.CommandContext(execCtx, config.Path)
+			cmd = exec.CommandContext(ctx, config.Path)
 		}
 	} else if config.Command != "" {
 		// Handle direct command/program
 		cmdParts := strings.Fields(config.Command)
 		if len(cmdParts) == 0 {
-			return ExecuteOutput{}, fmt.Errorf("invalid command: %s", config.Command)
+			return nil, cleanup, fmt.Errorf("invalid command: %s", config.Command)
 		}
 		if len(cmdParts) == 1 {
-			cmd = exec.CommandContext(execCtx, cmdParts[0])
+			cmd = exec.CommandContext(ctx, cmdParts[0])
 		} else {
-			cmd = exec.CommandContext(execCtx, cmdParts[0], cmdParts[1:]...)
+			cmd = exec.CommandContext(ctx, cmdParts[0], cmdParts[1:]...)
 		}
 	} else {
-		return ExecuteOutput{}, fmt.Errorf("must specify either content, path, or command")
+		return nil, cleanup, fmt.Errorf("must specify either content, path, or command")
 	}
 
 	// Add arguments
@@ -171,11 +331,70 @@ func executeScript(ctx context.Context, config ExecutorConfig, args []string) (E
 	}
 
 	// Set environment variables
-	if len(config.Env) > 0 {
-		cmd.Env = os.Environ()
-		for k, v := range config.Env {
-			cmd.Env = append(cmd.Env, fmt.Sprintf("%s=%s", k, v))
-		}
+	extraEnv, err := resolveEnv(config)
+	if err != nil {
+		return nil, cleanup, err
+	}
+	if len(extraEnv) > 0 {
+		cmd.Env = append(os.Environ(), extraEnv...)
+	}
+
+	// Apply memory and CPU limits
+	if err := applyLimits(cmd, config); err != nil {
+		return nil, cleanup, err
+	}
+
+	return cmd, cleanup, nil
+}
+
+// countingWriter counts the bytes written through it
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// outputFiles confines output_file to SCRIPTS_OUTPUT_DIR. output_file is
+// refused when it is not set, so calls cannot write anywhere the server can.
+var outputFiles safepath.Guard
+
+// outputFilePath resolves the output_file of a call, relative paths being
+// relative to SCRIPTS_OUTPUT_DIR
+func outputFilePath(path string) (string, error) {
+	if outputFiles.Root == "" {
+		return "", fmt.Errorf("output_file is disabled, set SCRIPTS_OUTPUT_DIR to allow it")
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(outputFiles.Root, path)
+	}
+	return outputFiles.Resolve(path)
+}
+
+// executeScript runs a script or program with the given configuration. When
+// outputFile is set, stdout is streamed to that file instead of being
+// captured.
+func executeScript(ctx context.Context, config ExecutorConfig, args []string, outputFile string) (ExecuteOutput, error) {
+	startTime := time.Now()
+
+	// Determine timeout
+	timeout := 30 * time.Second
+	if config.Timeout > 0 {
+		timeout = time.Duration(config.Timeout) * time.Second
+	}
+
+	// Create context with timeout
+	execCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	cmd, cleanup, err := buildCommand(execCtx, config, args)
+	defer cleanup()
+	if err != nil {
+		return ExecuteOutput{}, err
 	}
 
 	// Capture stdout and stderr
@@ -183,77 +402,132 @@ func executeScript(ctx context.Context, config ExecutorConfig, args []string) (E
 	cmd.Stdout = &stdoutBuf
 	cmd.Stderr = &stderrBuf
 
+	var stdoutFile *countingWriter
+	if outputFile != "" {
+		if outputFile, err = outputFilePath(outputFile); err != nil {
+			return ExecuteOutput{}, fmt.Errorf("invalid output_file: %w", err)
+		}
+		if err := os.MkdirAll(filepath.Dir(outputFile), 0755); err != nil {
+			return ExecuteOutput{}, fmt.Errorf("failed to create output_file directory: %w", err)
+		}
+		file, err := os.Create(outputFile)
+		if err != nil {
+			return ExecuteOutput{}, fmt.Errorf("failed to create output_file: %w", err)
+		}
+		defer file.Close()
+		stdoutFile = &countingWriter{w: file}
+		cmd.Stdout = stdoutFile
+	}
+
 	// Execute command
 	err = cmd.Run()
 	duration := time.Since(startTime)
+	result := func(stderr string, exitCode int) ExecuteOutput {
+		out := ExecuteOutput{
+			Stdout:     stdoutBuf.String(),
+			Stderr:     stderr,
+			ExitCode:   exitCode,
+			DurationMs: int(duration.Milliseconds()),
+		}
+		if stdoutFile != nil {
+			out.OutputFile = outputFile
+			out.StdoutBytes = stdoutFile.n
+		}
+		return out
+	}
 
 	// Get exit code
 	exitCode := 0
 	if err != nil {
 		if exitError, ok := err.(*exec.ExitError); ok {
 			exitCode = exitError.ExitCode()
+			if limitErr := limitedExitError(config, err, stderrBuf.String()); limitErr != "" {
+				stderrBuf.WriteString("\nError: " + limitErr)
+			}
 		} else {
 			// Context timeout or other error
 			if execCtx.Err() == context.DeadlineExceeded {
-				return ExecuteOutput{
-					Stdout:     stdoutBuf.String(),
-					Stderr:     stderrBuf.String() + "\nError: execution timeout",
-					ExitCode:   -1,
-					DurationMs: int(duration.Milliseconds()),
-				}, nil
+				return result(stderrBuf.String()+"\nError: execution timeout", -1), nil
 			}
-			return ExecuteOutput{
-				Stdout:     stdoutBuf.String(),
-				Stderr:     stderrBuf.String() + "\nError: " + err.Error(),
-				ExitCode:   -1,
-				DurationMs: int(duration.Milliseconds()),
-			}, nil
+			return result(stderrBuf.String()+"\nError: "+err.Error(), -1), nil
 		}
 	}
 
-	return ExecuteOutput{
-		Stdout:     stdoutBuf.String(),
-		Stderr:     stderrBuf.String(),
-		ExitCode:   exitCode,
-		DurationMs: int(duration.Milliseconds()),
-	}, nil
+	return result(stderrBuf.String(), exitCode), nil
 }
 
 // createExecutorHandler creates a handler function for a specific executor configuration
 func createExecutorHandler(config ExecutorConfig) func(context.Context, *mcp.CallToolRequest, ExecuteInput) (*mcp.CallToolResult, ExecuteOutput, error) {
 	return func(ctx context.Context, req *mcp.CallToolRequest, input ExecuteInput) (*mcp.CallToolResult, ExecuteOutput, error) {
-		output, err := executeScript(ctx, config, input.Args)
+		started := time.Now()
+		if err := checkArgs(config, input.Args); err != nil {
+			audit.record(config, input.Args, started, auditRecord{Status: "error", ExitCode: -1, Error: err.Error()})
+			return nil, ExecuteOutput{}, err
+		}
+		result, err := executeScript(ctx, config, input.Args, input.OutputFile)
+		entry := auditRecord{Status: callStatus(result, err), ExitCode: result.ExitCode}
+		if err != nil {
+			entry.ExitCode, entry.Error = -1, err.Error()
+		}
+		audit.record(config, input.Args, started, entry)
 		if err != nil {
 			return nil, ExecuteOutput{}, err
 		}
-		return nil, output, nil
+		result.Stdout, _ = output.Truncate(result.Stdout, "mark the executor long_running to page through its output")
+		result.Stderr, _ = output.Truncate(result.Stderr, "mark the executor long_running to page through its output")
+		return nil, result, nil
 	}
 }
 
-func main() {
-	// Read SCRIPTS environment variable
-	scriptsJSON := os.Getenv("SCRIPTS")
+// runDir returns SCRIPTS_RUN_DIR or the default directory for background
+// run output
+func runDir() string {
+	if dir := os.Getenv("SCRIPTS_RUN_DIR"); dir != "" {
+		return dir
+	}
+	return filepath.Join(os.TempDir(), "mcp-script-runs")
+}
+
+// defaultRunRetentionHours is how long finished background runs are kept
+const defaultRunRetentionHours = 24
+
+// runRetention returns SCRIPTS_RUN_RETENTION_HOURS, how long finished runs
+// and their output are kept
+func runRetention() (time.Duration, error) {
+	value := strings.TrimSpace(os.Getenv("SCRIPTS_RUN_RETENTION_HOURS"))
+	if value == "" {
+		return defaultRunRetentionHours * time.Hour, nil
+	}
+	hours, err := strconv.Atoi(value)
+	if err != nil || hours < 0 {
+		return 0, fmt.Errorf("invalid SCRIPTS_RUN_RETENTION_HOURS %q: expected a number of hours, 0 to keep runs forever", value)
+	}
+	return time.Duration(hours) * time.Hour, nil
+}
+
+// parseExecutors reads and validates the SCRIPTS configuration
+func parseExecutors(scriptsJSON string) ([]ExecutorConfig, error) {
 	if scriptsJSON == "" {
-		log.Fatal("SCRIPTS environment variable is required")
+		return nil, fmt.Errorf("SCRIPTS environment variable is required")
 	}
 
 	// Parse JSON configuration
 	var executors []ExecutorConfig
 	if err := json.Unmarshal([]byte(scriptsJSON), &executors); err != nil {
-		log.Fatalf("Failed to parse SCRIPTS JSON: %v", err)
+		return nil, fmt.Errorf("Failed to parse SCRIPTS JSON: %v", err)
 	}
 
 	if len(executors) == 0 {
-		log.Fatal("SCRIPTS must contain at least one executor configuration")
+		return nil, fmt.Errorf("SCRIPTS must contain at least one executor configuration")
 	}
 
 	// Validate configurations
 	for i, executor := range executors {
 		if executor.Name == "" {
-			log.Fatalf("Executor at index %d: name is required", i)
+			return nil, fmt.Errorf("Executor at index %d: name is required", i)
 		}
 		if executor.Description == "" {
-			log.Fatalf("Executor at index %d: description is required", i)
+			return nil, fmt.Errorf("Executor at index %d: description is required", i)
 		}
 
 		// Validate that exactly one of content, path, or command is specified
@@ -273,24 +547,117 @@ func main() {
 		}
 
 		if count != 1 {
-			log.Fatalf("Executor '%s': must specify exactly one of 'content', 'path', or 'command'", executor.Name)
+			return nil, fmt.Errorf("Executor '%s': must specify exactly one of 'content', 'path', or 'command'", executor.Name)
 		}
+
+		if executor.MinArgs < 0 {
+			return nil, fmt.Errorf("Executor '%s': min_args must not be negative", executor.Name)
+		}
+
+		if slices.ContainsFunc(executor.RedactArgs, func(i int) bool { return i < 0 }) {
+			return nil, fmt.Errorf("Executor '%s': redact_args positions must not be negative", executor.Name)
+		}
+
+		if executor.MaxMemoryMB < 0 || executor.MaxCPUSeconds < 0 {
+			return nil, fmt.Errorf("Executor '%s': max_memory_mb and max_cpu_seconds must not be negative", executor.Name)
+		}
+		if hasLimits(executor) && !limitsSupported {
+			return nil, fmt.Errorf("Executor '%s': max_memory_mb and max_cpu_seconds are only supported on Linux", executor.Name)
+		}
+	}
+	return executors, nil
+}
+
+func main() {
+	// Re-executed as the wrapper applying an executor's resource limits
+	if len(os.Args) > 1 && os.Args[1] == rlimitHelperArg {
+		runLimited(os.Args[2:])
+		return
+	}
+
+	executors, err := parseExecutors(os.Getenv("SCRIPTS"))
+	var auditErr error
+	audit, auditErr = newAuditLogFromEnv()
+	var interpretersErr error
+	interpreters, interpretersErr = parseInterpreters(os.Getenv("SCRIPTS_INTERPRETERS"))
+	var outputDirErr error
+	outputFiles, outputDirErr = safepath.New(os.Getenv("SCRIPTS_OUTPUT_DIR"))
+	if outputDirErr != nil {
+		outputDirErr = fmt.Errorf("invalid SCRIPTS_OUTPUT_DIR: %w", outputDirErr)
+	}
+	if err := extendPath(os.Getenv("SCRIPTS_PATH")); err != nil {
+		log.Fatalf("Failed to apply SCRIPTS_PATH: %v", err)
+	}
+	if selfcheck.Enabled() {
+		selfcheck.Exit("scripts", selfChecks(executors, err, auditErr, interpretersErr, outputDirErr)...)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+	if auditErr != nil {
+		log.Fatal(auditErr)
+	}
+	if interpretersErr != nil {
+		log.Fatal(interpretersErr)
+	}
+	if outputDirErr != nil {
+		log.Fatal(outputDirErr)
+	}
+	for _, executor := range executors {
+		executorsByName[executor.Name] = executor
 	}
 
 	// Create MCP server
 	server := mcp.NewServer(&mcp.Implementation{Name: "scripts", Version: "v1.0.0"}, nil)
 
 	// Register each executor as a tool
+	hasLongRunning := false
 	for _, executor := range executors {
+		if executor.LongRunning {
+			hasLongRunning = true
+			mcp.AddTool(server, &mcp.Tool{
+				Name:        executor.Name,
+				Description: toolDescription(executor) + "\n(runs in the background: returns a run_id to poll with get_run_output and stop with stop_run)",
+			}, createLongRunningHandler(executor))
+			continue
+		}
 		handler := createExecutorHandler(executor)
 		mcp.AddTool(server, &mcp.Tool{
 			Name:        executor.Name,
-			Description: executor.Description,
+			Description: toolDescription(executor),
 		}, handler)
 	}
 
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "describe_executor",
+		Description: "Describe an executor: its expected arguments (usage), an example invocation, the minimum number of arguments and whether it runs in the background",
+	}, DescribeExecutorHandler)
+
+	// Register run management tools for long running executors
+	if hasLongRunning {
+		retention, err := runRetention()
+		if err != nil {
+			log.Fatal(err)
+		}
+		globalRunManager, err = NewRunManager(runDir(), retention)
+		if err != nil {
+			log.Fatalf("Failed to initialize run manager: %v", err)
+		}
+		defer globalRunManager.StopAllRuns()
+
+		mcp.AddTool(server, &mcp.Tool{
+			Name:        "get_run_output",
+			Description: "Get the status and output of a background run. Pass the returned next_stdout_offset/next_stderr_offset on the next call to receive only new output.",
+		}, GetRunOutputHandler)
+
+		mcp.AddTool(server, &mcp.Tool{
+			Name:        "stop_run",
+			Description: "Stop a running background execution by run ID",
+		}, StopRunHandler)
+	}
+
 	// Run the server
-	if err := server.Run(context.Background(), &mcp.StdioTransport{}); err != nil {
+	if err := transport.Run(context.Background(), server); err != nil {
 		log.Fatal(err)
 	}
 }
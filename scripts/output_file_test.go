@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+
+	"github.com/mudler/mcps/pkg/safepath"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("output_file", func() {
+	var root string
+
+	BeforeEach(func() {
+		prev := outputFiles
+		DeferCleanup(func() { outputFiles = prev })
+
+		var err error
+		outputFiles, err = safepath.New(GinkgoT().TempDir())
+		Expect(err).NotTo(HaveOccurred())
+		root = outputFiles.Root
+	})
+
+	It("is refused when SCRIPTS_OUTPUT_DIR is not set", func() {
+		outputFiles = safepath.Guard{}
+		_, err := outputFilePath(filepath.Join(root, "out.txt"))
+		Expect(err).To(MatchError(ContainSubstring("SCRIPTS_OUTPUT_DIR")))
+	})
+
+	It("resolves relative paths against SCRIPTS_OUTPUT_DIR", func() {
+		path, err := outputFilePath("reports/january.csv")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(path).To(Equal(filepath.Join(root, "reports", "january.csv")))
+	})
+
+	It("accepts absolute paths under SCRIPTS_OUTPUT_DIR", func() {
+		path, err := outputFilePath(filepath.Join(root, "out.txt"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(path).To(Equal(filepath.Join(root, "out.txt")))
+	})
+
+	It("refuses paths leaving SCRIPTS_OUTPUT_DIR", func() {
+		for _, path := range []string{
+			"../escape.txt",
+			"reports/../../escape.txt",
+			filepath.Join(filepath.Dir(root), "escape.txt"),
+			"/etc/passwd",
+		} {
+			_, err := outputFilePath(path)
+			Expect(errors.Is(err, safepath.ErrOutsideRoot)).To(BeTrue(), "path %s: %v", path, err)
+		}
+	})
+
+	It("refuses symlinks leading outside SCRIPTS_OUTPUT_DIR", func() {
+		outside := GinkgoT().TempDir()
+		Expect(os.Symlink(outside, filepath.Join(root, "linked"))).To(Succeed())
+		Expect(os.Symlink(filepath.Join(outside, "target.txt"), filepath.Join(root, "file.txt"))).To(Succeed())
+
+		for _, path := range []string{"linked/out.txt", "file.txt"} {
+			_, err := outputFilePath(path)
+			Expect(errors.Is(err, safepath.ErrOutsideRoot)).To(BeTrue(), "path %s: %v", path, err)
+		}
+	})
+
+	It("streams stdout to the file", func() {
+		out, err := executeScript(context.Background(), ExecutorConfig{Name: "echo", Command: "echo hello"}, nil, "out/hello.txt")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(out.ExitCode).To(Equal(0))
+		Expect(out.Stdout).To(BeEmpty())
+		Expect(out.OutputFile).To(Equal(filepath.Join(root, "out", "hello.txt")))
+		Expect(os.ReadFile(out.OutputFile)).To(Equal([]byte("hello\n")))
+		Expect(out.StdoutBytes).To(BeEquivalentTo(6))
+	})
+
+	It("does not create anything outside SCRIPTS_OUTPUT_DIR", func() {
+		outside := GinkgoT().TempDir()
+		_, err := executeScript(context.Background(), ExecutorConfig{Name: "echo", Command: "echo hello"}, nil, filepath.Join(outside, "dir", "out.txt"))
+		Expect(err).To(MatchError(ContainSubstring("invalid output_file")))
+		Expect(filepath.Join(outside, "dir")).NotTo(BeAnExistingFile())
+	})
+})
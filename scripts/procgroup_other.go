@@ -0,0 +1,9 @@
+//go:build !unix
+
+package main
+
+import "os/exec"
+
+// killProcessGroup leaves cmd as is where process groups are not available:
+// cancelling its context only kills the process itself
+func killProcessGroup(cmd *exec.Cmd) {}
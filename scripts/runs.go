@@ -0,0 +1,368 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/mudler/mcps/pkg/output"
+)
+
+// Run represents a background execution of a long_running executor
+type Run struct {
+	ID         string
+	Executor   string
+	Status     string
+	ExitCode   int
+	Error      string
+	StartedAt  time.Time
+	StoppedAt  time.Time
+	StdoutPath string
+	StderrPath string
+	cancel     context.CancelFunc
+	stopped    bool
+}
+
+// RunManager keeps track of background runs
+type RunManager struct {
+	runs  map[string]*Run
+	mutex sync.RWMutex
+	dir   string
+	// retention is how long finished runs are kept, 0 keeps them forever
+	retention time.Duration
+}
+
+// Global run manager, only used when at least one executor is long_running
+var globalRunManager *RunManager
+
+// NewRunManager creates a run manager persisting output under dir, which
+// forgets finished runs and removes their output after retention
+func NewRunManager(dir string, retention time.Duration) (*RunManager, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create run directory: %w", err)
+	}
+	return &RunManager{
+		runs:      make(map[string]*Run),
+		dir:       dir,
+		retention: retention,
+	}, nil
+}
+
+// StartRun starts an executor in the background, writing its output to
+// per-run files so it can be polled while the process is still running
+func (rm *RunManager) StartRun(config ExecutorConfig, args []string) (*Run, error) {
+	id := uuid.New().String()
+	runDir := filepath.Join(rm.dir, id)
+	if err := os.MkdirAll(runDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create run directory: %w", err)
+	}
+
+	// Long running executors have no timeout unless one is configured
+	ctx, cancel := context.WithCancel(context.Background())
+	if config.Timeout > 0 {
+		ctx, cancel = context.WithTimeout(context.Background(), time.Duration(config.Timeout)*time.Second)
+	}
+
+	cmd, cleanup, err := buildCommand(ctx, config, args)
+	if err != nil {
+		cleanup()
+		cancel()
+		return nil, err
+	}
+	killProcessGroup(cmd)
+
+	run := &Run{
+		ID:         id,
+		Executor:   config.Name,
+		Status:     "running",
+		StdoutPath: filepath.Join(runDir, "stdout"),
+		StderrPath: filepath.Join(runDir, "stderr"),
+		cancel:     cancel,
+	}
+
+	stdout, err := os.Create(run.StdoutPath)
+	if err != nil {
+		cleanup()
+		cancel()
+		return nil, fmt.Errorf("failed to create stdout file: %w", err)
+	}
+	stderr, err := os.Create(run.StderrPath)
+	if err != nil {
+		stdout.Close()
+		cleanup()
+		cancel()
+		return nil, fmt.Errorf("failed to create stderr file: %w", err)
+	}
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+
+	run.StartedAt = time.Now()
+	if err := cmd.Start(); err != nil {
+		stdout.Close()
+		stderr.Close()
+		cleanup()
+		cancel()
+		return nil, fmt.Errorf("failed to start executor: %w", err)
+	}
+
+	rm.mutex.Lock()
+	rm.runs[id] = run
+	rm.mutex.Unlock()
+
+	go func() {
+		err := cmd.Wait()
+		stdout.Close()
+		stderr.Close()
+		cleanup()
+		limitErr := limitedExitError(config, err, tailFile(run.StderrPath, 4096))
+
+		rm.mutex.Lock()
+		run.StoppedAt = time.Now()
+		switch {
+		case run.stopped:
+			run.Status = "stopped"
+			run.ExitCode = -1
+		case errors.Is(ctx.Err(), context.DeadlineExceeded):
+			run.Status = "timeout"
+			run.ExitCode = -1
+		case err == nil:
+			run.Status = "completed"
+		default:
+			run.Status = "failed"
+			run.ExitCode = -1
+			if exitError, ok := err.(*exec.ExitError); ok {
+				run.ExitCode = exitError.ExitCode()
+			}
+			run.Error = limitErr
+		}
+		entry := auditRecord{RunID: run.ID, Status: run.Status, ExitCode: run.ExitCode, Error: run.Error}
+		rm.mutex.Unlock()
+		cancel()
+
+		audit.record(config, args, run.StartedAt, entry)
+		rm.scheduleCleanup(id)
+	}()
+
+	return run, nil
+}
+
+// GetRun returns a snapshot of a run by ID
+func (rm *RunManager) GetRun(id string) (Run, bool) {
+	rm.mutex.RLock()
+	defer rm.mutex.RUnlock()
+	run, exists := rm.runs[id]
+	if !exists {
+		return Run{}, false
+	}
+	return *run, true
+}
+
+// scheduleCleanup forgets a finished run and removes its output once the
+// retention period has passed
+func (rm *RunManager) scheduleCleanup(id string) {
+	if rm.retention <= 0 {
+		return
+	}
+	time.AfterFunc(rm.retention, func() {
+		rm.mutex.Lock()
+		delete(rm.runs, id)
+		rm.mutex.Unlock()
+		os.RemoveAll(filepath.Join(rm.dir, id))
+	})
+}
+
+// StopRun kills a running execution, with the processes it started
+func (rm *RunManager) StopRun(id string) error {
+	rm.mutex.Lock()
+	defer rm.mutex.Unlock()
+
+	run, exists := rm.runs[id]
+	if !exists {
+		return fmt.Errorf("run not found: %s", id)
+	}
+	if run.Status != "running" {
+		return fmt.Errorf("run is not running: %s", run.Status)
+	}
+	run.stopped = true
+	run.cancel()
+	return nil
+}
+
+// StopAllRuns kills every running execution (called on server shutdown)
+func (rm *RunManager) StopAllRuns() {
+	rm.mutex.Lock()
+	defer rm.mutex.Unlock()
+	for _, run := range rm.runs {
+		if run.Status == "running" {
+			run.stopped = true
+			run.cancel()
+		}
+	}
+}
+
+// readFrom returns the content of path starting at byte offset
+func readFrom(path string, offset int64) (string, int64, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", offset, err
+	}
+	defer file.Close()
+
+	if offset > 0 {
+		if _, err := file.Seek(offset, io.SeekStart); err != nil {
+			return "", offset, err
+		}
+	}
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return "", offset, err
+	}
+	return string(data), offset + int64(len(data)), nil
+}
+
+// tailFile returns up to the last n bytes of a file, or "" if it cannot be read
+func tailFile(path string, n int64) string {
+	info, err := os.Stat(path)
+	if err != nil {
+		return ""
+	}
+	data, _, err := readFrom(path, max(info.Size()-n, 0))
+	if err != nil {
+		return ""
+	}
+	return data
+}
+
+// limitRunOutput caps data read from offset to MCP_MAX_RESPONSE_BYTES. When
+// data is cut, the returned offset points right after the part that was kept
+// so the next poll resumes from there.
+func limitRunOutput(data string, offset, next int64, param string) (string, int64) {
+	kept, truncated := output.Head(data, output.MaxBytes())
+	if !truncated || kept == "" {
+		return data, next
+	}
+	next = offset + int64(len(kept))
+	data, _ = output.TruncateTo(data, len(kept), fmt.Sprintf("call get_run_output with %s=%d for the rest", param, next))
+	return data, next
+}
+
+// StartRunOutput is returned when a long_running executor is invoked
+type StartRunOutput struct {
+	RunID   string `json:"run_id" jsonschema:"the run ID to poll with get_run_output and stop with stop_run"`
+	Status  string `json:"status" jsonschema:"the run status (running)"`
+	Message string `json:"message" jsonschema:"status message"`
+}
+
+// GetRunOutputInput represents the input for polling a run
+type GetRunOutputInput struct {
+	RunID        string `json:"run_id" jsonschema:"the run ID"`
+	StdoutOffset int64  `json:"stdout_offset,omitempty" jsonschema:"byte offset to read stdout from, use next_stdout_offset of the previous call to get only new output (default: 0)"`
+	StderrOffset int64  `json:"stderr_offset,omitempty" jsonschema:"byte offset to read stderr from, use next_stderr_offset of the previous call to get only new output (default: 0)"`
+}
+
+// GetRunOutputOutput represents incremental output of a run
+type GetRunOutputOutput struct {
+	RunID            string `json:"run_id" jsonschema:"the run ID"`
+	Executor         string `json:"executor" jsonschema:"the executor name"`
+	Status           string `json:"status" jsonschema:"the run status: running, completed, failed, stopped, or timeout"`
+	ExitCode         int    `json:"exit_code" jsonschema:"exit code once the run has finished"`
+	Stdout           string `json:"stdout" jsonschema:"standard output since stdout_offset"`
+	Stderr           string `json:"stderr" jsonschema:"standard error since stderr_offset"`
+	NextStdoutOffset int64  `json:"next_stdout_offset" jsonschema:"offset to pass as stdout_offset on the next call"`
+	NextStderrOffset int64  `json:"next_stderr_offset" jsonschema:"offset to pass as stderr_offset on the next call"`
+	DurationMs       int    `json:"duration_ms" jsonschema:"elapsed execution time in milliseconds"`
+	Error            string `json:"error,omitempty" jsonschema:"why a failed run stopped, e.g. an exceeded resource limit"`
+}
+
+// StopRunInput represents the input for stopping a run
+type StopRunInput struct {
+	RunID string `json:"run_id" jsonschema:"the run ID to stop"`
+}
+
+// StopRunOutput represents the output from stopping a run
+type StopRunOutput struct {
+	RunID   string `json:"run_id" jsonschema:"the run ID"`
+	Message string `json:"message" jsonschema:"status message"`
+}
+
+// createLongRunningHandler creates a handler that starts the executor in the background
+func createLongRunningHandler(config ExecutorConfig) func(context.Context, *mcp.CallToolRequest, ExecuteInput) (*mcp.CallToolResult, StartRunOutput, error) {
+	return func(ctx context.Context, req *mcp.CallToolRequest, input ExecuteInput) (*mcp.CallToolResult, StartRunOutput, error) {
+		started := time.Now()
+		err := checkArgs(config, input.Args)
+		if err == nil && input.OutputFile != "" {
+			err = fmt.Errorf("output_file is not supported by long running executors, their output is already written to files under SCRIPTS_RUN_DIR")
+		}
+		if err != nil {
+			audit.record(config, input.Args, started, auditRecord{Status: "error", ExitCode: -1, Error: err.Error()})
+			return nil, StartRunOutput{}, err
+		}
+		run, err := globalRunManager.StartRun(config, input.Args)
+		if err != nil {
+			audit.record(config, input.Args, started, auditRecord{Status: "error", ExitCode: -1, Error: err.Error()})
+			return nil, StartRunOutput{}, err
+		}
+		return nil, StartRunOutput{
+			RunID:   run.ID,
+			Status:  run.Status,
+			Message: "Run started, poll get_run_output for progress",
+		}, nil
+	}
+}
+
+// GetRunOutputHandler returns the status and new output of a run
+func GetRunOutputHandler(ctx context.Context, req *mcp.CallToolRequest, input GetRunOutputInput) (*mcp.CallToolResult, GetRunOutputOutput, error) {
+	run, exists := globalRunManager.GetRun(input.RunID)
+	if !exists {
+		return nil, GetRunOutputOutput{}, fmt.Errorf("run not found: %s", input.RunID)
+	}
+
+	stdout, nextStdout, err := readFrom(run.StdoutPath, input.StdoutOffset)
+	if err != nil {
+		return nil, GetRunOutputOutput{}, fmt.Errorf("failed to read stdout: %w", err)
+	}
+	stderr, nextStderr, err := readFrom(run.StderrPath, input.StderrOffset)
+	if err != nil {
+		return nil, GetRunOutputOutput{}, fmt.Errorf("failed to read stderr: %w", err)
+	}
+
+	stdout, nextStdout = limitRunOutput(stdout, input.StdoutOffset, nextStdout, "stdout_offset")
+	stderr, nextStderr = limitRunOutput(stderr, input.StderrOffset, nextStderr, "stderr_offset")
+
+	endTime := run.StoppedAt
+	if endTime.IsZero() {
+		endTime = time.Now()
+	}
+
+	return nil, GetRunOutputOutput{
+		RunID:            run.ID,
+		Executor:         run.Executor,
+		Status:           run.Status,
+		ExitCode:         run.ExitCode,
+		Stdout:           stdout,
+		Stderr:           stderr,
+		NextStdoutOffset: nextStdout,
+		NextStderrOffset: nextStderr,
+		DurationMs:       int(endTime.Sub(run.StartedAt).Milliseconds()),
+		Error:            run.Error,
+	}, nil
+}
+
+// StopRunHandler stops a running execution
+func StopRunHandler(ctx context.Context, req *mcp.CallToolRequest, input StopRunInput) (*mcp.CallToolResult, StopRunOutput, error) {
+	if err := globalRunManager.StopRun(input.RunID); err != nil {
+		return nil, StopRunOutput{}, err
+	}
+	return nil, StopRunOutput{
+		RunID:   input.RunID,
+		Message: "Run stopped",
+	}, nil
+}
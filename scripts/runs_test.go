@@ -0,0 +1,100 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// processRunning reports whether pid is alive, zombies being dead
+func processRunning(pid int) bool {
+	stat, err := os.ReadFile(filepath.Join("/proc", strconv.Itoa(pid), "stat"))
+	if err != nil {
+		return false
+	}
+	// The state follows the command name, which is in parentheses
+	fields := strings.Fields(string(stat[strings.LastIndexByte(string(stat), ')')+1:]))
+	return len(fields) > 0 && fields[0] != "Z"
+}
+
+var _ = Describe("Background runs", func() {
+	runStatus := func(rm *RunManager, id string) func() string {
+		return func() string {
+			run, ok := rm.GetRun(id)
+			if !ok {
+				return "not_found"
+			}
+			return run.Status
+		}
+	}
+
+	It("kills the processes a run started when it is stopped", func() {
+		if runtime.GOOS != "linux" {
+			Skip("reads process states from /proc")
+		}
+		rm, err := NewRunManager(GinkgoT().TempDir(), 0)
+		Expect(err).NotTo(HaveOccurred())
+		DeferCleanup(rm.StopAllRuns)
+
+		run, err := rm.StartRun(ExecutorConfig{
+			Name:    "spawn",
+			Content: "#!/bin/sh\nsleep 30 &\necho $!\nwait\n",
+		}, nil)
+		Expect(err).NotTo(HaveOccurred())
+
+		var child int
+		Eventually(func() error {
+			content, err := os.ReadFile(run.StdoutPath)
+			if err != nil {
+				return err
+			}
+			child, err = strconv.Atoi(strings.TrimSpace(string(content)))
+			return err
+		}).Should(Succeed())
+		Expect(processRunning(child)).To(BeTrue())
+
+		Expect(rm.StopRun(run.ID)).To(Succeed())
+		Eventually(runStatus(rm, run.ID)).Should(Equal("stopped"))
+		Eventually(func() bool { return processRunning(child) }).Should(BeFalse())
+	})
+
+	It("forgets finished runs after the retention period", func() {
+		dir := GinkgoT().TempDir()
+		rm, err := NewRunManager(dir, 200*time.Millisecond)
+		Expect(err).NotTo(HaveOccurred())
+
+		run, err := rm.StartRun(ExecutorConfig{Name: "echo", Command: "echo hello"}, nil)
+		Expect(err).NotTo(HaveOccurred())
+		Eventually(runStatus(rm, run.ID)).Should(Equal("completed"))
+		Expect(filepath.Join(dir, run.ID)).To(BeADirectory())
+
+		Eventually(runStatus(rm, run.ID)).Should(Equal("not_found"))
+		Eventually(filepath.Join(dir, run.ID)).ShouldNot(BeADirectory())
+	})
+
+	It("keeps running runs and every run without retention", func() {
+		rm, err := NewRunManager(GinkgoT().TempDir(), 0)
+		Expect(err).NotTo(HaveOccurred())
+
+		run, err := rm.StartRun(ExecutorConfig{Name: "echo", Command: "echo hello"}, nil)
+		Expect(err).NotTo(HaveOccurred())
+		Eventually(runStatus(rm, run.ID)).Should(Equal("completed"))
+		Consistently(runStatus(rm, run.ID), 300*time.Millisecond).Should(Equal("completed"))
+	})
+
+	It("reads SCRIPTS_RUN_RETENTION_HOURS", func() {
+		GinkgoT().Setenv("SCRIPTS_RUN_RETENTION_HOURS", "")
+		Expect(runRetention()).To(Equal(defaultRunRetentionHours * time.Hour))
+		GinkgoT().Setenv("SCRIPTS_RUN_RETENTION_HOURS", "0")
+		Expect(runRetention()).To(BeZero())
+		GinkgoT().Setenv("SCRIPTS_RUN_RETENTION_HOURS", "-2")
+		_, err := runRetention()
+		Expect(err).To(MatchError(ContainSubstring("invalid SCRIPTS_RUN_RETENTION_HOURS")))
+	})
+})
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/mudler/mcps/pkg/selfcheck"
+)
+
+// selfChecks reports the SCRIPTS configuration and whether each executor's
+// program can be found
+func selfChecks(executors []ExecutorConfig, parseErr, auditErr, interpretersErr, outputDirErr error) []selfcheck.Check {
+	checks := []selfcheck.Check{
+		selfcheck.Value("SCRIPTS", fmt.Sprintf("%d executors", len(executors)), parseErr),
+	}
+	if os.Getenv("SCRIPTS_INTERPRETERS") != "" {
+		checks = append(checks, selfcheck.Value("SCRIPTS_INTERPRETERS", fmt.Sprintf("%d interpreters", len(interpreters)), interpretersErr))
+	}
+	if os.Getenv("SCRIPTS_OUTPUT_DIR") != "" {
+		checks = append(checks, selfcheck.Value("SCRIPTS_OUTPUT_DIR", outputFiles.Root, outputDirErr))
+	}
+	longRunning := false
+	for _, executor := range executors {
+		checks = append(checks, executorCheck(executor))
+		longRunning = longRunning || executor.LongRunning
+	}
+	if longRunning {
+		checks = append(checks, selfcheck.Dir("SCRIPTS_RUN_DIR", runDir()))
+		if os.Getenv("SCRIPTS_RUN_RETENTION_HOURS") != "" {
+			retention, err := runRetention()
+			checks = append(checks, selfcheck.Value("SCRIPTS_RUN_RETENTION_HOURS", retention.String(), err))
+		}
+	}
+	if path := os.Getenv("SCRIPTS_AUDIT_LOG"); path != "" {
+		if auditErr != nil {
+			checks = append(checks, selfcheck.Value("SCRIPTS_AUDIT_LOG", "", auditErr))
+		} else {
+			checks = append(checks, selfcheck.File("SCRIPTS_AUDIT_LOG", path))
+		}
+	}
+	return checks
+}
+
+// executorCheck resolves the program an executor runs and its working
+// directory, without running it
+func executorCheck(executor ExecutorConfig) selfcheck.Check {
+	return selfcheck.Check{Name: "executor " + executor.Name, Run: func(context.Context) (string, error) {
+		if executor.WorkingDir != "" {
+			if info, err := os.Stat(executor.WorkingDir); err != nil || !info.IsDir() {
+				return "", fmt.Errorf("working_dir %s is not a directory", executor.WorkingDir)
+			}
+		}
+
+		program := executor.Interpreter
+		switch {
+		case executor.Command != "":
+			fields := strings.Fields(executor.Command)
+			if len(fields) == 0 {
+				return "", fmt.Errorf("invalid command: %s", executor.Command)
+			}
+			resolved, err := exec.LookPath(fields[0])
+			if err != nil {
+				return "", fmt.Errorf("%s not found in PATH", fields[0])
+			}
+			return resolved, nil
+		case executor.Path != "":
+			info, err := os.Stat(executor.Path)
+			if err != nil {
+				return "", fmt.Errorf("path %s: %w", executor.Path, err)
+			}
+			if program == "" {
+				if content, err := os.ReadFile(executor.Path); err == nil {
+					program = detectInterpreter(string(content), executor.Path)
+				}
+			}
+			if program == "" {
+				if info.Mode()&0111 == 0 {
+					return "", fmt.Errorf("path %s is not executable and has no interpreter", executor.Path)
+				}
+				return executor.Path, nil
+			}
+		case program == "":
+			program = detectInterpreter(executor.Content, "")
+		}
+		if program == "" {
+			return "inline script", nil
+		}
+
+		return resolveInterpreter(program)
+	}}
+}
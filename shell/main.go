@@ -3,6 +3,7 @@ package main
 import (
 	"bytes"
 	"context"
+	"fmt"
 	"log"
 	"os"
 	"os/exec"
@@ -12,6 +13,8 @@ import (
 	"time"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/mudler/mcps/pkg/selfcheck"
+	"github.com/mudler/mcps/pkg/transport"
 )
 
 // Input type for executing shell scripts
@@ -177,7 +180,33 @@ func ExecuteCommand(ctx context.Context, req *mcp.CallToolRequest, input Execute
 	return nil, output, nil
 }
 
+// selfChecks validates the shell and working directory without running the
+// initialization script, which may have side effects
+func selfChecks() []selfcheck.Check {
+	checks := []selfcheck.Check{
+		selfcheck.Command(strings.Fields(getShellCommand())[0]),
+		{Name: "SHELL_WORKING_DIR", Run: func(context.Context) (string, error) {
+			dir := getWorkingDirectory()
+			if dir == "" {
+				return "current directory", nil
+			}
+			if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+				return "", fmt.Errorf("%s is not a directory", dir)
+			}
+			return dir, nil
+		}},
+	}
+	if os.Getenv("SHELL_INIT_SCRIPT") != "" {
+		checks = append(checks, selfcheck.Skipped("SHELL_INIT_SCRIPT", "not run in self-check mode"))
+	}
+	return checks
+}
+
 func main() {
+	if selfcheck.Enabled() {
+		selfcheck.Exit("shell", selfChecks()...)
+	}
+
 	// Run initialization script if SHELL_INIT_SCRIPT is set
 	if initScript := os.Getenv("SHELL_INIT_SCRIPT"); initScript != "" {
 		cmd := exec.CommandContext(context.Background(), "sh", "-c", initScript)
@@ -205,7 +234,7 @@ func main() {
 	}, ExecuteCommand)
 
 	// Run the server
-	if err := server.Run(context.Background(), &mcp.StdioTransport{}); err != nil {
+	if err := transport.Run(context.Background(), server); err != nil {
 		log.Fatal(err)
 	}
 }
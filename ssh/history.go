@@ -0,0 +1,203 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/mudler/mcps/pkg/output"
+)
+
+// historyOutputBytes caps the stdout/stderr kept for each history entry
+const historyOutputBytes = 4096
+
+// ExecutionRecord is one entry of the execution history
+type ExecutionRecord struct {
+	Timestamp  time.Time `json:"timestamp" jsonschema:"when the execution started"`
+	Host       string    `json:"host" jsonschema:"the SSH host"`
+	User       string    `json:"user,omitempty" jsonschema:"the SSH user"`
+	Script     string    `json:"script" jsonschema:"the executed script, with secrets redacted"`
+	ExitCode   int       `json:"exit_code" jsonschema:"exit code of the script"`
+	Success    bool      `json:"success" jsonschema:"whether the script executed successfully"`
+	Error      string    `json:"error,omitempty" jsonschema:"error message if execution failed"`
+	Stdout     string    `json:"stdout,omitempty" jsonschema:"standard output, truncated and with secrets redacted"`
+	Stderr     string    `json:"stderr,omitempty" jsonschema:"standard error, truncated and with secrets redacted"`
+	DurationMs int64     `json:"duration_ms" jsonschema:"execution duration in milliseconds"`
+}
+
+// GetExecutionHistoryInput represents the input for querying the history
+type GetExecutionHistoryInput struct {
+	Host  string `json:"host,omitempty" jsonschema:"optional host to filter executions by"`
+	Limit int    `json:"limit,omitempty" jsonschema:"maximum number of executions to return (default: 20)"`
+}
+
+// GetExecutionHistoryOutput represents the recent executions
+type GetExecutionHistoryOutput struct {
+	Executions []ExecutionRecord `json:"executions" jsonschema:"recent executions, newest first"`
+	Count      int               `json:"count" jsonschema:"number of executions returned"`
+}
+
+// executionHistory appends execution records to a JSON Lines file
+type executionHistory struct {
+	path  string
+	mutex sync.Mutex
+}
+
+// history is nil unless SSH_HISTORY_PATH is set
+var history *executionHistory
+
+// secretAssignment matches key=value style secrets such as "password=hunter2"
+var secretAssignment = regexp.MustCompile(`(?i)\b((?:[a-z0-9_-]*)(?:password|passwd|secret|token|api[_-]?key)[a-z0-9_-]*)(\s*[=:]\s*)("[^"]*"|'[^']*'|\S+)`)
+
+// redactSecrets removes the configured credentials and key=value style
+// secrets from s
+func redactSecrets(s string, secrets ...string) string {
+	return secretAssignment.ReplaceAllString(redactValues(s, secrets...), "$1$2[REDACTED]")
+}
+
+// redactValues removes the given credentials from s
+func redactValues(s string, secrets ...string) string {
+	for _, secret := range secrets {
+		if secret != "" {
+			s = strings.ReplaceAll(s, secret, "[REDACTED]")
+		}
+	}
+	return s
+}
+
+// record appends an execution to the history file
+func (h *executionHistory) record(entry ExecutionRecord) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to marshal history entry: %w", err)
+	}
+
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(h.path), 0755); err != nil {
+		return fmt.Errorf("failed to create history directory: %w", err)
+	}
+	file, err := os.OpenFile(h.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open history file: %w", err)
+	}
+	defer file.Close()
+
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write history entry: %w", err)
+	}
+	return nil
+}
+
+// recent returns up to limit executions for host (all hosts if empty), newest first
+func (h *executionHistory) recent(host string, limit int) ([]ExecutionRecord, error) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	file, err := os.Open(h.path)
+	if os.IsNotExist(err) {
+		return []ExecutionRecord{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open history file: %w", err)
+	}
+	defer file.Close()
+
+	var records []ExecutionRecord
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var entry ExecutionRecord
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			// Skip a partially written line rather than failing the whole query
+			continue
+		}
+		if host == "" || entry.Host == host {
+			records = append(records, entry)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read history file: %w", err)
+	}
+
+	result := []ExecutionRecord{}
+	for i := len(records) - 1; i >= 0 && len(result) < limit; i-- {
+		result = append(result, records[i])
+	}
+	return result, nil
+}
+
+// recordExecution stores the outcome of an execution in the history, if
+// enabled. The credentials of the input and the environment are redacted,
+// along with the given secrets resolved for the connection.
+func recordExecution(started time.Time, input ExecuteScriptInput, result ExecuteScriptOutput, secrets ...string) {
+	if history == nil {
+		return
+	}
+
+	user := input.User
+	if user == "" {
+		user = os.Getenv("SSH_USER")
+	}
+	host := result.Host
+	if host == "" {
+		host = input.Host
+	}
+	secrets = append(secrets, input.Password, input.KeyData, os.Getenv("SSH_PASSWORD"), os.Getenv("SSH_KEY_PASSPHRASE"))
+	truncate := func(s string) string {
+		s, _ = output.TruncateTo(redactSecrets(s, secrets...), historyOutputBytes, "")
+		return s
+	}
+
+	entry := ExecutionRecord{
+		Timestamp:  started,
+		Host:       host,
+		User:       user,
+		Script:     redactSecrets(input.Script, secrets...),
+		ExitCode:   result.ExitCode,
+		Success:    result.Success,
+		Error:      redactSecrets(result.Error, secrets...),
+		Stdout:     truncate(result.Stdout),
+		Stderr:     truncate(result.Stderr),
+		DurationMs: time.Since(started).Milliseconds(),
+	}
+	if err := history.record(entry); err != nil {
+		log.Printf("Warning: failed to record execution history: %v", err)
+	}
+}
+
+// GetExecutionHistory returns the most recent executions
+func GetExecutionHistory(ctx context.Context, req *mcp.CallToolRequest, input GetExecutionHistoryInput) (
+	*mcp.CallToolResult,
+	GetExecutionHistoryOutput,
+	error,
+) {
+	if history == nil {
+		return nil, GetExecutionHistoryOutput{}, fmt.Errorf("execution history is disabled (set SSH_HISTORY_PATH)")
+	}
+
+	limit := input.Limit
+	if limit <= 0 {
+		limit = 20
+	}
+
+	records, err := history.recent(input.Host, limit)
+	if err != nil {
+		return nil, GetExecutionHistoryOutput{}, err
+	}
+
+	return nil, GetExecutionHistoryOutput{
+		Executions: records,
+		Count:      len(records),
+	}, nil
+}
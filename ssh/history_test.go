@@ -0,0 +1,48 @@
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Execution history", func() {
+	BeforeEach(func() {
+		DeferCleanup(func(previous *executionHistory) { history = previous }, history)
+		history = &executionHistory{path: filepath.Join(GinkgoT().TempDir(), "history.jsonl")}
+		for _, name := range []string{"SSH_PASSWORD", "SSH_KEY_PASSPHRASE", "SSH_USER", "SSH_HOST", "SSH_ALLOWED_COMMANDS"} {
+			GinkgoT().Setenv(name, "")
+		}
+	})
+
+	It("redacts the credentials of the input and key=value secrets", func() {
+		recordExecution(time.Now(), ExecuteScriptInput{Host: "db", Password: "hunter2", Script: "echo hunter2; mysql --password=s3cret"},
+			ExecuteScriptOutput{Stdout: "hunter2\n", Stderr: "token: abc"})
+
+		records, err := history.recent("db", 10)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(records).To(HaveLen(1))
+		Expect(records[0].Script).To(Equal("echo [REDACTED]; mysql --password=[REDACTED]"))
+		Expect(records[0].Stdout).To(Equal("[REDACTED]\n"))
+		Expect(records[0].Stderr).To(Equal("token: [REDACTED]"))
+	})
+
+	It("redacts the credentials read from SSH_SECRETS_FILE", func() {
+		writeSecretsFile(`{"db": {"user": "admin", "password": "filepass", "key_passphrase": "filephrase"}}`)
+		// The denied command fails the call before connecting
+		GinkgoT().Setenv("SSH_DENIED_COMMANDS", "rm")
+
+		_, out, err := ExecuteScript(context.Background(), nil, ExecuteScriptInput{Host: "db", Script: "echo filepass filephrase; rm -rf /tmp/x"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(out.Error).To(ContainSubstring("denied by SSH_DENIED_COMMANDS"))
+
+		records, err := history.recent("db", 10)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(records).To(HaveLen(1))
+		Expect(records[0].Script).To(Equal("echo [REDACTED] [REDACTED]; rm -rf /tmp/x"))
+		Expect(records[0].Script).NotTo(ContainSubstring("filepass"))
+	})
+})
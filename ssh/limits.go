@@ -0,0 +1,143 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+)
+
+const (
+	// defaultMaxConnections and defaultMaxHostConnections bound the SSH
+	// connections open at once in total and to one host, staying under the
+	// default sshd MaxStartups of 10
+	defaultMaxConnections     = 20
+	defaultMaxHostConnections = 5
+)
+
+// connectionLimits bounds the SSH connections open at once, in total and per
+// host. Calls over a limit wait for a connection to close until their
+// timeout. A limit of 0 disables it.
+type connectionLimits struct {
+	maxTotal int
+	maxHost  int
+	total    chan struct{}
+	mutex    sync.Mutex
+	// hosts only holds the hosts in use, so it does not grow with every
+	// host ever connected to
+	hosts map[string]*hostSlots
+}
+
+// hostSlots are the connection slots of a host
+type hostSlots struct {
+	slots chan struct{}
+	// users counts the calls holding or waiting for a slot
+	users int
+}
+
+var connections = newConnectionLimits(defaultMaxConnections, defaultMaxHostConnections)
+
+func newConnectionLimits(maxTotal, maxHost int) *connectionLimits {
+	limits := &connectionLimits{maxTotal: maxTotal, maxHost: maxHost, hosts: map[string]*hostSlots{}}
+	if maxTotal > 0 {
+		limits.total = make(chan struct{}, maxTotal)
+	}
+	return limits
+}
+
+// parseConnectionLimits reads SSH_MAX_CONNECTIONS and
+// SSH_MAX_CONNECTIONS_PER_HOST, using the defaults for empty values
+func parseConnectionLimits(total, perHost string) (*connectionLimits, error) {
+	maxTotal, err := parseLimit("SSH_MAX_CONNECTIONS", total, defaultMaxConnections)
+	if err != nil {
+		return nil, err
+	}
+	maxHost, err := parseLimit("SSH_MAX_CONNECTIONS_PER_HOST", perHost, defaultMaxHostConnections)
+	if err != nil {
+		return nil, err
+	}
+	return newConnectionLimits(maxTotal, maxHost), nil
+}
+
+func parseLimit(name, value string, fallback int) (int, error) {
+	if value == "" {
+		return fallback, nil
+	}
+	limit, err := strconv.Atoi(value)
+	if err != nil || limit < 0 {
+		return 0, fmt.Errorf("invalid %s %q: must be a non-negative integer (0 for no limit)", name, value)
+	}
+	return limit, nil
+}
+
+// describe summarizes the limits for the self-check
+func (l *connectionLimits) describe() string {
+	format := func(limit int) string {
+		if limit == 0 {
+			return "unlimited"
+		}
+		return strconv.Itoa(limit)
+	}
+	return fmt.Sprintf("%s in total, %s per host", format(l.maxTotal), format(l.maxHost))
+}
+
+// acquire waits for a connection slot to address, in total and for the host,
+// until ctx is done. The returned function frees the slot.
+func (l *connectionLimits) acquire(ctx context.Context, address string) (func(), error) {
+	var host chan struct{}
+	if l.maxHost > 0 {
+		host = l.join(address)
+		select {
+		case host <- struct{}{}:
+		case <-ctx.Done():
+			l.leave(address)
+			return nil, fmt.Errorf("too many concurrent connections to %s: %d already open (SSH_MAX_CONNECTIONS_PER_HOST), none closed before the timeout", address, l.maxHost)
+		}
+	}
+
+	if l.total != nil {
+		select {
+		case l.total <- struct{}{}:
+		case <-ctx.Done():
+			if host != nil {
+				<-host
+				l.leave(address)
+			}
+			return nil, fmt.Errorf("too many concurrent SSH connections: %d already open (SSH_MAX_CONNECTIONS), none closed before the timeout", l.maxTotal)
+		}
+	}
+
+	return func() {
+		if l.total != nil {
+			<-l.total
+		}
+		if host != nil {
+			<-host
+			l.leave(address)
+		}
+	}, nil
+}
+
+// join returns the slots of address, counting the call as one of its users
+func (l *connectionLimits) join(address string) chan struct{} {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	host := l.hosts[address]
+	if host == nil {
+		host = &hostSlots{slots: make(chan struct{}, l.maxHost)}
+		l.hosts[address] = host
+	}
+	host.users++
+	return host.slots
+}
+
+// leave ends a call of join, forgetting address once no call uses it
+func (l *connectionLimits) leave(address string) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	if host := l.hosts[address]; host != nil {
+		if host.users--; host.users == 0 {
+			delete(l.hosts, address)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// acquireWithin tries to acquire a slot to address for a short while
+func acquireWithin(limits *connectionLimits, address string) (func(), error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	return limits.acquire(ctx, address)
+}
+
+// hostsInUse returns the number of hosts tracked by limits
+func hostsInUse(limits *connectionLimits) int {
+	limits.mutex.Lock()
+	defer limits.mutex.Unlock()
+	return len(limits.hosts)
+}
+
+var _ = Describe("Connection limits", func() {
+	It("bounds the connections in total", func() {
+		limits := newConnectionLimits(2, 0)
+		release, err := acquireWithin(limits, "a:22")
+		Expect(err).NotTo(HaveOccurred())
+		_, err = acquireWithin(limits, "b:22")
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = acquireWithin(limits, "c:22")
+		Expect(err).To(MatchError(ContainSubstring("SSH_MAX_CONNECTIONS)")))
+
+		release()
+		_, err = acquireWithin(limits, "c:22")
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("bounds the connections to each host", func() {
+		limits := newConnectionLimits(0, 1)
+		release, err := acquireWithin(limits, "a:22")
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = acquireWithin(limits, "a:22")
+		Expect(err).To(MatchError(ContainSubstring("SSH_MAX_CONNECTIONS_PER_HOST")))
+		releaseB, err := acquireWithin(limits, "b:22")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(hostsInUse(limits)).To(Equal(2))
+
+		release()
+		releaseB()
+		Expect(hostsInUse(limits)).To(BeZero())
+	})
+
+	It("hands a freed slot to a waiting call", func() {
+		limits := newConnectionLimits(1, 1)
+		release, err := acquireWithin(limits, "a:22")
+		Expect(err).NotTo(HaveOccurred())
+
+		acquired := make(chan error, 1)
+		go func() {
+			release, err := limits.acquire(context.Background(), "a:22")
+			if err == nil {
+				release()
+			}
+			acquired <- err
+		}()
+		Consistently(acquired, 50*time.Millisecond).ShouldNot(Receive())
+
+		release()
+		Eventually(acquired).Should(Receive(BeNil()))
+		Expect(hostsInUse(limits)).To(BeZero())
+	})
+
+	It("frees the host slot when the total limit is reached", func() {
+		limits := newConnectionLimits(1, 1)
+		release, err := acquireWithin(limits, "a:22")
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = acquireWithin(limits, "b:22")
+		Expect(err).To(MatchError(ContainSubstring("SSH_MAX_CONNECTIONS)")))
+		Expect(hostsInUse(limits)).To(Equal(1))
+
+		release()
+		Expect(hostsInUse(limits)).To(BeZero())
+		release, err = acquireWithin(limits, "b:22")
+		Expect(err).NotTo(HaveOccurred())
+		release()
+	})
+
+	DescribeTable("parseConnectionLimits",
+		func(total, perHost string, maxTotal, maxHost int, message string) {
+			limits, err := parseConnectionLimits(total, perHost)
+			if message != "" {
+				Expect(err).To(MatchError(ContainSubstring(message)))
+				return
+			}
+			Expect(err).NotTo(HaveOccurred())
+			Expect(limits.maxTotal).To(Equal(maxTotal))
+			Expect(limits.maxHost).To(Equal(maxHost))
+		},
+		Entry("defaults", "", "", defaultMaxConnections, defaultMaxHostConnections, ""),
+		Entry("configured", "3", "0", 3, 0, ""),
+		Entry("negative", "-1", "", 0, 0, "invalid SSH_MAX_CONNECTIONS"),
+		Entry("not a number", "", "many", 0, 0, "invalid SSH_MAX_CONNECTIONS_PER_HOST"),
+	)
+})
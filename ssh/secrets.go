@@ -0,0 +1,74 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// hostSecrets are the credentials of a host in SSH_SECRETS_FILE
+type hostSecrets struct {
+	User          string `json:"user,omitempty"`
+	Password      string `json:"password,omitempty"`
+	KeyPath       string `json:"key_path,omitempty"`
+	KeyData       string `json:"key_data,omitempty"`
+	KeyPassphrase string `json:"key_passphrase,omitempty"`
+}
+
+// loadSecretsFile reads a secrets file, a JSON object mapping host names to
+// their credentials, "*" holding those of the other hosts. The file is read
+// on every call so rotated secrets are picked up without a restart.
+func loadSecretsFile(path string) (map[string]hostSecrets, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read secrets file: %w", err)
+	}
+	var secrets map[string]hostSecrets
+	if err := json.Unmarshal(data, &secrets); err != nil {
+		// The error could quote the content of the file
+		var syntaxErr *json.SyntaxError
+		if errors.As(err, &syntaxErr) {
+			return nil, fmt.Errorf("invalid secrets file: JSON syntax error at offset %d", syntaxErr.Offset)
+		}
+		return nil, fmt.Errorf("invalid secrets file: expected an object mapping hosts to credentials")
+	}
+	for host, entry := range secrets {
+		if entry.KeyData != "" {
+			if _, err := decodeKeyData(entry.KeyData); err != nil {
+				return nil, fmt.Errorf("invalid secrets file: %s: %w", host, err)
+			}
+		}
+	}
+	return secrets, nil
+}
+
+// secretsFor returns the credentials SSH_SECRETS_FILE holds for host, empty
+// when the file is not configured
+func secretsFor(host string) (hostSecrets, error) {
+	path := os.Getenv("SSH_SECRETS_FILE")
+	if path == "" {
+		return hostSecrets{}, nil
+	}
+	secrets, err := loadSecretsFile(path)
+	if err != nil {
+		return hostSecrets{}, err
+	}
+	if entry, ok := secrets[host]; ok {
+		return entry, nil
+	}
+	return secrets["*"], nil
+}
+
+// decodeKeyData decodes a base64 encoded private key. Errors never include
+// the key.
+func decodeKeyData(value string) ([]byte, error) {
+	// Encoded keys are often wrapped over several lines
+	key, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(value), ""))
+	if err != nil {
+		return nil, fmt.Errorf("key_data is not valid base64")
+	}
+	return key, nil
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"encoding/base64"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// writeSecretsFile writes a secrets file and points SSH_SECRETS_FILE to it
+func writeSecretsFile(content string) string {
+	path := filepath.Join(GinkgoT().TempDir(), "secrets.json")
+	Expect(os.WriteFile(path, []byte(content), 0600)).To(Succeed())
+	GinkgoT().Setenv("SSH_SECRETS_FILE", path)
+	return path
+}
+
+var _ = Describe("Secrets file", func() {
+	key := base64.StdEncoding.EncodeToString([]byte("-----BEGIN KEY-----\nsecret key\n-----END KEY-----\n"))
+
+	Describe("loadSecretsFile", func() {
+		It("reads the credentials of each host", func() {
+			path := writeSecretsFile(`{"db": {"user": "admin", "password": "hunter2"}, "*": {"user": "ops", "key_data": "` + key + `"}}`)
+			secrets, err := loadSecretsFile(path)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(secrets).To(Equal(map[string]hostSecrets{
+				"db": {User: "admin", Password: "hunter2"},
+				"*":  {User: "ops", KeyData: key},
+			}))
+		})
+
+		DescribeTable("rejects invalid files without quoting them",
+			func(content, message string) {
+				_, err := loadSecretsFile(writeSecretsFile(content))
+				Expect(err).To(MatchError(ContainSubstring(message)))
+				Expect(err.Error()).NotTo(ContainSubstring("hunter2"))
+			},
+			Entry("syntax error", `{"db": {"password": "hunter2"`, "JSON syntax error"),
+			Entry("not an object", `["hunter2"]`, "expected an object"),
+			Entry("invalid key data", `{"db": {"key_data": "hunter2!"}}`, "db: key_data is not valid base64"),
+		)
+
+		It("fails for a missing file", func() {
+			_, err := loadSecretsFile(filepath.Join(GinkgoT().TempDir(), "missing.json"))
+			Expect(err).To(MatchError(ContainSubstring("failed to read secrets file")))
+		})
+	})
+
+	Describe("secretsFor", func() {
+		It("returns nothing without a secrets file", func() {
+			GinkgoT().Setenv("SSH_SECRETS_FILE", "")
+			Expect(secretsFor("db")).To(Equal(hostSecrets{}))
+		})
+
+		It("falls back to the default entry for other hosts", func() {
+			writeSecretsFile(`{"db": {"user": "admin", "password": "hunter2"}, "*": {"user": "ops"}}`)
+			Expect(secretsFor("db")).To(Equal(hostSecrets{User: "admin", Password: "hunter2"}))
+			Expect(secretsFor("web")).To(Equal(hostSecrets{User: "ops"}))
+		})
+
+		It("returns nothing for other hosts without a default entry", func() {
+			writeSecretsFile(`{"db": {"user": "admin"}}`)
+			Expect(secretsFor("web")).To(Equal(hostSecrets{}))
+		})
+	})
+
+	Describe("decodeKeyData", func() {
+		It("decodes keys wrapped over several lines", func() {
+			wrapped := key[:10] + "\n" + key[10:20] + "\r\n  " + key[20:]
+			Expect(decodeKeyData(wrapped)).To(BeEquivalentTo("-----BEGIN KEY-----\nsecret key\n-----END KEY-----\n"))
+		})
+
+		It("does not quote invalid keys", func() {
+			_, err := decodeKeyData("hunter2!")
+			Expect(err).To(MatchError("key_data is not valid base64"))
+		})
+	})
+})
@@ -0,0 +1,223 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// CommandTemplate is a vetted script exposed as its own tool, configured with
+// SSH_TEMPLATES
+type CommandTemplate struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description"`
+	Script      string          `json:"script"`
+	Params      []TemplateParam `json:"params,omitempty"`
+	Timeout     int             `json:"timeout,omitempty"`
+}
+
+// TemplateParam is a parameter of a command template, referenced in the
+// script as {{name}}
+type TemplateParam struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	// Pattern is a regular expression the whole value must match
+	Pattern string `json:"pattern,omitempty"`
+	// Default is used when the parameter is omitted. Parameters without a
+	// default are required.
+	Default string `json:"default,omitempty"`
+
+	pattern *regexp.Regexp
+}
+
+// RunTemplateInput represents the input of a command template tool
+type RunTemplateInput struct {
+	Host    string            `json:"host,omitempty" jsonschema:"the SSH host to run the command on (default: SSH_HOST env var)"`
+	Params  map[string]string `json:"params,omitempty" jsonschema:"values of the template parameters, by name"`
+	Timeout int               `json:"timeout,omitempty" jsonschema:"optional timeout in seconds (default: the template timeout, or 30)"`
+}
+
+// templateName is the allowed form of template and parameter names
+var templateName = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9_]*$`)
+
+// templatePlaceholder matches a {{name}} placeholder in a template script
+var templatePlaceholder = regexp.MustCompile(`\{\{\s*([a-zA-Z][a-zA-Z0-9_]*)\s*\}\}`)
+
+// parseTemplates reads and validates the SSH_TEMPLATES configuration. An
+// empty value configures no templates. reserved lists the names of the other
+// tools, which templates cannot take.
+func parseTemplates(templatesJSON string, reserved ...string) ([]CommandTemplate, error) {
+	if strings.TrimSpace(templatesJSON) == "" {
+		return nil, nil
+	}
+
+	var templates []CommandTemplate
+	if err := json.Unmarshal([]byte(templatesJSON), &templates); err != nil {
+		return nil, fmt.Errorf("failed to parse SSH_TEMPLATES JSON: %w", err)
+	}
+
+	names := slices.Clone(reserved)
+	for i := range templates {
+		template := &templates[i]
+		if !templateName.MatchString(template.Name) {
+			return nil, fmt.Errorf("template at index %d: name %q must start with a letter and contain only letters, digits and underscores", i, template.Name)
+		}
+		if slices.Contains(names, template.Name) {
+			return nil, fmt.Errorf("template %q: name is already used by another tool", template.Name)
+		}
+		names = append(names, template.Name)
+		if template.Description == "" {
+			return nil, fmt.Errorf("template %q: description is required", template.Name)
+		}
+		if strings.TrimSpace(template.Script) == "" {
+			return nil, fmt.Errorf("template %q: script is required", template.Name)
+		}
+		if template.Timeout < 0 {
+			return nil, fmt.Errorf("template %q: timeout must not be negative", template.Name)
+		}
+
+		var params []string
+		for j := range template.Params {
+			param := &template.Params[j]
+			if !templateName.MatchString(param.Name) {
+				return nil, fmt.Errorf("template %q: parameter name %q must start with a letter and contain only letters, digits and underscores", template.Name, param.Name)
+			}
+			if slices.Contains(params, param.Name) {
+				return nil, fmt.Errorf("template %q: parameter %q is listed twice", template.Name, param.Name)
+			}
+			params = append(params, param.Name)
+			if param.Pattern != "" {
+				pattern, err := regexp.Compile("^(?:" + param.Pattern + ")$")
+				if err != nil {
+					return nil, fmt.Errorf("template %q: invalid pattern for parameter %q: %w", template.Name, param.Name, err)
+				}
+				param.pattern = pattern
+				if param.Default != "" && !pattern.MatchString(param.Default) {
+					return nil, fmt.Errorf("template %q: default of parameter %q does not match its pattern", template.Name, param.Name)
+				}
+			}
+		}
+
+		if name, ok := quotedPlaceholder(template.Script); ok {
+			return nil, fmt.Errorf("template %q: placeholder {{%s}} must not be inside quotes, values are quoted when substituted", template.Name, name)
+		}
+		used := map[string]bool{}
+		for _, match := range templatePlaceholder.FindAllStringSubmatch(template.Script, -1) {
+			if !slices.Contains(params, match[1]) {
+				return nil, fmt.Errorf("template %q: script references undeclared parameter %q", template.Name, match[1])
+			}
+			used[match[1]] = true
+		}
+		for _, param := range params {
+			if !used[param] {
+				return nil, fmt.Errorf("template %q: parameter %q is not used in the script", template.Name, param)
+			}
+		}
+	}
+
+	return templates, nil
+}
+
+// quotedPlaceholder returns the name of the first placeholder written inside
+// single or double quotes. Substituted values are single-quoted, which
+// surrounding quotes would undo: '{{x}}' would leave the value unquoted.
+func quotedPlaceholder(script string) (string, bool) {
+	matches := templatePlaceholder.FindAllStringSubmatchIndex(script, -1)
+	single, double := false, false
+	for i := 0; i < len(script) && len(matches) > 0; i++ {
+		if i >= matches[0][0] {
+			if single || double {
+				return script[matches[0][2]:matches[0][3]], true
+			}
+			i = matches[0][1] - 1
+			matches = matches[1:]
+			continue
+		}
+		c := script[i]
+		switch {
+		case single:
+			single = c != '\''
+		case c == '\\':
+			i++
+		case c == '\'' && !double:
+			single = true
+		case c == '"':
+			double = !double
+		}
+	}
+	return "", false
+}
+
+// render validates the parameter values and substitutes them, shell-quoted,
+// into the script. Quoting keeps every value a single word, so values cannot
+// inject commands.
+func (t CommandTemplate) render(values map[string]string) (string, error) {
+	for name := range values {
+		if !slices.ContainsFunc(t.Params, func(param TemplateParam) bool { return param.Name == name }) {
+			return "", fmt.Errorf("unknown parameter %q for %s", name, t.Name)
+		}
+	}
+
+	quoted := map[string]string{}
+	for _, param := range t.Params {
+		value, ok := values[param.Name]
+		if !ok || value == "" {
+			if param.Default == "" {
+				return "", fmt.Errorf("parameter %q is required", param.Name)
+			}
+			value = param.Default
+		}
+		if param.pattern != nil && !param.pattern.MatchString(value) {
+			return "", fmt.Errorf("invalid value for parameter %q: must match %s", param.Name, param.Pattern)
+		}
+		quoted[param.Name] = shellQuote(value)
+	}
+
+	return templatePlaceholder.ReplaceAllStringFunc(t.Script, func(placeholder string) string {
+		return quoted[templatePlaceholder.FindStringSubmatch(placeholder)[1]]
+	}), nil
+}
+
+// toolDescription describes the template and its parameters to the model
+func (t CommandTemplate) toolDescription() string {
+	if len(t.Params) == 0 {
+		return t.Description
+	}
+	var params []string
+	for _, param := range t.Params {
+		desc := param.Name
+		if param.Description != "" {
+			desc += ": " + param.Description
+		}
+		if param.Default != "" {
+			desc += fmt.Sprintf(" (default: %s)", param.Default)
+		}
+		params = append(params, desc)
+	}
+	return t.Description + "\nParameters (pass in params): " + strings.Join(params, "; ")
+}
+
+// createTemplateHandler creates the handler of a template tool. The rendered
+// script runs like any other, so the command policy and the history apply.
+func createTemplateHandler(template CommandTemplate) func(context.Context, *mcp.CallToolRequest, RunTemplateInput) (*mcp.CallToolResult, ExecuteScriptOutput, error) {
+	return func(ctx context.Context, req *mcp.CallToolRequest, input RunTemplateInput) (*mcp.CallToolResult, ExecuteScriptOutput, error) {
+		script, err := template.render(input.Params)
+		if err != nil {
+			return nil, ExecuteScriptOutput{Error: err.Error()}, nil
+		}
+		timeout := input.Timeout
+		if timeout == 0 {
+			timeout = template.Timeout
+		}
+		return ExecuteScript(ctx, req, ExecuteScriptInput{
+			Host:    input.Host,
+			Script:  script,
+			Timeout: timeout,
+		})
+	}
+}
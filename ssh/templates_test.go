@@ -0,0 +1,128 @@
+package main
+
+import (
+	"encoding/json"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// mustParseTemplate parses a single template
+func mustParseTemplate(templateJSON string) CommandTemplate {
+	templates, err := parseTemplates("[" + templateJSON + "]")
+	Expect(err).NotTo(HaveOccurred())
+	Expect(templates).To(HaveLen(1))
+	return templates[0]
+}
+
+var _ = Describe("Command templates", func() {
+	Describe("parseTemplates", func() {
+		It("configures no templates when empty", func() {
+			templates, err := parseTemplates("  ")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(templates).To(BeNil())
+		})
+
+		It("rejects invalid templates", func() {
+			for templateJSON, message := range map[string]string{
+				`{"name": "1bad", "description": "d", "script": "ls"}`:                                                                    "must start with a letter",
+				`{"name": "execute_script", "description": "d", "script": "ls"}`:                                                          "already used by another tool",
+				`{"name": "t", "script": "ls"}`:                                                                                           "description is required",
+				`{"name": "t", "description": "d", "script": " "}`:                                                                        "script is required",
+				`{"name": "t", "description": "d", "script": "ls", "timeout": -1}`:                                                        "timeout must not be negative",
+				`{"name": "t", "description": "d", "script": "ls {{dir}}"}`:                                                               `undeclared parameter "dir"`,
+				`{"name": "t", "description": "d", "script": "ls", "params": [{"name": "dir"}]}`:                                          `parameter "dir" is not used`,
+				`{"name": "t", "description": "d", "script": "ls {{dir}}", "params": [{"name": "dir"}, {"name": "dir"}]}`:                 "listed twice",
+				`{"name": "t", "description": "d", "script": "ls {{dir}}", "params": [{"name": "dir", "pattern": "("}]}`:                  "invalid pattern",
+				`{"name": "t", "description": "d", "script": "ls {{n}}", "params": [{"name": "n", "pattern": "[0-9]+", "default": "x"}]}`: "does not match its pattern",
+			} {
+				_, err := parseTemplates("["+templateJSON+"]", "execute_script")
+				Expect(err).To(MatchError(ContainSubstring(message)), templateJSON)
+			}
+		})
+
+		It("rejects placeholders inside quotes", func() {
+			for _, script := range []string{
+				`echo '{{x}}'`,
+				`echo "{{x}}"`,
+				`echo "value: {{ x }} done"`,
+				`grep 'a' "b {{x}}"`,
+			} {
+				_, err := parseTemplates(`[{"name": "t", "description": "d", "script": ` + jsonString(script) + `, "params": [{"name": "x"}]}]`)
+				Expect(err).To(MatchError(ContainSubstring("placeholder {{x}} must not be inside quotes")), script)
+			}
+		})
+
+		It("accepts placeholders outside quotes", func() {
+			for _, script := range []string{
+				`echo {{x}}`,
+				`echo 'a' {{x}} "b"`,
+				`echo "it's" {{x}}`,
+				`echo \" {{x}} \'`,
+				`echo $(cat {{x}})`,
+			} {
+				_, err := parseTemplates(`[{"name": "t", "description": "d", "script": ` + jsonString(script) + `, "params": [{"name": "x"}]}]`)
+				Expect(err).NotTo(HaveOccurred(), script)
+			}
+		})
+	})
+
+	Describe("render", func() {
+		var template CommandTemplate
+
+		BeforeEach(func() {
+			template = mustParseTemplate(`{
+				"name": "service_status",
+				"description": "Show a service",
+				"script": "systemctl status {{service}} --lines {{lines}}",
+				"params": [
+					{"name": "service", "pattern": "[a-zA-Z0-9@._-]+"},
+					{"name": "lines", "pattern": "[0-9]+", "default": "20"}
+				]
+			}`)
+		})
+
+		It("substitutes quoted values and defaults", func() {
+			script, err := template.render(map[string]string{"service": "nginx"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(script).To(Equal("systemctl status 'nginx' --lines '20'"))
+
+			script, err = template.render(map[string]string{"service": "nginx", "lines": "5"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(script).To(Equal("systemctl status 'nginx' --lines '5'"))
+		})
+
+		It("requires parameters without a default", func() {
+			_, err := template.render(nil)
+			Expect(err).To(MatchError(`parameter "service" is required`))
+			_, err = template.render(map[string]string{"service": ""})
+			Expect(err).To(MatchError(`parameter "service" is required`))
+		})
+
+		It("checks values against the whole pattern", func() {
+			_, err := template.render(map[string]string{"service": "nginx; rm -rf /"})
+			Expect(err).To(MatchError(ContainSubstring(`invalid value for parameter "service"`)))
+			_, err = template.render(map[string]string{"service": "nginx", "lines": "5x"})
+			Expect(err).To(MatchError(ContainSubstring(`invalid value for parameter "lines"`)))
+		})
+
+		It("rejects unknown parameters", func() {
+			_, err := template.render(map[string]string{"service": "nginx", "user": "root"})
+			Expect(err).To(MatchError(`unknown parameter "user" for service_status`))
+		})
+
+		It("keeps values without a pattern a single word", func() {
+			template := mustParseTemplate(`{"name": "t", "description": "d", "script": "grep -r {{text}} /var/log", "params": [{"name": "text"}]}`)
+			script, err := template.render(map[string]string{"text": "it's $(rm -rf /); `id`"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(script).To(Equal(`grep -r 'it'\''s $(rm -rf /); ` + "`id`" + `' /var/log`))
+		})
+	})
+})
+
+// jsonString encodes s as a JSON string
+func jsonString(s string) string {
+	data, err := json.Marshal(s)
+	Expect(err).NotTo(HaveOccurred())
+	return string(data)
+}
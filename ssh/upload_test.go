@@ -0,0 +1,58 @@
+package main
+
+import (
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Script upload", func() {
+	BeforeEach(func() {
+		GinkgoT().Setenv("SSH_SCRIPT_UPLOAD", "")
+		GinkgoT().Setenv("SSH_SCRIPT_UPLOAD_THRESHOLD", "")
+	})
+
+	DescribeTable("shouldUploadScript",
+		func(mode, threshold, script string, upload bool) {
+			GinkgoT().Setenv("SSH_SCRIPT_UPLOAD", mode)
+			GinkgoT().Setenv("SSH_SCRIPT_UPLOAD_THRESHOLD", threshold)
+			Expect(shouldUploadScript(script)).To(Equal(upload))
+		},
+		Entry("short one-liner", "", "", "uptime", false),
+		Entry("multi-line script", "", "", "cd /tmp\nls", true),
+		Entry("carriage return", "", "", "ls\r", true),
+		Entry("tab", "", "", "ls\t-la", true),
+		Entry("over the default threshold", "", "", strings.Repeat("x", defaultUploadThreshold+1), true),
+		Entry("at the default threshold", "", "", strings.Repeat("x", defaultUploadThreshold), false),
+		Entry("over a configured threshold", "", "4", "uptime", true),
+		Entry("invalid threshold, using the default", "", "lots", "uptime", false),
+		Entry("negative threshold, using the default", "", "-1", "uptime", false),
+		Entry("always", "always", "", "uptime", true),
+		Entry("always, any case", "ALWAYS", "", "uptime", true),
+		Entry("never", "never", "", "cd /tmp\nls", false),
+	)
+
+	DescribeTable("scriptShell",
+		func(shellCmd, expected string) {
+			Expect(scriptShell(strings.Fields(shellCmd))).To(Equal(expected))
+		},
+		Entry("sh -c", "sh -c", "sh"),
+		Entry("combined flags", "bash -lc", "bash -l"),
+		Entry("several flags", "bash -e -c", "bash -e"),
+		Entry("no flag", "bash", "bash"),
+		Entry("other trailing flag", "zsh -l", "zsh -l"),
+	)
+
+	DescribeTable("scriptCommand",
+		func(shellCmd, script, uploadedPath, expected string) {
+			Expect(scriptCommand(strings.Fields(shellCmd), script, uploadedPath)).To(Equal(expected))
+		},
+		Entry("inline with sh -c", "sh -c", `echo "hi"`, "", `sh -c "echo \"hi\""`),
+		Entry("inline with a shell alone", "bash", "uptime", "", `bash -c "uptime"`),
+		Entry("uploaded", "bash -lc", "cd /tmp\nls", "/tmp/mcp-ssh-script.abc",
+			`bash -l '/tmp/mcp-ssh-script.abc'; status=$?; rm -f '/tmp/mcp-ssh-script.abc'; exit $status`),
+		Entry("uploaded to a path with a quote", "sh -c", "ls", "/tmp/it's",
+			`sh '/tmp/it'\''s'; status=$?; rm -f '/tmp/it'\''s'; exit $status`),
+	)
+})
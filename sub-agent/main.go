@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"log"
 	"os"
 	"strconv"
@@ -10,6 +11,8 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/mudler/mcps/pkg/selfcheck"
+	"github.com/mudler/mcps/pkg/transport"
 	"github.com/sashabaranov/go-openai"
 )
 
@@ -257,7 +260,39 @@ func GetSubAgentResult(ctx context.Context, req *mcp.CallToolRequest, input GetI
 	}, nil
 }
 
+// selfChecks lists the models of the endpoint to check the base URL and API
+// key, and that the default model is served
+func selfChecks() []selfcheck.Check {
+	return []selfcheck.Check{
+		{Name: "OPENAI_MODEL", Run: func(context.Context) (string, error) {
+			if openaiModel == "" {
+				return "", selfcheck.Warn(fmt.Errorf("not set, every call must pass a model"))
+			}
+			return openaiModel, nil
+		}},
+		{Name: "api", Run: func(ctx context.Context) (string, error) {
+			models, err := openaiClient.ListModels(ctx)
+			if err != nil {
+				return "", fmt.Errorf("listing models: %w", err)
+			}
+			if openaiModel == "" {
+				return fmt.Sprintf("%d models available", len(models.Models)), nil
+			}
+			for _, model := range models.Models {
+				if model.ID == openaiModel {
+					return fmt.Sprintf("%d models available, including %s", len(models.Models), openaiModel), nil
+				}
+			}
+			return "", selfcheck.Warn(fmt.Errorf("endpoint reachable but does not list model %q", openaiModel))
+		}},
+	}
+}
+
 func main() {
+	if selfcheck.Enabled() {
+		selfcheck.Exit("sub-agent", selfChecks()...)
+	}
+
 	// Create a server with the sub-agent tools
 	server := mcp.NewServer(&mcp.Implementation{Name: "sub-agent", Version: "v1.0.0"}, nil)
 
@@ -279,7 +314,7 @@ func main() {
 		Description: "Get the result of a completed sub-agent call by task ID.",
 	}, GetSubAgentResult)
 
-	if err := server.Run(context.Background(), &mcp.StdioTransport{}); err != nil {
+	if err := transport.Run(context.Background(), server); err != nil {
 		log.Fatal(err)
 	}
 }
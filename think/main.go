@@ -6,6 +6,8 @@ import (
 	"log"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/mudler/mcps/pkg/selfcheck"
+	"github.com/mudler/mcps/pkg/transport"
 )
 
 type Input struct {
@@ -34,10 +36,14 @@ func Think(ctx context.Context, req *mcp.CallToolRequest, input Input) (
 }
 
 func main() {
+	if selfcheck.Enabled() {
+		selfcheck.Exit("think")
+	}
+
 	// Create a server with a single tool.
 	server := mcp.NewServer(&mcp.Implementation{Name: "think", Version: "v1.0.0"}, nil)
 	mcp.AddTool(server, &mcp.Tool{Name: "think", Description: "A no-op tool that forces the model to think about a message"}, Think)
-	if err := server.Run(context.Background(), &mcp.StdioTransport{}); err != nil {
+	if err := transport.Run(context.Background(), server); err != nil {
 		log.Fatal(err)
 	}
 }
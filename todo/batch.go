@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/mudler/mcps/pkg/batch"
+)
+
+// memoryStorage holds the TODO list of a batch until it is committed. Load
+// returns a copy, so an operation failing half-way leaves the list unchanged.
+type memoryStorage struct {
+	list    *TODOList
+	changed bool
+}
+
+func (m *memoryStorage) Load() (*TODOList, error) {
+	data, err := json.Marshal(m.list)
+	if err != nil {
+		return nil, err
+	}
+	list := &TODOList{}
+	if err := json.Unmarshal(data, list); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+func (m *memoryStorage) Save(list *TODOList) error {
+	m.list = list
+	m.changed = true
+	return nil
+}
+
+// WithLock runs fn directly, the batch holding the lock of the real storage
+func (m *memoryStorage) WithLock(fn func() error) error {
+	return fn()
+}
+
+// batchHandlers returns the tools a batch may call. archive_done_todos is
+// left out as it writes the archive, which is not part of the transaction.
+func batchHandlers(adminMode bool) batch.Handlers {
+	handlers := batch.Handlers{
+		"update_todo_status":    batch.Tool(NewUpdateTODOStatusHandler(adminMode)),
+		"list_todos":            batch.Tool(ListTODOs),
+		"list_archived":         batch.Tool(ListArchivedTODOs),
+		"get_todo_status":       batch.Tool(GetTODOStatus),
+		"get_ready_todos":       batch.Tool(GetReadyTODOs),
+		"get_blocked_todos":     batch.Tool(GetBlockedTODOs),
+		"get_overdue_todos":     batch.Tool(GetOverdueTODOs),
+		"get_todo_dependencies": batch.Tool(GetTODODependencies),
+		"export_graph":          batch.Tool(ExportGraph),
+	}
+	if adminMode {
+		handlers["add_todo"] = batch.Tool(NewAddTODOHandler(adminMode))
+		handlers["update_todo_assignee"] = batch.Tool(NewUpdateTODOAssigneeHandler(adminMode))
+		handlers["update_todo_due"] = batch.Tool(NewUpdateTODODueHandler(adminMode))
+		handlers["remove_todo"] = batch.Tool(NewRemoveTODOHandler(adminMode))
+		handlers["add_todo_dependency"] = batch.Tool(NewAddTODODependencyHandler(adminMode))
+		handlers["remove_todo_dependency"] = batch.Tool(NewRemoveTODODependencyHandler(adminMode))
+	}
+	return handlers
+}
+
+// NewBatchHandler returns a handler running operations with handlers as a
+// transaction: the TODO list stays locked for the whole batch, and is only
+// saved once all operations ran, or not at all when the batch stops on a
+// failure
+func NewBatchHandler(handlers batch.Handlers) func(context.Context, *mcp.CallToolRequest, batch.Input) (*mcp.CallToolResult, batch.Output, error) {
+	return func(ctx context.Context, req *mcp.CallToolRequest, input batch.Input) (*mcp.CallToolResult, batch.Output, error) {
+		service := getService(ctx)
+		if service == nil {
+			return nil, batch.Output{}, fmt.Errorf("service not initialized")
+		}
+		if err := handlers.Validate(input); err != nil {
+			return nil, batch.Output{}, err
+		}
+
+		var output batch.Output
+		err := service.storage.WithLock(func() error {
+			list, err := service.storage.Load()
+			if err != nil {
+				return err
+			}
+			transaction := &memoryStorage{list: list}
+			batchService := *service
+			batchService.storage = transaction
+
+			output, err = handlers.Run(context.WithValue(ctx, serviceKey{}, &batchService), req, input)
+			if err != nil {
+				return err
+			}
+			if output.Stopped(input) {
+				output.RolledBack = transaction.changed
+				return nil
+			}
+			if !transaction.changed {
+				return nil
+			}
+			return service.storage.Save(transaction.list)
+		})
+		if err != nil {
+			return nil, batch.Output{}, err
+		}
+
+		return nil, output, nil
+	}
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+
+	"github.com/mudler/mcps/pkg/batch"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Batch", func() {
+	var (
+		storage *FileStorage
+		handler = NewBatchHandler(batchHandlers(true))
+	)
+
+	BeforeEach(func() {
+		tempDir, err := os.MkdirTemp("", "todo-batch-test-*")
+		Expect(err).NotTo(HaveOccurred())
+		DeferCleanup(os.RemoveAll, tempDir)
+
+		storage = NewFileStorage(filepath.Join(tempDir, "todos.json"))
+		prevService := globalService
+		DeferCleanup(func() { setGlobalService(prevService) })
+		setGlobalService(NewService(storage))
+	})
+
+	ids := func() []string {
+		list, err := storage.Load()
+		Expect(err).NotTo(HaveOccurred())
+		var ids []string
+		for _, item := range list.Items {
+			ids = append(ids, item.ID)
+		}
+		return ids
+	}
+
+	It("runs the operations in order and saves their changes", func() {
+		_, output, err := handler(context.Background(), nil, batch.Input{Operations: []batch.Operation{
+			{Tool: "add_todo", Arguments: map[string]any{"id": "a", "title": "First"}},
+			{Tool: "add_todo", Arguments: map[string]any{"id": "b", "title": "Second", "depends_on": []string{"a"}}},
+			{Tool: "update_todo_status", Arguments: map[string]any{"id": "a", "status": "in_progress"}},
+			{Tool: "get_ready_todos"},
+		}})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(output.Succeeded).To(Equal(4))
+		Expect(output.Failed).To(BeZero())
+		Expect(output.RolledBack).To(BeFalse())
+		Expect(output.Results[3].Output).To(BeAssignableToTypeOf(GetReadyTODOsOutput{}))
+		Expect(output.Results[3].Output.(GetReadyTODOsOutput).Count).To(BeZero())
+		Expect(ids()).To(Equal([]string{"a", "b"}))
+	})
+
+	It("discards all changes when it stops on a failure", func() {
+		_, output, err := handler(context.Background(), nil, batch.Input{Operations: []batch.Operation{
+			{Tool: "add_todo", Arguments: map[string]any{"id": "a", "title": "First"}},
+			{Tool: "add_todo", Arguments: map[string]any{"id": "b", "title": "Second", "depends_on": []string{"missing"}}},
+			{Tool: "add_todo", Arguments: map[string]any{"id": "c", "title": "Third"}},
+		}})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(output.Succeeded).To(Equal(1))
+		Expect(output.Failed).To(Equal(1))
+		Expect(output.Skipped).To(Equal(1))
+		Expect(output.RolledBack).To(BeTrue())
+		Expect(output.Results[1].Error).To(ContainSubstring("missing"))
+		Expect(ids()).To(BeEmpty())
+	})
+
+	It("saves the successful operations with continue_on_error", func() {
+		_, output, err := handler(context.Background(), nil, batch.Input{ContinueOnError: true, Operations: []batch.Operation{
+			{Tool: "add_todo", Arguments: map[string]any{"id": "a", "title": "First"}},
+			{Tool: "add_todo", Arguments: map[string]any{"id": "a", "title": "Duplicate"}},
+			{Tool: "add_todo", Arguments: map[string]any{"id": "c", "title": "Third"}},
+		}})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(output.Succeeded).To(Equal(2))
+		Expect(output.Failed).To(Equal(1))
+		Expect(output.RolledBack).To(BeFalse())
+		Expect(ids()).To(Equal([]string{"a", "c"}))
+	})
+
+	It("rejects tools that cannot be batched before running anything", func() {
+		_, _, err := handler(context.Background(), nil, batch.Input{Operations: []batch.Operation{
+			{Tool: "add_todo", Arguments: map[string]any{"id": "a", "title": "First"}},
+			{Tool: "archive_done_todos"},
+		}})
+		Expect(err).To(MatchError(ContainSubstring(`unknown tool "archive_done_todos"`)))
+		Expect(ids()).To(BeEmpty())
+	})
+
+	It("only offers admin tools in admin mode", func() {
+		Expect(batchHandlers(false).Names()).NotTo(ContainElement("add_todo"))
+		Expect(batchHandlers(false).Names()).To(ContainElement("update_todo_status"))
+	})
+})
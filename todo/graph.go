@@ -0,0 +1,121 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Graph formats supported by export_graph
+const (
+	graphFormatDOT     = "dot"
+	graphFormatMermaid = "mermaid"
+)
+
+// ExportGraph renders the dependency graph in DOT or Mermaid format, nodes
+// labeled with the ID, title, status and assignee of the items and edges
+// pointing from an item to the items it depends on. With an ID, only that
+// item and its transitive dependencies are included.
+func (s *Service) ExportGraph(format, id string) (*ExportGraphOutput, error) {
+	format = strings.ToLower(strings.TrimSpace(format))
+	if format == "" {
+		format = graphFormatDOT
+	}
+	if format != graphFormatDOT && format != graphFormatMermaid {
+		return nil, fmt.Errorf("invalid format '%s': must be %s or %s", format, graphFormatDOT, graphFormatMermaid)
+	}
+
+	var result *ExportGraphOutput
+	err := s.storage.WithLock(func() error {
+		list, err := s.storage.Load()
+		if err != nil {
+			return err
+		}
+
+		nodes := list.Items
+		if id != "" {
+			item := s.findTODOByID(list, id)
+			if item == nil {
+				return fmt.Errorf("TODO item with ID '%s' not found", id)
+			}
+			nodes = []TODOItem{*item}
+			s.collectDependencies(list, item.DependsOn, map[string]bool{item.ID: true}, func(dep *TODOItem) {
+				nodes = append(nodes, *dep)
+			})
+		}
+
+		result = renderGraph(format, nodes)
+		return nil
+	})
+	return result, err
+}
+
+// renderGraph renders the nodes and the dependencies between them, in the
+// order of nodes
+func renderGraph(format string, nodes []TODOItem) *ExportGraphOutput {
+	// Mermaid node IDs cannot contain most punctuation, so nodes are numbered
+	names := make(map[string]string, len(nodes))
+	for i, item := range nodes {
+		if format == graphFormatMermaid {
+			names[item.ID] = fmt.Sprintf("n%d", i)
+		} else {
+			names[item.ID] = dotQuote(item.ID)
+		}
+	}
+
+	var b strings.Builder
+	if format == graphFormatMermaid {
+		b.WriteString("graph LR\n")
+	} else {
+		b.WriteString("digraph todos {\n  rankdir=LR;\n  node [shape=box];\n")
+	}
+
+	for _, item := range nodes {
+		label := []string{item.ID + ": " + item.Title, item.Status}
+		if item.Assignee != "" {
+			label[1] += ", " + item.Assignee
+		}
+		if format == graphFormatMermaid {
+			fmt.Fprintf(&b, "  %s[\"%s\"]\n", names[item.ID], mermaidEscape(strings.Join(label, "<br/>")))
+		} else {
+			fmt.Fprintf(&b, "  %s [label=%s];\n", names[item.ID], dotQuote(strings.Join(label, "\n")))
+		}
+	}
+
+	edges := 0
+	for _, item := range nodes {
+		for _, depID := range item.DependsOn {
+			dep, ok := names[depID]
+			if !ok {
+				continue
+			}
+			edges++
+			if format == graphFormatMermaid {
+				fmt.Fprintf(&b, "  %s --> %s\n", names[item.ID], dep)
+			} else {
+				fmt.Fprintf(&b, "  %s -> %s;\n", names[item.ID], dep)
+			}
+		}
+	}
+
+	if format == graphFormatDOT {
+		b.WriteString("}\n")
+	}
+
+	return &ExportGraphOutput{
+		Format: format,
+		Graph:  b.String(),
+		Nodes:  len(nodes),
+		Edges:  edges,
+	}
+}
+
+// dotQuote quotes a DOT identifier or label, newlines becoming line breaks
+func dotQuote(value string) string {
+	value = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+	return `"` + value + `"`
+}
+
+// mermaidEscape escapes the characters that end or break a quoted Mermaid label
+func mermaidEscape(value string) string {
+	return strings.NewReplacer(`"`, "#quot;", "\n", " ").Replace(value)
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ExportGraph", func() {
+	var service *Service
+
+	BeforeEach(func() {
+		service = NewService(NewMockStorage())
+		_, _ = service.AddTODO("todo-1", "Design", "alice", nil)
+		_, _ = service.AddTODO("todo-2", `Build "core"`, "", []string{"todo-1"})
+		_, _ = service.AddTODO("todo-3", "Ship", "bob", []string{"todo-2", "todo-1"})
+		_, _ = service.AddTODO("todo-4", "Unrelated", "", nil)
+	})
+
+	It("should export the whole list in DOT by default", func() {
+		graph, err := service.ExportGraph("", "")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(graph.Format).To(Equal("dot"))
+		Expect(graph.Nodes).To(Equal(4))
+		Expect(graph.Edges).To(Equal(3))
+		Expect(graph.Graph).To(Equal(`digraph todos {
+  rankdir=LR;
+  node [shape=box];
+  "todo-1" [label="todo-1: Design\npending, alice"];
+  "todo-2" [label="todo-2: Build \"core\"\npending"];
+  "todo-3" [label="todo-3: Ship\npending, bob"];
+  "todo-4" [label="todo-4: Unrelated\npending"];
+  "todo-2" -> "todo-1";
+  "todo-3" -> "todo-2";
+  "todo-3" -> "todo-1";
+}
+`))
+	})
+
+	It("should export in Mermaid", func() {
+		graph, err := service.ExportGraph("mermaid", "")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(graph.Format).To(Equal("mermaid"))
+		Expect(graph.Graph).To(HavePrefix("graph LR\n"))
+		Expect(graph.Graph).To(ContainSubstring(`  n1["todo-2: Build #quot;core#quot;<br/>pending"]`))
+		Expect(graph.Graph).To(ContainSubstring("  n2 --> n1\n"))
+		Expect(graph.Graph).To(ContainSubstring("  n2 --> n0\n"))
+	})
+
+	It("should limit the graph to an item and its transitive dependencies", func() {
+		Expect(service.UpdateStatus("todo-1", "in_progress")).To(Succeed())
+		graph, err := service.ExportGraph("dot", "todo-2")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(graph.Nodes).To(Equal(2))
+		Expect(graph.Edges).To(Equal(1))
+		Expect(graph.Graph).To(ContainSubstring(`"todo-1" [label="todo-1: Design\nin_progress, alice"];`))
+		Expect(graph.Graph).NotTo(ContainSubstring("todo-3"))
+		Expect(graph.Graph).NotTo(ContainSubstring("todo-4"))
+	})
+
+	It("should return an error for an unknown item", func() {
+		_, err := service.ExportGraph("dot", "missing")
+		Expect(err).To(MatchError(ContainSubstring("not found")))
+	})
+
+	It("should return an error for an unknown format", func() {
+		_, err := service.ExportGraph("svg", "")
+		Expect(err).To(MatchError(ContainSubstring("invalid format")))
+	})
+})
@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
@@ -14,11 +15,30 @@ func setGlobalService(service *Service) {
 	globalService = service
 }
 
-// getService returns the global service instance
-func getService() *Service {
+// serviceKey is the context key of the service operations of a batch run on
+type serviceKey struct{}
+
+// getService returns the service of the batch ctx belongs to, or the global
+// service instance
+func getService(ctx context.Context) *Service {
+	if service, ok := ctx.Value(serviceKey{}).(*Service); ok {
+		return service
+	}
 	return globalService
 }
 
+// parseDueAt parses an optional RFC3339 due date, returning nil when empty
+func parseDueAt(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	dueAt, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid due_at '%s': must be in RFC3339 format (e.g. 2025-01-31T17:00:00Z)", value)
+	}
+	return &dueAt, nil
+}
+
 // NewAddTODOHandler returns a handler configured for admin mode
 func NewAddTODOHandler(adminMode bool) func(context.Context, *mcp.CallToolRequest, AddTODOInput) (*mcp.CallToolResult, AddTODOOutput, error) {
 	return func(ctx context.Context, req *mcp.CallToolRequest, input AddTODOInput) (*mcp.CallToolResult, AddTODOOutput, error) {
@@ -26,7 +46,7 @@ func NewAddTODOHandler(adminMode bool) func(context.Context, *mcp.CallToolReques
 			return nil, AddTODOOutput{}, fmt.Errorf("this action requires admin mode (set TODO_ADMIN_MODE=true): add_todo")
 		}
 
-		service := getService()
+		service := getService(ctx)
 		if service == nil {
 			return nil, AddTODOOutput{}, fmt.Errorf("service not initialized")
 		}
@@ -35,7 +55,12 @@ func NewAddTODOHandler(adminMode bool) func(context.Context, *mcp.CallToolReques
 			return nil, AddTODOOutput{}, fmt.Errorf("TODO ID is required")
 		}
 
-		item, err := service.AddTODO(input.ID, input.Title, input.Assignee, input.DependsOn)
+		dueAt, err := parseDueAt(input.DueAt)
+		if err != nil {
+			return nil, AddTODOOutput{}, err
+		}
+
+		item, err := service.AddTODOWithDue(input.ID, input.Title, input.Assignee, input.DependsOn, dueAt)
 		if err != nil {
 			return nil, AddTODOOutput{}, err
 		}
@@ -46,6 +71,8 @@ func NewAddTODOHandler(adminMode bool) func(context.Context, *mcp.CallToolReques
 			Status:    item.Status,
 			Assignee:  item.Assignee,
 			DependsOn: item.DependsOn,
+			DueAt:     item.DueAt,
+			Version:   item.Version,
 		}, nil
 	}
 }
@@ -53,15 +80,16 @@ func NewAddTODOHandler(adminMode bool) func(context.Context, *mcp.CallToolReques
 // NewUpdateTODOStatusHandler returns handler with appropriate behavior based on admin mode
 func NewUpdateTODOStatusHandler(adminMode bool) func(context.Context, *mcp.CallToolRequest, UpdateTODOStatusInput) (*mcp.CallToolResult, UpdateTODOStatusOutput, error) {
 	return func(ctx context.Context, req *mcp.CallToolRequest, input UpdateTODOStatusInput) (*mcp.CallToolResult, UpdateTODOStatusOutput, error) {
-		service := getService()
+		service := getService(ctx)
 		if service == nil {
 			return nil, UpdateTODOStatusOutput{}, fmt.Errorf("service not initialized")
 		}
 
+		var version int
 		var err error
 		if adminMode {
 			// Admin mode: allow updating any TODO
-			err = service.UpdateStatus(input.ID, input.Status)
+			version, err = service.UpdateStatusVersion(input.ID, input.Status, input.ExpectedVersion)
 		} else {
 			// Agent mode: only allow updating assigned TODOs
 			if input.AgentName == "" {
@@ -70,19 +98,21 @@ func NewUpdateTODOStatusHandler(adminMode bool) func(context.Context, *mcp.CallT
 					Message: "agent_name is required when not in admin mode",
 				}, nil
 			}
-			err = service.UpdateStatusWithAgent(input.ID, input.Status, input.AgentName)
+			version, err = service.UpdateStatusWithAgentVersion(input.ID, input.Status, input.AgentName, input.ExpectedVersion)
 		}
 
 		if err != nil {
 			return nil, UpdateTODOStatusOutput{
 				Success: false,
 				Message: err.Error(),
+				Version: conflictVersion(err),
 			}, nil
 		}
 
 		return nil, UpdateTODOStatusOutput{
 			Success: true,
 			Message: fmt.Sprintf("TODO item '%s' status updated to '%s'", input.ID, input.Status),
+			Version: version,
 		}, nil
 	}
 }
@@ -94,22 +124,66 @@ func NewUpdateTODOAssigneeHandler(adminMode bool) func(context.Context, *mcp.Cal
 			return nil, UpdateTODOAssigneeOutput{}, fmt.Errorf("this action requires admin mode (set TODO_ADMIN_MODE=true): update_todo_assignee")
 		}
 
-		service := getService()
+		service := getService(ctx)
 		if service == nil {
 			return nil, UpdateTODOAssigneeOutput{}, fmt.Errorf("service not initialized")
 		}
 
-		err := service.UpdateAssignee(input.ID, input.Assignee)
+		version, err := service.UpdateAssigneeVersion(input.ID, input.Assignee, input.ExpectedVersion)
 		if err != nil {
 			return nil, UpdateTODOAssigneeOutput{
 				Success: false,
 				Message: err.Error(),
+				Version: conflictVersion(err),
 			}, nil
 		}
 
 		return nil, UpdateTODOAssigneeOutput{
 			Success: true,
 			Message: fmt.Sprintf("TODO item '%s' assignee updated to '%s'", input.ID, input.Assignee),
+			Version: version,
+		}, nil
+	}
+}
+
+// NewUpdateTODODueHandler returns a handler configured for admin mode
+func NewUpdateTODODueHandler(adminMode bool) func(context.Context, *mcp.CallToolRequest, UpdateTODODueInput) (*mcp.CallToolResult, UpdateTODODueOutput, error) {
+	return func(ctx context.Context, req *mcp.CallToolRequest, input UpdateTODODueInput) (*mcp.CallToolResult, UpdateTODODueOutput, error) {
+		if !adminMode {
+			return nil, UpdateTODODueOutput{}, fmt.Errorf("this action requires admin mode (set TODO_ADMIN_MODE=true): update_todo_due")
+		}
+
+		service := getService(ctx)
+		if service == nil {
+			return nil, UpdateTODODueOutput{}, fmt.Errorf("service not initialized")
+		}
+
+		dueAt, err := parseDueAt(input.DueAt)
+		if err != nil {
+			return nil, UpdateTODODueOutput{
+				Success: false,
+				Message: err.Error(),
+			}, nil
+		}
+
+		version, err := service.UpdateDueDateVersion(input.ID, dueAt, input.ExpectedVersion)
+		if err != nil {
+			return nil, UpdateTODODueOutput{
+				Success: false,
+				Message: err.Error(),
+				Version: conflictVersion(err),
+			}, nil
+		}
+
+		message := fmt.Sprintf("TODO item '%s' due date cleared", input.ID)
+		if dueAt != nil {
+			message = fmt.Sprintf("TODO item '%s' due date updated to '%s'", input.ID, dueAt.Format(time.RFC3339))
+		}
+
+		return nil, UpdateTODODueOutput{
+			Success: true,
+			Message: message,
+			Version: version,
 		}, nil
 	}
 }
@@ -121,16 +195,17 @@ func NewRemoveTODOHandler(adminMode bool) func(context.Context, *mcp.CallToolReq
 			return nil, RemoveTODOOutput{}, fmt.Errorf("this action requires admin mode (set TODO_ADMIN_MODE=true): remove_todo")
 		}
 
-		service := getService()
+		service := getService(ctx)
 		if service == nil {
 			return nil, RemoveTODOOutput{}, fmt.Errorf("service not initialized")
 		}
 
-		err := service.RemoveTODO(input.ID)
+		err := service.RemoveTODOVersion(input.ID, input.ExpectedVersion)
 		if err != nil {
 			return nil, RemoveTODOOutput{
 				Success: false,
 				Message: err.Error(),
+				Version: conflictVersion(err),
 			}, nil
 		}
 
@@ -141,13 +216,66 @@ func NewRemoveTODOHandler(adminMode bool) func(context.Context, *mcp.CallToolReq
 	}
 }
 
+// NewArchiveDoneTODOsHandler returns a handler configured for admin mode
+func NewArchiveDoneTODOsHandler(adminMode bool) func(context.Context, *mcp.CallToolRequest, ArchiveDoneTODOsInput) (*mcp.CallToolResult, ArchiveDoneTODOsOutput, error) {
+	return func(ctx context.Context, req *mcp.CallToolRequest, input ArchiveDoneTODOsInput) (*mcp.CallToolResult, ArchiveDoneTODOsOutput, error) {
+		if !adminMode {
+			return nil, ArchiveDoneTODOsOutput{}, fmt.Errorf("this action requires admin mode (set TODO_ADMIN_MODE=true): archive_done_todos")
+		}
+
+		service := getService(ctx)
+		if service == nil {
+			return nil, ArchiveDoneTODOsOutput{}, fmt.Errorf("service not initialized")
+		}
+
+		archived, skipped, err := service.ArchiveDone()
+		if err != nil {
+			return nil, ArchiveDoneTODOsOutput{}, err
+		}
+
+		message := fmt.Sprintf("Archived %d done TODO item(s)", len(archived))
+		if len(skipped) > 0 {
+			message += fmt.Sprintf(", kept %d that active TODOs depend on", len(skipped))
+		}
+
+		return nil, ArchiveDoneTODOsOutput{
+			Archived: archived,
+			Skipped:  skipped,
+			Count:    len(archived),
+			Message:  message,
+		}, nil
+	}
+}
+
+// ListArchivedTODOs lists all archived TODO items
+func ListArchivedTODOs(ctx context.Context, req *mcp.CallToolRequest, input ListArchivedTODOsInput) (
+	*mcp.CallToolResult,
+	ListArchivedTODOsOutput,
+	error,
+) {
+	service := getService(ctx)
+	if service == nil {
+		return nil, ListArchivedTODOsOutput{}, fmt.Errorf("service not initialized")
+	}
+
+	items, err := service.ListArchived()
+	if err != nil {
+		return nil, ListArchivedTODOsOutput{}, err
+	}
+
+	return nil, ListArchivedTODOsOutput{
+		Items: items,
+		Count: len(items),
+	}, nil
+}
+
 // ListTODOs lists all TODO items
 func ListTODOs(ctx context.Context, req *mcp.CallToolRequest, input struct{}) (
 	*mcp.CallToolResult,
 	ListTODOsOutput,
 	error,
 ) {
-	service := getService()
+	service := getService(ctx)
 	if service == nil {
 		return nil, ListTODOsOutput{}, fmt.Errorf("service not initialized")
 	}
@@ -169,7 +297,7 @@ func GetTODOStatus(ctx context.Context, req *mcp.CallToolRequest, input GetTODOS
 	GetTODOStatusOutput,
 	error,
 ) {
-	service := getService()
+	service := getService(ctx)
 	if service == nil {
 		return nil, GetTODOStatusOutput{}, fmt.Errorf("service not initialized")
 	}
@@ -192,6 +320,7 @@ func GetTODOStatus(ctx context.Context, req *mcp.CallToolRequest, input GetTODOS
 		Done:       summary.Done,
 		Blocked:    blocked,
 		Ready:      ready,
+		ByStatus:   summary.ByStatus,
 		ByAssignee: summary.ByAssignee,
 	}, nil
 }
@@ -203,22 +332,24 @@ func NewAddTODODependencyHandler(adminMode bool) func(context.Context, *mcp.Call
 			return nil, AddTODODependencyOutput{}, fmt.Errorf("this action requires admin mode (set TODO_ADMIN_MODE=true): add_todo_dependency")
 		}
 
-		service := getService()
+		service := getService(ctx)
 		if service == nil {
 			return nil, AddTODODependencyOutput{}, fmt.Errorf("service not initialized")
 		}
 
-		err := service.AddDependency(input.ID, input.DependsOn)
+		version, err := service.AddDependencyVersion(input.ID, input.DependsOn, input.ExpectedVersion)
 		if err != nil {
 			return nil, AddTODODependencyOutput{
 				Success: false,
 				Message: err.Error(),
+				Version: conflictVersion(err),
 			}, nil
 		}
 
 		return nil, AddTODODependencyOutput{
 			Success: true,
 			Message: fmt.Sprintf("Dependency '%s' added to TODO '%s'", input.DependsOn, input.ID),
+			Version: version,
 		}, nil
 	}
 }
@@ -230,22 +361,24 @@ func NewRemoveTODODependencyHandler(adminMode bool) func(context.Context, *mcp.C
 			return nil, RemoveTODODependencyOutput{}, fmt.Errorf("this action requires admin mode (set TODO_ADMIN_MODE=true): remove_todo_dependency")
 		}
 
-		service := getService()
+		service := getService(ctx)
 		if service == nil {
 			return nil, RemoveTODODependencyOutput{}, fmt.Errorf("service not initialized")
 		}
 
-		err := service.RemoveDependency(input.ID, input.DependsOn)
+		version, err := service.RemoveDependencyVersion(input.ID, input.DependsOn, input.ExpectedVersion)
 		if err != nil {
 			return nil, RemoveTODODependencyOutput{
 				Success: false,
 				Message: err.Error(),
+				Version: conflictVersion(err),
 			}, nil
 		}
 
 		return nil, RemoveTODODependencyOutput{
 			Success: true,
 			Message: fmt.Sprintf("Dependency '%s' removed from TODO '%s'", input.DependsOn, input.ID),
+			Version: version,
 		}, nil
 	}
 }
@@ -256,7 +389,7 @@ func GetReadyTODOs(ctx context.Context, req *mcp.CallToolRequest, input GetReady
 	GetReadyTODOsOutput,
 	error,
 ) {
-	service := getService()
+	service := getService(ctx)
 	if service == nil {
 		return nil, GetReadyTODOsOutput{}, fmt.Errorf("service not initialized")
 	}
@@ -278,7 +411,7 @@ func GetBlockedTODOs(ctx context.Context, req *mcp.CallToolRequest, input GetBlo
 	GetBlockedTODOsOutput,
 	error,
 ) {
-	service := getService()
+	service := getService(ctx)
 	if service == nil {
 		return nil, GetBlockedTODOsOutput{}, fmt.Errorf("service not initialized")
 	}
@@ -294,13 +427,35 @@ func GetBlockedTODOs(ctx context.Context, req *mcp.CallToolRequest, input GetBlo
 	}, nil
 }
 
+// GetOverdueTODOs returns TODOs that are past their due date
+func GetOverdueTODOs(ctx context.Context, req *mcp.CallToolRequest, input GetOverdueTODOsInput) (
+	*mcp.CallToolResult,
+	GetOverdueTODOsOutput,
+	error,
+) {
+	service := getService(ctx)
+	if service == nil {
+		return nil, GetOverdueTODOsOutput{}, fmt.Errorf("service not initialized")
+	}
+
+	items, err := service.GetOverdueTODOs(time.Now())
+	if err != nil {
+		return nil, GetOverdueTODOsOutput{}, err
+	}
+
+	return nil, GetOverdueTODOsOutput{
+		Items: items,
+		Count: len(items),
+	}, nil
+}
+
 // GetTODODependencies returns dependencies for a TODO
 func GetTODODependencies(ctx context.Context, req *mcp.CallToolRequest, input GetTODODependenciesInput) (
 	*mcp.CallToolResult,
 	GetTODODependenciesOutput,
 	error,
 ) {
-	service := getService()
+	service := getService(ctx)
 	if service == nil {
 		return nil, GetTODODependenciesOutput{}, fmt.Errorf("service not initialized")
 	}
@@ -312,3 +467,22 @@ func GetTODODependencies(ctx context.Context, req *mcp.CallToolRequest, input Ge
 
 	return nil, *result, nil
 }
+
+// ExportGraph renders the dependency graph of the TODO list
+func ExportGraph(ctx context.Context, req *mcp.CallToolRequest, input ExportGraphInput) (
+	*mcp.CallToolResult,
+	ExportGraphOutput,
+	error,
+) {
+	service := getService(ctx)
+	if service == nil {
+		return nil, ExportGraphOutput{}, fmt.Errorf("service not initialized")
+	}
+
+	result, err := service.ExportGraph(input.Format, input.ID)
+	if err != nil {
+		return nil, ExportGraphOutput{}, err
+	}
+
+	return nil, *result, nil
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 	. "github.com/onsi/ginkgo/v2"
@@ -76,6 +77,36 @@ var _ = Describe("Handlers", func() {
 			Expect(updateOut.Success).To(BeTrue())
 		})
 
+		It("should report the version and reject stale updates", func() {
+			storage := NewFileStorage(filePath)
+			service := NewService(storage)
+			setGlobalService(service)
+			addHandler := NewAddTODOHandler(true)
+			updateHandler := NewUpdateTODOStatusHandler(true)
+
+			_, addOut, _ := addHandler(context.Background(), nil, AddTODOInput{ID: "todo-1", Title: "Test"})
+			Expect(addOut.Version).To(Equal(1))
+
+			_, updateOut, err := updateHandler(context.Background(), nil, UpdateTODOStatusInput{
+				ID:              addOut.ID,
+				Status:          "in_progress",
+				ExpectedVersion: &addOut.Version,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(updateOut.Success).To(BeTrue())
+			Expect(updateOut.Version).To(Equal(2))
+
+			_, staleOut, err := updateHandler(context.Background(), nil, UpdateTODOStatusInput{
+				ID:              addOut.ID,
+				Status:          "done",
+				ExpectedVersion: &addOut.Version,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(staleOut.Success).To(BeFalse())
+			Expect(staleOut.Message).To(ContainSubstring("changed concurrently"))
+			Expect(staleOut.Version).To(Equal(2))
+		})
+
 		It("should list all TODOs", func() {
 			storage := NewFileStorage(filePath)
 			service := NewService(storage)
@@ -91,6 +122,98 @@ var _ = Describe("Handlers", func() {
 		})
 	})
 
+	Context("Due date handlers", func() {
+		BeforeEach(func() {
+			setGlobalService(NewService(NewFileStorage(filePath)))
+		})
+
+		It("should add a TODO with a due date", func() {
+			handler := NewAddTODOHandler(true)
+			_, output, err := handler(context.Background(), nil, AddTODOInput{
+				ID:    "todo-1",
+				Title: "Test",
+				DueAt: "2025-01-31T17:00:00Z",
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(output.DueAt).NotTo(BeNil())
+			Expect(output.DueAt.Format(time.RFC3339)).To(Equal("2025-01-31T17:00:00Z"))
+		})
+
+		It("should reject an invalid due date", func() {
+			handler := NewAddTODOHandler(true)
+			_, _, err := handler(context.Background(), nil, AddTODOInput{
+				ID:    "todo-1",
+				Title: "Test",
+				DueAt: "tomorrow",
+			})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("RFC3339"))
+		})
+
+		It("should update the due date and report overdue TODOs", func() {
+			addHandler := NewAddTODOHandler(true)
+			_, _, _ = addHandler(context.Background(), nil, AddTODOInput{ID: "todo-1", Title: "Test"})
+
+			dueHandler := NewUpdateTODODueHandler(true)
+			_, output, err := dueHandler(context.Background(), nil, UpdateTODODueInput{
+				ID:    "todo-1",
+				DueAt: time.Now().Add(-time.Hour).Format(time.RFC3339),
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(output.Success).To(BeTrue())
+
+			_, overdue, err := GetOverdueTODOs(context.Background(), nil, GetOverdueTODOsInput{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(overdue.Count).To(Equal(1))
+			Expect(overdue.Items[0].ID).To(Equal("todo-1"))
+		})
+
+		It("should reject UpdateTODODue when not in admin mode", func() {
+			handler := NewUpdateTODODueHandler(false)
+			_, _, err := handler(context.Background(), nil, UpdateTODODueInput{ID: "todo-1"})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("admin mode"))
+		})
+	})
+
+	Context("Archive handlers", func() {
+		BeforeEach(func() {
+			archivePath := filepath.Join(tempDir, "todos-archive.json")
+			setGlobalService(NewServiceWithArchive(NewFileStorage(filePath), NewFileStorage(archivePath)))
+		})
+
+		It("should archive done TODOs and list them", func() {
+			addHandler := NewAddTODOHandler(true)
+			_, _, _ = addHandler(context.Background(), nil, AddTODOInput{ID: "todo-1", Title: "Done"})
+			_, _, _ = addHandler(context.Background(), nil, AddTODOInput{ID: "todo-2", Title: "Open"})
+			statusHandler := NewUpdateTODOStatusHandler(true)
+			_, _, _ = statusHandler(context.Background(), nil, UpdateTODOStatusInput{ID: "todo-1", Status: "done"})
+
+			handler := NewArchiveDoneTODOsHandler(true)
+			_, output, err := handler(context.Background(), nil, ArchiveDoneTODOsInput{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(output.Archived).To(Equal([]string{"todo-1"}))
+			Expect(output.Count).To(Equal(1))
+
+			_, archived, err := ListArchivedTODOs(context.Background(), nil, ListArchivedTODOsInput{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(archived.Count).To(Equal(1))
+			Expect(archived.Items[0].ID).To(Equal("todo-1"))
+
+			_, list, err := ListTODOs(context.Background(), nil, struct{}{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(list.Count).To(Equal(1))
+			Expect(list.Items[0].ID).To(Equal("todo-2"))
+		})
+
+		It("should reject ArchiveDoneTODOs when not in admin mode", func() {
+			handler := NewArchiveDoneTODOsHandler(false)
+			_, _, err := handler(context.Background(), nil, ArchiveDoneTODOsInput{})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("admin mode"))
+		})
+	})
+
 	Context("Dependency handlers", func() {
 		var storage *FileStorage
 		var service *Service
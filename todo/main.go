@@ -2,14 +2,19 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"log"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/mudler/mcps/pkg/selfcheck"
+	"github.com/mudler/mcps/pkg/transport"
 )
 
 var todoFilePath string
+var todoArchivePath string
 
 func main() {
 	// Get file path from environment variable, default to /data/todos.json
@@ -18,12 +23,37 @@ func main() {
 		todoFilePath = "/data/todos.json"
 	}
 
+	// Archived TODOs are kept next to the TODO file unless configured
+	todoArchivePath = os.Getenv("TODO_ARCHIVE_PATH")
+	if todoArchivePath == "" {
+		todoArchivePath = filepath.Join(filepath.Dir(todoFilePath), "todos-archive.json")
+	}
+
+	// Custom statuses and transitions replace the default workflow
+	workflow, workflowErr := LoadWorkflowFromEnv()
+	workflowValue := ""
+	if workflow != nil {
+		workflowValue = strings.Join(workflow.Statuses, ", ")
+	}
+
+	if selfcheck.Enabled() {
+		selfcheck.Exit("todo",
+			selfcheck.File("TODO_FILE_PATH", todoFilePath),
+			selfcheck.File("TODO_ARCHIVE_PATH", todoArchivePath),
+			selfcheck.Value("TODO_STATUSES", workflowValue, workflowErr),
+		)
+	}
+	if workflowErr != nil {
+		log.Fatal(workflowErr)
+	}
+
 	// Create directory if it doesn't exist
 	os.MkdirAll(filepath.Dir(todoFilePath), 0755)
 
 	// Create storage and service
 	storage := NewFileStorage(todoFilePath)
-	service := NewService(storage)
+	service := NewServiceWithArchive(storage, NewFileStorage(todoArchivePath))
+	service.SetWorkflow(workflow)
 	setGlobalService(service)
 
 	// Check admin mode once at startup
@@ -44,6 +74,11 @@ func main() {
 			Description: "Update the assignee of a TODO item",
 		}, NewUpdateTODOAssigneeHandler(adminMode))
 
+		mcp.AddTool(server, &mcp.Tool{
+			Name:        "update_todo_due",
+			Description: "Set or clear the due date of a TODO item (RFC3339)",
+		}, NewUpdateTODODueHandler(adminMode))
+
 		mcp.AddTool(server, &mcp.Tool{
 			Name:        "remove_todo",
 			Description: "Remove a TODO item by ID",
@@ -58,12 +93,17 @@ func main() {
 			Name:        "remove_todo_dependency",
 			Description: "Remove a dependency from a TODO item",
 		}, NewRemoveTODODependencyHandler(adminMode))
+
+		mcp.AddTool(server, &mcp.Tool{
+			Name:        "archive_done_todos",
+			Description: "Move all done TODO items that no active TODO depends on to the archive",
+		}, NewArchiveDoneTODOsHandler(adminMode))
 	}
 
 	// Register always-available tools
 	mcp.AddTool(server, &mcp.Tool{
 		Name:        "update_todo_status",
-		Description: "Update the status of a TODO item (pending, in_progress, or done)",
+		Description: fmt.Sprintf("Update the status of a TODO item (%s)", workflow.describe()),
 	}, NewUpdateTODOStatusHandler(adminMode))
 
 	mcp.AddTool(server, &mcp.Tool{
@@ -71,6 +111,11 @@ func main() {
 		Description: "List all TODO items",
 	}, ListTODOs)
 
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "list_archived",
+		Description: "List all archived TODO items",
+	}, ListArchivedTODOs)
+
 	mcp.AddTool(server, &mcp.Tool{
 		Name:        "get_todo_status",
 		Description: "Get a summary of the TODO list status with counts by status and assignee",
@@ -78,7 +123,7 @@ func main() {
 
 	mcp.AddTool(server, &mcp.Tool{
 		Name:        "get_ready_todos",
-		Description: "Get all TODO items that are ready to start (pending with all dependencies satisfied)",
+		Description: fmt.Sprintf("Get all TODO items that are ready to start (%s with all dependencies satisfied)", workflow.Initial()),
 	}, GetReadyTODOs)
 
 	mcp.AddTool(server, &mcp.Tool{
@@ -86,12 +131,30 @@ func main() {
 		Description: "Get all TODO items that are blocked by dependencies",
 	}, GetBlockedTODOs)
 
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "get_overdue_todos",
+		Description: "Get all TODO items that are not done and past their due date, most overdue first",
+	}, GetOverdueTODOs)
+
 	mcp.AddTool(server, &mcp.Tool{
 		Name:        "get_todo_dependencies",
 		Description: "Get dependencies for a TODO item (direct and optionally transitive)",
 	}, GetTODODependencies)
 
-	if err := server.Run(context.Background(), &mcp.StdioTransport{}); err != nil {
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "export_graph",
+		Description: "Export the dependency graph of the TODO items in DOT (Graphviz) or Mermaid format, optionally limited to one item and its transitive dependencies",
+	}, ExportGraph)
+
+	handlers := batchHandlers(adminMode)
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "batch",
+		Description: fmt.Sprintf("Run several TODO operations in order in a single call, as a transaction: the list is locked for the whole batch and nothing is saved when it stops on a failure (unless continue_on_error is set, in which case the successful operations are saved). Each operation names a tool (%s) with its arguments; returns the result of each", strings.Join(handlers.Names(), ", ")),
+	}, NewBatchHandler(handlers))
+
+	registerResources(server)
+
+	if err := transport.Run(context.Background(), server); err != nil {
 		log.Fatal(err)
 	}
 }
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"context"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/mudler/mcps/pkg/resources"
+)
+
+// registerResources exposes the TODO list as read-only resources, rendered
+// like the output of the matching tools
+func registerResources(server *mcp.Server) {
+	resources.AddJSON(server, &mcp.Resource{
+		URI:         "todo://todos",
+		Name:        "todos",
+		Title:       "TODO list",
+		Description: "All TODO items, as returned by list_todos",
+	}, readTODOs)
+
+	resources.AddJSON(server, &mcp.Resource{
+		URI:         "todo://status",
+		Name:        "status",
+		Title:       "TODO status",
+		Description: "Counts of TODO items by status and assignee, as returned by get_todo_status",
+	}, readTODOStatus)
+
+	resources.AddJSON(server, &mcp.Resource{
+		URI:         "todo://archive",
+		Name:        "archive",
+		Title:       "Archived TODOs",
+		Description: "All archived TODO items, as returned by list_archived",
+	}, readArchivedTODOs)
+}
+
+func readTODOs(ctx context.Context) (any, error) {
+	_, output, err := ListTODOs(ctx, nil, struct{}{})
+	return output, err
+}
+
+func readTODOStatus(ctx context.Context) (any, error) {
+	_, output, err := GetTODOStatus(ctx, nil, GetTODOStatusInput{})
+	return output, err
+}
+
+func readArchivedTODOs(ctx context.Context) (any, error) {
+	_, output, err := ListArchivedTODOs(ctx, nil, ListArchivedTODOsInput{})
+	return output, err
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Resources", func() {
+	var session *mcp.ClientSession
+
+	BeforeEach(func() {
+		tempDir, err := os.MkdirTemp("", "todo-resources-test-*")
+		Expect(err).NotTo(HaveOccurred())
+		DeferCleanup(os.RemoveAll, tempDir)
+		setGlobalService(NewServiceWithArchive(
+			NewFileStorage(filepath.Join(tempDir, "todos.json")),
+			NewFileStorage(filepath.Join(tempDir, "todos-archive.json")),
+		))
+
+		server := mcp.NewServer(&mcp.Implementation{Name: "todo", Version: "v1.0.0"}, nil)
+		registerResources(server)
+
+		ctx := context.Background()
+		serverTransport, clientTransport := mcp.NewInMemoryTransports()
+		serverSession, err := server.Connect(ctx, serverTransport, nil)
+		Expect(err).NotTo(HaveOccurred())
+		DeferCleanup(serverSession.Close)
+
+		client := mcp.NewClient(&mcp.Implementation{Name: "client", Version: "v1.0.0"}, nil)
+		session, err = client.Connect(ctx, clientTransport, nil)
+		Expect(err).NotTo(HaveOccurred())
+		DeferCleanup(session.Close)
+	})
+
+	read := func(uri string, value any) {
+		result, err := session.ReadResource(context.Background(), &mcp.ReadResourceParams{URI: uri})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.Contents).To(HaveLen(1))
+		Expect(json.Unmarshal([]byte(result.Contents[0].Text), value)).To(Succeed())
+	}
+
+	It("should list the TODO resources", func() {
+		result, err := session.ListResources(context.Background(), nil)
+		Expect(err).NotTo(HaveOccurred())
+		var uris []string
+		for _, resource := range result.Resources {
+			uris = append(uris, resource.URI)
+		}
+		Expect(uris).To(ConsistOf("todo://todos", "todo://status", "todo://archive"))
+	})
+
+	It("should read the current TODO list", func() {
+		var list ListTODOsOutput
+		read("todo://todos", &list)
+		Expect(list.Count).To(Equal(0))
+
+		_, err := getService(context.Background()).AddTODO("todo-1", "Write docs", "agent1", nil)
+		Expect(err).NotTo(HaveOccurred())
+
+		read("todo://todos", &list)
+		Expect(list.Count).To(Equal(1))
+		Expect(list.Items[0].ID).To(Equal("todo-1"))
+
+		var status GetTODOStatusOutput
+		read("todo://status", &status)
+		Expect(status.Pending).To(Equal(1))
+		Expect(status.ByAssignee).To(HaveKeyWithValue("agent1", 1))
+	})
+})
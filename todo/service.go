@@ -1,21 +1,43 @@
 package main
 
 import (
+	"errors"
 	"fmt"
+	"sort"
+	"strings"
+	"time"
 )
 
 // Service provides business logic for TODO management
 type Service struct {
-	storage Storage
+	storage  Storage
+	archive  Storage
+	workflow *Workflow
 }
 
 // NewService creates a new Service instance
 func NewService(storage Storage) *Service {
 	return &Service{
-		storage: storage,
+		storage:  storage,
+		workflow: DefaultWorkflow(),
 	}
 }
 
+// NewServiceWithArchive creates a new Service instance that moves archived
+// TODOs to a separate storage
+func NewServiceWithArchive(storage, archive Storage) *Service {
+	return &Service{
+		storage:  storage,
+		archive:  archive,
+		workflow: DefaultWorkflow(),
+	}
+}
+
+// SetWorkflow replaces the default pending/in_progress/done workflow
+func (s *Service) SetWorkflow(workflow *Workflow) {
+	s.workflow = workflow
+}
+
 // findTODOByID finds a TODO item by ID in the list
 func (s *Service) findTODOByID(list *TODOList, id string) *TODOItem {
 	for i := range list.Items {
@@ -26,6 +48,70 @@ func (s *Service) findTODOByID(list *TODOList, id string) *TODOItem {
 	return nil
 }
 
+// VersionConflictError is returned when a TODO item is changed with an
+// expected version that is not its current version, because it was changed
+// since the caller read it
+type VersionConflictError struct {
+	ID       string
+	Expected int
+	Current  int
+}
+
+func (e *VersionConflictError) Error() string {
+	return fmt.Sprintf("TODO '%s' was changed concurrently: expected version %d, current version %d; read it again and retry", e.ID, e.Expected, e.Current)
+}
+
+// checkVersion fails with a VersionConflictError when expectedVersion is set
+// and is not the version of item
+func checkVersion(item *TODOItem, expectedVersion *int) error {
+	if expectedVersion != nil && *expectedVersion != item.Version {
+		return &VersionConflictError{ID: item.ID, Expected: *expectedVersion, Current: item.Version}
+	}
+	return nil
+}
+
+// conflictVersion returns the current version of the item err is a conflict
+// on, 0 for other errors
+func conflictVersion(err error) int {
+	var conflict *VersionConflictError
+	if errors.As(err, &conflict) {
+		return conflict.Current
+	}
+	return 0
+}
+
+// updateItem runs change on the TODO item with the given ID, checking
+// expectedVersion first, then bumps its version and saves the list. It
+// returns the new version.
+func (s *Service) updateItem(id string, expectedVersion *int, change func(list *TODOList, item *TODOItem) error) (int, error) {
+	version := 0
+	err := s.storage.WithLock(func() error {
+		list, err := s.storage.Load()
+		if err != nil {
+			return err
+		}
+
+		item := s.findTODOByID(list, id)
+		if item == nil {
+			return fmt.Errorf("TODO item with ID '%s' not found", id)
+		}
+		if err := checkVersion(item, expectedVersion); err != nil {
+			return err
+		}
+		if err := change(list, item); err != nil {
+			return err
+		}
+
+		item.Version++
+		version = item.Version
+		return s.storage.Save(list)
+	})
+	if err != nil {
+		return 0, err
+	}
+	return version, nil
+}
+
 // validateDependenciesExist validates that all dependency IDs exist in the list
 func (s *Service) validateDependenciesExist(list *TODOList, dependsOn []string) error {
 	for _, depID := range dependsOn {
@@ -46,6 +132,11 @@ func (s *Service) validateIDUniqueness(list *TODOList, id string) error {
 
 // AddTODO adds a new TODO item
 func (s *Service) AddTODO(id, title, assignee string, dependsOn []string) (*TODOItem, error) {
+	return s.AddTODOWithDue(id, title, assignee, dependsOn, nil)
+}
+
+// AddTODOWithDue adds a new TODO item with an optional due date
+func (s *Service) AddTODOWithDue(id, title, assignee string, dependsOn []string, dueAt *time.Time) (*TODOItem, error) {
 	if id == "" {
 		return nil, fmt.Errorf("TODO ID is required")
 	}
@@ -78,9 +169,11 @@ func (s *Service) AddTODO(id, title, assignee string, dependsOn []string) (*TODO
 		newItem := TODOItem{
 			ID:        id,
 			Title:     title,
-			Status:    "pending",
+			Status:    s.workflow.Initial(),
 			Assignee:  assignee,
 			DependsOn: dependsOnCopy,
+			DueAt:     dueAt,
+			Version:   1,
 		}
 
 		list.Items = append(list.Items, newItem)
@@ -96,7 +189,8 @@ func (s *Service) AddTODO(id, title, assignee string, dependsOn []string) (*TODO
 	return item, nil
 }
 
-// checkDependenciesSatisfied checks if all dependencies of a TODO are done
+// checkDependenciesSatisfied checks if all dependencies of a TODO are in a
+// terminal status
 func (s *Service) checkDependenciesSatisfied(list *TODOList, item *TODOItem) (bool, []string) {
 	if len(item.DependsOn) == 0 {
 		return true, nil
@@ -105,7 +199,7 @@ func (s *Service) checkDependenciesSatisfied(list *TODOList, item *TODOItem) (bo
 	var blocking []string
 	for _, depID := range item.DependsOn {
 		dep := s.findTODOByID(list, depID)
-		if dep == nil || dep.Status != "done" {
+		if dep == nil || !s.workflow.IsTerminal(dep.Status) {
 			blocking = append(blocking, depID)
 		}
 	}
@@ -115,37 +209,18 @@ func (s *Service) checkDependenciesSatisfied(list *TODOList, item *TODOItem) (bo
 
 // UpdateStatusWithAgent updates the status of a TODO item with agent permission check
 func (s *Service) UpdateStatusWithAgent(id, status, agentName string) error {
-	if agentName == "" {
-		return fmt.Errorf("agent name is required when not in admin mode")
-	}
+	_, err := s.UpdateStatusWithAgentVersion(id, status, agentName, nil)
+	return err
+}
 
-	validStatuses := map[string]bool{"pending": true, "in_progress": true, "done": true}
-	if !validStatuses[status] {
-		return fmt.Errorf("invalid status: %s (must be pending, in_progress, or done)", status)
+// UpdateStatusWithAgentVersion updates the status of a TODO item with agent
+// permission check, failing with a VersionConflictError when expectedVersion
+// is set and is not the version of the item. It returns the new version.
+func (s *Service) UpdateStatusWithAgentVersion(id, status, agentName string, expectedVersion *int) (int, error) {
+	if agentName == "" {
+		return 0, fmt.Errorf("agent name is required when not in admin mode")
 	}
-
-	return s.storage.WithLock(func() error {
-		list, err := s.storage.Load()
-		if err != nil {
-			return err
-		}
-
-		var item *TODOItem
-		var currentStatus string
-		found := false
-		for i := range list.Items {
-			if list.Items[i].ID == id {
-				item = &list.Items[i]
-				currentStatus = item.Status
-				found = true
-				break
-			}
-		}
-
-		if !found {
-			return fmt.Errorf("TODO item with ID '%s' not found", id)
-		}
-
+	return s.updateStatus(id, status, expectedVersion, func(item *TODOItem) error {
 		// Check assignee permission
 		if item.Assignee == "" {
 			return fmt.Errorf("TODO '%s' is not assigned to any agent", id)
@@ -153,91 +228,63 @@ func (s *Service) UpdateStatusWithAgent(id, status, agentName string) error {
 		if item.Assignee != agentName {
 			return fmt.Errorf("TODO '%s' is not assigned to agent '%s' (assigned to '%s')", id, agentName, item.Assignee)
 		}
-
-		// Check dependencies if transitioning to in_progress or done from pending
-		if status == "in_progress" && currentStatus == "pending" {
-			satisfied, blocking := s.checkDependenciesSatisfied(list, item)
-			if !satisfied {
-				blockingInfo := []string{}
-				for _, blockID := range blocking {
-					blockItem := s.findTODOByID(list, blockID)
-					if blockItem != nil {
-						blockingInfo = append(blockingInfo, fmt.Sprintf("%s (%s)", blockID, blockItem.Status))
-					} else {
-						blockingInfo = append(blockingInfo, blockID)
-					}
-				}
-				return fmt.Errorf("TODO '%s' is blocked by dependencies: %v", id, blockingInfo)
-			}
-		} else if status == "done" && currentStatus == "pending" {
-			// Allow pending -> done if dependencies are satisfied
-			satisfied, blocking := s.checkDependenciesSatisfied(list, item)
-			if !satisfied {
-				blockingInfo := []string{}
-				for _, blockID := range blocking {
-					blockItem := s.findTODOByID(list, blockID)
-					if blockItem != nil {
-						blockingInfo = append(blockingInfo, fmt.Sprintf("%s (%s)", blockID, blockItem.Status))
-					} else {
-						blockingInfo = append(blockingInfo, blockID)
-					}
-				}
-				return fmt.Errorf("TODO '%s' is blocked by dependencies: %v", id, blockingInfo)
-			}
-		}
-		// Allow in_progress -> done, done -> in_progress, in_progress -> pending without dependency checks
-
-		item.Status = status
-		return s.storage.Save(list)
+		return nil
 	})
 }
 
 // UpdateStatus updates the status of a TODO item (admin/internal use)
 func (s *Service) UpdateStatus(id, status string) error {
-	validStatuses := map[string]bool{"pending": true, "in_progress": true, "done": true}
-	if !validStatuses[status] {
-		return fmt.Errorf("invalid status: %s (must be pending, in_progress, or done)", status)
+	_, err := s.UpdateStatusVersion(id, status, nil)
+	return err
+}
+
+// UpdateStatusVersion updates the status of a TODO item (admin/internal use),
+// failing with a VersionConflictError when expectedVersion is set and is not
+// the version of the item. It returns the new version.
+func (s *Service) UpdateStatusVersion(id, status string, expectedVersion *int) (int, error) {
+	return s.updateStatus(id, status, expectedVersion, nil)
+}
+
+// updateStatus validates a status change against the workflow and the
+// dependencies of the TODO, after the optional allowed check and the
+// expected version, and saves it
+func (s *Service) updateStatus(id, status string, expectedVersion *int, allowed func(item *TODOItem) error) (int, error) {
+	if !s.workflow.IsValid(status) {
+		return 0, fmt.Errorf("invalid status: %s (must be %s)", status, s.workflow.describe())
 	}
 
-	return s.storage.WithLock(func() error {
+	version := 0
+	err := s.storage.WithLock(func() error {
 		list, err := s.storage.Load()
 		if err != nil {
 			return err
 		}
 
-		var item *TODOItem
-		var currentStatus string
-		found := false
-		for i := range list.Items {
-			if list.Items[i].ID == id {
-				item = &list.Items[i]
-				currentStatus = item.Status
-				found = true
-				break
+		item := s.findTODOByID(list, id)
+		if item == nil {
+			return fmt.Errorf("TODO item with ID '%s' not found", id)
+		}
+		if allowed != nil {
+			if err := allowed(item); err != nil {
+				return err
 			}
 		}
-
-		if !found {
-			return fmt.Errorf("TODO item with ID '%s' not found", id)
+		if err := checkVersion(item, expectedVersion); err != nil {
+			return err
 		}
 
-		// Check dependencies if transitioning to in_progress or done from pending
-		if status == "in_progress" && currentStatus == "pending" {
-			satisfied, blocking := s.checkDependenciesSatisfied(list, item)
-			if !satisfied {
-				blockingInfo := []string{}
-				for _, blockID := range blocking {
-					blockItem := s.findTODOByID(list, blockID)
-					if blockItem != nil {
-						blockingInfo = append(blockingInfo, fmt.Sprintf("%s (%s)", blockID, blockItem.Status))
-					} else {
-						blockingInfo = append(blockingInfo, blockID)
-					}
-				}
-				return fmt.Errorf("TODO '%s' is blocked by dependencies: %v", id, blockingInfo)
+		if !s.workflow.CanTransition(item.Status, status) {
+			next := s.workflow.Transitions[item.Status]
+			if len(next) == 0 {
+				return fmt.Errorf("TODO '%s' cannot leave status '%s'", id, item.Status)
 			}
-		} else if status == "done" && currentStatus == "pending" {
-			// Allow pending -> done if dependencies are satisfied
+			return fmt.Errorf("TODO '%s' cannot move from '%s' to '%s' (allowed: %s)", id, item.Status, status, strings.Join(next, ", "))
+		}
+
+		// A TODO leaves its initial status, e.g. pending -> in_progress or
+		// pending -> done, only once its dependencies are satisfied. Other
+		// changes, e.g. done -> in_progress, are not checked.
+		if item.Status == s.workflow.Initial() && status != item.Status {
 			satisfied, blocking := s.checkDependenciesSatisfied(list, item)
 			if !satisfied {
 				blockingInfo := []string{}
@@ -252,35 +299,47 @@ func (s *Service) UpdateStatus(id, status string) error {
 				return fmt.Errorf("TODO '%s' is blocked by dependencies: %v", id, blockingInfo)
 			}
 		}
-		// Allow in_progress -> done, done -> in_progress, in_progress -> pending without dependency checks
 
 		item.Status = status
+		item.Version++
+		version = item.Version
 		return s.storage.Save(list)
 	})
+	if err != nil {
+		return 0, err
+	}
+	return version, nil
 }
 
 // UpdateAssignee updates the assignee of a TODO item
 func (s *Service) UpdateAssignee(id, assignee string) error {
-	return s.storage.WithLock(func() error {
-		list, err := s.storage.Load()
-		if err != nil {
-			return err
-		}
+	_, err := s.UpdateAssigneeVersion(id, assignee, nil)
+	return err
+}
 
-		found := false
-		for i := range list.Items {
-			if list.Items[i].ID == id {
-				list.Items[i].Assignee = assignee
-				found = true
-				break
-			}
-		}
+// UpdateAssigneeVersion updates the assignee of a TODO item, failing with a
+// VersionConflictError when expectedVersion is set and is not the version of
+// the item. It returns the new version.
+func (s *Service) UpdateAssigneeVersion(id, assignee string, expectedVersion *int) (int, error) {
+	return s.updateItem(id, expectedVersion, func(list *TODOList, item *TODOItem) error {
+		item.Assignee = assignee
+		return nil
+	})
+}
 
-		if !found {
-			return fmt.Errorf("TODO item with ID '%s' not found", id)
-		}
+// UpdateDueDate sets the due date of a TODO item, a nil dueAt clears it
+func (s *Service) UpdateDueDate(id string, dueAt *time.Time) error {
+	_, err := s.UpdateDueDateVersion(id, dueAt, nil)
+	return err
+}
 
-		return s.storage.Save(list)
+// UpdateDueDateVersion sets the due date of a TODO item, a nil dueAt clears
+// it, failing with a VersionConflictError when expectedVersion is set and is
+// not the version of the item. It returns the new version.
+func (s *Service) UpdateDueDateVersion(id string, dueAt *time.Time, expectedVersion *int) (int, error) {
+	return s.updateItem(id, expectedVersion, func(list *TODOList, item *TODOItem) error {
+		item.DueAt = dueAt
+		return nil
 	})
 }
 
@@ -300,6 +359,13 @@ func (s *Service) findDependents(list *TODOList, id string) []string {
 
 // RemoveTODO removes a TODO item by ID
 func (s *Service) RemoveTODO(id string) error {
+	return s.RemoveTODOVersion(id, nil)
+}
+
+// RemoveTODOVersion removes a TODO item by ID, failing with a
+// VersionConflictError when expectedVersion is set and is not the version of
+// the item
+func (s *Service) RemoveTODOVersion(id string, expectedVersion *int) error {
 	return s.storage.WithLock(func() error {
 		list, err := s.storage.Load()
 		if err != nil {
@@ -316,6 +382,9 @@ func (s *Service) RemoveTODO(id string) error {
 		newItems := []TODOItem{}
 		for _, item := range list.Items {
 			if item.ID == id {
+				if err := checkVersion(&item, expectedVersion); err != nil {
+					return err
+				}
 				found = true
 			} else {
 				newItems = append(newItems, item)
@@ -331,6 +400,102 @@ func (s *Service) RemoveTODO(id string) error {
 	})
 }
 
+// ArchiveDone moves done TODOs, in any terminal status of the workflow, to
+// the archive and removes them from the active list. A done TODO is kept
+// when a TODO that is not archived along with it depends on it, so the
+// active list never references archived IDs. It returns the archived and
+// the kept (skipped) done TODO IDs.
+func (s *Service) ArchiveDone() ([]string, []string, error) {
+	if s.archive == nil {
+		return nil, nil, fmt.Errorf("archive storage not configured")
+	}
+
+	archived := []string{}
+	skipped := []string{}
+	err := s.storage.WithLock(func() error {
+		list, err := s.storage.Load()
+		if err != nil {
+			return err
+		}
+
+		candidates := map[string]bool{}
+		for _, item := range list.Items {
+			if s.workflow.IsTerminal(item.Status) {
+				candidates[item.ID] = true
+			}
+		}
+
+		// Drop candidates with a dependent that stays active until nothing
+		// changes, so chains of done TODOs are archived together
+		for changed := true; changed; {
+			changed = false
+			for id := range candidates {
+				for _, depID := range s.findDependents(list, id) {
+					if !candidates[depID] {
+						delete(candidates, id)
+						changed = true
+						break
+					}
+				}
+			}
+		}
+
+		archive, err := s.archive.Load()
+		if err != nil {
+			return err
+		}
+
+		remaining := []TODOItem{}
+		for _, item := range list.Items {
+			switch {
+			case candidates[item.ID]:
+				archive.Items = append(archive.Items, item)
+				archived = append(archived, item.ID)
+			case s.workflow.IsTerminal(item.Status):
+				skipped = append(skipped, item.ID)
+				remaining = append(remaining, item)
+			default:
+				remaining = append(remaining, item)
+			}
+		}
+
+		if len(archived) == 0 {
+			return nil
+		}
+
+		// Write the archive first: if saving the active list fails the
+		// items are duplicated rather than lost
+		if err := s.archive.Save(archive); err != nil {
+			return err
+		}
+		list.Items = remaining
+		return s.storage.Save(list)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return archived, skipped, nil
+}
+
+// ListArchived returns all archived TODO items
+func (s *Service) ListArchived() ([]TODOItem, error) {
+	if s.archive == nil {
+		return nil, fmt.Errorf("archive storage not configured")
+	}
+
+	var items []TODOItem
+	err := s.storage.WithLock(func() error {
+		list, err := s.archive.Load()
+		if err != nil {
+			return err
+		}
+		items = make([]TODOItem, len(list.Items))
+		copy(items, list.Items)
+		return nil
+	})
+	return items, err
+}
+
 // ListTODOs returns all TODO items
 func (s *Service) ListTODOs() ([]TODOItem, error) {
 	var items []TODOItem
@@ -354,6 +519,7 @@ type StatusSummary struct {
 	Done       int
 	Blocked    int
 	Ready      int
+	ByStatus   map[string]int
 	ByAssignee map[string]int
 }
 
@@ -373,10 +539,12 @@ func (s *Service) GetStatus() (*StatusSummary, error) {
 			Done:       0,
 			Blocked:    0,
 			Ready:      0,
+			ByStatus:   make(map[string]int),
 			ByAssignee: make(map[string]int),
 		}
 
 		for _, item := range list.Items {
+			summary.ByStatus[item.Status]++
 			switch item.Status {
 			case "pending":
 				summary.Pending++
@@ -428,22 +596,19 @@ func (s *Service) detectCircularDependency(list *TODOList, todoID, dependsOnID s
 
 // AddDependency adds a dependency to an existing TODO
 func (s *Service) AddDependency(todoID, dependsOnID string) error {
+	_, err := s.AddDependencyVersion(todoID, dependsOnID, nil)
+	return err
+}
+
+// AddDependencyVersion adds a dependency to a TODO item, failing with a
+// VersionConflictError when expectedVersion is set and is not the version of
+// the item. It returns the new version.
+func (s *Service) AddDependencyVersion(todoID, dependsOnID string, expectedVersion *int) (int, error) {
 	if todoID == dependsOnID {
-		return fmt.Errorf("TODO cannot depend on itself")
+		return 0, fmt.Errorf("TODO cannot depend on itself")
 	}
 
-	return s.storage.WithLock(func() error {
-		list, err := s.storage.Load()
-		if err != nil {
-			return err
-		}
-
-		// Find the TODO
-		todo := s.findTODOByID(list, todoID)
-		if todo == nil {
-			return fmt.Errorf("TODO item with ID '%s' not found", todoID)
-		}
-
+	return s.updateItem(todoID, expectedVersion, func(list *TODOList, todo *TODOItem) error {
 		// Validate dependency TODO exists
 		if s.findTODOByID(list, dependsOnID) == nil {
 			return fmt.Errorf("dependency TODO with ID '%s' not found", dependsOnID)
@@ -463,12 +628,12 @@ func (s *Service) AddDependency(todoID, dependsOnID string) error {
 
 		// Add dependency
 		todo.DependsOn = append(todo.DependsOn, dependsOnID)
-
-		return s.storage.Save(list)
+		return nil
 	})
 }
 
-// GetReadyTODOs returns TODOs that are pending with all dependencies satisfied
+// GetReadyTODOs returns TODOs that are pending, in the initial status, with
+// all dependencies satisfied
 func (s *Service) GetReadyTODOs() ([]TODOItem, error) {
 	var ready []TODOItem
 	err := s.storage.WithLock(func() error {
@@ -478,7 +643,7 @@ func (s *Service) GetReadyTODOs() ([]TODOItem, error) {
 		}
 
 		for _, item := range list.Items {
-			if item.Status == "pending" {
+			if item.Status == s.workflow.Initial() {
 				satisfied, _ := s.checkDependenciesSatisfied(list, &item)
 				if satisfied {
 					ready = append(ready, item)
@@ -500,7 +665,7 @@ func (s *Service) GetBlockedTODOs() ([]BlockedTODO, error) {
 		}
 
 		for _, item := range list.Items {
-			if item.Status == "pending" && len(item.DependsOn) > 0 {
+			if item.Status == s.workflow.Initial() && len(item.DependsOn) > 0 {
 				satisfied, blockingIDs := s.checkDependenciesSatisfied(list, &item)
 				if !satisfied {
 					blockingInfo := []BlockingInfo{}
@@ -519,6 +684,7 @@ func (s *Service) GetBlockedTODOs() ([]BlockedTODO, error) {
 						Title:     item.Title,
 						Status:    item.Status,
 						Assignee:  item.Assignee,
+						DueAt:     item.DueAt,
 						BlockedBy: blockingInfo,
 					})
 				}
@@ -529,6 +695,34 @@ func (s *Service) GetBlockedTODOs() ([]BlockedTODO, error) {
 	return blocked, err
 }
 
+// GetOverdueTODOs returns TODOs that are not done and past their due date at
+// now, most overdue first
+func (s *Service) GetOverdueTODOs(now time.Time) ([]OverdueTODO, error) {
+	var overdue []OverdueTODO
+	err := s.storage.WithLock(func() error {
+		list, err := s.storage.Load()
+		if err != nil {
+			return err
+		}
+
+		for _, item := range list.Items {
+			if s.workflow.IsTerminal(item.Status) || item.DueAt == nil || !item.DueAt.Before(now) {
+				continue
+			}
+			overdue = append(overdue, OverdueTODO{
+				TODOItem:  item,
+				OverdueBy: now.Sub(*item.DueAt).Round(time.Second).String(),
+			})
+		}
+		return nil
+	})
+
+	sort.SliceStable(overdue, func(i, j int) bool {
+		return overdue[i].DueAt.Before(*overdue[j].DueAt)
+	})
+	return overdue, err
+}
+
 // GetDependencies returns dependencies for a TODO
 func (s *Service) GetDependencies(id string, transitive bool) (*GetTODODependenciesOutput, error) {
 	var result *GetTODODependenciesOutput
@@ -565,32 +759,16 @@ func (s *Service) GetDependencies(id string, transitive bool) (*GetTODODependenc
 
 		// Get transitive dependencies if requested
 		if transitive {
-			visited := make(map[string]bool)
-			var collectTransitive func(depID string)
-			collectTransitive = func(depID string) {
-				if visited[depID] || directMap[depID] {
-					return
-				}
-				visited[depID] = true
-				dep := s.findTODOByID(list, depID)
-				if dep != nil {
-					result.Transitive = append(result.Transitive, DependencyInfo{
-						ID:     dep.ID,
-						Title:  dep.Title,
-						Status: dep.Status,
-					})
-					for _, subDepID := range dep.DependsOn {
-						collectTransitive(subDepID)
-					}
-				}
-			}
-
 			for _, depID := range item.DependsOn {
 				dep := s.findTODOByID(list, depID)
 				if dep != nil {
-					for _, subDepID := range dep.DependsOn {
-						collectTransitive(subDepID)
-					}
+					s.collectDependencies(list, dep.DependsOn, directMap, func(dep *TODOItem) {
+						result.Transitive = append(result.Transitive, DependencyInfo{
+							ID:     dep.ID,
+							Title:  dep.Title,
+							Status: dep.Status,
+						})
+					})
 				}
 			}
 		}
@@ -600,20 +778,34 @@ func (s *Service) GetDependencies(id string, transitive bool) (*GetTODODependenc
 	return result, err
 }
 
-// RemoveDependency removes a dependency from a TODO
-func (s *Service) RemoveDependency(todoID, dependsOnID string) error {
-	return s.storage.WithLock(func() error {
-		list, err := s.storage.Load()
-		if err != nil {
-			return err
+// collectDependencies walks the given dependency IDs and their own
+// dependencies depth first, calling visit once for each TODO found. IDs in
+// seen are skipped, and the visited ones are added to it.
+func (s *Service) collectDependencies(list *TODOList, ids []string, seen map[string]bool, visit func(dep *TODOItem)) {
+	for _, depID := range ids {
+		if seen[depID] {
+			continue
 		}
-
-		// Find the TODO
-		todo := s.findTODOByID(list, todoID)
-		if todo == nil {
-			return fmt.Errorf("TODO item with ID '%s' not found", todoID)
+		seen[depID] = true
+		dep := s.findTODOByID(list, depID)
+		if dep != nil {
+			visit(dep)
+			s.collectDependencies(list, dep.DependsOn, seen, visit)
 		}
+	}
+}
 
+// RemoveDependency removes a dependency from a TODO
+func (s *Service) RemoveDependency(todoID, dependsOnID string) error {
+	_, err := s.RemoveDependencyVersion(todoID, dependsOnID, nil)
+	return err
+}
+
+// RemoveDependencyVersion removes a dependency from a TODO item, failing
+// with a VersionConflictError when expectedVersion is set and is not the
+// version of the item. It returns the new version.
+func (s *Service) RemoveDependencyVersion(todoID, dependsOnID string, expectedVersion *int) (int, error) {
+	return s.updateItem(todoID, expectedVersion, func(list *TODOList, todo *TODOItem) error {
 		// Remove dependency if it exists
 		newDeps := []string{}
 		for _, dep := range todo.DependsOn {
@@ -623,7 +815,6 @@ func (s *Service) RemoveDependency(todoID, dependsOnID string) error {
 		}
 
 		todo.DependsOn = newDeps
-
-		return s.storage.Save(list)
+		return nil
 	})
 }
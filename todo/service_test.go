@@ -1,6 +1,9 @@
 package main
 
 import (
+	"errors"
+	"time"
+
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 )
@@ -528,6 +531,60 @@ var _ = Describe("Service", func() {
 		})
 	})
 
+	Context("Due dates", func() {
+		var now time.Time
+
+		BeforeEach(func() {
+			now = time.Date(2025, 1, 15, 12, 0, 0, 0, time.UTC)
+		})
+
+		It("should store the due date when adding a TODO", func() {
+			dueAt := now.Add(24 * time.Hour)
+			item, err := service.AddTODOWithDue("todo-1", "Task", "", nil, &dueAt)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(item.DueAt).NotTo(BeNil())
+			Expect(item.DueAt.Equal(dueAt)).To(BeTrue())
+		})
+
+		It("should set and clear the due date", func() {
+			_, _ = service.AddTODO("todo-1", "Task", "", nil)
+			dueAt := now.Add(time.Hour)
+
+			Expect(service.UpdateDueDate("todo-1", &dueAt)).To(Succeed())
+			items, _ := service.ListTODOs()
+			Expect(items[0].DueAt).NotTo(BeNil())
+
+			Expect(service.UpdateDueDate("todo-1", nil)).To(Succeed())
+			items, _ = service.ListTODOs()
+			Expect(items[0].DueAt).To(BeNil())
+		})
+
+		It("should return error when updating due date of non-existent TODO", func() {
+			err := service.UpdateDueDate("non-existent", nil)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("not found"))
+		})
+
+		It("should return non-done overdue TODOs sorted by how overdue they are", func() {
+			hourAgo := now.Add(-time.Hour)
+			dayAgo := now.Add(-24 * time.Hour)
+			tomorrow := now.Add(24 * time.Hour)
+			_, _ = service.AddTODOWithDue("todo-1", "Late", "", nil, &hourAgo)
+			_, _ = service.AddTODOWithDue("todo-2", "Very late", "", nil, &dayAgo)
+			_, _ = service.AddTODOWithDue("todo-3", "Not due yet", "", nil, &tomorrow)
+			_, _ = service.AddTODOWithDue("todo-4", "Done late", "", nil, &dayAgo)
+			_, _ = service.AddTODO("todo-5", "No due date", "", nil)
+			_ = service.UpdateStatus("todo-4", "done")
+
+			overdue, err := service.GetOverdueTODOs(now)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(overdue).To(HaveLen(2))
+			Expect(overdue[0].ID).To(Equal("todo-2"))
+			Expect(overdue[0].OverdueBy).To(Equal("24h0m0s"))
+			Expect(overdue[1].ID).To(Equal("todo-1"))
+		})
+	})
+
 	Context("GetBlockedTODOs", func() {
 		BeforeEach(func() {
 			_, _ = service.AddTODO("todo-1", "Dependency 1", "", nil)
@@ -650,6 +707,109 @@ var _ = Describe("Service", func() {
 		})
 	})
 
+	Context("ArchiveDone", func() {
+		var archiveStorage *MockStorage
+
+		BeforeEach(func() {
+			archiveStorage = NewMockStorage()
+			service = NewServiceWithArchive(mockStorage, archiveStorage)
+			_, _ = service.AddTODO("todo-1", "Parent", "", nil)
+			_, _ = service.AddTODO("todo-2", "Child", "", []string{"todo-1"})
+			_, _ = service.AddTODO("todo-3", "Standalone", "", nil)
+			_ = service.UpdateStatus("todo-1", "done")
+			_ = service.UpdateStatus("todo-3", "done")
+		})
+
+		It("should archive done TODOs and keep those with active dependents", func() {
+			archived, skipped, err := service.ArchiveDone()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(archived).To(Equal([]string{"todo-3"}))
+			Expect(skipped).To(Equal([]string{"todo-1"}))
+
+			list, _ := mockStorage.Load()
+			Expect(list.Items).To(HaveLen(2))
+			items, err := service.ListArchived()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(items).To(HaveLen(1))
+			Expect(items[0].ID).To(Equal("todo-3"))
+		})
+
+		It("should archive chains of done TODOs together", func() {
+			_ = service.UpdateStatus("todo-2", "done")
+
+			archived, skipped, err := service.ArchiveDone()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(archived).To(ConsistOf("todo-1", "todo-2", "todo-3"))
+			Expect(skipped).To(BeEmpty())
+
+			list, _ := mockStorage.Load()
+			Expect(list.Items).To(BeEmpty())
+		})
+
+		It("should append to an existing archive", func() {
+			_, _, _ = service.ArchiveDone()
+			_ = service.UpdateStatus("todo-2", "done")
+			_, _, _ = service.ArchiveDone()
+
+			items, err := service.ListArchived()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(items).To(HaveLen(3))
+		})
+
+		It("should fail without archive storage", func() {
+			_, _, err := NewService(mockStorage).ArchiveDone()
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Context("Versions", func() {
+		version := func(v int) *int { return &v }
+
+		It("starts new TODOs at version 1 and bumps it on every change", func() {
+			item, err := service.AddTODO("todo-1", "Task", "", nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(item.Version).To(Equal(1))
+			_, _ = service.AddTODO("todo-2", "Dep", "", nil)
+
+			v, err := service.UpdateStatusVersion("todo-1", "in_progress", version(1))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(v).To(Equal(2))
+			v, err = service.UpdateAssigneeVersion("todo-1", "agent-1", nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(v).To(Equal(3))
+			v, err = service.AddDependencyVersion("todo-1", "todo-2", version(3))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(v).To(Equal(4))
+
+			items, err := service.ListTODOs()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(items[0].Version).To(Equal(4))
+			Expect(items[1].Version).To(Equal(1))
+		})
+
+		It("rejects a change made with a stale version", func() {
+			_, _ = service.AddTODO("todo-1", "Task", "agent-1", nil)
+			_ = service.UpdateAssignee("todo-1", "agent-2")
+
+			_, err := service.UpdateStatusWithAgentVersion("todo-1", "in_progress", "agent-2", version(1))
+			var conflict *VersionConflictError
+			Expect(errors.As(err, &conflict)).To(BeTrue())
+			Expect(conflict.Expected).To(Equal(1))
+			Expect(conflict.Current).To(Equal(2))
+
+			_, err = service.UpdateDueDateVersion("todo-1", nil, version(1))
+			Expect(err).To(BeAssignableToTypeOf(&VersionConflictError{}))
+			Expect(service.RemoveTODOVersion("todo-1", version(1))).To(BeAssignableToTypeOf(&VersionConflictError{}))
+
+			items, _ := service.ListTODOs()
+			Expect(items).To(HaveLen(1))
+			Expect(items[0].Status).To(Equal("pending"))
+			Expect(items[0].Version).To(Equal(2))
+
+			Expect(service.RemoveTODOVersion("todo-1", version(2))).To(Succeed())
+		})
+	})
+
 	Context("Edge cases", func() {
 		It("should handle empty dependency list correctly", func() {
 			item, err := service.AddTODO("todo-1", "Test", "", []string{})
@@ -1,12 +1,16 @@
 package main
 
+import "time"
+
 // TODOItem represents a single TODO item
 type TODOItem struct {
-	ID        string   `json:"id"`                   // Unique identifier
-	Title     string   `json:"title"`                // Task title
-	Status    string   `json:"status"`               // "pending", "in_progress", "done"
-	Assignee  string   `json:"assignee"`             // Agent name assigned to task
-	DependsOn []string `json:"depends_on,omitempty"` // Array of TODO IDs this item depends on
+	ID        string     `json:"id"`                   // Unique identifier
+	Title     string     `json:"title"`                // Task title
+	Status    string     `json:"status"`               // "pending", "in_progress", "done" or a status of TODO_STATUSES
+	Assignee  string     `json:"assignee"`             // Agent name assigned to task
+	DependsOn []string   `json:"depends_on,omitempty"` // Array of TODO IDs this item depends on
+	DueAt     *time.Time `json:"due_at,omitempty"`     // Optional deadline
+	Version   int        `json:"version"`              // Incremented on every change, for optimistic updates
 }
 
 // TODOList represents the entire TODO list
@@ -20,52 +24,77 @@ type AddTODOInput struct {
 	Title     string   `json:"title" jsonschema:"the title of the TODO item"`
 	Assignee  string   `json:"assignee,omitempty" jsonschema:"the agent name assigned to this TODO item (optional)"`
 	DependsOn []string `json:"depends_on,omitempty" jsonschema:"array of TODO IDs this item depends on (optional)"`
+	DueAt     string   `json:"due_at,omitempty" jsonschema:"the due date in RFC3339 format, e.g. 2025-01-31T17:00:00Z (optional)"`
 }
 
 type UpdateTODOStatusInput struct {
-	ID        string `json:"id" jsonschema:"the ID of the TODO item to update"`
-	Status    string `json:"status" jsonschema:"the new status (pending, in_progress, or done)"`
-	AgentName string `json:"agent_name,omitempty" jsonschema:"the name of the agent performing the update (required when not in admin mode)"`
+	ID              string `json:"id" jsonschema:"the ID of the TODO item to update"`
+	Status          string `json:"status" jsonschema:"the new status (pending, in_progress, or done unless other statuses are configured)"`
+	AgentName       string `json:"agent_name,omitempty" jsonschema:"the name of the agent performing the update (required when not in admin mode)"`
+	ExpectedVersion *int   `json:"expected_version,omitempty" jsonschema:"fail with a conflict unless the TODO item is still at this version (optional)"`
 }
 
 type UpdateTODOAssigneeInput struct {
-	ID       string `json:"id" jsonschema:"the ID of the TODO item to update"`
-	Assignee string `json:"assignee" jsonschema:"the new assignee agent name"`
+	ID              string `json:"id" jsonschema:"the ID of the TODO item to update"`
+	Assignee        string `json:"assignee" jsonschema:"the new assignee agent name"`
+	ExpectedVersion *int   `json:"expected_version,omitempty" jsonschema:"fail with a conflict unless the TODO item is still at this version (optional)"`
+}
+
+type UpdateTODODueInput struct {
+	ID              string `json:"id" jsonschema:"the ID of the TODO item to update"`
+	DueAt           string `json:"due_at,omitempty" jsonschema:"the new due date in RFC3339 format, leave empty to clear it"`
+	ExpectedVersion *int   `json:"expected_version,omitempty" jsonschema:"fail with a conflict unless the TODO item is still at this version (optional)"`
 }
 
 type RemoveTODOInput struct {
-	ID string `json:"id" jsonschema:"the ID of the TODO item to remove"`
+	ID              string `json:"id" jsonschema:"the ID of the TODO item to remove"`
+	ExpectedVersion *int   `json:"expected_version,omitempty" jsonschema:"fail with a conflict unless the TODO item is still at this version (optional)"`
 }
 
 type GetTODOStatusInput struct{}
 
 // Dependency management input types
 type AddTODODependencyInput struct {
-	ID        string `json:"id" jsonschema:"the ID of the TODO item"`
-	DependsOn string `json:"depends_on" jsonschema:"the ID of the TODO this item depends on"`
+	ID              string `json:"id" jsonschema:"the ID of the TODO item"`
+	DependsOn       string `json:"depends_on" jsonschema:"the ID of the TODO this item depends on"`
+	ExpectedVersion *int   `json:"expected_version,omitempty" jsonschema:"fail with a conflict unless the TODO item is still at this version (optional)"`
 }
 
 type RemoveTODODependencyInput struct {
-	ID        string `json:"id" jsonschema:"the ID of the TODO item"`
-	DependsOn string `json:"depends_on" jsonschema:"the ID of the dependency to remove"`
+	ID              string `json:"id" jsonschema:"the ID of the TODO item"`
+	DependsOn       string `json:"depends_on" jsonschema:"the ID of the dependency to remove"`
+	ExpectedVersion *int   `json:"expected_version,omitempty" jsonschema:"fail with a conflict unless the TODO item is still at this version (optional)"`
 }
 
 type GetReadyTODOsInput struct{}
 
 type GetBlockedTODOsInput struct{}
 
+type GetOverdueTODOsInput struct{}
+
+type ArchiveDoneTODOsInput struct{}
+
+type ListArchivedTODOsInput struct{}
+
 type GetTODODependenciesInput struct {
 	ID         string `json:"id" jsonschema:"the ID of the TODO item"`
 	Transitive bool   `json:"transitive,omitempty" jsonschema:"whether to include transitive dependencies (default: false)"`
 }
 
+type ExportGraphInput struct {
+	Format string `json:"format,omitempty" jsonschema:"dot (Graphviz) or mermaid (default: dot)"`
+	ID     string `json:"id,omitempty" jsonschema:"only export this TODO item and its transitive dependencies (default: the whole list)"`
+}
+
 // Output types
 type AddTODOOutput struct {
-	ID        string   `json:"id" jsonschema:"the ID of the created TODO item"`
-	Title     string   `json:"title" jsonschema:"the title of the TODO item"`
-	Status    string   `json:"status" jsonschema:"the status of the TODO item"`
-	Assignee  string   `json:"assignee" jsonschema:"the assignee of the TODO item"`
-	DependsOn []string `json:"depends_on,omitempty" jsonschema:"dependencies of the TODO item"`
+	ID        string     `json:"id" jsonschema:"the ID of the created TODO item"`
+	Title     string     `json:"title" jsonschema:"the title of the TODO item"`
+	Status    string     `json:"status" jsonschema:"the status of the TODO item"`
+	Assignee  string     `json:"assignee" jsonschema:"the assignee of the TODO item"`
+	DependsOn []string   `json:"depends_on,omitempty" jsonschema:"dependencies of the TODO item"`
+	DueAt     *time.Time `json:"due_at,omitempty" jsonschema:"the due date of the TODO item"`
+	Version   int        `json:"version" jsonschema:"the version of the TODO item, to pass as expected_version"`
 }
 
 type ListTODOsOutput struct {
@@ -76,16 +105,37 @@ type ListTODOsOutput struct {
 type UpdateTODOStatusOutput struct {
 	Success bool   `json:"success" jsonschema:"whether the update was successful"`
 	Message string `json:"message" jsonschema:"status message"`
+	Version int    `json:"version,omitempty" jsonschema:"the version of the TODO item after the change, or its current version on a version conflict"`
 }
 
 type UpdateTODOAssigneeOutput struct {
 	Success bool   `json:"success" jsonschema:"whether the update was successful"`
 	Message string `json:"message" jsonschema:"status message"`
+	Version int    `json:"version,omitempty" jsonschema:"the version of the TODO item after the change, or its current version on a version conflict"`
+}
+
+type UpdateTODODueOutput struct {
+	Success bool   `json:"success" jsonschema:"whether the update was successful"`
+	Message string `json:"message" jsonschema:"status message"`
+	Version int    `json:"version,omitempty" jsonschema:"the version of the TODO item after the change, or its current version on a version conflict"`
 }
 
 type RemoveTODOOutput struct {
 	Success bool   `json:"success" jsonschema:"whether the removal was successful"`
 	Message string `json:"message" jsonschema:"status message"`
+	Version int    `json:"version,omitempty" jsonschema:"the current version of the TODO item on a version conflict"`
+}
+
+type ArchiveDoneTODOsOutput struct {
+	Archived []string `json:"archived" jsonschema:"IDs of the TODO items moved to the archive"`
+	Skipped  []string `json:"skipped,omitempty" jsonschema:"IDs of done TODO items kept because active TODOs depend on them"`
+	Count    int      `json:"count" jsonschema:"number of archived items"`
+	Message  string   `json:"message" jsonschema:"status message"`
+}
+
+type ListArchivedTODOsOutput struct {
+	Items []TODOItem `json:"items" jsonschema:"list of archived TODO items"`
+	Count int        `json:"count" jsonschema:"number of archived items"`
 }
 
 type GetTODOStatusOutput struct {
@@ -95,6 +145,7 @@ type GetTODOStatusOutput struct {
 	Done       int            `json:"done" jsonschema:"number of done items"`
 	Blocked    int            `json:"blocked" jsonschema:"number of blocked items (pending with unsatisfied dependencies)"`
 	Ready      int            `json:"ready" jsonschema:"number of ready items (pending with all dependencies satisfied)"`
+	ByStatus   map[string]int `json:"by_status" jsonschema:"count of items by status, including configured statuses"`
 	ByAssignee map[string]int `json:"by_assignee" jsonschema:"count of items by assignee"`
 }
 
@@ -102,11 +153,13 @@ type GetTODOStatusOutput struct {
 type AddTODODependencyOutput struct {
 	Success bool   `json:"success" jsonschema:"whether the operation was successful"`
 	Message string `json:"message" jsonschema:"status message"`
+	Version int    `json:"version,omitempty" jsonschema:"the version of the TODO item after the change, or its current version on a version conflict"`
 }
 
 type RemoveTODODependencyOutput struct {
 	Success bool   `json:"success" jsonschema:"whether the operation was successful"`
 	Message string `json:"message" jsonschema:"status message"`
+	Version int    `json:"version,omitempty" jsonschema:"the version of the TODO item after the change, or its current version on a version conflict"`
 }
 
 // BlockingInfo represents information about a blocking dependency
@@ -122,9 +175,16 @@ type BlockedTODO struct {
 	Title     string         `json:"title" jsonschema:"the title of the blocked TODO"`
 	Status    string         `json:"status" jsonschema:"the status of the blocked TODO"`
 	Assignee  string         `json:"assignee" jsonschema:"the assignee of the blocked TODO"`
+	DueAt     *time.Time     `json:"due_at,omitempty" jsonschema:"the due date of the blocked TODO"`
 	BlockedBy []BlockingInfo `json:"blocked_by" jsonschema:"list of blocking dependencies"`
 }
 
+// OverdueTODO represents a TODO that is not done past its due date
+type OverdueTODO struct {
+	TODOItem
+	OverdueBy string `json:"overdue_by" jsonschema:"how long ago the TODO was due"`
+}
+
 type GetReadyTODOsOutput struct {
 	Items []TODOItem `json:"items" jsonschema:"list of ready TODO items"`
 	Count int        `json:"count" jsonschema:"number of ready items"`
@@ -135,6 +195,11 @@ type GetBlockedTODOsOutput struct {
 	Count int           `json:"count" jsonschema:"number of blocked items"`
 }
 
+type GetOverdueTODOsOutput struct {
+	Items []OverdueTODO `json:"items" jsonschema:"list of overdue TODO items, most overdue first"`
+	Count int           `json:"count" jsonschema:"number of overdue items"`
+}
+
 // DependencyInfo represents information about a dependency
 type DependencyInfo struct {
 	ID     string `json:"id" jsonschema:"the ID of the dependency"`
@@ -147,3 +212,10 @@ type GetTODODependenciesOutput struct {
 	Transitive  []DependencyInfo `json:"transitive,omitempty" jsonschema:"transitive dependencies (if requested)"`
 	DirectCount int              `json:"direct_count" jsonschema:"number of direct dependencies"`
 }
+
+type ExportGraphOutput struct {
+	Format string `json:"format" jsonschema:"the format of the graph"`
+	Graph  string `json:"graph" jsonschema:"the dependency graph, edges pointing from a TODO item to the items it depends on"`
+	Nodes  int    `json:"nodes" jsonschema:"number of TODO items in the graph"`
+	Edges  int    `json:"edges" jsonschema:"number of dependencies in the graph"`
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+)
+
+// Workflow defines the statuses a TODO can have and how it moves between
+// them
+type Workflow struct {
+	// Statuses lists the valid statuses, the first one being given to new
+	// TODOs
+	Statuses []string
+	// Terminal lists the statuses of finished TODOs. A dependency in one of
+	// them is satisfied, and such TODOs are archived and never overdue.
+	Terminal []string
+	// Transitions maps a status to the statuses it can change to. A nil map
+	// allows every change.
+	Transitions map[string][]string
+}
+
+// DefaultWorkflow is the pending -> in_progress -> done flow, allowing any
+// change between the three statuses
+func DefaultWorkflow() *Workflow {
+	return &Workflow{
+		Statuses: []string{"pending", "in_progress", "done"},
+		Terminal: []string{"done"},
+	}
+}
+
+// Initial returns the status of new TODOs
+func (w *Workflow) Initial() string {
+	return w.Statuses[0]
+}
+
+// IsValid reports whether status belongs to the workflow
+func (w *Workflow) IsValid(status string) bool {
+	return slices.Contains(w.Statuses, status)
+}
+
+// IsTerminal reports whether status marks a finished TODO
+func (w *Workflow) IsTerminal(status string) bool {
+	return slices.Contains(w.Terminal, status)
+}
+
+// CanTransition reports whether a TODO can change from one status to the
+// other. Setting the current status again is always allowed.
+func (w *Workflow) CanTransition(from, to string) bool {
+	if w.Transitions == nil || from == to {
+		return true
+	}
+	return slices.Contains(w.Transitions[from], to)
+}
+
+// describe lists the statuses for error messages, e.g. "a, b, or c"
+func (w *Workflow) describe() string {
+	if len(w.Statuses) <= 2 {
+		return strings.Join(w.Statuses, " or ")
+	}
+	return strings.Join(w.Statuses[:len(w.Statuses)-1], ", ") + ", or " + w.Statuses[len(w.Statuses)-1]
+}
+
+// LoadWorkflowFromEnv builds the workflow from TODO_STATUSES,
+// TODO_TERMINAL_STATUSES and TODO_TRANSITIONS, falling back to the default
+// workflow when none is set
+func LoadWorkflowFromEnv() (*Workflow, error) {
+	return ParseWorkflow(os.Getenv("TODO_STATUSES"), os.Getenv("TODO_TERMINAL_STATUSES"), os.Getenv("TODO_TRANSITIONS"))
+}
+
+// ParseWorkflow builds a workflow from comma-separated statuses and terminal
+// statuses, and comma-separated "from->to" transitions where from can be *
+// to allow the change from any status. Empty values keep the default
+// statuses, "done" as terminal status and allow every transition.
+func ParseWorkflow(statuses, terminal, transitions string) (*Workflow, error) {
+	workflow := DefaultWorkflow()
+	if strings.TrimSpace(statuses) != "" {
+		workflow.Statuses = splitList(statuses)
+		if len(workflow.Statuses) < 2 {
+			return nil, fmt.Errorf("invalid TODO_STATUSES: at least two statuses are required")
+		}
+		for i, status := range workflow.Statuses {
+			if slices.Contains(workflow.Statuses[:i], status) {
+				return nil, fmt.Errorf("invalid TODO_STATUSES: %q is listed twice", status)
+			}
+		}
+	}
+
+	if strings.TrimSpace(terminal) != "" {
+		workflow.Terminal = splitList(terminal)
+	}
+	if len(workflow.Terminal) == 0 {
+		return nil, fmt.Errorf("invalid TODO_TERMINAL_STATUSES: at least one terminal status is required")
+	}
+	for _, status := range workflow.Terminal {
+		if !workflow.IsValid(status) {
+			return nil, fmt.Errorf("invalid TODO_TERMINAL_STATUSES: unknown status %q (must be %s)", status, workflow.describe())
+		}
+		if status == workflow.Initial() {
+			return nil, fmt.Errorf("invalid TODO_TERMINAL_STATUSES: %q is the initial status", status)
+		}
+	}
+
+	if strings.TrimSpace(transitions) != "" {
+		workflow.Transitions = map[string][]string{}
+		for _, transition := range splitList(transitions) {
+			from, to, ok := strings.Cut(transition, "->")
+			from, to = strings.TrimSpace(from), strings.TrimSpace(to)
+			if !ok || from == "" || to == "" {
+				return nil, fmt.Errorf("invalid TODO_TRANSITIONS: %q is not in the from->to form", transition)
+			}
+			for _, status := range []string{from, to} {
+				if status != "*" && !workflow.IsValid(status) {
+					return nil, fmt.Errorf("invalid TODO_TRANSITIONS: unknown status %q in %q (must be %s)", status, transition, workflow.describe())
+				}
+			}
+			if to == "*" {
+				return nil, fmt.Errorf("invalid TODO_TRANSITIONS: %q cannot lead to any status", transition)
+			}
+			sources := []string{from}
+			if from == "*" {
+				sources = workflow.Statuses
+			}
+			for _, source := range sources {
+				if source != to && !slices.Contains(workflow.Transitions[source], to) {
+					workflow.Transitions[source] = append(workflow.Transitions[source], to)
+				}
+			}
+		}
+	}
+
+	return workflow, nil
+}
+
+// splitList splits a comma-separated list, dropping empty entries
+func splitList(value string) []string {
+	var list []string
+	for _, entry := range strings.Split(value, ",") {
+		if entry = strings.TrimSpace(entry); entry != "" {
+			list = append(list, entry)
+		}
+	}
+	return list
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Workflow", func() {
+	Context("ParseWorkflow", func() {
+		It("should keep the default workflow when nothing is configured", func() {
+			workflow, err := ParseWorkflow("", "", "")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(workflow.Statuses).To(Equal([]string{"pending", "in_progress", "done"}))
+			Expect(workflow.Terminal).To(Equal([]string{"done"}))
+			Expect(workflow.CanTransition("done", "pending")).To(BeTrue())
+			Expect(workflow.describe()).To(Equal("pending, in_progress, or done"))
+		})
+
+		It("should parse statuses, terminal statuses and transitions", func() {
+			workflow, err := ParseWorkflow("todo, doing, review, done, cancelled", "done,cancelled", "todo->doing, doing->review, review->done, review->doing, *->cancelled")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(workflow.Initial()).To(Equal("todo"))
+			Expect(workflow.IsTerminal("cancelled")).To(BeTrue())
+			Expect(workflow.CanTransition("review", "doing")).To(BeTrue())
+			Expect(workflow.CanTransition("todo", "done")).To(BeFalse())
+			Expect(workflow.CanTransition("review", "cancelled")).To(BeTrue())
+			Expect(workflow.Transitions["cancelled"]).To(BeEmpty())
+		})
+
+		It("should reject invalid configurations", func() {
+			_, err := ParseWorkflow("open", "", "")
+			Expect(err).To(MatchError(ContainSubstring("at least two statuses")))
+
+			_, err = ParseWorkflow("open,open,closed", "", "")
+			Expect(err).To(MatchError(ContainSubstring("listed twice")))
+
+			_, err = ParseWorkflow("open,closed", "", "")
+			Expect(err).To(MatchError(ContainSubstring(`unknown status "done"`)))
+
+			_, err = ParseWorkflow("open,closed", "open", "")
+			Expect(err).To(MatchError(ContainSubstring("initial status")))
+
+			_, err = ParseWorkflow("open,closed", "closed", "open=>closed")
+			Expect(err).To(MatchError(ContainSubstring("from->to")))
+
+			_, err = ParseWorkflow("open,closed", "closed", "open->merged")
+			Expect(err).To(MatchError(ContainSubstring(`unknown status "merged"`)))
+		})
+	})
+
+	Context("Service with a custom workflow", func() {
+		var service *Service
+
+		BeforeEach(func() {
+			workflow, err := ParseWorkflow("pending,in_progress,review,done,cancelled", "done,cancelled", "pending->in_progress,in_progress->review,review->done,review->in_progress,*->cancelled")
+			Expect(err).NotTo(HaveOccurred())
+			service = NewServiceWithArchive(NewMockStorage(), NewMockStorage())
+			service.SetWorkflow(workflow)
+
+			_, err = service.AddTODO("todo-1", "Design", "agent1", nil)
+			Expect(err).NotTo(HaveOccurred())
+			_, err = service.AddTODO("todo-2", "Build", "agent1", []string{"todo-1"})
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("should accept custom statuses along the allowed transitions", func() {
+			Expect(service.UpdateStatus("todo-1", "in_progress")).To(Succeed())
+			Expect(service.UpdateStatus("todo-1", "review")).To(Succeed())
+			Expect(service.UpdateStatus("todo-1", "done")).To(Succeed())
+		})
+
+		It("should reject transitions that are not configured", func() {
+			err := service.UpdateStatus("todo-1", "done")
+			Expect(err).To(MatchError(ContainSubstring("cannot move from 'pending' to 'done' (allowed: in_progress, cancelled)")))
+
+			Expect(service.UpdateStatus("todo-1", "cancelled")).To(Succeed())
+			err = service.UpdateStatus("todo-1", "pending")
+			Expect(err).To(MatchError(ContainSubstring("cannot leave status 'cancelled'")))
+		})
+
+		It("should reject statuses outside of the workflow", func() {
+			err := service.UpdateStatus("todo-1", "blocked")
+			Expect(err).To(MatchError(ContainSubstring("must be pending, in_progress, review, done, or cancelled")))
+		})
+
+		It("should treat any terminal status as satisfying dependencies", func() {
+			Expect(service.UpdateStatus("todo-2", "in_progress")).To(MatchError(ContainSubstring("blocked by dependencies")))
+
+			Expect(service.UpdateStatus("todo-1", "cancelled")).To(Succeed())
+			ready, err := service.GetReadyTODOs()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ready).To(HaveLen(1))
+			Expect(ready[0].ID).To(Equal("todo-2"))
+			Expect(service.UpdateStatus("todo-2", "in_progress")).To(Succeed())
+		})
+
+		It("should archive TODOs in any terminal status", func() {
+			Expect(service.UpdateStatus("todo-1", "cancelled")).To(Succeed())
+			Expect(service.UpdateStatus("todo-2", "cancelled")).To(Succeed())
+
+			archived, skipped, err := service.ArchiveDone()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(archived).To(ConsistOf("todo-1", "todo-2"))
+			Expect(skipped).To(BeEmpty())
+		})
+
+		It("should count items by status", func() {
+			Expect(service.UpdateStatus("todo-1", "in_progress")).To(Succeed())
+			Expect(service.UpdateStatus("todo-1", "review")).To(Succeed())
+
+			summary, err := service.GetStatus()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(summary.ByStatus).To(Equal(map[string]int{"review": 1, "pending": 1}))
+		})
+	})
+})
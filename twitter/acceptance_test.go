@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"os"
+	"path/filepath"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -35,6 +36,16 @@ var _ = Describe("Twitter acceptance", func() {
 			Expect(out.Count).To(BeNumerically("<=", 5))
 		})
 
+		It("get_engagement_summary aggregates recent tweets", func() {
+			ctx := context.Background()
+			_, out, err := GetEngagementSummary(ctx, nil, GetEngagementSummaryInput{Username: "TwitterDev", MaxResults: 5})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(out.TweetCount).To(BeNumerically("<=", 5))
+			if out.TweetCount > 0 {
+				Expect(out.BestTweet).NotTo(BeNil())
+			}
+		})
+
 		It("search_tweets returns structure", func() {
 			ctx := context.Background()
 			_, out, err := SearchTweets(ctx, nil, SearchTweetsInput{Query: "twitter", MaxResults: 5})
@@ -51,6 +62,34 @@ var _ = Describe("Twitter acceptance", func() {
 		})
 	})
 
+	Describe("Relationship snapshots", func() {
+		It("snapshot_relationships and diff_relationships compare stored lists", func() {
+			GinkgoT().Setenv("TWITTER_SNAPSHOT_PATH", filepath.Join(GinkgoT().TempDir(), "snapshots.json"))
+			ctx := context.Background()
+
+			_, _, err := DiffRelationships(ctx, nil, DiffRelationshipsInput{UserID: "783214", Type: "followers"})
+			Expect(err).To(HaveOccurred())
+
+			_, first, err := SnapshotRelationships(ctx, nil, SnapshotRelationshipsInput{UserID: "783214", Type: "followers", MaxResults: 5})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(first.SnapshotID).To(Equal(1))
+			Expect(first.Count).To(BeNumerically("<=", 5))
+
+			_, second, err := SnapshotRelationships(ctx, nil, SnapshotRelationshipsInput{UserID: "783214", Type: "followers", MaxResults: 5})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(second.SnapshotID).To(Equal(2))
+			Expect(second.Snapshots).To(Equal(2))
+
+			_, diff, err := DiffRelationships(ctx, nil, DiffRelationshipsInput{UserID: "783214", Type: "followers"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(diff.From.ID).To(Equal(1))
+			Expect(diff.To.ID).To(Equal(2))
+			Expect(diff.NetChange).To(Equal(second.Count - first.Count))
+			Expect(diff.NewCount).To(Equal(len(diff.New)))
+			Expect(diff.LostCount).To(Equal(len(diff.Lost)))
+		})
+	})
+
 	Describe("Timeline tools", func() {
 		It("get_timeline with type user returns tweets", func() {
 			ctx := context.Background()
@@ -65,7 +104,7 @@ var _ = Describe("Twitter acceptance", func() {
 				Skip("get_unanswered_mentions requires user context (OAuth 1.0a)")
 			}
 			ctx := context.Background()
-			_, out, err := GetUnansweredMentions(ctx, nil, struct{}{})
+			_, out, err := GetUnansweredMentions(ctx, nil, GetUnansweredMentionsInput{})
 			Expect(err).NotTo(HaveOccurred())
 			Expect(out.Tweets).NotTo(BeNil())
 			Expect(out.Count).To(BeNumerically("<=", maxTweets))
@@ -104,7 +143,7 @@ var _ = Describe("Twitter acceptance", func() {
 				Skip("auth user ID not resolved (rate limited); get_mentions needs it")
 			}
 			ctx := context.Background()
-			_, out, err := GetUnansweredMentions(ctx, nil, struct{}{})
+			_, out, err := GetUnansweredMentions(ctx, nil, GetUnansweredMentionsInput{})
 			Expect(err).NotTo(HaveOccurred())
 			Expect(out.Tweets).NotTo(BeNil())
 		})
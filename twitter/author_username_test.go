@@ -0,0 +1,35 @@
+package main
+
+import (
+	"context"
+
+	twitter "github.com/g8rswimmer/go-twitter/v2"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("author usernames", func() {
+	BeforeEach(func() {
+		useMockClient(newMockHTTPClient(), defaultAPIHost)
+	})
+
+	It("are filled from the included users in search_tweets", func() {
+		_, out, err := SearchTweets(context.Background(), nil, SearchTweetsInput{Query: "golang"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(out.Tweets[0].AuthorUsername).To(Equal("mcp_agent"))
+		Expect(out.Tweets[2].AuthorUsername).To(Equal("ada_example"))
+	})
+
+	It("are filled from the included users in get_timeline", func() {
+		_, out, err := GetTimeline(context.Background(), nil, GetTimelineInput{TimelineType: "mentions"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(out.Tweets[0].AuthorID).To(Equal("1000000000000000002"))
+		Expect(out.Tweets[0].AuthorUsername).To(Equal("ada_example"))
+	})
+
+	It("are left empty when the author is not included", func() {
+		t := &twitter.TweetObj{ID: "1", Text: "hello", AuthorID: "42"}
+		Expect(tweetFromObj(t, nil).AuthorUsername).To(BeEmpty())
+		Expect(tweetFromObj(t, &twitter.TweetRawIncludes{}).AuthorUsername).To(BeEmpty())
+	})
+})
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Context propagation", func() {
+	var server *httptest.Server
+	var prevV1Client *http.Client
+	var prevUserCtx bool
+
+	BeforeEach(func() {
+		// An image host that never answers until the request is abandoned
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			select {
+			case <-r.Context().Done():
+			case <-time.After(10 * time.Second):
+			}
+		}))
+		prevV1Client, prevUserCtx = v1Client, hasUserCtx
+		v1Client, hasUserCtx = http.DefaultClient, true
+	})
+
+	AfterEach(func() {
+		v1Client, hasUserCtx = prevV1Client, prevUserCtx
+		server.Close()
+	})
+
+	It("upload_media stops fetching image_url when the call is cancelled", func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(100*time.Millisecond, cancel)
+
+		started := time.Now()
+		_, _, err := UploadMedia(ctx, nil, UploadMediaInput{ImageURL: server.URL + "/image.jpg"})
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("context canceled"))
+		Expect(time.Since(started)).To(BeNumerically("<", 5*time.Second))
+	})
+})
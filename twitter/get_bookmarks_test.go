@@ -0,0 +1,29 @@
+package main
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("get_bookmarks", func() {
+	BeforeEach(func() {
+		useMockClient(newMockHTTPClient(), defaultAPIHost)
+	})
+
+	It("returns the bookmarked tweets with their metrics", func() {
+		_, out, err := GetBookmarks(context.Background(), nil, GetBookmarksInput{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(out.Count).To(Equal(1))
+		Expect(out.Tweets[0].ID).To(Equal("2000000000000000003"))
+		Expect(out.Tweets[0].Metrics).To(HaveKeyWithValue("like_count", 210))
+	})
+
+	It("explains that a bearer token cannot read bookmarks", func() {
+		hasUserCtx = false
+		_, _, err := GetBookmarks(context.Background(), nil, GetBookmarksInput{})
+		Expect(err).To(MatchError(ContainSubstring("requires user context")))
+		Expect(err).To(MatchError(ContainSubstring("bearer token")))
+	})
+})
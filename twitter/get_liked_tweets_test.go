@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"sync"
+
+	"github.com/mudler/mcps/pkg/mock"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("get_liked_tweets", func() {
+	var (
+		mutex sync.Mutex
+		// path and maxResults are those of the last liked tweets request
+		path, maxResults string
+	)
+
+	BeforeEach(func() {
+		httpClient := mock.NewClient(
+			mock.Route{Method: http.MethodGet, Pattern: "/2/users/*/liked_tweets", Handler: func(req *http.Request) (int, interface{}) {
+				mutex.Lock()
+				defer mutex.Unlock()
+				path, maxResults = req.URL.Path, req.URL.Query().Get("max_results")
+				return http.StatusOK, mockTweetList(mockMentions)
+			}},
+		)
+
+		useMockClient(httpClient, defaultAPIHost)
+	})
+
+	lastRequest := func() (string, string) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		return path, maxResults
+	}
+
+	It("lists the tweets liked by the authenticated user by default", func() {
+		_, out, err := GetLikedTweets(context.Background(), nil, GetLikedTweetsInput{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(out.Count).To(Equal(2))
+		Expect(out.Tweets[0].ID).To(Equal("3000000000000000001"))
+		Expect(out.Tweets[0].Metrics).To(HaveKeyWithValue("like_count", 7))
+		requested, _ := lastRequest()
+		Expect(requested).To(Equal("/2/users/" + mockUserID + "/liked_tweets"))
+	})
+
+	It("lists the likes of another user", func() {
+		_, _, err := GetLikedTweets(context.Background(), nil, GetLikedTweetsInput{UserID: "1000000000000000002"})
+		Expect(err).NotTo(HaveOccurred())
+		requested, _ := lastRequest()
+		Expect(requested).To(Equal("/2/users/1000000000000000002/liked_tweets"))
+	})
+
+	It("asks for at least the smallest page the API accepts", func() {
+		_, _, err := GetLikedTweets(context.Background(), nil, GetLikedTweetsInput{MaxResults: 5})
+		Expect(err).NotTo(HaveOccurred())
+		_, requested := lastRequest()
+		Expect(requested).To(Equal("10"))
+	})
+
+	It("requires user_id without user context", func() {
+		hasUserCtx = false
+		_, _, err := GetLikedTweets(context.Background(), nil, GetLikedTweetsInput{})
+		Expect(err).To(MatchError(ContainSubstring("user_id required")))
+	})
+})
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"sync"
+
+	"github.com/mudler/mcps/pkg/mock"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("get_spaces", func() {
+	var (
+		mutex sync.Mutex
+		// userIDs is the user_ids of the last Spaces request
+		userIDs string
+		// spaces is what the Spaces endpoint answers
+		spaces []map[string]interface{}
+	)
+
+	BeforeEach(func() {
+		spaces = mockSpaces
+		httpClient := mock.NewClient(
+			mock.Route{Method: http.MethodGet, Pattern: "/2/spaces/by/creator_ids", Handler: func(req *http.Request) (int, interface{}) {
+				mutex.Lock()
+				defer mutex.Unlock()
+				userIDs = req.URL.Query().Get("user_ids")
+				if len(spaces) == 0 {
+					return http.StatusOK, map[string]interface{}{"meta": map[string]int{"result_count": 0}}
+				}
+				return http.StatusOK, map[string]interface{}{"data": spaces, "meta": map[string]int{"result_count": len(spaces)}}
+			}},
+		)
+
+		useMockClient(httpClient, defaultAPIHost)
+	})
+
+	lastUserIDs := func() string {
+		mutex.Lock()
+		defer mutex.Unlock()
+		return userIDs
+	}
+
+	It("lists the Spaces of the authenticated user by default", func() {
+		_, out, err := GetSpaces(context.Background(), nil, GetSpacesInput{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(lastUserIDs()).To(Equal(mockUserID))
+		Expect(out.UserID).To(Equal(mockUserID))
+		Expect(out.Count).To(Equal(2))
+		Expect(out.Spaces[0].Title).To(Equal("Building MCP servers"))
+		Expect(out.Spaces[0].State).To(Equal("live"))
+		Expect(out.Spaces[0].StartedAt).NotTo(BeEmpty())
+		Expect(out.Spaces[1].State).To(Equal("scheduled"))
+		Expect(out.Spaces[1].ScheduledStart).NotTo(BeEmpty())
+	})
+
+	It("keeps only the Spaces in the given state", func() {
+		_, out, err := GetSpaces(context.Background(), nil, GetSpacesInput{UserID: "1000000000000000002", State: "scheduled"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(lastUserIDs()).To(Equal("1000000000000000002"))
+		Expect(out.Count).To(Equal(1))
+		Expect(out.Spaces[0].Title).To(Equal("Office hours"))
+	})
+
+	It("returns an empty list for accounts without Spaces", func() {
+		mutex.Lock()
+		spaces = nil
+		mutex.Unlock()
+		_, out, err := GetSpaces(context.Background(), nil, GetSpacesInput{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(out.Count).To(BeZero())
+		Expect(out.Spaces).NotTo(BeNil())
+		Expect(out.Spaces).To(BeEmpty())
+	})
+
+	It("rejects an unknown state", func() {
+		_, _, err := GetSpaces(context.Background(), nil, GetSpacesInput{State: "ended"})
+		Expect(err).To(MatchError(ContainSubstring("state must be live or scheduled")))
+	})
+
+	It("requires user_id without user context", func() {
+		hasUserCtx = false
+		_, _, err := GetSpaces(context.Background(), nil, GetSpacesInput{})
+		Expect(err).To(MatchError(ContainSubstring("user_id required")))
+	})
+})
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("get_thread", func() {
+	var (
+		mutex    sync.Mutex
+		searches []string
+	)
+
+	BeforeEach(func() {
+		searches = nil
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			switch r.URL.Path {
+			case "/2/tweets/10":
+				w.Write([]byte(`{"data":{"id":"10","text":"1/ a thread","author_id":"1","conversation_id":"10","created_at":"2099-01-01T10:00:00.000Z"}}`))
+			case "/2/tweets/search/recent":
+				mutex.Lock()
+				searches = append(searches, r.URL.Query().Get("query"))
+				mutex.Unlock()
+				if r.URL.Query().Get("next_token") == "" {
+					// Newest first, like the API, with someone else's reply
+					// and a second answer of the author to the same tweet
+					w.Write([]byte(`{"data":[
+						{"id":"14","text":"an afterthought","author_id":"1","referenced_tweets":[{"type":"replied_to","id":"11"}]},
+						{"id":"13","text":"nice thread","author_id":"2","referenced_tweets":[{"type":"replied_to","id":"11"}]},
+						{"id":"12","text":"3/ and the end","author_id":"1","referenced_tweets":[{"type":"replied_to","id":"11"}]}
+					],"meta":{"result_count":3,"next_token":"page-2"}}`))
+					return
+				}
+				w.Write([]byte(`{"data":[
+					{"id":"11","text":"2/ goes on","author_id":"1","referenced_tweets":[{"type":"replied_to","id":"10"}]}
+				],"meta":{"result_count":1}}`))
+			default:
+				w.WriteHeader(http.StatusNotFound)
+				w.Write([]byte(`{"title":"Not Found Error","detail":"not found"}`))
+			}
+		}))
+
+		DeferCleanup(server.Close)
+		useMockClient(http.DefaultClient, server.URL)
+	})
+
+	ids := func(tweets []TweetOut) []string {
+		var list []string
+		for _, t := range tweets {
+			list = append(list, t.ID)
+		}
+		return list
+	}
+
+	It("follows the author's self-replies across search pages", func() {
+		_, out, err := GetThread(context.Background(), nil, GetThreadInput{TweetID: "10"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ids(out.Tweets)).To(Equal([]string{"10", "11", "12"}))
+		Expect(out.Count).To(Equal(3))
+		Expect(out.Truncated).To(BeFalse())
+		Expect(out.Note).To(BeEmpty())
+
+		mutex.Lock()
+		defer mutex.Unlock()
+		Expect(searches).To(HaveLen(2))
+		Expect(searches[0]).To(Equal("conversation_id:10 from:1"))
+	})
+
+	It("caps the thread at max_tweets", func() {
+		_, out, err := GetThread(context.Background(), nil, GetThreadInput{TweetID: "10", MaxTweets: 2})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ids(out.Tweets)).To(Equal([]string{"10", "11"}))
+		Expect(out.Truncated).To(BeTrue())
+		Expect(out.Note).To(ContainSubstring("max_tweets"))
+	})
+
+	It("requires a tweet_id", func() {
+		_, _, err := GetThread(context.Background(), nil, GetThreadInput{})
+		Expect(err).To(MatchError(ContainSubstring("tweet_id required")))
+	})
+})
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("get_tweet", func() {
+	BeforeEach(func() {
+		useMockClient(newMockHTTPClient(), defaultAPIHost)
+	})
+
+	It("returns a single tweet with its metrics", func() {
+		_, out, err := GetTweet(context.Background(), nil, GetTweetInput{TweetID: "2000000000000000003"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(out.Tweet.ID).To(Equal("2000000000000000003"))
+		Expect(out.Tweet.Metrics).To(HaveKeyWithValue("like_count", 210))
+		Expect(out.Referenced).To(BeEmpty())
+	})
+
+	It("includes the tweet it replies to when asked", func() {
+		_, out, err := GetTweet(context.Background(), nil, GetTweetInput{TweetID: "2000000000000000002", IncludeReplies: true})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(out.Referenced).To(HaveLen(1))
+		Expect(out.Referenced[0].ID).To(Equal("3000000000000000001"))
+	})
+
+	It("reports unknown tweets", func() {
+		_, _, err := GetTweet(context.Background(), nil, GetTweetInput{TweetID: "42"})
+		Expect(err).To(MatchError(ContainSubstring("42")))
+	})
+
+	It("requires a tweet_id", func() {
+		_, _, err := GetTweet(context.Background(), nil, GetTweetInput{})
+		Expect(err).To(MatchError(ContainSubstring("tweet_id required")))
+	})
+})
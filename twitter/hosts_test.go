@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+
+	twitter "github.com/g8rswimmer/go-twitter/v2"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("API hosts", func() {
+	var (
+		server *httptest.Server
+		mutex  sync.Mutex
+		paths  []string
+	)
+
+	BeforeEach(func() {
+		paths = nil
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			mutex.Lock()
+			paths = append(paths, r.URL.Path)
+			mutex.Unlock()
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`[]`))
+		}))
+
+		prevClient, prevV1Client, prevUserCtx, prevAuthMode := client, v1Client, hasUserCtx, authMode
+		prevAPIHost, prevUploadHost, prevTokenURL := apiHost, uploadHost, oauth2TokenURL
+		DeferCleanup(func() {
+			client, v1Client, hasUserCtx, authMode = prevClient, prevV1Client, prevUserCtx, prevAuthMode
+			apiHost, uploadHost, oauth2TokenURL = prevAPIHost, prevUploadHost, prevTokenURL
+			hostErr = nil
+			server.Close()
+		})
+
+		for _, name := range []string{"TWITTER_API_HOST", "TWITTER_UPLOAD_HOST", "TWITTER_MOCK", "TWITTER_API_KEY", "TWITTER_OAUTH2_ACCESS_TOKEN", "TWITTER_OAUTH2_TOKEN_PATH"} {
+			GinkgoT().Setenv(name, "")
+		}
+	})
+
+	requested := func() []string {
+		mutex.Lock()
+		defer mutex.Unlock()
+		return append([]string(nil), paths...)
+	}
+
+	It("defaults to the Twitter hosts", func() {
+		GinkgoT().Setenv("TWITTER_BEARER_TOKEN", "token")
+		Expect(InitClientFromEnv()).To(BeTrue())
+		Expect(apiHost).To(Equal("https://api.twitter.com"))
+		Expect(uploadHost).To(Equal("https://upload.twitter.com"))
+		Expect(oauth2TokenURL).To(Equal("https://api.twitter.com/2/oauth2/token"))
+	})
+
+	It("sends API and v1.1 requests to TWITTER_API_HOST", func() {
+		GinkgoT().Setenv("TWITTER_API_HOST", server.URL+"/")
+		GinkgoT().Setenv("TWITTER_BEARER_TOKEN", "token")
+		Expect(InitClientFromEnv()).To(BeTrue())
+		Expect(client.Host).To(Equal(server.URL))
+
+		client.UserNameLookup(context.Background(), []string{"TwitterDev"}, twitter.UserLookupOpts{})
+		v1Client = http.DefaultClient
+		GetTrends(context.Background(), nil, GetTrendsInput{WOEID: 1})
+
+		Expect(requested()).To(ContainElement(HavePrefix("/2/users/by")))
+		Expect(requested()).To(ContainElement("/1.1/trends/place.json"))
+	})
+
+	It("uploads media to TWITTER_UPLOAD_HOST", func() {
+		GinkgoT().Setenv("TWITTER_UPLOAD_HOST", server.URL)
+		GinkgoT().Setenv("TWITTER_BEARER_TOKEN", "token")
+		Expect(InitClientFromEnv()).To(BeTrue())
+
+		v1Client = http.DefaultClient
+		uploadMediaV1(context.Background(), []byte("image"), uploadedMedia{mediaType: "image/jpeg", extension: ".jpg", category: "tweet_image"})
+		Expect(requested()).To(ContainElement("/1.1/media/upload.json"))
+	})
+
+	It("rejects hosts that are not base URLs", func() {
+		GinkgoT().Setenv("TWITTER_API_HOST", "api.example.com")
+		GinkgoT().Setenv("TWITTER_BEARER_TOKEN", "token")
+		Expect(InitClientFromEnv()).To(BeFalse())
+		Expect(hostErr).To(MatchError(ContainSubstring("invalid TWITTER_API_HOST")))
+	})
+})
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("list management", func() {
+	BeforeEach(func() {
+		useMockClient(newMockHTTPClient(), defaultAPIHost)
+	})
+
+	It("creates a list and returns its ID", func() {
+		_, out, err := CreateList(context.Background(), nil, CreateListInput{Name: "golang", Description: "Go people", Private: true})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(out.ListID).To(Equal("6000000000000000001"))
+		Expect(out.Name).To(Equal("golang"))
+	})
+
+	It("requires a name", func() {
+		_, _, err := CreateList(context.Background(), nil, CreateListInput{Name: " "})
+		Expect(err).To(MatchError(ContainSubstring("name required")))
+	})
+
+	It("adds and removes members", func() {
+		_, out, err := AddListMember(context.Background(), nil, ListMemberInput{ListID: "6000000000000000001", UserID: "1000000000000000002"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(out.Success).To(BeTrue())
+		_, out, err = RemoveListMember(context.Background(), nil, ListMemberInput{ListID: "6000000000000000001", UserID: "1000000000000000002"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(out.Success).To(BeTrue())
+	})
+
+	It("explains that a bearer token cannot manage lists", func() {
+		hasUserCtx = false
+		_, _, err := CreateList(context.Background(), nil, CreateListInput{Name: "golang"})
+		Expect(err).To(MatchError(ContainSubstring("bearer token")))
+		_, _, err = AddListMember(context.Background(), nil, ListMemberInput{ListID: "1", UserID: "2"})
+		Expect(err).To(MatchError(ContainSubstring("bearer token")))
+		_, _, err = RemoveListMember(context.Background(), nil, ListMemberInput{ListID: "1", UserID: "2"})
+		Expect(err).To(MatchError(ContainSubstring("bearer token")))
+	})
+})
@@ -10,6 +10,7 @@ import (
 	"log"
 	"mime/multipart"
 	"net/http"
+	"net/url"
 	"os"
 	"strconv"
 	"strings"
@@ -18,11 +19,45 @@ import (
 	"github.com/dghubble/oauth1"
 	twitter "github.com/g8rswimmer/go-twitter/v2"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/mudler/mcps/pkg/mock"
+	"github.com/mudler/mcps/pkg/selfcheck"
+	"github.com/mudler/mcps/pkg/transport"
 )
 
 const (
 	defaultMaxTweets = 50
-	unansweredWindow = 24 * time.Hour
+
+	// defaultUnansweredHours and maxUnansweredHours bound how far back
+	// get_unanswered_mentions looks
+	defaultUnansweredHours = 24
+	maxUnansweredHours     = 168
+
+	// maxTweetMedia is the number of images a tweet can carry
+	maxTweetMedia = 4
+
+	// minPollOptions, maxPollOptions and maxPollOptionLength bound the
+	// choices of a poll, minPollMinutes and maxPollMinutes how long it is open
+	minPollOptions      = 2
+	maxPollOptions      = 4
+	maxPollOptionLength = 25
+	minPollMinutes      = 5
+	maxPollMinutes      = 10080
+
+	// maxUserSearchResults is the page size limit of the v1.1 user search
+	maxUserSearchResults = 20
+
+	// minLikedTweets is the smallest page of liked tweets the API returns
+	minLikedTweets = 10
+
+	defaultThreadTweets = 25
+	maxThreadTweets     = 100
+	// maxThreadPages bounds the search pages read to assemble a thread
+	maxThreadPages = 5
+	// recentSearchWindow is how far back the recent search endpoint goes
+	recentSearchWindow = 7 * 24 * time.Hour
+
+	defaultAPIHost    = "https://api.twitter.com"
+	defaultUploadHost = "https://upload.twitter.com"
 )
 
 var debugLogging bool
@@ -54,8 +89,30 @@ var (
 	maxTweets  int
 	hasUserCtx bool
 	v1Client   *http.Client
+	// authMode describes the credentials in use
+	authMode string
+	// apiHost and uploadHost are the base URLs of the API and of the v1.1
+	// media upload, overridden with TWITTER_API_HOST and TWITTER_UPLOAD_HOST
+	apiHost    = defaultAPIHost
+	uploadHost = defaultUploadHost
+	// hostErr is set by InitClientFromEnv when a host setting is invalid
+	hostErr error
 )
 
+// hostFromEnv reads a base URL such as https://api.twitter.com from the env
+// var name, falling back to def when unset
+func hostFromEnv(name, def string) (string, error) {
+	value := strings.TrimRight(strings.TrimSpace(os.Getenv(name)), "/")
+	if value == "" {
+		return def, nil
+	}
+	u, err := url.Parse(value)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "", fmt.Errorf("invalid %s %q: expected a base URL such as %s", name, value, def)
+	}
+	return value, nil
+}
+
 // bearerAuthorizer adds Bearer token to requests
 type bearerAuthorizer struct {
 	token string
@@ -76,6 +133,39 @@ type GetTweetsInput struct {
 	UserID     string `json:"user_id" jsonschema:"Twitter user ID (numeric string)"`
 	Username   string `json:"username,omitempty" jsonschema:"Twitter username (handle) - used if user_id not set"`
 	MaxResults int    `json:"max_results,omitempty" jsonschema:"max tweets to return (default 50, cap 50)"`
+	NextToken  string `json:"next_token,omitempty" jsonschema:"next_token of a previous call, to get the following page"`
+}
+
+type GetTweetInput struct {
+	TweetID        string `json:"tweet_id" jsonschema:"ID of the tweet to fetch"`
+	IncludeReplies bool   `json:"include_replies,omitempty" jsonschema:"also return the tweets it replies to, quotes or retweets"`
+}
+
+type GetBookmarksInput struct {
+	MaxResults int    `json:"max_results,omitempty" jsonschema:"max tweets to return (default 50, cap 50)"`
+	NextToken  string `json:"next_token,omitempty" jsonschema:"next_token of a previous call, to get the following page"`
+}
+
+type GetLikedTweetsInput struct {
+	UserID     string `json:"user_id,omitempty" jsonschema:"ID of the user whose likes to list (default: the authenticated user)"`
+	MaxResults int    `json:"max_results,omitempty" jsonschema:"max tweets to return (default 50, min 10, cap 50)"`
+	NextToken  string `json:"next_token,omitempty" jsonschema:"next_token of a previous call, to get the following page"`
+}
+
+type GetThreadInput struct {
+	TweetID   string `json:"tweet_id" jsonschema:"ID of the first tweet of the thread"`
+	MaxTweets int    `json:"max_tweets,omitempty" jsonschema:"max tweets to return, the first one included (default 25, cap 100)"`
+}
+
+type GetUnansweredMentionsInput struct {
+	Hours      int `json:"hours,omitempty" jsonschema:"how many hours back to look for mentions (default 24, max 168)"`
+	MaxResults int `json:"max_results,omitempty" jsonschema:"max mentions to return (default 50, cap 50)"`
+}
+
+type GetEngagementSummaryInput struct {
+	UserID     string `json:"user_id,omitempty" jsonschema:"Twitter user ID (numeric string)"`
+	Username   string `json:"username,omitempty" jsonschema:"Twitter username (handle) - used if user_id not set"`
+	MaxResults int    `json:"max_results,omitempty" jsonschema:"number of recent tweets to analyze (default 50, cap 50)"`
 }
 
 type GetProfileInput struct {
@@ -87,6 +177,7 @@ type SearchTweetsInput struct {
 	Query      string `json:"query" jsonschema:"search query (keywords, hashtags)"`
 	SortOrder  string `json:"sort_order,omitempty" jsonschema:"recency or relevancy"`
 	MaxResults int    `json:"max_results,omitempty" jsonschema:"max tweets (default 50, cap 50)"`
+	NextToken  string `json:"next_token,omitempty" jsonschema:"next_token of a previous call, to get the following page"`
 }
 
 type LikeTweetInput struct {
@@ -104,10 +195,17 @@ type PostTweetInput struct {
 	MediaIDs         []string `json:"media_ids,omitempty" jsonschema:"media IDs from upload_media"`
 	InReplyToTweetID string   `json:"in_reply_to_tweet_id,omitempty" jsonschema:"tweet ID to reply to"`
 	QuoteTweetID     string   `json:"quote_tweet_id,omitempty" jsonschema:"tweet ID to quote"`
+	Poll             *PollIn  `json:"poll,omitempty" jsonschema:"poll to attach, cannot be combined with media_ids or quote_tweet_id"`
+}
+
+type PollIn struct {
+	Options         []string `json:"options" jsonschema:"2 to 4 choices of up to 25 characters"`
+	DurationMinutes int      `json:"duration_minutes" jsonschema:"how long the poll is open, 5 to 10080 minutes (7 days)"`
 }
 
 type CreateThreadInput struct {
-	Tweets []string `json:"tweets" jsonschema:"array of tweet texts in order"`
+	Tweets         []string `json:"tweets" jsonschema:"array of tweet texts in order"`
+	ReplyToTweetID string   `json:"reply_to_tweet_id,omitempty" jsonschema:"existing tweet ID the first tweet replies to, to continue a thread under it"`
 }
 
 type GetTimelineInput struct {
@@ -121,6 +219,23 @@ type GetListTweetsInput struct {
 	MaxResults int    `json:"max_results,omitempty" jsonschema:"max tweets (default 50, cap 50)"`
 }
 
+type CreateListInput struct {
+	Name        string `json:"name" jsonschema:"name of the list (max 25 characters)"`
+	Description string `json:"description,omitempty" jsonschema:"description of the list (max 100 characters)"`
+	Private     bool   `json:"private,omitempty" jsonschema:"only visible to the owner"`
+}
+
+type ListMemberInput struct {
+	ListID string `json:"list_id" jsonschema:"ID of a list owned by the authenticated user"`
+	UserID string `json:"user_id" jsonschema:"ID of the user to add or remove"`
+}
+
+type SearchUsersInput struct {
+	Query      string `json:"query" jsonschema:"keywords to search in names, handles and bios"`
+	MaxResults int    `json:"max_results,omitempty" jsonschema:"max users to return (default 20, cap 20)"`
+	Page       int    `json:"page,omitempty" jsonschema:"page of results, starting at 1 (default 1)"`
+}
+
 type GetTrendsInput struct {
 	WOEID int `json:"woeid" jsonschema:"Where On Earth ID (e.g. 1=worldwide, 23424977=US)"`
 }
@@ -136,19 +251,41 @@ type FollowUserInput struct {
 	Follow       bool   `json:"follow" jsonschema:"true to follow, false to unfollow"`
 }
 
+type BlockUserInput struct {
+	TargetUserID string `json:"target_user_id" jsonschema:"user ID to block/unblock"`
+	Block        bool   `json:"block" jsonschema:"true to block, false to unblock"`
+}
+
+type MuteUserInput struct {
+	TargetUserID string `json:"target_user_id" jsonschema:"user ID to mute/unmute"`
+	Mute         bool   `json:"mute" jsonschema:"true to mute, false to unmute"`
+}
+
 type UploadMediaInput struct {
 	ImageBase64 string `json:"image_base64,omitempty" jsonschema:"base64-encoded image data"`
 	ImageURL    string `json:"image_url,omitempty" jsonschema:"URL of image to upload"`
 }
 
+type UploadMediaBatchInput struct {
+	Images []UploadMediaInput `json:"images" jsonschema:"the images to upload, up to 4, each with image_base64 or image_url"`
+}
+
 // Simplified output types (JSON-friendly)
 type TweetOut struct {
-	ID        string         `json:"id"`
-	Text      string         `json:"text"`
-	AuthorID  string         `json:"author_id,omitempty"`
-	CreatedAt string         `json:"created_at,omitempty"`
-	Metrics   map[string]int `json:"public_metrics,omitempty"`
-	MediaURLs []string       `json:"media_urls,omitempty"`
+	ID             string               `json:"id"`
+	Text           string               `json:"text"`
+	AuthorID       string               `json:"author_id,omitempty"`
+	AuthorUsername string               `json:"author_username,omitempty" jsonschema:"username of the author, when the response includes it"`
+	CreatedAt      string               `json:"created_at,omitempty"`
+	Metrics        map[string]int       `json:"public_metrics,omitempty"`
+	MediaURLs      []string             `json:"media_urls,omitempty"`
+	Referenced     []ReferencedTweetOut `json:"referenced,omitempty" jsonschema:"tweets this one replies to, quotes or retweets"`
+}
+
+type ReferencedTweetOut struct {
+	Type string `json:"type" jsonschema:"replied_to, quoted or retweeted"`
+	ID   string `json:"id"`
+	Text string `json:"text,omitempty" jsonschema:"text of the referenced tweet, when it could be fetched"`
 }
 
 type UserOut struct {
@@ -161,8 +298,48 @@ type UserOut struct {
 }
 
 type GetTweetsOutput struct {
-	Tweets []TweetOut `json:"tweets"`
-	Count  int        `json:"count"`
+	Tweets    []TweetOut `json:"tweets"`
+	Count     int        `json:"count"`
+	NextToken string     `json:"next_token,omitempty" jsonschema:"pass as next_token to get the following page, empty on the last page"`
+}
+
+type GetTweetOutput struct {
+	Tweet      TweetOut   `json:"tweet"`
+	Referenced []TweetOut `json:"referenced_tweets,omitempty" jsonschema:"tweets the tweet replies to, quotes or retweets, with include_replies"`
+}
+
+type GetBookmarksOutput struct {
+	Tweets    []TweetOut `json:"tweets"`
+	Count     int        `json:"count"`
+	NextToken string     `json:"next_token,omitempty" jsonschema:"pass as next_token to get the following page, empty on the last page"`
+}
+
+type GetLikedTweetsOutput struct {
+	Tweets    []TweetOut `json:"tweets"`
+	Count     int        `json:"count"`
+	NextToken string     `json:"next_token,omitempty" jsonschema:"pass as next_token to get the following page, empty on the last page"`
+}
+
+type GetThreadOutput struct {
+	Tweets    []TweetOut `json:"tweets" jsonschema:"the tweets of the thread, in reading order"`
+	Count     int        `json:"count"`
+	Truncated bool       `json:"truncated" jsonschema:"whether the thread goes on past the returned tweets"`
+	Note      string     `json:"note,omitempty" jsonschema:"why the thread may be incomplete"`
+}
+
+type GetEngagementSummaryOutput struct {
+	TweetCount          int       `json:"tweet_count"`
+	TotalLikes          int       `json:"total_likes"`
+	TotalRetweets       int       `json:"total_retweets"`
+	TotalReplies        int       `json:"total_replies"`
+	TotalQuotes         int       `json:"total_quotes"`
+	TotalImpressions    int       `json:"total_impressions"`
+	AverageLikes        float64   `json:"average_likes"`
+	AverageRetweets     float64   `json:"average_retweets"`
+	AverageReplies      float64   `json:"average_replies"`
+	AverageQuotes       float64   `json:"average_quotes"`
+	BestTweet           *TweetOut `json:"best_tweet,omitempty"`
+	BestTweetEngagement int       `json:"best_tweet_engagement,omitempty"`
 }
 
 type GetProfileOutput struct {
@@ -170,8 +347,9 @@ type GetProfileOutput struct {
 }
 
 type SearchTweetsOutput struct {
-	Tweets []TweetOut `json:"tweets"`
-	Count  int        `json:"count"`
+	Tweets    []TweetOut `json:"tweets"`
+	Count     int        `json:"count"`
+	NextToken string     `json:"next_token,omitempty" jsonschema:"pass as next_token to get the following page, empty on the last page"`
 }
 
 type GetTimelineOutput struct {
@@ -190,6 +368,12 @@ type TrendOut struct {
 	TweetVolume int    `json:"tweet_volume,omitempty"`
 }
 
+type SearchUsersOutput struct {
+	Users []UserOut `json:"users"`
+	Count int       `json:"count"`
+	Page  int       `json:"page" jsonschema:"the page returned, pass page+1 to get the following one"`
+}
+
 type GetTrendsOutput struct {
 	Trends []TrendOut `json:"trends"`
 	Count  int        `json:"count"`
@@ -205,20 +389,31 @@ type ActionOutput struct {
 	Message string `json:"message"`
 }
 
+type CreateListOutput struct {
+	ListID string `json:"list_id" jsonschema:"ID of the new list, for get_list_tweets and add_list_member"`
+	Name   string `json:"name"`
+}
+
 type PostTweetOutput struct {
 	TweetID string `json:"tweet_id"`
 	Text    string `json:"text"`
 }
 
 type CreateThreadOutput struct {
-	TweetIDs []string `json:"tweet_ids"`
-	Count    int      `json:"count"`
+	TweetIDs []string `json:"tweet_ids" jsonschema:"IDs of the posted tweets in order"`
+	Count    int      `json:"count" jsonschema:"number of posted tweets"`
+	Error    string   `json:"error,omitempty" jsonschema:"why posting stopped before the last tweet, the thread being partial"`
 }
 
 type UploadMediaOutput struct {
 	MediaID string `json:"media_id"`
 }
 
+type UploadMediaBatchOutput struct {
+	MediaIDs []string `json:"media_ids" jsonschema:"media IDs in the order of the images, to pass to post_tweet"`
+	Count    int      `json:"count"`
+}
+
 func capMax(n, cap int) int {
 	if n <= 0 {
 		return cap
@@ -236,6 +431,11 @@ func tweetFromObj(t *twitter.TweetObj, includes *twitter.TweetRawIncludes) Tweet
 		AuthorID:  t.AuthorID,
 		CreatedAt: t.CreatedAt,
 	}
+	if includes != nil {
+		if author := includes.UsersByID()[t.AuthorID]; author != nil {
+			out.AuthorUsername = author.UserName
+		}
+	}
 	if t.PublicMetrics != nil {
 		out.Metrics = map[string]int{
 			"like_count":       t.PublicMetrics.Likes,
@@ -252,9 +452,33 @@ func tweetFromObj(t *twitter.TweetObj, includes *twitter.TweetRawIncludes) Tweet
 			}
 		}
 	}
+	for _, ref := range t.ReferencedTweets {
+		if ref == nil {
+			continue
+		}
+		referenced := ReferencedTweetOut{Type: ref.Type, ID: ref.ID}
+		if included := includedTweet(includes, ref.ID); included != nil {
+			referenced.Text = included.Text
+		}
+		out.Referenced = append(out.Referenced, referenced)
+	}
 	return out
 }
 
+// includedTweet returns the tweet with the given ID from the includes of a
+// response, requested with the referenced_tweets.id expansion, or nil
+func includedTweet(includes *twitter.TweetRawIncludes, id string) *twitter.TweetObj {
+	if includes == nil {
+		return nil
+	}
+	for _, t := range includes.Tweets {
+		if t != nil && t.ID == id {
+			return t
+		}
+	}
+	return nil
+}
+
 func userFromObj(u *twitter.UserObj) UserOut {
 	if u == nil {
 		return UserOut{}
@@ -291,34 +515,223 @@ func errMsg(err error) string {
 
 // --- Handlers ---
 
-func GetTweets(ctx context.Context, req *mcp.CallToolRequest, input GetTweetsInput) (*mcp.CallToolResult, GetTweetsOutput, error) {
-	userID := input.UserID
-	if userID == "" && input.Username != "" {
-		resp, err := client.UserNameLookup(ctx, []string{input.Username}, twitter.UserLookupOpts{})
+// fetchUserTweets returns the most recent tweets of a user, resolving the
+// username to an ID when userID is empty. token selects a page, the token
+// of the following one is returned.
+func fetchUserTweets(ctx context.Context, userID, username string, maxResults int, token string) ([]TweetOut, string, error) {
+	if userID == "" && username != "" {
+		resp, err := client.UserNameLookup(ctx, []string{username}, twitter.UserLookupOpts{})
 		if err != nil {
-			return nil, GetTweetsOutput{}, fmt.Errorf("user lookup: %w", err)
+			return nil, "", fmt.Errorf("user lookup: %w", err)
 		}
 		if resp.Raw == nil || len(resp.Raw.Users) == 0 || resp.Raw.Users[0] == nil {
-			return nil, GetTweetsOutput{}, fmt.Errorf("user not found: %s", input.Username)
+			return nil, "", fmt.Errorf("user not found: %s", username)
 		}
 		userID = resp.Raw.Users[0].ID
 	}
 	if userID == "" {
-		return nil, GetTweetsOutput{}, fmt.Errorf("user_id or username required")
+		return nil, "", fmt.Errorf("user_id or username required")
 	}
-	n := capMax(input.MaxResults, maxTweets)
+	n := capMax(maxResults, maxTweets)
 	if n == 0 {
 		n = maxTweets
 	}
 	opts := twitter.UserTweetTimelineOpts{
-		MaxResults:  n,
+		MaxResults:      n,
+		TweetFields:     []twitter.TweetField{twitter.TweetFieldCreatedAt, twitter.TweetFieldAuthorID, twitter.TweetFieldAttachments, twitter.TweetFieldPublicMetrics, twitter.TweetFieldReferencedTweets},
+		Expansions:      []twitter.Expansion{twitter.ExpansionAttachmentsMediaKeys, twitter.ExpansionReferencedTweetsID},
+		MediaFields:     []twitter.MediaField{twitter.MediaFieldURL},
+		PaginationToken: token,
+	}
+	resp, err := client.UserTweetTimeline(ctx, userID, opts)
+	if err != nil {
+		return nil, "", fmt.Errorf("timeline: %w", err)
+	}
+	var tweets []TweetOut
+	if resp.Raw != nil {
+		for _, t := range resp.Raw.Tweets {
+			tweets = append(tweets, tweetFromObj(t, resp.Raw.Includes))
+		}
+	}
+	next := ""
+	if resp.Meta != nil {
+		next = resp.Meta.NextToken
+	}
+	return tweets, next, nil
+}
+
+func GetTweets(ctx context.Context, req *mcp.CallToolRequest, input GetTweetsInput) (*mcp.CallToolResult, GetTweetsOutput, error) {
+	tweets, next, err := fetchUserTweets(ctx, input.UserID, input.Username, input.MaxResults, input.NextToken)
+	if err != nil {
+		return nil, GetTweetsOutput{}, err
+	}
+	return nil, GetTweetsOutput{Tweets: tweets, Count: len(tweets), NextToken: next}, nil
+}
+
+// GetTweet looks up a single tweet with its metrics and media
+func GetTweet(ctx context.Context, req *mcp.CallToolRequest, input GetTweetInput) (*mcp.CallToolResult, GetTweetOutput, error) {
+	if input.TweetID == "" {
+		return nil, GetTweetOutput{}, fmt.Errorf("tweet_id required")
+	}
+	opts := twitter.TweetLookupOpts{
 		TweetFields: []twitter.TweetField{twitter.TweetFieldCreatedAt, twitter.TweetFieldAuthorID, twitter.TweetFieldAttachments, twitter.TweetFieldPublicMetrics},
 		Expansions:  []twitter.Expansion{twitter.ExpansionAttachmentsMediaKeys},
 		MediaFields: []twitter.MediaField{twitter.MediaFieldURL},
 	}
-	resp, err := client.UserTweetTimeline(ctx, userID, opts)
+	if input.IncludeReplies {
+		opts.Expansions = append(opts.Expansions, twitter.ExpansionReferencedTweetsID)
+	}
+	resp, err := client.TweetLookup(ctx, []string{input.TweetID}, opts)
+	if err != nil {
+		return nil, GetTweetOutput{}, fmt.Errorf("tweet lookup: %w", err)
+	}
+	// Unknown or deleted tweets come back as partial errors without data
+	if resp.Raw == nil || len(resp.Raw.Tweets) == 0 || resp.Raw.Tweets[0] == nil {
+		return nil, GetTweetOutput{}, fmt.Errorf("tweet not found: %s", input.TweetID)
+	}
+	out := GetTweetOutput{Tweet: tweetFromObj(resp.Raw.Tweets[0], resp.Raw.Includes)}
+	if input.IncludeReplies && resp.Raw.Includes != nil {
+		for _, t := range resp.Raw.Includes.Tweets {
+			if t != nil {
+				out.Referenced = append(out.Referenced, tweetFromObj(t, resp.Raw.Includes))
+			}
+		}
+	}
+	return nil, out, nil
+}
+
+// GetThread assembles a thread from its first tweet: the chain of replies
+// the author made to their own tweets. Replies are found with a recent
+// search on the conversation, so they must be at most 7 days old.
+func GetThread(ctx context.Context, req *mcp.CallToolRequest, input GetThreadInput) (*mcp.CallToolResult, GetThreadOutput, error) {
+	if input.TweetID == "" {
+		return nil, GetThreadOutput{}, fmt.Errorf("tweet_id required")
+	}
+	limit := capMax(input.MaxTweets, maxThreadTweets)
+	if limit == 0 {
+		limit = defaultThreadTweets
+	}
+	tweetFields := []twitter.TweetField{twitter.TweetFieldCreatedAt, twitter.TweetFieldAuthorID, twitter.TweetFieldAttachments, twitter.TweetFieldPublicMetrics, twitter.TweetFieldConversationID, twitter.TweetFieldReferencedTweets, twitter.TweetFieldInReplyToUserID}
+	resp, err := client.TweetLookup(ctx, []string{input.TweetID}, twitter.TweetLookupOpts{
+		TweetFields: tweetFields,
+		Expansions:  []twitter.Expansion{twitter.ExpansionAttachmentsMediaKeys},
+		MediaFields: []twitter.MediaField{twitter.MediaFieldURL},
+	})
+	if err != nil {
+		return nil, GetThreadOutput{}, fmt.Errorf("tweet lookup: %w", err)
+	}
+	if resp.Raw == nil || len(resp.Raw.Tweets) == 0 || resp.Raw.Tweets[0] == nil {
+		return nil, GetThreadOutput{}, fmt.Errorf("tweet not found: %s", input.TweetID)
+	}
+	first := resp.Raw.Tweets[0]
+	out := GetThreadOutput{Tweets: []TweetOut{tweetFromObj(first, resp.Raw.Includes)}}
+	conversation := first.ConversationID
+	if conversation == "" {
+		conversation = first.ID
+	}
+
+	// Self-replies keyed by the tweet they reply to
+	replies := map[string][]TweetOut{}
+	complete := true
+	opts := twitter.TweetRecentSearchOpts{
+		MaxResults:  100,
+		TweetFields: tweetFields,
+		Expansions:  []twitter.Expansion{twitter.ExpansionAttachmentsMediaKeys},
+		MediaFields: []twitter.MediaField{twitter.MediaFieldURL},
+	}
+	query := fmt.Sprintf("conversation_id:%s from:%s", conversation, first.AuthorID)
+	for page := 0; ; page++ {
+		if page == maxThreadPages {
+			complete = false
+			break
+		}
+		search, err := client.TweetRecentSearch(ctx, query, opts)
+		if err != nil {
+			return nil, GetThreadOutput{}, fmt.Errorf("search: %w", err)
+		}
+		if search.Raw != nil {
+			for _, t := range search.Raw.Tweets {
+				// Only the author's answers to themselves belong to the thread
+				if t == nil || t.AuthorID != first.AuthorID || (t.InReplyToUserID != "" && t.InReplyToUserID != first.AuthorID) {
+					continue
+				}
+				for _, ref := range t.ReferencedTweets {
+					if ref != nil && ref.Type == "replied_to" {
+						replies[ref.ID] = append(replies[ref.ID], tweetFromObj(t, search.Raw.Includes))
+					}
+				}
+			}
+		}
+		if search.Meta == nil || search.Meta.NextToken == "" {
+			break
+		}
+		opts.NextToken = search.Meta.NextToken
+	}
+
+	// Follow the chain from the first tweet, taking the earliest reply when
+	// the author answered a tweet more than once
+	current := first.ID
+	for {
+		next, ok := earliestTweet(replies[current])
+		if !ok {
+			break
+		}
+		if len(out.Tweets) == limit {
+			out.Truncated = true
+			out.Note = fmt.Sprintf("thread cut at %d tweets, raise max_tweets to get more", limit)
+			break
+		}
+		out.Tweets = append(out.Tweets, next)
+		current = next.ID
+	}
+	out.Count = len(out.Tweets)
+
+	if !out.Truncated {
+		if !complete {
+			out.Truncated = true
+			out.Note = "conversation too long to search entirely, later tweets may be missing"
+		} else if created, err := time.Parse(time.RFC3339, first.CreatedAt); err == nil && time.Since(created) > recentSearchWindow {
+			out.Note = "replies older than 7 days are not searchable, later tweets may be missing"
+		}
+	}
+	return nil, out, nil
+}
+
+// earliestTweet returns the tweet with the lowest ID, IDs growing with time
+func earliestTweet(tweets []TweetOut) (TweetOut, bool) {
+	if len(tweets) == 0 {
+		return TweetOut{}, false
+	}
+	earliest := tweets[0]
+	for _, t := range tweets[1:] {
+		if len(t.ID) < len(earliest.ID) || (len(t.ID) == len(earliest.ID) && t.ID < earliest.ID) {
+			earliest = t
+		}
+	}
+	return earliest, true
+}
+
+// GetBookmarks lists the tweets bookmarked by the authenticated user
+func GetBookmarks(ctx context.Context, req *mcp.CallToolRequest, input GetBookmarksInput) (*mcp.CallToolResult, GetBookmarksOutput, error) {
+	if !hasUserCtx {
+		return nil, GetBookmarksOutput{}, fmt.Errorf("get_bookmarks requires user context (OAuth 1.0a or OAuth 2.0), bookmarks are private and a bearer token cannot read them")
+	}
+	if authUserID == "" {
+		return nil, GetBookmarksOutput{}, fmt.Errorf("auth user ID not resolved (rate limited or lookup failed); try again later")
+	}
+	n := capMax(input.MaxResults, maxTweets)
+	if n == 0 {
+		n = maxTweets
+	}
+	resp, err := client.TweetBookmarksLookup(ctx, authUserID, twitter.TweetBookmarksLookupOpts{
+		MaxResults:      n,
+		TweetFields:     []twitter.TweetField{twitter.TweetFieldCreatedAt, twitter.TweetFieldAuthorID, twitter.TweetFieldAttachments, twitter.TweetFieldPublicMetrics},
+		Expansions:      []twitter.Expansion{twitter.ExpansionAttachmentsMediaKeys},
+		MediaFields:     []twitter.MediaField{twitter.MediaFieldURL},
+		PaginationToken: input.NextToken,
+	})
 	if err != nil {
-		return nil, GetTweetsOutput{}, fmt.Errorf("timeline: %w", err)
+		return nil, GetBookmarksOutput{}, fmt.Errorf("bookmarks: %w", err)
 	}
 	var tweets []TweetOut
 	if resp.Raw != nil {
@@ -326,7 +739,95 @@ func GetTweets(ctx context.Context, req *mcp.CallToolRequest, input GetTweetsInp
 			tweets = append(tweets, tweetFromObj(t, resp.Raw.Includes))
 		}
 	}
-	return nil, GetTweetsOutput{Tweets: tweets, Count: len(tweets)}, nil
+	next := ""
+	if resp.Meta != nil {
+		next = resp.Meta.NextToken
+	}
+	return nil, GetBookmarksOutput{Tweets: tweets, Count: len(tweets), NextToken: next}, nil
+}
+
+// GetLikedTweets lists the tweets a user liked, the authenticated user by
+// default
+func GetLikedTweets(ctx context.Context, req *mcp.CallToolRequest, input GetLikedTweetsInput) (*mcp.CallToolResult, GetLikedTweetsOutput, error) {
+	userID := input.UserID
+	if userID == "" {
+		if !hasUserCtx {
+			return nil, GetLikedTweetsOutput{}, fmt.Errorf("user_id required (no user context to default to the authenticated user)")
+		}
+		if authUserID == "" {
+			return nil, GetLikedTweetsOutput{}, fmt.Errorf("auth user ID not resolved (rate limited or lookup failed); try again later or pass user_id")
+		}
+		userID = authUserID
+	}
+	n := capMax(input.MaxResults, maxTweets)
+	if n == 0 {
+		n = maxTweets
+	}
+	// The API refuses pages of fewer than 10 likes
+	n = max(n, minLikedTweets)
+	resp, err := client.UserLikesLookup(ctx, userID, twitter.UserLikesLookupOpts{
+		MaxResults:      n,
+		TweetFields:     []twitter.TweetField{twitter.TweetFieldCreatedAt, twitter.TweetFieldAuthorID, twitter.TweetFieldAttachments, twitter.TweetFieldPublicMetrics},
+		Expansions:      []twitter.Expansion{twitter.ExpansionAttachmentsMediaKeys},
+		MediaFields:     []twitter.MediaField{twitter.MediaFieldURL},
+		PaginationToken: input.NextToken,
+	})
+	if err != nil {
+		return nil, GetLikedTweetsOutput{}, fmt.Errorf("liked tweets: %w", err)
+	}
+	var tweets []TweetOut
+	if resp.Raw != nil {
+		for _, t := range resp.Raw.Tweets {
+			tweets = append(tweets, tweetFromObj(t, resp.Raw.Includes))
+		}
+	}
+	next := ""
+	if resp.Meta != nil {
+		next = resp.Meta.NextToken
+	}
+	return nil, GetLikedTweetsOutput{Tweets: tweets, Count: len(tweets), NextToken: next}, nil
+}
+
+// summarizeEngagement aggregates the public metrics of the given tweets. The
+// best tweet is the one with the highest likes + retweets + replies + quotes.
+func summarizeEngagement(tweets []TweetOut) GetEngagementSummaryOutput {
+	var out GetEngagementSummaryOutput
+	best := -1
+	for i, t := range tweets {
+		if t.Metrics == nil {
+			continue
+		}
+		out.TweetCount++
+		out.TotalLikes += t.Metrics["like_count"]
+		out.TotalRetweets += t.Metrics["retweet_count"]
+		out.TotalReplies += t.Metrics["reply_count"]
+		out.TotalQuotes += t.Metrics["quote_count"]
+		out.TotalImpressions += t.Metrics["impression_count"]
+		engagement := t.Metrics["like_count"] + t.Metrics["retweet_count"] + t.Metrics["reply_count"] + t.Metrics["quote_count"]
+		if best < 0 || engagement > out.BestTweetEngagement {
+			best = i
+			out.BestTweetEngagement = engagement
+		}
+	}
+	if out.TweetCount > 0 {
+		n := float64(out.TweetCount)
+		out.AverageLikes = float64(out.TotalLikes) / n
+		out.AverageRetweets = float64(out.TotalRetweets) / n
+		out.AverageReplies = float64(out.TotalReplies) / n
+		out.AverageQuotes = float64(out.TotalQuotes) / n
+	}
+	if best >= 0 {
+		out.BestTweet = &tweets[best]
+	}
+	return out
+}
+
+func GetEngagementSummary(ctx context.Context, req *mcp.CallToolRequest, input GetEngagementSummaryInput) (*mcp.CallToolResult, GetEngagementSummaryOutput, error) {
+	tweets, _, err := fetchUserTweets(ctx, input.UserID, input.Username, input.MaxResults, "")
+	if err != nil {
+		return nil, GetEngagementSummaryOutput{}, err
+	}
+	return nil, summarizeEngagement(tweets), nil
 }
 
 func GetProfile(ctx context.Context, req *mcp.CallToolRequest, input GetProfileInput) (*mcp.CallToolResult, GetProfileOutput, error) {
@@ -368,9 +869,10 @@ func SearchTweets(ctx context.Context, req *mcp.CallToolRequest, input SearchTwe
 	}
 	opts := twitter.TweetRecentSearchOpts{
 		MaxResults:  n,
-		TweetFields: []twitter.TweetField{twitter.TweetFieldCreatedAt, twitter.TweetFieldAuthorID, twitter.TweetFieldPublicMetrics},
-		Expansions:  []twitter.Expansion{twitter.ExpansionAuthorID},
+		TweetFields: []twitter.TweetField{twitter.TweetFieldCreatedAt, twitter.TweetFieldAuthorID, twitter.TweetFieldPublicMetrics, twitter.TweetFieldReferencedTweets},
+		Expansions:  []twitter.Expansion{twitter.ExpansionAuthorID, twitter.ExpansionReferencedTweetsID},
 		UserFields:  []twitter.UserField{twitter.UserFieldUserName},
+		NextToken:   input.NextToken,
 	}
 	if input.SortOrder == "recency" {
 		opts.SortOrder = twitter.TweetSearchSortOrderRecency
@@ -387,12 +889,16 @@ func SearchTweets(ctx context.Context, req *mcp.CallToolRequest, input SearchTwe
 			tweets = append(tweets, tweetFromObj(t, resp.Raw.Includes))
 		}
 	}
-	return nil, SearchTweetsOutput{Tweets: tweets, Count: len(tweets)}, nil
+	next := ""
+	if resp.Meta != nil {
+		next = resp.Meta.NextToken
+	}
+	return nil, SearchTweetsOutput{Tweets: tweets, Count: len(tweets), NextToken: next}, nil
 }
 
 func LikeTweet(ctx context.Context, req *mcp.CallToolRequest, input LikeTweetInput) (*mcp.CallToolResult, ActionOutput, error) {
 	if !hasUserCtx {
-		return nil, ActionOutput{}, fmt.Errorf("like_tweet requires user context (OAuth 1.0a)")
+		return nil, ActionOutput{}, fmt.Errorf("like_tweet requires user context (OAuth 1.0a or OAuth 2.0)")
 	}
 	if input.Like {
 		_, err := client.UserLikes(ctx, authUserID, input.TweetID)
@@ -410,7 +916,7 @@ func LikeTweet(ctx context.Context, req *mcp.CallToolRequest, input LikeTweetInp
 
 func Retweet(ctx context.Context, req *mcp.CallToolRequest, input RetweetInput) (*mcp.CallToolResult, ActionOutput, error) {
 	if !hasUserCtx {
-		return nil, ActionOutput{}, fmt.Errorf("retweet requires user context (OAuth 1.0a)")
+		return nil, ActionOutput{}, fmt.Errorf("retweet requires user context (OAuth 1.0a or OAuth 2.0)")
 	}
 	if input.Retweet {
 		_, err := client.UserRetweet(ctx, authUserID, input.TweetID)
@@ -428,7 +934,7 @@ func Retweet(ctx context.Context, req *mcp.CallToolRequest, input RetweetInput)
 
 func PostTweet(ctx context.Context, req *mcp.CallToolRequest, input PostTweetInput) (*mcp.CallToolResult, PostTweetOutput, error) {
 	if !hasUserCtx {
-		return nil, PostTweetOutput{}, fmt.Errorf("post_tweet requires user context (OAuth 1.0a)")
+		return nil, PostTweetOutput{}, fmt.Errorf("post_tweet requires user context (OAuth 1.0a or OAuth 2.0)")
 	}
 	create := twitter.CreateTweetRequest{Text: input.Text}
 	if input.InReplyToTweetID != "" {
@@ -443,6 +949,18 @@ func PostTweet(ctx context.Context, req *mcp.CallToolRequest, input PostTweetInp
 	if create.Text == "" && (create.Media == nil || len(create.Media.IDs) == 0) {
 		return nil, PostTweetOutput{}, fmt.Errorf("text or media_ids required")
 	}
+	if input.Poll != nil {
+		poll, err := tweetPoll(input)
+		if err != nil {
+			return nil, PostTweetOutput{}, err
+		}
+		create.Poll = poll
+		tweet, err := createPollTweet(ctx, create)
+		if err != nil {
+			return nil, PostTweetOutput{}, fmt.Errorf("create tweet: %w", err)
+		}
+		return nil, PostTweetOutput{TweetID: tweet.ID, Text: tweet.Text}, nil
+	}
 	resp, err := client.CreateTweet(ctx, create)
 	if err != nil {
 		return nil, PostTweetOutput{}, fmt.Errorf("create tweet: %w", err)
@@ -453,31 +971,104 @@ func PostTweet(ctx context.Context, req *mcp.CallToolRequest, input PostTweetInp
 	return nil, PostTweetOutput{TweetID: resp.Tweet.ID, Text: resp.Tweet.Text}, nil
 }
 
+// tweetPoll validates the poll of a post_tweet input against the API limits
+func tweetPoll(input PostTweetInput) (*twitter.CreateTweetPoll, error) {
+	poll := input.Poll
+	if len(input.MediaIDs) > 0 || input.QuoteTweetID != "" {
+		return nil, fmt.Errorf("a poll cannot be combined with media_ids or quote_tweet_id")
+	}
+	if len(poll.Options) < minPollOptions || len(poll.Options) > maxPollOptions {
+		return nil, fmt.Errorf("a poll needs %d to %d options, got %d", minPollOptions, maxPollOptions, len(poll.Options))
+	}
+	for i, option := range poll.Options {
+		if strings.TrimSpace(option) == "" {
+			return nil, fmt.Errorf("poll option %d is empty", i+1)
+		}
+		if n := len([]rune(option)); n > maxPollOptionLength {
+			return nil, fmt.Errorf("poll option %d is %d characters long, at most %d are allowed", i+1, n, maxPollOptionLength)
+		}
+	}
+	if poll.DurationMinutes < minPollMinutes || poll.DurationMinutes > maxPollMinutes {
+		return nil, fmt.Errorf("poll duration_minutes must be between %d and %d (7 days), got %d", minPollMinutes, maxPollMinutes, poll.DurationMinutes)
+	}
+	return &twitter.CreateTweetPoll{Options: poll.Options, DurationMinutes: poll.DurationMinutes}, nil
+}
+
+// createPollTweet posts a tweet carrying a poll. client.CreateTweet cannot:
+// its request validation dereferences the reply settings whenever a poll is
+// set, panicking on polls that are not replies.
+func createPollTweet(ctx context.Context, create twitter.CreateTweetRequest) (*twitter.CreateTweetData, error) {
+	body, err := json.Marshal(create)
+	if err != nil {
+		return nil, err
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, client.Host+"/2/tweets", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Accept", "application/json")
+	client.Authorizer.Add(httpReq)
+	resp, err := client.Client.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		data, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("tweets API: %s %s", resp.Status, string(data))
+	}
+	var created twitter.CreateTweetResponse
+	if err := json.NewDecoder(resp.Body).Decode(&created); err != nil {
+		return nil, fmt.Errorf("decode created tweet: %w", err)
+	}
+	if created.Tweet == nil {
+		return nil, fmt.Errorf("no tweet in response")
+	}
+	return created.Tweet, nil
+}
+
 func CreateThread(ctx context.Context, req *mcp.CallToolRequest, input CreateThreadInput) (*mcp.CallToolResult, CreateThreadOutput, error) {
 	if !hasUserCtx {
-		return nil, CreateThreadOutput{}, fmt.Errorf("create_thread requires user context (OAuth 1.0a)")
+		return nil, CreateThreadOutput{}, fmt.Errorf("create_thread requires user context (OAuth 1.0a or OAuth 2.0)")
 	}
 	if len(input.Tweets) == 0 {
 		return nil, CreateThreadOutput{}, fmt.Errorf("tweets array required")
 	}
+	ids, err := postThread(ctx, input.Tweets, input.ReplyToTweetID)
+	if err != nil && len(ids) == 0 {
+		return nil, CreateThreadOutput{}, err
+	}
+	// Tweets already posted stay published, so a partial thread is reported
+	// with their IDs rather than as a bare error
+	out := CreateThreadOutput{TweetIDs: ids, Count: len(ids)}
+	if err != nil {
+		out.Error = err.Error()
+	}
+	return nil, out, nil
+}
+
+// postThread posts texts in order, each replying to the previous one and the
+// first to replyTo when set. It returns the IDs of the tweets posted before
+// any error.
+func postThread(ctx context.Context, texts []string, replyTo string) ([]string, error) {
 	var ids []string
-	var lastID string
-	for i, text := range input.Tweets {
+	for i, text := range texts {
 		create := twitter.CreateTweetRequest{Text: text}
-		if i > 0 {
-			create.Reply = &twitter.CreateTweetReply{InReplyToTweetID: lastID}
+		if replyTo != "" {
+			create.Reply = &twitter.CreateTweetReply{InReplyToTweetID: replyTo}
 		}
 		resp, err := client.CreateTweet(ctx, create)
 		if err != nil {
-			return nil, CreateThreadOutput{}, fmt.Errorf("tweet %d: %w", i+1, err)
+			return ids, fmt.Errorf("tweet %d: %w", i+1, err)
 		}
 		if resp.Tweet == nil {
-			return nil, CreateThreadOutput{}, fmt.Errorf("no tweet in response for tweet %d", i+1)
+			return ids, fmt.Errorf("no tweet in response for tweet %d", i+1)
 		}
-		lastID = resp.Tweet.ID
-		ids = append(ids, lastID)
+		replyTo = resp.Tweet.ID
+		ids = append(ids, replyTo)
 	}
-	return nil, CreateThreadOutput{TweetIDs: ids, Count: len(ids)}, nil
+	return ids, nil
 }
 
 func GetTimeline(ctx context.Context, req *mcp.CallToolRequest, input GetTimelineInput) (*mcp.CallToolResult, GetTimelineOutput, error) {
@@ -485,10 +1076,13 @@ func GetTimeline(ctx context.Context, req *mcp.CallToolRequest, input GetTimelin
 	if n == 0 {
 		n = maxTweets
 	}
+	// Referenced tweets tell replies and quotes apart, with what they point at
+	tweetFields := []twitter.TweetField{twitter.TweetFieldCreatedAt, twitter.TweetFieldAuthorID, twitter.TweetFieldPublicMetrics, twitter.TweetFieldReferencedTweets}
+	expansions := []twitter.Expansion{twitter.ExpansionAuthorID, twitter.ExpansionReferencedTweetsID}
 	opts := twitter.UserTweetTimelineOpts{
 		MaxResults:  n,
-		TweetFields: []twitter.TweetField{twitter.TweetFieldCreatedAt, twitter.TweetFieldAuthorID, twitter.TweetFieldPublicMetrics},
-		Expansions:  []twitter.Expansion{twitter.ExpansionAuthorID},
+		TweetFields: tweetFields,
+		Expansions:  expansions,
 		UserFields:  []twitter.UserField{twitter.UserFieldUserName},
 	}
 	var tweets []*twitter.TweetObj
@@ -496,12 +1090,12 @@ func GetTimeline(ctx context.Context, req *mcp.CallToolRequest, input GetTimelin
 	switch strings.ToLower(input.TimelineType) {
 	case "home":
 		if !hasUserCtx {
-			return nil, GetTimelineOutput{}, fmt.Errorf("home timeline requires user context (OAuth 1.0a)")
+			return nil, GetTimelineOutput{}, fmt.Errorf("home timeline requires user context (OAuth 1.0a or OAuth 2.0)")
 		}
 		revOpts := twitter.UserTweetReverseChronologicalTimelineOpts{
 			MaxResults:  n,
-			TweetFields: []twitter.TweetField{twitter.TweetFieldCreatedAt, twitter.TweetFieldAuthorID, twitter.TweetFieldPublicMetrics},
-			Expansions:  []twitter.Expansion{twitter.ExpansionAuthorID},
+			TweetFields: tweetFields,
+			Expansions:  expansions,
 			UserFields:  []twitter.UserField{twitter.UserFieldUserName},
 		}
 		resp, err := client.UserTweetReverseChronologicalTimeline(ctx, authUserID, revOpts)
@@ -538,8 +1132,8 @@ func GetTimeline(ctx context.Context, req *mcp.CallToolRequest, input GetTimelin
 		}
 		mentOpts := twitter.UserMentionTimelineOpts{
 			MaxResults:  n,
-			TweetFields: []twitter.TweetField{twitter.TweetFieldCreatedAt, twitter.TweetFieldAuthorID, twitter.TweetFieldPublicMetrics},
-			Expansions:  []twitter.Expansion{twitter.ExpansionAuthorID},
+			TweetFields: tweetFields,
+			Expansions:  expansions,
 			UserFields:  []twitter.UserField{twitter.UserFieldUserName},
 		}
 		resp, err := client.UserMentionTimeline(ctx, uid, mentOpts)
@@ -560,14 +1154,25 @@ func GetTimeline(ctx context.Context, req *mcp.CallToolRequest, input GetTimelin
 	return nil, GetTimelineOutput{Tweets: out, Count: len(out)}, nil
 }
 
-func GetUnansweredMentions(ctx context.Context, req *mcp.CallToolRequest, input struct{}) (*mcp.CallToolResult, GetTimelineOutput, error) {
+func GetUnansweredMentions(ctx context.Context, req *mcp.CallToolRequest, input GetUnansweredMentionsInput) (*mcp.CallToolResult, GetTimelineOutput, error) {
 	if !hasUserCtx {
-		return nil, GetTimelineOutput{}, fmt.Errorf("get_unanswered_mentions requires user context (OAuth 1.0a)")
+		return nil, GetTimelineOutput{}, fmt.Errorf("get_unanswered_mentions requires user context (OAuth 1.0a or OAuth 2.0)")
 	}
 	if authUserID == "" {
 		return nil, GetTimelineOutput{}, fmt.Errorf("auth user ID not resolved (rate limited or lookup failed); try again later")
 	}
-	startTime := time.Now().Add(-unansweredWindow)
+	hours := input.Hours
+	if hours == 0 {
+		hours = defaultUnansweredHours
+	}
+	if hours < 0 || hours > maxUnansweredHours {
+		return nil, GetTimelineOutput{}, fmt.Errorf("hours must be between 1 and %d", maxUnansweredHours)
+	}
+	n := capMax(input.MaxResults, maxTweets)
+	if n == 0 {
+		n = maxTweets
+	}
+	startTime := time.Now().Add(-time.Duration(hours) * time.Hour)
 	mentOpts := twitter.UserMentionTimelineOpts{
 		StartTime:   startTime,
 		MaxResults:  100,
@@ -599,7 +1204,7 @@ func GetUnansweredMentions(ctx context.Context, req *mcp.CallToolRequest, input
 				continue
 			}
 			out = append(out, tweetFromObj(t, mentResp.Raw.Includes))
-			if len(out) >= maxTweets {
+			if len(out) >= n {
 				break
 			}
 		}
@@ -631,12 +1236,62 @@ func GetListTweets(ctx context.Context, req *mcp.CallToolRequest, input GetListT
 	return nil, GetListTweetsOutput{Tweets: tweets, Count: len(tweets)}, nil
 }
 
+// CreateList creates a list owned by the authenticated user
+func CreateList(ctx context.Context, req *mcp.CallToolRequest, input CreateListInput) (*mcp.CallToolResult, CreateListOutput, error) {
+	if !hasUserCtx {
+		return nil, CreateListOutput{}, fmt.Errorf("create_list requires user context (OAuth 1.0a or OAuth 2.0), a bearer token cannot manage lists")
+	}
+	if strings.TrimSpace(input.Name) == "" {
+		return nil, CreateListOutput{}, fmt.Errorf("name required")
+	}
+	list := twitter.ListMetaData{Name: &input.Name, Private: &input.Private}
+	if input.Description != "" {
+		list.Description = &input.Description
+	}
+	resp, err := client.CreateList(ctx, list)
+	if err != nil {
+		return nil, CreateListOutput{}, fmt.Errorf("create list: %s", errMsg(err))
+	}
+	if resp.List == nil {
+		return nil, CreateListOutput{}, fmt.Errorf("no list in response")
+	}
+	return nil, CreateListOutput{ListID: resp.List.ID, Name: resp.List.Name}, nil
+}
+
+// AddListMember adds a user to a list of the authenticated user
+func AddListMember(ctx context.Context, req *mcp.CallToolRequest, input ListMemberInput) (*mcp.CallToolResult, ActionOutput, error) {
+	if !hasUserCtx {
+		return nil, ActionOutput{}, fmt.Errorf("add_list_member requires user context (OAuth 1.0a or OAuth 2.0), a bearer token cannot manage lists")
+	}
+	if input.ListID == "" || input.UserID == "" {
+		return nil, ActionOutput{}, fmt.Errorf("list_id and user_id required")
+	}
+	if _, err := client.AddListMember(ctx, input.ListID, input.UserID); err != nil {
+		return nil, ActionOutput{Success: false, Message: errMsg(err)}, nil
+	}
+	return nil, ActionOutput{Success: true, Message: "added to list"}, nil
+}
+
+// RemoveListMember removes a user from a list of the authenticated user
+func RemoveListMember(ctx context.Context, req *mcp.CallToolRequest, input ListMemberInput) (*mcp.CallToolResult, ActionOutput, error) {
+	if !hasUserCtx {
+		return nil, ActionOutput{}, fmt.Errorf("remove_list_member requires user context (OAuth 1.0a or OAuth 2.0), a bearer token cannot manage lists")
+	}
+	if input.ListID == "" || input.UserID == "" {
+		return nil, ActionOutput{}, fmt.Errorf("list_id and user_id required")
+	}
+	if _, err := client.RemoveListMember(ctx, input.ListID, input.UserID); err != nil {
+		return nil, ActionOutput{Success: false, Message: errMsg(err)}, nil
+	}
+	return nil, ActionOutput{Success: true, Message: "removed from list"}, nil
+}
+
 func GetTrends(ctx context.Context, _ *mcp.CallToolRequest, input GetTrendsInput) (*mcp.CallToolResult, GetTrendsOutput, error) {
 	if v1Client == nil {
 		return nil, GetTrendsOutput{}, fmt.Errorf("trends require OAuth 1.0a (v1.1 API)")
 	}
-	url := fmt.Sprintf("https://api.twitter.com/1.1/trends/place.json?id=%d", input.WOEID)
-	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	trendsURL := fmt.Sprintf("%s/1.1/trends/place.json?id=%d", apiHost, input.WOEID)
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, trendsURL, nil)
 	if err != nil {
 		return nil, GetTrendsOutput{}, err
 	}
@@ -673,6 +1328,76 @@ func GetTrends(ctx context.Context, _ *mcp.CallToolRequest, input GetTrendsInput
 	return nil, GetTrendsOutput{Trends: trends, Count: len(trends)}, nil
 }
 
+// SearchUsers searches users by keyword through the v1.1 API, the v2 API
+// having no user search
+func SearchUsers(ctx context.Context, _ *mcp.CallToolRequest, input SearchUsersInput) (*mcp.CallToolResult, SearchUsersOutput, error) {
+	if v1Client == nil {
+		return nil, SearchUsersOutput{}, fmt.Errorf("search_users requires OAuth 1.0a (v1.1 API)")
+	}
+	if strings.TrimSpace(input.Query) == "" {
+		return nil, SearchUsersOutput{}, fmt.Errorf("query required")
+	}
+	n := capMax(input.MaxResults, maxUserSearchResults)
+	if n == 0 {
+		n = maxUserSearchResults
+	}
+	page := input.Page
+	if page < 1 {
+		page = 1
+	}
+	params := url.Values{
+		"q":                {input.Query},
+		"count":            {strconv.Itoa(n)},
+		"page":             {strconv.Itoa(page)},
+		"include_entities": {"false"},
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, apiHost+"/1.1/users/search.json?"+params.Encode(), nil)
+	if err != nil {
+		return nil, SearchUsersOutput{}, err
+	}
+	resp, err := v1Client.Do(httpReq)
+	if err != nil {
+		return nil, SearchUsersOutput{}, fmt.Errorf("user search request: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, SearchUsersOutput{}, fmt.Errorf("user search API: %s %s", resp.Status, string(body))
+	}
+	var raw []struct {
+		ID              string `json:"id_str"`
+		Name            string `json:"name"`
+		ScreenName      string `json:"screen_name"`
+		Description     string `json:"description"`
+		ProfileImageURL string `json:"profile_image_url_https"`
+		FollowersCount  int    `json:"followers_count"`
+		FriendsCount    int    `json:"friends_count"`
+		StatusesCount   int    `json:"statuses_count"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&raw); err != nil {
+		return nil, SearchUsersOutput{}, fmt.Errorf("decode user search: %w", err)
+	}
+	users := []UserOut{}
+	for _, u := range raw {
+		if len(users) == n {
+			break
+		}
+		users = append(users, UserOut{
+			ID:              u.ID,
+			Name:            u.Name,
+			Username:        u.ScreenName,
+			Description:     u.Description,
+			ProfileImageURL: u.ProfileImageURL,
+			PublicMetrics: map[string]int{
+				"followers_count": u.FollowersCount,
+				"following_count": u.FriendsCount,
+				"tweet_count":     u.StatusesCount,
+			},
+		})
+	}
+	return nil, SearchUsersOutput{Users: users, Count: len(users), Page: page}, nil
+}
+
 func GetUserRelationships(ctx context.Context, req *mcp.CallToolRequest, input GetUserRelationshipsInput) (*mcp.CallToolResult, GetUserRelationshipsOutput, error) {
 	n := capMax(input.MaxResults, 100)
 	if n == 0 {
@@ -707,7 +1432,7 @@ func GetUserRelationships(ctx context.Context, req *mcp.CallToolRequest, input G
 
 func FollowUser(ctx context.Context, req *mcp.CallToolRequest, input FollowUserInput) (*mcp.CallToolResult, ActionOutput, error) {
 	if !hasUserCtx {
-		return nil, ActionOutput{}, fmt.Errorf("follow_user requires user context (OAuth 1.0a)")
+		return nil, ActionOutput{}, fmt.Errorf("follow_user requires user context (OAuth 1.0a or OAuth 2.0)")
 	}
 	if input.Follow {
 		_, err := client.UserFollows(ctx, authUserID, input.TargetUserID)
@@ -723,41 +1448,134 @@ func FollowUser(ctx context.Context, req *mcp.CallToolRequest, input FollowUserI
 	return nil, ActionOutput{Success: true, Message: "unfollowed"}, nil
 }
 
+func BlockUser(ctx context.Context, req *mcp.CallToolRequest, input BlockUserInput) (*mcp.CallToolResult, ActionOutput, error) {
+	if !hasUserCtx {
+		return nil, ActionOutput{}, fmt.Errorf("block_user requires user context (OAuth 1.0a or OAuth 2.0)")
+	}
+	if input.Block {
+		_, err := client.UserBlocks(ctx, authUserID, input.TargetUserID)
+		if err != nil {
+			return nil, ActionOutput{Success: false, Message: errMsg(err)}, nil
+		}
+		return nil, ActionOutput{Success: true, Message: "blocked"}, nil
+	}
+	_, err := client.DeleteUserBlocks(ctx, authUserID, input.TargetUserID)
+	if err != nil {
+		return nil, ActionOutput{Success: false, Message: errMsg(err)}, nil
+	}
+	return nil, ActionOutput{Success: true, Message: "unblocked"}, nil
+}
+
+func MuteUser(ctx context.Context, req *mcp.CallToolRequest, input MuteUserInput) (*mcp.CallToolResult, ActionOutput, error) {
+	if !hasUserCtx {
+		return nil, ActionOutput{}, fmt.Errorf("mute_user requires user context (OAuth 1.0a or OAuth 2.0)")
+	}
+	if input.Mute {
+		_, err := client.UserMutes(ctx, authUserID, input.TargetUserID)
+		if err != nil {
+			return nil, ActionOutput{Success: false, Message: errMsg(err)}, nil
+		}
+		return nil, ActionOutput{Success: true, Message: "muted"}, nil
+	}
+	_, err := client.DeleteUserMutes(ctx, authUserID, input.TargetUserID)
+	if err != nil {
+		return nil, ActionOutput{Success: false, Message: errMsg(err)}, nil
+	}
+	return nil, ActionOutput{Success: true, Message: "unmuted"}, nil
+}
+
 func UploadMedia(ctx context.Context, req *mcp.CallToolRequest, input UploadMediaInput) (*mcp.CallToolResult, UploadMediaOutput, error) {
 	if !hasUserCtx || v1Client == nil {
 		return nil, UploadMediaOutput{}, fmt.Errorf("upload_media requires OAuth 1.0a")
 	}
+	body, media, err := loadMedia(ctx, input)
+	if err != nil {
+		return nil, UploadMediaOutput{}, err
+	}
+	mediaID, err := uploadMediaV1(ctx, body, media)
+	if err != nil {
+		return nil, UploadMediaOutput{}, err
+	}
+	return nil, UploadMediaOutput{MediaID: mediaID}, nil
+}
+
+// UploadMediaBatch uploads up to maxTweetMedia images in order. Every image
+// is fetched and checked before the first upload, so a bad entry fails the
+// batch without uploading anything.
+func UploadMediaBatch(ctx context.Context, req *mcp.CallToolRequest, input UploadMediaBatchInput) (*mcp.CallToolResult, UploadMediaBatchOutput, error) {
+	if !hasUserCtx || v1Client == nil {
+		return nil, UploadMediaBatchOutput{}, fmt.Errorf("upload_media_batch requires OAuth 1.0a")
+	}
+	if len(input.Images) == 0 {
+		return nil, UploadMediaBatchOutput{}, fmt.Errorf("images required")
+	}
+	if len(input.Images) > maxTweetMedia {
+		return nil, UploadMediaBatchOutput{}, fmt.Errorf("at most %d images can be attached to a tweet, got %d", maxTweetMedia, len(input.Images))
+	}
+	bodies := make([][]byte, len(input.Images))
+	medias := make([]uploadedMedia, len(input.Images))
+	for i, image := range input.Images {
+		var err error
+		if bodies[i], medias[i], err = loadMedia(ctx, image); err != nil {
+			return nil, UploadMediaBatchOutput{}, fmt.Errorf("image %d: %w", i, err)
+		}
+	}
+	ids := make([]string, 0, len(input.Images))
+	for i := range input.Images {
+		mediaID, err := uploadMediaV1(ctx, bodies[i], medias[i])
+		if err != nil {
+			// Media uploaded so far are never attached and expire on their own
+			return nil, UploadMediaBatchOutput{}, fmt.Errorf("image %d: %w", i, err)
+		}
+		ids = append(ids, mediaID)
+	}
+	return nil, UploadMediaBatchOutput{MediaIDs: ids, Count: len(ids)}, nil
+}
+
+// loadMedia decodes or fetches the image of an upload and detects its type
+func loadMedia(ctx context.Context, input UploadMediaInput) ([]byte, uploadedMedia, error) {
 	var body []byte
+	var contentType string
 	if input.ImageBase64 != "" {
 		var err error
 		body, err = base64.StdEncoding.DecodeString(input.ImageBase64)
 		if err != nil {
-			return nil, UploadMediaOutput{}, fmt.Errorf("decode base64: %w", err)
+			return nil, uploadedMedia{}, fmt.Errorf("decode base64: %w", err)
 		}
 	} else if input.ImageURL != "" {
-		resp, err := http.Get(input.ImageURL)
+		imageReq, err := http.NewRequestWithContext(ctx, http.MethodGet, input.ImageURL, nil)
+		if err != nil {
+			return nil, uploadedMedia{}, fmt.Errorf("fetch image: %w", err)
+		}
+		resp, err := http.DefaultClient.Do(imageReq)
 		if err != nil {
-			return nil, UploadMediaOutput{}, fmt.Errorf("fetch image: %w", err)
+			return nil, uploadedMedia{}, fmt.Errorf("fetch image: %w", err)
 		}
 		defer resp.Body.Close()
 		body, err = io.ReadAll(resp.Body)
 		if err != nil {
-			return nil, UploadMediaOutput{}, fmt.Errorf("read image: %w", err)
+			return nil, uploadedMedia{}, fmt.Errorf("read image: %w", err)
 		}
+		contentType = resp.Header.Get("Content-Type")
 	} else {
-		return nil, UploadMediaOutput{}, fmt.Errorf("image_base64 or image_url required")
+		return nil, uploadedMedia{}, fmt.Errorf("image_base64 or image_url required")
 	}
-	mediaID, err := uploadMediaV1(ctx, body)
+	media, err := detectMedia(body, contentType)
 	if err != nil {
-		return nil, UploadMediaOutput{}, err
+		return nil, uploadedMedia{}, err
 	}
-	return nil, UploadMediaOutput{MediaID: mediaID}, nil
+	return body, media, nil
 }
 
-func uploadMediaV1(ctx context.Context, data []byte) (string, error) {
-	initURL := "https://upload.twitter.com/1.1/media/upload.json"
-	form := fmt.Sprintf("command=INIT&total_bytes=%d&media_type=image/jpeg", len(data))
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, initURL, strings.NewReader(form))
+func uploadMediaV1(ctx context.Context, data []byte, media uploadedMedia) (string, error) {
+	initURL := uploadHost + "/1.1/media/upload.json"
+	form := url.Values{
+		"command":        {"INIT"},
+		"total_bytes":    {strconv.Itoa(len(data))},
+		"media_type":     {media.mediaType},
+		"media_category": {media.category},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, initURL, strings.NewReader(form.Encode()))
 	if err != nil {
 		return "", err
 	}
@@ -776,13 +1594,13 @@ func uploadMediaV1(ctx context.Context, data []byte) (string, error) {
 	if initResp.MediaIDString == "" {
 		return "", fmt.Errorf("media INIT: no media_id_string")
 	}
-	appendURL := "https://upload.twitter.com/1.1/media/upload.json"
+	appendURL := uploadHost + "/1.1/media/upload.json"
 	var body bytes.Buffer
 	w := multipart.NewWriter(&body)
 	_ = w.WriteField("command", "APPEND")
 	_ = w.WriteField("media_id", initResp.MediaIDString)
 	_ = w.WriteField("segment_index", "0")
-	part, _ := w.CreateFormFile("media", "image.jpg")
+	part, _ := w.CreateFormFile("media", "image"+media.extension)
 	_, _ = part.Write(data)
 	contentType := w.FormDataContentType()
 	_ = w.Close()
@@ -819,8 +1637,13 @@ func uploadMediaV1(ctx context.Context, data []byte) (string, error) {
 	return initResp.MediaIDString, nil
 }
 
+// oauth2Err is set by InitClientFromEnv when the OAuth 2.0 token settings are
+// invalid
+var oauth2Err error
+
 // InitClientFromEnv initializes the Twitter client from environment variables.
-// Used by main and by acceptance tests. Returns true if credentials were set.
+// Used by main and by acceptance tests. Returns true if credentials were set
+// or TWITTER_MOCK is enabled.
 func InitClientFromEnv() bool {
 	maxTweets = defaultMaxTweets
 	if n, err := strconv.Atoi(os.Getenv("TWITTER_MAX_TWEETS")); err == nil && n > 0 {
@@ -829,6 +1652,27 @@ func InitClientFromEnv() bool {
 			maxTweets = 100
 		}
 	}
+	if apiHost, hostErr = hostFromEnv("TWITTER_API_HOST", defaultAPIHost); hostErr != nil {
+		return false
+	}
+	if uploadHost, hostErr = hostFromEnv("TWITTER_UPLOAD_HOST", defaultUploadHost); hostErr != nil {
+		return false
+	}
+	oauth2TokenURL = apiHost + "/2/oauth2/token"
+	rateLimitWait, _ = strconv.ParseBool(os.Getenv("TWITTER_RATE_LIMIT_WAIT"))
+	if mock.Enabled("TWITTER") {
+		v1Client = newMockHTTPClient()
+		client = &twitter.Client{
+			Authorizer: noopAuthorizer{},
+			Client:     v1Client,
+			Host:       apiHost,
+		}
+		hasUserCtx = true
+		authUserID = mockUserID
+		authMode = "mock"
+		debugLog("Twitter MCP: TWITTER_MOCK enabled, using canned responses")
+		return true
+	}
 	apiKey := os.Getenv("TWITTER_API_KEY")
 	apiSecret := os.Getenv("TWITTER_API_SECRET")
 	accessToken := os.Getenv("TWITTER_ACCESS_TOKEN")
@@ -837,57 +1681,107 @@ func InitClientFromEnv() bool {
 	if apiKey != "" && apiSecret != "" && accessToken != "" && accessSecret != "" {
 		config := oauth1.NewConfig(apiKey, apiSecret)
 		token := oauth1.NewToken(accessToken, accessSecret)
-		v1Client = config.Client(context.Background(), token)
+		v1Client = withRateLimits(config.Client(context.Background(), token))
 		client = &twitter.Client{
 			Authorizer: noopAuthorizer{},
 			Client:     v1Client,
-			Host:       "https://api.twitter.com",
+			Host:       apiHost,
 		}
 		hasUserCtx = true
-		ctx := context.Background()
-		resp, err := client.AuthUserLookup(ctx, twitter.UserLookupOpts{})
-		if err != nil {
-			debugLogf("Warning: could not resolve auth user: %v", err)
-		} else if resp.Raw != nil && len(resp.Raw.Users) > 0 {
-			authUserID = resp.Raw.Users[0].ID
-		}
+		authMode = "OAuth 1.0a user context"
+		resolveAuthUser()
 		debugLog("Twitter MCP: using OAuth 1.0a user context")
 		return true
 	}
+	userToken, err := newOAuth2TransportFromEnv()
+	if err != nil {
+		oauth2Err = err
+		return false
+	}
+	if userToken != nil {
+		client = &twitter.Client{
+			Authorizer: noopAuthorizer{},
+			Client:     withRateLimits(&http.Client{Transport: userToken}),
+			Host:       apiHost,
+		}
+		hasUserCtx = true
+		authMode = "OAuth 2.0 user context"
+		resolveAuthUser()
+		debugLog("Twitter MCP: using OAuth 2.0 user context; trends and media upload require OAuth 1.0a")
+		return true
+	}
 	if bearer != "" {
 		client = &twitter.Client{
 			Authorizer: bearerAuthorizer{token: bearer},
-			Client:     http.DefaultClient,
-			Host:       "https://api.twitter.com",
+			Client:     withRateLimits(http.DefaultClient),
+			Host:       apiHost,
 		}
+		authMode = "bearer token (read-only, no user context)"
 		debugLog("Twitter MCP: using Bearer (app-only); write and home/mentions tools will fail without user context")
 		return true
 	}
 	return false
 }
 
+// resolveAuthUser looks up the ID of the user the credentials belong to
+func resolveAuthUser() {
+	resp, err := client.AuthUserLookup(context.Background(), twitter.UserLookupOpts{})
+	if err != nil {
+		debugLogf("Warning: could not resolve auth user: %v", err)
+	} else if resp.Raw != nil && len(resp.Raw.Users) > 0 {
+		authUserID = resp.Raw.Users[0].ID
+	}
+}
+
 func main() {
-	if !InitClientFromEnv() {
-		fmt.Fprintln(os.Stderr, "twitter MCP: Set TWITTER_BEARER_TOKEN or all of TWITTER_API_KEY, TWITTER_API_SECRET, TWITTER_ACCESS_TOKEN, TWITTER_ACCESS_SECRET")
+	initialized := InitClientFromEnv()
+	if selfcheck.Enabled() {
+		selfcheck.Exit("twitter", selfChecks(initialized)...)
+	}
+	if hostErr != nil {
+		fmt.Fprintln(os.Stderr, "twitter MCP:", hostErr)
+		os.Exit(1)
+	}
+	if oauth2Err != nil {
+		fmt.Fprintln(os.Stderr, "twitter MCP:", oauth2Err)
+		os.Exit(1)
+	}
+	if !initialized {
+		fmt.Fprintln(os.Stderr, "twitter MCP: Set TWITTER_BEARER_TOKEN, TWITTER_OAUTH2_ACCESS_TOKEN or all of TWITTER_API_KEY, TWITTER_API_SECRET, TWITTER_ACCESS_TOKEN, TWITTER_ACCESS_SECRET (or TWITTER_MOCK=true for canned responses)")
 		os.Exit(1)
 	}
 
 	server := mcp.NewServer(&mcp.Implementation{Name: "twitter", Version: "v1.0.0"}, nil)
 	mcp.AddTool(server, &mcp.Tool{Name: "get_tweets", Description: "Fetch recent tweets from a user (with media support)"}, GetTweets)
+	mcp.AddTool(server, &mcp.Tool{Name: "get_tweet", Description: "Get a single tweet by ID with its metrics and media, optionally with the tweets it replies to or quotes"}, GetTweet)
+	mcp.AddTool(server, &mcp.Tool{Name: "get_thread", Description: "Get a thread from the ID of its first tweet: the chain of replies the author made to their own tweets, in order. Replies older than 7 days cannot be found."}, GetThread)
+	mcp.AddTool(server, &mcp.Tool{Name: "get_bookmarks", Description: "List the tweets bookmarked by the authenticated user (with media); requires user context"}, GetBookmarks)
+	mcp.AddTool(server, &mcp.Tool{Name: "get_liked_tweets", Description: "List the tweets a user liked (with media), the authenticated user by default"}, GetLikedTweets)
+	mcp.AddTool(server, &mcp.Tool{Name: "get_spaces", Description: "List the live and scheduled Spaces a user hosts, with their titles, states and start times, the authenticated user by default"}, GetSpaces)
+	mcp.AddTool(server, &mcp.Tool{Name: "get_engagement_summary", Description: "Aggregate engagement metrics (likes, retweets, replies, best tweet) over a user's recent tweets"}, GetEngagementSummary)
 	mcp.AddTool(server, &mcp.Tool{Name: "get_profile", Description: "Get a user's profile information"}, GetProfile)
 	mcp.AddTool(server, &mcp.Tool{Name: "search_tweets", Description: "Search for tweets by hashtag or keyword"}, SearchTweets)
 	mcp.AddTool(server, &mcp.Tool{Name: "like_tweet", Description: "Like or unlike a tweet"}, LikeTweet)
 	mcp.AddTool(server, &mcp.Tool{Name: "retweet", Description: "Retweet or undo retweet"}, Retweet)
-	mcp.AddTool(server, &mcp.Tool{Name: "post_tweet", Description: "Post a new tweet with optional media, reply, or quote"}, PostTweet)
-	mcp.AddTool(server, &mcp.Tool{Name: "create_thread", Description: "Create a Twitter thread"}, CreateThread)
+	mcp.AddTool(server, &mcp.Tool{Name: "post_tweet", Description: "Post a new tweet with optional media, reply, quote, or poll"}, PostTweet)
+	mcp.AddTool(server, &mcp.Tool{Name: "create_thread", Description: "Create a Twitter thread, optionally continuing under an existing tweet"}, CreateThread)
 	mcp.AddTool(server, &mcp.Tool{Name: "get_timeline", Description: "Get tweets from home, user, or mentions timeline"}, GetTimeline)
-	mcp.AddTool(server, &mcp.Tool{Name: "get_unanswered_mentions", Description: "Get tweets that mention you and you have not replied to (last 24 hours)"}, GetUnansweredMentions)
+	mcp.AddTool(server, &mcp.Tool{Name: "get_unanswered_mentions", Description: "Get tweets that mention you and you have not replied to, over the last hours (default 24, max 168)"}, GetUnansweredMentions)
 	mcp.AddTool(server, &mcp.Tool{Name: "get_list_tweets", Description: "Get tweets from a Twitter list"}, GetListTweets)
+	mcp.AddTool(server, &mcp.Tool{Name: "create_list", Description: "Create a list owned by you and return its list_id, for get_list_tweets and add_list_member"}, CreateList)
+	mcp.AddTool(server, &mcp.Tool{Name: "add_list_member", Description: "Add a user to one of your lists"}, AddListMember)
+	mcp.AddTool(server, &mcp.Tool{Name: "remove_list_member", Description: "Remove a user from one of your lists"}, RemoveListMember)
+	mcp.AddTool(server, &mcp.Tool{Name: "search_users", Description: "Search users by keyword in their name, handle or bio, with follower counts; requires OAuth 1.0a"}, SearchUsers)
 	mcp.AddTool(server, &mcp.Tool{Name: "get_trends", Description: "Get current trending topics by place (WOEID)"}, GetTrends)
 	mcp.AddTool(server, &mcp.Tool{Name: "get_user_relationships", Description: "Get followers or following list"}, GetUserRelationships)
+	mcp.AddTool(server, &mcp.Tool{Name: "snapshot_relationships", Description: "Store the current followers or following list of a user in a local snapshot file for later comparison"}, SnapshotRelationships)
+	mcp.AddTool(server, &mcp.Tool{Name: "diff_relationships", Description: "Compare the latest followers/following snapshot of a user to a prior one, returning new and lost accounts"}, DiffRelationships)
 	mcp.AddTool(server, &mcp.Tool{Name: "follow_user", Description: "Follow or unfollow a user"}, FollowUser)
-	mcp.AddTool(server, &mcp.Tool{Name: "upload_media", Description: "Upload an image (JPEG/PNG/GIF) and get media_id for post_tweet"}, UploadMedia)
-	if err := server.Run(context.Background(), &mcp.StdioTransport{}); err != nil {
+	mcp.AddTool(server, &mcp.Tool{Name: "block_user", Description: "Block or unblock a user"}, BlockUser)
+	mcp.AddTool(server, &mcp.Tool{Name: "mute_user", Description: "Mute or unmute a user"}, MuteUser)
+	mcp.AddTool(server, &mcp.Tool{Name: "upload_media", Description: "Upload an image (JPEG/PNG/GIF/WebP) and get media_id for post_tweet"}, UploadMedia)
+	mcp.AddTool(server, &mcp.Tool{Name: "upload_media_batch", Description: "Upload up to 4 images at once and get their media_ids, in order, for post_tweet. Fails without uploading anything if an image cannot be read or has an unsupported type"}, UploadMediaBatch)
+	if err := transport.Run(context.Background(), server); err != nil {
 		fmt.Fprintln(os.Stderr, "twitter MCP:", err)
 		os.Exit(1)
 	}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"image/gif"
+	"mime"
+	"net/http"
+)
+
+// mediaExtensions are the image types accepted by the media upload, with the
+// file extension sent in the APPEND step
+var mediaExtensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+	"image/webp": ".webp",
+}
+
+// uploadedMedia describes the image sent by upload_media
+type uploadedMedia struct {
+	mediaType string
+	extension string
+	category  string
+}
+
+// detectMedia sniffs the type of an image from its content, falling back to
+// the Content-Type header it was served with when the content is not
+// recognized. Animated GIFs are uploaded in the tweet_gif category, which
+// the FINALIZE step requires for them.
+func detectMedia(data []byte, contentType string) (uploadedMedia, error) {
+	mediaType := http.DetectContentType(data)
+	if _, ok := mediaExtensions[mediaType]; !ok && contentType != "" {
+		if parsed, _, err := mime.ParseMediaType(contentType); err == nil {
+			mediaType = parsed
+		}
+	}
+	extension, ok := mediaExtensions[mediaType]
+	if !ok {
+		return uploadedMedia{}, fmt.Errorf("unsupported media type %s, upload_media accepts JPEG, PNG, GIF and WebP images", mediaType)
+	}
+	media := uploadedMedia{mediaType: mediaType, extension: extension, category: "tweet_image"}
+	if mediaType == "image/gif" && animatedGIF(data) {
+		media.category = "tweet_gif"
+	}
+	return media, nil
+}
+
+// animatedGIF reports whether a GIF has more than one frame
+func animatedGIF(data []byte) bool {
+	decoded, err := gif.DecodeAll(bytes.NewReader(data))
+	return err == nil && len(decoded.Image) > 1
+}
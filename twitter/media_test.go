@@ -0,0 +1,153 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("upload_media", func() {
+	var (
+		mutex     sync.Mutex
+		initForm  url.Values
+		inits     int
+		filenames []string
+	)
+
+	pngImage := func() []byte {
+		var buf bytes.Buffer
+		Expect(png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 2, 2)))).To(Succeed())
+		return buf.Bytes()
+	}
+	gifImage := func(frames int) []byte {
+		anim := &gif.GIF{}
+		for i := 0; i < frames; i++ {
+			anim.Image = append(anim.Image, image.NewPaletted(image.Rect(0, 0, 2, 2), color.Palette{color.Black, color.White}))
+			anim.Delay = append(anim.Delay, 10)
+		}
+		var buf bytes.Buffer
+		Expect(gif.EncodeAll(&buf, anim)).To(Succeed())
+		return buf.Bytes()
+	}
+
+	BeforeEach(func() {
+		initForm, inits, filenames = nil, 0, nil
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			mutex.Lock()
+			defer mutex.Unlock()
+			if err := r.ParseMultipartForm(1 << 20); err == nil {
+				for _, files := range r.MultipartForm.File {
+					for _, file := range files {
+						filenames = append(filenames, file.Filename)
+					}
+				}
+			} else if r.ParseForm() == nil && r.PostForm.Get("command") == "INIT" {
+				initForm = r.PostForm
+				inits++
+			}
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprintf(w, `{"media_id_string":"%d"}`, 41+inits)
+		}))
+
+		prevV1Client, prevUserCtx, prevUploadHost := v1Client, hasUserCtx, uploadHost
+		DeferCleanup(func() {
+			v1Client, hasUserCtx, uploadHost = prevV1Client, prevUserCtx, prevUploadHost
+			server.Close()
+		})
+		v1Client, hasUserCtx, uploadHost = http.DefaultClient, true, server.URL
+	})
+
+	upload := func(data []byte) (UploadMediaOutput, error) {
+		_, out, err := UploadMedia(context.Background(), nil, UploadMediaInput{ImageBase64: base64.StdEncoding.EncodeToString(data)})
+		return out, err
+	}
+
+	It("sends the detected type and extension of a PNG", func() {
+		out, err := upload(pngImage())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(out.MediaID).To(Equal("42"))
+
+		mutex.Lock()
+		defer mutex.Unlock()
+		Expect(initForm.Get("media_type")).To(Equal("image/png"))
+		Expect(initForm.Get("media_category")).To(Equal("tweet_image"))
+		Expect(filenames).To(Equal([]string{"image.png"}))
+	})
+
+	It("uploads animated GIFs in the tweet_gif category", func() {
+		_, err := upload(gifImage(2))
+		Expect(err).NotTo(HaveOccurred())
+
+		mutex.Lock()
+		defer mutex.Unlock()
+		Expect(initForm.Get("media_type")).To(Equal("image/gif"))
+		Expect(initForm.Get("media_category")).To(Equal("tweet_gif"))
+		Expect(filenames).To(Equal([]string{"image.gif"}))
+	})
+
+	It("keeps still GIFs in the tweet_image category", func() {
+		media, err := detectMedia(gifImage(1), "")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(media.category).To(Equal("tweet_image"))
+	})
+
+	It("falls back to the Content-Type the image was served with", func() {
+		media, err := detectMedia([]byte("not sniffable"), "image/webp; charset=binary")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(media.mediaType).To(Equal("image/webp"))
+		Expect(media.extension).To(Equal(".webp"))
+	})
+
+	It("rejects unsupported types before uploading", func() {
+		_, err := upload([]byte("%PDF-1.4 not an image"))
+		Expect(err).To(MatchError(ContainSubstring("unsupported media type application/pdf")))
+
+		mutex.Lock()
+		defer mutex.Unlock()
+		Expect(initForm).To(BeNil())
+	})
+
+	Context("upload_media_batch", func() {
+		encode := func(data []byte) UploadMediaInput {
+			return UploadMediaInput{ImageBase64: base64.StdEncoding.EncodeToString(data)}
+		}
+
+		It("returns the media IDs in the order of the images", func() {
+			_, out, err := UploadMediaBatch(context.Background(), nil, UploadMediaBatchInput{Images: []UploadMediaInput{encode(pngImage()), encode(gifImage(2))}})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(out.MediaIDs).To(Equal([]string{"42", "43"}))
+			Expect(out.Count).To(Equal(2))
+
+			mutex.Lock()
+			defer mutex.Unlock()
+			Expect(filenames).To(Equal([]string{"image.png", "image.gif"}))
+		})
+
+		It("fails with the index of a bad image without uploading any", func() {
+			_, _, err := UploadMediaBatch(context.Background(), nil, UploadMediaBatchInput{Images: []UploadMediaInput{encode(pngImage()), {ImageBase64: "not base64!"}}})
+			Expect(err).To(MatchError(ContainSubstring("image 1: decode base64")))
+
+			mutex.Lock()
+			defer mutex.Unlock()
+			Expect(inits).To(BeZero())
+		})
+
+		It("rejects more images than a tweet can carry", func() {
+			images := make([]UploadMediaInput, maxTweetMedia+1)
+			_, _, err := UploadMediaBatch(context.Background(), nil, UploadMediaBatchInput{Images: images})
+			Expect(err).To(MatchError(ContainSubstring("at most 4 images")))
+		})
+	})
+})
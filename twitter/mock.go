@@ -0,0 +1,282 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"sync/atomic"
+
+	"github.com/mudler/mcps/pkg/mock"
+)
+
+// mockUserID is the authenticated user when TWITTER_MOCK is enabled
+const mockUserID = "1000000000000000001"
+
+// mockCreatedAt is the fixed creation time of every mock tweet
+const mockCreatedAt = "2025-01-15T10:30:00.000Z"
+
+var mockUsers = []map[string]interface{}{
+	mockUser(mockUserID, "MCP Agent", "mcp_agent", 120, 80, 42),
+	mockUser("1000000000000000002", "Ada Example", "ada_example", 5400, 310, 1280),
+	mockUser("1000000000000000003", "Grace Example", "grace_example", 980, 150, 640),
+}
+
+// mockTweets back the user, home, list and search timelines, the last one
+// being bookmarked. The reply references one of mockMentions so
+// get_unanswered_mentions filters it out.
+var mockTweets = []map[string]interface{}{
+	mockTweet("2000000000000000001", mockUserID, "Shipping a new release of our MCP servers today #golang", 48, 12, 5, 2, nil),
+	mockTweet("2000000000000000002", mockUserID, "@ada_example thanks, glad it helped!", 3, 0, 0, 0, map[string]string{"replied_to": "3000000000000000001"}),
+	mockTweet("2000000000000000003", "1000000000000000002", "Offline demos are underrated #golang", 210, 40, 18, 6, nil),
+}
+
+var mockMentions = []map[string]interface{}{
+	mockTweet("3000000000000000001", "1000000000000000002", "@mcp_agent the new release works great", 7, 1, 1, 0, nil),
+	mockTweet("3000000000000000002", "1000000000000000003", "@mcp_agent does the twitter server support lists?", 2, 0, 0, 0, nil),
+}
+
+var mockTrends = []map[string]interface{}{
+	{
+		"trends": []map[string]interface{}{
+			{"name": "#golang", "url": "http://twitter.com/search?q=%23golang", "query": "%23golang", "tweet_volume": 12800},
+			{"name": "MCP", "url": "http://twitter.com/search?q=MCP", "query": "MCP", "tweet_volume": 5300},
+			{"name": "#opensource", "url": "http://twitter.com/search?q=%23opensource", "query": "%23opensource", "tweet_volume": nil},
+		},
+	},
+}
+
+// mockSpaces are the live and scheduled Spaces of every user
+var mockSpaces = []map[string]interface{}{
+	{"id": "1YqKDqWqdPLsV", "state": "live", "title": "Building MCP servers", "started_at": "2025-01-15T18:00:00.000Z", "participant_count": 42, "lang": "en", "host_ids": []string{mockUserID}},
+	{"id": "1vOxwjaWEbdJB", "state": "scheduled", "title": "Office hours", "scheduled_start": "2025-01-22T18:00:00.000Z", "lang": "en", "host_ids": []string{mockUserID}},
+}
+
+// mockTweetSeq numbers tweets created through the mock backend
+var mockTweetSeq int64
+
+func mockUser(id, name, username string, followers, following, tweets int) map[string]interface{} {
+	return map[string]interface{}{
+		"id":                id,
+		"name":              name,
+		"username":          username,
+		"description":       fmt.Sprintf("Mock profile for @%s", username),
+		"profile_image_url": fmt.Sprintf("https://example.com/%s.png", username),
+		"public_metrics": map[string]int{
+			"followers_count": followers,
+			"following_count": following,
+			"tweet_count":     tweets,
+			"listed_count":    0,
+		},
+	}
+}
+
+// mockUserSearch returns the mock users whose name or handle contains query,
+// in the v1.1 format of users/search.json
+func mockUserSearch(query string) []map[string]interface{} {
+	users := []map[string]interface{}{}
+	query = strings.ToLower(query)
+	for _, user := range mockUsers {
+		name, username := fmt.Sprint(user["name"]), fmt.Sprint(user["username"])
+		if !strings.Contains(strings.ToLower(name), query) && !strings.Contains(strings.ToLower(username), query) {
+			continue
+		}
+		metrics := user["public_metrics"].(map[string]int)
+		users = append(users, map[string]interface{}{
+			"id_str":                  user["id"],
+			"name":                    name,
+			"screen_name":             username,
+			"description":             user["description"],
+			"profile_image_url_https": user["profile_image_url"],
+			"followers_count":         metrics["followers_count"],
+			"friends_count":           metrics["following_count"],
+			"statuses_count":          metrics["tweet_count"],
+		})
+	}
+	return users
+}
+
+func mockTweet(id, authorID, text string, likes, retweets, replies, quotes int, refs map[string]string) map[string]interface{} {
+	tweet := map[string]interface{}{
+		"id":         id,
+		"text":       text,
+		"author_id":  authorID,
+		"created_at": mockCreatedAt,
+		"public_metrics": map[string]int{
+			"like_count":       likes,
+			"retweet_count":    retweets,
+			"reply_count":      replies,
+			"quote_count":      quotes,
+			"impression_count": likes * 40,
+		},
+	}
+	for kind, ref := range refs {
+		tweet["referenced_tweets"] = []map[string]string{{"type": kind, "id": ref}}
+	}
+	return tweet
+}
+
+// mockUsersBy returns the fixture users matching the values of a lookup
+// query parameter, inventing a profile for unknown names so any handle works
+func mockUsersBy(field string, values []string) []map[string]interface{} {
+	users := []map[string]interface{}{}
+	for i, value := range values {
+		var found map[string]interface{}
+		for _, user := range mockUsers {
+			if strings.EqualFold(fmt.Sprint(user[field]), value) {
+				found = user
+				break
+			}
+		}
+		if found == nil {
+			if field == "username" {
+				found = mockUser(fmt.Sprintf("1000000000000001%03d", i), value, value, 100, 100, 100)
+			} else {
+				found = mockUser(value, "Mock User "+value, "user_"+value, 100, 100, 100)
+			}
+		}
+		users = append(users, found)
+	}
+	return users
+}
+
+// findMockTweet returns the fixture tweet with the given ID, or nil
+func findMockTweet(id string) map[string]interface{} {
+	for _, list := range [][]map[string]interface{}{mockTweets, mockMentions} {
+		for _, tweet := range list {
+			if tweet["id"] == id {
+				return tweet
+			}
+		}
+	}
+	return nil
+}
+
+// mockTweetLookup answers GET /2/tweets/{id} and GET /2/tweets?ids=...,
+// including the referenced tweets when the referenced_tweets.id expansion is
+// requested. Unknown IDs are reported as errors, like the API does.
+func mockTweetLookup(req *http.Request) (int, interface{}) {
+	single := req.URL.Path != "/2/tweets"
+	ids := strings.Split(req.URL.Query().Get("ids"), ",")
+	if single {
+		ids = []string{strings.TrimPrefix(req.URL.Path, "/2/tweets/")}
+	}
+
+	tweets := []map[string]interface{}{}
+	var missing []map[string]string
+	for _, id := range ids {
+		if tweet := findMockTweet(id); tweet != nil {
+			tweets = append(tweets, tweet)
+		} else {
+			missing = append(missing, map[string]string{"value": id, "detail": "Could not find tweet with id: [" + id + "].", "title": "Not Found Error", "type": "https://api.twitter.com/2/problems/resource-not-found"})
+		}
+	}
+	body := map[string]interface{}{}
+	if len(missing) > 0 {
+		body["errors"] = missing
+	}
+	if len(tweets) == 0 {
+		return http.StatusOK, body
+	}
+	if single {
+		body["data"] = tweets[0]
+	} else {
+		body["data"] = tweets
+	}
+	includes := map[string]interface{}{"users": mockUsers}
+	if strings.Contains(req.URL.Query().Get("expansions"), "referenced_tweets.id") {
+		includes["tweets"] = mockReferencedTweets(tweets)
+	}
+	body["includes"] = includes
+	return http.StatusOK, body
+}
+
+// mockReferencedTweets returns the fixture tweets the given tweets reply to,
+// quote or retweet
+func mockReferencedTweets(tweets []map[string]interface{}) []map[string]interface{} {
+	referenced := []map[string]interface{}{}
+	for _, tweet := range tweets {
+		refs, _ := tweet["referenced_tweets"].([]map[string]string)
+		for _, ref := range refs {
+			if found := findMockTweet(ref["id"]); found != nil {
+				referenced = append(referenced, found)
+			}
+		}
+	}
+	return referenced
+}
+
+func mockTweetList(tweets []map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"data":     tweets,
+		"includes": map[string]interface{}{"users": mockUsers, "tweets": mockReferencedTweets(tweets)},
+		"meta":     map[string]int{"result_count": len(tweets)},
+	}
+}
+
+// newMockHTTPClient returns an HTTP client answering the Twitter v2 API (and
+// the v1.1 trends, user search and media endpoints) from the fixtures above. Writes succeed
+// without changing any fixture.
+func newMockHTTPClient() *http.Client {
+	userList := mock.JSON(http.StatusOK, map[string]interface{}{
+		"data": mockUsers[1:],
+		"meta": map[string]int{"result_count": len(mockUsers) - 1},
+	})
+	return mock.NewClient(
+		mock.Route{Method: http.MethodGet, Pattern: "/2/users/me", Handler: mock.JSON(http.StatusOK, map[string]interface{}{"data": mockUsers[0]})},
+		mock.Route{Method: http.MethodGet, Pattern: "/2/users/by", Handler: func(req *http.Request) (int, interface{}) {
+			return http.StatusOK, map[string]interface{}{"data": mockUsersBy("username", strings.Split(req.URL.Query().Get("usernames"), ","))}
+		}},
+		mock.Route{Method: http.MethodGet, Pattern: "/2/users", Handler: func(req *http.Request) (int, interface{}) {
+			return http.StatusOK, map[string]interface{}{"data": mockUsersBy("id", strings.Split(req.URL.Query().Get("ids"), ","))}
+		}},
+		mock.Route{Method: http.MethodGet, Pattern: "/2/users/*/tweets", Handler: mock.JSON(http.StatusOK, mockTweetList(mockTweets))},
+		mock.Route{Method: http.MethodGet, Pattern: "/2/users/*/timelines/reverse_chronological", Handler: mock.JSON(http.StatusOK, mockTweetList(mockTweets))},
+		mock.Route{Method: http.MethodGet, Pattern: "/2/users/*/mentions", Handler: mock.JSON(http.StatusOK, mockTweetList(mockMentions))},
+		mock.Route{Method: http.MethodGet, Pattern: "/2/users/*/bookmarks", Handler: mock.JSON(http.StatusOK, mockTweetList(mockTweets[2:]))},
+		mock.Route{Method: http.MethodGet, Pattern: "/2/users/*/liked_tweets", Handler: mock.JSON(http.StatusOK, mockTweetList(mockMentions))},
+		mock.Route{Method: http.MethodGet, Pattern: "/2/spaces/by/creator_ids", Handler: mock.JSON(http.StatusOK, map[string]interface{}{"data": mockSpaces, "meta": map[string]int{"result_count": len(mockSpaces)}})},
+		mock.Route{Method: http.MethodGet, Pattern: "/2/tweets", Handler: mockTweetLookup},
+		mock.Route{Method: http.MethodGet, Pattern: "/2/tweets/*", Handler: mockTweetLookup},
+		mock.Route{Method: http.MethodGet, Pattern: "/2/tweets/search/recent", Handler: mock.JSON(http.StatusOK, mockTweetList(mockTweets))},
+		mock.Route{Method: http.MethodGet, Pattern: "/2/lists/*/tweets", Handler: mock.JSON(http.StatusOK, mockTweetList(mockTweets))},
+		mock.Route{Method: http.MethodGet, Pattern: "/2/users/*/followers", Handler: userList},
+		mock.Route{Method: http.MethodGet, Pattern: "/2/users/*/following", Handler: userList},
+		mock.Route{Method: http.MethodPost, Pattern: "/2/users/*/likes", Handler: mock.JSON(http.StatusOK, map[string]interface{}{"data": map[string]bool{"liked": true}})},
+		mock.Route{Method: http.MethodDelete, Pattern: "/2/users/*/likes/*", Handler: mock.JSON(http.StatusOK, map[string]interface{}{"data": map[string]bool{"liked": false}})},
+		mock.Route{Method: http.MethodPost, Pattern: "/2/users/*/retweets", Handler: mock.JSON(http.StatusOK, map[string]interface{}{"data": map[string]bool{"retweeted": true}})},
+		mock.Route{Method: http.MethodDelete, Pattern: "/2/users/*/retweets/*", Handler: mock.JSON(http.StatusOK, map[string]interface{}{"data": map[string]bool{"retweeted": false}})},
+		mock.Route{Method: http.MethodPost, Pattern: "/2/users/*/following", Handler: mock.JSON(http.StatusOK, map[string]interface{}{"data": map[string]bool{"following": true, "pending_follow": false}})},
+		mock.Route{Method: http.MethodDelete, Pattern: "/2/users/*/following/*", Handler: mock.JSON(http.StatusOK, map[string]interface{}{"data": map[string]bool{"following": false}})},
+		mock.Route{Method: http.MethodPost, Pattern: "/2/users/*/blocking", Handler: mock.JSON(http.StatusOK, map[string]interface{}{"data": map[string]bool{"blocking": true}})},
+		mock.Route{Method: http.MethodDelete, Pattern: "/2/users/*/blocking/*", Handler: mock.JSON(http.StatusOK, map[string]interface{}{"data": map[string]bool{"blocking": false}})},
+		mock.Route{Method: http.MethodPost, Pattern: "/2/users/*/muting", Handler: mock.JSON(http.StatusOK, map[string]interface{}{"data": map[string]bool{"muting": true}})},
+		mock.Route{Method: http.MethodDelete, Pattern: "/2/users/*/muting/*", Handler: mock.JSON(http.StatusOK, map[string]interface{}{"data": map[string]bool{"muting": false}})},
+		mock.Route{Method: http.MethodPost, Pattern: "/2/tweets", Handler: func(req *http.Request) (int, interface{}) {
+			var body struct {
+				Text string `json:"text"`
+			}
+			if err := mock.DecodeBody(req, &body); err != nil {
+				return http.StatusBadRequest, map[string]interface{}{"title": "Invalid Request", "detail": err.Error()}
+			}
+			id := fmt.Sprintf("4%018d", atomic.AddInt64(&mockTweetSeq, 1))
+			return http.StatusCreated, map[string]interface{}{"data": map[string]string{"id": id, "text": body.Text}}
+		}},
+		mock.Route{Method: http.MethodDelete, Pattern: "/2/tweets/*", Handler: mock.JSON(http.StatusOK, map[string]interface{}{"data": map[string]bool{"deleted": true}})},
+		mock.Route{Method: http.MethodPost, Pattern: "/2/lists", Handler: func(req *http.Request) (int, interface{}) {
+			var body struct {
+				Name string `json:"name"`
+			}
+			if err := mock.DecodeBody(req, &body); err != nil {
+				return http.StatusBadRequest, map[string]interface{}{"title": "Invalid Request", "detail": err.Error()}
+			}
+			return http.StatusCreated, map[string]interface{}{"data": map[string]string{"id": "6000000000000000001", "name": body.Name}}
+		}},
+		mock.Route{Method: http.MethodPost, Pattern: "/2/lists/*/members", Handler: mock.JSON(http.StatusOK, map[string]interface{}{"data": map[string]bool{"is_member": true}})},
+		mock.Route{Method: http.MethodDelete, Pattern: "/2/lists/*/members/*", Handler: mock.JSON(http.StatusOK, map[string]interface{}{"data": map[string]bool{"is_member": false}})},
+		mock.Route{Method: http.MethodGet, Pattern: "/1.1/trends/place.json", Handler: mock.JSON(http.StatusOK, mockTrends)},
+		mock.Route{Method: http.MethodGet, Pattern: "/1.1/users/search.json", Handler: func(req *http.Request) (int, interface{}) {
+			return http.StatusOK, mockUserSearch(req.URL.Query().Get("q"))
+		}},
+		mock.Route{Method: http.MethodPost, Pattern: "/1.1/media/upload.json", Handler: mock.JSON(http.StatusOK, map[string]string{"media_id_string": "5000000000000000001"})},
+	)
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("block_user and mute_user", func() {
+	BeforeEach(func() {
+		useMockClient(newMockHTTPClient(), defaultAPIHost)
+	})
+
+	It("blocks and unblocks a user", func() {
+		_, out, err := BlockUser(context.Background(), nil, BlockUserInput{TargetUserID: "1000000000000000002", Block: true})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(out).To(Equal(ActionOutput{Success: true, Message: "blocked"}))
+		_, out, err = BlockUser(context.Background(), nil, BlockUserInput{TargetUserID: "1000000000000000002"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(out).To(Equal(ActionOutput{Success: true, Message: "unblocked"}))
+	})
+
+	It("mutes and unmutes a user", func() {
+		_, out, err := MuteUser(context.Background(), nil, MuteUserInput{TargetUserID: "1000000000000000002", Mute: true})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(out).To(Equal(ActionOutput{Success: true, Message: "muted"}))
+		_, out, err = MuteUser(context.Background(), nil, MuteUserInput{TargetUserID: "1000000000000000002"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(out).To(Equal(ActionOutput{Success: true, Message: "unmuted"}))
+	})
+
+	It("requires user context", func() {
+		hasUserCtx = false
+		_, _, err := BlockUser(context.Background(), nil, BlockUserInput{TargetUserID: "1", Block: true})
+		Expect(err).To(MatchError(ContainSubstring("requires user context")))
+		_, _, err = MuteUser(context.Background(), nil, MuteUserInput{TargetUserID: "1", Mute: true})
+		Expect(err).To(MatchError(ContainSubstring("requires user context")))
+	})
+})
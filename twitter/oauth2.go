@@ -0,0 +1,250 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// oauth2TokenURL is the endpoint used to refresh OAuth 2.0 user tokens
+var oauth2TokenURL = "https://api.twitter.com/2/oauth2/token"
+
+// refreshMargin refreshes tokens slightly before they expire, so a request
+// does not race the expiry
+const refreshMargin = time.Minute
+
+// oauth2Token is an OAuth 2.0 user access token obtained with the
+// authorization code flow with PKCE
+type oauth2Token struct {
+	AccessToken  string    `json:"access_token"`
+	RefreshToken string    `json:"refresh_token,omitempty"`
+	Expiry       time.Time `json:"expiry,omitzero"`
+}
+
+// oauth2Transport authenticates requests with an OAuth 2.0 user access token
+// and refreshes it when it expires or the API rejects it. Twitter rotates
+// refresh tokens on every refresh, so the new pair is written to tokenPath,
+// when set, to survive restarts.
+type oauth2Transport struct {
+	clientID     string
+	clientSecret string
+	tokenPath    string
+	base         http.RoundTripper
+
+	mutex sync.Mutex
+	token oauth2Token
+}
+
+// newOAuth2TransportFromEnv builds the transport from TWITTER_OAUTH2_* env
+// vars. A token saved in TWITTER_OAUTH2_TOKEN_PATH by a previous refresh
+// takes precedence over the env tokens. It returns nil when no access token
+// is configured.
+func newOAuth2TransportFromEnv() (*oauth2Transport, error) {
+	t := &oauth2Transport{
+		clientID:     os.Getenv("TWITTER_CLIENT_ID"),
+		clientSecret: os.Getenv("TWITTER_CLIENT_SECRET"),
+		tokenPath:    os.Getenv("TWITTER_OAUTH2_TOKEN_PATH"),
+		base:         http.DefaultTransport,
+		token: oauth2Token{
+			AccessToken:  os.Getenv("TWITTER_OAUTH2_ACCESS_TOKEN"),
+			RefreshToken: os.Getenv("TWITTER_OAUTH2_REFRESH_TOKEN"),
+		},
+	}
+	if t.tokenPath != "" {
+		data, err := os.ReadFile(t.tokenPath)
+		switch {
+		case err == nil:
+			var saved oauth2Token
+			if err := json.Unmarshal(data, &saved); err != nil {
+				return nil, fmt.Errorf("parse %s: %w", t.tokenPath, err)
+			}
+			if saved.AccessToken != "" {
+				t.token = saved
+			}
+		case !os.IsNotExist(err):
+			return nil, fmt.Errorf("read %s: %w", t.tokenPath, err)
+		}
+	}
+	if t.token.AccessToken == "" {
+		return nil, nil
+	}
+	if t.token.RefreshToken != "" && t.clientID == "" {
+		return nil, fmt.Errorf("TWITTER_CLIENT_ID is required to refresh OAuth 2.0 tokens")
+	}
+	return t, nil
+}
+
+// canRefresh reports whether an expired token can be renewed
+func (t *oauth2Transport) canRefresh() bool {
+	return t.token.RefreshToken != "" && t.clientID != ""
+}
+
+// accessToken returns a valid access token, refreshing it first if it is
+// about to expire
+func (t *oauth2Transport) accessToken(req *http.Request) (string, error) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if !t.token.Expiry.IsZero() && time.Now().Add(refreshMargin).After(t.token.Expiry) && t.canRefresh() {
+		if err := t.refreshLocked(req); err != nil {
+			return "", err
+		}
+	}
+	return t.token.AccessToken, nil
+}
+
+// refreshAfterReject refreshes the token after the API rejected used, unless
+// a concurrent request already did. It reports whether a new token is
+// available.
+func (t *oauth2Transport) refreshAfterReject(req *http.Request, used string) (bool, error) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if t.token.AccessToken != used {
+		return true, nil
+	}
+	if !t.canRefresh() {
+		return false, nil
+	}
+	if err := t.refreshLocked(req); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// refreshLocked exchanges the refresh token for a new token pair. The caller
+// holds the mutex.
+func (t *oauth2Transport) refreshLocked(req *http.Request) error {
+	form := url.Values{}
+	form.Set("grant_type", "refresh_token")
+	form.Set("refresh_token", t.token.RefreshToken)
+	form.Set("client_id", t.clientID)
+	refreshReq, err := http.NewRequestWithContext(req.Context(), http.MethodPost, oauth2TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	refreshReq.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	// Confidential clients authenticate, public clients only pass client_id
+	if t.clientSecret != "" {
+		refreshReq.SetBasicAuth(url.QueryEscape(t.clientID), url.QueryEscape(t.clientSecret))
+	}
+	resp, err := t.base.RoundTrip(refreshReq)
+	if err != nil {
+		return fmt.Errorf("refresh OAuth 2.0 token: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("refresh OAuth 2.0 token: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("refresh OAuth 2.0 token: %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	var refreshed struct {
+		AccessToken  string `json:"access_token"`
+		RefreshToken string `json:"refresh_token"`
+		ExpiresIn    int    `json:"expires_in"`
+	}
+	if err := json.Unmarshal(body, &refreshed); err != nil {
+		return fmt.Errorf("refresh OAuth 2.0 token: decode: %w", err)
+	}
+	if refreshed.AccessToken == "" {
+		return fmt.Errorf("refresh OAuth 2.0 token: no access_token in response")
+	}
+
+	t.token.AccessToken = refreshed.AccessToken
+	if refreshed.RefreshToken != "" {
+		t.token.RefreshToken = refreshed.RefreshToken
+	}
+	t.token.Expiry = time.Time{}
+	if refreshed.ExpiresIn > 0 {
+		t.token.Expiry = time.Now().Add(time.Duration(refreshed.ExpiresIn) * time.Second)
+	}
+	debugLog("Twitter MCP: refreshed OAuth 2.0 user token")
+	if err := t.saveLocked(); err != nil {
+		// The refreshed token still works for this process
+		debugLogf("Warning: could not save OAuth 2.0 token: %v", err)
+	}
+	return nil
+}
+
+// saveLocked writes the token pair to tokenPath, if set
+func (t *oauth2Transport) saveLocked() error {
+	if t.tokenPath == "" {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(t.tokenPath), 0700); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(t.token, "", "  ")
+	if err != nil {
+		return err
+	}
+	tempFile := t.tokenPath + ".tmp"
+	if err := os.WriteFile(tempFile, data, 0600); err != nil {
+		return err
+	}
+	if err := os.Rename(tempFile, t.tokenPath); err != nil {
+		os.Remove(tempFile)
+		return err
+	}
+	return nil
+}
+
+// RoundTrip sends req with the current access token, and retries it once
+// with a refreshed token when the API answers 401 Unauthorized
+func (t *oauth2Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// Keep the body to replay it after a refresh
+	var body []byte
+	if req.Body != nil && req.GetBody == nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	send := func(token string) (*http.Response, error) {
+		clone := req.Clone(req.Context())
+		switch {
+		case body != nil:
+			clone.Body = io.NopCloser(bytes.NewReader(body))
+		case req.GetBody != nil:
+			b, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			clone.Body = b
+		}
+		clone.Header.Set("Authorization", "Bearer "+token)
+		return t.base.RoundTrip(clone)
+	}
+
+	token, err := t.accessToken(req)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := send(token)
+	if err != nil || resp.StatusCode != http.StatusUnauthorized {
+		return resp, err
+	}
+
+	refreshed, err := t.refreshAfterReject(req, token)
+	if err != nil || !refreshed {
+		if err != nil {
+			debugLogf("Warning: %v", err)
+		}
+		return resp, nil
+	}
+	resp.Body.Close()
+	t.mutex.Lock()
+	token = t.token.AccessToken
+	t.mutex.Unlock()
+	return send(token)
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("OAuth 2.0 user tokens", func() {
+	var (
+		api, tokenServer *httptest.Server
+		prevTokenURL     string
+		refreshes        atomic.Int32
+		tokenPath        string
+	)
+
+	BeforeEach(func() {
+		refreshes.Store(0)
+		// An API that only accepts the refreshed token
+		api = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Authorization") != "Bearer fresh-access" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			body, _ := io.ReadAll(r.Body)
+			w.Write(body)
+		}))
+		tokenServer = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			refreshes.Add(1)
+			r.ParseForm()
+			if r.Form.Get("grant_type") != "refresh_token" || r.Form.Get("refresh_token") != "old-refresh" || r.Form.Get("client_id") != "client" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			json.NewEncoder(w).Encode(map[string]any{
+				"access_token":  "fresh-access",
+				"refresh_token": "new-refresh",
+				"expires_in":    7200,
+			})
+		}))
+		prevTokenURL = oauth2TokenURL
+		oauth2TokenURL = tokenServer.URL
+		tokenPath = filepath.Join(GinkgoT().TempDir(), "token.json")
+	})
+
+	AfterEach(func() {
+		oauth2TokenURL = prevTokenURL
+		api.Close()
+		tokenServer.Close()
+	})
+
+	newTransport := func(token oauth2Token) *oauth2Transport {
+		return &oauth2Transport{clientID: "client", tokenPath: tokenPath, base: http.DefaultTransport, token: token}
+	}
+
+	It("refreshes a rejected token and replays the request", func() {
+		transport := newTransport(oauth2Token{AccessToken: "stale-access", RefreshToken: "old-refresh"})
+		resp, err := (&http.Client{Transport: transport}).Post(api.URL, "text/plain", strings.NewReader("payload"))
+		Expect(err).ToNot(HaveOccurred())
+		defer resp.Body.Close()
+		Expect(resp.StatusCode).To(Equal(http.StatusOK))
+		body, _ := io.ReadAll(resp.Body)
+		Expect(string(body)).To(Equal("payload"))
+		Expect(refreshes.Load()).To(Equal(int32(1)))
+
+		// The rotated refresh token is saved for the next start
+		data, err := os.ReadFile(tokenPath)
+		Expect(err).ToNot(HaveOccurred())
+		var saved oauth2Token
+		Expect(json.Unmarshal(data, &saved)).To(Succeed())
+		Expect(saved.AccessToken).To(Equal("fresh-access"))
+		Expect(saved.RefreshToken).To(Equal("new-refresh"))
+		Expect(saved.Expiry).To(BeTemporally(">", time.Now()))
+	})
+
+	It("refreshes an expired token before sending the request", func() {
+		transport := newTransport(oauth2Token{AccessToken: "stale-access", RefreshToken: "old-refresh", Expiry: time.Now().Add(-time.Hour)})
+		resp, err := (&http.Client{Transport: transport}).Get(api.URL)
+		Expect(err).ToNot(HaveOccurred())
+		resp.Body.Close()
+		Expect(resp.StatusCode).To(Equal(http.StatusOK))
+		Expect(refreshes.Load()).To(Equal(int32(1)))
+	})
+
+	It("returns the 401 when there is no refresh token", func() {
+		transport := newTransport(oauth2Token{AccessToken: "stale-access"})
+		resp, err := (&http.Client{Transport: transport}).Get(api.URL)
+		Expect(err).ToNot(HaveOccurred())
+		resp.Body.Close()
+		Expect(resp.StatusCode).To(Equal(http.StatusUnauthorized))
+		Expect(refreshes.Load()).To(Equal(int32(0)))
+	})
+
+	It("prefers the token saved by a previous refresh over the env tokens", func() {
+		Expect(os.WriteFile(tokenPath, []byte(`{"access_token":"fresh-access","refresh_token":"new-refresh"}`), 0600)).To(Succeed())
+		env := map[string]string{
+			"TWITTER_OAUTH2_ACCESS_TOKEN":  "stale-access",
+			"TWITTER_OAUTH2_REFRESH_TOKEN": "old-refresh",
+			"TWITTER_CLIENT_ID":            "client",
+			"TWITTER_OAUTH2_TOKEN_PATH":    tokenPath,
+		}
+		for name, value := range env {
+			prev, set := os.LookupEnv(name)
+			os.Setenv(name, value)
+			DeferCleanup(func() {
+				if set {
+					os.Setenv(name, prev)
+				} else {
+					os.Unsetenv(name)
+				}
+			})
+		}
+
+		transport, err := newOAuth2TransportFromEnv()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(transport.token.AccessToken).To(Equal("fresh-access"))
+		Expect(transport.token.RefreshToken).To(Equal("new-refresh"))
+	})
+})
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Pagination", func() {
+	var (
+		mutex   sync.Mutex
+		queries []url.Values
+		body    string
+	)
+
+	BeforeEach(func() {
+		queries = nil
+		body = `{"data":[{"id":"1","text":"first"}],"meta":{"result_count":1,"next_token":"page-2"}}`
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			mutex.Lock()
+			queries = append(queries, r.URL.Query())
+			mutex.Unlock()
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(body))
+		}))
+
+		DeferCleanup(server.Close)
+		useMockClient(http.DefaultClient, server.URL)
+	})
+
+	lastQuery := func() url.Values {
+		mutex.Lock()
+		defer mutex.Unlock()
+		Expect(queries).NotTo(BeEmpty())
+		return queries[len(queries)-1]
+	}
+
+	It("returns and follows the next_token of get_tweets", func() {
+		_, out, err := GetTweets(context.Background(), nil, GetTweetsInput{UserID: "42"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(out.Count).To(Equal(1))
+		Expect(out.NextToken).To(Equal("page-2"))
+
+		_, _, err = GetTweets(context.Background(), nil, GetTweetsInput{UserID: "42", NextToken: out.NextToken})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(lastQuery().Get("pagination_token")).To(Equal("page-2"))
+	})
+
+	It("returns and follows the next_token of search_tweets", func() {
+		_, out, err := SearchTweets(context.Background(), nil, SearchTweetsInput{Query: "golang"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(out.NextToken).To(Equal("page-2"))
+
+		_, _, err = SearchTweets(context.Background(), nil, SearchTweetsInput{Query: "golang", NextToken: out.NextToken})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(lastQuery().Get("next_token")).To(Equal("page-2"))
+	})
+
+	It("returns and follows the next_token of get_bookmarks", func() {
+		prevUserCtx, prevAuthUserID := hasUserCtx, authUserID
+		DeferCleanup(func() { hasUserCtx, authUserID = prevUserCtx, prevAuthUserID })
+		hasUserCtx, authUserID = true, "42"
+
+		_, out, err := GetBookmarks(context.Background(), nil, GetBookmarksInput{MaxResults: 10})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(out.NextToken).To(Equal("page-2"))
+		Expect(lastQuery().Get("max_results")).To(Equal("10"))
+
+		_, _, err = GetBookmarks(context.Background(), nil, GetBookmarksInput{NextToken: out.NextToken})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(lastQuery().Get("pagination_token")).To(Equal("page-2"))
+	})
+
+	It("handles a last page without meta", func() {
+		body = `{"data":[]}`
+		_, tweets, err := GetTweets(context.Background(), nil, GetTweetsInput{UserID: "42"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(tweets.NextToken).To(BeEmpty())
+
+		_, search, err := SearchTweets(context.Background(), nil, SearchTweetsInput{Query: "golang"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(search.NextToken).To(BeEmpty())
+	})
+})
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+
+	twitter "github.com/g8rswimmer/go-twitter/v2"
+	"github.com/mudler/mcps/pkg/mock"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("post_tweet polls", func() {
+	var (
+		mutex sync.Mutex
+		// posted is the poll of the last created tweet
+		posted *twitter.CreateTweetPoll
+	)
+
+	BeforeEach(func() {
+		httpClient := mock.NewClient(
+			mock.Route{Method: http.MethodPost, Pattern: "/2/tweets", Handler: func(req *http.Request) (int, interface{}) {
+				var body twitter.CreateTweetRequest
+				if err := mock.DecodeBody(req, &body); err != nil {
+					return http.StatusBadRequest, map[string]interface{}{"title": "Invalid Request", "detail": err.Error()}
+				}
+				mutex.Lock()
+				defer mutex.Unlock()
+				posted = body.Poll
+				return http.StatusCreated, map[string]interface{}{"data": map[string]string{"id": "4000000000000000100", "text": body.Text}}
+			}},
+		)
+
+		useMockClient(httpClient, defaultAPIHost)
+		posted = nil
+	})
+
+	lastPoll := func() *twitter.CreateTweetPoll {
+		mutex.Lock()
+		defer mutex.Unlock()
+		return posted
+	}
+
+	It("posts a tweet with a poll", func() {
+		_, out, err := PostTweet(context.Background(), nil, PostTweetInput{
+			Text: "Tabs or spaces?",
+			Poll: &PollIn{Options: []string{"Tabs", "Spaces"}, DurationMinutes: 60},
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(out.TweetID).To(Equal("4000000000000000100"))
+		Expect(out.Text).To(Equal("Tabs or spaces?"))
+		Expect(lastPoll()).To(Equal(&twitter.CreateTweetPoll{Options: []string{"Tabs", "Spaces"}, DurationMinutes: 60}))
+	})
+
+	It("posts a poll in reply to a tweet", func() {
+		_, _, err := PostTweet(context.Background(), nil, PostTweetInput{
+			Text:             "Which one?",
+			InReplyToTweetID: "2000000000000000001",
+			Poll:             &PollIn{Options: []string{"A", "B", "C"}, DurationMinutes: 5},
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(lastPoll().Options).To(HaveLen(3))
+	})
+
+	DescribeTable("rejects invalid polls",
+		func(input PostTweetInput, message string) {
+			_, _, err := PostTweet(context.Background(), nil, input)
+			Expect(err).To(MatchError(ContainSubstring(message)))
+			Expect(lastPoll()).To(BeNil())
+		},
+		Entry("with one option", PostTweetInput{Text: "?", Poll: &PollIn{Options: []string{"A"}, DurationMinutes: 60}}, "needs 2 to 4 options, got 1"),
+		Entry("with five options", PostTweetInput{Text: "?", Poll: &PollIn{Options: []string{"A", "B", "C", "D", "E"}, DurationMinutes: 60}}, "needs 2 to 4 options, got 5"),
+		Entry("with an empty option", PostTweetInput{Text: "?", Poll: &PollIn{Options: []string{"A", " "}, DurationMinutes: 60}}, "option 2 is empty"),
+		Entry("with a long option", PostTweetInput{Text: "?", Poll: &PollIn{Options: []string{"A", strings.Repeat("b", 26)}, DurationMinutes: 60}}, "at most 25"),
+		Entry("lasting too little", PostTweetInput{Text: "?", Poll: &PollIn{Options: []string{"A", "B"}, DurationMinutes: 4}}, "between 5 and 10080"),
+		Entry("lasting too long", PostTweetInput{Text: "?", Poll: &PollIn{Options: []string{"A", "B"}, DurationMinutes: 10081}}, "between 5 and 10080"),
+		Entry("with media", PostTweetInput{Text: "?", MediaIDs: []string{"1"}, Poll: &PollIn{Options: []string{"A", "B"}, DurationMinutes: 60}}, "cannot be combined"),
+		Entry("without text", PostTweetInput{Poll: &PollIn{Options: []string{"A", "B"}, DurationMinutes: 60}}, "text or media_ids required"),
+	)
+})
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// maxRateLimitWait caps how long TWITTER_RATE_LIMIT_WAIT waits for a rate
+// limit to reset. Limits resetting later fail right away.
+const maxRateLimitWait = 2 * time.Minute
+
+// rateLimitWait makes read requests wait for the rate limit to reset and
+// retry once, set with TWITTER_RATE_LIMIT_WAIT
+var rateLimitWait bool
+
+// rateLimitError is returned for 429 responses
+type rateLimitError struct {
+	limit     int
+	remaining int
+	// reset is when the limit resets, zero when the response did not say
+	reset time.Time
+}
+
+func (e *rateLimitError) Error() string {
+	if e.reset.IsZero() {
+		return "Twitter API rate limit exceeded (429), retry later"
+	}
+	seconds := int(time.Until(e.reset).Round(time.Second).Seconds())
+	if seconds < 0 {
+		seconds = 0
+	}
+	msg := fmt.Sprintf("Twitter API rate limit exceeded (429): resets in %ds at %s", seconds, e.reset.UTC().Format(time.RFC3339))
+	if e.limit > 0 {
+		msg += fmt.Sprintf(", %d of %d requests remaining", e.remaining, e.limit)
+	}
+	return msg
+}
+
+// rateLimitFromHeader reads the x-rate-limit-* headers of a response
+func rateLimitFromHeader(header http.Header) *rateLimitError {
+	e := &rateLimitError{}
+	e.limit, _ = strconv.Atoi(header.Get("x-rate-limit-limit"))
+	e.remaining, _ = strconv.Atoi(header.Get("x-rate-limit-remaining"))
+	if reset, err := strconv.ParseInt(header.Get("x-rate-limit-reset"), 10, 64); err == nil {
+		e.reset = time.Unix(reset, 0)
+	}
+	return e
+}
+
+// rateLimitTransport turns 429 responses into a rateLimitError telling when
+// the limit resets. With rateLimitWait, GET requests wait up to
+// maxRateLimitWait for the reset and are retried once.
+type rateLimitTransport struct {
+	base http.RoundTripper
+}
+
+// withRateLimits returns a copy of c sending its requests through a
+// rateLimitTransport
+func withRateLimits(c *http.Client) *http.Client {
+	base := c.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	wrapped := *c
+	wrapped.Transport = &rateLimitTransport{base: base}
+	return &wrapped
+}
+
+func (t *rateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.roundTrip(req)
+	limitErr, ok := err.(*rateLimitError)
+	if !ok || !rateLimitWait || req.Method != http.MethodGet || limitErr.reset.IsZero() {
+		return resp, err
+	}
+
+	wait := time.Until(limitErr.reset)
+	if wait > maxRateLimitWait {
+		return nil, err
+	}
+	debugLogf("Twitter MCP: rate limited on %s, retrying in %s", req.URL.Path, wait.Round(time.Second))
+	if wait > 0 {
+		timer := time.NewTimer(wait)
+		defer timer.Stop()
+		select {
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		case <-timer.C:
+		}
+	}
+	return t.roundTrip(req)
+}
+
+// roundTrip sends the request once, logging when the limit runs out
+func (t *rateLimitTransport) roundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusTooManyRequests {
+		if resp.Header.Get("x-rate-limit-remaining") == "0" {
+			debugLogf("Twitter MCP: rate limit of %s exhausted: %v", req.URL.Path, rateLimitFromHeader(resp.Header).reset)
+		}
+		return resp, nil
+	}
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+	return nil, rateLimitFromHeader(resp.Header)
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("rate limits", func() {
+	var (
+		mutex    sync.Mutex
+		requests int
+		// limited is the number of requests answered with a 429
+		limited int
+		resetIn time.Duration
+	)
+
+	BeforeEach(func() {
+		requests, limited, resetIn = 0, 1, time.Second
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			mutex.Lock()
+			defer mutex.Unlock()
+			requests++
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("x-rate-limit-limit", "900")
+			w.Header().Set("x-rate-limit-reset", strconv.FormatInt(time.Now().Add(resetIn).Unix(), 10))
+			if requests <= limited {
+				w.Header().Set("x-rate-limit-remaining", "0")
+				w.WriteHeader(http.StatusTooManyRequests)
+				w.Write([]byte(`{"title":"Too Many Requests","detail":"Too Many Requests","type":"about:blank","status":429}`))
+				return
+			}
+			w.Header().Set("x-rate-limit-remaining", "899")
+			if r.Method == http.MethodPost {
+				w.Write([]byte(`{"data":{"liked":true}}`))
+				return
+			}
+			w.Write([]byte(`{"data":{"id":"1","text":"hello","author_id":"2"}}`))
+		}))
+
+		DeferCleanup(server.Close)
+		DeferCleanup(func(wait bool) { rateLimitWait = wait }, rateLimitWait)
+		useMockClient(withRateLimits(http.DefaultClient), server.URL)
+		rateLimitWait, authUserID = false, "2"
+	})
+
+	count := func() int {
+		mutex.Lock()
+		defer mutex.Unlock()
+		return requests
+	}
+
+	It("reports when the limit resets", func() {
+		resetIn = 42 * time.Second
+		_, _, err := GetTweet(context.Background(), nil, GetTweetInput{TweetID: "1"})
+		Expect(err).To(MatchError(MatchRegexp(`rate limit exceeded \(429\): resets in 4[12]s at .*, 0 of 900 requests remaining`)))
+		Expect(count()).To(Equal(1))
+	})
+
+	It("waits for the reset and retries reads once with TWITTER_RATE_LIMIT_WAIT", func() {
+		rateLimitWait = true
+		_, out, err := GetTweet(context.Background(), nil, GetTweetInput{TweetID: "1"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(out.Tweet.Text).To(Equal("hello"))
+		Expect(count()).To(Equal(2))
+	})
+
+	It("gives up when the retry is rate limited too", func() {
+		rateLimitWait, limited = true, 2
+		_, _, err := GetTweet(context.Background(), nil, GetTweetInput{TweetID: "1"})
+		Expect(err).To(MatchError(ContainSubstring("rate limit exceeded")))
+		Expect(count()).To(Equal(2))
+	})
+
+	It("does not wait for limits resetting later than the cap", func() {
+		rateLimitWait, resetIn = true, maxRateLimitWait+time.Minute
+		_, _, err := GetTweet(context.Background(), nil, GetTweetInput{TweetID: "1"})
+		Expect(err).To(MatchError(ContainSubstring("rate limit exceeded")))
+		Expect(count()).To(Equal(1))
+	})
+
+	It("does not retry writes", func() {
+		rateLimitWait = true
+		_, out, err := LikeTweet(context.Background(), nil, LikeTweetInput{TweetID: "1", Like: true})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(out.Success).To(BeFalse())
+		Expect(out.Message).To(ContainSubstring("rate limit exceeded"))
+		Expect(count()).To(Equal(1))
+	})
+})
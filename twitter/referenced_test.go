@@ -0,0 +1,42 @@
+package main
+
+import (
+	"context"
+
+	twitter "github.com/g8rswimmer/go-twitter/v2"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("referenced tweets", func() {
+	BeforeEach(func() {
+		useMockClient(newMockHTTPClient(), defaultAPIHost)
+	})
+
+	reply := ReferencedTweetOut{Type: "replied_to", ID: "3000000000000000001", Text: "@mcp_agent the new release works great"}
+
+	It("tells replies apart in get_tweets", func() {
+		_, out, err := GetTweets(context.Background(), nil, GetTweetsInput{UserID: mockUserID})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(out.Tweets).To(HaveLen(3))
+		Expect(out.Tweets[0].Referenced).To(BeEmpty())
+		Expect(out.Tweets[1].Referenced).To(ConsistOf(reply))
+	})
+
+	It("tells replies apart in get_timeline", func() {
+		_, out, err := GetTimeline(context.Background(), nil, GetTimelineInput{TimelineType: "user"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(out.Tweets[1].Referenced).To(ConsistOf(reply))
+	})
+
+	It("tells replies apart in search_tweets", func() {
+		_, out, err := SearchTweets(context.Background(), nil, SearchTweetsInput{Query: "golang"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(out.Tweets[1].Referenced).To(ConsistOf(reply))
+	})
+
+	It("keeps the reference when the referenced tweet is not included", func() {
+		t := &twitter.TweetObj{ID: "1", Text: "quoting", ReferencedTweets: []*twitter.TweetReferencedTweetObj{{Type: "quoted", ID: "2"}}}
+		Expect(tweetFromObj(t, nil).Referenced).To(ConsistOf(ReferencedTweetOut{Type: "quoted", ID: "2"}))
+	})
+})
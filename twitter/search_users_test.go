@@ -0,0 +1,31 @@
+package main
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("search_users", func() {
+	BeforeEach(func() {
+		prevV1Client := v1Client
+		DeferCleanup(func() { v1Client = prevV1Client })
+		v1Client = newMockHTTPClient()
+	})
+
+	It("returns the matching users with their follower counts", func() {
+		_, out, err := SearchUsers(context.Background(), nil, SearchUsersInput{Query: "example"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(out.Count).To(Equal(2))
+		Expect(out.Page).To(Equal(1))
+		Expect(out.Users[0].Username).To(Equal("ada_example"))
+		Expect(out.Users[0].PublicMetrics).To(HaveKeyWithValue("followers_count", 5400))
+	})
+
+	It("requires OAuth 1.0a", func() {
+		v1Client = nil
+		_, _, err := SearchUsers(context.Background(), nil, SearchUsersInput{Query: "example"})
+		Expect(err).To(MatchError(ContainSubstring("requires OAuth 1.0a")))
+	})
+})
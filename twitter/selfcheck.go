@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	twitter "github.com/g8rswimmer/go-twitter/v2"
+	"github.com/mudler/mcps/pkg/mock"
+	"github.com/mudler/mcps/pkg/selfcheck"
+)
+
+// selfChecks reports the credentials found by InitClientFromEnv and whether
+// the API accepts them
+func selfChecks(initialized bool) []selfcheck.Check {
+	hosts := selfcheck.Value("hosts", apiHost+", "+uploadHost, hostErr)
+	if hostErr != nil {
+		// The credentials are not read when the hosts are invalid
+		return []selfcheck.Check{hosts}
+	}
+
+	credentialsErr := oauth2Err
+	if credentialsErr == nil && !initialized {
+		credentialsErr = fmt.Errorf("set TWITTER_BEARER_TOKEN, TWITTER_OAUTH2_ACCESS_TOKEN or all of TWITTER_API_KEY, TWITTER_API_SECRET, TWITTER_ACCESS_TOKEN, TWITTER_ACCESS_SECRET")
+	}
+	checks := []selfcheck.Check{hosts, selfcheck.Value("credentials", authMode, credentialsErr)}
+
+	switch {
+	case !initialized:
+	case mock.Enabled("TWITTER"):
+		checks = append(checks, selfcheck.Skipped("api", "TWITTER_MOCK enabled"))
+	default:
+		checks = append(checks, selfcheck.Check{Name: "api", Run: checkAPI})
+	}
+	return append(checks, selfcheck.Optional(selfcheck.File("TWITTER_SNAPSHOT_PATH", snapshots.filePath())))
+}
+
+// checkAPI looks up the authenticated user, or a well-known account with an
+// app-only bearer token
+func checkAPI(ctx context.Context) (string, error) {
+	if hasUserCtx {
+		resp, err := client.AuthUserLookup(ctx, twitter.UserLookupOpts{})
+		if err != nil {
+			return "", fmt.Errorf("auth user lookup: %w", err)
+		}
+		if resp.Raw == nil || len(resp.Raw.Users) == 0 {
+			return "", fmt.Errorf("auth user lookup returned no user")
+		}
+		return "authenticated as @" + resp.Raw.Users[0].UserName, nil
+	}
+	resp, err := client.UserNameLookup(ctx, []string{"X"}, twitter.UserLookupOpts{})
+	if err != nil {
+		return "", fmt.Errorf("user lookup: %w", err)
+	}
+	if resp.Raw == nil || len(resp.Raw.Users) == 0 {
+		return "", fmt.Errorf("user lookup returned no user")
+	}
+	return "bearer token accepted", nil
+}
@@ -0,0 +1,305 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	twitter "github.com/g8rswimmer/go-twitter/v2"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+const (
+	defaultSnapshotPath    = "/data/twitter-snapshots.json"
+	defaultSnapshotLimit   = 1000
+	maxSnapshotLimit       = 5000
+	maxSnapshotsPerAccount = 100
+)
+
+// RelationshipSnapshot is the followers or following list of a user at a point in time
+type RelationshipSnapshot struct {
+	ID      int       `json:"id"`
+	TakenAt time.Time `json:"taken_at"`
+	Users   []UserOut `json:"users"`
+}
+
+type SnapshotRelationshipsInput struct {
+	UserID     string `json:"user_id,omitempty" jsonschema:"user ID (default: the authenticated user)"`
+	Type       string `json:"type" jsonschema:"followers or following"`
+	MaxResults int    `json:"max_results,omitempty" jsonschema:"max users to store, fetched page by page (default 1000, cap 5000)"`
+}
+
+type SnapshotRelationshipsOutput struct {
+	SnapshotID int       `json:"snapshot_id"`
+	UserID     string    `json:"user_id"`
+	Type       string    `json:"type"`
+	TakenAt    time.Time `json:"taken_at"`
+	Count      int       `json:"count"`
+	Snapshots  int       `json:"snapshots" jsonschema:"number of snapshots stored for this user and type"`
+}
+
+type DiffRelationshipsInput struct {
+	UserID         string `json:"user_id,omitempty" jsonschema:"user ID (default: the authenticated user)"`
+	Type           string `json:"type" jsonschema:"followers or following"`
+	BaseSnapshotID int    `json:"base_snapshot_id,omitempty" jsonschema:"snapshot to compare the latest one against (default: the snapshot before the latest)"`
+}
+
+type SnapshotInfo struct {
+	ID      int       `json:"id"`
+	TakenAt time.Time `json:"taken_at"`
+	Count   int       `json:"count"`
+}
+
+type DiffRelationshipsOutput struct {
+	UserID    string       `json:"user_id"`
+	Type      string       `json:"type"`
+	From      SnapshotInfo `json:"from"`
+	To        SnapshotInfo `json:"to"`
+	New       []UserOut    `json:"new"`
+	Lost      []UserOut    `json:"lost"`
+	NewCount  int          `json:"new_count"`
+	LostCount int          `json:"lost_count"`
+	NetChange int          `json:"net_change"`
+}
+
+// snapshotStore persists relationship snapshots in a single JSON file keyed
+// by "<user_id>/<type>", keeping the most recent maxSnapshotsPerAccount each
+type snapshotStore struct {
+	mutex sync.Mutex
+}
+
+var snapshots = &snapshotStore{}
+
+// filePath returns TWITTER_SNAPSHOT_PATH or the default location
+func (s *snapshotStore) filePath() string {
+	if path := os.Getenv("TWITTER_SNAPSHOT_PATH"); path != "" {
+		return path
+	}
+	return defaultSnapshotPath
+}
+
+func (s *snapshotStore) load() (map[string][]RelationshipSnapshot, error) {
+	all := map[string][]RelationshipSnapshot{}
+	data, err := os.ReadFile(s.filePath())
+	if os.IsNotExist(err) {
+		return all, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read snapshots: %w", err)
+	}
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &all); err != nil {
+			return nil, fmt.Errorf("parse snapshots: %w", err)
+		}
+	}
+	return all, nil
+}
+
+func (s *snapshotStore) save(all map[string][]RelationshipSnapshot) error {
+	path := s.filePath()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("create snapshot directory: %w", err)
+	}
+	data, err := json.MarshalIndent(all, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal snapshots: %w", err)
+	}
+	tempFile := path + ".tmp"
+	if err := os.WriteFile(tempFile, data, 0644); err != nil {
+		return fmt.Errorf("write snapshots: %w", err)
+	}
+	if err := os.Rename(tempFile, path); err != nil {
+		os.Remove(tempFile)
+		return fmt.Errorf("write snapshots: %w", err)
+	}
+	return nil
+}
+
+// add stores a new snapshot and returns it with the number now kept for key
+func (s *snapshotStore) add(key string, users []UserOut, takenAt time.Time) (RelationshipSnapshot, int, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	all, err := s.load()
+	if err != nil {
+		return RelationshipSnapshot{}, 0, err
+	}
+	list := all[key]
+	snapshot := RelationshipSnapshot{ID: 1, TakenAt: takenAt, Users: users}
+	if len(list) > 0 {
+		snapshot.ID = list[len(list)-1].ID + 1
+	}
+	list = append(list, snapshot)
+	if len(list) > maxSnapshotsPerAccount {
+		list = list[len(list)-maxSnapshotsPerAccount:]
+	}
+	all[key] = list
+	if err := s.save(all); err != nil {
+		return RelationshipSnapshot{}, 0, err
+	}
+	return snapshot, len(list), nil
+}
+
+func (s *snapshotStore) list(key string) ([]RelationshipSnapshot, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	all, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+	return all[key], nil
+}
+
+// relationshipTarget validates the type and defaults the user to the
+// authenticated one, returning the snapshot key
+func relationshipTarget(userID, typ string) (string, string, string, error) {
+	typ = strings.ToLower(strings.TrimSpace(typ))
+	if typ != "followers" && typ != "following" {
+		return "", "", "", fmt.Errorf("type must be followers or following")
+	}
+	if userID == "" {
+		userID = authUserID
+	}
+	if userID == "" {
+		return "", "", "", fmt.Errorf("user_id required (auth user ID not resolved)")
+	}
+	return userID, typ, userID + "/" + typ, nil
+}
+
+// fetchAllRelationships pages through the followers or following of a user
+// until limit users are collected or the list ends
+func fetchAllRelationships(ctx context.Context, userID, typ string, limit int) ([]UserOut, error) {
+	users := []UserOut{}
+	token := ""
+	for len(users) < limit {
+		pageSize := capMax(limit-len(users), 1000)
+		var raw *twitter.UserRaw
+		next := ""
+		if typ == "followers" {
+			resp, err := client.UserFollowersLookup(ctx, userID, twitter.UserFollowersLookupOpts{MaxResults: pageSize, PaginationToken: token})
+			if err != nil {
+				return nil, fmt.Errorf("followers: %w", err)
+			}
+			raw = resp.Raw
+			if resp.Meta != nil {
+				next = resp.Meta.NextToken
+			}
+		} else {
+			resp, err := client.UserFollowingLookup(ctx, userID, twitter.UserFollowingLookupOpts{MaxResults: pageSize, PaginationToken: token})
+			if err != nil {
+				return nil, fmt.Errorf("following: %w", err)
+			}
+			raw = resp.Raw
+			if resp.Meta != nil {
+				next = resp.Meta.NextToken
+			}
+		}
+		if raw != nil {
+			for _, u := range raw.Users {
+				users = append(users, userFromObj(u))
+			}
+		}
+		if next == "" {
+			break
+		}
+		token = next
+	}
+	if len(users) > limit {
+		users = users[:limit]
+	}
+	return users, nil
+}
+
+func SnapshotRelationships(ctx context.Context, req *mcp.CallToolRequest, input SnapshotRelationshipsInput) (*mcp.CallToolResult, SnapshotRelationshipsOutput, error) {
+	userID, typ, key, err := relationshipTarget(input.UserID, input.Type)
+	if err != nil {
+		return nil, SnapshotRelationshipsOutput{}, err
+	}
+	limit := capMax(input.MaxResults, maxSnapshotLimit)
+	if input.MaxResults <= 0 {
+		limit = defaultSnapshotLimit
+	}
+	users, err := fetchAllRelationships(ctx, userID, typ, limit)
+	if err != nil {
+		return nil, SnapshotRelationshipsOutput{}, err
+	}
+	snapshot, kept, err := snapshots.add(key, users, time.Now().UTC())
+	if err != nil {
+		return nil, SnapshotRelationshipsOutput{}, err
+	}
+	return nil, SnapshotRelationshipsOutput{
+		SnapshotID: snapshot.ID,
+		UserID:     userID,
+		Type:       typ,
+		TakenAt:    snapshot.TakenAt,
+		Count:      len(users),
+		Snapshots:  kept,
+	}, nil
+}
+
+func DiffRelationships(ctx context.Context, req *mcp.CallToolRequest, input DiffRelationshipsInput) (*mcp.CallToolResult, DiffRelationshipsOutput, error) {
+	userID, typ, key, err := relationshipTarget(input.UserID, input.Type)
+	if err != nil {
+		return nil, DiffRelationshipsOutput{}, err
+	}
+	list, err := snapshots.list(key)
+	if err != nil {
+		return nil, DiffRelationshipsOutput{}, err
+	}
+	if len(list) < 2 {
+		return nil, DiffRelationshipsOutput{}, fmt.Errorf("need at least two %s snapshots for user %s, found %d; call snapshot_relationships first", typ, userID, len(list))
+	}
+
+	latest := list[len(list)-1]
+	base := list[len(list)-2]
+	if input.BaseSnapshotID != 0 {
+		found := false
+		for _, s := range list {
+			if s.ID == input.BaseSnapshotID {
+				base, found = s, true
+				break
+			}
+		}
+		if !found {
+			return nil, DiffRelationshipsOutput{}, fmt.Errorf("snapshot %d not found for user %s (%s)", input.BaseSnapshotID, userID, typ)
+		}
+	}
+
+	before := make(map[string]bool, len(base.Users))
+	for _, u := range base.Users {
+		before[u.ID] = true
+	}
+	after := make(map[string]bool, len(latest.Users))
+	for _, u := range latest.Users {
+		after[u.ID] = true
+	}
+
+	out := DiffRelationshipsOutput{
+		UserID: userID,
+		Type:   typ,
+		From:   SnapshotInfo{ID: base.ID, TakenAt: base.TakenAt, Count: len(base.Users)},
+		To:     SnapshotInfo{ID: latest.ID, TakenAt: latest.TakenAt, Count: len(latest.Users)},
+		New:    []UserOut{},
+		Lost:   []UserOut{},
+	}
+	for _, u := range latest.Users {
+		if !before[u.ID] {
+			out.New = append(out.New, u)
+		}
+	}
+	for _, u := range base.Users {
+		if !after[u.ID] {
+			out.Lost = append(out.Lost, u)
+		}
+	}
+	out.NewCount = len(out.New)
+	out.LostCount = len(out.Lost)
+	out.NetChange = len(latest.Users) - len(base.Users)
+	return nil, out, nil
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	twitter "github.com/g8rswimmer/go-twitter/v2"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+type GetSpacesInput struct {
+	UserID string `json:"user_id,omitempty" jsonschema:"ID of the user hosting the Spaces (default: the authenticated user)"`
+	State  string `json:"state,omitempty" jsonschema:"live or scheduled to keep only those Spaces (default: both)"`
+}
+
+type SpaceOut struct {
+	ID               string   `json:"id"`
+	Title            string   `json:"title,omitempty"`
+	State            string   `json:"state" jsonschema:"live or scheduled"`
+	ScheduledStart   string   `json:"scheduled_start,omitempty" jsonschema:"when a scheduled Space is set to start"`
+	StartedAt        string   `json:"started_at,omitempty" jsonschema:"when a live Space started"`
+	ParticipantCount int      `json:"participant_count,omitempty"`
+	Lang             string   `json:"lang,omitempty"`
+	HostIDs          []string `json:"host_ids,omitempty"`
+}
+
+type GetSpacesOutput struct {
+	UserID string     `json:"user_id"`
+	Spaces []SpaceOut `json:"spaces" jsonschema:"the live and scheduled Spaces of the user, empty when there are none"`
+	Count  int        `json:"count"`
+}
+
+// GetSpaces lists the live and scheduled Spaces a user created, the
+// authenticated user by default. Ended Spaces are not returned by the API.
+func GetSpaces(ctx context.Context, req *mcp.CallToolRequest, input GetSpacesInput) (*mcp.CallToolResult, GetSpacesOutput, error) {
+	switch input.State {
+	case "", string(twitter.SpaceStateLive), string(twitter.SpaceStateScheduled):
+	default:
+		return nil, GetSpacesOutput{}, fmt.Errorf("state must be live or scheduled, got %q", input.State)
+	}
+	userID := input.UserID
+	if userID == "" {
+		if !hasUserCtx {
+			return nil, GetSpacesOutput{}, fmt.Errorf("user_id required (no user context to default to the authenticated user)")
+		}
+		if authUserID == "" {
+			return nil, GetSpacesOutput{}, fmt.Errorf("auth user ID not resolved (rate limited or lookup failed); try again later or pass user_id")
+		}
+		userID = authUserID
+	}
+	resp, err := client.SpacesByCreatorLookup(ctx, []string{userID}, twitter.SpacesByCreatorLookupOpts{
+		SpaceFields: []twitter.SpaceField{
+			twitter.SpaceFieldTitle, twitter.SpaceFieldState, twitter.SpaceFieldScheduledStart, twitter.SpaceFieldStartedAt,
+			twitter.SpaceFieldParticipantCount, twitter.SpaceFieldLang, twitter.SpaceFieldHostIDs,
+		},
+	})
+	if err != nil {
+		return nil, GetSpacesOutput{}, fmt.Errorf("spaces: %w", err)
+	}
+	// Accounts without Spaces get a response without data
+	spaces := []SpaceOut{}
+	if resp.Raw != nil {
+		for _, s := range resp.Raw.Spaces {
+			if s == nil || (input.State != "" && s.State != input.State) {
+				continue
+			}
+			spaces = append(spaces, SpaceOut{
+				ID:               s.ID,
+				Title:            s.Title,
+				State:            s.State,
+				ScheduledStart:   s.ScheduledStart,
+				StartedAt:        s.StartedAt,
+				ParticipantCount: s.ParticipantCount,
+				Lang:             s.Lang,
+				HostIDs:          s.HostIDs,
+			})
+		}
+	}
+	return nil, GetSpacesOutput{UserID: userID, Spaces: spaces, Count: len(spaces)}, nil
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+
+	twitter "github.com/g8rswimmer/go-twitter/v2"
+	"github.com/mudler/mcps/pkg/mock"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("create_thread", func() {
+	var (
+		mutex sync.Mutex
+		// replies are the in_reply_to_tweet_id of the created tweets, in order
+		replies []string
+		// failAt is the 1-based number of the tweet creation that fails, 0 for none
+		failAt int
+	)
+
+	BeforeEach(func() {
+		replies, failAt = nil, 0
+		httpClient := mock.NewClient(
+			mock.Route{Method: http.MethodPost, Pattern: "/2/tweets", Handler: func(req *http.Request) (int, interface{}) {
+				var body twitter.CreateTweetRequest
+				if err := mock.DecodeBody(req, &body); err != nil {
+					return http.StatusBadRequest, map[string]interface{}{"title": "Invalid Request", "detail": err.Error()}
+				}
+				mutex.Lock()
+				defer mutex.Unlock()
+				if len(replies)+1 == failAt {
+					return http.StatusForbidden, map[string]interface{}{"title": "Forbidden", "detail": "duplicate content"}
+				}
+				replyTo := ""
+				if body.Reply != nil {
+					replyTo = body.Reply.InReplyToTweetID
+				}
+				replies = append(replies, replyTo)
+				return http.StatusCreated, map[string]interface{}{"data": map[string]string{"id": fmt.Sprintf("40000000000000002%02d", len(replies)), "text": body.Text}}
+			}},
+		)
+
+		useMockClient(httpClient, defaultAPIHost)
+	})
+
+	postedReplies := func() []string {
+		mutex.Lock()
+		defer mutex.Unlock()
+		return replies
+	}
+
+	It("chains the tweets as replies", func() {
+		_, out, err := CreateThread(context.Background(), nil, CreateThreadInput{Tweets: []string{"one", "two", "three"}})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(out.TweetIDs).To(Equal([]string{"4000000000000000201", "4000000000000000202", "4000000000000000203"}))
+		Expect(out.Error).To(BeEmpty())
+		Expect(postedReplies()).To(Equal([]string{"", "4000000000000000201", "4000000000000000202"}))
+	})
+
+	It("continues under an existing tweet", func() {
+		_, out, err := CreateThread(context.Background(), nil, CreateThreadInput{Tweets: []string{"one", "two"}, ReplyToTweetID: "2000000000000000001"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(out.Count).To(Equal(2))
+		Expect(postedReplies()).To(Equal([]string{"2000000000000000001", "4000000000000000201"}))
+	})
+
+	It("returns the tweets posted before a failure", func() {
+		failAt = 3
+		_, out, err := CreateThread(context.Background(), nil, CreateThreadInput{Tweets: []string{"one", "two", "three", "four"}})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(out.TweetIDs).To(Equal([]string{"4000000000000000201", "4000000000000000202"}))
+		Expect(out.Count).To(Equal(2))
+		Expect(out.Error).To(ContainSubstring("tweet 3"))
+	})
+
+	It("fails when the first tweet cannot be posted", func() {
+		failAt = 1
+		_, _, err := CreateThread(context.Background(), nil, CreateThreadInput{Tweets: []string{"one", "two"}})
+		Expect(err).To(MatchError(ContainSubstring("tweet 1")))
+	})
+})
@@ -1,17 +1,27 @@
 package main
 
 import (
-	"os"
+	"net/http"
 	"testing"
 
+	twitter "github.com/g8rswimmer/go-twitter/v2"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 )
 
 func TestTwitter(t *testing.T) {
-	if os.Getenv("TWITTER_ACCEPTANCE") != "true" {
-		t.Skip("Twitter acceptance tests are disabled. Set TWITTER_ACCEPTANCE=true and Twitter env credentials to run.")
-	}
 	RegisterFailHandler(Fail)
-	RunSpecs(t, "Twitter acceptance")
+	RunSpecs(t, "Twitter Suite")
+}
+
+// useMockClient sends the requests of the tools to httpClient and host, as
+// the authenticated mock user, until the end of the spec. Use
+// newMockHTTPClient or mock.NewClient with defaultAPIHost, or
+// http.DefaultClient with the URL of a test server.
+func useMockClient(httpClient *http.Client, host string) {
+	DeferCleanup(func(c *twitter.Client, userCtx bool, userID string, max int) {
+		client, hasUserCtx, authUserID, maxTweets = c, userCtx, userID, max
+	}, client, hasUserCtx, authUserID, maxTweets)
+	client = &twitter.Client{Authorizer: noopAuthorizer{}, Client: httpClient, Host: host}
+	hasUserCtx, authUserID, maxTweets = true, mockUserID, defaultMaxTweets
 }
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/mudler/mcps/pkg/mock"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("get_unanswered_mentions", func() {
+	var (
+		mutex     sync.Mutex
+		startTime time.Time
+	)
+
+	BeforeEach(func() {
+		mentions := mockMentions
+		httpClient := mock.NewClient(
+			mock.Route{Method: http.MethodGet, Pattern: "/2/users/*/mentions", Handler: func(req *http.Request) (int, interface{}) {
+				mutex.Lock()
+				defer mutex.Unlock()
+				startTime, _ = time.Parse(time.RFC3339, req.URL.Query().Get("start_time"))
+				return http.StatusOK, mockTweetList(mentions)
+			}},
+			mock.Route{Method: http.MethodGet, Pattern: "/2/users/*/tweets", Handler: mock.JSON(http.StatusOK, mockTweetList(mockTweets))},
+		)
+
+		useMockClient(httpClient, defaultAPIHost)
+	})
+
+	requestedWindow := func() time.Duration {
+		mutex.Lock()
+		defer mutex.Unlock()
+		return time.Since(startTime)
+	}
+
+	It("looks back 24 hours by default", func() {
+		_, out, err := GetUnansweredMentions(context.Background(), nil, GetUnansweredMentionsInput{})
+		Expect(err).NotTo(HaveOccurred())
+		// The mention the mock user replied to is filtered out
+		Expect(out.Count).To(Equal(1))
+		Expect(out.Tweets[0].ID).To(Equal("3000000000000000002"))
+		Expect(requestedWindow()).To(BeNumerically("~", 24*time.Hour, time.Minute))
+	})
+
+	It("widens the window and limits the results", func() {
+		_, out, err := GetUnansweredMentions(context.Background(), nil, GetUnansweredMentionsInput{Hours: 72, MaxResults: 1})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(out.Count).To(Equal(1))
+		Expect(requestedWindow()).To(BeNumerically("~", 72*time.Hour, time.Minute))
+	})
+
+	It("rejects windows over a week", func() {
+		_, _, err := GetUnansweredMentions(context.Background(), nil, GetUnansweredMentionsInput{Hours: 169})
+		Expect(err).To(MatchError(ContainSubstring("hours must be between 1 and 168")))
+	})
+})
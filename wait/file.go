@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/mudler/mcps/pkg/safepath"
+)
+
+const (
+	defaultPollInterval = 1.0
+	minPollInterval     = 0.1
+	defaultFileTimeout  = 60.0
+)
+
+type WaitForFileInput struct {
+	Path     string  `json:"path" jsonschema:"the file or directory to watch"`
+	Absent   bool    `json:"absent,omitempty" jsonschema:"wait until the path no longer exists instead of until it exists"`
+	Interval float64 `json:"interval,omitempty" jsonschema:"seconds between checks (default 1, minimum 0.1)"`
+	Timeout  float64 `json:"timeout,omitempty" jsonschema:"maximum seconds to wait (default 60, maximum 3600)"`
+}
+
+type WaitForFileOutput struct {
+	Met     bool    `json:"met" jsonschema:"whether the condition was met before the timeout"`
+	Elapsed float64 `json:"elapsed" jsonschema:"seconds waited"`
+	Message string  `json:"message" jsonschema:"description of the outcome"`
+}
+
+// pathExists reports whether path exists. Errors other than not-existing,
+// such as permission problems, are returned so they are not mistaken for a
+// missing file.
+func pathExists(path string) (bool, error) {
+	_, err := os.Stat(path)
+	if err == nil {
+		return true, nil
+	}
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	return false, err
+}
+
+func WaitForFile(ctx context.Context, req *mcp.CallToolRequest, input WaitForFileInput) (
+	*mcp.CallToolResult,
+	WaitForFileOutput,
+	error,
+) {
+	// Validate input
+	if input.Path == "" {
+		return nil, WaitForFileOutput{}, fmt.Errorf("path is required")
+	}
+	path, err := safepath.Clean(input.Path)
+	if err != nil {
+		return nil, WaitForFileOutput{}, err
+	}
+	interval := input.Interval
+	if interval == 0 {
+		interval = defaultPollInterval
+	}
+	if interval < minPollInterval {
+		return nil, WaitForFileOutput{}, fmt.Errorf("interval must be at least %.1f seconds, got: %f", minPollInterval, input.Interval)
+	}
+	timeout := input.Timeout
+	if timeout == 0 {
+		timeout = defaultFileTimeout
+	}
+	if timeout < 0 {
+		return nil, WaitForFileOutput{}, fmt.Errorf("timeout must be positive, got: %f", input.Timeout)
+	}
+	if timeout > maxDuration {
+		return nil, WaitForFileOutput{}, fmt.Errorf("timeout exceeds maximum of %d seconds (1 hour), got: %f", maxDuration, input.Timeout)
+	}
+
+	// Describe the path when the condition is met and when it times out
+	met, unmet := "exists", "does not exist yet"
+	if input.Absent {
+		met, unmet = "does not exist", "still exists"
+	}
+
+	start := time.Now()
+	deadline := time.NewTimer(time.Duration(timeout * float64(time.Second)))
+	defer deadline.Stop()
+	ticker := time.NewTicker(time.Duration(interval * float64(time.Second)))
+	defer ticker.Stop()
+
+	for {
+		exists, err := pathExists(path)
+		if err != nil {
+			return nil, WaitForFileOutput{}, fmt.Errorf("failed to check %s: %w", input.Path, err)
+		}
+		if exists != input.Absent {
+			elapsed := time.Since(start).Seconds()
+			return nil, WaitForFileOutput{
+				Met:     true,
+				Elapsed: elapsed,
+				Message: fmt.Sprintf("%s %s after %.2f seconds", input.Path, met, elapsed),
+			}, nil
+		}
+
+		// Wait with context cancellation support
+		select {
+		case <-ctx.Done():
+			return nil, WaitForFileOutput{}, ctx.Err()
+		case <-deadline.C:
+			elapsed := time.Since(start).Seconds()
+			return nil, WaitForFileOutput{
+				Met:     false,
+				Elapsed: elapsed,
+				Message: fmt.Sprintf("Timed out after %.2f seconds: %s %s", elapsed, input.Path, unmet),
+			}, nil
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("wait_for_file", func() {
+	var path string
+
+	BeforeEach(func() {
+		path = filepath.Join(GinkgoT().TempDir(), "ready")
+	})
+
+	It("returns at once when the file exists", func() {
+		Expect(os.WriteFile(path, nil, 0644)).To(Succeed())
+		_, out, err := WaitForFile(context.Background(), nil, WaitForFileInput{Path: path})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(out.Met).To(BeTrue())
+		Expect(out.Message).To(ContainSubstring(path + " exists"))
+	})
+
+	It("waits for the file to appear", func() {
+		created := path
+		time.AfterFunc(200*time.Millisecond, func() {
+			defer GinkgoRecover()
+			Expect(os.WriteFile(created, nil, 0644)).To(Succeed())
+		})
+		_, out, err := WaitForFile(context.Background(), nil, WaitForFileInput{Path: path, Interval: 0.1, Timeout: 5})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(out.Met).To(BeTrue())
+		Expect(out.Elapsed).To(BeNumerically(">=", 0.2))
+	})
+
+	It("waits for the file to go away in absent mode", func() {
+		Expect(os.WriteFile(path, nil, 0644)).To(Succeed())
+		removed := path
+		time.AfterFunc(200*time.Millisecond, func() {
+			defer GinkgoRecover()
+			Expect(os.Remove(removed)).To(Succeed())
+		})
+		_, out, err := WaitForFile(context.Background(), nil, WaitForFileInput{Path: path, Absent: true, Interval: 0.1, Timeout: 5})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(out.Met).To(BeTrue())
+		Expect(out.Message).To(ContainSubstring(path + " does not exist"))
+	})
+
+	It("reports a timeout as a condition not met", func() {
+		_, out, err := WaitForFile(context.Background(), nil, WaitForFileInput{Path: path, Interval: 0.1, Timeout: 0.3})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(out.Met).To(BeFalse())
+		Expect(out.Elapsed).To(BeNumerically(">=", 0.3))
+		Expect(out.Message).To(ContainSubstring("Timed out"))
+		Expect(out.Message).To(ContainSubstring("does not exist yet"))
+	})
+
+	It("stops when the context is cancelled", func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(100*time.Millisecond, cancel)
+		_, _, err := WaitForFile(ctx, nil, WaitForFileInput{Path: path, Interval: 0.1, Timeout: 5})
+		Expect(err).To(MatchError(context.Canceled))
+	})
+
+	DescribeTable("rejects invalid input",
+		func(input WaitForFileInput, message string) {
+			_, _, err := WaitForFile(context.Background(), nil, input)
+			Expect(err).To(MatchError(ContainSubstring(message)))
+		},
+		Entry("no path", WaitForFileInput{}, "path is required"),
+		Entry("interval too short", WaitForFileInput{Path: "/tmp/x", Interval: 0.01}, "interval must be at least 0.1 seconds"),
+		Entry("negative timeout", WaitForFileInput{Path: "/tmp/x", Timeout: -1}, "timeout must be positive"),
+		Entry("timeout over an hour", WaitForFileInput{Path: "/tmp/x", Timeout: maxDuration + 1}, "timeout exceeds maximum"),
+	)
+})
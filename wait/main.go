@@ -4,13 +4,18 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"os"
+	"strconv"
 	"time"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/mudler/mcps/pkg/selfcheck"
+	"github.com/mudler/mcps/pkg/transport"
 )
 
 type Input struct {
-	Duration float64 `json:"duration" jsonschema:"duration to wait in seconds (supports fractional seconds like 0.5, 1.5)"`
+	Duration         float64 `json:"duration" jsonschema:"duration to wait in seconds (supports fractional seconds like 0.5, 1.5)"`
+	ProgressInterval float64 `json:"progress_interval,omitempty" jsonschema:"seconds between progress notifications with elapsed and remaining time, sent only when the client requested progress (default: WAIT_PROGRESS_INTERVAL or 5)"`
 }
 
 type Output struct {
@@ -19,6 +24,35 @@ type Output struct {
 
 const maxDuration = 3600 // 1 hour in seconds
 
+const (
+	defaultProgressInterval = 5.0
+	minProgressInterval     = 0.1
+)
+
+// progressInterval is the default number of seconds between progress
+// notifications, set with WAIT_PROGRESS_INTERVAL
+var progressInterval = defaultProgressInterval
+
+func init() {
+	if value := os.Getenv("WAIT_PROGRESS_INTERVAL"); value != "" {
+		interval, err := strconv.ParseFloat(value, 64)
+		if err != nil || interval < minProgressInterval {
+			log.Printf("Warning: invalid WAIT_PROGRESS_INTERVAL %q, using %.0f", value, defaultProgressInterval)
+			interval = defaultProgressInterval
+		}
+		progressInterval = interval
+	}
+}
+
+// progressToken returns the token the client attached to the call to ask for
+// progress notifications, or nil when it did not
+func progressToken(req *mcp.CallToolRequest) any {
+	if req == nil || req.Params == nil || req.Session == nil {
+		return nil
+	}
+	return req.Params.GetProgressToken()
+}
+
 func Wait(ctx context.Context, req *mcp.CallToolRequest, input Input) (
 	*mcp.CallToolResult,
 	Output,
@@ -32,24 +66,64 @@ func Wait(ctx context.Context, req *mcp.CallToolRequest, input Input) (
 		return nil, Output{}, fmt.Errorf("duration exceeds maximum of %d seconds (1 hour), got: %f", maxDuration, input.Duration)
 	}
 
+	interval := progressInterval
+	if input.ProgressInterval != 0 {
+		if input.ProgressInterval < minProgressInterval {
+			return nil, Output{}, fmt.Errorf("progress_interval must be at least %.1f seconds, got: %f", minProgressInterval, input.ProgressInterval)
+		}
+		interval = input.ProgressInterval
+	}
+
 	// Convert seconds to duration
 	duration := time.Duration(input.Duration * float64(time.Second))
+	timer := time.NewTimer(duration)
+	defer timer.Stop()
+
+	// Clients that did not ask for progress just block until the end
+	var ticks <-chan time.Time
+	token := progressToken(req)
+	if token != nil {
+		ticker := time.NewTicker(time.Duration(interval * float64(time.Second)))
+		defer ticker.Stop()
+		ticks = ticker.C
+	}
 
 	// Wait with context cancellation support
-	select {
-	case <-ctx.Done():
-		return nil, Output{}, ctx.Err()
-	case <-time.After(duration):
-		message := fmt.Sprintf("Waited for %.2f seconds", input.Duration)
-		return nil, Output{Message: message}, nil
+	start := time.Now()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, Output{}, ctx.Err()
+		case <-ticks:
+			elapsed := time.Since(start).Seconds()
+			if elapsed > input.Duration {
+				elapsed = input.Duration
+			}
+			err := req.Session.NotifyProgress(ctx, &mcp.ProgressNotificationParams{
+				ProgressToken: token,
+				Progress:      elapsed,
+				Total:         input.Duration,
+				Message:       fmt.Sprintf("Waited %.1f of %g seconds, %.1f remaining", elapsed, input.Duration, input.Duration-elapsed),
+			})
+			if err != nil {
+				log.Printf("Failed to send progress notification: %v", err)
+			}
+		case <-timer.C:
+			message := fmt.Sprintf("Waited for %.2f seconds", input.Duration)
+			return nil, Output{Message: message}, nil
+		}
 	}
 }
 
 func main() {
-	// Create a server with a single tool.
+	if selfcheck.Enabled() {
+		selfcheck.Exit("wait", selfcheck.Value("WAIT_PROGRESS_INTERVAL", fmt.Sprintf("%gs", progressInterval), nil))
+	}
+
 	server := mcp.NewServer(&mcp.Implementation{Name: "wait", Version: "v1.0.0"}, nil)
-	mcp.AddTool(server, &mcp.Tool{Name: "wait", Description: "Wait for a specified duration in seconds"}, Wait)
-	if err := server.Run(context.Background(), &mcp.StdioTransport{}); err != nil {
+	mcp.AddTool(server, &mcp.Tool{Name: "wait", Description: "Wait for a specified duration in seconds, reporting progress to clients that request it"}, Wait)
+	mcp.AddTool(server, &mcp.Tool{Name: "wait_for_file", Description: "Wait until a file or directory exists (or, with absent, no longer exists), polling up to a timeout"}, WaitForFile)
+	if err := transport.Run(context.Background(), server); err != nil {
 		log.Fatal(err)
 	}
 }
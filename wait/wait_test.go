@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"sync"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("wait", func() {
+	var (
+		session  *mcp.ClientSession
+		mutex    sync.Mutex
+		progress []*mcp.ProgressNotificationParams
+	)
+
+	BeforeEach(func() {
+		progress = nil
+		server := mcp.NewServer(&mcp.Implementation{Name: "wait", Version: "v1.0.0"}, nil)
+		mcp.AddTool(server, &mcp.Tool{Name: "wait", Description: "wait"}, Wait)
+
+		ctx := context.Background()
+		serverTransport, clientTransport := mcp.NewInMemoryTransports()
+		serverSession, err := server.Connect(ctx, serverTransport, nil)
+		Expect(err).NotTo(HaveOccurred())
+		DeferCleanup(serverSession.Close)
+
+		client := mcp.NewClient(&mcp.Implementation{Name: "client", Version: "v1.0.0"}, &mcp.ClientOptions{
+			ProgressNotificationHandler: func(_ context.Context, req *mcp.ProgressNotificationClientRequest) {
+				mutex.Lock()
+				defer mutex.Unlock()
+				progress = append(progress, req.Params)
+			},
+		})
+		session, err = client.Connect(ctx, clientTransport, nil)
+		Expect(err).NotTo(HaveOccurred())
+		DeferCleanup(session.Close)
+	})
+
+	notifications := func() []*mcp.ProgressNotificationParams {
+		mutex.Lock()
+		defer mutex.Unlock()
+		return append([]*mcp.ProgressNotificationParams(nil), progress...)
+	}
+
+	call := func(params *mcp.CallToolParams) *mcp.CallToolResult {
+		result, err := session.CallTool(context.Background(), params)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.IsError).To(BeFalse())
+		return result
+	}
+
+	It("reports progress to clients asking for it", func() {
+		params := &mcp.CallToolParams{Meta: mcp.Meta{}, Name: "wait", Arguments: map[string]any{"duration": 0.35, "progress_interval": 0.1}}
+		params.SetProgressToken("wait-1")
+		call(params)
+
+		Eventually(notifications).Should(HaveLen(3))
+		for _, notification := range notifications() {
+			Expect(notification.ProgressToken).To(Equal("wait-1"))
+			Expect(notification.Total).To(Equal(0.35))
+			Expect(notification.Progress).To(BeNumerically("<=", 0.35))
+			Expect(notification.Message).To(ContainSubstring("of 0.35 seconds"))
+		}
+	})
+
+	It("sends no notifications without a progress token", func() {
+		call(&mcp.CallToolParams{Name: "wait", Arguments: map[string]any{"duration": 0.35, "progress_interval": 0.1}})
+		Consistently(notifications, "200ms").Should(BeEmpty())
+	})
+
+	It("waits without a session", func() {
+		_, out, err := Wait(context.Background(), nil, Input{Duration: 0.1, ProgressInterval: 0.1})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(out.Message).To(Equal("Waited for 0.10 seconds"))
+	})
+
+	It("stops when the context is cancelled", func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, _, err := Wait(ctx, nil, Input{Duration: 5})
+		Expect(err).To(MatchError(context.Canceled))
+	})
+
+	DescribeTable("rejects invalid input",
+		func(input Input, message string) {
+			_, _, err := Wait(context.Background(), nil, input)
+			Expect(err).To(MatchError(ContainSubstring(message)))
+		},
+		Entry("no duration", Input{}, "duration must be positive"),
+		Entry("negative duration", Input{Duration: -1}, "duration must be positive"),
+		Entry("duration over an hour", Input{Duration: maxDuration + 1}, "duration exceeds maximum"),
+		Entry("progress interval too short", Input{Duration: 1, ProgressInterval: 0.01}, "progress_interval must be at least 0.1 seconds"),
+		Entry("negative progress interval", Input{Duration: 1, ProgressInterval: -1}, "progress_interval must be at least 0.1 seconds"),
+	)
+})
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Geocode", func() {
+	var requested *url.URL
+
+	// respond answers every request with status and body, recording the
+	// URL of the last one
+	respond := func(status int, body string) {
+		useServer(func(w http.ResponseWriter, r *http.Request) {
+			requested = r.URL
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(status)
+			w.Write([]byte(body))
+		})
+	}
+
+	BeforeEach(func() {
+		requested = nil
+	})
+
+	It("returns the candidate locations", func() {
+		results, err := json.Marshal(map[string]interface{}{"results": mockLocations})
+		Expect(err).NotTo(HaveOccurred())
+		respond(http.StatusOK, string(results))
+
+		_, out, err := Geocode(context.Background(), nil, GeocodeInput{Query: " Springfield "})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(out.Count).To(Equal(3))
+		Expect(out.Locations).To(Equal(mockLocations))
+		Expect(requested.Path).To(Equal("/v1/search"))
+		Expect(requested.Query().Get("name")).To(Equal("Springfield"))
+		Expect(requested.Query().Get("count")).To(Equal("5"))
+		Expect(requested.Query().Get("language")).To(Equal("en"))
+	})
+
+	It("returns no locations when nothing matches", func() {
+		respond(http.StatusOK, `{"generationtime_ms": 0.5}`)
+
+		_, out, err := Geocode(context.Background(), nil, GeocodeInput{Query: "Nowhere"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(out.Count).To(BeZero())
+		Expect(out.Locations).To(BeEmpty())
+		Expect(out.Locations).NotTo(BeNil())
+	})
+
+	DescribeTable("caps max_results",
+		func(maxResults int, count string) {
+			respond(http.StatusOK, `{}`)
+			_, _, err := Geocode(context.Background(), nil, GeocodeInput{Query: "Paris", MaxResults: maxResults})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(requested.Query().Get("count")).To(Equal(count))
+		},
+		Entry("defaulting when unset", 0, "5"),
+		Entry("defaulting when negative", -1, "5"),
+		Entry("keeping a value in range", 12, "12"),
+		Entry("capping at the maximum", 50, "20"),
+	)
+
+	It("reports the reason of a rejected request", func() {
+		respond(http.StatusBadRequest, `{"error": true, "reason": "Parameter count must be between 1 and 100"}`)
+
+		_, _, err := Geocode(context.Background(), nil, GeocodeInput{Query: "Paris"})
+		Expect(err).To(MatchError("weather API returned status code 400: Parameter count must be between 1 and 100"))
+	})
+
+	It("reports the status of a failed request without a reason", func() {
+		respond(http.StatusInternalServerError, `oops`)
+
+		_, _, err := Geocode(context.Background(), nil, GeocodeInput{Query: "Paris"})
+		Expect(err).To(MatchError("weather API returned status code: 500"))
+	})
+
+	It("requires a query", func() {
+		_, _, err := Geocode(context.Background(), nil, GeocodeInput{Query: "  "})
+		Expect(err).To(MatchError("query is required"))
+	})
+})
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+const (
+	archiveURL = "https://archive-api.open-meteo.com/v1/archive"
+
+	// maxHistoryDays caps the span of a historical lookup
+	maxHistoryDays = 92
+	dateLayout     = "2006-01-02"
+)
+
+type HistoricalWeatherInput struct {
+	City      string   `json:"city,omitempty" jsonschema:"the city to look up, resolved to its best geocoding match; pass latitude and longitude from geocode for ambiguous names"`
+	Latitude  *float64 `json:"latitude,omitempty" jsonschema:"latitude of a location resolved with geocode, used instead of city"`
+	Longitude *float64 `json:"longitude,omitempty" jsonschema:"longitude of a location resolved with geocode, used instead of city"`
+	StartDate string   `json:"start_date" jsonschema:"first day, YYYY-MM-DD"`
+	EndDate   string   `json:"end_date,omitempty" jsonschema:"last day, YYYY-MM-DD, before today (default: start_date); at most 92 days after start_date"`
+}
+
+// HistoricalDay is the weather summary of a single past day. Values the
+// archive does not have yet (the last few days) are left empty.
+type HistoricalDay struct {
+	Date           string `json:"date" jsonschema:"day, YYYY-MM-DD in the local time of the location"`
+	Description    string `json:"description,omitempty" jsonschema:"weather description"`
+	TemperatureMax string `json:"temperature_max,omitempty" jsonschema:"maximum temperature"`
+	TemperatureMin string `json:"temperature_min,omitempty" jsonschema:"minimum temperature"`
+	Precipitation  string `json:"precipitation,omitempty" jsonschema:"total precipitation"`
+	WindMax        string `json:"wind_max,omitempty" jsonschema:"maximum wind speed"`
+}
+
+type HistoricalWeatherOutput struct {
+	Location *Location       `json:"location,omitempty" jsonschema:"the geocoding match used when looking up by city"`
+	Days     []HistoricalDay `json:"days" jsonschema:"daily summaries, oldest first"`
+	Count    int             `json:"count" jsonschema:"number of days returned"`
+}
+
+type archiveResponse struct {
+	Daily struct {
+		Time           []string   `json:"time"`
+		WeatherCode    []*int     `json:"weather_code"`
+		TemperatureMax []*float64 `json:"temperature_2m_max"`
+		TemperatureMin []*float64 `json:"temperature_2m_min"`
+		Precipitation  []*float64 `json:"precipitation_sum"`
+		WindSpeedMax   []*float64 `json:"wind_speed_10m_max"`
+	} `json:"daily"`
+}
+
+// parseDateRange validates a historical date range relative to today
+func parseDateRange(startDate, endDate string, today time.Time) (time.Time, time.Time, error) {
+	start, err := time.Parse(dateLayout, strings.TrimSpace(startDate))
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid start_date %q: expected YYYY-MM-DD", startDate)
+	}
+	end := start
+	if strings.TrimSpace(endDate) != "" {
+		if end, err = time.Parse(dateLayout, strings.TrimSpace(endDate)); err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid end_date %q: expected YYYY-MM-DD", endDate)
+		}
+	}
+	if end.Before(start) {
+		return time.Time{}, time.Time{}, fmt.Errorf("end_date %s is before start_date %s", end.Format(dateLayout), start.Format(dateLayout))
+	}
+	today = time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, time.UTC)
+	if !end.Before(today) {
+		return time.Time{}, time.Time{}, fmt.Errorf("end_date %s is not in the past, use get_weather for current conditions", end.Format(dateLayout))
+	}
+	if days := int(end.Sub(start).Hours()/24) + 1; days > maxHistoryDays {
+		return time.Time{}, time.Time{}, fmt.Errorf("date range spans %d days, at most %d are allowed", days, maxHistoryDays)
+	}
+	return start, end, nil
+}
+
+// resolveCity returns the best geocoding match for a city name
+func resolveCity(ctx context.Context, city string) (*Location, error) {
+	_, out, err := Geocode(ctx, nil, GeocodeInput{Query: city, MaxResults: 1})
+	if err != nil {
+		return nil, err
+	}
+	if out.Count == 0 {
+		return nil, fmt.Errorf("no location found for %q", city)
+	}
+	return &out.Locations[0], nil
+}
+
+func GetHistoricalWeather(ctx context.Context, req *mcp.CallToolRequest, input HistoricalWeatherInput) (
+	*mcp.CallToolResult,
+	HistoricalWeatherOutput,
+	error,
+) {
+	start, end, err := parseDateRange(input.StartDate, input.EndDate, time.Now().UTC())
+	if err != nil {
+		return nil, HistoricalWeatherOutput{}, err
+	}
+
+	var location *Location
+	var latitude, longitude float64
+	switch {
+	case input.Latitude != nil || input.Longitude != nil:
+		if input.Latitude == nil || input.Longitude == nil {
+			return nil, HistoricalWeatherOutput{}, fmt.Errorf("latitude and longitude must be given together")
+		}
+		latitude, longitude = *input.Latitude, *input.Longitude
+	case strings.TrimSpace(input.City) != "":
+		if location, err = resolveCity(ctx, strings.TrimSpace(input.City)); err != nil {
+			return nil, HistoricalWeatherOutput{}, err
+		}
+		latitude, longitude = location.Latitude, location.Longitude
+	default:
+		return nil, HistoricalWeatherOutput{}, fmt.Errorf("city or latitude and longitude are required")
+	}
+	if latitude < -90 || latitude > 90 || longitude < -180 || longitude > 180 {
+		return nil, HistoricalWeatherOutput{}, fmt.Errorf("invalid coordinates %g,%g", latitude, longitude)
+	}
+
+	params := url.Values{}
+	params.Set("latitude", strconv.FormatFloat(latitude, 'f', -1, 64))
+	params.Set("longitude", strconv.FormatFloat(longitude, 'f', -1, 64))
+	params.Set("start_date", start.Format(dateLayout))
+	params.Set("end_date", end.Format(dateLayout))
+	params.Set("daily", "weather_code,temperature_2m_max,temperature_2m_min,precipitation_sum,wind_speed_10m_max")
+	params.Set("timezone", "auto")
+
+	var resp archiveResponse
+	if err := getJSON(ctx, archiveURL, params, &resp); err != nil {
+		return nil, HistoricalWeatherOutput{}, err
+	}
+
+	daily := resp.Daily
+	days := make([]HistoricalDay, 0, len(daily.Time))
+	for i, date := range daily.Time {
+		day := HistoricalDay{Date: date}
+		if i < len(daily.WeatherCode) && daily.WeatherCode[i] != nil {
+			day.Description = weatherDescription(*daily.WeatherCode[i])
+		}
+		if i < len(daily.TemperatureMax) && daily.TemperatureMax[i] != nil {
+			day.TemperatureMax = formatTemperature(*daily.TemperatureMax[i])
+		}
+		if i < len(daily.TemperatureMin) && daily.TemperatureMin[i] != nil {
+			day.TemperatureMin = formatTemperature(*daily.TemperatureMin[i])
+		}
+		if i < len(daily.Precipitation) && daily.Precipitation[i] != nil {
+			day.Precipitation = fmt.Sprintf("%.1f mm", *daily.Precipitation[i])
+		}
+		if i < len(daily.WindSpeedMax) && daily.WindSpeedMax[i] != nil {
+			day.WindMax = formatWind(*daily.WindSpeedMax[i])
+		}
+		days = append(days, day)
+	}
+
+	return nil, HistoricalWeatherOutput{Location: location, Days: days, Count: len(days)}, nil
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("parseDateRange", func() {
+	// today is late in the day, so only the date may count
+	today := time.Date(2025, 3, 15, 18, 30, 0, 0, time.UTC)
+
+	DescribeTable("accepts past ranges",
+		func(startDate, endDate, start, end string) {
+			gotStart, gotEnd, err := parseDateRange(startDate, endDate, today)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(gotStart.Format(dateLayout)).To(Equal(start))
+			Expect(gotEnd.Format(dateLayout)).To(Equal(end))
+		},
+		Entry("with both dates", "2025-01-01", "2025-01-31", "2025-01-01", "2025-01-31"),
+		Entry("defaulting end_date to start_date", "2025-02-10", "", "2025-02-10", "2025-02-10"),
+		Entry("trimming spaces", " 2025-02-10 ", " 2025-02-11 ", "2025-02-10", "2025-02-11"),
+		Entry("ending yesterday", "2025-03-14", "2025-03-14", "2025-03-14", "2025-03-14"),
+		Entry("spanning exactly 92 days", "2024-12-01", "2025-03-02", "2024-12-01", "2025-03-02"),
+	)
+
+	DescribeTable("rejects invalid ranges",
+		func(startDate, endDate, message string) {
+			_, _, err := parseDateRange(startDate, endDate, today)
+			Expect(err).To(MatchError(message))
+		},
+		Entry("with an invalid start_date", "15/03/2025", "", `invalid start_date "15/03/2025": expected YYYY-MM-DD`),
+		Entry("with an invalid end_date", "2025-01-01", "2025-02-30", `invalid end_date "2025-02-30": expected YYYY-MM-DD`),
+		Entry("with end_date before start_date", "2025-02-10", "2025-02-09", "end_date 2025-02-09 is before start_date 2025-02-10"),
+		Entry("with end_date today", "2025-03-01", "2025-03-15", "end_date 2025-03-15 is not in the past, use get_weather for current conditions"),
+		Entry("with a start_date today and no end_date", "2025-03-15", "", "end_date 2025-03-15 is not in the past, use get_weather for current conditions"),
+		Entry("with end_date in the future", "2025-03-01", "2025-04-01", "end_date 2025-04-01 is not in the past, use get_weather for current conditions"),
+		Entry("spanning more than 92 days", "2024-12-01", "2025-03-03", "date range spans 93 days, at most 92 are allowed"),
+	)
+})
@@ -8,13 +8,19 @@ import (
 	"log"
 	"net/http"
 	"net/url"
+	"strings"
 	"time"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/mudler/mcps/pkg/mock"
+	"github.com/mudler/mcps/pkg/selfcheck"
+	"github.com/mudler/mcps/pkg/transport"
 )
 
 type Input struct {
-	City string `json:"city" jsonschema:"the city to get the weather for"`
+	City      string   `json:"city,omitempty" jsonschema:"the city to get the weather for; ambiguous names are guessed, use geocode and pass latitude and longitude instead"`
+	Latitude  *float64 `json:"latitude,omitempty" jsonschema:"latitude of a location resolved with geocode, used instead of city"`
+	Longitude *float64 `json:"longitude,omitempty" jsonschema:"longitude of a location resolved with geocode, used instead of city"`
 }
 
 type Output struct {
@@ -37,22 +43,41 @@ type WeatherAPIResponse struct {
 	Forecast    []Forecast `json:"forecast"`
 }
 
+// httpClient is used for all weather API requests
+var httpClient = &http.Client{
+	Timeout: 10 * time.Second,
+}
+
 func GetWeather(ctx context.Context, req *mcp.CallToolRequest, input Input) (
 	*mcp.CallToolResult,
 	Output,
 	error,
 ) {
+	// Coordinates from geocode identify the place exactly
+	if input.Latitude != nil || input.Longitude != nil {
+		if input.Latitude == nil || input.Longitude == nil {
+			return nil, Output{}, fmt.Errorf("latitude and longitude must be given together")
+		}
+		output, err := getWeatherAt(ctx, *input.Latitude, *input.Longitude)
+		if err != nil {
+			return nil, Output{}, err
+		}
+		return nil, output, nil
+	}
+	if strings.TrimSpace(input.City) == "" {
+		return nil, Output{}, fmt.Errorf("city or latitude and longitude are required")
+	}
+
 	// URL encode the city name to handle special characters and spaces
 	encodedCity := url.QueryEscape(input.City)
 	weatherURL := fmt.Sprintf("http://goweather.xyz/weather/%s", encodedCity)
 
-	// Create HTTP client with timeout
-	client := &http.Client{
-		Timeout: 10 * time.Second,
+	// Make HTTP request, aborted if the client cancels the call
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, weatherURL, nil)
+	if err != nil {
+		return nil, Output{}, fmt.Errorf("failed to create request: %w", err)
 	}
-
-	// Make HTTP request
-	resp, err := client.Get(weatherURL)
+	resp, err := httpClient.Do(httpReq)
 	if err != nil {
 		return nil, Output{}, fmt.Errorf("failed to fetch weather data: %w", err)
 	}
@@ -86,11 +111,33 @@ func GetWeather(ctx context.Context, req *mcp.CallToolRequest, input Input) (
 	return nil, output, nil
 }
 
+// selfChecks probes the city lookup and the Open-Meteo APIs
+func selfChecks(mocked bool) []selfcheck.Check {
+	if mocked {
+		return []selfcheck.Check{selfcheck.Skipped("api", "WEATHER_MOCK enabled")}
+	}
+	return []selfcheck.Check{
+		selfcheck.HTTP("goweather", httpClient, "http://goweather.xyz/weather/London", nil),
+		selfcheck.HTTP("open-meteo", httpClient, geocodingURL+"?name=London&count=1", nil),
+	}
+}
+
 func main() {
-	// Create a server with a single tool.
+	mocked := mock.Enabled("WEATHER")
+	if mocked {
+		// Serve a canned forecast instead of calling the weather API
+		httpClient = newMockHTTPClient()
+		log.Println("WEATHER_MOCK enabled: using canned weather responses")
+	}
+	if selfcheck.Enabled() {
+		selfcheck.Exit("weather", selfChecks(mocked)...)
+	}
+
 	server := mcp.NewServer(&mcp.Implementation{Name: "weather", Version: "v1.0.0"}, nil)
-	mcp.AddTool(server, &mcp.Tool{Name: "get_weather", Description: "Get current weather and forecast for a city"}, GetWeather)
-	if err := server.Run(context.Background(), &mcp.StdioTransport{}); err != nil {
+	mcp.AddTool(server, &mcp.Tool{Name: "get_weather", Description: "Get current weather and forecast for a city, or for coordinates returned by geocode"}, GetWeather)
+	mcp.AddTool(server, &mcp.Tool{Name: "geocode", Description: "Find candidate locations for a place name, with country, region and coordinates, to disambiguate cities like Springfield"}, Geocode)
+	mcp.AddTool(server, &mcp.Tool{Name: "get_historical_weather", Description: "Get daily weather summaries for a city or coordinates over a past date range (up to 92 days)"}, GetHistoricalWeather)
+	if err := transport.Run(context.Background(), server); err != nil {
 		log.Fatal(err)
 	}
 }
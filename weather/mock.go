@@ -0,0 +1,79 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/mudler/mcps/pkg/mock"
+)
+
+// mockWeather is returned for every city when WEATHER_MOCK is enabled
+var mockWeather = WeatherAPIResponse{
+	Temperature: "+21 °C",
+	Wind:        "12 km/h",
+	Description: "Partly cloudy",
+	Forecast: []Forecast{
+		{Day: "1", Temperature: "+19 °C", Wind: "10 km/h"},
+		{Day: "2", Temperature: "+17 °C", Wind: "18 km/h"},
+		{Day: "3", Temperature: "+22 °C", Wind: "8 km/h"},
+	},
+}
+
+// mockLocations are the geocoding candidates returned for every query
+var mockLocations = []Location{
+	{Name: "Springfield", Admin1: "Illinois", Admin2: "Sangamon", Country: "United States", CountryCode: "US", Latitude: 39.80172, Longitude: -89.64371, Timezone: "America/Chicago", Population: 114394},
+	{Name: "Springfield", Admin1: "Missouri", Admin2: "Greene", Country: "United States", CountryCode: "US", Latitude: 37.21533, Longitude: -93.29824, Timezone: "America/Chicago", Population: 169176},
+	{Name: "Springfield", Admin1: "Massachusetts", Admin2: "Hampden", Country: "United States", CountryCode: "US", Latitude: 42.10148, Longitude: -72.58981, Timezone: "America/New_York", Population: 155929},
+}
+
+// mockForecast is the Open-Meteo forecast returned for any coordinates
+var mockForecast = map[string]interface{}{
+	"current": map[string]interface{}{"temperature_2m": 21.3, "wind_speed_10m": 12.1, "weather_code": 2},
+	"daily": map[string]interface{}{
+		"time":               []string{"2025-01-15", "2025-01-16", "2025-01-17", "2025-01-18"},
+		"temperature_2m_max": []float64{21.3, 19.0, 17.2, 22.4},
+		"wind_speed_10m_max": []float64{12.1, 10.0, 18.3, 8.0},
+	},
+}
+
+// mockArchive answers the Open-Meteo archive API with one made up summary
+// per requested day, cycling through a few weather patterns
+func mockArchive(req *http.Request) (int, interface{}) {
+	start, err := time.Parse(dateLayout, req.URL.Query().Get("start_date"))
+	if err != nil {
+		return http.StatusBadRequest, map[string]interface{}{"error": true, "reason": "Invalid start_date"}
+	}
+	end, err := time.Parse(dateLayout, req.URL.Query().Get("end_date"))
+	if err != nil {
+		return http.StatusBadRequest, map[string]interface{}{"error": true, "reason": "Invalid end_date"}
+	}
+	codes := []int{0, 2, 61, 3}
+	daily := map[string][]interface{}{}
+	for i, day := 0, start; !day.After(end); i, day = i+1, day.AddDate(0, 0, 1) {
+		daily["time"] = append(daily["time"], day.Format(dateLayout))
+		daily["weather_code"] = append(daily["weather_code"], codes[i%len(codes)])
+		daily["temperature_2m_max"] = append(daily["temperature_2m_max"], 18.0+float64(i%5))
+		daily["temperature_2m_min"] = append(daily["temperature_2m_min"], 9.0+float64(i%3))
+		daily["precipitation_sum"] = append(daily["precipitation_sum"], float64(i%4)*1.5)
+		daily["wind_speed_10m_max"] = append(daily["wind_speed_10m_max"], 10.0+float64(i%6))
+	}
+	return http.StatusOK, map[string]interface{}{"daily": daily}
+}
+
+// newMockHTTPClient returns an HTTP client answering the weather API with
+// mockWeather and the Open-Meteo APIs with the fixtures above
+func newMockHTTPClient() *http.Client {
+	return mock.NewClient(
+		mock.Route{Method: http.MethodGet, Pattern: "/weather/*", Handler: mock.JSON(http.StatusOK, mockWeather)},
+		mock.Route{Method: http.MethodGet, Pattern: "/v1/search", Handler: func(req *http.Request) (int, interface{}) {
+			count, err := strconv.Atoi(req.URL.Query().Get("count"))
+			if err != nil || count > len(mockLocations) {
+				count = len(mockLocations)
+			}
+			return http.StatusOK, map[string]interface{}{"results": mockLocations[:count]}
+		}},
+		mock.Route{Method: http.MethodGet, Pattern: "/v1/forecast", Handler: mock.JSON(http.StatusOK, mockForecast)},
+		mock.Route{Method: http.MethodGet, Pattern: "/v1/archive", Handler: mockArchive},
+	)
+}
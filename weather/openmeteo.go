@@ -0,0 +1,217 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+const (
+	geocodingURL = "https://geocoding-api.open-meteo.com/v1/search"
+	forecastURL  = "https://api.open-meteo.com/v1/forecast"
+
+	defaultGeocodeResults = 5
+	maxGeocodeResults     = 20
+	forecastDays          = 3
+)
+
+type GeocodeInput struct {
+	Query      string `json:"query" jsonschema:"the place name to look up, e.g. Springfield"`
+	MaxResults int    `json:"max_results,omitempty" jsonschema:"maximum number of candidate locations (default 5, max 20)"`
+}
+
+// Location is a geocoding candidate. Its latitude and longitude can be passed
+// to get_weather to get the weather for exactly this place.
+type Location struct {
+	Name        string  `json:"name" jsonschema:"place name"`
+	Admin1      string  `json:"admin1,omitempty" jsonschema:"first-level administrative region, e.g. state or province"`
+	Admin2      string  `json:"admin2,omitempty" jsonschema:"second-level administrative region, e.g. county"`
+	Country     string  `json:"country,omitempty" jsonschema:"country name"`
+	CountryCode string  `json:"country_code,omitempty" jsonschema:"ISO 3166-1 alpha-2 country code"`
+	Latitude    float64 `json:"latitude" jsonschema:"latitude in degrees"`
+	Longitude   float64 `json:"longitude" jsonschema:"longitude in degrees"`
+	Timezone    string  `json:"timezone,omitempty" jsonschema:"IANA timezone of the place"`
+	Population  int     `json:"population,omitempty" jsonschema:"population, when known"`
+}
+
+type GeocodeOutput struct {
+	Locations []Location `json:"locations" jsonschema:"candidate locations, most relevant first"`
+	Count     int        `json:"count" jsonschema:"number of candidates returned"`
+}
+
+// getJSON fetches an Open-Meteo endpoint and decodes its JSON body into out
+func getJSON(ctx context.Context, endpoint string, params url.Values, out interface{}) error {
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint+"?"+params.Encode(), nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	resp, err := httpClient.Do(httpReq)
+	if err != nil {
+		return fmt.Errorf("failed to fetch weather data: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response body: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		// Open-Meteo explains rejected parameters in {"error": true, "reason": "..."}
+		var apiErr struct {
+			Reason string `json:"reason"`
+		}
+		if json.Unmarshal(body, &apiErr) == nil && apiErr.Reason != "" {
+			return fmt.Errorf("weather API returned status code %d: %s", resp.StatusCode, apiErr.Reason)
+		}
+		return fmt.Errorf("weather API returned status code: %d", resp.StatusCode)
+	}
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("failed to parse weather data: %w", err)
+	}
+	return nil
+}
+
+func Geocode(ctx context.Context, req *mcp.CallToolRequest, input GeocodeInput) (
+	*mcp.CallToolResult,
+	GeocodeOutput,
+	error,
+) {
+	query := strings.TrimSpace(input.Query)
+	if query == "" {
+		return nil, GeocodeOutput{}, fmt.Errorf("query is required")
+	}
+	count := input.MaxResults
+	if count <= 0 {
+		count = defaultGeocodeResults
+	}
+	if count > maxGeocodeResults {
+		count = maxGeocodeResults
+	}
+
+	params := url.Values{}
+	params.Set("name", query)
+	params.Set("count", strconv.Itoa(count))
+	params.Set("language", "en")
+	params.Set("format", "json")
+
+	var resp struct {
+		Results []Location `json:"results"`
+	}
+	if err := getJSON(ctx, geocodingURL, params, &resp); err != nil {
+		return nil, GeocodeOutput{}, err
+	}
+
+	locations := resp.Results
+	if locations == nil {
+		locations = []Location{}
+	}
+	return nil, GeocodeOutput{Locations: locations, Count: len(locations)}, nil
+}
+
+// forecastResponse is the subset of the Open-Meteo forecast API used by
+// get_weather
+type forecastResponse struct {
+	Current struct {
+		Temperature float64 `json:"temperature_2m"`
+		WindSpeed   float64 `json:"wind_speed_10m"`
+		WeatherCode int     `json:"weather_code"`
+	} `json:"current"`
+	Daily struct {
+		Time           []string  `json:"time"`
+		TemperatureMax []float64 `json:"temperature_2m_max"`
+		WindSpeedMax   []float64 `json:"wind_speed_10m_max"`
+	} `json:"daily"`
+}
+
+// getWeatherAt returns the current weather and forecast for coordinates from
+// Open-Meteo, in the same format as the city lookup
+func getWeatherAt(ctx context.Context, latitude, longitude float64) (Output, error) {
+	if latitude < -90 || latitude > 90 || longitude < -180 || longitude > 180 {
+		return Output{}, fmt.Errorf("invalid coordinates %g,%g", latitude, longitude)
+	}
+
+	params := url.Values{}
+	params.Set("latitude", strconv.FormatFloat(latitude, 'f', -1, 64))
+	params.Set("longitude", strconv.FormatFloat(longitude, 'f', -1, 64))
+	params.Set("current", "temperature_2m,wind_speed_10m,weather_code")
+	params.Set("daily", "temperature_2m_max,wind_speed_10m_max")
+	params.Set("forecast_days", strconv.Itoa(forecastDays+1))
+	params.Set("timezone", "auto")
+
+	var resp forecastResponse
+	if err := getJSON(ctx, forecastURL, params, &resp); err != nil {
+		return Output{}, err
+	}
+
+	output := Output{
+		Temperature: formatTemperature(resp.Current.Temperature),
+		Wind:        formatWind(resp.Current.WindSpeed),
+		Description: weatherDescription(resp.Current.WeatherCode),
+		Forecast:    []Forecast{},
+	}
+	// The first daily entry is today, the forecast starts tomorrow like the
+	// city lookup
+	for i := 1; i < len(resp.Daily.Time) && i <= forecastDays; i++ {
+		forecast := Forecast{Day: strconv.Itoa(i)}
+		if i < len(resp.Daily.TemperatureMax) {
+			forecast.Temperature = formatTemperature(resp.Daily.TemperatureMax[i])
+		}
+		if i < len(resp.Daily.WindSpeedMax) {
+			forecast.Wind = formatWind(resp.Daily.WindSpeedMax[i])
+		}
+		output.Forecast = append(output.Forecast, forecast)
+	}
+	return output, nil
+}
+
+func formatTemperature(celsius float64) string {
+	return fmt.Sprintf("%+.0f °C", celsius)
+}
+
+func formatWind(kmh float64) string {
+	return fmt.Sprintf("%.0f km/h", kmh)
+}
+
+// weatherDescription translates a WMO weather interpretation code
+func weatherDescription(code int) string {
+	switch code {
+	case 0:
+		return "Clear sky"
+	case 1:
+		return "Mainly clear"
+	case 2:
+		return "Partly cloudy"
+	case 3:
+		return "Overcast"
+	case 45, 48:
+		return "Fog"
+	case 51, 53, 55:
+		return "Drizzle"
+	case 56, 57:
+		return "Freezing drizzle"
+	case 61, 63, 65:
+		return "Rain"
+	case 66, 67:
+		return "Freezing rain"
+	case 71, 73, 75:
+		return "Snow"
+	case 77:
+		return "Snow grains"
+	case 80, 81, 82:
+		return "Rain showers"
+	case 85, 86:
+		return "Snow showers"
+	case 95:
+		return "Thunderstorm"
+	case 96, 99:
+		return "Thunderstorm with hail"
+	}
+	return fmt.Sprintf("Unknown (WMO code %d)", code)
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestWeather(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Weather Suite")
+}
+
+// serverTransport sends every request to a test server, keeping its path
+// and query, so the Open-Meteo URLs need not change
+type serverTransport struct {
+	server *url.URL
+}
+
+func (t serverTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	r = r.Clone(r.Context())
+	r.URL.Scheme, r.URL.Host = t.server.Scheme, t.server.Host
+	return http.DefaultTransport.RoundTrip(r)
+}
+
+// useServer starts a test server with handler and sends the requests of the
+// tools to it until the end of the spec
+func useServer(handler http.HandlerFunc) {
+	server := httptest.NewServer(handler)
+	serverURL, err := url.Parse(server.URL)
+	Expect(err).NotTo(HaveOccurred())
+	DeferCleanup(func(c *http.Client) {
+		httpClient = c
+		server.Close()
+	}, httpClient)
+	httpClient = &http.Client{Transport: serverTransport{server: serverURL}}
+}